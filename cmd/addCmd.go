@@ -15,24 +15,40 @@ var AddCmd = &cobra.Command{
 	Short: "Add design files to the staging area",
 	Long: `Add design files to the staging area for the next commit.
 
+Multi-file units (InDesign packages, Sketch workspaces, font families) are
+treated as one logical group: staging only some members prints a warning
+listing the unstaged siblings, and --group stages the whole unit.
+
+Application autosave and crash-recovery files (~*.tmp, *recovered*.tmp,
+*recovered*.psb, PSAutoRecover/ and DataRecovery/ folders) are skipped unless
+--include-autosave is given.
+
 Examples:
-  dgit add logo.ai     # Add specific file
-  dgit add .           # Add all design files
-  dgit add *.psd       # Add all PSD files`,
+  dgit add logo.ai                     # Add specific file
+  dgit add .                           # Add all design files
+  dgit add *.psd                       # Add all PSD files
+  dgit add --group Brochure/           # Add an InDesign package with links and fonts
+  dgit add --group fonts/Inter-Bold.otf # Add the whole Inter font family`,
 	Args: cobra.MinimumNArgs(1),
 	Run:  runAdd,
 }
 
+func init() {
+	AddCmd.Flags().BoolP("group", "g", false, "Stage every member of the package or font family a file belongs to")
+	AddCmd.Flags().Bool("include-autosave", false, "Also stage app autosave and crash-recovery files")
+}
+
 // runAdd stages files for the next commit
 func runAdd(cmd *cobra.Command, args []string) {
-	if !isInDgitRepository() {
-		printError("not a dgit repository (or any of the parent directories)")
-		printSuggestion("Run 'dgit init' to initialize a repository")
-		os.Exit(1)
-	}
-
-	dgitDir := findDgitDirectory()
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	checkNoBatch(dgitDir)
+	lock := lockRepository(dgitDir, "add")
+	defer lock.Release()
 	stagingArea := staging.NewStagingArea(dgitDir)
+	stagingArea.WholeGroups, _ = cmd.Flags().GetBool("group")
+	stagingArea.IncludeAutosave, _ = cmd.Flags().GetBool("include-autosave")
+	stagingArea.Progress = ciProgress()
 
 	if err := stagingArea.LoadStaging(); err != nil {
 		printError(fmt.Sprintf("loading staging area: %v", err))
@@ -41,6 +57,7 @@ func runAdd(cmd *cobra.Command, args []string) {
 
 	var allAddedFiles []string
 	var allFailedFiles = make(map[string]error)
+	var skippedAutosave []string
 
 	for _, arg := range args {
 		result, err := stagingArea.AddPattern(arg)
@@ -50,6 +67,7 @@ func runAdd(cmd *cobra.Command, args []string) {
 		}
 
 		allAddedFiles = append(allAddedFiles, result.AddedFiles...)
+		skippedAutosave = append(skippedAutosave, result.SkippedAutosave...)
 
 		for file, fileErr := range result.FailedFiles {
 			printWarning(fmt.Sprintf("failed to add %s: %v", file, fileErr))
@@ -62,6 +80,11 @@ func runAdd(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if ciMode {
+		printAddResult(stagingArea, allAddedFiles, allFailedFiles, skippedAutosave)
+		return
+	}
+
 	if len(allAddedFiles) > 0 {
 		printSuccess(fmt.Sprintf("Added %d file(s) to staging area:", len(allAddedFiles)))
 		for _, file := range allAddedFiles {
@@ -72,6 +95,61 @@ func runAdd(cmd *cobra.Command, args []string) {
 	} else {
 		fmt.Println("No files were added to staging area.")
 	}
+
+	if len(skippedAutosave) > 0 {
+		fmt.Printf("\nSkipped %d autosave/recovery file(s):\n", len(skippedAutosave))
+		for _, file := range skippedAutosave {
+			fmt.Printf("  ~ %s\n", file)
+		}
+		printSuggestion("Use 'dgit add --include-autosave <file>' to stage one anyway")
+	}
+
+	printIncompleteGroups(stagingArea)
+}
+
+// printAddResult prints what was staged as JSON for CI mode
+func printAddResult(stagingArea *staging.StagingArea, added []string, failed map[string]error, skippedAutosave []string) {
+	failures := make(map[string]string, len(failed))
+	for file, err := range failed {
+		failures[file] = err.Error()
+	}
+	incomplete := []map[string]interface{}{}
+	for _, item := range stagingArea.IncompleteGroups() {
+		incomplete = append(incomplete, map[string]interface{}{
+			"kind": item.Group.Kind, "name": item.Group.Name, "unstaged": item.Unstaged,
+		})
+	}
+	if added == nil {
+		added = []string{}
+	}
+	if skippedAutosave == nil {
+		skippedAutosave = []string{}
+	}
+	ciResult(map[string]interface{}{
+		"added":             added,
+		"failed":            failures,
+		"skipped_autosave":  skippedAutosave,
+		"incomplete_groups": incomplete,
+		"staged":            stagingArea.GetFileCount(),
+	})
+}
+
+// printIncompleteGroups warns about packages that would be committed half-versioned
+func printIncompleteGroups(stagingArea *staging.StagingArea) {
+	incomplete := stagingArea.IncompleteGroups()
+	if len(incomplete) == 0 {
+		return
+	}
+
+	fmt.Println()
+	for _, item := range incomplete {
+		printWarning(fmt.Sprintf("%s '%s' is partially staged; %d member(s) not staged:",
+			item.Group.Kind, item.Group.Name, len(item.Unstaged)))
+		for _, path := range item.Unstaged {
+			fmt.Printf("  - %s\n", path)
+		}
+	}
+	printSuggestion("Use 'dgit add --group <file>' to stage the whole unit")
 }
 
 // printStagingStatus displays staged files with metadata
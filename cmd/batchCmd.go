@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"dgit/internal/batch"
+
+	"github.com/spf13/cobra"
+)
+
+// BatchCmd runs a script of operations as one transaction
+var BatchCmd = &cobra.Command{
+	Use:   "batch <script> | --recover",
+	Short: "Run a JSON or YAML script of operations as one transaction",
+	Long: `Run a script of operations in order, under one repository lock, as a single
+transaction: if any step fails, everything the earlier steps did is rolled back
+(staged files, commits, tags, archived snapshots and materialized files) and the
+repository is left as it was. One report covers the whole run.
+
+Operations and their fields:
+  add          patterns (required), group, include_autosave
+  commit       message (required), profile, ignore_policies
+  tag          name (required), version, message, force
+  archive      versions (required): LZ4 snapshots move to the Zstd archive tier
+  materialize  pinfile, or version and files; dest (default: the project)
+
+A version is a number ("17", "v17"), a tag, a commit hash prefix, or "latest"
+for the newest commit, including ones the script made; tag and materialize use
+the newest commit when no version is given. Paths are relative to the current
+directory, as on the command line. Use "-" to read the script from stdin
+(JSON, or YAML when it does not start with '{' or '[').
+
+Example script (delivery.yaml):
+  description: Delivery to client
+  operations:
+    - op: add
+      patterns: ["exports/*.psd", logo.ai]
+    - op: commit
+      message: Final delivery
+    - op: tag
+      name: delivery-2026-10
+    - op: materialize
+      dest: ../handoff
+    - op: archive
+      versions: [1, 2, 3]
+
+A batch interrupted by a crash is rolled back by 'dgit batch --recover' or by
+the next dgit command that changes the repository (add, commit, restore, tag,
+...). Read-only commands such as log and status only warn about it.
+
+Examples:
+  dgit batch delivery.yaml            # Run the script
+  dgit batch --check delivery.yaml    # Validate without running
+  dgit batch - < steps.json --json    # From stdin, JSON report
+  dgit batch --recover                # Roll back an interrupted batch`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if recovering, _ := cmd.Flags().GetBool("recover"); recovering {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: runBatch,
+}
+
+func init() {
+	BatchCmd.Flags().Bool("check", false, "Validate the script without running it")
+	BatchCmd.Flags().Bool("recover", false, "Roll back a batch that was interrupted, then exit")
+	BatchCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// runBatch parses a script and runs it as one transaction
+func runBatch(cmd *cobra.Command, args []string) {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	checkOnly, _ := cmd.Flags().GetBool("check")
+	if recovering, _ := cmd.Flags().GetBool("recover"); recovering {
+		runBatchRecover(asJSON)
+		return
+	}
+	var dgitDir string
+	if checkOnly {
+		dgitDir = checkDgitRepository()
+	} else {
+		dgitDir = checkWritableRepository()
+	}
+
+	name := args[0]
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+		name = "stdin"
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		printError(fmt.Sprintf("reading script: %v", err))
+		exit(ExitNotFound)
+	}
+	script, err := batch.Parse(data, name)
+	if err != nil {
+		exitWithCode(ExitUsage, err.Error(), "See 'dgit batch --help' for the script format")
+	}
+
+	if checkOnly {
+		if asJSON || ciMode {
+			ciResult(map[string]interface{}{"valid": true, "operations": len(script.Operations)})
+			return
+		}
+		printSuccess(fmt.Sprintf("%s is valid: %d operation(s)", name, len(script.Operations)))
+		return
+	}
+
+	checkNotMirror(dgitDir)
+	manager := batch.NewBatchManager(dgitDir)
+	manager.Progress = ciProgress()
+	quiet := asJSON || ciMode
+	if !quiet {
+		if script.Description != "" {
+			fmt.Println(script.Description)
+		}
+		fmt.Printf("Running %d operation(s) from %s\n\n", len(script.Operations), name)
+	}
+
+	report, err := manager.Run(script, name, func(step *batch.StepResult) {
+		if quiet {
+			return
+		}
+		label := fmt.Sprintf("[%d/%d] %-11s", step.Step, len(script.Operations), step.Op)
+		if step.Status == batch.StatusFailed {
+			fmt.Printf("  %s %s %s\n", red("✗"), label, step.Error)
+			return
+		}
+		fmt.Printf("  %s %s %s\n", green("✓"), label, step.Summary)
+	})
+	if report == nil {
+		code := exitCode(err)
+		if errors.Is(err, batch.ErrLocked) || errors.Is(err, batch.ErrQueueBusy) {
+			code = ExitConflict
+		}
+		printError(err.Error())
+		exit(code)
+	}
+
+	if quiet {
+		ciResult(report)
+	} else {
+		printBatchReport(report)
+	}
+	if err != nil {
+		exit(exitCode(err))
+	}
+}
+
+// runBatchRecover rolls back an interrupted batch, reporting when there was none
+func runBatchRecover(asJSON bool) {
+	dgitDir := locateRepository()
+	journal, err := batch.RecoverInterrupted(dgitDir)
+	if err != nil {
+		printError(fmt.Sprintf("rolling back the interrupted batch: %v", err))
+		exit(ExitError)
+	}
+	if asJSON || ciMode {
+		result := map[string]interface{}{"rolled_back": journal != nil}
+		if journal != nil {
+			result["script"] = journal.Script
+			result["started_at"] = journal.StartedAt
+		}
+		ciResult(result)
+		return
+	}
+	if journal == nil {
+		if batch.Running(dgitDir) {
+			printInfo("A batch is running; nothing to recover.")
+			return
+		}
+		printSuccess("No interrupted batch to roll back")
+		return
+	}
+	printSuccess(fmt.Sprintf("Rolled back batch '%s' (started %s)", journal.Script,
+		journal.StartedAt.Local().Format("2006-01-02 15:04")))
+}
+
+// printBatchReport prints the outcome of a batch run
+func printBatchReport(report *batch.Report) {
+	fmt.Println()
+	if !report.RolledBack {
+		summary := fmt.Sprintf("Batch complete: %d operation(s) in %.1fs", len(report.Steps), float64(report.DurationMs)/1000)
+		if len(report.Committed) > 0 {
+			summary += fmt.Sprintf(", committed %s", formatVersionList(report.Committed))
+		}
+		printSuccess(summary)
+		return
+	}
+
+	rolledBack, skipped := 0, 0
+	for _, step := range report.Steps {
+		switch step.Status {
+		case batch.StatusRolledBack:
+			rolledBack++
+		case batch.StatusSkipped:
+			skipped++
+		}
+	}
+	if report.RollbackError != "" {
+		printError(fmt.Sprintf("Batch failed and %s", report.RollbackError))
+		printSuggestion("Fix the problem; the next dgit command retries the rollback")
+		return
+	}
+	printError(fmt.Sprintf("Batch failed; rolled back %d completed operation(s), skipped %d", rolledBack, skipped))
+	printInfo("The repository is unchanged.")
+}
+
+// reportInterruptedBatch warns about a batch that was cut short without undoing it
+func reportInterruptedBatch(dgitDir string) {
+	journal, err := batch.Interrupted(dgitDir)
+	if err != nil {
+		printWarning(fmt.Sprintf("an interrupted batch could not be read: %v", err))
+		return
+	}
+	if journal != nil {
+		printWarning(fmt.Sprintf("batch '%s' (started %s) did not finish; its partial changes are still in place",
+			journal.Script, journal.StartedAt.Local().Format("2006-01-02 15:04")))
+		printSuggestion("Run 'dgit batch --recover' to roll it back; the next command that changes the repository also does")
+	}
+}
+
+// recoverBatch rolls back a batch that was cut short, telling the user
+func recoverBatch(dgitDir string) {
+	journal, err := batch.RecoverInterrupted(dgitDir)
+	if err != nil {
+		printWarning(fmt.Sprintf("an interrupted batch could not be rolled back: %v", err))
+		return
+	}
+	if journal != nil {
+		printWarning(fmt.Sprintf("rolled back batch '%s' (started %s), which did not finish",
+			journal.Script, journal.StartedAt.Local().Format("2006-01-02 15:04")))
+	}
+}
+
+// checkNoBatch refuses to change staging or history while a batch runs, rather than
+// waiting on the repository lock for the whole batch
+func checkNoBatch(dgitDir string) {
+	if batch.Running(dgitDir) {
+		exitWithCode(ExitConflict, "a batch is running in this repository", "Wait for 'dgit batch' to finish")
+	}
+}
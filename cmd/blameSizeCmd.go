@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"dgit/internal/log"
+
+	"github.com/spf13/cobra"
+)
+
+// BlameSizeCmd reports which files and versions account for repository size
+var BlameSizeCmd = &cobra.Command{
+	Use:   "blame-size",
+	Short: "Show which files contribute most to repository size",
+	Long: `Attribute stored snapshot and delta sizes to file paths across history.
+
+Each commit's stored object is split between its files in proportion to their
+original sizes, then summed per path over every version.
+
+Examples:
+  dgit blame-size              # Top 20 paths by stored size
+  dgit blame-size -n 5         # Top 5 paths
+  dgit blame-size --versions   # Also list the heaviest version per path
+  dgit blame-size --json       # Machine-readable output`,
+	Args: cobra.NoArgs,
+	Run:  runBlameSize,
+}
+
+func init() {
+	BlameSizeCmd.Flags().IntP("number", "n", 20, "Limit the number of paths to show (0 for all)")
+	BlameSizeCmd.Flags().Bool("versions", false, "Show the heaviest version for each path")
+	BlameSizeCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// runBlameSize prints the storage attribution report
+func runBlameSize(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	logManager := log.NewLogManager(dgitDir)
+
+	attribution, err := logManager.GetStorageAttribution()
+	if err != nil {
+		printError(fmt.Sprintf("computing storage attribution: %v", err))
+		os.Exit(1)
+	}
+
+	number, _ := cmd.Flags().GetInt("number")
+	showVersions, _ := cmd.Flags().GetBool("versions")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	paths := attribution.Paths
+	if number > 0 && number < len(paths) {
+		paths = paths[:number]
+	}
+
+	if jsonOutput {
+		result := map[string]interface{}{
+			"total_stored":  attribution.TotalStored,
+			"total_commits": attribution.TotalCommits,
+			"paths":         paths,
+		}
+		if jsonData, err := json.Marshal(result); err == nil {
+			fmt.Println(string(jsonData))
+		}
+		return
+	}
+
+	if len(attribution.Paths) == 0 {
+		fmt.Println("No commits yet.")
+		return
+	}
+
+	fmt.Printf("Storage attribution (%d commits, %s stored)\n\n",
+		attribution.TotalCommits, formatBytes(attribution.TotalStored))
+	fmt.Printf("  %-10s %6s  %-8s  %s\n", "STORED", "SHARE", "VERSIONS", "PATH")
+
+	for _, entry := range paths {
+		share := 0.0
+		if attribution.TotalStored > 0 {
+			share = float64(entry.StoredBytes) / float64(attribution.TotalStored) * 100
+		}
+		fmt.Printf("  %-10s %5.1f%%  %-8d  %s\n",
+			formatBytes(entry.StoredBytes), share, len(entry.Versions), entry.Path)
+
+		if showVersions && entry.LargestVersion > 0 {
+			fmt.Printf("  %-10s %6s  %-8s  └ heaviest: v%d (%s)\n",
+				"", "", "", entry.LargestVersion, formatBytes(entry.LargestBytes))
+		}
+	}
+
+	if len(paths) < len(attribution.Paths) {
+		fmt.Printf("\n... and %d more paths (use -n 0 to show all)\n", len(attribution.Paths)-len(paths))
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"dgit/internal/pin"
+	"dgit/internal/policy"
+	"dgit/internal/progress"
+	"dgit/internal/restore"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// CI mode makes dgit safe to drive from automation: prompts are declined instead of
+// waiting on stdin, colors and emoji are dropped, progress is reported as JSON lines on
+// stderr, commands print JSON results, and failures exit with distinct codes.
+
+// EnvCI enables CI mode when set to a true value (1, true)
+const EnvCI = "DGIT_CI"
+
+// Exit codes returned in CI mode; outside CI mode every failure exits with ExitError
+const (
+	ExitOK            = 0
+	ExitError         = 1 // Any failure without a more specific code
+	ExitUsage         = 2 // Invalid command line
+	ExitNotRepository = 3 // Not inside a dgit repository, or its storage is unavailable
+	ExitConflict      = 4 // Local changes would be overwritten
+	ExitVerifyFailed  = 5 // Content does not match its recorded hash
+	ExitPolicy        = 6 // Blocking asset policy violations
+	ExitNotFound      = 7 // Version, commit or file does not exist
+)
+
+// ExitCodesHelp documents the exit codes for command help
+const ExitCodesHelp = `Exit codes in CI mode:
+  0  success                   4  local changes would be overwritten
+  1  other failure             5  content does not match its recorded hash
+  2  invalid command line      6  blocked by an asset policy
+  3  not a dgit repository     7  version, commit or file not found`
+
+var ciMode bool
+
+// CIMode reports whether CI mode is active
+func CIMode() bool {
+	return ciMode
+}
+
+// SetupCI enables CI mode from the --ci flag or DGIT_CI before a command runs
+func SetupCI(cmd *cobra.Command, flag bool) {
+	env, _ := strconv.ParseBool(os.Getenv(EnvCI))
+	ciMode = flag || env
+	if !ciMode {
+		return
+	}
+
+	color.NoColor = true
+	// Prefer machine-readable output wherever a command offers it
+	if jsonFlag := cmd.Flags().Lookup("json"); jsonFlag != nil && !jsonFlag.Changed {
+		jsonFlag.Value.Set("true")
+	}
+}
+
+// CIRequested reports whether CI mode was asked for on the command line or environment
+// It is available before flags are parsed, when cobra's own messages are configured
+func CIRequested() bool {
+	requested, _ := strconv.ParseBool(os.Getenv(EnvCI))
+	for _, arg := range os.Args[1:] {
+		if arg == "--ci" || arg == "--ci=true" {
+			requested = true
+		}
+	}
+	return requested
+}
+
+// FailUsage reports a command line error returned by cobra and exits
+func FailUsage(err error) {
+	if ciMode || CIRequested() {
+		ciMode = true
+		ciMessage("error", err.Error())
+		os.Exit(ExitUsage)
+	}
+	fmt.Printf("❌ Error: %v\n", err)
+	os.Exit(ExitError)
+}
+
+// exit terminates with code in CI mode and with ExitError otherwise
+func exit(code int) {
+	if !ciMode && code != ExitOK {
+		code = ExitError
+	}
+	os.Exit(code)
+}
+
+// exitCode picks the CI exit code for an error returned by a manager
+func exitCode(err error) int {
+	var overwrite *restore.OverwriteError
+	var interrupted *restore.InterruptedError
+	var violation *policy.PolicyError
+	var mismatch *pin.MismatchError
+	switch {
+	case errors.As(err, &overwrite), errors.As(err, &interrupted):
+		return ExitConflict
+	case errors.Is(err, restore.ErrNoCheckout):
+		return ExitNotFound
+	case errors.As(err, &violation):
+		return ExitPolicy
+	case errors.As(err, &mismatch):
+		return ExitVerifyFailed
+	case errors.Is(err, os.ErrNotExist):
+		return ExitNotFound
+	}
+	return ExitError
+}
+
+// ciProgress returns a reporter writing progress events as JSON lines to stderr in CI
+// mode, which also silences the managers' human-readable output; nil otherwise
+func ciProgress() progress.Reporter {
+	if !ciMode {
+		return nil
+	}
+	encoder := json.NewEncoder(os.Stderr)
+	return func(e progress.Event) {
+		encoder.Encode(e)
+	}
+}
+
+// ciMessage writes a status message as a JSON line; errors and warnings go to stderr
+func ciMessage(level, message string) {
+	line, _ := json.Marshal(map[string]string{"level": level, "message": message})
+	if level == "error" || level == "warning" || level == "hint" {
+		fmt.Fprintln(os.Stderr, string(line))
+		return
+	}
+	fmt.Println(string(line))
+}
+
+// ciResult prints a command's result as JSON on stdout
+func ciResult(result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	fmt.Println(string(data))
+}
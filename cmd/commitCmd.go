@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	
 	"dgit/internal/commit"
+	"dgit/internal/log"
+	"dgit/internal/policy"
+	"dgit/internal/queue"
 	"dgit/internal/staging"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +26,16 @@ Examples:
   dgit commit "Logo design completed"
   dgit commit -m "Updated color scheme to brand guidelines"
   dgit commit                       # Opens editor for commit message
+  dgit commit --async -m "Hero render" # Queue and return immediately (see 'dgit queue')
+
+Asset policies configured under "policies" in .dgit/config (minimum DPI, maximum
+dimensions, required color mode, maximum file size) are checked for every file.
+Violations are printed as warnings; those with severity "block" stop the commit
+unless --ignore-policies is given.
+
+Compression is tuned by a profile: "speed" for the fastest commits, "balanced"
+(the default) or "archive" for the smallest storage. Set one for the repository
+as "compression.profile" in .dgit/config, or per commit with --profile.
 
 The commit will:
 - Create a snapshot (ZIP) of all staged files
@@ -36,20 +50,18 @@ The commit will:
 func init() {
 	// Add -m flag for commit message (similar to git)
 	CommitCmd.Flags().StringP("message", "m", "", "Commit message")
+	CommitCmd.Flags().Bool("async", false, "Queue the commit and compress/store it in the background")
+	CommitCmd.Flags().Bool("ignore-policies", false, "Commit even if blocking asset policies fail")
+	CommitCmd.Flags().String("profile", "", "Compression profile: speed, balanced or archive (default: from config)")
 }
 
 // runCommit executes the commit command functionality
 // Creates a snapshot of all staged files with metadata
 func runCommit(cmd *cobra.Command, args []string) {
-	// Ensure we're working within a DGit repository
-	if !isInDgitRepository() {
-		printError("not a dgit repository (or any of the parent directories)")
-		printSuggestion("Run 'dgit init' to initialize a repository")
-		os.Exit(1)
-	}
-
-	// Get repository and staging area
-	dgitDir := findDgitDirectory()
+	// Ensure we're working within a usable DGit repository
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	checkNoBatch(dgitDir)
 	stagingArea := staging.NewStagingArea(dgitDir)
 	
 	// Load current staging area state
@@ -60,6 +72,9 @@ func runCommit(cmd *cobra.Command, args []string) {
 
 	// Check if there are any files to commit
 	if stagingArea.IsEmpty() {
+		if ciMode {
+			exitWithError("no files staged for commit", "Use 'dgit add <files>' to stage files for commit")
+		}
 		fmt.Println("No files staged for commit.")
 		fmt.Println("   Use 'dgit add <files>' to stage files for commit.")
 		os.Exit(1)
@@ -73,6 +88,8 @@ func runCommit(cmd *cobra.Command, args []string) {
 	} else if msgFlag, _ := cmd.Flags().GetString("message"); msgFlag != "" {
 		// Message provided via -m flag
 		message = msgFlag
+	} else if ciMode {
+		exitWithCode(ExitUsage, "commit message required in CI mode", "Pass it with -m")
 	} else {
 		// Interactive input for commit message
 		fmt.Print("Enter commit message: ")
@@ -91,20 +108,115 @@ func runCommit(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// Held until the staging area is cleared, so the queue worker or another command
+	// cannot take the same version number or change staging mid-commit. Staging is read
+	// again under the lock, as it may have changed while the message was typed
+	lock := lockRepository(dgitDir, "commit")
+	defer lock.Release()
+	stagingArea = staging.NewStagingArea(dgitDir)
+	stagingArea.Progress = ciProgress()
+	if err := stagingArea.LoadStaging(); err != nil {
+		printError(fmt.Sprintf("loading staging area: %v", err))
+		os.Exit(1)
+	}
+	if stagingArea.IsEmpty() {
+		printError("the staging area was emptied by another dgit command")
+		os.Exit(1)
+	}
+
+	// A commit that only deletes files still needs the rest of the version
+	removed := stagingArea.GetRemovedFiles()
+	renamed := stagingArea.GetRenamedFiles()
+	if len(removed) > 0 && len(stagingArea.GetStagedFiles()) == 0 {
+		tracked, err := log.NewLogManager(dgitDir).GetTrackedFiles()
+		if err != nil {
+			printError(fmt.Sprintf("reading tracked files: %v", err))
+			os.Exit(1)
+		}
+		carried, err := stagingArea.StageRemaining(tracked)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		if len(carried) > 0 {
+			printInfo(fmt.Sprintf("Including %d other tracked file(s) in the new version", len(carried)))
+		}
+	}
+
 	// Get staged files for processing
 	stagedFiles := stagingArea.GetStagedFiles()
+	if len(stagedFiles) == 0 {
+		printError("cannot commit: every tracked file would be removed")
+		printSuggestion("Stage at least one file to keep with 'dgit add'")
+		os.Exit(1)
+	}
+
+	ignorePolicies, _ := cmd.Flags().GetBool("ignore-policies")
+	profile, _ := cmd.Flags().GetString("profile")
+	if _, ok := commit.Profiles[strings.ToLower(profile)]; profile != "" && !ok {
+		exitWithCode(ExitUsage, fmt.Sprintf("unknown compression profile '%s'", profile),
+			fmt.Sprintf("Choose one of: %s", strings.Join(commit.ProfileNames(), ", ")))
+	}
+
+	// Async: hand the staged set to the background worker and return
+	if async, _ := cmd.Flags().GetBool("async"); async {
+		job, err := queue.NewQueueManager(dgitDir).Enqueue(message, stagedFiles, queue.JobOptions{
+			Removed:        removed,
+			Renamed:        renamed,
+			IgnorePolicies: ignorePolicies,
+			Profile:        profile,
+		})
+		if err != nil {
+			printError(fmt.Sprintf("queueing commit: %v", err))
+			os.Exit(1)
+		}
+		if err := stagingArea.ClearStaging(); err != nil {
+			printWarning(fmt.Sprintf("failed to clear staging area: %v", err))
+		}
+		ensureQueueWorker(dgitDir)
+		if ciMode {
+			ciResult(map[string]interface{}{"queued": job.ID, "files": len(job.Files), "bytes": job.TotalSize()})
+			return
+		}
+
+		printGreen(fmt.Sprintf("Queued commit %s (%d files, %s)", job.ID, len(job.Files), formatBytes(job.TotalSize())))
+		fmt.Printf("%s\n", message)
+		fmt.Println("Compression and storage continue in the background; check progress with 'dgit queue'.")
+		return
+	}
+	if pending := queue.NewQueueManager(dgitDir).Pending(); len(pending) > 0 {
+		printWarning(fmt.Sprintf("%d queued commit(s) still pending; the one being stored keeps its place, this commit takes the next version and the rest follow it", len(pending)))
+	}
 	
 	// Display DGit-style commit progress messages
-	fmt.Printf("Creating commit with %d design files...\n", len(stagedFiles))
-	fmt.Println("Analyzing design file metadata...")
-	fmt.Println("Creating snapshot archive...")
+	if !ciMode {
+		fmt.Printf("Creating commit with %d design files...\n", len(stagedFiles))
+		fmt.Println("Analyzing design file metadata...")
+		fmt.Println("Creating snapshot archive...")
+	}
 	
 	// Create the actual commit with metadata and snapshot
 	commitManager := commit.NewCommitManager(dgitDir)
+	commitManager.Removed = removed
+	commitManager.Renamed = renamed
+	commitManager.IgnorePolicies = ignorePolicies
+	commitManager.Locked = true
+	commitManager.Progress = ciProgress()
+	if profile != "" {
+		commitManager.ApplyProfile(profile)
+	}
 	newCommit, err := commitManager.CreateCommit(message, stagedFiles)
 	if err != nil {
 		printError(fmt.Sprintf("creating commit: %v", err))
-		os.Exit(1)
+		var unchanged *commit.UnchangedError
+		if errors.As(err, &unchanged) {
+			printSuggestion(fmt.Sprintf("Save your changes first; 'dgit restore v%d' already gives you this content", unchanged.Version))
+		}
+		var violation *policy.PolicyError
+		if errors.As(err, &violation) {
+			printSuggestion("Fix the files, or commit anyway with --ignore-policies")
+		}
+		exit(exitCode(err))
 	}
 
 	// Clear staging area after successful commit
@@ -112,15 +224,31 @@ func runCommit(cmd *cobra.Command, args []string) {
 		printWarning(fmt.Sprintf("failed to clear staging area: %v", err))
 	}
 
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"version": newCommit.Version,
+			"hash":    newCommit.Hash,
+			"message": newCommit.Message,
+			"files":   newCommit.FilesCount,
+			"removed": newCommit.Removed,
+			"renamed": newCommit.Renamed,
+		})
+		return
+	}
+
 	// Display DGit-style success message with commit details
 	fmt.Printf("\n")
-	printGreen(fmt.Sprintf("Created commit %s", newCommit.Hash[:8]))
+	printGreen(fmt.Sprintf("Created commit %s", abbrevHash(newCommit.Hash)))
 	fmt.Printf("%s\n", message)
 	printCyan(fmt.Sprintf("Author: %s", newCommit.Author))
 	
 	// Show design-specific file details (unique to DGit!)
 	printBlue(fmt.Sprintf("Design files (%d):", newCommit.FilesCount))
 	for fileName, metadata := range newCommit.Metadata {
+		if oldPath, ok := newCommit.Renamed[fileName]; ok {
+			fmt.Printf("   [renamed] %s -> %s\n", oldPath, fileName)
+			continue
+		}
 		if metaMap, ok := metadata.(map[string]interface{}); ok {
 			// Get file type for display
 			fileType := getFileType(fileName)
@@ -154,6 +282,9 @@ func runCommit(cmd *cobra.Command, args []string) {
 			fmt.Printf("   %s\n", fileName)
 		}
 	}
+	for _, fileName := range newCommit.Removed {
+		fmt.Printf("   [deleted] %s\n", fileName)
+	}
 	
 	printGreen(fmt.Sprintf("Snapshot: %s", newCommit.SnapshotZip))
 	printBold("Ready for collaboration!")
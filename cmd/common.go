@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/repolock"
+
 	"github.com/fatih/color"
 )
 
@@ -59,16 +65,78 @@ func findDgitDirectory() string {
 
 // checkDgitRepository checks if we're in a DGit repository and exits with error message if not
 // Convenience function that combines check and error handling
+// Read-only commands use it: an interrupted batch is reported, not rolled back
 func checkDgitRepository() string {
+	dgitDir := locateRepository()
+	reportRepository(dgitDir)
+	return dgitDir
+}
+
+// checkWritableRepository is checkDgitRepository for commands that change the repository
+// (staging, history, HEAD, tags, object storage or project files); they first roll back
+// an interrupted batch
+func checkWritableRepository() string {
+	dgitDir := locateRepository()
+	recoverRepository(dgitDir)
+	return dgitDir
+}
+
+// recoverRepository undoes what a crash left half done before a command changes the repository
+func recoverRepository(dgitDir string) {
+	// A batch cut short by a crash is undone before anything builds on its partial work
+	recoverBatch(dgitDir)
+
+	// A crash while HEAD was rewritten must not orphan the repository state
+	recoverHead(dgitDir)
+}
+
+// reportRepository tells a read-only command what a crash left half done, without undoing it
+func reportRepository(dgitDir string) {
+	reportInterruptedBatch(dgitDir)
+	reportDamagedHead(dgitDir)
+}
+
+// lockRepository takes the repository lock for a command that changes history or
+// staging, waiting while another command holds it
+func lockRepository(dgitDir, operation string) *repolock.Lock {
+	lock, err := repolock.Acquire(dgitDir, operation, func(holder string) {
+		printInfo(fmt.Sprintf("Waiting for another dgit command to finish (%s)...", holder))
+	})
+	if err != nil {
+		exitWithError(err.Error(), "Check that the .dgit directory is writable")
+	}
+	return lock
+}
+
+// locateRepository finds the repository and checks it can be used at all
+func locateRepository() string {
 	if !isInDgitRepository() {
-		exitWithError("not a dgit repository (or any of the parent directories)", "Run 'dgit init' to initialize a repository")
+		exitWithCode(ExitNotRepository, "not a dgit repository (or any of the parent directories)", "Run 'dgit init' to initialize a repository")
+	}
+	dgitDir := findDgitDirectory()
+
+	// Refuse to misread repositories written by a newer DGit
+	if err := initializer.CheckFormat(dgitDir); err != nil {
+		exitWithError(err.Error(), "Upgrade DGit to work with this repository")
+	}
+
+	// Relocated storage may live on a volume that is not mounted
+	if err := initializer.CheckStorage(dgitDir); err != nil {
+		exitWithCode(ExitNotRepository, err.Error(),
+			"Mount the storage volume, or run 'dgit relocate <path>' from a copy")
 	}
-	return findDgitDirectory()
+
+	return dgitDir
 }
 
 // exitWithError prints error messages and exits with status code 1
 // Provides consistent error handling across all commands
 func exitWithError(message string, suggestion string) {
+	exitWithCode(ExitError, message, suggestion)
+}
+
+// exitWithCode is exitWithError with a specific CI exit code
+func exitWithCode(code int, message string, suggestion string) {
 	if message != "" {
 		if suggestion != "" {
 			// Error with helpful suggestion
@@ -79,33 +147,86 @@ func exitWithError(message string, suggestion string) {
 			printError(message)
 		}
 	}
-	os.Exit(1)
+	exit(code)
 }
 
 // printError prints an error message with red color formatting
 func printError(message string) {
+	if ciMode {
+		ciMessage("error", message)
+		return
+	}
 	fmt.Fprintf(os.Stderr, "%s: %s\n", red("Error"), message)
 }
 
 // printSuggestion prints a suggestion message with yellow color formatting
 func printSuggestion(message string) {
+	if ciMode {
+		ciMessage("hint", message)
+		return
+	}
 	fmt.Fprintf(os.Stderr, "%s\n", yellow(message))
 }
 
 // printSuccess prints a success message with green color formatting
 func printSuccess(message string) {
+	if ciMode {
+		ciMessage("success", message)
+		return
+	}
 	fmt.Printf("%s %s\n", green("✓"), message)
 }
 
 // printWarning prints a warning message with yellow color formatting
 func printWarning(message string) {
+	if ciMode {
+		ciMessage("warning", message)
+		return
+	}
 	fmt.Fprintf(os.Stderr, "%s: %s\n", yellow("Warning"), message)
 }
 
 // printInfo prints an informational message with default color
 func printInfo(message string) {
+	if ciMode {
+		ciMessage("info", message)
+		return
+	}
 	fmt.Println(message)
 }
 
+// formatBytes renders a byte count in the largest fitting unit (KB, MB, GB)
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
+
+// confirmAction asks a yes/no question on stdin; anything but "y"/"yes" declines
+// In CI mode nothing is read and the action is declined
+func confirmAction(prompt string) bool {
+	if ciMode {
+		printWarning(fmt.Sprintf("%s: declined (no prompts in CI mode)", prompt))
+		return false
+	}
+	fmt.Printf("%s [y/N]: ", prompt)
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(input))
+	return answer == "y" || answer == "yes"
+}
+
 // Helper functions for colored output using fatih/color library
 // These functions provide convenient access to colored printing
\ No newline at end of file
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/preset"
+
+	"github.com/spf13/cobra"
+)
+
+// ConfigCmd groups repository configuration tasks
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show, change and share repository configuration",
+	Long: `Show, change and share repository configuration.
+
+Settings live in two files. .dgit/config is shared: it travels with backups,
+mirrors and presets. .dgit/config.local holds overrides for this machine only
+(cache sizes, storage path, delta memory limits) and wins when both set a key.
+Changes to machine-specific keys are written to config.local automatically.
+
+A preset captures the settings a studio standardizes across its repositories:
+compression, asset policies, path normalization, extended attributes, previews,
+metadata packing, performance monitoring, environment capture and hash display.
+The author, storage location, format version and telemetry credentials stay with
+each repository.
+
+Examples:
+  dgit config show                                     # Effective settings
+  dgit config show --local                             # This machine's overrides
+  dgit config set --local compression.cache.main_cache_size 8192
+  dgit config set display.hash_length 16               # Longer abbreviated hashes
+  dgit config export studio.json --name "Studio 2026"  # Write a preset
+  dgit config import studio.json                       # Apply it to this project
+  dgit config import studio.json --only policies       # Apply selected sections
+  dgit config import studio.json --dry-run             # Show what would change`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration",
+	Args:  cobra.NoArgs,
+	Run:   runConfigShow,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a configuration value by its dotted key",
+	Long: `Set a configuration value by its dotted key, e.g. compression.cache.main_cache_size.
+
+The value is parsed as JSON when possible (numbers, true/false, lists), otherwise
+it is stored as a string. With --local the value goes to .dgit/config.local and
+only affects this machine.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runConfigSet,
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export <preset.json>",
+	Short: "Write this repository's shared settings to a preset file",
+	Args:  cobra.ExactArgs(1),
+	Run:   runConfigExport,
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <preset.json>",
+	Short: "Replace this repository's shared settings with a preset's",
+	Args:  cobra.ExactArgs(1),
+	Run:   runConfigImport,
+}
+
+func init() {
+	configShowCmd.Flags().Bool("local", false, "Show only this machine's overrides (config.local)")
+	configShowCmd.Flags().Bool("shared", false, "Show only the shared config, without local overrides")
+	configSetCmd.Flags().Bool("local", false, "Write to config.local instead of the shared config")
+	configExportCmd.Flags().String("name", "", "Name recorded in the preset")
+	configImportCmd.Flags().StringSlice("only", nil, fmt.Sprintf("Sections to import (%s)", strings.Join(preset.Sections, ", ")))
+	configImportCmd.Flags().Bool("dry-run", false, "Show which sections would change without writing")
+
+	ConfigCmd.AddCommand(configShowCmd)
+	ConfigCmd.AddCommand(configSetCmd)
+	ConfigCmd.AddCommand(configExportCmd)
+	ConfigCmd.AddCommand(configImportCmd)
+}
+
+// runConfigShow prints the effective, shared or local configuration as JSON
+func runConfigShow(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+	local, _ := cmd.Flags().GetBool("local")
+	shared, _ := cmd.Flags().GetBool("shared")
+	if local && shared {
+		printError("--local and --shared cannot be combined")
+		exit(ExitUsage)
+	}
+
+	var value interface{}
+	var err error
+	switch {
+	case local:
+		value, err = initializer.GetLocalConfig(dgitDir)
+	case shared:
+		value, err = initializer.GetSharedConfig(dgitDir)
+	default:
+		value, err = initializer.GetConfig(dgitDir)
+	}
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(value)
+		return
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// runConfigSet writes one value to the shared config or config.local
+func runConfigSet(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	local, _ := cmd.Flags().GetBool("local")
+	key := args[0]
+	if !local {
+		checkNotMirror(dgitDir)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(args[1]), &value); err != nil {
+		value = args[1]
+	}
+
+	if err := initializer.SetConfigValue(dgitDir, key, value, local); err != nil {
+		printError(err.Error())
+		exit(ExitUsage)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"key": key, "value": value, "local": local})
+		return
+	}
+
+	target := "shared config"
+	if local {
+		target = initializer.LocalConfigFile
+	}
+	printSuccess(fmt.Sprintf("Set %s = %s in %s", key, args[1], target))
+}
+
+// runConfigExport writes the preset file
+func runConfigExport(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+	name, _ := cmd.Flags().GetString("name")
+
+	p, err := preset.NewPresetManager(dgitDir).Export(name)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if err := p.Save(args[0]); err != nil {
+		printError(fmt.Sprintf("writing %s: %v", args[0], err))
+		os.Exit(1)
+	}
+
+	sections := make([]string, 0, len(p.Settings))
+	for _, section := range preset.Sections {
+		if _, ok := p.Settings[section]; ok {
+			sections = append(sections, section)
+		}
+	}
+	printSuccess(fmt.Sprintf("Exported %s to %s", strings.Join(sections, ", "), args[0]))
+	printInfo(fmt.Sprintf("Apply it in other projects with: dgit config import %s", args[0]))
+}
+
+// runConfigImport applies the preset file
+func runConfigImport(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	only, _ := cmd.Flags().GetStringSlice("only")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	p, err := preset.Load(args[0])
+	if err != nil {
+		printError(fmt.Sprintf("reading preset: %v", err))
+		exit(exitCode(err))
+	}
+
+	changes, err := preset.NewPresetManager(dgitDir).Import(p, only, dryRun)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"preset": p.Name, "dry_run": dryRun, "sections": changes})
+		return
+	}
+
+	changed := 0
+	for _, change := range changes {
+		state := "unchanged"
+		if change.Changed {
+			state = yellow("updated")
+			if dryRun {
+				state = yellow("would update")
+			}
+			changed++
+		}
+		fmt.Printf("  %-12s %s\n", change.Section, state)
+	}
+
+	name := args[0]
+	if p.Name != "" {
+		name = fmt.Sprintf("'%s'", p.Name)
+	}
+	switch {
+	case len(changes) == 0:
+		fmt.Println("The preset has none of the selected sections.")
+	case dryRun:
+		fmt.Printf("%d section(s) would change; run without --dry-run to apply.\n", changed)
+	default:
+		printSuccess(fmt.Sprintf("Applied preset %s: %d of %d section(s) changed", name, changed, len(changes)))
+	}
+}
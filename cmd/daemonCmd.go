@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"dgit/internal/daemon"
+
+	"github.com/spf13/cobra"
+)
+
+// DaemonCmd manages the background daemon
+var DaemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run background work as an OS-managed daemon",
+	Long: `Run dgit's background work in one long-lived process: queued commits from
+'dgit commit --async' are processed as they arrive, and storage is maintained
+hourly (leftover temp files pruned, cold snapshots archived when the archive
+stage is enabled, snapshots demoted when the disk runs low).
+
+'dgit daemon install' registers the daemon for this repository with the operating
+system so it starts at login, restarts if it crashes and survives reboots:
+a LaunchAgent on macOS, a systemd user service on Linux and a logon task in
+Task Scheduler on Windows. Output goes to .dgit/daemon.log.
+
+Examples:
+  dgit daemon install                        # Start at login, low priority
+  dgit daemon install --memory 2048 --cpu 50 # Cap memory (MB) and CPU (% of a core)
+  dgit daemon status                         # Is it installed and running?
+  dgit daemon uninstall                      # Stop it and remove the registration
+  dgit daemon run                            # Run in the foreground`,
+}
+
+var daemonRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the daemon in the foreground",
+	Args:  cobra.NoArgs,
+	Run:   runDaemonRun,
+}
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Register the daemon to start at login",
+	Args:  cobra.NoArgs,
+	Run:   runDaemonInstall,
+}
+
+var daemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop the daemon and remove its registration",
+	Args:  cobra.NoArgs,
+	Run:   runDaemonUninstall,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is installed and running",
+	Args:  cobra.NoArgs,
+	Run:   runDaemonStatus,
+}
+
+func init() {
+	daemonRunCmd.Flags().Duration("interval", 5*time.Second, "Commit queue polling interval")
+	daemonRunCmd.Flags().String("log", "", "Append output to this file instead of stdout")
+	daemonInstallCmd.Flags().Duration("interval", 5*time.Second, "Commit queue polling interval")
+	daemonInstallCmd.Flags().Int("memory", 0, "Memory limit in MB (0 = unlimited)")
+	daemonInstallCmd.Flags().Int("cpu", 0, "CPU limit as a percentage of one core (0 = unlimited)")
+	daemonInstallCmd.Flags().Int("nice", 10, "Scheduling priority from 0 (normal) to 19 (lowest)")
+	daemonStatusCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	DaemonCmd.AddCommand(daemonRunCmd)
+	DaemonCmd.AddCommand(daemonInstallCmd)
+	DaemonCmd.AddCommand(daemonUninstallCmd)
+	DaemonCmd.AddCommand(daemonStatusCmd)
+}
+
+// runDaemonRun processes the queue and maintains storage until interrupted
+func runDaemonRun(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	interval, _ := cmd.Flags().GetDuration("interval")
+	logPath, _ := cmd.Flags().GetString("log")
+
+	var out io.Writer = os.Stdout
+	if logPath != "" {
+		logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			printError(fmt.Sprintf("opening log: %v", err))
+			os.Exit(1)
+		}
+		defer logFile.Close()
+		out = logFile
+	}
+	logf := func(format string, a ...interface{}) {
+		fmt.Fprintf(out, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, a...))
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	logf("daemon started (pid %d)", os.Getpid())
+	if err := daemon.Run(dgitDir, interval, stop, logf); err != nil {
+		logf("daemon stopped: %v", err)
+		os.Exit(1)
+	}
+	logf("daemon stopped")
+}
+
+// runDaemonInstall registers the daemon with the OS service manager
+func runDaemonInstall(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	checkNotMirror(dgitDir)
+	interval, _ := cmd.Flags().GetDuration("interval")
+	limits := daemon.Limits{}
+	limits.MemoryMB, _ = cmd.Flags().GetInt("memory")
+	limits.CPUPercent, _ = cmd.Flags().GetInt("cpu")
+	limits.Nice, _ = cmd.Flags().GetInt("nice")
+
+	service, err := daemon.NewService(dgitDir, limits, interval)
+	if err != nil {
+		printError(err.Error())
+		exit(ExitUsage)
+	}
+	notes, err := service.Install()
+	if err != nil {
+		printError(fmt.Sprintf("installing daemon: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"label": service.Label, "status": service.Status(), "limits": limits, "notes": notes})
+		return
+	}
+
+	st := service.Status()
+	printSuccess(fmt.Sprintf("Installed daemon %s with %s", service.Label, st.Manager))
+	if st.UnitPath != "" {
+		fmt.Printf("  Definition: %s\n", st.UnitPath)
+	}
+	fmt.Printf("  Log:        %s\n", service.LogFile)
+	for _, note := range notes {
+		printWarning(note)
+	}
+	printInfo("It starts at login and restarts if it exits unexpectedly; remove it with 'dgit daemon uninstall'")
+}
+
+// runDaemonUninstall removes the daemon registration
+func runDaemonUninstall(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+
+	service, err := daemon.NewService(dgitDir, daemon.Limits{}, 0)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if err := service.Uninstall(); err != nil {
+		printError(err.Error())
+		exit(ExitNotFound)
+	}
+	printSuccess(fmt.Sprintf("Uninstalled daemon %s", service.Label))
+}
+
+// runDaemonStatus reports the daemon registration
+func runDaemonStatus(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	service, err := daemon.NewService(dgitDir, daemon.Limits{}, 0)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	st := service.Status()
+	if ciMode {
+		ciResult(st)
+		return
+	}
+	if asJSON {
+		data, _ := json.MarshalIndent(st, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	switch {
+	case !st.Installed:
+		fmt.Println("Daemon is not installed for this repository.")
+		printSuggestion("Install it with 'dgit daemon install'")
+	case st.Running:
+		fmt.Printf("Daemon %s is %s (%s)\n", service.Label, green("running"), st.Manager)
+	default:
+		fmt.Printf("Daemon %s is installed but %s (%s)\n", service.Label, yellow("not running"), st.Manager)
+	}
+	if st.UnitPath != "" && st.Installed {
+		fmt.Printf("  Definition: %s\n", st.UnitPath)
+	}
+	fmt.Printf("  Log:        %s\n", service.LogFile)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"dgit/internal/derived"
+
+	"github.com/spf13/cobra"
+)
+
+// DerivedCmd links exported assets to the source file versions they were generated from
+var DerivedCmd = &cobra.Command{
+	Use:   "derived",
+	Short: "Link exports to the source versions they were generated from",
+	Long: `Record which committed version of a design file an export was generated from.
+
+Exports (PNG, PDF, renders) don't need to be versioned themselves; DGit only remembers
+where they came from. When the source is committed again, 'dgit status' lists the
+export as stale so it can be regenerated before it is handed off.
+
+Export scripts and app hooks can record links as they write files.
+
+Examples:
+  dgit derived add exports/hero.png --derived-from hero.psd          # Latest hero.psd version
+  dgit derived add exports/hero@2x.png --derived-from hero.psd -v 14 # A specific version
+  dgit derived list                                                   # All links and freshness
+  dgit derived remove exports/hero.png`,
+}
+
+var derivedAddCmd = &cobra.Command{
+	Use:   "add <export...> --derived-from <source>",
+	Short: "Record that exports were generated from a source file",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runDerivedAdd,
+}
+
+var derivedListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List exports and whether their source has moved on",
+	Args:  cobra.NoArgs,
+	Run:   runDerivedList,
+}
+
+var derivedRemoveCmd = &cobra.Command{
+	Use:   "remove <export...>",
+	Short: "Forget the source link of exports",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runDerivedRemove,
+}
+
+func init() {
+	derivedAddCmd.Flags().String("derived-from", "", "Source design file the exports were generated from")
+	derivedAddCmd.Flags().StringP("version", "v", "", "Source version (default: latest version containing the source)")
+	derivedAddCmd.MarkFlagRequired("derived-from")
+
+	DerivedCmd.AddCommand(derivedAddCmd)
+	DerivedCmd.AddCommand(derivedListCmd)
+	DerivedCmd.AddCommand(derivedRemoveCmd)
+}
+
+// runDerivedAdd records export -> source links
+func runDerivedAdd(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	source, _ := cmd.Flags().GetString("derived-from")
+	version, _ := cmd.Flags().GetString("version")
+
+	manager := derived.NewDerivedManager(dgitDir)
+	sourcePath := repositoryPath(dgitDir, source)
+
+	recorded := []*derived.Link{}
+	failed := false
+	for _, export := range args {
+		if _, err := os.Stat(export); err != nil {
+			printWarning(fmt.Sprintf("%s does not exist yet; recording the link anyway", export))
+		}
+		link, err := manager.Record(repositoryPath(dgitDir, export), export, sourcePath, version)
+		if err != nil {
+			printError(err.Error())
+			failed = true
+			continue
+		}
+		recorded = append(recorded, link)
+		if !ciMode {
+			printSuccess(fmt.Sprintf("%s <- %s v%d", link.Export, link.Source, link.Version))
+		}
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"recorded": recorded})
+	}
+	if failed {
+		exit(ExitError)
+	}
+}
+
+// runDerivedList prints every link with its freshness
+func runDerivedList(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	manager := derived.NewDerivedManager(dgitDir)
+
+	links, err := manager.List()
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if len(links) == 0 && !ciMode {
+		fmt.Println("No derived assets recorded.")
+		printInfo("Record one with: dgit derived add <export> --derived-from <source>")
+		return
+	}
+
+	stale, err := manager.Stale()
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	latest := make(map[string]int, len(stale))
+	for _, link := range stale {
+		latest[link.Export] = link.LatestVersion
+	}
+
+	if ciMode {
+		entries := []map[string]interface{}{}
+		for _, link := range links {
+			state := "up to date"
+			if _, err := os.Stat(link.Export); err != nil {
+				state = "missing"
+			} else if _, ok := latest[link.Export]; ok {
+				state = "stale"
+			}
+			entries = append(entries, map[string]interface{}{"link": link, "state": state, "latest_version": latest[link.Export]})
+		}
+		ciResult(entries)
+		return
+	}
+
+	for _, link := range links {
+		state := green("up to date")
+		if _, err := os.Stat(link.Export); err != nil {
+			state = yellow("missing")
+		} else if version, ok := latest[link.Export]; ok {
+			state = yellow(fmt.Sprintf("stale, source now v%d", version))
+		}
+		fmt.Printf("%s  <- %s v%d  [%s]\n", link.Export, link.Source, link.Version, state)
+	}
+}
+
+// runDerivedRemove forgets export links
+func runDerivedRemove(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	manager := derived.NewDerivedManager(dgitDir)
+
+	removed := []string{}
+	failed := false
+	for _, export := range args {
+		if err := manager.Remove(repositoryPath(dgitDir, export)); err != nil {
+			printError(err.Error())
+			failed = true
+			continue
+		}
+		removed = append(removed, export)
+		if !ciMode {
+			fmt.Printf("Removed link for %s\n", export)
+		}
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"removed": removed})
+	}
+	if failed {
+		exit(ExitError)
+	}
+}
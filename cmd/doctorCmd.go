@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+
+	"dgit/internal/health"
+	"dgit/internal/maintenance"
+	"dgit/internal/replica"
+
+	"github.com/spf13/cobra"
+)
+
+// DoctorCmd checks repository health and repairs what it can
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check repository health and fix what can be fixed",
+	Long: `Check repository health and show a score out of 100.
+
+The score adds up signals that slow a repository down or put data at risk:
+  delta-chain   versions that replay more deltas than the compression profile allows
+  cache         .dgit/cache above compression.cache.main_cache_size
+  temp-files    leftovers of interrupted commits and restores
+  unverified    versions whose objects were never read back
+  damaged       versions whose objects are missing or corrupt
+  queue         background commits that failed or wait with no worker running
+
+90 and above is healthy, 70 and above fair. 'dgit status' shows the score on its
+last line. With --fix each finding is addressed: long chains get full
+snapshots, the cache is trimmed, leftovers are deleted, objects are verified
+and the commit queue is processed. Damaged versions need their objects back
+from a mirror or backup first; --fix then checks them again.
+
+Examples:
+  dgit doctor            # Score and findings
+  dgit doctor --fix      # Address every finding
+  dgit doctor --json     # Machine-readable report`,
+	Args: cobra.NoArgs,
+	Run:  runDoctor,
+}
+
+func init() {
+	DoctorCmd.Flags().Bool("fix", false, "Address every finding")
+	DoctorCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// runDoctor reports health, optionally fixing each finding and checking again
+func runDoctor(cmd *cobra.Command, _ []string) {
+	fix, _ := cmd.Flags().GetBool("fix")
+	dgitDir := locateRepository()
+	if fix {
+		recoverRepository(dgitDir)
+	} else {
+		reportRepository(dgitDir)
+	}
+	asJSON, _ := cmd.Flags().GetBool("json")
+	hm := health.NewHealthManager(dgitDir)
+
+	report, err := hm.Check()
+	if err != nil {
+		printError(fmt.Sprintf("checking health: %v", err))
+		exit(ExitError)
+	}
+
+	var fixes []map[string]string
+	if fix && len(report.Findings) > 0 {
+		checkNotMirror(dgitDir)
+		mm := maintenance.NewMaintenanceManager(dgitDir)
+		compaction, trackErr := mm.Track("doctor --fix", func() error {
+			for _, finding := range report.Findings {
+				summary, err := hm.Fix(finding)
+				entry := map[string]string{"kind": finding.Kind, "result": summary}
+				if err != nil {
+					entry["error"] = err.Error()
+					printWarning(fmt.Sprintf("%s: %v", finding.Kind, err))
+				} else if !asJSON && !ciMode {
+					fmt.Printf("%s %s: %s\n", green("✓"), finding.Kind, summary)
+				}
+				fixes = append(fixes, entry)
+			}
+			return nil
+		})
+		if !asJSON && !ciMode {
+			printCompactionSummary(compaction, trackErr)
+			fmt.Println()
+		}
+		if report, err = hm.Check(); err != nil {
+			printError(fmt.Sprintf("checking health: %v", err))
+			exit(ExitError)
+		}
+	}
+
+	if asJSON || ciMode {
+		result := map[string]interface{}{"report": report}
+		if fix {
+			result["fixes"] = fixes
+		}
+		ciResult(result)
+	} else {
+		printHealthReport(report, fix)
+	}
+	for _, finding := range report.Findings {
+		if finding.Severity == health.SeverityCritical {
+			exit(ExitVerifyFailed)
+		}
+	}
+}
+
+// printHealthReport prints the score and each finding with what --fix would do
+func printHealthReport(report *health.Report, fixed bool) {
+	fmt.Printf("Repository health: %s\n", describeHealth(report))
+	if len(report.Findings) == 0 {
+		return
+	}
+	fmt.Println()
+	fixable := 0
+	for _, finding := range report.Findings {
+		marker := yellow("!")
+		switch finding.Severity {
+		case health.SeverityCritical:
+			marker = red("✗")
+		case health.SeverityInfo:
+			marker = cyan("i")
+		}
+		fmt.Printf("  %s %-12s %s (-%d)\n", marker, finding.Kind, finding.Message, finding.Penalty)
+		if finding.Fix != "" {
+			fmt.Printf("    %-12s fix: %s\n", "", finding.Fix)
+			fixable++
+		}
+	}
+	if fixable > 0 && !fixed {
+		fmt.Println()
+		printSuggestion(fmt.Sprintf("Run 'dgit doctor --fix' to address %d finding(s)", fixable))
+	}
+}
+
+// describeHealth renders "92/100 (healthy)" colored by status
+func describeHealth(report *health.Report) string {
+	text := fmt.Sprintf("%d/100 (%s)", report.Score, report.Status)
+	switch report.Status {
+	case health.StatusHealthy:
+		return green(text)
+	case health.StatusFair:
+		return yellow(text)
+	}
+	return red(text)
+}
+
+// printHealthSummary shows the health score as the last line of 'dgit status'
+// Replicas hold only the objects their jobs fetched, so they are not scored
+func printHealthSummary(dgitDir string) {
+	if _, ok := replica.IsReplica(dgitDir); ok {
+		return
+	}
+	report, err := health.NewHealthManager(dgitDir).Check()
+	if err != nil {
+		return
+	}
+	fmt.Println()
+	line := "Health: " + describeHealth(report)
+	if len(report.Findings) > 0 {
+		line += fmt.Sprintf(", %d finding(s); see 'dgit doctor'", len(report.Findings))
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"dgit/internal/export"
+
+	"github.com/spf13/cobra"
+)
+
+// ExportCmd dumps commit and file metadata into analyst-friendly formats
+var ExportCmd = &cobra.Command{
+	Use:   "export <format> <target>",
+	Short: "Export commit and file metadata (CSV, SQLite, Parquet)",
+	Args:  cobra.ExactArgs(2),
+	Run:   runExport,
+}
+
+func init() {
+	ExportCmd.Flags().Bool("include-hidden", false, "Include versions hidden with 'dgit hide'")
+
+	var formats []string
+	for _, exporter := range export.All() {
+		formats = append(formats, fmt.Sprintf("  %-9s %s", exporter.Name(), exporter.Description()))
+	}
+
+	ExportCmd.Long = `Export commit history and per-file design metadata for analysis.
+
+Two tables are exported: "commits" (one row per version) and "files" (one row
+per file per version, with type, size, dimensions, color mode and layer counts).
+
+Formats:
+` + strings.Join(formats, "\n") + `
+
+Examples:
+  dgit export sqlite history.db       # Then: sqlite3 history.db "SELECT * FROM commits"
+  dgit export csv reports/            # reports/commits.csv, reports/files.csv
+  dgit export parquet warehouse/      # warehouse/commits.parquet, warehouse/files.parquet
+
+Versions hidden with 'dgit hide' are left out unless --include-hidden is given.`
+}
+
+// runExport collects history and hands it to the selected exporter
+func runExport(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+
+	exporter, err := export.Get(args[0])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+	tables, err := export.Collect(dgitDir, hiddenVersions(dgitDir, includeHidden))
+	if err != nil {
+		printError(fmt.Sprintf("collecting metadata: %v", err))
+		os.Exit(1)
+	}
+
+	written, err := exporter.Export(tables, args[1])
+	if err != nil {
+		printError(fmt.Sprintf("exporting %s: %v", exporter.Name(), err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		rows := make(map[string]int, len(tables))
+		for _, table := range tables {
+			rows[table.Name] = len(table.Rows)
+		}
+		ciResult(map[string]interface{}{"format": exporter.Name(), "rows": rows, "written": written})
+		return
+	}
+
+	for _, table := range tables {
+		fmt.Printf("  %-8s %d rows\n", table.Name, len(table.Rows))
+	}
+	for _, path := range written {
+		printSuccess(fmt.Sprintf("Wrote %s", path))
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/activity"
+
+	"github.com/spf13/cobra"
+)
+
+// FeedCmd shows recent team activity recorded in the shared activity feed
+var FeedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "Show recent versions committed by you and your teammates",
+	Long: `Show the team activity feed stored in .dgit/activity.jsonl.
+
+Every commit appends an entry with the user and machine that created it, so when a
+repository lives on a shared drive everyone can see their teammates' recent versions
+without any server or push/pull setup.
+
+Examples:
+  dgit feed                   # Newest 20 events
+  dgit feed -n 50             # Newest 50 events
+  dgit feed --user alice      # Only alice's commits (user or author name)
+  dgit feed --since 2d        # Events from the last two days (also 12h, 30m, 2026-10-01)
+  dgit feed --include-hidden  # Include versions hidden with 'dgit hide'`,
+	Args: cobra.NoArgs,
+	Run:  runFeed,
+}
+
+func init() {
+	FeedCmd.Flags().IntP("number", "n", 20, "Number of events to show (0 for all)")
+	FeedCmd.Flags().StringP("user", "u", "", "Only show events by this user or author")
+	FeedCmd.Flags().String("since", "", "Only show events newer than a duration (30m, 12h, 7d) or date (YYYY-MM-DD)")
+	FeedCmd.Flags().Bool("include-hidden", false, "Include versions hidden with 'dgit hide'")
+}
+
+// runFeed prints feed events, newest first
+func runFeed(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+
+	number, _ := cmd.Flags().GetInt("number")
+	userName, _ := cmd.Flags().GetString("user")
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+
+	filter := activity.Filter{User: userName, Limit: number, Exclude: hiddenVersions(dgitDir, includeHidden)}
+	if sinceFlag != "" {
+		since, err := parseSince(sinceFlag)
+		if err != nil {
+			printError(err.Error())
+			os.Exit(1)
+		}
+		filter.Since = since
+	}
+
+	events, err := activity.NewActivityLog(dgitDir).Read(filter)
+	if err != nil {
+		printError(fmt.Sprintf("reading activity feed: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		if events == nil {
+			events = []*activity.Event{}
+		}
+		ciResult(events)
+		return
+	}
+	if len(events) == 0 {
+		fmt.Println("No activity yet.")
+		return
+	}
+
+	me, machine := activity.Identity()
+	for _, event := range events {
+		who := fmt.Sprintf("%s@%s", event.User, event.Machine)
+		if event.User == me && event.Machine == machine {
+			who += " (you)"
+		}
+
+		fmt.Printf("%s  %s  v%d %s  %s\n",
+			event.Timestamp.Local().Format("2006-01-02 15:04"), cyan(who), event.Version, abbrevHash(event.Hash), event.Message)
+		if len(event.Files) > 0 {
+			fmt.Printf("    %d files, %s: %s\n", len(event.Files), formatBytes(event.Bytes), summarizeFeedFiles(event.Files))
+		}
+	}
+}
+
+// summarizeFeedFiles lists the first few files of an event
+func summarizeFeedFiles(files []string) string {
+	const shown = 3
+	if len(files) <= shown {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s, +%d more", strings.Join(files[:shown], ", "), len(files)-shown)
+}
+
+// parseSince accepts a relative age (30m, 12h, 7d) or a YYYY-MM-DD date
+func parseSince(value string) (time.Time, error) {
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-age), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value '%s' (use 30m, 12h, 7d or YYYY-MM-DD)", value)
+}
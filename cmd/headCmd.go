@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+
+	"dgit/internal/log"
+	"dgit/internal/mirror"
+	"dgit/internal/replica"
+
+	"github.com/spf13/cobra"
+)
+
+// HeadCmd shows HEAD and the values it recently held
+var HeadCmd = &cobra.Command{
+	Use:   "head",
+	Short: "Show the checked-out commit and recent HEAD values",
+	Long: `Show the commit HEAD points at.
+
+HEAD is replaced atomically on every commit, and each value it takes is kept in
+.dgit/HEAD.ring (the last 32). If HEAD is ever found empty or unreadable, for
+example after a crash or a disk filling up, the next command that changes the
+repository points it back at the newest commit whose record verifies, and says
+so. Read-only commands only warn; 'dgit head --repair' fixes it on its own.
+
+Examples:
+  dgit head              # Current HEAD
+  dgit head --history    # Recent HEAD values, newest first
+  dgit head --repair     # Repair an empty or unreadable HEAD
+  dgit head --json       # Machine-readable`,
+	Args: cobra.NoArgs,
+	Run:  runHead,
+}
+
+func init() {
+	HeadCmd.Flags().Bool("history", false, "List recent HEAD values")
+	HeadCmd.Flags().Bool("repair", false, "Point an empty or unreadable HEAD back at the newest valid commit")
+	HeadCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// runHead prints HEAD, or its recorded history
+func runHead(cmd *cobra.Command, _ []string) {
+	var dgitDir string
+	if repair, _ := cmd.Flags().GetBool("repair"); repair {
+		dgitDir = locateRepository()
+		if !recoverHead(dgitDir) {
+			printInfo("HEAD is fine; nothing to repair.")
+		}
+	} else {
+		dgitDir = checkDgitRepository()
+	}
+	showHistory, _ := cmd.Flags().GetBool("history")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	entries, err := log.HeadHistory(dgitDir)
+	if err != nil {
+		printWarning(err.Error())
+	}
+	head := headCommit(dgitDir)
+
+	if asJSON || ciMode {
+		result := map[string]interface{}{"head": nil, "history": entries}
+		if head != nil {
+			result["head"] = map[string]interface{}{"hash": head.Hash, "version": head.Version}
+		}
+		if entries == nil {
+			result["history"] = []log.HeadEntry{}
+		}
+		ciResult(result)
+		return
+	}
+
+	if head == nil {
+		fmt.Println("HEAD: no commits yet")
+	} else {
+		fmt.Printf("HEAD: %s (v%d) %s\n", cyan(abbrevHash(head.Hash)), head.Version, head.Message)
+	}
+	if !showHistory {
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No HEAD history recorded yet.")
+		return
+	}
+	fmt.Println()
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		fmt.Printf("  %s  %s  v%-4d %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), abbrevHash(entry.Hash), entry.Version, entry.Reason)
+	}
+}
+
+// recoverHead repairs an empty or unreadable HEAD, telling the user what it now names;
+// it reports whether HEAD was damaged
+// Mirrors and replicas take HEAD from their source and are left alone
+func recoverHead(dgitDir string) bool {
+	if headFollowsSource(dgitDir) {
+		return false
+	}
+	recovery, err := log.NewLogManager(dgitDir).RecoverHead()
+	if err != nil {
+		printWarning(fmt.Sprintf("HEAD is damaged and could not be recovered: %v", err))
+		return true
+	}
+	if recovery == nil {
+		return false
+	}
+	printWarning(fmt.Sprintf("HEAD was empty or unreadable; pointed it at v%d (%s), %s", recovery.Version, abbrevHash(recovery.Hash), recoverySource(recovery)))
+	return true
+}
+
+// reportDamagedHead warns about an empty or unreadable HEAD without rewriting it
+func reportDamagedHead(dgitDir string) {
+	if headFollowsSource(dgitDir) {
+		return
+	}
+	recovery, err := log.NewLogManager(dgitDir).CheckHead()
+	if err != nil {
+		printWarning(fmt.Sprintf("HEAD is damaged and no commit to recover it at was found: %v", err))
+		return
+	}
+	if recovery == nil {
+		return
+	}
+	printWarning(fmt.Sprintf("HEAD is empty or unreadable; it belongs at v%d (%s), %s", recovery.Version, abbrevHash(recovery.Hash), recoverySource(recovery)))
+	printSuggestion("Run 'dgit head --repair' to fix it; the next command that changes the repository also does")
+}
+
+// headFollowsSource reports whether HEAD is taken from another repository (mirrors, replicas)
+func headFollowsSource(dgitDir string) bool {
+	if _, ok := mirror.IsMirror(dgitDir); ok {
+		return true
+	}
+	_, ok := replica.IsReplica(dgitDir)
+	return ok
+}
+
+// recoverySource describes where a HEAD recovery found its commit
+func recoverySource(recovery *log.HeadRecovery) string {
+	if recovery.Source == "ring" {
+		return "the last recorded HEAD"
+	}
+	return "the newest valid commit"
+}
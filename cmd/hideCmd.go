@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"dgit/internal/activity"
+	"dgit/internal/hidden"
+	"dgit/internal/log"
+
+	"github.com/spf13/cobra"
+)
+
+// HideCmd hides versions from default listings without deleting their data
+var HideCmd = &cobra.Command{
+	Use:   "hide [<version>...]",
+	Short: "Hide versions from log, feed, exports and reports (data is kept)",
+	Long: `Hide versions from default listings without deleting anything.
+
+A hidden version stays in the history and in storage: 'dgit show' and
+'dgit restore' still work with it, and later versions are unaffected. It is
+left out of 'dgit log', 'dgit feed', 'dgit export', 'dgit report', 'dgit review
+list' and 'dgit previews rebuild' unless --include-hidden is given, so
+explorations don't surface in client-facing reports. Commit hashes don't change.
+
+Examples:
+  dgit hide v17                           # Hide one version
+  dgit hide v17 v18 -m "logo exploration" # Hide several, noting why
+  dgit hide --list                        # Show hidden versions
+  dgit unhide v17                         # Make it visible again
+  dgit log --include-hidden               # Full history`,
+	Run: runHide,
+}
+
+// UnhideCmd makes hidden versions visible again
+var UnhideCmd = &cobra.Command{
+	Use:   "unhide <version>...",
+	Short: "Show hidden versions in listings again",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runUnhide,
+}
+
+func init() {
+	HideCmd.Flags().StringP("message", "m", "", "Why the versions are hidden")
+	HideCmd.Flags().Bool("list", false, "List hidden versions")
+}
+
+// runHide hides the given versions, or lists hidden ones
+func runHide(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	manager := hidden.NewHiddenManager(dgitDir)
+
+	if list, _ := cmd.Flags().GetBool("list"); list || len(args) == 0 {
+		printHiddenVersions(dgitDir, manager)
+		return
+	}
+
+	checkNotMirror(dgitDir)
+	reason, _ := cmd.Flags().GetString("message")
+	versions := resolveVersionArgs(dgitDir, args)
+	user, _ := activity.Identity()
+	added, err := manager.Hide(versions, reason, user)
+	if err != nil {
+		printError(err.Error())
+		exit(ExitError)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"hidden": added})
+		return
+	}
+	if len(added) == 0 {
+		printInfo("Already hidden; nothing changed")
+		return
+	}
+	printSuccess(fmt.Sprintf("Hid %s", formatVersionList(added)))
+	printSuggestion("Hidden versions can still be shown and restored; list them with 'dgit hide --list'")
+}
+
+// runUnhide makes the given versions visible
+func runUnhide(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+
+	removed, err := hidden.NewHiddenManager(dgitDir).Unhide(resolveVersionArgs(dgitDir, args))
+	if err != nil {
+		printError(err.Error())
+		exit(ExitError)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"unhidden": removed})
+		return
+	}
+	if len(removed) == 0 {
+		printInfo("None of these versions are hidden; nothing changed")
+		return
+	}
+	printSuccess(fmt.Sprintf("%s visible again", formatVersionList(removed)))
+}
+
+// printHiddenVersions lists hidden versions with their commit messages
+func printHiddenVersions(dgitDir string, manager *hidden.HiddenManager) {
+	entries, err := manager.List()
+	if err != nil {
+		printError(err.Error())
+		exit(ExitError)
+	}
+	if ciMode {
+		if entries == nil {
+			entries = []*hidden.Entry{}
+		}
+		ciResult(entries)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("No hidden versions.")
+		return
+	}
+
+	logManager := log.NewLogManager(dgitDir)
+	for _, entry := range entries {
+		message := ""
+		if commit, err := logManager.GetCommit(entry.Version); err == nil {
+			message = commit.Message
+		}
+		fmt.Printf("  %-6s %s  %s\n", cyan(fmt.Sprintf("v%d", entry.Version)), entry.HiddenAt.Local().Format("2006-01-02 15:04"), message)
+		if entry.Reason != "" {
+			fmt.Printf("         hidden by %s: %s\n", entry.HiddenBy, entry.Reason)
+		}
+	}
+}
+
+// resolveVersionArgs turns versions ("v17", "17"), tags or commit hashes into versions,
+// exiting on unknown ones; numbers are read as versions, then tags, then hash prefixes
+func resolveVersionArgs(dgitDir string, args []string) []int {
+	logManager := log.NewLogManager(dgitDir)
+	versions := make([]int, 0, len(args))
+	for _, arg := range args {
+		var commit *log.Commit
+		var err error
+		if version, parseErr := parseVersion(arg); parseErr == nil {
+			commit, err = logManager.GetCommit(version)
+		}
+		if commit == nil {
+			if commit, err = resolveTag(dgitDir, arg); err != nil {
+				printError(err.Error())
+				exit(ExitNotFound)
+			}
+		}
+		if commit == nil {
+			commit, err = logManager.GetCommitByHash(arg)
+		}
+		var ambiguous *log.AmbiguousHashError
+		if errors.As(err, &ambiguous) {
+			printError(err.Error())
+			exit(ExitUsage)
+		}
+		if err != nil {
+			printError(fmt.Sprintf("version '%s' not found", arg))
+			exit(ExitNotFound)
+		}
+		versions = append(versions, commit.Version)
+	}
+	return versions
+}
+
+// hiddenVersions returns the versions default listings leave out; nil when include is set
+func hiddenVersions(dgitDir string, include bool) map[int]bool {
+	if include {
+		return nil
+	}
+	return hidden.NewHiddenManager(dgitDir).Versions()
+}
+
+// formatVersionList renders versions as "v3, v7"
+func formatVersionList(versions []int) string {
+	text := ""
+	for i, version := range versions {
+		if i > 0 {
+			text += ", "
+		}
+		text += fmt.Sprintf("v%d", version)
+	}
+	return text
+}
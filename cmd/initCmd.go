@@ -44,5 +44,9 @@ func runInit(cmd *cobra.Command, args []string) {
 
 	// Display success message with absolute path
 	absPath, _ := filepath.Abs(targetDir)
+	if ciMode {
+		ciResult(map[string]interface{}{"initialized": absPath})
+		return
+	}
 	printSuccess(fmt.Sprintf("Initialized DGit repository in %s", absPath))
 }
\ No newline at end of file
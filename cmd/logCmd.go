@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"dgit/internal/hidden"
 	"dgit/internal/log"
 
 	"github.com/spf13/cobra"
@@ -11,36 +13,117 @@ import (
 
 // LogCmd shows commit history with design-specific metadata
 var LogCmd = &cobra.Command{
-	Use:   "log",
+	Use:   "log [file]",
 	Short: "Show commit history",
 	Long: `Display the commit history showing:
 - Commit hashes and messages
 - Author and timestamp information
 - File counts and metadata summaries
 
+With a file argument only the versions that touched that file are shown,
+following it back across renames made with 'dgit mv'.
+
 Examples:
   dgit log                    # Show all commits
   dgit log --oneline          # Show compact format
-  dgit log -n 5               # Show last 5 commits`,
-	Run: runLog,
+  dgit log -n 5               # Show last 5 commits
+  dgit log final/poster.psd   # History of one file, including before it was moved
+  dgit log --since 7d         # Commits from the last week
+  dgit log --include-hidden   # Include versions hidden with 'dgit hide'
+
+Dates are shown in this machine's timezone; when the author was in another
+timezone their local time is shown too. A commit timestamped before its parent
+(the author's clock was behind) is marked, and --since keeps it whenever its
+parent is kept.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runLog,
 }
 
 func init() {
 	LogCmd.Flags().BoolP("oneline", "o", false, "Show commits in compact one-line format")
 	LogCmd.Flags().IntP("number", "n", 0, "Limit the number of commits to show")
+	LogCmd.Flags().Bool("include-hidden", false, "Include versions hidden with 'dgit hide'")
+	LogCmd.Flags().String("since", "", "Only commits newer than an age (30m, 12h, 7d) or date (YYYY-MM-DD)")
 }
 
 // runLog displays commit history with design-specific information
-func runLog(cmd *cobra.Command, _ []string) {
+func runLog(cmd *cobra.Command, args []string) {
 	dgitDir := checkDgitRepository()
 	logManager := log.NewLogManager(dgitDir)
 
-	commits, err := logManager.GetCommitHistory()
+	var commits []*log.Commit
+	var err error
+	if len(args) == 1 {
+		commits, err = logManager.FileHistory(repositoryPath(dgitDir, args[0]))
+	} else {
+		commits, err = logManager.GetCommitHistory()
+	}
 	if err != nil {
 		printError(fmt.Sprintf("loading commit history: %v", err))
 		os.Exit(1)
 	}
 
+	var since time.Time
+	if sinceFlag, _ := cmd.Flags().GetString("since"); sinceFlag != "" {
+		if since, err = parseSince(sinceFlag); err != nil {
+			printError(err.Error())
+			exit(ExitUsage)
+		}
+	}
+
+	// Skew and effective times compare each commit with its parent, so use the whole history
+	history := commits
+	if len(args) == 1 {
+		history, _ = logManager.GetCommitHistory()
+	}
+	skewed := make(map[int]log.ClockSkew)
+	for _, skew := range log.FindClockSkew(history) {
+		skewed[skew.Version] = skew
+	}
+	if !since.IsZero() {
+		effective := log.EffectiveTimes(history)
+		var recent []*log.Commit
+		for _, c := range commits {
+			if !effective[c.Version].Before(since) {
+				recent = append(recent, c)
+			}
+		}
+		commits = recent
+	}
+
+	includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+	hiddenSet := hidden.NewHiddenManager(dgitDir).Versions()
+	var visible []*log.Commit
+	for _, c := range commits {
+		if includeHidden || !hiddenSet[c.Version] {
+			visible = append(visible, c)
+		}
+	}
+	hiddenCount := len(commits) - len(visible)
+	commits = visible
+
+	number, _ := cmd.Flags().GetInt("number")
+	if number > 0 && number < len(commits) {
+		commits = commits[:number]
+	}
+	if ciMode {
+		printLogResult(commits, hiddenSet, skewed)
+		return
+	}
+
+	if len(commits) == 0 && hiddenCount > 0 {
+		fmt.Printf("All %d matching versions are hidden.\n", hiddenCount)
+		printSuggestion("Show them with 'dgit log --include-hidden'")
+		return
+	}
+	if len(commits) == 0 && !since.IsZero() {
+		fmt.Println("No commits in that period.")
+		return
+	}
+	if len(commits) == 0 && len(args) == 1 {
+		fmt.Printf("No commits touch %s.\n", args[0])
+		return
+	}
 	if len(commits) == 0 {
 		fmt.Println("No commits yet.")
 		printInfo("Use 'dgit add' and 'dgit commit' to create your first commit.")
@@ -48,21 +131,26 @@ func runLog(cmd *cobra.Command, _ []string) {
 	}
 
 	oneline, _ := cmd.Flags().GetBool("oneline")
-	number, _ := cmd.Flags().GetInt("number")
-
-	if number > 0 && number < len(commits) {
-		commits = commits[:number]
-	}
 
 	fmt.Printf("Commit History (%d commits)\n\n", len(commits))
 
 	for i, c := range commits {
+		hiddenMark := ""
+		if hiddenSet[c.Version] {
+			hiddenMark = yellow(" [hidden]")
+		}
+		if _, ok := skewed[c.Version]; ok {
+			hiddenMark += yellow(" [clock skew]")
+		}
 		if oneline {
-			fmt.Printf("%s (v%d) %s\n", c.Hash[:8], c.Version, c.Message)
+			fmt.Printf("%s (v%d)%s %s\n", abbrevHash(c.Hash), c.Version, hiddenMark, c.Message)
 		} else {
-			fmt.Printf("commit %s (v%d)\n", c.Hash[:12], c.Version)
+			fmt.Printf("commit %s (v%d)%s\n", abbrevHash(c.Hash), c.Version, hiddenMark)
 			fmt.Printf("Author: %s\n", c.Author)
-			fmt.Printf("Date: %s\n", c.Timestamp.Format("Mon Jan 2 15:04:05 2006"))
+			fmt.Printf("Date: %s\n", formatCommitDate(c))
+			if skew, ok := skewed[c.Version]; ok {
+				fmt.Printf("    %s\n", yellow(describeClockSkew(skew)))
+			}
 			fmt.Printf("\n    %s\n", c.Message)
 
 			if c.FilesCount > 0 {
@@ -77,6 +165,9 @@ func runLog(cmd *cobra.Command, _ []string) {
 					fmt.Printf("    %s\n", summary)
 				}
 			}
+			for newPath, oldPath := range c.Renamed {
+				fmt.Printf("    Renamed: %s -> %s\n", oldPath, newPath)
+			}
 
 			if i < len(commits)-1 {
 				fmt.Println()
@@ -85,4 +176,45 @@ func runLog(cmd *cobra.Command, _ []string) {
 	}
 
 	fmt.Printf("\nTotal: %d commits in history\n", len(commits))
+	if hiddenCount > 0 {
+		printInfo(fmt.Sprintf("%d hidden version(s) not shown; use --include-hidden to list them", hiddenCount))
+	}
+}
+
+// formatCommitDate shows a commit's time in the local timezone, adding the author's
+// local time when they committed from another timezone
+func formatCommitDate(c *log.Commit) string {
+	local := c.Timestamp.Local()
+	date := local.Format("Mon Jan 2 15:04:05 2006")
+	author := c.AuthorTime()
+	_, localOffset := local.Zone()
+	if _, authorOffset := author.Zone(); authorOffset != localOffset {
+		date += fmt.Sprintf(" (author's time %s %s)", author.Format("15:04"), log.ZoneOffset(author))
+	}
+	return date
+}
+
+// describeClockSkew explains a commit timestamped before its parent
+func describeClockSkew(skew log.ClockSkew) string {
+	return fmt.Sprintf("timestamped %s before its parent v%d; the author's clock was behind",
+		skew.Behind().Round(time.Second), skew.Parent)
+}
+
+// printLogResult prints the listed commits as JSON for CI mode
+func printLogResult(commits []*log.Commit, hiddenSet map[int]bool, skewed map[int]log.ClockSkew) {
+	entries := []map[string]interface{}{}
+	for _, c := range commits {
+		_, skew := skewed[c.Version]
+		entries = append(entries, map[string]interface{}{
+			"hash":       c.Hash,
+			"version":    c.Version,
+			"message":    c.Message,
+			"author":     c.Author,
+			"timestamp":  c.Timestamp,
+			"files":      c.FilesCount,
+			"hidden":     hiddenSet[c.Version],
+			"clock_skew": skew,
+		})
+	}
+	ciResult(entries)
 }
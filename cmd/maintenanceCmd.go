@@ -0,0 +1,520 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dgit/internal/generation"
+	initializer "dgit/internal/init"
+	"dgit/internal/maintenance"
+	"dgit/internal/metapack"
+	"dgit/internal/mirror"
+	"dgit/internal/queue"
+	"dgit/internal/replica"
+
+	"github.com/spf13/cobra"
+)
+
+// MaintenanceCmd groups repository housekeeping tasks
+var MaintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Run repository housekeeping tasks",
+	Long: `Run repository housekeeping tasks.
+
+Examples:
+  dgit maintenance pack-metadata       # Pack loose commit JSONs into Zstd batches
+  dgit maintenance invalidate-caches   # Force every index and cache to rebuild
+  dgit maintenance demote --dry-run    # What would be demoted if disk space ran low
+  dgit maintenance gc --dry-run        # Objects no commit references
+  dgit maintenance log                 # What past maintenance reclaimed and sped up`,
+}
+
+// packMetadataCmd packs loose commit records
+var packMetadataCmd = &cobra.Command{
+	Use:   "pack-metadata",
+	Short: "Pack commit metadata JSONs into compressed batches",
+	Long: `Pack loose commit metadata files (.dgit/commits/vN.json) into batched,
+Zstd-compressed pack files with an index. Packed history is read transparently
+by log, show, status and restore, and reduces inode count for repositories with
+many commits.
+
+Set "metadata.pack_enabled" in .dgit/config to pack automatically after commits.`,
+	Args: cobra.NoArgs,
+	Run:  runPackMetadata,
+}
+
+// invalidateCachesCmd bumps the repository generation
+var invalidateCachesCmd = &cobra.Command{
+	Use:   "invalidate-caches",
+	Short: "Mark every index and cache as stale",
+	Long: `Advance the repository generation so every derived index and cache
+(commit pack index, access index, cached version objects) is rebuilt on next use.
+
+History-rewriting operations do this automatically; run it manually after
+editing .dgit by hand or restoring it from a backup.`,
+	Args: cobra.NoArgs,
+	Run:  runInvalidateCaches,
+}
+
+// demoteCmd frees disk space by moving hot storage to the archive tier
+var demoteCmd = &cobra.Command{
+	Use:   "demote",
+	Short: "Free disk space by demoting LZ4 snapshots to the Zstd archive",
+	Long: `Free disk space when the volume holding the repository runs low.
+
+Cached copies of versions that are stored elsewhere are dropped first. Then LZ4
+snapshots are recompressed into the Zstd archive tier, least recently restored
+first, until free space is back above the threshold. The checked-out version
+stays hot because the next commit is stored as a delta against it. Demoted
+versions remain fully restorable, only slower to read.
+
+The threshold is "compression.archive_stage.low_space_threshold_mb" in
+.dgit/config (default 10 GB). With "demote_on_low_space" set, this runs
+automatically after each restore while space is low.`,
+	Args: cobra.NoArgs,
+	Run:  runDemote,
+}
+
+// gcCmd removes objects no commit references
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove stored objects that no commit references",
+	Long: `Remove objects left behind by interrupted commits, rolled-back operations or
+manual edits, which no version needs.
+
+Garbage collection is mark and sweep. Every commit is a root, including hidden
+and tagged versions: each version's own objects are marked in every storage
+tier, along with everything a restore of it reads (delta bases and rebased
+snapshots). Every object file in the storage tiers and the restore cache that
+nothing marked is unreachable. If any version's objects cannot be resolved, the
+pass stops before deleting anything.
+
+Objects written within the grace period (default 1h) are kept even when
+unreachable, since a commit or restore in progress writes objects before its
+record exists. Use --dry-run to list exactly what would be removed.`,
+	Args: cobra.NoArgs,
+	Run:  runGC,
+}
+
+// maintenanceLogCmd prints compaction reports
+var maintenanceLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show before/after reports of past maintenance runs",
+	Long: `Show what each maintenance run changed in storage: objects removed, bytes
+reclaimed, delta chains shortened and the estimated effect on checkout time.
+
+Reports are recorded whenever archiving, pruning, demotion, metadata packing or
+the daemon's hourly maintenance changes storage, and are kept in
+.dgit/metrics/maintenance.jsonl. Checkout estimates come from a simple model of
+decompression and delta replay costs; compare them with each other rather than
+with wall-clock timings.`,
+	Args: cobra.NoArgs,
+	Run:  runMaintenanceLog,
+}
+
+func init() {
+	maintenanceLogCmd.Flags().IntP("limit", "n", 10, "Show the most recent N reports (0 for all)")
+	maintenanceLogCmd.Flags().Bool("json", false, "Output in JSON format")
+	packMetadataCmd.Flags().Int("batch-size", metapack.DefaultBatchSize, "Maximum commit records per pack file")
+	demoteCmd.Flags().Bool("dry-run", false, "List what would be demoted without changing anything")
+	demoteCmd.Flags().Bool("force", false, "Demote everything possible even if disk space is not low")
+	demoteCmd.Flags().Bool("json", false, "Output in JSON format")
+	gcCmd.Flags().Bool("dry-run", false, "List unreachable objects without removing them")
+	gcCmd.Flags().Duration("grace", maintenance.DefaultGCGrace, "Keep unreachable objects written more recently than this")
+	gcCmd.Flags().Bool("json", false, "Output in JSON format")
+	MaintenanceCmd.AddCommand(packMetadataCmd)
+	MaintenanceCmd.AddCommand(invalidateCachesCmd)
+	MaintenanceCmd.AddCommand(demoteCmd)
+	MaintenanceCmd.AddCommand(gcCmd)
+	MaintenanceCmd.AddCommand(maintenanceLogCmd)
+}
+
+// runDemote demotes storage while disk space is low, or lists what it would demote
+func runDemote(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	maintenanceManager := maintenance.NewMaintenanceManager(dgitDir)
+	disk := maintenanceManager.DiskStatus()
+
+	if dryRun {
+		candidates, err := maintenanceManager.PressureCandidates()
+		if err != nil {
+			printError(fmt.Sprintf("analyzing storage: %v", err))
+			os.Exit(1)
+		}
+		if jsonOutput {
+			ciResult(map[string]interface{}{"disk": disk, "candidates": candidates})
+			return
+		}
+		printDiskStatus(disk)
+		if len(candidates) == 0 {
+			fmt.Println("Nothing to demote: no cached copies or LZ4 snapshots besides the checked-out version.")
+			return
+		}
+		fmt.Println("Would demote, in order:")
+		for _, c := range candidates {
+			fmt.Printf("  %-10s v%-5d %10s  %s\n", c.Kind, c.Version, formatBytes(c.Size), c.Reason)
+		}
+		return
+	}
+
+	if !jsonOutput {
+		printDiskStatus(disk)
+		if !force && !disk.Low() {
+			fmt.Println("Disk space is above the threshold; nothing to do (use --force to demote anyway).")
+			return
+		}
+	}
+
+	var result *maintenance.PressureResult
+	report, err := maintenanceManager.Track("demote", func() (err error) {
+		result, err = maintenanceManager.RelieveDiskPressure(force)
+		return err
+	})
+	if jsonOutput {
+		ciResult(result)
+	}
+	if err != nil {
+		printError(fmt.Sprintf("demoting storage: %v", err))
+		os.Exit(1)
+	}
+	if !jsonOutput {
+		printPressureResult(result)
+		printCompactionSummary(report, nil)
+	}
+	if len(result.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// runGC removes unreachable objects, or lists them
+func runGC(cmd *cobra.Command, _ []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	dgitDir := locateRepository()
+	if dryRun {
+		reportRepository(dgitDir)
+	} else {
+		recoverRepository(dgitDir)
+	}
+	checkNotMirror(dgitDir)
+	// A replica holds only the objects it fetched; the shared repository owns the rest
+	if _, isReplica := replica.IsReplica(dgitDir); isReplica {
+		exitWithError("garbage collection runs in the shared repository, not in a replica",
+			"Shrink the local object cache with 'dgit replica prune'")
+	}
+	checkNoBatch(dgitDir)
+	if queue.NewQueueManager(dgitDir).WorkerRunning() {
+		exitWithCode(ExitConflict, "background commits are being stored", "Wait for 'dgit queue' to finish")
+	}
+	// A commit finishing mid-sweep would have its new objects collected
+	if !dryRun {
+		lock := lockRepository(dgitDir, "gc")
+		defer lock.Release()
+	}
+
+	grace, _ := cmd.Flags().GetDuration("grace")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	opts := maintenance.GCOptions{DryRun: dryRun, Grace: grace}
+
+	maintenanceManager := maintenance.NewMaintenanceManager(dgitDir)
+	var result *maintenance.GCResult
+	var report *maintenance.CompactionReport
+	var err error
+	if dryRun {
+		result, err = maintenanceManager.GC(opts)
+	} else {
+		report, err = maintenanceManager.Track("gc", func() (err error) {
+			result, err = maintenanceManager.GC(opts)
+			return err
+		})
+	}
+	if result == nil {
+		printError(fmt.Sprintf("collecting garbage: %v", err))
+		printSuggestion("Run 'dgit doctor' to find missing objects or unreadable commits")
+		exit(ExitError)
+	}
+	if jsonOutput || ciMode {
+		ciResult(result)
+	} else {
+		printGCResult(dgitDir, result)
+		if !dryRun {
+			printCompactionSummary(report, nil)
+		}
+	}
+	if err != nil {
+		printError(err.Error())
+		exit(ExitError)
+	}
+	if len(result.Failed) > 0 {
+		exit(ExitError)
+	}
+}
+
+// printGCResult lists unreachable objects and what was removed
+func printGCResult(dgitDir string, result *maintenance.GCResult) {
+	storageDir := initializer.GetStorageDir(dgitDir)
+	display := func(path string) string {
+		for _, root := range []string{storageDir, dgitDir} {
+			if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.ToSlash(rel)
+			}
+		}
+		return path
+	}
+
+	fmt.Printf("Marked %d reachable object(s) from %d commit(s); %d object(s) scanned\n",
+		result.Reachable, result.Commits, result.Scanned)
+	if len(result.Unreachable) > 0 {
+		if result.DryRun {
+			fmt.Println("Would remove:")
+		} else {
+			fmt.Println("Unreachable:")
+		}
+		var total int64
+		for _, s := range result.Unreachable {
+			total += s.Size
+			fmt.Printf("  %-40s %10s  %s\n", display(s.Path), formatBytes(s.Size), s.Reason)
+		}
+		if result.DryRun {
+			fmt.Printf("  Total: %d object(s), %s\n", len(result.Unreachable), formatBytes(total))
+		}
+	}
+	if len(result.Recent) > 0 {
+		printInfo(fmt.Sprintf("%d unreachable object(s) written in the grace period were kept (see --grace)", len(result.Recent)))
+	}
+	for _, failure := range result.Failed {
+		printWarning(failure)
+	}
+
+	switch {
+	case len(result.Unreachable) == 0:
+		printSuccess("No unreachable objects")
+	case result.DryRun:
+		printSuggestion("Run 'dgit maintenance gc' to remove them")
+	default:
+		printSuccess(fmt.Sprintf("Removed %d unreachable object(s), reclaimed %s", result.Removed, formatBytes(result.Reclaimed)))
+	}
+}
+
+// relieveDiskPressure demotes storage after a restore when the disk is low and the
+// repository allows it; failures only warn because the restore itself succeeded
+func relieveDiskPressure(dgitDir string) {
+	if _, isMirror := mirror.IsMirror(dgitDir); isMirror {
+		return
+	}
+	// A replica's objects are a cache; the shared repository owns the archive tier
+	if _, isReplica := replica.IsReplica(dgitDir); isReplica {
+		return
+	}
+	maintenanceManager := maintenance.NewMaintenanceManager(dgitDir)
+	if !maintenanceManager.DemoteOnLowSpace() || !maintenanceManager.DiskStatus().Low() {
+		return
+	}
+
+	printWarning("Disk space is low; demoting least recently restored versions to the archive")
+	var result *maintenance.PressureResult
+	_, err := maintenanceManager.Track("demote (low disk space)", func() (err error) {
+		result, err = maintenanceManager.RelieveDiskPressure(false)
+		return err
+	})
+	if err != nil {
+		printWarning(fmt.Sprintf("demoting storage: %v", err))
+		return
+	}
+	printPressureResult(result)
+}
+
+// printDiskStatus shows free space against the low-space threshold
+func printDiskStatus(disk *maintenance.DiskStatus) {
+	if !disk.Known() {
+		fmt.Println("Free space: unknown on this platform")
+		return
+	}
+	state := green("ok")
+	if disk.Low() {
+		state = yellow("low")
+	}
+	fmt.Printf("Free space: %s of %s (threshold %s) - %s\n",
+		formatBytes(disk.Free), formatBytes(disk.Total), formatBytes(disk.Threshold), state)
+}
+
+// printPressureResult reports what a demotion pass did
+func printPressureResult(result *maintenance.PressureResult) {
+	for _, s := range result.Applied {
+		switch s.Kind {
+		case maintenance.KindArchive:
+			fmt.Printf("  Archived v%d (%s)\n", s.Version, s.Reason)
+		case maintenance.KindDropCache:
+			fmt.Printf("  Dropped cached copy of v%d\n", s.Version)
+		}
+	}
+	for _, failure := range result.Failed {
+		printWarning(failure)
+	}
+	if len(result.Applied) == 0 {
+		fmt.Println("Nothing left to demote.")
+		return
+	}
+
+	printSuccess(fmt.Sprintf("Demoted %d item(s), reclaimed %s", len(result.Applied), formatBytes(result.Reclaimed)))
+	if result.After.Low() {
+		printSuggestion("Still below the free space threshold; free space elsewhere or move storage with 'dgit relocate'")
+	}
+}
+
+// runInvalidateCaches bumps the generation
+func runInvalidateCaches(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+
+	current, err := generation.Bump(dgitDir, "manual invalidation")
+	if err != nil {
+		printError(fmt.Sprintf("invalidating caches: %v", err))
+		os.Exit(1)
+	}
+	printSuccess(fmt.Sprintf("Repository generation is now %d; caches will rebuild on next use", current))
+}
+
+// runPackMetadata packs all loose commit records
+func runPackMetadata(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+
+	if err := initializer.EnsureFormatVersion(dgitDir); err != nil {
+		printError(fmt.Sprintf("recording repository format: %v", err))
+		os.Exit(1)
+	}
+
+	store := metapack.NewStore(filepath.Join(dgitDir, "commits"))
+	var result *metapack.PackResult
+	_, err := maintenance.NewMaintenanceManager(dgitDir).Track("pack-metadata", func() (err error) {
+		result, err = store.Pack(batchSize)
+		return err
+	})
+	if err != nil {
+		printError(fmt.Sprintf("packing metadata: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"records_packed": result.RecordsPacked,
+			"packs_written":  result.PacksWritten,
+			"loose_bytes":    result.LooseBytes,
+			"packed_bytes":   result.PackedBytes,
+		})
+		return
+	}
+	if result.RecordsPacked == 0 {
+		fmt.Println("No loose commit records to pack.")
+		return
+	}
+
+	printSuccess(fmt.Sprintf("Packed %d commit records into %d packs", result.RecordsPacked, result.PacksWritten))
+	fmt.Printf("Metadata size: %s → %s\n", formatBytes(result.LooseBytes), formatBytes(result.PackedBytes))
+}
+
+// runMaintenanceLog prints recorded compaction reports, newest first
+func runMaintenanceLog(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	limit, _ := cmd.Flags().GetInt("limit")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	reports, err := maintenance.NewMaintenanceManager(dgitDir).Reports()
+	if err != nil {
+		printError(fmt.Sprintf("reading maintenance log: %v", err))
+		os.Exit(1)
+	}
+	if limit > 0 && len(reports) > limit {
+		reports = reports[len(reports)-limit:]
+	}
+	if jsonOutput {
+		ciResult(reports)
+		return
+	}
+	if len(reports) == 0 {
+		fmt.Println("No maintenance has changed storage yet.")
+		printSuggestion("See what could be reclaimed with 'dgit stats'")
+		return
+	}
+
+	for i := len(reports) - 1; i >= 0; i-- {
+		report := reports[i]
+		fmt.Printf("%s  %s (%.1fs)\n", cyan(report.Time.Format("2006-01-02 15:04")), report.Operation, report.Duration.Seconds())
+		printCompactionReport(report)
+		if report.Error != "" {
+			fmt.Printf("  %s %s\n", yellow("stopped early:"), report.Error)
+		}
+		fmt.Println()
+	}
+}
+
+// printCompactionReport prints the before/after details of one report
+func printCompactionReport(report *maintenance.CompactionReport) {
+	before, after := report.Before, report.After
+	fmt.Printf("  Objects:   %d → %d (%d removed, %d added)\n", before.Objects, after.Objects, report.ObjectsRemoved, report.ObjectsAdded)
+	fmt.Printf("  Storage:   %s → %s (%s)\n", formatBytes(before.ObjectBytes+before.MetadataBytes),
+		formatBytes(after.ObjectBytes+after.MetadataBytes), describeReclaimed(report.BytesReclaimed))
+	if before.MetadataFiles != after.MetadataFiles {
+		fmt.Printf("  Metadata:  %d → %d files\n", before.MetadataFiles, after.MetadataFiles)
+	}
+	if report.ChainsShortened > 0 || before.LongestChain != after.LongestChain {
+		fmt.Printf("  Chains:    %d shortened, longest %d → %d steps\n", report.ChainsShortened, before.LongestChain, after.LongestChain)
+	}
+	if before.EstimatedCheckout > 0 && after.EstimatedCheckout > 0 {
+		fmt.Printf("  Checkout:  ~%s → ~%s per version (%s)\n", formatEstimate(before.EstimatedCheckout),
+			formatEstimate(after.EstimatedCheckout), describeSpeedup(report.CheckoutSpeedup))
+	}
+}
+
+// printCompactionSummary prints a one-line summary after a maintenance operation
+func printCompactionSummary(report *maintenance.CompactionReport, err error) {
+	if err != nil {
+		printWarning(fmt.Sprintf("recording maintenance report: %v", err))
+	}
+	if report == nil || !report.Changed() {
+		return
+	}
+	summary := fmt.Sprintf("Storage %s, %d object(s) removed", describeReclaimed(report.BytesReclaimed), report.ObjectsRemoved)
+	if report.ChainsShortened > 0 {
+		summary += fmt.Sprintf(", %d chain(s) shortened", report.ChainsShortened)
+	}
+	if report.CheckoutSpeedup > 0 {
+		summary += ", checkouts " + describeSpeedup(report.CheckoutSpeedup)
+	}
+	printInfo(summary)
+	printSuggestion("Details: 'dgit maintenance log'")
+}
+
+// describeReclaimed words a byte delta as reclaimed or grown
+func describeReclaimed(bytes int64) string {
+	if bytes < 0 {
+		return formatBytes(-bytes) + " grown"
+	}
+	return formatBytes(bytes) + " reclaimed"
+}
+
+// describeSpeedup words an estimated checkout speedup ratio
+func describeSpeedup(speedup float64) string {
+	switch {
+	case speedup >= 1.05:
+		return green(fmt.Sprintf("%.1fx faster", speedup))
+	case speedup > 0 && speedup <= 0.95:
+		return yellow(fmt.Sprintf("%.1fx slower", 1/speedup))
+	}
+	return "unchanged"
+}
+
+// formatEstimate rounds an estimated duration for display
+func formatEstimate(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
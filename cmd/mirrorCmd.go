@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"dgit/internal/mirror"
+	"dgit/internal/replica"
+
+	"github.com/spf13/cobra"
+)
+
+// MirrorCmd keeps read-only replicas of the repository on other volumes
+var MirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Keep verified read-only replicas of the repository for disaster recovery",
+	Long: `Maintain full read-only copies of this repository (objects, commit metadata and
+HEAD) on a NAS, mounted bucket or second disk.
+
+Syncs are incremental: only files that changed since the last sync are copied, and
+every copy is verified by SHA256. Objects are copied before the metadata and HEAD
+that reference them, so an interrupted sync never leaves a mirror pointing at data
+it does not have. 'dgit restore' works directly inside a mirror; commands that
+would write to it are refused.
+
+Examples:
+  dgit mirror add /Volumes/NAS/projectX        # Register and run the first sync
+  dgit mirror sync                             # Bring every mirror up to date
+  dgit mirror verify                           # Re-hash both sides and compare
+  dgit mirror sync --full projectX             # Recopy everything after a failed verify
+  dgit mirror list                             # Mirrors and when they were synced
+  dgit mirror remove projectX                  # Stop mirroring (files are kept)`,
+}
+
+var mirrorAddCmd = &cobra.Command{
+	Use:   "add <path>",
+	Short: "Register a mirror location and sync it",
+	Args:  cobra.ExactArgs(1),
+	Run:   runMirrorAdd,
+}
+
+var mirrorSyncCmd = &cobra.Command{
+	Use:   "sync [name|path...]",
+	Short: "Copy new and changed data to mirrors (all when none are named)",
+	Run:   runMirrorSync,
+}
+
+var mirrorVerifyCmd = &cobra.Command{
+	Use:   "verify [name|path...]",
+	Short: "Compare mirrors against the repository by SHA256",
+	Run:   runMirrorVerify,
+}
+
+var mirrorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List mirrors",
+	Args:  cobra.NoArgs,
+	Run:   runMirrorList,
+}
+
+var mirrorRemoveCmd = &cobra.Command{
+	Use:   "remove <name|path>",
+	Short: "Unregister a mirror without deleting its files",
+	Args:  cobra.ExactArgs(1),
+	Run:   runMirrorRemove,
+}
+
+func init() {
+	mirrorAddCmd.Flags().String("name", "", "Name for the mirror (default: directory name)")
+	mirrorSyncCmd.Flags().BoolP("verbose", "v", false, "List each copied file")
+	mirrorSyncCmd.Flags().Bool("verify", false, "Verify each mirror after syncing")
+	mirrorSyncCmd.Flags().Bool("full", false, "Recopy every file, repairing copies that fail verification")
+
+	MirrorCmd.AddCommand(mirrorAddCmd)
+	MirrorCmd.AddCommand(mirrorSyncCmd)
+	MirrorCmd.AddCommand(mirrorVerifyCmd)
+	MirrorCmd.AddCommand(mirrorListCmd)
+	MirrorCmd.AddCommand(mirrorRemoveCmd)
+}
+
+// checkNotMirror refuses to modify a read-only mirror or replica
+func checkNotMirror(dgitDir string) {
+	if marker, ok := mirror.IsMirror(dgitDir); ok {
+		exitWithError(fmt.Sprintf("this repository is a read-only mirror of %s", marker.Source),
+			"Make changes in the original repository; they reach the mirror on its next sync")
+	}
+	if marker, ok := replica.IsReplica(dgitDir); ok {
+		exitWithError(fmt.Sprintf("this repository is a read-only replica of %s", filepath.Dir(marker.Source)),
+			"Make changes in the shared repository; replicas pick them up on the next restore or 'dgit replica refresh'")
+	}
+}
+
+// runMirrorAdd registers a mirror and runs its first sync
+func runMirrorAdd(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	name, _ := cmd.Flags().GetString("name")
+
+	manager := mirror.NewMirrorManager(dgitDir)
+	m, err := manager.Add(args[0], name)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	if !ciMode {
+		fmt.Printf("Mirroring to %s...\n", m.Path)
+	}
+	if !syncMirror(manager, m, false) {
+		printSuggestion(fmt.Sprintf("The mirror is registered; retry with 'dgit mirror sync %s'", m.Name))
+		os.Exit(1)
+	}
+}
+
+// runMirrorSync syncs the named mirrors, or all of them
+func runMirrorSync(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	verify, _ := cmd.Flags().GetBool("verify")
+
+	manager := mirror.NewMirrorManager(dgitDir)
+	manager.Full, _ = cmd.Flags().GetBool("full")
+	mirrors := selectMirrors(manager, args)
+
+	failed := 0
+	for _, m := range mirrors {
+		if !ciMode {
+			fmt.Printf("Syncing mirror '%s' (%s)...\n", m.Name, m.Path)
+		}
+		if !syncMirror(manager, m, verbose) {
+			failed++
+			continue
+		}
+		if verify && !verifyMirror(manager, m) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runMirrorVerify verifies the named mirrors, or all of them
+func runMirrorVerify(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+	manager := mirror.NewMirrorManager(dgitDir)
+
+	failed := 0
+	for _, m := range selectMirrors(manager, args) {
+		if !ciMode {
+			fmt.Printf("Verifying mirror '%s' (%s)...\n", m.Name, m.Path)
+		}
+		if !verifyMirror(manager, m) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		exit(ExitVerifyFailed)
+	}
+}
+
+// runMirrorList prints registered mirrors
+func runMirrorList(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+
+	mirrors, err := mirror.NewMirrorManager(dgitDir).List()
+	if err != nil {
+		printError(fmt.Sprintf("reading mirrors: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		entries := []map[string]interface{}{}
+		for _, m := range mirrors {
+			_, err := os.Stat(m.Path)
+			entries = append(entries, map[string]interface{}{"mirror": m, "available": err == nil})
+		}
+		ciResult(entries)
+		return
+	}
+	if len(mirrors) == 0 {
+		fmt.Println("No mirrors. Add one with 'dgit mirror add <path>'.")
+		return
+	}
+
+	for _, m := range mirrors {
+		synced := yellow("never synced")
+		if !m.LastSync.IsZero() {
+			synced = fmt.Sprintf("synced %s (v%d)", m.LastSync.Local().Format("2006-01-02 15:04"), m.LastVersion)
+		}
+		verified := ""
+		if !m.LastVerify.IsZero() {
+			verified = fmt.Sprintf(", verified %s", m.LastVerify.Local().Format("2006-01-02 15:04"))
+		}
+		state := ""
+		if _, err := os.Stat(m.Path); err != nil {
+			state = yellow("  [unavailable]")
+		}
+		fmt.Printf("%-16s  %s  %s%s%s\n", m.Name, m.Path, synced, verified, state)
+	}
+}
+
+// runMirrorRemove unregisters a mirror
+func runMirrorRemove(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	manager := mirror.NewMirrorManager(dgitDir)
+
+	m, err := manager.Find(args[0])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if err := manager.Remove(m); err != nil {
+		printError(fmt.Sprintf("removing mirror: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"removed": m.Name, "path": m.Path})
+		return
+	}
+	printSuccess(fmt.Sprintf("Stopped mirroring to '%s'; files at %s were kept", m.Name, m.Path))
+}
+
+// selectMirrors resolves mirror arguments, defaulting to every registered mirror
+func selectMirrors(manager *mirror.MirrorManager, args []string) []*mirror.Mirror {
+	if len(args) == 0 {
+		mirrors, err := manager.List()
+		if err != nil {
+			printError(fmt.Sprintf("reading mirrors: %v", err))
+			os.Exit(1)
+		}
+		if len(mirrors) == 0 {
+			exitWithError("no mirrors configured", "Add one with 'dgit mirror add <path>'")
+		}
+		return mirrors
+	}
+
+	var mirrors []*mirror.Mirror
+	for _, arg := range args {
+		m, err := manager.Find(arg)
+		if err != nil {
+			exitWithCode(ExitNotFound, err.Error(), "List mirrors with 'dgit mirror list'")
+		}
+		mirrors = append(mirrors, m)
+	}
+	return mirrors
+}
+
+// syncMirror runs one sync and prints its outcome
+func syncMirror(manager *mirror.MirrorManager, m *mirror.Mirror, verbose bool) bool {
+	manager.Progress = nil
+	if verbose && !ciMode {
+		manager.Progress = func(relPath string, size int64) {
+			fmt.Printf("  copied %s (%s)\n", filepath.ToSlash(relPath), formatBytes(size))
+		}
+	}
+
+	result, err := manager.Sync(m)
+	if err != nil {
+		printError(fmt.Sprintf("mirror '%s': %v", m.Name, err))
+		return false
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"mirror":      m.Name,
+			"version":     result.Version,
+			"copied":      result.Copied,
+			"bytes":       result.Bytes,
+			"unchanged":   result.Unchanged,
+			"pruned":      result.Pruned,
+			"duration_ms": result.Duration.Milliseconds(),
+		})
+		return true
+	}
+	printSuccess(fmt.Sprintf("Mirror '%s' at v%d: %d file(s) copied (%s), %d unchanged, %d pruned in %s",
+		m.Name, result.Version, result.Copied, formatBytes(result.Bytes), result.Unchanged, result.Pruned,
+		result.Duration.Round(time.Millisecond)))
+	return true
+}
+
+// verifyMirror runs one verification and prints any differences
+func verifyMirror(manager *mirror.MirrorManager, m *mirror.Mirror) bool {
+	result, err := manager.Verify(m)
+	if err != nil {
+		printError(fmt.Sprintf("mirror '%s': %v", m.Name, err))
+		return false
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"mirror":     m.Name,
+			"ok":         result.OK(),
+			"checked":    result.Checked,
+			"missing":    result.Missing,
+			"mismatched": result.Mismatched,
+			"extra":      result.Extra,
+		})
+		return result.OK()
+	}
+	if result.OK() {
+		printSuccess(fmt.Sprintf("Mirror '%s' matches: %d file(s) verified", m.Name, result.Checked))
+		return true
+	}
+
+	printError(fmt.Sprintf("mirror '%s' differs from the repository", m.Name))
+	for _, path := range result.Missing {
+		fmt.Printf("  missing:    %s\n", path)
+	}
+	for _, path := range result.Mismatched {
+		fmt.Printf("  mismatched: %s\n", path)
+	}
+	for _, path := range result.Extra {
+		fmt.Printf("  extra:      %s\n", path)
+	}
+	printSuggestion(fmt.Sprintf("Run 'dgit mirror sync --full %s' to repair it", m.Name))
+	return false
+}
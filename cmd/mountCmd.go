@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"dgit/internal/mount"
+
+	"github.com/spf13/cobra"
+)
+
+// MountCmd exposes history as browsable folders
+var MountCmd = &cobra.Command{
+	Use:   "mount [dir]",
+	Short: "Browse every version as a read-only folder (history/v12/hero.psd)",
+	Long: `Expose the repository's history as read-only folders, one per version, so old
+versions can be opened or dragged straight into Photoshop or Illustrator from
+Finder or Explorer without checking anything out.
+
+  history/v11/hero.psd
+  history/v12/hero.psd
+  history/v12/exports/banner.ai
+
+History is served as a WebDAV share on this machine only (127.0.0.1), which
+file managers connect to without extra drivers. On macOS the share is mounted
+at [dir] automatically; on Linux it is mounted with davfs2 when run as root.
+Elsewhere, or without [dir], connect to the printed address yourself:
+
+  macOS     Finder > Go > Connect to Server
+  Windows   Explorer > This PC > Map network drive
+  Linux     the file manager's "Connect to Server" with dav://
+
+Files are restored the first time a version is opened, into a temporary cache
+that is removed when the command stops. Versions hidden with 'dgit hide' are
+left out unless --include-hidden is given. Stop with Ctrl-C.
+
+Examples:
+  dgit mount ./history             # Mount at ./history until Ctrl-C
+  dgit mount --port 8642           # Serve only, on a fixed port
+  dgit mount ./history --include-hidden`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runMount,
+}
+
+func init() {
+	MountCmd.Flags().Int("port", 0, "Port to serve on (default: any free port)")
+	MountCmd.Flags().Bool("include-hidden", false, "Include versions hidden with 'dgit hide'")
+	MountCmd.Flags().Bool("no-mount", false, "Serve the share without mounting it at [dir]")
+}
+
+// runMount serves history until interrupted, mounting it at dir when possible
+func runMount(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+	port, _ := cmd.Flags().GetInt("port")
+	includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+	noMount, _ := cmd.Flags().GetBool("no-mount")
+
+	refreshReplica(dgitDir)
+	cacheDir, err := os.MkdirTemp("", "dgit-mount-")
+	if err != nil {
+		exitWithError(fmt.Sprintf("failed to create cache directory: %v", err), "Check free space in the temporary directory")
+	}
+	defer os.RemoveAll(cacheDir)
+
+	server := mount.NewServer(dgitDir, cacheDir)
+	server.IncludeHidden = includeHidden
+	server.OnMaterialize = func(version int, took time.Duration, err error) {
+		if err != nil {
+			printError(fmt.Sprintf("v%d could not be restored: %v", version, err))
+			return
+		}
+		printInfo(fmt.Sprintf("Restored v%d (%s)", version, took.Round(time.Millisecond)))
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		os.RemoveAll(cacheDir)
+		exitWithError(fmt.Sprintf("cannot listen on port %d: %v", port, err), "Choose another port with --port, or leave it out to use any free port")
+	}
+	url := fmt.Sprintf("http://%s/", listener.Addr())
+	httpServer := &http.Server{Handler: server}
+	go httpServer.Serve(listener)
+
+	if ciMode {
+		ciResult(map[string]interface{}{"serving": url})
+	} else {
+		printSuccess(fmt.Sprintf("Serving history at %s", cyan(url)))
+	}
+
+	var unmount func() error
+	if len(args) == 1 && !noMount {
+		unmount = attachHistory(url, args[0])
+	} else {
+		printMountInstructions(url)
+	}
+	if !ciMode {
+		fmt.Println("Press Ctrl-C to stop")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	if !ciMode {
+		fmt.Println()
+	}
+
+	if unmount != nil {
+		if err := unmount(); err != nil {
+			printWarning(fmt.Sprintf("failed to unmount %s: %v", args[0], err))
+			printSuggestion(fmt.Sprintf("Unmount it with 'umount %s'", args[0]))
+		}
+	}
+	httpServer.Close()
+	printSuccess("Stopped serving history")
+}
+
+// attachHistory mounts the share at dir, falling back to instructions when the
+// platform's client is unavailable
+func attachHistory(url, dir string) func() error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+	created := false
+	if _, err := os.Stat(absDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(absDir, 0755); err != nil {
+			printWarning(fmt.Sprintf("cannot create %s: %v", dir, err))
+			printMountInstructions(url)
+			return nil
+		}
+		created = true
+	}
+	removeCreated := func() {
+		if created {
+			os.Remove(absDir) // Only succeeds while empty
+		}
+	}
+
+	unmount, err := mount.Attach(url, absDir)
+	if err != nil {
+		removeCreated()
+		if !errors.Is(err, mount.ErrNoClient) {
+			printWarning(fmt.Sprintf("could not mount at %s: %v", dir, err))
+		}
+		printMountInstructions(url)
+		return nil
+	}
+	printSuccess(fmt.Sprintf("History mounted at %s", cyan(dir)))
+	return func() error {
+		err := unmount()
+		if err == nil {
+			removeCreated()
+		}
+		return err
+	}
+}
+
+// printMountInstructions explains how to connect to the share from the file manager
+func printMountInstructions(url string) {
+	switch runtime.GOOS {
+	case "darwin":
+		printSuggestion(fmt.Sprintf("In Finder choose Go > Connect to Server and enter %s", url))
+	case "windows":
+		printSuggestion(fmt.Sprintf("In Explorer choose This PC > Map network drive and enter %s", url))
+	default:
+		printSuggestion(fmt.Sprintf("Connect from the file manager with dav://%s", url[len("http://"):]))
+	}
+}
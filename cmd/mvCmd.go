@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dgit/internal/log"
+	"dgit/internal/pathnorm"
+	"dgit/internal/staging"
+
+	"github.com/spf13/cobra"
+)
+
+// MvCmd moves or renames a tracked file while keeping its history
+var MvCmd = &cobra.Command{
+	Use:   "mv <source> <destination>",
+	Short: "Move or rename a file and keep its history",
+	Long: `Move or rename a design file in the working directory and stage the move.
+
+The next commit records the rename in its manifest, so 'dgit log <new path>'
+keeps showing the versions made under the old name. If the destination is an
+existing directory the file keeps its name and is moved into it.
+
+Examples:
+  dgit mv poster.psd final/poster-v2.psd   # Rename and move into final/
+  dgit mv logo.ai archive/                 # Move into an existing directory
+  dgit log final/poster-v2.psd             # History includes poster.psd versions`,
+	Args: cobra.ExactArgs(2),
+	Run:  runMv,
+}
+
+func init() {
+	MvCmd.Flags().BoolP("force", "f", false, "Overwrite the destination if it already exists")
+}
+
+// runMv moves a file on disk and stages the rename
+func runMv(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	checkNoBatch(dgitDir)
+	lock := lockRepository(dgitDir, "mv")
+	defer lock.Release()
+	force, _ := cmd.Flags().GetBool("force")
+
+	source, destination := args[0], args[1]
+	if info, err := os.Stat(destination); err == nil && info.IsDir() {
+		destination = filepath.Join(destination, filepath.Base(source))
+	}
+
+	if _, err := os.Stat(source); err != nil {
+		exitWithError(fmt.Sprintf("cannot move '%s': file not found", source), "")
+	}
+	if _, err := os.Stat(destination); err == nil && !force {
+		exitWithError(fmt.Sprintf("destination '%s' already exists", destination), "Use --force to overwrite it")
+	}
+
+	stagingArea := staging.NewStagingArea(dgitDir)
+	stagingArea.Progress = ciProgress()
+	if err := stagingArea.LoadStaging(); err != nil {
+		printError(fmt.Sprintf("loading staging area: %v", err))
+		os.Exit(1)
+	}
+
+	tracked, err := log.NewLogManager(dgitDir).GetTrackedFiles()
+	if err != nil {
+		printError(fmt.Sprintf("reading tracked files: %v", err))
+		os.Exit(1)
+	}
+
+	oldPath := repositoryPath(dgitDir, source)
+	newPath := repositoryPath(dgitDir, destination)
+	_, wasRenamed := stagingArea.RenamedFrom(oldPath)
+	isTracked := wasRenamed || containsPath(tracked, oldPath)
+
+	if stagingArea.IsRemoved(oldPath) {
+		exitWithError(fmt.Sprintf("'%s' is staged for deletion", source), "")
+	}
+	if !isTracked && !stagingArea.HasFile(source) {
+		exitWithError(fmt.Sprintf("'%s' is not under version control", source), "Stage it first with 'dgit add', or move it with your file manager")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		printError(fmt.Sprintf("creating %s: %v", filepath.Dir(destination), err))
+		os.Exit(1)
+	}
+	if err := os.Rename(source, destination); err != nil {
+		printError(fmt.Sprintf("moving '%s': %v", source, err))
+		os.Exit(1)
+	}
+
+	// The content now lives at the new path; stage it there
+	if stagingArea.HasFile(source) {
+		stagingArea.RemoveFile(source)
+	}
+	if err := stagingArea.AddFile(destination); err != nil {
+		os.Rename(destination, source)
+		exitWithError(fmt.Sprintf("cannot stage '%s': %v", destination, err), "The file was moved back")
+	}
+	if isTracked {
+		stagingArea.StageRename(oldPath, newPath)
+	}
+
+	if err := stagingArea.SaveStaging(); err != nil {
+		printError(fmt.Sprintf("saving staging area: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(map[string]interface{}{"from": oldPath, "to": newPath, "rename_staged": isTracked})
+		return
+	}
+	printSuccess(fmt.Sprintf("Renamed '%s' -> '%s'", oldPath, newPath))
+	if isTracked {
+		printInfo("Run 'dgit commit' to record the move; history follows the file to its new name")
+	}
+}
+
+// repositoryPath converts a command-line path to the form stored in commit metadata
+func repositoryPath(dgitDir, path string) string {
+	relPath := path
+	if absPath, err := filepath.Abs(path); err == nil {
+		if workDir, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(workDir, absPath); err == nil {
+				relPath = rel
+			}
+		}
+	}
+	return pathnorm.LoadPolicy(dgitDir).Apply(relPath)
+}
+
+// containsPath reports whether paths contains path
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"dgit/internal/scaffold"
+	"dgit/internal/staging"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmd creates a new design file from a tracked template
+var NewCmd = &cobra.Command{
+	Use:   "new --from-template <template> <new-file>",
+	Short: "Create a new file from a tracked template",
+	Long: `Create a new design file by copying a committed template, and stage it.
+
+The template version it was copied from is recorded with the new file's metadata
+when it is committed, so you can always tell which deliverables came from which
+version of a master template ('dgit show' lists it per file).
+
+Examples:
+  dgit new --from-template social-post.psd banner_kr.psd
+  dgit new --from-template social-post.psd --template-version v4 banner_jp.psd`,
+	Args: cobra.ExactArgs(2),
+	Run:  runNew,
+}
+
+func init() {
+	NewCmd.Flags().Bool("from-template", false, "Copy the first argument, a tracked template, to the second")
+	NewCmd.Flags().String("template-version", "", "Template version to copy (default: latest version containing it)")
+	NewCmd.MarkFlagRequired("from-template")
+}
+
+// runNew copies a template version and stages the result with its provenance
+func runNew(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	templateVersion, _ := cmd.Flags().GetString("template-version")
+
+	templatePath := repositoryPath(dgitDir, args[0])
+	destination := args[1]
+
+	origin, err := scaffold.NewScaffoldManager(dgitDir).Create(templatePath, templateVersion, destination)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	stagingArea := staging.NewStagingArea(dgitDir)
+	stagingArea.Progress = ciProgress()
+	if err := stagingArea.LoadStaging(); err != nil {
+		printError(fmt.Sprintf("loading staging area: %v", err))
+		os.Exit(1)
+	}
+	if err := stagingArea.AddFile(destination); err != nil {
+		printWarning(fmt.Sprintf("created %s but could not stage it: %v", destination, err))
+		os.Exit(1)
+	}
+	stagingArea.SetTemplate(destination, origin)
+	if err := stagingArea.SaveStaging(); err != nil {
+		printError(fmt.Sprintf("saving staging area: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(map[string]interface{}{"created": destination, "template": origin})
+		return
+	}
+	printSuccess(fmt.Sprintf("Created %s from %s (v%d)", destination, origin.Path, origin.Version))
+	printInfo("The file is staged; its template version is recorded when you commit")
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"dgit/internal/pin"
+
+	"github.com/spf13/cobra"
+)
+
+// PinCmd writes a pinfile mapping asset paths to exact versions
+var PinCmd = &cobra.Command{
+	Use:   "pin [files...]",
+	Short: "Write a pinfile locking assets to exact versions for build pipelines",
+	Long: `Write a pinfile (lockfile) that maps asset paths to the versions, commit hashes
+and content hashes they have in one version of the repository.
+
+Build scripts check the pinfile into their own repository and run
+'dgit materialize --from-pinfile' to fetch exactly those bytes, so websites and
+apps always build against the same asset versions.
+
+Examples:
+  dgit pin                                  # Pin every file in the latest version
+  dgit pin logo.ai hero.psd -o assets.lock  # Pin selected files
+  dgit pin -v v12                           # Pin files as they were in v12`,
+	Run: runPin,
+}
+
+// MaterializeCmd writes the assets named by a pinfile
+var MaterializeCmd = &cobra.Command{
+	Use:   "materialize",
+	Short: "Write the exact asset versions listed in a pinfile",
+	Long: `Restore every file listed in a pinfile into a directory, verifying each against
+its pinned content hash. Files that already match are left untouched, so repeated
+builds only write what changed.
+
+When the destination is the project itself, files about to be replaced are saved
+to the DGit trash first (see 'dgit trash').
+
+Examples:
+  dgit materialize --from-pinfile assets.lock                    # Into the current directory
+  dgit materialize --from-pinfile assets.lock --dest ../site/img # Into a build directory`,
+	Args: cobra.NoArgs,
+	Run:  runMaterialize,
+}
+
+func init() {
+	PinCmd.Flags().StringP("version", "v", "", "Version to pin (default: latest)")
+	PinCmd.Flags().StringP("output", "o", "assets.lock", "Pinfile to write")
+
+	MaterializeCmd.Flags().String("from-pinfile", "", "Pinfile to read")
+	MaterializeCmd.Flags().String("dest", ".", "Directory to write assets into")
+	MaterializeCmd.MarkFlagRequired("from-pinfile")
+}
+
+// runPin writes the pinfile
+func runPin(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	version, _ := cmd.Flags().GetString("version")
+	output, _ := cmd.Flags().GetString("output")
+
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		paths[i] = repositoryPath(dgitDir, arg)
+	}
+
+	lock, err := pin.NewPinManager(dgitDir).Pin(paths, version)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if err := lock.Save(output); err != nil {
+		printError(fmt.Sprintf("writing %s: %v", output, err))
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"pinfile": output, "files": lock.Files})
+		return
+	}
+
+	for _, entry := range lock.Files {
+		fmt.Printf("  %s  v%d  %s\n", entry.Path, entry.Version, shortHash(entry.Hash))
+	}
+	printSuccess(fmt.Sprintf("Pinned %d file(s) to %s", len(lock.Files), output))
+	printInfo(fmt.Sprintf("Build with: dgit materialize --from-pinfile %s", output))
+}
+
+// runMaterialize writes the pinned assets
+func runMaterialize(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+	pinfile, _ := cmd.Flags().GetString("from-pinfile")
+	dest, _ := cmd.Flags().GetString("dest")
+
+	lock, err := pin.Load(pinfile)
+	if err != nil {
+		printError(fmt.Sprintf("reading pinfile: %v", err))
+		exit(exitCode(err))
+	}
+
+	refreshReplica(dgitDir)
+	var versions []int
+	for _, entry := range lock.Files {
+		versions = append(versions, entry.Version)
+	}
+	fetchForReplica(dgitDir, versions...)
+
+	result, err := pin.NewPinManager(dgitDir).Materialize(lock, dest)
+	if ciMode {
+		ciResult(map[string]interface{}{"dest": dest, "written": result.Written, "current": result.Current, "backup": result.Backup})
+		if err != nil {
+			printError(err.Error())
+			exit(exitCode(err))
+		}
+		return
+	}
+	if result != nil {
+		if result.Backup != nil {
+			printInfo(fmt.Sprintf("Replaced files were saved to the trash: dgit trash restore %s", result.Backup.ID))
+		}
+		for _, entry := range result.Written {
+			fmt.Printf("  %s %s (v%d)\n", green("wrote"), entry.Path, entry.Version)
+		}
+	}
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	printSuccess(fmt.Sprintf("Materialized %d file(s) into %s (%d already up to date)", len(result.Written), dest, len(result.Current)))
+}
+
+// shortHash abbreviates a content hash for display
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/plugin"
+
+	"github.com/spf13/cobra"
+)
+
+// PluginCmd lists external commands and shows the context passed to them
+var PluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "List external dgit-<name> commands and the context they receive",
+	Long: `Extend dgit with external commands. Any executable on PATH named dgit-<name>
+runs as 'dgit <name> [args...]' when <name> is not a built-in command, so a
+studio can ship 'dgit deliver' as a dgit-deliver script or binary.
+
+Plugins receive the repository context in environment variables:
+  DGIT_PLUGIN_API    Version of this contract (currently 1)
+  DGIT_PLUGIN_NAME   The plugin's name, e.g. deliver
+  DGIT_EXEC          Path of the dgit executable, for calling back
+  DGIT_DIR           The repository's .dgit directory (unset outside a repository)
+  DGIT_WORK_TREE     The directory containing .dgit
+  DGIT_STORAGE_DIR   Object storage root
+  DGIT_HEAD          Checked-out version number
+  DGIT_HEAD_HASH     Checked-out commit hash
+  DGIT_CI            Set to 1 when dgit runs in CI mode
+
+For structured data, plugins call back into dgit in CI mode: '$DGIT_EXEC --ci
+<command>' prints JSON results on stdout and progress events as JSON lines on
+stderr, and 'dgit plugin context --manifest' prints the context above with the
+files of the checked-out version. The plugin's exit code becomes dgit's.
+
+Examples:
+  dgit plugin list                     # Installed plugins and where they are
+  dgit plugin context --manifest       # What a plugin sees, as JSON
+  dgit deliver --to client-a           # Runs dgit-deliver --to client-a`,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List dgit-<name> executables found on PATH",
+	Args:  cobra.NoArgs,
+	Run:   runPluginList,
+}
+
+var pluginContextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Print the repository context passed to plugins as JSON",
+	Args:  cobra.NoArgs,
+	Run:   runPluginContext,
+}
+
+func init() {
+	pluginListCmd.Flags().Bool("json", false, "Output in JSON format")
+	pluginContextCmd.Flags().Bool("manifest", false, "Include the files of the checked-out version")
+
+	PluginCmd.AddCommand(pluginListCmd)
+	PluginCmd.AddCommand(pluginContextCmd)
+}
+
+// RunPlugin runs an external command when args name no built-in one, exiting with its
+// exit code; it returns false when dgit should handle args itself
+func RunPlugin(root *cobra.Command, args []string) bool {
+	ci := CIRequested()
+	var name string
+	var rest []string
+	for i, arg := range args {
+		if arg == "--ci" || arg == "--ci=true" {
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			// Other root flags (--help, --version) belong to dgit
+			return false
+		}
+		name, rest = arg, args[i+1:]
+		break
+	}
+	if name == "" || name == "help" || name == "completion" || isBuiltin(root, name) {
+		return false
+	}
+	p, ok := plugin.Find(name)
+	if !ok {
+		return false
+	}
+
+	command := exec.Command(p.Path, rest...)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+	command.Env = pluginContext(ci).Environ(name)
+
+	// The plugin shares the terminal and handles Ctrl-C itself; dgit waits for it
+	signal.Ignore(os.Interrupt)
+	err := command.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		os.Exit(exitErr.ExitCode())
+	case err != nil:
+		ciMode = ci
+		printError(fmt.Sprintf("running plugin %s: %v", p.Path, err))
+		exit(ExitError)
+	}
+	os.Exit(ExitOK)
+	return true
+}
+
+// isBuiltin reports whether name is a built-in command or alias
+func isBuiltin(root *cobra.Command, name string) bool {
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginContext describes the repository around the working directory
+func pluginContext(ci bool) *plugin.Context {
+	context := &plugin.Context{CI: ci}
+	if executable, err := os.Executable(); err == nil {
+		context.Exec = executable
+	}
+	dgitDir := findDgitDirectory()
+	if dgitDir == "" {
+		return context
+	}
+	context.DgitDir, _ = filepath.Abs(dgitDir)
+	context.WorkTree = filepath.Dir(context.DgitDir)
+	context.Storage = initializer.GetStorageDir(context.DgitDir)
+	if head := headCommit(context.DgitDir); head != nil {
+		context.Head, context.HeadHash = head.Version, head.Hash
+	}
+	return context
+}
+
+// headCommit returns the commit HEAD points at, falling back to the latest commit
+func headCommit(dgitDir string) *log.Commit {
+	logManager := log.NewLogManager(dgitDir)
+	if data, err := os.ReadFile(filepath.Join(dgitDir, "HEAD")); err == nil {
+		if hash := strings.TrimSpace(string(data)); hash != "" {
+			if commit, err := logManager.GetCommitByHash(hash); err == nil {
+				return commit
+			}
+		}
+	}
+	if version := logManager.GetCurrentVersion(); version > 0 {
+		if commit, err := logManager.GetCommit(version); err == nil {
+			return commit
+		}
+	}
+	return nil
+}
+
+// runPluginList lists plugins found on PATH
+func runPluginList(cmd *cobra.Command, _ []string) {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	plugins := plugin.Discover()
+
+	if asJSON || ciMode {
+		entries := make([]map[string]interface{}, 0, len(plugins))
+		for _, p := range plugins {
+			entries = append(entries, map[string]interface{}{"name": p.Name, "path": p.Path, "shadowed": isBuiltin(cmd.Root(), p.Name)})
+		}
+		ciResult(entries)
+		return
+	}
+
+	if len(plugins) == 0 {
+		fmt.Println("No plugins found on PATH.")
+		printSuggestion("Install an executable named dgit-<name> on PATH to add 'dgit <name>'")
+		return
+	}
+	for _, p := range plugins {
+		line := fmt.Sprintf("  %-20s %s", cyan(p.Name), p.Path)
+		if isBuiltin(cmd.Root(), p.Name) {
+			line += yellow("  (hidden by the built-in command)")
+		}
+		fmt.Println(line)
+	}
+}
+
+// manifestEntry is one file of the checked-out version
+type manifestEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type,omitempty"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// runPluginContext prints the plugin context, optionally with the HEAD manifest
+func runPluginContext(cmd *cobra.Command, _ []string) {
+	withManifest, _ := cmd.Flags().GetBool("manifest")
+	context := pluginContext(ciMode)
+
+	result := map[string]interface{}{
+		"api_version": plugin.APIVersion,
+		"exec":        context.Exec,
+		"ci":          context.CI,
+	}
+	if context.DgitDir != "" {
+		result["dgit_dir"] = context.DgitDir
+		result["work_tree"] = context.WorkTree
+		result["storage_dir"] = context.Storage
+		result["head"] = context.Head
+		result["head_hash"] = context.HeadHash
+	}
+
+	if withManifest {
+		files := []manifestEntry{}
+		if context.DgitDir != "" {
+			if head := headCommit(context.DgitDir); head != nil {
+				for path, meta := range head.Metadata {
+					fileMeta, _ := meta.(map[string]interface{})
+					entry := manifestEntry{Path: path}
+					entry.Type, _ = fileMeta["type"].(string)
+					entry.Hash, _ = fileMeta["hash"].(string)
+					if size, ok := fileMeta["size"].(float64); ok {
+						entry.Size = int64(size)
+					}
+					files = append(files, entry)
+				}
+			}
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		result["files"] = files
+	}
+	ciResult(result)
+}
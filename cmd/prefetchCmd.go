@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"dgit/internal/log"
+	"dgit/internal/replica"
+	"dgit/internal/restore"
+
+	"github.com/spf13/cobra"
+)
+
+// PrefetchCmd warms local storage ahead of a review session
+var PrefetchCmd = &cobra.Command{
+	Use:   "prefetch <version|range>...",
+	Short: "Make sure the objects for a range of versions are local, optionally pre-reconstructed",
+	Long: `Make sure everything needed to restore a range of versions is on this machine,
+so a client review never waits on the network or on decompression.
+
+In a replica the objects are fetched from the shared store into the local cache
+('dgit replica fetch' for a whole range). In any repository the objects are
+checked, and versions whose objects are missing are reported.
+
+With --reconstruct, versions that would be slow to restore are rebuilt ahead of
+time into the restore cache (.dgit/cache/vN.lz4): snapshots moved to the Zstd
+archive tier are decompressed, and delta chains are replayed. Restores read the
+cache before any other tier. Versions already stored as a fast snapshot are left
+as they are. Cache copies are dropped again by 'dgit maintenance demote' when
+disk space runs low, and whenever history is rewritten.
+
+A range is <from>..<to>, inclusive; either end may be a version, tag or hash,
+and may be left out to mean the first or the latest version. Versions hidden
+with 'dgit hide' are skipped in ranges unless --include-hidden is given.
+
+Examples:
+  dgit prefetch v10..v20                  # Objects for v10 through v20
+  dgit prefetch v10..v20 --reconstruct    # ...and rebuild them into the cache
+  dgit prefetch client-approved..         # From a tag to the latest version
+  dgit prefetch v3 v7 v12 --reconstruct   # Individual versions`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runPrefetch,
+}
+
+func init() {
+	PrefetchCmd.Flags().Bool("reconstruct", false, "Rebuild archived and delta versions into the restore cache")
+	PrefetchCmd.Flags().Bool("include-hidden", false, "Include versions hidden with 'dgit hide' in ranges")
+	PrefetchCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// prefetchReport is the JSON form of a prefetch
+type prefetchReport struct {
+	Versions []int                 `json:"versions"`
+	Fetch    *replica.FetchResult  `json:"fetch,omitempty"`
+	Warmed   []*restore.WarmResult `json:"warmed,omitempty"`
+	Missing  map[string]string     `json:"missing,omitempty"`
+}
+
+// runPrefetch fetches and optionally reconstructs the objects for the given versions
+func runPrefetch(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	reconstruct, _ := cmd.Flags().GetBool("reconstruct")
+	includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	quiet := asJSON || ciMode
+
+	refreshReplica(dgitDir)
+	versions := expandVersionRanges(dgitDir, args, hiddenVersions(dgitDir, includeHidden))
+	if len(versions) == 0 {
+		printError("no versions in the given range")
+		exit(ExitNotFound)
+	}
+	report := &prefetchReport{Versions: versions, Missing: make(map[string]string)}
+
+	if _, ok := replica.IsReplica(dgitDir); ok {
+		result, err := openReplica(dgitDir).Fetch(versions)
+		if err != nil {
+			printError(fmt.Sprintf("fetching objects from the shared store: %v", err))
+			exit(exitCode(err))
+		}
+		report.Fetch = result
+		if !quiet {
+			printFetchResult(result)
+		}
+	}
+
+	manager := restore.NewRestoreManager(dgitDir)
+	for _, version := range versions {
+		if !reconstruct {
+			if _, err := manager.RequiredFiles(version); err != nil {
+				report.Missing[fmt.Sprintf("v%d", version)] = err.Error()
+			}
+			continue
+		}
+		result, err := manager.Warm(version)
+		if err != nil {
+			report.Missing[fmt.Sprintf("v%d", version)] = err.Error()
+			if !quiet {
+				fmt.Printf("  %s %-6s %s\n", red("✗"), fmt.Sprintf("v%d", version), err)
+			}
+			continue
+		}
+		report.Warmed = append(report.Warmed, result)
+		if !quiet {
+			printWarmResult(result)
+		}
+	}
+
+	if quiet {
+		ciResult(report)
+	} else {
+		printPrefetchSummary(report, reconstruct)
+	}
+	if len(report.Missing) > 0 {
+		exit(ExitNotFound)
+	}
+}
+
+// printWarmResult prints how one version will be restored
+func printWarmResult(result *restore.WarmResult) {
+	label := fmt.Sprintf("v%d", result.Version)
+	switch result.Status {
+	case restore.WarmReconstructed:
+		from := "archive"
+		if result.Source == "delta_chain" {
+			from = fmt.Sprintf("%d-step delta chain", result.Steps)
+		}
+		fmt.Printf("  %s %-6s rebuilt from %s (%s)\n", green("✓"), label, from, formatBytes(result.Bytes))
+	case restore.WarmCached:
+		fmt.Printf("  %s %-6s already in the restore cache\n", green("✓"), label)
+	default:
+		fmt.Printf("  %s %-6s restores directly\n", green("✓"), label)
+	}
+}
+
+// printPrefetchSummary reports the outcome of a prefetch
+func printPrefetchSummary(report *prefetchReport, reconstruct bool) {
+	if reconstruct {
+		fmt.Println()
+	}
+	if len(report.Missing) > 0 {
+		var missing []string
+		for version, reason := range report.Missing {
+			missing = append(missing, version)
+			if !reconstruct {
+				printError(fmt.Sprintf("%s: %s", version, reason))
+			}
+		}
+		sort.Strings(missing)
+		printWarning(fmt.Sprintf("%d of %d version(s) cannot be restored here: %s",
+			len(report.Missing), len(report.Versions), strings.Join(missing, ", ")))
+		printSuggestion("Check 'dgit doctor' for missing objects")
+		return
+	}
+
+	rebuilt := 0
+	var written int64
+	for _, result := range report.Warmed {
+		if result.Status == restore.WarmReconstructed {
+			rebuilt++
+			written += result.Bytes
+		}
+	}
+	summary := fmt.Sprintf("%d version(s) ready (%s)", len(report.Versions), formatVersionSpan(report.Versions))
+	if reconstruct {
+		summary += fmt.Sprintf(", %d rebuilt into the cache (%s)", rebuilt, formatBytes(written))
+	}
+	printSuccess(summary)
+	if !reconstruct {
+		printSuggestion("Add --reconstruct to rebuild archived and delta versions ahead of time")
+	}
+}
+
+// expandVersionRanges resolves versions and <from>..<to> ranges into sorted, unique
+// versions; versions in skip are left out of ranges but kept when named directly
+func expandVersionRanges(dgitDir string, args []string, skip map[int]bool) []int {
+	logManager := log.NewLogManager(dgitDir)
+	seen := make(map[int]bool)
+	var versions []int
+	add := func(version int) {
+		if !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+
+	for _, arg := range args {
+		from, to, isRange := strings.Cut(arg, "..")
+		if !isRange {
+			add(commitForVersionArg(dgitDir, arg).Version)
+			continue
+		}
+
+		first, last := 1, logManager.GetCurrentVersion()
+		if from != "" {
+			first = commitForVersionArg(dgitDir, from).Version
+		}
+		if to != "" {
+			last = commitForVersionArg(dgitDir, to).Version
+		}
+		if first > last {
+			exitWithCode(ExitUsage, fmt.Sprintf("range '%s' runs backwards (v%d is after v%d)", arg, first, last),
+				fmt.Sprintf("Use 'dgit prefetch v%d..v%d'", last, first))
+		}
+		for version := first; version <= last; version++ {
+			if skip[version] {
+				continue
+			}
+			if _, err := logManager.GetCommit(version); err == nil {
+				add(version)
+			}
+		}
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// formatVersionSpan renders sorted versions as "v3" or "v3-v9"
+func formatVersionSpan(versions []int) string {
+	if len(versions) == 1 {
+		return fmt.Sprintf("v%d", versions[0])
+	}
+	return fmt.Sprintf("v%d-v%d", versions[0], versions[len(versions)-1])
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"dgit/internal/log"
+	"dgit/internal/preview"
+	"dgit/internal/progress"
+
+	"github.com/spf13/cobra"
+)
+
+// PreviewsCmd manages thumbnails of committed files
+var PreviewsCmd = &cobra.Command{
+	Use:   "previews",
+	Short: "Manage thumbnails of committed design files",
+	Long: `Manage the thumbnails DGit keeps for committed files (.dgit/previews/).
+
+New commits get thumbnails automatically when "previews.enabled" is set in
+.dgit/config. Repositories created before previews existed, or whose thumbnails
+were made by an older preview format, can backfill them with 'rebuild'.
+
+Thumbnails come from the first renderer in "previews.renderers" that handles the
+file: "embedded" (the preview saved inside PSD/AI files), "raster" (the PSD
+composite and plain images), or an external command defined in "previews.external":
+
+  "external": [{"name": "magick", "extensions": [".psd", ".ai"],
+                "command": ["magick", "{input}[0]", "-thumbnail", "{size}x{size}", "{output}"]}],
+  "renderers": ["magick", "embedded", "raster"]
+
+Examples:
+  dgit previews rebuild              # Backfill thumbnails for all history
+  dgit previews rebuild --from v40   # Only versions from v40 on
+  dgit previews rebuild --restart    # Start over instead of resuming
+  dgit previews status               # How much of history has thumbnails`,
+}
+
+var previewsRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Generate missing thumbnails for past versions (resumable)",
+	Args:  cobra.NoArgs,
+	Run:   runPreviewsRebuild,
+}
+
+var previewsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show thumbnail coverage of history",
+	Args:  cobra.NoArgs,
+	Run:   runPreviewsStatus,
+}
+
+func init() {
+	previewsRebuildCmd.Flags().String("from", "v1", "First version to process")
+	previewsRebuildCmd.Flags().Bool("restart", false, "Ignore progress saved by an interrupted rebuild")
+	previewsRebuildCmd.Flags().Bool("include-hidden", false, "Include versions hidden with 'dgit hide'")
+
+	PreviewsCmd.AddCommand(previewsRebuildCmd)
+	PreviewsCmd.AddCommand(previewsStatusCmd)
+}
+
+// runPreviewsRebuild walks history and backfills thumbnails
+func runPreviewsRebuild(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+	fromFlag, _ := cmd.Flags().GetString("from")
+	restart, _ := cmd.Flags().GetBool("restart")
+	includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+
+	from, err := strconv.Atoi(strings.TrimPrefix(fromFlag, "v"))
+	if err != nil || from < 1 {
+		exitWithError(fmt.Sprintf("invalid --from version '%s'", fromFlag), "Use a version such as v1 or 12")
+	}
+
+	report := ciProgress()
+	if !ciMode {
+		report = func(e progress.Event) {
+			if e.Phase == progress.PhasePreview {
+				fmt.Printf("\r  %s  (%d/%d versions)", e.File, e.Current, e.Total)
+			}
+		}
+		fmt.Printf("Rebuilding previews from v%d...\n", from)
+	}
+	state, err := preview.NewPreviewManager(dgitDir).Rebuild(preview.RebuildOptions{From: from, Restart: restart, Skip: hiddenVersions(dgitDir, includeHidden)}, report)
+	if !ciMode {
+		fmt.Println()
+	}
+	if err != nil {
+		printError(fmt.Sprintf("rebuilding previews: %v", err))
+		if state != nil {
+			printSuggestion(fmt.Sprintf("Progress is saved through v%d; run the command again to resume", state.Completed))
+		}
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"completed":   state.Completed,
+			"generated":   state.Generated,
+			"existing":    state.Existing,
+			"unsupported": state.Skipped,
+			"failed":      state.Failed,
+		})
+		return
+	}
+	printSuccess(fmt.Sprintf("Previews up to date through v%d", state.Completed))
+	fmt.Printf("  Generated: %d, already present: %d, unsupported: %d, failed: %d\n",
+		state.Generated, state.Existing, state.Skipped, state.Failed)
+	if !preview.LoadSettings(dgitDir).Enabled {
+		printInfo("Set \"previews\": {\"enabled\": true} in .dgit/config to generate thumbnails on every commit")
+	}
+}
+
+// runPreviewsStatus reports how many committed files have thumbnails
+func runPreviewsStatus(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	previews := preview.NewPreviewManager(dgitDir)
+
+	commits, err := log.NewLogManager(dgitDir).GetCommitHistory()
+	if err != nil {
+		printError(fmt.Sprintf("loading commit history: %v", err))
+		os.Exit(1)
+	}
+
+	supported, covered, unknown := 0, 0, 0
+	for _, commit := range commits {
+		for path, raw := range commit.Metadata {
+			if !previews.Supports(path) {
+				continue
+			}
+			supported++
+			meta, _ := raw.(map[string]interface{})
+			hash, _ := meta["hash"].(string)
+			switch {
+			case hash == "":
+				unknown++
+			case previews.Has(hash):
+				covered++
+			}
+		}
+	}
+
+	var names []string
+	for _, renderer := range previews.Renderers {
+		names = append(names, renderer.Name())
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"enabled":   preview.LoadSettings(dgitDir).Enabled,
+			"size":      previews.Size,
+			"renderers": names,
+			"covered":   covered,
+			"supported": supported,
+			"unknown":   unknown,
+		})
+		return
+	}
+
+	enabled := "disabled"
+	if preview.LoadSettings(dgitDir).Enabled {
+		enabled = "enabled"
+	}
+	fmt.Printf("Commit-time previews: %s (%dpx)\n", enabled, previews.Size)
+	fmt.Printf("Renderers: %s\n", strings.Join(names, " → "))
+	fmt.Printf("Files with thumbnails: %d of %d previewable file versions\n", covered, supported)
+	if unknown > 0 {
+		fmt.Printf("  %d older file versions predate content hashes, so their coverage cannot be shown\n", unknown)
+	}
+	if covered < supported {
+		printInfo("Backfill with: dgit previews rebuild")
+	}
+}
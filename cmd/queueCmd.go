@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"dgit/internal/queue"
+
+	"github.com/spf13/cobra"
+)
+
+// QueueCmd manages commits queued with 'dgit commit --async'
+var QueueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Inspect and process background commits",
+	Long: `Inspect and process commits queued with 'dgit commit --async'.
+
+Queued commits are compressed and stored by a background worker, so committing
+a multi-gigabyte file returns immediately. 'dgit commit --async' starts a worker
+automatically; 'dgit queue work --watch' runs one as a long-lived daemon.
+
+Examples:
+  dgit queue                 # List queued, running, finished and failed commits
+  dgit queue work            # Process the queue in the foreground, then exit
+  dgit queue work --watch    # Keep running and pick up new commits as they arrive
+  dgit queue retry <id>      # Re-queue a failed commit
+  dgit queue clean           # Remove finished entries`,
+	Args: cobra.NoArgs,
+	Run:  runQueueList,
+}
+
+var queueWorkCmd = &cobra.Command{
+	Use:   "work",
+	Short: "Process queued commits",
+	Args:  cobra.NoArgs,
+	Run:   runQueueWork,
+}
+
+var queueRetryCmd = &cobra.Command{
+	Use:   "retry <id>",
+	Short: "Re-queue a failed commit",
+	Args:  cobra.ExactArgs(1),
+	Run:   runQueueRetry,
+}
+
+var queueCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove finished queue entries",
+	Args:  cobra.NoArgs,
+	Run:   runQueueClean,
+}
+
+func init() {
+	queueWorkCmd.Flags().Bool("watch", false, "Keep running and poll for new commits")
+	queueWorkCmd.Flags().Duration("interval", 5*time.Second, "Polling interval with --watch")
+	QueueCmd.AddCommand(queueWorkCmd)
+	QueueCmd.AddCommand(queueRetryCmd)
+	QueueCmd.AddCommand(queueCleanCmd)
+}
+
+// runQueueList prints every queue entry with its state
+func runQueueList(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	qm := queue.NewQueueManager(dgitDir)
+
+	jobs, err := qm.List()
+	if err != nil {
+		printError(fmt.Sprintf("reading commit queue: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		if jobs == nil {
+			jobs = []*queue.Job{}
+		}
+		ciResult(map[string]interface{}{"jobs": jobs, "worker_running": qm.WorkerRunning()})
+		return
+	}
+	if len(jobs) == 0 {
+		fmt.Println("Commit queue is empty.")
+		return
+	}
+
+	for _, job := range jobs {
+		fmt.Printf("%s  %-7s  %d files, %s  \"%s\"\n",
+			job.ID, job.State, len(job.Files), formatBytes(job.TotalSize()), job.Message)
+		switch job.State {
+		case queue.StateDone:
+			fmt.Printf("    committed as %s (v%d) in %s\n",
+				abbrevHash(job.Hash), job.Version, job.FinishedAt.Sub(job.StartedAt).Round(time.Millisecond))
+		case queue.StateFailed:
+			fmt.Printf("    error: %s\n", job.Error)
+		}
+	}
+
+	if len(qm.Pending()) > 0 && !qm.WorkerRunning() {
+		fmt.Println()
+		printSuggestion("No worker is running. Start one with 'dgit queue work'")
+	}
+}
+
+// runQueueWork processes queued commits, optionally as a daemon
+func runQueueWork(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+	watch, _ := cmd.Flags().GetBool("watch")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	qm := queue.NewQueueManager(dgitDir)
+	qm.Progress = ciProgress()
+	err := qm.Work(watch, interval, stop, func(job *queue.Job) {
+		if ciMode {
+			ciResult(map[string]interface{}{"id": job.ID, "state": job.State, "version": job.Version, "hash": job.Hash, "error": job.Error})
+			return
+		}
+		if job.State == queue.StateDone {
+			printSuccess(fmt.Sprintf("Queued commit %s finished: %s (v%d) \"%s\"", job.ID, abbrevHash(job.Hash), job.Version, job.Message))
+		} else {
+			printError(fmt.Sprintf("queued commit %s failed: %s", job.ID, job.Error))
+		}
+	})
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// runQueueRetry re-queues a failed job and makes sure a worker picks it up
+func runQueueRetry(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+
+	job, err := queue.NewQueueManager(dgitDir).Retry(args[0])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	ensureQueueWorker(dgitDir)
+	if ciMode {
+		ciResult(map[string]interface{}{"requeued": job.ID})
+		return
+	}
+	printSuccess(fmt.Sprintf("Re-queued commit %s \"%s\"", job.ID, job.Message))
+}
+
+// runQueueClean removes finished entries
+func runQueueClean(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+
+	removed, err := queue.NewQueueManager(dgitDir).Clean()
+	if err != nil {
+		printError(fmt.Sprintf("cleaning commit queue: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"removed": removed})
+		return
+	}
+	fmt.Printf("Removed %d finished queue entries.\n", removed)
+}
+
+// ensureQueueWorker starts a detached 'dgit queue work' unless one is already running
+// Worker output goes to .dgit/queue/worker.log
+func ensureQueueWorker(dgitDir string) {
+	qm := queue.NewQueueManager(dgitDir)
+	if qm.WorkerRunning() {
+		return
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		workDir, _ := os.Getwd()
+		err = qm.StartWorker(executable, workDir)
+	}
+	if err != nil {
+		printWarning(fmt.Sprintf("could not start queue worker: %v", err))
+		printSuggestion("Run 'dgit queue work' to process the queue")
+	}
+}
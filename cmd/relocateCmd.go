@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"dgit/internal/relocate"
+
+	"github.com/spf13/cobra"
+)
+
+// RelocateCmd moves object storage to another directory or volume
+var RelocateCmd = &cobra.Command{
+	Use:   "relocate <path>",
+	Short: "Move object storage to another volume",
+	Long: `Move snapshots, deltas, archives and legacy objects to another location.
+
+Every file is copied and verified by SHA256 before the repository config is
+switched to the new location in a single atomic write. If anything fails the
+repository keeps using the original storage. Commit metadata, staging and
+cache stay in .dgit.
+
+Run 'dgit relocate .dgit' to move storage back inside the repository.
+
+Examples:
+  dgit relocate /Volumes/BigDisk/projectX.dgit
+  dgit relocate /mnt/archive/projectX --keep-source`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRelocate,
+}
+
+func init() {
+	RelocateCmd.Flags().Bool("keep-source", false, "Keep the original storage after a verified copy")
+	RelocateCmd.Flags().BoolP("verbose", "v", false, "List each verified file")
+}
+
+// runRelocate executes the storage move
+func runRelocate(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	keepSource, _ := cmd.Flags().GetBool("keep-source")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	relocateManager := relocate.NewRelocateManager(dgitDir)
+	if verbose && !ciMode {
+		relocateManager.Progress = func(relPath string, size int64) {
+			fmt.Printf("  verified %s (%s)\n", relPath, formatBytes(size))
+		}
+	}
+
+	if !ciMode {
+		fmt.Printf("Relocating object storage to %s...\n", args[0])
+	}
+	result, err := relocateManager.Relocate(args[0], keepSource)
+	if err != nil {
+		printError(fmt.Sprintf("relocate failed: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"storage":        result.TargetDir,
+			"previous":       result.SourceDir,
+			"files":          result.FilesCopied,
+			"bytes":          result.BytesCopied,
+			"source_removed": result.SourceRemoved,
+			"duration_ms":    result.Duration.Milliseconds(),
+		})
+		return
+	}
+
+	printSuccess(fmt.Sprintf("Moved %d files (%s) in %.1fs",
+		result.FilesCopied, formatBytes(result.BytesCopied), result.Duration.Seconds()))
+	fmt.Printf("Object storage: %s\n", result.TargetDir)
+
+	if keepSource {
+		printInfo(fmt.Sprintf("Original storage kept at %s", result.SourceDir))
+	} else if !result.SourceRemoved {
+		printWarning(fmt.Sprintf("Could not fully remove old storage at %s; it is safe to delete manually", result.SourceDir))
+	}
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"dgit/internal/replica"
+
+	"github.com/spf13/cobra"
+)
+
+// ReplicaCmd manages read replicas backed by a shared object store
+var ReplicaCmd = &cobra.Command{
+	Use:   "replica",
+	Short: "Read-only replicas that fetch objects from a shared store on demand",
+	Long: `Create lightweight read-only replicas of a repository whose objects live on a
+shared network store, for render farms and other machines that only need to
+restore or materialize pinned versions.
+
+A replica holds the commit history and settings of the shared repository, which
+are refreshed before every restore. Objects are fetched from the shared store
+only when a restore needs them and kept in a local read-through cache, so many
+machines can work concurrently without each holding the full repository. The
+least recently used objects are evicted when the cache exceeds its limit.
+Commands that would write to a replica are refused.
+
+Examples:
+  dgit replica init /mnt/share/projectX ./projectX  # Create a replica
+  dgit replica init /mnt/share/projectX . --cache-limit 50000
+  dgit restore v12                                  # Fetches only what v12 needs
+  dgit pin materialize --from-pinfile shot.lock     # Same for pinned versions
+  dgit replica fetch 12 13                          # Warm the cache ahead of a job
+  dgit replica status                               # Source, history and cache use
+  dgit replica prune                                # Shrink the cache to its limit`,
+}
+
+var replicaInitCmd = &cobra.Command{
+	Use:   "init <shared-repo> [dir]",
+	Short: "Create a replica of a shared repository (in the current directory by default)",
+	Args:  cobra.RangeArgs(1, 2),
+	Run:   runReplicaInit,
+}
+
+var replicaRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Copy new commits and settings from the shared repository",
+	Args:  cobra.NoArgs,
+	Run:   runReplicaRefresh,
+}
+
+var replicaFetchCmd = &cobra.Command{
+	Use:   "fetch <version>...",
+	Short: "Fetch the objects the given versions need into the local cache",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runReplicaFetch,
+}
+
+var replicaStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the replica's source and cache usage",
+	Args:  cobra.NoArgs,
+	Run:   runReplicaStatus,
+}
+
+var replicaPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Evict least recently used objects above the cache limit",
+	Args:  cobra.NoArgs,
+	Run:   runReplicaPrune,
+}
+
+func init() {
+	replicaInitCmd.Flags().Int64("cache-limit", replica.DefaultCacheLimit, "Local object cache limit in MB")
+	replicaInitCmd.Flags().String("store", "", "Shared object store path (default: the shared repository's storage)")
+	replicaStatusCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	ReplicaCmd.AddCommand(replicaInitCmd)
+	ReplicaCmd.AddCommand(replicaRefreshCmd)
+	ReplicaCmd.AddCommand(replicaFetchCmd)
+	ReplicaCmd.AddCommand(replicaStatusCmd)
+	ReplicaCmd.AddCommand(replicaPruneCmd)
+}
+
+// runReplicaInit creates a replica and copies the shared history into it
+func runReplicaInit(cmd *cobra.Command, args []string) {
+	dir := "."
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	cacheLimit, _ := cmd.Flags().GetInt64("cache-limit")
+	store, _ := cmd.Flags().GetString("store")
+
+	manager, err := replica.Create(args[0], dir, store, cacheLimit)
+	if err != nil {
+		printError(err.Error())
+		exit(ExitNotRepository)
+	}
+	if ciMode {
+		ciResult(manager.Marker)
+		return
+	}
+	printSuccess(fmt.Sprintf("Created replica of %s (latest v%d)", filepath.Dir(manager.Marker.Source), manager.Marker.Version))
+	fmt.Printf("  Objects: %s\n", manager.Marker.Store)
+	fmt.Printf("  Cache:   %s limit\n", formatBytes(manager.Marker.CacheLimit*1024*1024))
+	printSuggestion("Objects are fetched when a restore needs them; warm the cache with 'dgit replica fetch <version>'")
+}
+
+// runReplicaRefresh copies new history from the shared repository
+func runReplicaRefresh(cmd *cobra.Command, args []string) {
+	manager := openReplica(checkDgitRepository())
+	if err := manager.Refresh(); err != nil {
+		printError(fmt.Sprintf("refreshing replica: %v", err))
+		exit(ExitNotRepository)
+	}
+	if ciMode {
+		ciResult(manager.Marker)
+		return
+	}
+	printSuccess(fmt.Sprintf("Replica is up to date with %s (latest v%d)", filepath.Dir(manager.Marker.Source), manager.Marker.Version))
+}
+
+// runReplicaFetch fetches the objects for the given versions
+func runReplicaFetch(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+	manager := openReplica(dgitDir)
+
+	var versions []int
+	for _, arg := range args {
+		version, err := strconv.Atoi(strings.TrimPrefix(arg, "v"))
+		if err != nil {
+			printError(fmt.Sprintf("invalid version: %s", arg))
+			exit(ExitUsage)
+		}
+		versions = append(versions, version)
+	}
+
+	refreshReplica(dgitDir)
+	result, err := manager.Fetch(versions)
+	if err != nil {
+		printError(fmt.Sprintf("fetching objects: %v", err))
+		exit(exitCode(err))
+	}
+	if ciMode {
+		ciResult(result)
+		return
+	}
+	printFetchResult(result)
+}
+
+// runReplicaStatus shows where the replica reads from and how full its cache is
+func runReplicaStatus(cmd *cobra.Command, args []string) {
+	manager := openReplica(checkDgitRepository())
+	usage := manager.Usage()
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	if asJSON || ciMode {
+		data, _ := json.MarshalIndent(map[string]interface{}{"replica": manager.Marker, "cache": usage}, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	marker := manager.Marker
+	fmt.Printf("Replica of %s\n", cyan(filepath.Dir(marker.Source)))
+	fmt.Printf("  Objects:   %s\n", marker.Store)
+	fmt.Printf("  History:   v%d, refreshed %s\n", marker.Version, marker.RefreshedAt.Format("2006-01-02 15:04"))
+	limit := marker.CacheLimit * 1024 * 1024
+	cacheLine := fmt.Sprintf("%s of %s (%d objects)", formatBytes(usage.Bytes), formatBytes(limit), usage.Files)
+	if usage.Bytes > limit {
+		cacheLine = yellow(cacheLine)
+		defer printSuggestion("Shrink the cache with 'dgit replica prune'")
+	}
+	fmt.Printf("  Cache:     %s\n", cacheLine)
+}
+
+// runReplicaPrune evicts objects above the cache limit
+func runReplicaPrune(cmd *cobra.Command, args []string) {
+	manager := openReplica(checkDgitRepository())
+	evicted, err := manager.Evict(nil)
+	if err != nil {
+		printError(fmt.Sprintf("pruning cache: %v", err))
+		os.Exit(1)
+	}
+	usage := manager.Usage()
+	if ciMode {
+		ciResult(map[string]interface{}{"evicted": evicted, "cache": usage})
+		return
+	}
+	printSuccess(fmt.Sprintf("Evicted %d object(s); cache holds %s", evicted, formatBytes(usage.Bytes)))
+}
+
+// openReplica opens the replica at dgitDir, exiting when it is a regular repository
+func openReplica(dgitDir string) *replica.ReplicaManager {
+	manager, err := replica.NewReplicaManager(dgitDir)
+	if err != nil {
+		exitWithError(err.Error(), "Create one with 'dgit replica init <shared-repo>'")
+	}
+	return manager
+}
+
+// refreshReplica brings a replica's history up to date before it is read; when the
+// shared repository is unreachable the replica keeps working from its last refresh
+func refreshReplica(dgitDir string) {
+	if _, ok := replica.IsReplica(dgitDir); !ok {
+		return
+	}
+	manager := openReplica(dgitDir)
+	if err := manager.Refresh(); err != nil {
+		printWarning(fmt.Sprintf("replica history not refreshed, using v%d: %v", manager.Marker.Version, err))
+	}
+}
+
+// fetchForReplica fetches the objects versions need when dgitDir is a replica
+func fetchForReplica(dgitDir string, versions ...int) {
+	if _, ok := replica.IsReplica(dgitDir); !ok {
+		return
+	}
+	result, err := openReplica(dgitDir).Fetch(versions)
+	if err != nil {
+		printError(fmt.Sprintf("fetching objects from the shared store: %v", err))
+		exit(exitCode(err))
+	}
+	if !ciMode && result.Fetched > 0 {
+		printFetchResult(result)
+	}
+}
+
+// printFetchResult summarizes a fetch
+func printFetchResult(result *replica.FetchResult) {
+	fmt.Printf("Fetched %d object(s) (%s), %d already cached", result.Fetched, formatBytes(result.Bytes), result.Cached)
+	if result.Evicted > 0 {
+		fmt.Printf(", evicted %d", result.Evicted)
+	}
+	fmt.Println()
+}
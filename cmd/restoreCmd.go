@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -14,16 +15,37 @@ import (
 
 // RestoreCmd restores files from a specific commit
 var RestoreCmd = &cobra.Command{
-	Use:   "restore <version_or_hash> [file...]",
-	Short: "Restore files from a specific commit",
+	Use:     "restore <version_or_hash> [file...]",
+	Aliases: []string{"checkout"},
+	Short:   "Restore files from a specific commit",
 	Long: `Restore files from a specific commit version or hash to the working directory.
 If no files are specified, all files from that commit will be restored.
 
+Files are reconstructed into .dgit/checkout first and moved into the working
+directory once complete, with progress recorded in a journal. If a restore is
+interrupted (crash, reboot, full disk), 'dgit checkout --continue' resumes it
+from the last completed step instead of replaying the whole delta chain, and
+'dgit checkout --abort' discards it.
+
+Independent files are written in parallel. The number of writers follows the
+disk being written to: many on SSDs, and on spinning disks and network shares
+only one large file streams at a time so the drive does not thrash. Set
+restore.workers or restore.io_profile (ssd, hdd, network) in config.local, or
+use --jobs for a single restore.
+
+Files modified since HEAD are never overwritten silently. Restore now refuses
+to run over them without --force (earlier versions overwrote them): it stops,
+lists them and exits with an error. With --force they are first backed up to
+.dgit/trash and can be recovered with 'dgit trash restore <id>'.
+
 Examples:
   dgit restore 1                  # Restore all files from version 1
   dgit restore c3a5f7b8           # Restore all files from commit hash
   dgit restore 2 my_design.psd    # Restore specific file from version 2
   dgit restore 2 designs/         # Restore directory from version 2
+  dgit checkout 3 --force         # Overwrite local changes (backed up first)
+  dgit checkout --continue        # Finish an interrupted restore
+  dgit checkout 3 --jobs 1        # Write one file at a time
 
 File matching supports:
 - Exact path matching
@@ -31,6 +53,14 @@ File matching supports:
 - Directory matching
 - Partial path matching`,
 	Args: func(cmd *cobra.Command, args []string) error {
+		resume, _ := cmd.Flags().GetBool("continue")
+		abort, _ := cmd.Flags().GetBool("abort")
+		if resume || abort {
+			if len(args) > 0 {
+				return fmt.Errorf("--continue and --abort take no arguments")
+			}
+			return nil
+		}
 		if len(args) < 1 {
 			return fmt.Errorf("requires at least one argument: <version_or_hash>")
 		}
@@ -39,11 +69,34 @@ File matching supports:
 	Run: runRestore,
 }
 
+func init() {
+	RestoreCmd.Flags().BoolP("force", "f", false, "Overwrite locally modified files after backing them up to the trash")
+	RestoreCmd.Flags().Bool("continue", false, "Resume an interrupted restore")
+	RestoreCmd.Flags().Bool("abort", false, "Discard an interrupted restore")
+	RestoreCmd.Flags().IntP("jobs", "j", 0, "Files to write at once (default: chosen for the disk)")
+	RestoreCmd.MarkFlagsMutuallyExclusive("continue", "abort")
+}
+
 // runRestore restores files from a specific commit to the working directory
 func runRestore(cmd *cobra.Command, args []string) {
-	dgitDir := checkDgitRepository()
+	dgitDir := checkWritableRepository()
 
 	restoreManager := restore.NewRestoreManager(dgitDir)
+	restoreManager.Force, _ = cmd.Flags().GetBool("force")
+	restoreManager.Resumable = true
+	restoreManager.Workers, _ = cmd.Flags().GetInt("jobs")
+	restoreManager.Progress = ciProgress()
+
+	if abort, _ := cmd.Flags().GetBool("abort"); abort {
+		runRestoreAbort(dgitDir)
+		return
+	}
+	if resume, _ := cmd.Flags().GetBool("continue"); resume {
+		runRestoreContinue(dgitDir, restoreManager)
+		return
+	}
+
+	refreshReplica(dgitDir)
 	logManager := log.NewLogManager(dgitDir)
 
 	commitRef := args[0]
@@ -56,30 +109,123 @@ func runRestore(cmd *cobra.Command, args []string) {
 	targetCommit, err := findTargetCommit(logManager, commitRef)
 	if err != nil {
 		printError(fmt.Sprintf("Failed to find commit: %v", err))
-		os.Exit(1)
+		exit(ExitNotFound)
+	}
+	fetchForReplica(dgitDir, targetCommit.Version)
+
+	if ciMode {
+		result, err := restoreManager.Restore(fmt.Sprintf("v%d", targetCommit.Version), filesToRestore)
+		if err != nil {
+			if overwriteErr, ok := err.(*restore.OverwriteError); ok {
+				ciResult(map[string]interface{}{"error": "local changes would be overwritten", "modified": overwriteErr.Files})
+			}
+			printError(fmt.Sprintf("Restore failed: %v", err))
+			exit(exitCode(err))
+		}
+		printRestoreCIResult(dgitDir, result)
+		return
 	}
 
 	if len(filesToRestore) == 0 {
-		fmt.Printf("Restoring all files from commit %s (v%d)\n", targetCommit.Hash[:8], targetCommit.Version)
+		fmt.Printf("Restoring all files from commit %s (v%d)\n", abbrevHash(targetCommit.Hash), targetCommit.Version)
 		fmt.Printf("\"%s\"\n", targetCommit.Message)
 		fmt.Printf("Files: %d\n\n", targetCommit.FilesCount)
 	} else {
-		fmt.Printf("Restoring %d specific files from commit %s (v%d)\n", len(filesToRestore), targetCommit.Hash[:8], targetCommit.Version)
+		fmt.Printf("Restoring %d specific files from commit %s (v%d)\n", len(filesToRestore), abbrevHash(targetCommit.Hash), targetCommit.Version)
 		fmt.Printf("\"%s\"\n", targetCommit.Message)
 		fmt.Printf("Target files: %v\n\n", filesToRestore)
 	}
 
 	err = performRestore(restoreManager, targetCommit, filesToRestore)
+	if overwriteErr, ok := err.(*restore.OverwriteError); ok {
+		printError("Restore aborted: local changes would be overwritten")
+		for _, file := range overwriteErr.Files {
+			fmt.Printf("  modified: %s\n", file)
+		}
+		printSuggestion("Commit your changes first, or use --force to back them up and overwrite")
+		exit(ExitConflict)
+	}
+	if _, ok := err.(*restore.InterruptedError); ok {
+		printError(fmt.Sprintf("Restore failed: %v", err))
+		printSuggestion("Finish it with 'dgit checkout --continue' or discard it with 'dgit checkout --abort'")
+		exit(ExitConflict)
+	}
 	if err != nil {
 		printError(fmt.Sprintf("Restore failed: %v", err))
+		printRestoreResumeHint(dgitDir)
 		os.Exit(1)
 	}
+	relieveDiskPressure(dgitDir)
 }
 
-// findTargetCommit finds a commit by hash or version number
+// runRestoreContinue finishes an interrupted restore
+func runRestoreContinue(dgitDir string, restoreManager *restore.RestoreManager) {
+	if journal, _ := restore.LoadJournal(dgitDir); journal != nil {
+		fetchForReplica(dgitDir, journal.Version)
+	}
+	result, err := restoreManager.Continue()
+	if err != nil {
+		printError(fmt.Sprintf("Restore failed: %v", err))
+		if err != restore.ErrNoCheckout {
+			printRestoreResumeHint(dgitDir)
+		}
+		exit(exitCode(err))
+	}
+	if ciMode {
+		printRestoreCIResult(dgitDir, result)
+		return
+	}
+	relieveDiskPressure(dgitDir)
+}
+
+// runRestoreAbort discards an interrupted restore
+func runRestoreAbort(dgitDir string) {
+	journal, err := restore.AbortCheckout(dgitDir)
+	if err != nil {
+		printError(err.Error())
+		exit(exitCode(err))
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"aborted": journal.Version, "placed": journal.Placed})
+		return
+	}
+	printSuccess(fmt.Sprintf("Discarded the interrupted checkout of v%d", journal.Version))
+	if journal.Placed > 0 {
+		printWarning(fmt.Sprintf("%d file(s) had already been moved into the working directory", journal.Placed))
+	}
+}
+
+// printRestoreCIResult reports a finished restore as JSON
+func printRestoreCIResult(dgitDir string, result *restore.RestoreResult) {
+	failed := make(map[string]string, len(result.ErrorFiles))
+	for file, fileErr := range result.ErrorFiles {
+		failed[file] = fileErr.Error()
+	}
+	ciResult(map[string]interface{}{
+		"version":  result.SourceVersion,
+		"hash":     result.SourceCommitHash,
+		"restored": result.RestoredFiles,
+		"failed":   failed,
+	})
+	if len(failed) > 0 {
+		exit(ExitError)
+	}
+	relieveDiskPressure(dgitDir)
+}
+
+// printRestoreResumeHint tells the user how to resume a restore that left a journal behind
+func printRestoreResumeHint(dgitDir string) {
+	if journal, _ := restore.LoadJournal(dgitDir); journal != nil {
+		printSuggestion("Resume with 'dgit checkout --continue' once the problem is fixed, or 'dgit checkout --abort'")
+	}
+}
+
+// findTargetCommit finds a commit by tag, hash or version number
 func findTargetCommit(logManager *log.LogManager, commitRef string) (*log.Commit, error) {
-	var targetCommit *log.Commit
-	var err error
+	targetCommit, err := resolveTag(logManager.DgitDir, commitRef)
+	if targetCommit != nil || err != nil {
+		return targetCommit, err
+	}
 
 	isHashCandidate := false
 	if len(commitRef) >= 4 && len(commitRef) <= 64 {
@@ -97,6 +243,10 @@ func findTargetCommit(logManager *log.LogManager, commitRef string) (*log.Commit
 		if err == nil && targetCommit != nil {
 			return targetCommit, nil
 		}
+		var ambiguous *log.AmbiguousHashError
+		if errors.As(err, &ambiguous) {
+			return nil, err
+		}
 	}
 
 	strippedCommitRef := strings.TrimPrefix(commitRef, "v")
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"dgit/internal/activity"
+	"dgit/internal/review"
+
+	"github.com/spf13/cobra"
+)
+
+// ReviewCmd manages review bundles stored alongside commits
+var ReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Collect screenshots and comments for a version into a review bundle",
+	Long: `Keep design review artifacts versioned with the work.
+
+A review bundle belongs to one version and holds a summary of each file's changes
+since its previous version, annotated screenshots, and reviewer comments. Bundles
+are stored in .dgit/reviews/ and can be exported to a single self-contained HTML page.
+
+Examples:
+  dgit review create v12 -t "Homepage hero" --attach notes.png
+  dgit review comment v12 "Logo is too close to the edge" --file hero.psd
+  dgit review comment v12 "Looks good" --approve
+  dgit review export v12 -o hero-review.html`,
+}
+
+var reviewCreateCmd = &cobra.Command{
+	Use:   "create <version>",
+	Short: "Start a review bundle for a version",
+	Args:  cobra.ExactArgs(1),
+	Run:   runReviewCreate,
+}
+
+var reviewCommentCmd = &cobra.Command{
+	Use:   "comment <review> [text]",
+	Short: "Add a comment and/or screenshots to a review",
+	Args:  cobra.RangeArgs(1, 2),
+	Run:   runReviewComment,
+}
+
+var reviewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List review bundles",
+	Args:  cobra.NoArgs,
+	Run:   runReviewList,
+}
+
+var reviewShowCmd = &cobra.Command{
+	Use:   "show <review>",
+	Short: "Show a review bundle",
+	Args:  cobra.ExactArgs(1),
+	Run:   runReviewShow,
+}
+
+var reviewExportCmd = &cobra.Command{
+	Use:   "export <review>",
+	Short: "Export a review bundle to HTML",
+	Args:  cobra.ExactArgs(1),
+	Run:   runReviewExport,
+}
+
+func init() {
+	reviewCreateCmd.Flags().StringP("title", "t", "", "Review title")
+	reviewCreateCmd.Flags().StringSlice("attach", nil, "Screenshot(s) to include")
+	reviewCreateCmd.Flags().StringP("message", "m", "", "Opening comment")
+
+	reviewCommentCmd.Flags().String("file", "", "Design file the comment is about")
+	reviewCommentCmd.Flags().StringSlice("attach", nil, "Annotated screenshot(s) to include")
+	reviewCommentCmd.Flags().Bool("approve", false, "Mark the review approved")
+	reviewCommentCmd.Flags().Bool("request-changes", false, "Mark the review as needing changes")
+
+	reviewExportCmd.Flags().StringP("output", "o", "", "Output file (default: review-<id>.html)")
+
+	reviewListCmd.Flags().Bool("include-hidden", false, "Include reviews of versions hidden with 'dgit hide'")
+
+	ReviewCmd.AddCommand(reviewCreateCmd)
+	ReviewCmd.AddCommand(reviewCommentCmd)
+	ReviewCmd.AddCommand(reviewListCmd)
+	ReviewCmd.AddCommand(reviewShowCmd)
+	ReviewCmd.AddCommand(reviewExportCmd)
+}
+
+// runReviewCreate opens a review bundle for a version
+func runReviewCreate(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	title, _ := cmd.Flags().GetString("title")
+	attachments, _ := cmd.Flags().GetStringSlice("attach")
+	message, _ := cmd.Flags().GetString("message")
+	author, _ := activity.Identity()
+
+	manager := review.NewReviewManager(dgitDir)
+	bundle, err := manager.Create(args[0], title, author)
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	if err := addReviewNotes(manager, bundle, message, "", attachments, author); err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(bundle)
+		return
+	}
+	printSuccess(fmt.Sprintf("Created review %s for v%d (%d files, %d screenshots)", bundle.ID, bundle.Version, len(bundle.Files), len(bundle.Attachments)))
+	printInfo(fmt.Sprintf("Add feedback with: dgit review comment %s \"...\"", bundle.ID))
+}
+
+// runReviewComment adds feedback to a review
+func runReviewComment(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	file, _ := cmd.Flags().GetString("file")
+	attachments, _ := cmd.Flags().GetStringSlice("attach")
+	approve, _ := cmd.Flags().GetBool("approve")
+	requestChanges, _ := cmd.Flags().GetBool("request-changes")
+	author, _ := activity.Identity()
+
+	text := ""
+	if len(args) == 2 {
+		text = args[1]
+	}
+	if text == "" && len(attachments) == 0 && !approve && !requestChanges {
+		exitWithError("nothing to add", "Give comment text, --attach a screenshot, or --approve/--request-changes")
+	}
+	if approve && requestChanges {
+		exitWithError("--approve and --request-changes cannot be combined", "")
+	}
+
+	manager := review.NewReviewManager(dgitDir)
+	bundle, err := manager.Load(args[0])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	if approve {
+		bundle.State = review.StateApproved
+	} else if requestChanges {
+		bundle.State = review.StateChangesRequested
+	}
+	if err := addReviewNotes(manager, bundle, text, file, attachments, author); err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(bundle)
+		return
+	}
+	printSuccess(fmt.Sprintf("Updated review %s (%s)", bundle.ID, bundle.State))
+}
+
+// addReviewNotes attaches screenshots and records a comment, then saves the bundle
+func addReviewNotes(manager *review.ReviewManager, bundle *review.Bundle, text, file string, attachments []string, author string) error {
+	var names []string
+	for _, path := range attachments {
+		attachment, err := manager.Attach(bundle, path, file, text, author)
+		if err != nil {
+			return err
+		}
+		names = append(names, attachment.Name)
+	}
+
+	if text != "" {
+		bundle.Comments = append(bundle.Comments, &review.Comment{
+			Author:     author,
+			Text:       text,
+			File:       file,
+			Attachment: strings.Join(names, ", "),
+			CreatedAt:  time.Now(),
+		})
+	}
+	return manager.Save(bundle)
+}
+
+// runReviewList prints all review bundles
+func runReviewList(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+
+	bundles, err := review.NewReviewManager(dgitDir).List()
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+	hiddenSet := hiddenVersions(dgitDir, includeHidden)
+	if ciMode {
+		visible := []*review.Bundle{}
+		for _, bundle := range bundles {
+			if !hiddenSet[bundle.Version] {
+				visible = append(visible, bundle)
+			}
+		}
+		ciResult(visible)
+		return
+	}
+	if len(bundles) == 0 {
+		fmt.Println("No reviews yet.")
+		return
+	}
+
+	for _, bundle := range bundles {
+		if hiddenSet[bundle.Version] {
+			continue
+		}
+		title := bundle.Title
+		if title == "" {
+			title = bundle.Message
+		}
+		fmt.Printf("%-10s %s  (%d comments, %d screenshots)  [%s]\n",
+			bundle.ID, title, len(bundle.Comments), len(bundle.Attachments), reviewState(bundle.State))
+	}
+}
+
+// runReviewShow prints a review bundle
+func runReviewShow(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+
+	bundle, err := review.NewReviewManager(dgitDir).Load(args[0])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	if ciMode {
+		ciResult(bundle)
+		return
+	}
+
+	fmt.Printf("Review %s of v%d (%s) — %s\n", bundle.ID, bundle.Version, abbrevHash(bundle.CommitHash), reviewState(bundle.State))
+	if bundle.Title != "" {
+		fmt.Printf("  %s\n", bundle.Title)
+	}
+	fmt.Printf("  %s\n\n", bundle.Message)
+
+	fmt.Printf("Files (%d):\n", len(bundle.Files))
+	for _, file := range bundle.Files {
+		changes := "new file"
+		if file.PreviousVersion > 0 {
+			changes = fmt.Sprintf("since v%d: %s", file.PreviousVersion, strings.Join(file.Changes, ", "))
+			if len(file.Changes) == 0 {
+				changes = fmt.Sprintf("since v%d: no visible property changes", file.PreviousVersion)
+			}
+		}
+		fmt.Printf("  %s  (%s)\n", file.Path, changes)
+	}
+
+	if len(bundle.Attachments) > 0 {
+		fmt.Printf("\nScreenshots (%d):\n", len(bundle.Attachments))
+		for _, attachment := range bundle.Attachments {
+			fmt.Printf("  %s  %s\n", attachment.Name, attachment.Caption)
+		}
+	}
+
+	fmt.Printf("\nComments (%d):\n", len(bundle.Comments))
+	for _, comment := range bundle.Comments {
+		about := ""
+		if comment.File != "" {
+			about = " on " + comment.File
+		}
+		fmt.Printf("  %s%s (%s):\n    %s\n", cyan(comment.Author), about, comment.CreatedAt.Format("2006-01-02 15:04"), comment.Text)
+	}
+}
+
+// runReviewExport writes a review bundle as HTML
+func runReviewExport(cmd *cobra.Command, args []string) {
+	dgitDir := checkDgitRepository()
+	output, _ := cmd.Flags().GetString("output")
+
+	manager := review.NewReviewManager(dgitDir)
+	bundle, err := manager.Load(args[0])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if output == "" {
+		output = fmt.Sprintf("review-%s.html", bundle.ID)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		printError(fmt.Sprintf("creating %s: %v", output, err))
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	if err := manager.ExportHTML(bundle, file); err != nil {
+		printError(fmt.Sprintf("exporting review: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"review": bundle.ID, "output": output})
+		return
+	}
+	printSuccess(fmt.Sprintf("Exported review %s to %s", bundle.ID, output))
+}
+
+// reviewState colors a review state for terminal output
+func reviewState(state string) string {
+	switch state {
+	case review.StateApproved:
+		return green(state)
+	case review.StateChangesRequested:
+		return yellow(state)
+	default:
+		return state
+	}
+}
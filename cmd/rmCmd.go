@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dgit/internal/log"
+	"dgit/internal/pathnorm"
+	"dgit/internal/staging"
+	"dgit/internal/status"
+	"dgit/internal/trash"
+
+	"github.com/spf13/cobra"
+)
+
+// RmCmd stages intentional deletions of tracked files
+var RmCmd = &cobra.Command{
+	Use:   "rm <files...>",
+	Short: "Remove tracked files and stage their deletion",
+	Long: `Stage the deletion of tracked design files so the next commit records it,
+instead of the deletion only being noticed when 'dgit status' finds a missing file.
+
+The working file is deleted (its content stays in history and can be brought back
+with 'dgit restore'). Files with uncommitted changes are refused unless --force is
+given, in which case they are first backed up to the DGit trash.
+
+Examples:
+  dgit rm old-logo.ai                 # Delete and stage the deletion
+  dgit rm --os-trash draft.psd        # Move to the Finder/desktop trash instead
+  dgit rm --keep mockup.sketch        # Stop tracking, leave the file on disk`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runRm,
+}
+
+func init() {
+	RmCmd.Flags().Bool("keep", false, "Keep the working file; only stage the deletion")
+	RmCmd.Flags().Bool("os-trash", false, "Move the working file to the operating system trash")
+	RmCmd.Flags().BoolP("force", "f", false, "Remove files with uncommitted changes (backed up to the DGit trash first)")
+}
+
+// runRm deletes tracked files and records the deletions in the staging area
+func runRm(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	checkNotMirror(dgitDir)
+	checkNoBatch(dgitDir)
+	lock := lockRepository(dgitDir, "rm")
+	defer lock.Release()
+	keep, _ := cmd.Flags().GetBool("keep")
+	osTrash, _ := cmd.Flags().GetBool("os-trash")
+	force, _ := cmd.Flags().GetBool("force")
+
+	stagingArea := staging.NewStagingArea(dgitDir)
+	if err := stagingArea.LoadStaging(); err != nil {
+		printError(fmt.Sprintf("loading staging area: %v", err))
+		os.Exit(1)
+	}
+
+	logManager := log.NewLogManager(dgitDir)
+	version := logManager.GetCurrentVersion()
+	if version == 0 {
+		exitWithError("nothing is tracked yet", "Files can only be removed after they have been committed")
+	}
+	latest, err := logManager.GetCommit(version)
+	if err != nil {
+		printError(fmt.Sprintf("loading v%d: %v", version, err))
+		os.Exit(1)
+	}
+
+	workDir, _ := os.Getwd()
+	policy := pathnorm.LoadPolicy(dgitDir)
+	var snapshotHashes map[string]string
+	var staged []string
+	failed := false
+
+	for _, arg := range args {
+		absPath, err := filepath.Abs(arg)
+		if err != nil {
+			printError(fmt.Sprintf("%s: %v", arg, err))
+			failed = true
+			continue
+		}
+		relPath, err := filepath.Rel(workDir, absPath)
+		if err != nil {
+			relPath = absPath
+		}
+		relPath = policy.Apply(relPath)
+
+		fileMeta, tracked := latest.Metadata[relPath].(map[string]interface{})
+		if !tracked {
+			printError(fmt.Sprintf("'%s' is not tracked in v%d", arg, version))
+			failed = true
+			continue
+		}
+
+		if _, err := os.Stat(absPath); err == nil && !keep {
+			// Compare against the committed content before throwing anything away
+			modified := true
+			if current, err := status.CalculateFileHash(absPath); err == nil {
+				committed, _ := fileMeta["hash"].(string)
+				if committed == "" {
+					if snapshotHashes == nil {
+						snapshotHashes, _ = status.NewStatusManager(dgitDir).GetSnapshotFileHashes(version)
+					}
+					committed = snapshotHashes[relPath]
+				}
+				modified = current != committed
+			}
+
+			if modified && !force {
+				printError(fmt.Sprintf("'%s' has changes not committed in v%d", arg, version))
+				printSuggestion("Commit them first, use --keep to leave the file on disk, or --force to remove anyway")
+				failed = true
+				continue
+			}
+
+			if err := removeWorkingFile(dgitDir, workDir, relPath, absPath, modified, osTrash); err != nil {
+				printError(fmt.Sprintf("removing '%s': %v", arg, err))
+				failed = true
+				continue
+			}
+		}
+
+		if stagingArea.HasFile(absPath) {
+			stagingArea.RemoveFile(absPath)
+		}
+		stagingArea.StageRemoval(relPath)
+		staged = append(staged, relPath)
+		if !ciMode {
+			fmt.Printf("rm '%s'\n", relPath)
+		}
+	}
+
+	if err := stagingArea.SaveStaging(); err != nil {
+		printError(fmt.Sprintf("saving staging area: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		if staged == nil {
+			staged = []string{}
+		}
+		ciResult(map[string]interface{}{"removed": staged, "staged_deletions": stagingArea.GetRemovedFiles()})
+		if failed {
+			exit(ExitError)
+		}
+		return
+	}
+
+	if removed := stagingArea.GetRemovedFiles(); len(removed) > 0 {
+		fmt.Println()
+		printInfo(fmt.Sprintf("%d deletion(s) staged; run 'dgit commit' to record them", len(removed)))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// removeWorkingFile deletes a tracked file from the working tree
+// Modified files are backed up to the DGit trash, since their content exists nowhere else
+func removeWorkingFile(dgitDir, workDir, relPath, absPath string, modified, osTrash bool) error {
+	if osTrash && !modified {
+		err := trash.MoveToSystemTrash(absPath)
+		if err == nil {
+			return nil
+		}
+		printWarning(fmt.Sprintf("%v; keeping a copy in the DGit trash instead", err))
+		modified = true
+	}
+
+	if modified {
+		entry, err := trash.NewTrashManager(dgitDir).Save(workDir, []string{relPath}, "dgit rm")
+		if err != nil {
+			return err
+		}
+		printInfo(fmt.Sprintf("Backed up '%s' to trash %s", relPath, entry.ID))
+	}
+	return os.Remove(absPath)
+}
@@ -33,12 +33,12 @@ func runScan(cmd *cobra.Command, args []string) {
 	}
 
 	if !isInDgitRepository() {
-		printError("not a dgit repository (or any of the parent directories)")
-		printSuggestion("Run 'dgit init' to initialize a repository")
-		os.Exit(1)
+		exitWithCode(ExitNotRepository, "not a dgit repository (or any of the parent directories)", "Run 'dgit init' to initialize a repository")
 	}
 
-	fmt.Printf("Scanning design files in: %s\n", targetDir)
+	if !ciMode {
+		fmt.Printf("Scanning design files in: %s\n", targetDir)
+	}
 
 	quickScanner := scanner.NewQuickScanner()
 	result, err := quickScanner.Scan(targetDir)
@@ -47,6 +47,16 @@ func runScan(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if ciMode {
+		ciResult(map[string]interface{}{
+			"directory":    targetDir,
+			"files":        result.TotalFiles,
+			"bytes":        result.TotalSize,
+			"types":        result.TypeCounts,
+			"scan_time_ms": result.ScanTime.Milliseconds(),
+		})
+		return
+	}
 	printScanResults(result)
 }
 
@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 
+	"dgit/internal/environment"
+	"dgit/internal/hidden"
 	"dgit/internal/log"
 	"dgit/internal/scanner"
 
@@ -40,14 +43,14 @@ func runShow(cmd *cobra.Command, args []string) {
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 
 	if isFilePath(target) {
-		showFileDetails(target, cmd)
+		showFileDetails(target, cmd, jsonOutput)
 	} else {
 		showCommitDetails(target, cmd, jsonOutput) // 파라미터 추가
 	}
 }
 
 // showFileDetails displays comprehensive file analysis
-func showFileDetails(filePath string, cmd *cobra.Command) {
+func showFileDetails(filePath string, cmd *cobra.Command, jsonOutput bool) {
 	if !fileExists(filePath) {
 		printError(fmt.Sprintf("file not found: %s", filePath))
 		os.Exit(1)
@@ -58,7 +61,9 @@ func showFileDetails(filePath string, cmd *cobra.Command) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("Analyzing file: %s\n\n", filePath)
+	if !jsonOutput {
+		fmt.Printf("Analyzing file: %s\n\n", filePath)
+	}
 
 	// Use detailed scanner for comprehensive analysis
 	detailedScanner := scanner.NewDetailedScanner()
@@ -68,6 +73,21 @@ func showFileDetails(filePath string, cmd *cobra.Command) {
 		os.Exit(1)
 	}
 
+	if jsonOutput {
+		ciResult(map[string]interface{}{
+			"path":        fileInfo.Path,
+			"type":        fileInfo.Type,
+			"size":        fileInfo.FileSize,
+			"dimensions":  fileInfo.Dimensions,
+			"color_mode":  fileInfo.ColorMode,
+			"application": fileInfo.Version,
+			"layers":      fileInfo.Layers,
+			"layer_names": fileInfo.LayerNames,
+			"artboards":   fileInfo.Artboards,
+		})
+		return
+	}
+
 	printFileDetails(fileInfo, cmd)
 }
 
@@ -86,7 +106,18 @@ func showCommitDetails(commitRef string, cmd *cobra.Command, jsonOutput bool) {
 	if nameOnly {
 		printCommitFileNames(commit, jsonOutput) // 파라미터 추가
 	} else {
-		printCommitDetails(commit, jsonOutput) // 전체 정보도 JSON 지원
+		var previous *log.Commit
+		if commit.Version > 1 {
+			previous, _ = logManager.GetCommit(commit.Version - 1)
+		}
+		printCommitDetails(commit, previous, jsonOutput) // 전체 정보도 JSON 지원
+		if entry := hidden.NewHiddenManager(dgitDir).Get(commit.Version); entry != nil && !jsonOutput {
+			note := fmt.Sprintf("v%d is hidden from listings (since %s)", commit.Version, entry.HiddenAt.Local().Format("2006-01-02"))
+			if entry.Reason != "" {
+				note += ": " + entry.Reason
+			}
+			printInfo(note)
+		}
 	}
 }
 
@@ -127,15 +158,18 @@ func printFileDetails(fileInfo *scanner.DetailedFileInfo, cmd *cobra.Command) {
 	fmt.Printf("\nAnalysis completed\n")
 }
 
-// printCommitDetails displays comprehensive commit information
-func printCommitDetails(commit *log.Commit, jsonOutput bool) {
+// printCommitDetails displays comprehensive commit information; previous, when given,
+// is the commit before it, whose environment and timestamp are compared
+func printCommitDetails(commit *log.Commit, previous *log.Commit, jsonOutput bool) {
 	if jsonOutput {
 		// JSON 출력
 		result := map[string]interface{}{
 			"commit":      commit.Hash,
 			"version":     commit.Version,
 			"author":      commit.Author,
-			"date":        commit.Timestamp.Format("Mon Jan 2 15:04:05 2006"),
+			"date":        commit.Timestamp.Local().Format("Mon Jan 2 15:04:05 2006"),
+			"timestamp":   commit.Timestamp.UTC(),
+			"zone":        log.ZoneOffset(commit.AuthorTime()),
 			"message":     commit.Message,
 			"files_count": commit.FilesCount,
 			"files":       commit.Metadata,
@@ -150,6 +184,13 @@ func printCommitDetails(commit *log.Commit, jsonOutput bool) {
 			}
 		}
 
+		if commit.Environment != nil {
+			result["environment"] = commit.Environment
+			if previous != nil {
+				result["environment_changes"] = environment.Compare(previous.Environment, commit.Environment)
+			}
+		}
+
 		if jsonData, err := json.Marshal(result); err == nil {
 			fmt.Println(string(jsonData))
 		}
@@ -159,7 +200,11 @@ func printCommitDetails(commit *log.Commit, jsonOutput bool) {
 	// 기존 텍스트 출력
 	fmt.Printf("commit %s (v%d)\n", commit.Hash, commit.Version)
 	fmt.Printf("Author: %s\n", commit.Author)
-	fmt.Printf("Date: %s\n", commit.Timestamp.Format("Mon Jan 2 15:04:05 2006"))
+	fmt.Printf("Date: %s\n", formatCommitDate(commit))
+	if previous != nil && commit.Timestamp.Before(previous.Timestamp) {
+		fmt.Printf("%s\n", yellow(describeClockSkew(log.ClockSkew{Version: commit.Version, Timestamp: commit.Timestamp,
+			Parent: previous.Version, ParentTimestamp: previous.Timestamp})))
+	}
 	fmt.Printf("\n    %s\n\n", commit.Message)
 
 	// Storage information
@@ -170,20 +215,59 @@ func printCommitDetails(commit *log.Commit, jsonOutput bool) {
 		if commit.CompressionInfo.BaseVersion > 0 {
 			fmt.Printf("Base version: v%d\n", commit.CompressionInfo.BaseVersion)
 		}
+		if commit.CompressionInfo.DeltaSkipped != "" {
+			fmt.Printf("Delta skipped: %s\n", commit.CompressionInfo.DeltaSkipped)
+		}
 		fmt.Println()
 	}
 
+	if commit.Environment != nil {
+		printCommitEnvironment(commit, previous)
+	}
+
 	// Files in commit
 	fmt.Printf("Files (%d):\n", commit.FilesCount)
 	for fileName, metadata := range commit.Metadata {
 		fmt.Printf("  %s", fileName)
 		if metaMap, ok := metadata.(map[string]interface{}); ok {
 			printStoredMetadata(metaMap) // 기존 함수 활용
+			if template, ok := metaMap["template"].(map[string]interface{}); ok {
+				version, _ := template["version"].(float64)
+				fmt.Printf(" [from template %v@v%.0f]", template["path"], version)
+			}
 		}
 		fmt.Println()
 	}
 }
 
+// printCommitEnvironment displays the recorded tool environment and what changed since previous
+func printCommitEnvironment(commit *log.Commit, previous *log.Commit) {
+	fmt.Println("Environment:")
+	for _, field := range commit.Environment.Fields() {
+		fmt.Printf("  %-15s %s\n", field[0]+":", field[1])
+	}
+	if previous != nil {
+		changes := environment.Compare(previous.Environment, commit.Environment)
+		if previous.Environment == nil {
+			fmt.Printf("  %s\n", cyan(fmt.Sprintf("(v%d recorded no environment)", previous.Version)))
+		} else if len(changes) > 0 {
+			fmt.Printf("  Changed since v%d:\n", previous.Version)
+			for _, change := range changes {
+				fmt.Printf("    %-13s %s -> %s\n", change.Field+":", valueOrNone(change.From), yellow(valueOrNone(change.To)))
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// valueOrNone shows an empty environment value as "none"
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
 // printCommitFileNames displays only file names from commit
 func printCommitFileNames(commit *log.Commit, jsonOutput bool) {
 	if jsonOutput {
@@ -221,11 +305,20 @@ func fileExists(path string) bool {
 }
 
 func findCommit(logManager *log.LogManager, commitRef string) (*log.Commit, error) {
-	// Try by hash first
+	// Tags name commits explicitly, so they win over hash prefixes
+	if commit, err := resolveTag(logManager.DgitDir, commitRef); commit != nil || err != nil {
+		return commit, err
+	}
+
+	// Try by hash
 	commit, err := logManager.GetCommitByHash(commitRef)
 	if err == nil {
 		return commit, nil
 	}
+	var ambiguous *log.AmbiguousHashError
+	if errors.As(err, &ambiguous) {
+		return nil, err
+	}
 
 	// Try by version number
 	if version, parseErr := parseVersion(commitRef); parseErr == nil {
@@ -235,6 +328,20 @@ func findCommit(logManager *log.LogManager, commitRef string) (*log.Commit, erro
 	return nil, fmt.Errorf("commit not found")
 }
 
+// hashLength caches the configured hash abbreviation for this run
+var hashLength int
+
+// abbrevHash shortens a commit hash to the repository's display.hash_length
+func abbrevHash(hash string) string {
+	if hashLength == 0 {
+		hashLength = log.DefaultHashLength
+		if dgitDir := findDgitDirectory(); dgitDir != "" {
+			hashLength = log.HashLength(dgitDir)
+		}
+	}
+	return log.AbbreviateHash(hash, hashLength)
+}
+
 func parseVersion(versionStr string) (int, error) {
 	if strings.HasPrefix(versionStr, "v") {
 		versionStr = strings.TrimPrefix(versionStr, "v")
@@ -271,6 +378,9 @@ func printStoredMetadata(metaMap map[string]interface{}) {
 	if colorMode, ok := metaMap["color_mode"].(string); ok && colorMode != "Unknown" {
 		details = append(details, colorMode)
 	}
+	if profile, ok := metaMap["icc_profile"].(string); ok {
+		details = append(details, profile)
+	}
 
 	if len(details) > 0 {
 		fmt.Printf(" (%s)", strings.Join(details, ", "))
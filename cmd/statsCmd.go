@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/maintenance"
+
+	"github.com/spf13/cobra"
+)
+
+// StatsCmd shows repository storage statistics and archive/prune suggestions
+var StatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show repository statistics and storage suggestions",
+	Long: `Show repository storage statistics and suggest archive/prune candidates.
+
+DGit records when each version is read or restored. Large LZ4 snapshots that
+have not been accessed for a while are suggested for the Zstd archive tier,
+and temporary files left behind by interrupted operations are suggested for
+pruning. Archived versions remain fully restorable.
+
+Examples:
+  dgit stats                       # Statistics and suggestions
+  dgit stats --min-size 1024       # Only versions larger than 1 GB
+  dgit stats --days 180            # Only versions idle for 6 months
+  dgit stats --apply               # Apply suggestions after confirmation
+  dgit stats --apply --yes         # Apply without prompting`,
+	Args: cobra.NoArgs,
+	Run:  runStats,
+}
+
+func init() {
+	StatsCmd.Flags().Int64("min-size", 100, "Minimum snapshot size in MB for archive suggestions")
+	StatsCmd.Flags().Int("days", 0, "Days without access before suggesting archive (default: archive_after_days from config)")
+	StatsCmd.Flags().Bool("apply", false, "Archive/prune the suggested candidates")
+	StatsCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation when applying")
+	StatsCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// runStats prints statistics, suggestions, and optionally applies them
+func runStats(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	logManager := log.NewLogManager(dgitDir)
+	maintenanceManager := maintenance.NewMaintenanceManager(dgitDir)
+
+	minSizeMB, _ := cmd.Flags().GetInt64("min-size")
+	days, _ := cmd.Flags().GetInt("days")
+	apply, _ := cmd.Flags().GetBool("apply")
+	skipConfirm, _ := cmd.Flags().GetBool("yes")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	opts := maintenance.SuggestionOptions{
+		MinSize:        minSizeMB * 1024 * 1024,
+		NotAccessedFor: maintenanceManager.DefaultIdlePeriod(),
+	}
+	if days > 0 {
+		opts.NotAccessedFor = time.Duration(days) * 24 * time.Hour
+	}
+
+	stats, err := logManager.GetCompressionStatistics()
+	if err != nil {
+		printError(fmt.Sprintf("loading commit history: %v", err))
+		os.Exit(1)
+	}
+
+	suggestions, err := maintenanceManager.SuggestCandidates(opts)
+	if err != nil {
+		printError(fmt.Sprintf("analyzing access patterns: %v", err))
+		os.Exit(1)
+	}
+
+	storage := map[string]int64{"cache": directorySize(filepath.Join(dgitDir, "cache"))}
+	storageDir := initializer.GetStorageDir(dgitDir)
+	for _, dir := range initializer.ObjectStorageDirs {
+		storage[dir] = directorySize(filepath.Join(storageDir, dir))
+	}
+
+	disk := maintenanceManager.DiskStatus()
+
+	if jsonOutput {
+		result := map[string]interface{}{
+			"statistics":  stats,
+			"storage":     storage,
+			"disk":        disk,
+			"suggestions": suggestions,
+		}
+		if jsonData, err := json.Marshal(result); err == nil {
+			fmt.Println(string(jsonData))
+		}
+		return
+	}
+
+	displayStatistics(stats, storage)
+	if disk.Low() {
+		fmt.Println()
+		printWarning(fmt.Sprintf("Only %s free on the storage volume (threshold %s)", formatBytes(disk.Free), formatBytes(disk.Threshold)))
+		printSuggestion("Run 'dgit maintenance demote' to move least recently restored versions to the archive")
+	}
+	displaySuggestions(suggestions, opts)
+
+	if len(suggestions) == 0 {
+		return
+	}
+
+	if !apply {
+		fmt.Println()
+		printSuggestion("Run 'dgit stats --apply' to archive/prune these candidates")
+		return
+	}
+
+	fmt.Println()
+	if !skipConfirm && !confirmAction(fmt.Sprintf("Apply %d suggestions?", len(suggestions))) {
+		fmt.Println("Aborted.")
+		return
+	}
+
+	applySuggestions(maintenanceManager, suggestions)
+}
+
+// displayStatistics prints commit and storage totals
+func displayStatistics(stats *log.CompressionStatistics, storage map[string]int64) {
+	fmt.Println(bold("Repository statistics"))
+	fmt.Printf("  Commits:      %d (%d compressed, %d legacy)\n",
+		stats.TotalCommits, stats.CompressedCommits, stats.LegacyCommits)
+
+	if len(stats.StrategyStats) > 0 {
+		strategies := make([]string, 0, len(stats.StrategyStats))
+		for strategy, count := range stats.StrategyStats {
+			strategies = append(strategies, fmt.Sprintf("%s %d", strategy, count))
+		}
+		sort.Strings(strategies)
+		fmt.Printf("  Strategies:   %s\n", strings.Join(strategies, ", "))
+	}
+	fmt.Printf("  Space saved:  %s\n", formatBytes(stats.TotalSavedSpace))
+
+	fmt.Printf("  Storage:      snapshots %s, deltas %s, archive %s, cache %s",
+		formatBytes(storage["snapshots"]), formatBytes(storage["deltas"]),
+		formatBytes(storage["archive"]), formatBytes(storage["cache"]))
+	if storage["objects"] > 0 {
+		fmt.Printf(", legacy %s", formatBytes(storage["objects"]))
+	}
+	fmt.Println()
+}
+
+// displaySuggestions prints grouped archive and prune candidates
+func displaySuggestions(suggestions []*maintenance.Suggestion, opts maintenance.SuggestionOptions) {
+	fmt.Println()
+	fmt.Println(bold("Suggestions"))
+
+	if len(suggestions) == 0 {
+		fmt.Println("  No archive or prune candidates. Storage looks healthy.")
+		return
+	}
+
+	var archive, prune []*maintenance.Suggestion
+	var archiveBytes, pruneBytes int64
+	for _, s := range suggestions {
+		switch s.Kind {
+		case maintenance.KindArchive:
+			archive = append(archive, s)
+			archiveBytes += s.Size
+		case maintenance.KindPrune:
+			prune = append(prune, s)
+			pruneBytes += s.Size
+		}
+	}
+
+	if len(archive) > 0 {
+		fmt.Printf("  %s %d versions larger than %s not accessed in %d days (%s)\n",
+			yellow("archive"), len(archive), formatBytes(opts.MinSize),
+			int(opts.NotAccessedFor.Hours()/24), formatBytes(archiveBytes))
+		for _, s := range archive {
+			fmt.Printf("    v%-5d %10s  last accessed %s (%s)\n",
+				s.Version, formatBytes(s.Size), s.LastAccess.Format("2006-01-02"), s.Reason)
+		}
+	}
+
+	if len(prune) > 0 {
+		fmt.Printf("  %s %d leftover temporary files (%s)\n",
+			yellow("prune"), len(prune), formatBytes(pruneBytes))
+	}
+}
+
+// applySuggestions archives/prunes each candidate and reports reclaimed space
+func applySuggestions(maintenanceManager *maintenance.MaintenanceManager, suggestions []*maintenance.Suggestion) {
+	var reclaimed int64
+	applied := 0
+
+	report, err := maintenanceManager.Track("stats --apply", func() error {
+		for _, s := range suggestions {
+			saved, err := maintenanceManager.Apply(s)
+			if err != nil {
+				printWarning(fmt.Sprintf("%s %s: %v", s.Kind, filepath.Base(s.Path), err))
+				continue
+			}
+			if s.Kind == maintenance.KindArchive {
+				fmt.Printf("Archived v%d (%s saved)\n", s.Version, formatBytes(saved))
+			}
+			reclaimed += saved
+			applied++
+		}
+		return nil
+	})
+
+	printSuccess(fmt.Sprintf("Applied %d of %d suggestions, reclaimed %s", applied, len(suggestions), formatBytes(reclaimed)))
+	printCompactionSummary(report, err)
+}
+
+// directorySize sums file sizes under a directory (missing directories count as zero)
+func directorySize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
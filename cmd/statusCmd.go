@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"dgit/internal/derived"
 	"dgit/internal/log"
+	"dgit/internal/queue"
+	"dgit/internal/restore"
 	"dgit/internal/scanner"
 	"dgit/internal/staging"
 	"dgit/internal/status"
@@ -22,16 +26,28 @@ var StatusCmd = &cobra.Command{
 - Files staged for commit
 - Modified files not yet staged  
 - Untracked design files
+- App autosave/recovery files (listed separately, never staged by default)
 - Deleted files
+- Exports generated from a source version that has since been committed again
+- Repository health score, with findings detailed by 'dgit doctor'
 
 Shows metadata changes for design files such as layer count, 
-dimension changes, and color mode changes.`,
+dimension changes, and color mode changes.
+
+With --sizes, each changed file also shows how much it grew or shrank since its
+last committed version, and the storage the next commit is expected to add is
+estimated from how well recent commits compressed.`,
 	Run: runStatus,
 }
 
+func init() {
+	StatusCmd.Flags().Bool("sizes", false, "Show size changes and estimate the storage the next commit will add")
+}
+
 // runStatus shows repository status with design file metadata changes
 func runStatus(cmd *cobra.Command, args []string) {
 	dgitDir := checkDgitRepository()
+	showSizes, _ := cmd.Flags().GetBool("sizes")
 
 	stagingArea := staging.NewStagingArea(dgitDir)
 	statusManager := status.NewStatusManager(dgitDir)
@@ -43,43 +59,72 @@ func runStatus(cmd *cobra.Command, args []string) {
 	}
 
 	currentVersion := logManager.GetCurrentVersion()
+	currentWorkDir, _ := os.Getwd()
+
+	var stagedSizes map[string]status.SizeChange
+	if showSizes {
+		stagedSizes = sizeChangesByPath(statusManager, stagedPaths(stagingArea), currentWorkDir)
+	}
+
+	result, compareErr := statusManager.CompareWithCommit(currentVersion, scanCurrentDirectory(currentWorkDir))
+	var autosaveFiles []status.FileStatus
+	var modifiedSizes map[string]status.SizeChange
+	if compareErr == nil {
+		result.ModifiedFiles = filterStagedFiles(result.ModifiedFiles, stagingArea)
+		result.UntrackedFiles = filterStagedFiles(result.UntrackedFiles, stagingArea)
+		result.DeletedFiles = filterStagedRemovals(filterStagedFiles(result.DeletedFiles, stagingArea), stagingArea)
+		result.UntrackedFiles, autosaveFiles = splitAutosaveFiles(result.UntrackedFiles)
+
+		if showSizes {
+			var paths []string
+			for _, fileStatus := range result.ModifiedFiles {
+				paths = append(paths, fileStatus.Path)
+			}
+			modifiedSizes = sizeChangesByPath(statusManager, paths, currentWorkDir)
+		}
+	}
+
+	if ciMode {
+		if compareErr != nil {
+			printError(fmt.Sprintf("comparing with last commit: %v", compareErr))
+			exit(ExitError)
+		}
+		printStatusResult(dgitDir, currentVersion, stagingArea, result, autosaveFiles, stagedSizes, modifiedSizes)
+		return
+	}
+
 	fmt.Printf("On version %d\n\n", currentVersion+1)
+	printQueueSummary(dgitDir)
+	printInterruptedCheckout(dgitDir)
 
 	if !stagingArea.IsEmpty() {
 		fmt.Println("Changes to be committed:")
-		printStatusStagingInfo(stagingArea)
+		printStatusStagingInfo(stagingArea, stagedSizes)
 		fmt.Println()
 	} else {
 		fmt.Println("No changes staged for commit.")
 		fmt.Println()
 	}
 
-	currentWorkDir, _ := os.Getwd()
-	currentDirFiles := scanCurrentDirectory(currentWorkDir)
-
-	result, err := statusManager.CompareWithCommit(currentVersion, currentDirFiles)
-	if err != nil {
-		printWarning(fmt.Sprintf("Failed to compare with last commit: %v", err))
+	if compareErr != nil {
+		printWarning(fmt.Sprintf("Failed to compare with last commit: %v", compareErr))
 		return
 	}
 
 	var lastCommit *log.Commit
 	if currentVersion > 0 {
+		var err error
 		lastCommit, err = logManager.GetCommit(currentVersion)
 		if err != nil {
 			printWarning(fmt.Sprintf("Failed to load last commit for metadata comparison: %v", err))
 		}
 	}
 
-	result.ModifiedFiles = filterStagedFiles(result.ModifiedFiles, stagingArea)
-	result.UntrackedFiles = filterStagedFiles(result.UntrackedFiles, stagingArea)
-	result.DeletedFiles = filterStagedFiles(result.DeletedFiles, stagingArea)
-
 	if len(result.ModifiedFiles) > 0 {
 		fmt.Println("Changes not staged for commit:")
 		for _, fileStatus := range result.ModifiedFiles {
 			metadataSummary := getMetadataChangeSummary(fileStatus.Path, lastCommit, currentWorkDir)
-			fmt.Printf("  modified: %s%s\n", fileStatus.Path, metadataSummary)
+			fmt.Printf("  modified: %s%s%s\n", fileStatus.Path, metadataSummary, sizeChangeSummary(modifiedSizes, fileStatus.Path))
 		}
 		fmt.Println()
 	} else {
@@ -97,22 +142,101 @@ func runStatus(cmd *cobra.Command, args []string) {
 		fmt.Println("No untracked files.")
 	}
 
+	if len(autosaveFiles) > 0 {
+		fmt.Println("Autosave/recovery files (not staged by default):")
+		for _, fileStatus := range autosaveFiles {
+			fmt.Printf("  %s %s\n", yellow("[autosave]"), fileStatus.Path)
+		}
+		fmt.Println()
+	}
+
 	if len(result.DeletedFiles) > 0 {
 		fmt.Println("Deleted files:")
 		for _, fileStatus := range result.DeletedFiles {
 			fmt.Printf("  deleted: %s\n", fileStatus.Path)
 		}
+		fmt.Println("  (use 'dgit rm <file>' to record the deletion in the next commit)")
 		fmt.Println()
 	} else {
 		fmt.Println("No deleted files.")
 	}
 
+	printStaleExports(dgitDir)
+
+	if showSizes {
+		printCommitEstimate(statusManager, stagedSizes, modifiedSizes)
+	}
+
 	fmt.Println("Commands:")
 	fmt.Println("   Use 'dgit add <file>' to stage files for commit")
 	fmt.Println("   Use 'dgit commit' to commit staged changes")
 	if len(result.ModifiedFiles) > 0 || len(result.UntrackedFiles) > 0 {
 		fmt.Println("   Use 'dgit scan' to analyze design file details")
 	}
+
+	printHealthSummary(dgitDir)
+}
+
+// statusEntry is one file in the CI status result; size fields are set with --sizes
+type statusEntry struct {
+	Path          string `json:"path"`
+	RenamedFrom   string `json:"renamed_from,omitempty"`
+	Size          int64  `json:"size,omitempty"`
+	CommittedSize int64  `json:"committed_size,omitempty"`
+	SinceVersion  int    `json:"since_version,omitempty"`
+}
+
+// printStatusResult prints the working tree status as JSON for CI mode
+func printStatusResult(dgitDir string, currentVersion int, stagingArea *staging.StagingArea, result *status.FileStatusResult,
+	autosaveFiles []status.FileStatus, stagedSizes, modifiedSizes map[string]status.SizeChange) {
+	entries := func(files []status.FileStatus, sizes map[string]status.SizeChange) []statusEntry {
+		list := []statusEntry{}
+		for _, file := range files {
+			list = append(list, newStatusEntry(file.Path, sizes))
+		}
+		return list
+	}
+
+	staged := []statusEntry{}
+	for _, path := range stagedPaths(stagingArea) {
+		entry := newStatusEntry(path, stagedSizes)
+		entry.RenamedFrom, _ = stagingArea.RenamedFrom(path)
+		staged = append(staged, entry)
+	}
+	sort.Slice(staged, func(i, j int) bool { return staged[i].Path < staged[j].Path })
+
+	staleExports := []string{}
+	if stale, err := derived.NewDerivedManager(dgitDir).Stale(); err == nil {
+		for _, link := range stale {
+			staleExports = append(staleExports, link.Export)
+		}
+	}
+
+	output := map[string]interface{}{
+		"version":       currentVersion,
+		"staged":        staged,
+		"removed":       stagingArea.GetRemovedFiles(),
+		"modified":      entries(result.ModifiedFiles, modifiedSizes),
+		"untracked":     entries(result.UntrackedFiles, nil),
+		"autosave":      entries(autosaveFiles, nil),
+		"deleted":       entries(result.DeletedFiles, nil),
+		"stale_exports": staleExports,
+	}
+	if journal, err := restore.LoadJournal(dgitDir); err == nil && journal != nil {
+		output["interrupted_checkout"] = journal.Version
+	}
+	ciResult(output)
+}
+
+// newStatusEntry describes path, with its size change when one was measured
+func newStatusEntry(path string, sizes map[string]status.SizeChange) statusEntry {
+	entry := statusEntry{Path: path}
+	if change, ok := sizes[path]; ok {
+		entry.Size = change.Size
+		entry.CommittedSize = change.CommittedSize
+		entry.SinceVersion = change.Version
+	}
+	return entry
 }
 
 // scanCurrentDirectory scans for design files and returns their hashes
@@ -130,7 +254,7 @@ func scanCurrentDirectory(currentWorkDir string) map[string]string {
 			return nil
 		}
 
-		if scanner.IsDesignFile(path) {
+		if scanner.IsDesignFile(path) || scanner.IsAutosaveFile(path) {
 			relPath, relErr := filepath.Rel(currentWorkDir, path)
 			if relErr != nil {
 				return nil
@@ -148,6 +272,35 @@ func scanCurrentDirectory(currentWorkDir string) map[string]string {
 	return currentDirFiles
 }
 
+// splitAutosaveFiles separates app autosave/recovery files from real untracked files
+func splitAutosaveFiles(files []status.FileStatus) ([]status.FileStatus, []status.FileStatus) {
+	var regular, autosave []status.FileStatus
+	for _, file := range files {
+		if scanner.IsAutosaveFile(file.Path) {
+			autosave = append(autosave, file)
+		} else {
+			regular = append(regular, file)
+		}
+	}
+	return regular, autosave
+}
+
+// filterStagedRemovals removes deletions already staged with 'dgit rm' or explained by 'dgit mv'
+func filterStagedRemovals(files []status.FileStatus, stagingArea *staging.StagingArea) []status.FileStatus {
+	moved := make(map[string]bool)
+	for _, oldPath := range stagingArea.GetRenamedFiles() {
+		moved[oldPath] = true
+	}
+
+	var filtered []status.FileStatus
+	for _, file := range files {
+		if !stagingArea.IsRemoved(file.Path) && !moved[file.Path] {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
 // filterStagedFiles removes files that are already staged
 func filterStagedFiles(files []status.FileStatus, stagingArea *staging.StagingArea) []status.FileStatus {
 	var filtered []status.FileStatus
@@ -220,9 +373,149 @@ func getStatusFileType(filePath string) string {
 }
 
 // printStagingStatus displays files staged for commit
-func printStatusStagingInfo(stagingArea *staging.StagingArea) {
+// sizes, when not nil, adds each file's size change since its last commit
+func printStatusStagingInfo(stagingArea *staging.StagingArea, sizes map[string]status.SizeChange) {
 	for _, file := range stagingArea.GetStagedFiles() {
 		fileType := getStatusFileType(file.Path)
-		fmt.Printf("  [%s] new file: %s\n", fileType, file.Path)
+		if oldPath, ok := stagingArea.RenamedFrom(file.Path); ok {
+			fmt.Printf("  [%s] renamed: %s -> %s%s\n", fileType, oldPath, file.Path, sizeChangeSummary(sizes, file.Path))
+			continue
+		}
+		fmt.Printf("  [%s] new file: %s%s\n", fileType, file.Path, sizeChangeSummary(sizes, file.Path))
+	}
+	for _, path := range stagingArea.GetRemovedFiles() {
+		fmt.Printf("  [%s] deleted: %s\n", getStatusFileType(path), path)
+	}
+}
+
+// stagedPaths lists the paths of staged files
+func stagedPaths(stagingArea *staging.StagingArea) []string {
+	var paths []string
+	for _, file := range stagingArea.GetStagedFiles() {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// sizeChangesByPath looks up size changes for paths, keyed by path
+func sizeChangesByPath(statusManager *status.StatusManager, paths []string, workDir string) map[string]status.SizeChange {
+	changes := make(map[string]status.SizeChange)
+	for _, change := range statusManager.SizeChanges(paths, workDir) {
+		changes[change.Path] = change
+	}
+	return changes
+}
+
+// sizeChangeSummary describes a file's size change since its last commit
+func sizeChangeSummary(sizes map[string]status.SizeChange, path string) string {
+	change, ok := sizes[path]
+	if !ok {
+		return ""
+	}
+	if change.Version == 0 {
+		return fmt.Sprintf(" (%s, not committed before)", formatBytes(change.Size))
+	}
+
+	delta := change.Delta()
+	switch {
+	case delta > 0:
+		return " " + yellow(fmt.Sprintf("(+%s since v%d)", formatBytes(delta), change.Version))
+	case delta < 0:
+		return " " + green(fmt.Sprintf("(-%s since v%d)", formatBytes(-delta), change.Version))
+	}
+	return fmt.Sprintf(" (same size as v%d)", change.Version)
+}
+
+// printCommitEstimate estimates the storage the next commit will add
+func printCommitEstimate(statusManager *status.StatusManager, staged, modified map[string]status.SizeChange) {
+	var stagedBytes, modifiedBytes int64
+	for _, change := range staged {
+		stagedBytes += change.Size
+	}
+	for _, change := range modified {
+		modifiedBytes += change.Size
+	}
+
+	fmt.Println("Next commit:")
+	if len(staged) == 0 {
+		fmt.Println("  nothing staged")
+	} else {
+		estimate := statusManager.EstimateCommitStorage(stagedBytes)
+		fmt.Printf("  ~%s of storage for %d staged file(s) (%s)\n",
+			cyan(formatBytes(estimate.StoredBytes)), len(staged), estimateBasis(estimate))
+	}
+	if len(modified) > 0 {
+		estimate := statusManager.EstimateCommitStorage(stagedBytes + modifiedBytes)
+		fmt.Printf("  ~%s if the %d modified file(s) are staged too\n",
+			cyan(formatBytes(estimate.StoredBytes)), len(modified))
+	}
+	fmt.Println()
+}
+
+// estimateBasis explains where a storage estimate's compression ratio comes from
+func estimateBasis(estimate status.StorageEstimate) string {
+	kind := "snapshot"
+	if estimate.Delta {
+		kind = "delta"
+	}
+	if estimate.Samples == 0 {
+		return fmt.Sprintf("typical %s compression, %.0f%% of original", kind, estimate.Ratio*100)
+	}
+	return fmt.Sprintf("%.0f%% of original, based on %d recent %s commit(s)", estimate.Ratio*100, estimate.Samples, kind)
+}
+
+// printStaleExports warns about exports whose source has newer commits
+func printStaleExports(dgitDir string) {
+	stale, err := derived.NewDerivedManager(dgitDir).Stale()
+	if err != nil || len(stale) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Stale exports (source committed since they were generated):")
+	for _, link := range stale {
+		fmt.Printf("  %s %s  (from %s v%d, now v%d)\n", yellow("[stale]"), link.Export, link.Source, link.Version, link.LatestVersion)
+	}
+	fmt.Println("  (re-export, then run 'dgit derived add <export> --derived-from <source>')")
+	fmt.Println()
+}
+
+// printInterruptedCheckout reports a restore that can be continued with --continue
+func printInterruptedCheckout(dgitDir string) {
+	journal, err := restore.LoadJournal(dgitDir)
+	if err != nil || journal == nil {
+		return
+	}
+	printWarning(fmt.Sprintf("Checkout of v%d was interrupted (%s phase, %d of %d file(s) placed)",
+		journal.Version, journal.Phase, journal.Placed, len(journal.Staged)))
+	printSuggestion("Finish it with 'dgit checkout --continue' or discard it with 'dgit checkout --abort'")
+	fmt.Println()
+}
+
+// printQueueSummary reports background commits that are pending or failed
+func printQueueSummary(dgitDir string) {
+	jobs, err := queue.NewQueueManager(dgitDir).List()
+	if err != nil || len(jobs) == 0 {
+		return
+	}
+
+	pending, failed := 0, 0
+	for _, job := range jobs {
+		switch job.State {
+		case queue.StateQueued, queue.StateRunning:
+			pending++
+		case queue.StateFailed:
+			failed++
+		}
+	}
+
+	if pending > 0 {
+		fmt.Printf("Commit queue: %d commit(s) being stored in the background\n", pending)
+	}
+	if failed > 0 {
+		printWarning(fmt.Sprintf("%d queued commit(s) failed; see 'dgit queue'", failed))
+	}
+	if pending > 0 || failed > 0 {
+		fmt.Println()
 	}
 }
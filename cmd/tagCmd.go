@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"dgit/internal/activity"
+	"dgit/internal/log"
+	"dgit/internal/tag"
+
+	"github.com/spf13/cobra"
+)
+
+// TagCmd names versions
+var TagCmd = &cobra.Command{
+	Use:   "tag [<name> [<version>]]",
+	Short: "Name a version, or list tags",
+	Long: `Name a version so it can be referred to by that name.
+
+A tag can be used wherever a version or commit hash is accepted ('dgit show',
+'dgit restore', 'dgit hide', 'dgit pin'...). Without a version the tag names the
+latest commit. Tags are kept in .dgit/tags.json, outside the commit records, so
+tagging never changes commit hashes. Names that read as versions ("17", "v17")
+are not allowed.
+
+Examples:
+  dgit tag                                  # List tags
+  dgit tag client-approved                  # Name the latest version
+  dgit tag delivery-2026-10 v14 -m "final"  # Name v14
+  dgit tag -f client-approved v15           # Move an existing tag
+  dgit tag -d client-approved               # Delete a tag`,
+	Args: cobra.MaximumNArgs(2),
+	Run:  runTag,
+}
+
+func init() {
+	TagCmd.Flags().StringP("message", "m", "", "Note stored with the tag")
+	TagCmd.Flags().BoolP("force", "f", false, "Move the tag if it already names another version")
+	TagCmd.Flags().BoolP("delete", "d", false, "Delete the named tag")
+	TagCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// runTag lists, creates or deletes tags
+func runTag(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		dgitDir := checkDgitRepository()
+		asJSON, _ := cmd.Flags().GetBool("json")
+		printTags(dgitDir, tag.NewTagManager(dgitDir), asJSON)
+		return
+	}
+
+	dgitDir := checkWritableRepository()
+	manager := tag.NewTagManager(dgitDir)
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	checkNotMirror(dgitDir)
+	name := args[0]
+	if remove, _ := cmd.Flags().GetBool("delete"); remove {
+		deleted, err := manager.Delete(name)
+		if err != nil {
+			printError(err.Error())
+			exit(ExitNotFound)
+		}
+		if asJSON || ciMode {
+			ciResult(map[string]interface{}{"deleted": deleted})
+			return
+		}
+		printSuccess(fmt.Sprintf("Deleted tag '%s' (was v%d)", name, deleted.Version))
+		return
+	}
+
+	var commit *log.Commit
+	if len(args) == 2 {
+		commit = commitForVersionArg(dgitDir, args[1])
+	} else if commit = headCommit(dgitDir); commit == nil {
+		printError("no commits to tag yet")
+		exit(ExitNotFound)
+	}
+
+	message, _ := cmd.Flags().GetString("message")
+	force, _ := cmd.Flags().GetBool("force")
+	user, _ := activity.Identity()
+	previous, err := manager.Set(name, commit.Version, commit.Hash, message, user, force)
+	var exists *tag.ExistsError
+	if errors.As(err, &exists) {
+		printError(err.Error())
+		printSuggestion(fmt.Sprintf("Use 'dgit tag -f %s v%d' to move it", name, commit.Version))
+		exit(ExitConflict)
+	}
+	if err != nil {
+		printError(err.Error())
+		exit(ExitUsage)
+	}
+
+	if asJSON || ciMode {
+		ciResult(map[string]interface{}{"tag": manager.Get(name), "previous": previous})
+		return
+	}
+	if previous != nil && previous.Version != commit.Version {
+		printSuccess(fmt.Sprintf("Moved tag '%s' from v%d to v%d (%s)", name, previous.Version, commit.Version, abbrevHash(commit.Hash)))
+		return
+	}
+	printSuccess(fmt.Sprintf("Tagged v%d (%s) as '%s'", commit.Version, abbrevHash(commit.Hash), name))
+}
+
+// printTags lists tags with their versions and commit messages
+func printTags(dgitDir string, manager *tag.TagManager, asJSON bool) {
+	tags, err := manager.List()
+	if err != nil {
+		printError(err.Error())
+		exit(ExitError)
+	}
+	if asJSON || ciMode {
+		if tags == nil {
+			tags = []*tag.Tag{}
+		}
+		ciResult(tags)
+		return
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags.")
+		return
+	}
+
+	logManager := log.NewLogManager(dgitDir)
+	for _, t := range tags {
+		message := t.Message
+		if commit, err := logManager.GetCommit(t.Version); err == nil && message == "" {
+			message = commit.Message
+		}
+		fmt.Printf("  %-24s %-6s %s  %s\n", green(t.Name), cyan(fmt.Sprintf("v%d", t.Version)), abbrevHash(t.Hash), message)
+	}
+}
+
+// commitForVersionArg resolves one version argument ("v17", "17", hash prefix or tag),
+// exiting on unknown ones
+func commitForVersionArg(dgitDir, arg string) *log.Commit {
+	versions := resolveVersionArgs(dgitDir, []string{arg})
+	commit, err := log.NewLogManager(dgitDir).GetCommit(versions[0])
+	if err != nil {
+		printError(fmt.Sprintf("version '%s' not found", arg))
+		exit(ExitNotFound)
+	}
+	return commit
+}
+
+// resolveTag returns the commit a tag names; nil with no error when ref is not a tag
+func resolveTag(dgitDir, ref string) (*log.Commit, error) {
+	if tag.ValidateName(ref) != nil {
+		return nil, nil
+	}
+	return tag.NewTagManager(dgitDir).Resolve(ref)
+}
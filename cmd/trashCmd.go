@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dgit/internal/trash"
+
+	"github.com/spf13/cobra"
+)
+
+// TrashCmd manages working tree backups taken before destructive operations
+var TrashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List and recover files backed up before forced restores",
+	Long: `List and recover working tree files that were backed up to .dgit/trash
+before 'dgit restore --force' (or 'dgit checkout --force') overwrote them.
+
+Examples:
+  dgit trash list                    # Show backups, newest first
+  dgit trash restore 20261016-1015   # Put backed up files back (ID prefix is enough)
+  dgit trash drop 20261016-1015      # Permanently delete a backup`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trash entries",
+	Args:  cobra.NoArgs,
+	Run:   runTrashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Recover the files in a trash entry into the working directory",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTrashRestore,
+}
+
+var trashDropCmd = &cobra.Command{
+	Use:   "drop <id>",
+	Short: "Permanently delete a trash entry",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTrashDrop,
+}
+
+func init() {
+	TrashCmd.AddCommand(trashListCmd)
+	TrashCmd.AddCommand(trashRestoreCmd)
+	TrashCmd.AddCommand(trashDropCmd)
+}
+
+// runTrashList prints all trash entries
+func runTrashList(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+
+	entries, err := trash.NewTrashManager(dgitDir).List()
+	if err != nil {
+		printError(fmt.Sprintf("reading trash: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		if entries == nil {
+			entries = []*trash.Entry{}
+		}
+		ciResult(entries)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("Trash is empty.")
+		return
+	}
+
+	for _, entry := range entries {
+		var total int64
+		for _, file := range entry.Files {
+			total += file.Size
+		}
+		fmt.Printf("%s  %s  %d files, %s  (%s)\n",
+			entry.ID, entry.CreatedAt.Format("2006-01-02 15:04"), len(entry.Files), formatBytes(total), entry.Reason)
+		for _, file := range entry.Files {
+			fmt.Printf("    %s\n", file.Path)
+		}
+	}
+}
+
+// runTrashRestore copies an entry's files back into the working directory
+func runTrashRestore(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	workDir := filepath.Dir(dgitDir)
+
+	recovered, err := trash.NewTrashManager(dgitDir).Recover(args[0], workDir)
+	if ciMode {
+		if recovered == nil {
+			recovered = []string{}
+		}
+		ciResult(map[string]interface{}{"recovered": recovered})
+	} else {
+		for _, path := range recovered {
+			fmt.Printf("  recovered: %s\n", path)
+		}
+	}
+	if err != nil {
+		printError(fmt.Sprintf("recovering trash entry: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		return
+	}
+
+	printSuccess(fmt.Sprintf("Recovered %d files", len(recovered)))
+}
+
+// runTrashDrop deletes a trash entry
+func runTrashDrop(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+
+	if err := trash.NewTrashManager(dgitDir).Drop(args[0]); err != nil {
+		printError(fmt.Sprintf("dropping trash entry: %v", err))
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"dropped": args[0]})
+		return
+	}
+	printSuccess(fmt.Sprintf("Dropped trash entry %s", args[0]))
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+
+	"dgit/internal/log"
+
+	"github.com/spf13/cobra"
+)
+
+// VerifyHistoryCmd validates the commit hash chain
+var VerifyHistoryCmd = &cobra.Command{
+	Use:   "verify-history",
+	Short: "Check that no past commit record has been modified",
+	Long: `Validate the whole commit history as a hash chain.
+
+Each commit's hash is computed from its complete record (message, author, time,
+file metadata and content hashes, storage details) including the hash of its
+parent. Editing any past commit file therefore breaks its own hash and the link
+from the commit after it, and HEAD no longer names the latest commit.
+
+Commits timestamped before their parent, made on a machine whose clock was
+behind, are listed as warnings; they do not fail the check.
+
+Commits made before hash chaining was introduced can only be checked for correct
+parent links; they are reported as legacy.
+
+Examples:
+  dgit verify-history          # Check the chain and report problems
+  dgit verify-history --json   # Machine-readable report`,
+	Args: cobra.NoArgs,
+	Run:  runVerifyHistory,
+}
+
+func init() {
+	VerifyHistoryCmd.Flags().Bool("json", false, "Output in JSON format")
+}
+
+// runVerifyHistory verifies the chain and exits non-zero on any problem
+func runVerifyHistory(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	report, err := log.NewLogManager(dgitDir).VerifyHistory()
+	if err != nil {
+		printError(err.Error())
+		exit(ExitError)
+	}
+
+	if jsonOutput {
+		ciResult(report)
+		if !report.OK() {
+			exit(ExitVerifyFailed)
+		}
+		return
+	}
+
+	if report.Commits == 0 {
+		fmt.Println("No commits to verify.")
+		return
+	}
+
+	if !report.OK() {
+		printError(fmt.Sprintf("history integrity check failed: %d problem(s) in %d commit(s)", len(report.Issues), report.Commits))
+		for _, issue := range report.Issues {
+			fmt.Printf("  v%-5d %s\n", issue.Version, issue.Problem)
+		}
+		printSuggestion("Compare with a mirror or backup ('dgit mirror verify') to find the original records")
+		printClockSkew(report.ClockSkew)
+		exit(ExitVerifyFailed)
+	}
+
+	printSuccess(fmt.Sprintf("History intact: %d commit(s) chained up to HEAD %s", report.Commits, report.Head))
+	if report.Legacy > 0 {
+		printInfo(fmt.Sprintf("%d commit(s) predate hash chaining; only their parent links were checked", report.Legacy))
+	}
+	printClockSkew(report.ClockSkew)
+}
+
+// printClockSkew warns about commits timestamped before their parent
+func printClockSkew(skews []log.ClockSkew) {
+	if len(skews) == 0 {
+		return
+	}
+	printWarning(fmt.Sprintf("%d commit(s) have timestamps earlier than their parent (clock skew)", len(skews)))
+	for _, skew := range skews {
+		fmt.Printf("  v%-5d %s\n", skew.Version, describeClockSkew(skew))
+	}
+	printInfo("History order is unaffected; check the system clock on the machines that made them.")
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dgit/internal/worktree"
+
+	"github.com/spf13/cobra"
+)
+
+// WorktreeCmd manages additional checkouts that share the repository's object store
+var WorktreeCmd = &cobra.Command{
+	Use:   "worktree",
+	Short: "Check out versions into extra directories for side-by-side review",
+	Long: `Manage additional checked-out trees linked to this repository.
+
+Each worktree is a plain directory containing the files of one version, restored
+from the repository's object store, so two versions can be compared side by side
+in Finder/Explorer or opened together in the design app. The repository's storage
+is shared, never duplicated.
+
+Examples:
+  dgit worktree add ../review-v12 v12     # Check out v12 next to the project
+  dgit worktree list                      # Show worktrees and their versions
+  dgit worktree remove review-v12         # Delete it (refuses if files were edited)
+  dgit worktree prune                     # Forget worktrees deleted by hand`,
+}
+
+var worktreeAddCmd = &cobra.Command{
+	Use:   "add <path> <version>",
+	Short: "Check out a version into a new directory",
+	Args:  cobra.ExactArgs(2),
+	Run:   runWorktreeAdd,
+}
+
+var worktreeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List worktrees",
+	Args:  cobra.NoArgs,
+	Run:   runWorktreeList,
+}
+
+var worktreeRemoveCmd = &cobra.Command{
+	Use:   "remove <name|path>",
+	Short: "Delete a worktree directory and unregister it",
+	Args:  cobra.ExactArgs(1),
+	Run:   runWorktreeRemove,
+}
+
+var worktreePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Unregister worktrees whose directories no longer exist",
+	Args:  cobra.NoArgs,
+	Run:   runWorktreePrune,
+}
+
+func init() {
+	worktreeRemoveCmd.Flags().BoolP("force", "f", false, "Delete even if files in the worktree were edited")
+
+	WorktreeCmd.AddCommand(worktreeAddCmd)
+	WorktreeCmd.AddCommand(worktreeListCmd)
+	WorktreeCmd.AddCommand(worktreeRemoveCmd)
+	WorktreeCmd.AddCommand(worktreePruneCmd)
+}
+
+// runWorktreeAdd checks out a version into a new directory
+func runWorktreeAdd(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+
+	// A worktree inside the project would show up as untracked files
+	if absPath, err := filepath.Abs(args[0]); err == nil {
+		repoRoot := filepath.Dir(dgitDir)
+		if strings.HasPrefix(absPath, repoRoot+string(filepath.Separator)) {
+			printWarning(fmt.Sprintf("%s is inside the project; its files will appear in 'dgit status'", args[0]))
+		}
+	}
+
+	manager := worktree.NewWorktreeManager(dgitDir)
+	manager.Progress = ciProgress()
+	wt, err := manager.Add(args[0], args[1])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"name": wt.Name, "path": wt.Path, "version": wt.Version, "hash": wt.Hash, "files": len(wt.Files)})
+		return
+	}
+
+	fmt.Println()
+	printSuccess(fmt.Sprintf("Worktree '%s' created at %s (v%d, %d files)", wt.Name, wt.Path, wt.Version, len(wt.Files)))
+	printInfo(fmt.Sprintf("Remove it with: dgit worktree remove %s", wt.Name))
+}
+
+// runWorktreeList prints registered worktrees
+func runWorktreeList(cmd *cobra.Command, _ []string) {
+	dgitDir := checkDgitRepository()
+	manager := worktree.NewWorktreeManager(dgitDir)
+
+	worktrees, err := manager.List()
+	if err != nil {
+		printError(fmt.Sprintf("reading worktrees: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		entries := []map[string]interface{}{}
+		for _, wt := range worktrees {
+			entries = append(entries, map[string]interface{}{
+				"name": wt.Name, "path": wt.Path, "version": wt.Version,
+				"missing": wt.Missing(), "modified": manager.Modified(wt),
+			})
+		}
+		ciResult(map[string]interface{}{"main": filepath.Dir(dgitDir), "worktrees": entries})
+		return
+	}
+
+	fmt.Printf("%-20s  %s\n", "(main)", filepath.Dir(dgitDir))
+	for _, wt := range worktrees {
+		state := ""
+		if wt.Missing() {
+			state = yellow("  [missing]")
+		} else if modified := manager.Modified(wt); len(modified) > 0 {
+			state = yellow(fmt.Sprintf("  [%d modified]", len(modified)))
+		}
+		fmt.Printf("%-20s  %s  v%d%s\n", wt.Name, wt.Path, wt.Version, state)
+	}
+}
+
+// runWorktreeRemove deletes a worktree
+func runWorktreeRemove(cmd *cobra.Command, args []string) {
+	dgitDir := checkWritableRepository()
+	manager := worktree.NewWorktreeManager(dgitDir)
+	force, _ := cmd.Flags().GetBool("force")
+
+	wt, err := manager.Find(args[0])
+	if err != nil {
+		printError(err.Error())
+		os.Exit(1)
+	}
+
+	if err := manager.Remove(wt, force); err != nil {
+		printError(err.Error())
+		if !force {
+			printSuggestion("Copy out anything you want to keep, or use --force to delete anyway")
+		}
+		os.Exit(1)
+	}
+	if ciMode {
+		ciResult(map[string]interface{}{"removed": wt.Name, "path": wt.Path})
+		return
+	}
+	printSuccess(fmt.Sprintf("Removed worktree '%s' (%s)", wt.Name, wt.Path))
+}
+
+// runWorktreePrune unregisters worktrees deleted outside dgit
+func runWorktreePrune(cmd *cobra.Command, _ []string) {
+	dgitDir := checkWritableRepository()
+
+	pruned, err := worktree.NewWorktreeManager(dgitDir).Prune()
+	if err != nil {
+		printError(fmt.Sprintf("pruning worktrees: %v", err))
+		os.Exit(1)
+	}
+
+	if ciMode {
+		names := []string{}
+		for _, wt := range pruned {
+			names = append(names, wt.Name)
+		}
+		ciResult(map[string]interface{}{"pruned": names})
+		return
+	}
+	if len(pruned) == 0 {
+		fmt.Println("Nothing to prune.")
+		return
+	}
+	for _, wt := range pruned {
+		fmt.Printf("Pruned %s (%s)\n", wt.Name, wt.Path)
+	}
+}
@@ -0,0 +1,179 @@
+package access
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/generation"
+	"dgit/internal/metapack"
+	"dgit/internal/repolock"
+)
+
+// VersionAccess records when a version's stored data was last read or restored
+type VersionAccess struct {
+	LastRead     time.Time `json:"last_read,omitempty"`    // Delta base reads
+	LastRestore  time.Time `json:"last_restore,omitempty"` // Explicit user restores
+	ReadCount    int       `json:"read_count"`             // Number of reads
+	RestoreCount int       `json:"restore_count"`          // Number of restores
+}
+
+// LastAccess returns the most recent read or restore time
+func (va *VersionAccess) LastAccess() time.Time {
+	if va.LastRestore.After(va.LastRead) {
+		return va.LastRestore
+	}
+	return va.LastRead
+}
+
+// AccessTracker maintains per-version access times in cache metadata (.dgit/cache/access.json)
+// Writers hold cache/access.json.lock and replace the index with a rename, so readers
+// never see a partial file and concurrent recorders never lose each other's updates
+type AccessTracker struct {
+	DgitDir   string
+	IndexFile string
+	versions  map[string]*VersionAccess
+}
+
+// accessIndex is the on-disk layout; older indexes are a bare version map
+type accessIndex struct {
+	Generation uint64                    `json:"generation"`
+	Versions   map[string]*VersionAccess `json:"versions"`
+}
+
+// NewAccessTracker creates a new access tracker for the repository
+func NewAccessTracker(dgitDir string) *AccessTracker {
+	return &AccessTracker{
+		DgitDir:   dgitDir,
+		IndexFile: filepath.Join(dgitDir, "cache", "access.json"),
+		versions:  make(map[string]*VersionAccess),
+	}
+}
+
+// Load reads the access index from disk; a missing index is not an error
+// After a history rewrite (generation bump) entries for versions that no longer exist are
+// dropped; Load never writes, so the pruned index is stored by the next recorded access
+func (at *AccessTracker) Load() error {
+	data, err := os.ReadFile(at.IndexFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read access index: %w", err)
+	}
+
+	var index accessIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return fmt.Errorf("failed to parse access index: %w", err)
+	}
+	if index.Versions == nil {
+		// Legacy layout without a generation stamp
+		index.Versions = make(map[string]*VersionAccess)
+		if err := json.Unmarshal(data, &index.Versions); err != nil {
+			return fmt.Errorf("failed to parse access index: %w", err)
+		}
+	}
+	at.versions = index.Versions
+
+	if generation.IsStale(at.DgitDir, index.Generation) {
+		store := metapack.NewStore(filepath.Join(at.DgitDir, "commits"))
+		for key := range at.versions {
+			version, err := strconv.Atoi(strings.TrimPrefix(key, "v"))
+			if err != nil || !store.HasRecord(version) {
+				delete(at.versions, key)
+			}
+		}
+	}
+	return nil
+}
+
+// Save writes the access index to disk
+func (at *AccessTracker) Save() error {
+	lock, err := at.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	return at.write()
+}
+
+// lock takes the access index lock
+func (at *AccessTracker) lock() (*repolock.Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(at.IndexFile), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return repolock.LockFile(at.IndexFile + ".lock")
+}
+
+// write replaces the index on disk; the caller holds the index lock
+func (at *AccessTracker) write() error {
+	index := accessIndex{Generation: generation.Current(at.DgitDir), Versions: at.versions}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal access index: %w", err)
+	}
+
+	tempPath := at.IndexFile + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write access index: %w", err)
+	}
+	if err := os.Rename(tempPath, at.IndexFile); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write access index: %w", err)
+	}
+	return nil
+}
+
+// Get returns access information for a version, or nil if it was never accessed
+func (at *AccessTracker) Get(version int) *VersionAccess {
+	return at.versions[versionKey(version)]
+}
+
+// RecordRead marks a version as read now and persists the index (best effort)
+func (at *AccessTracker) RecordRead(version int) {
+	at.record(version, func(va *VersionAccess) {
+		va.LastRead = time.Now()
+		va.ReadCount++
+	})
+}
+
+// RecordRestore marks a version as restored now and persists the index (best effort)
+func (at *AccessTracker) RecordRestore(version int) {
+	at.record(version, func(va *VersionAccess) {
+		va.LastRestore = time.Now()
+		va.RestoreCount++
+	})
+}
+
+// record reloads the index, applies an update and saves it, all under the index lock
+// Tracking failures must never break the read path, so errors are ignored
+func (at *AccessTracker) record(version int, update func(*VersionAccess)) {
+	if version <= 0 {
+		return
+	}
+	lock, err := at.lock()
+	if err != nil {
+		return
+	}
+	defer lock.Release()
+	at.Load()
+
+	key := versionKey(version)
+	va, exists := at.versions[key]
+	if !exists {
+		va = &VersionAccess{}
+		at.versions[key] = va
+	}
+	update(va)
+
+	at.write()
+}
+
+// versionKey formats the index key for a version ("v12")
+func versionKey(version int) string {
+	return fmt.Sprintf("v%d", version)
+}
@@ -0,0 +1,67 @@
+package access
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"dgit/internal/generation"
+)
+
+func TestConcurrentRecordsAreKept(t *testing.T) {
+	dgitDir := t.TempDir()
+
+	const recorders, reads = 8, 25
+	var wg sync.WaitGroup
+	for i := 0; i < recorders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < reads; j++ {
+				NewAccessTracker(dgitDir).RecordRead(3)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tracker := NewAccessTracker(dgitDir)
+	if err := tracker.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := tracker.Get(3); got == nil || got.ReadCount != recorders*reads {
+		t.Fatalf("access index after %d concurrent reads: %+v", recorders*reads, got)
+	}
+	if _, err := os.Stat(tracker.IndexFile + ".tmp"); !os.IsNotExist(err) {
+		t.Fatal("temporary index left behind")
+	}
+}
+
+func TestLoadDoesNotWriteStaleIndex(t *testing.T) {
+	dgitDir := t.TempDir()
+	tracker := NewAccessTracker(dgitDir)
+	tracker.RecordRestore(7)
+	before, err := os.ReadFile(tracker.IndexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 7 has no commit record, so a history rewrite makes its entry stale
+	if _, err := generation.Bump(dgitDir, "test"); err != nil {
+		t.Fatal(err)
+	}
+	reader := NewAccessTracker(dgitDir)
+	if err := reader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if reader.Get(7) != nil {
+		t.Fatal("stale entry for a removed version survived Load")
+	}
+
+	after, err := os.ReadFile(tracker.IndexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Fatal("Load rewrote the access index")
+	}
+}
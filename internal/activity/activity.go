@@ -0,0 +1,157 @@
+package activity
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// The activity feed lets teammates sharing a repository on a network drive see each
+// other's versions without a server: every writer appends one JSON line per event to
+// .dgit/activity.jsonl. Each line is written with a single O_APPEND write so concurrent
+// writers from different machines don't interleave within a line; unreadable lines
+// (e.g. a torn write from a disconnected client) are skipped by readers.
+
+// Event types
+const (
+	EventCommit = "commit"
+)
+
+// Event is one line of the activity feed
+type Event struct {
+	Event     string    `json:"event"`
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	Message   string    `json:"message,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	User      string    `json:"user"`
+	Machine   string    `json:"machine"`
+	Timestamp time.Time `json:"timestamp"`
+	Files     []string  `json:"files,omitempty"`
+	Bytes     int64     `json:"bytes,omitempty"`
+}
+
+// Filter selects feed events; zero values match everything
+type Filter struct {
+	User  string    // Matches User or Author
+	Since time.Time // Only events at or after this time
+	Limit int       // Newest N events
+
+	Exclude map[int]bool // Versions to leave out (hidden versions)
+}
+
+// ActivityLog reads and appends the shared activity feed
+type ActivityLog struct {
+	DgitDir string
+	LogFile string
+}
+
+// NewActivityLog creates an activity log for a repository
+func NewActivityLog(dgitDir string) *ActivityLog {
+	return &ActivityLog{
+		DgitDir: dgitDir,
+		LogFile: filepath.Join(dgitDir, "activity.jsonl"),
+	}
+}
+
+// Identity returns the OS user and machine name recorded with each event
+func Identity() (string, string) {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		name = current.Username
+	}
+	if name == "" {
+		name = os.Getenv("USERNAME")
+	}
+	if name == "" {
+		name = "unknown"
+	}
+
+	machine, err := os.Hostname()
+	if err != nil || machine == "" {
+		machine = "unknown"
+	}
+	return name, machine
+}
+
+// Append records an event, filling in identity and timestamp when unset
+func (a *ActivityLog) Append(event *Event) error {
+	if event.User == "" || event.Machine == "" {
+		name, machine := Identity()
+		if event.User == "" {
+			event.User = name
+		}
+		if event.Machine == "" {
+			event.Machine = machine
+		}
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	file, err := os.OpenFile(a.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open activity feed: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(line); err != nil {
+		return fmt.Errorf("failed to append to activity feed: %w", err)
+	}
+	return nil
+}
+
+// Read returns matching events, newest first
+func (a *ActivityLog) Read(filter Filter) ([]*Event, error) {
+	file, err := os.Open(a.LogFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open activity feed: %w", err)
+	}
+	defer file.Close()
+
+	var events []*Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Event == "" {
+			continue
+		}
+		if filter.User != "" && event.User != filter.User && event.Author != filter.User {
+			continue
+		}
+		if !filter.Since.IsZero() && event.Timestamp.Before(filter.Since) {
+			continue
+		}
+		if filter.Exclude[event.Version] {
+			continue
+		}
+		events = append(events, &event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read activity feed: %w", err)
+	}
+
+	// Clocks on different machines disagree slightly; order by timestamp, not file position
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.After(events[j].Timestamp)
+	})
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
@@ -0,0 +1,639 @@
+// Package batch runs a script of repository operations as one transaction. A script
+// lists steps (add patterns, commit, tag, archive, materialize) that run in order under
+// a single repository lock; if any step fails, everything the earlier steps did is
+// rolled back from the journal and the repository is left as it was. One report covers
+// the whole run, so delivery-day automation checks a single result.
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/activity"
+	"dgit/internal/commit"
+	"dgit/internal/log"
+	"dgit/internal/maintenance"
+	"dgit/internal/pin"
+	"dgit/internal/progress"
+	"dgit/internal/queue"
+	"dgit/internal/repolock"
+	"dgit/internal/staging"
+	"dgit/internal/status"
+	"dgit/internal/tag"
+	"dgit/internal/trash"
+)
+
+// Operations
+const (
+	OpAdd         = "add"         // Stage files matching patterns
+	OpCommit      = "commit"      // Commit the staged files
+	OpTag         = "tag"         // Name a version
+	OpArchive     = "archive"     // Move LZ4 snapshots to the Zstd archive tier
+	OpMaterialize = "materialize" // Write pinned files into a directory
+)
+
+// Step statuses
+const (
+	StatusDone       = "done"
+	StatusFailed     = "failed"
+	StatusSkipped    = "skipped"     // Not run because an earlier step failed
+	StatusRolledBack = "rolled-back" // Ran, then undone because a later step failed
+)
+
+// lockOperation names a batch as the holder of the repository lock
+const lockOperation = "batch"
+
+// ErrLocked is returned when another batch or command holds the repository lock
+var ErrLocked = errors.New("another dgit command is changing the repository")
+
+// ErrQueueBusy is returned when the background commit worker is storing commits
+var ErrQueueBusy = errors.New("background commits are being stored; wait for 'dgit queue' to finish")
+
+// Ref is a version reference: 17, "v17", a tag, a commit hash prefix, or "HEAD"/"latest"
+// for the newest commit, including ones made earlier in the batch
+type Ref string
+
+// UnmarshalJSON accepts version numbers as well as strings
+func (r *Ref) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*r = Ref(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("version must be a number or a string, got %s", data)
+	}
+	*r = Ref(number.String())
+	return nil
+}
+
+// Operation is one step of a script
+type Operation struct {
+	Op string `json:"op"`
+
+	// add
+	Patterns        []string `json:"patterns,omitempty"`
+	Group           bool     `json:"group,omitempty"`
+	IncludeAutosave bool     `json:"include_autosave,omitempty"`
+
+	// commit; Message is also the note stored with a tag
+	Message        string `json:"message,omitempty"`
+	Profile        string `json:"profile,omitempty"`
+	IgnorePolicies bool   `json:"ignore_policies,omitempty"`
+
+	// tag
+	Name  string `json:"name,omitempty"`
+	Force bool   `json:"force,omitempty"`
+
+	// tag and materialize: the version to use (default: the newest commit)
+	Version Ref `json:"version,omitempty"`
+
+	// archive
+	Versions []Ref `json:"versions,omitempty"`
+
+	// materialize: a pinfile, or files of Version (default: every file)
+	Pinfile string   `json:"pinfile,omitempty"`
+	Files   []string `json:"files,omitempty"`
+	Dest    string   `json:"dest,omitempty"`
+}
+
+// Script is a parsed batch script
+type Script struct {
+	Description string       `json:"description,omitempty"`
+	Operations  []*Operation `json:"operations"`
+}
+
+// StepResult is the outcome of one step
+type StepResult struct {
+	Step       int    `json:"step"` // 1-based
+	Op         string `json:"op"`
+	Status     string `json:"status"`
+	Summary    string `json:"summary,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report summarizes a batch run
+type Report struct {
+	Script        string        `json:"script"`
+	Steps         []*StepResult `json:"steps"`
+	Committed     []int         `json:"committed,omitempty"` // Versions created (removed again after a rollback)
+	Tagged        []string      `json:"tagged,omitempty"`
+	RolledBack    bool          `json:"rolled_back"`
+	RollbackError string        `json:"rollback_error,omitempty"`
+	DurationMs    int64         `json:"duration_ms"`
+}
+
+// Parse reads a script in JSON or YAML; name picks the format by extension (.json, .yaml,
+// .yml) and content decides otherwise. A top-level list is read as the operations
+func Parse(data []byte, name string) (*Script, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	trimmed := bytes.TrimSpace(data)
+	isJSON := ext == ".json" || (ext != ".yaml" && ext != ".yml" && len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['))
+	if !isJSON {
+		document, err := parseYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if trimmed, err = json.Marshal(document); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	}
+
+	script := &Script{}
+	var target interface{} = script
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		target = &script.Operations
+	}
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return nil, fmt.Errorf("invalid script: %w", err)
+	}
+	return script, script.Validate()
+}
+
+// Validate checks every step before anything runs
+func (s *Script) Validate() error {
+	if len(s.Operations) == 0 {
+		return fmt.Errorf("script has no operations")
+	}
+	for i, op := range s.Operations {
+		if op == nil {
+			return fmt.Errorf("step %d: empty operation", i+1)
+		}
+		if err := op.validate(); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, op.Op, err)
+		}
+	}
+	return nil
+}
+
+// validate checks one step's fields
+func (op *Operation) validate() error {
+	switch op.Op {
+	case OpAdd:
+		if len(op.Patterns) == 0 {
+			return fmt.Errorf("'patterns' is required")
+		}
+	case OpCommit:
+		if strings.TrimSpace(op.Message) == "" {
+			return fmt.Errorf("'message' is required")
+		}
+		if _, ok := commit.Profiles[strings.ToLower(op.Profile)]; op.Profile != "" && !ok {
+			return fmt.Errorf("unknown compression profile '%s' (choose one of: %s)", op.Profile, strings.Join(commit.ProfileNames(), ", "))
+		}
+	case OpTag:
+		if err := tag.ValidateName(op.Name); err != nil {
+			return err
+		}
+	case OpArchive:
+		if len(op.Versions) == 0 {
+			return fmt.Errorf("'versions' is required")
+		}
+	case OpMaterialize:
+		if op.Pinfile != "" && (op.Version != "" || len(op.Files) > 0) {
+			return fmt.Errorf("use either 'pinfile' or 'version'/'files', not both")
+		}
+		if op.Pinfile != "" {
+			if _, err := pin.Load(op.Pinfile); err != nil {
+				return err
+			}
+		}
+	case "":
+		return fmt.Errorf("'op' is required")
+	default:
+		return fmt.Errorf("unknown operation '%s' (expected add, commit, tag, archive or materialize)", op.Op)
+	}
+	return nil
+}
+
+// BatchManager runs scripts against a repository
+type BatchManager struct {
+	DgitDir  string
+	WorkDir  string // Project directory
+	BatchDir string
+
+	// Progress receives the add, commit and restore events of each step; nil discards
+	// them, since the batch reports once per step
+	Progress progress.Reporter
+}
+
+// NewBatchManager creates a new batch manager
+func NewBatchManager(dgitDir string) *BatchManager {
+	batchDir := filepath.Join(dgitDir, "batch")
+	return &BatchManager{
+		DgitDir:  dgitDir,
+		WorkDir:  filepath.Dir(dgitDir),
+		BatchDir: batchDir,
+	}
+}
+
+// Running reports whether a batch holds the repository lock
+func Running(dgitDir string) bool {
+	return repolock.HeldFor(dgitDir) == lockOperation
+}
+
+// Interrupted returns the journal of a batch that stopped without finishing (a crash or
+// a kill) and has not been rolled back yet; nil when there is none
+func Interrupted(dgitDir string) (*Journal, error) {
+	if Running(dgitDir) {
+		return nil, nil
+	}
+	return loadJournal(dgitDir)
+}
+
+// RecoverInterrupted rolls back a batch that stopped without finishing; it returns the
+// journal that was undone, nil when there was nothing to do. The rollback runs under the
+// repository lock and is left for later while another command holds it
+func RecoverInterrupted(dgitDir string) (*Journal, error) {
+	lock, err := repolock.TryAcquire(dgitDir, "recover")
+	if errors.Is(err, repolock.ErrBusy) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	journal, err := loadJournal(dgitDir)
+	if err != nil || journal == nil {
+		return nil, err
+	}
+	if err := journal.rollback(dgitDir); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// Run executes a script under one lock; onStep is called as each step finishes
+// A failed step rolls back the whole batch and its error is returned with the report
+func (bm *BatchManager) Run(script *Script, name string, onStep func(*StepResult)) (*Report, error) {
+	if err := script.Validate(); err != nil {
+		return nil, err
+	}
+	lock, err := bm.acquireLock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	// A batch that crashed earlier is undone before this one starts
+	if previous, err := loadJournal(bm.DgitDir); err != nil {
+		return nil, err
+	} else if previous != nil {
+		if err := previous.rollback(bm.DgitDir); err != nil {
+			return nil, fmt.Errorf("an earlier batch was interrupted and could not be rolled back: %w", err)
+		}
+	}
+
+	journal, err := beginJournal(bm.DgitDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	report := &Report{Script: name}
+	var failure error
+	for i, op := range script.Operations {
+		step := &StepResult{Step: i + 1, Op: op.Op}
+		report.Steps = append(report.Steps, step)
+		if failure != nil {
+			step.Status = StatusSkipped
+			continue
+		}
+
+		stepStart := time.Now()
+		summary, err := bm.runStep(op, journal, report)
+		step.DurationMs = time.Since(stepStart).Milliseconds()
+		step.Summary = summary
+		if err != nil {
+			step.Status = StatusFailed
+			step.Error = err.Error()
+			failure = fmt.Errorf("step %d (%s): %w", step.Step, op.Op, err)
+		} else {
+			step.Status = StatusDone
+		}
+		if onStep != nil {
+			onStep(step)
+		}
+	}
+
+	if failure != nil {
+		report.RolledBack = true
+		if err := journal.rollback(bm.DgitDir); err != nil {
+			report.RollbackError = err.Error()
+		}
+		for _, step := range report.Steps {
+			if step.Status == StatusDone {
+				step.Status = StatusRolledBack
+			}
+		}
+	} else if err := journal.discard(); err != nil {
+		failure = fmt.Errorf("batch finished but its journal could not be removed: %w", err)
+	}
+	report.DurationMs = time.Since(startTime).Milliseconds()
+	return report, failure
+}
+
+// runStep executes one operation and describes what it did
+func (bm *BatchManager) runStep(op *Operation, journal *Journal, report *Report) (string, error) {
+	switch op.Op {
+	case OpAdd:
+		return bm.add(op)
+	case OpCommit:
+		return bm.commit(op, report)
+	case OpTag:
+		return bm.tag(op, report)
+	case OpArchive:
+		return bm.archive(op, journal)
+	case OpMaterialize:
+		return bm.materialize(op, journal)
+	}
+	return "", fmt.Errorf("unknown operation '%s'", op.Op)
+}
+
+// add stages the files matching each pattern; a file that cannot be staged fails the step
+func (bm *BatchManager) add(op *Operation) (string, error) {
+	stagingArea := staging.NewStagingArea(bm.DgitDir)
+	stagingArea.Progress = bm.reporter()
+	stagingArea.WholeGroups = op.Group
+	stagingArea.IncludeAutosave = op.IncludeAutosave
+	if err := stagingArea.LoadStaging(); err != nil {
+		return "", err
+	}
+
+	added := 0
+	for _, pattern := range op.Patterns {
+		result, err := stagingArea.AddPattern(pattern)
+		if err != nil {
+			return "", fmt.Errorf("adding '%s': %w", pattern, err)
+		}
+		for file, fileErr := range result.FailedFiles {
+			return "", fmt.Errorf("adding %s: %w", file, fileErr)
+		}
+		added += len(result.AddedFiles)
+	}
+	if err := stagingArea.SaveStaging(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("staged %d file(s), %d in total", added, stagingArea.GetFileCount()), nil
+}
+
+// commit commits the staged files, as 'dgit commit' does
+func (bm *BatchManager) commit(op *Operation, report *Report) (string, error) {
+	stagingArea := staging.NewStagingArea(bm.DgitDir)
+	stagingArea.Progress = bm.reporter()
+	if err := stagingArea.LoadStaging(); err != nil {
+		return "", err
+	}
+	if stagingArea.IsEmpty() {
+		return "", fmt.Errorf("no files staged for commit")
+	}
+	removed := stagingArea.GetRemovedFiles()
+	if len(removed) > 0 && len(stagingArea.GetStagedFiles()) == 0 {
+		tracked, err := log.NewLogManager(bm.DgitDir).GetTrackedFiles()
+		if err != nil {
+			return "", err
+		}
+		if _, err := stagingArea.StageRemaining(tracked); err != nil {
+			return "", err
+		}
+	}
+	stagedFiles := stagingArea.GetStagedFiles()
+	sort.Slice(stagedFiles, func(i, j int) bool { return stagedFiles[i].Path < stagedFiles[j].Path })
+
+	commitManager := commit.NewCommitManager(bm.DgitDir)
+	commitManager.Progress = bm.reporter()
+	commitManager.Removed = removed
+	commitManager.Renamed = stagingArea.GetRenamedFiles()
+	commitManager.IgnorePolicies = op.IgnorePolicies
+	commitManager.Provisional = true
+	commitManager.Locked = true // The batch holds the repository lock
+	if op.Profile != "" {
+		if err := commitManager.ApplyProfile(op.Profile); err != nil {
+			return "", err
+		}
+	}
+	newCommit, err := commitManager.CreateCommit(op.Message, stagedFiles)
+	if err != nil {
+		return "", err
+	}
+	if err := stagingArea.ClearStaging(); err != nil {
+		return "", fmt.Errorf("committed v%d but the staging area was not cleared: %w", newCommit.Version, err)
+	}
+
+	report.Committed = append(report.Committed, newCommit.Version)
+	return fmt.Sprintf("v%d (%s) with %d file(s): %s", newCommit.Version,
+		log.AbbreviateHash(newCommit.Hash, log.HashLength(bm.DgitDir)), newCommit.FilesCount, firstLine(op.Message)), nil
+}
+
+// tag names a version, the newest commit by default
+func (bm *BatchManager) tag(op *Operation, report *Report) (string, error) {
+	target, err := bm.resolve(op.Version)
+	if err != nil {
+		return "", err
+	}
+	user, _ := activity.Identity()
+	previous, err := tag.NewTagManager(bm.DgitDir).Set(op.Name, target.Version, target.Hash, op.Message, user, op.Force)
+	if err != nil {
+		return "", err
+	}
+
+	report.Tagged = append(report.Tagged, op.Name)
+	if previous != nil && previous.Version != target.Version {
+		return fmt.Sprintf("moved '%s' from v%d to v%d", op.Name, previous.Version, target.Version), nil
+	}
+	return fmt.Sprintf("'%s' names v%d", op.Name, target.Version), nil
+}
+
+// archive recompresses the listed versions' LZ4 snapshots into the Zstd archive tier
+func (bm *BatchManager) archive(op *Operation, journal *Journal) (string, error) {
+	mm := maintenance.NewMaintenanceManager(bm.DgitDir)
+	var archived, already []int
+	var saved int64
+	for _, ref := range op.Versions {
+		target, err := bm.resolve(ref)
+		if err != nil {
+			return "", err
+		}
+		version := target.Version
+		snapshotPath := filepath.Join(mm.SnapshotsDir, fmt.Sprintf("v%d.lz4", version))
+		if _, err := os.Stat(snapshotPath); err != nil {
+			if _, err := os.Stat(filepath.Join(mm.ArchiveDir, fmt.Sprintf("v%d.zstd", version))); err == nil {
+				already = append(already, version)
+				continue
+			}
+			return "", fmt.Errorf("v%d has no LZ4 snapshot to archive (it is stored as a delta)", version)
+		}
+		if err := journal.preserve(snapshotPath); err != nil {
+			return "", err
+		}
+		reclaimed, err := mm.ArchiveVersion(version)
+		if err != nil {
+			return "", err
+		}
+		archived = append(archived, version)
+		saved += reclaimed
+	}
+
+	summary := fmt.Sprintf("archived %s, %.1f MB saved", versionList(archived), float64(saved)/(1<<20))
+	if len(archived) == 0 {
+		summary = "nothing to archive"
+	}
+	if len(already) > 0 {
+		summary += fmt.Sprintf("; %s already archived", versionList(already))
+	}
+	return summary, nil
+}
+
+// materialize writes pinned files into Dest (the project by default)
+func (bm *BatchManager) materialize(op *Operation, journal *Journal) (string, error) {
+	pinManager := pin.NewPinManager(bm.DgitDir)
+	var lock *pin.Lockfile
+	var err error
+	if op.Pinfile != "" {
+		lock, err = pin.Load(op.Pinfile)
+	} else {
+		var target *log.Commit
+		if target, err = bm.resolve(op.Version); err == nil {
+			lock, err = pinManager.Pin(op.Files, fmt.Sprintf("v%d", target.Version))
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	dest := op.Dest
+	if dest == "" {
+		dest = "."
+	}
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return "", err
+	}
+
+	// Note what is there now so a rollback removes new files and restores replaced ones;
+	// new directories are listed outermost first, ahead of the files written into them
+	var created, files, replaced []string
+	seen := make(map[string]bool)
+	for _, entry := range lock.Files {
+		target := filepath.Join(absDest, filepath.FromSlash(entry.Path))
+		hash, err := status.CalculateFileHash(target)
+		switch {
+		case err != nil:
+			files = append(files, target)
+			var dirs []string
+			for dir := filepath.Dir(target); !seen[dir]; dir = filepath.Dir(dir) {
+				if _, err := os.Stat(dir); err == nil {
+					break
+				}
+				seen[dir] = true
+				dirs = append([]string{dir}, dirs...)
+			}
+			created = append(created, dirs...)
+		case hash != entry.Hash:
+			replaced = append(replaced, entry.Path)
+		}
+	}
+	created = append(created, files...)
+	// Materialize backs up project files itself; other destinations are backed up here
+	if absDest != bm.WorkDir && len(replaced) > 0 {
+		backup, err := trash.NewTrashManager(bm.DgitDir).Save(absDest, replaced, "batch materialize")
+		if err != nil {
+			return "", fmt.Errorf("failed to back up files before replacing them: %w", err)
+		}
+		if err := journal.recordWritten(nil, backup, absDest); err != nil {
+			return "", err
+		}
+	}
+
+	result, err := pinManager.Materialize(lock, absDest)
+	if result != nil {
+		if recordErr := journal.recordWritten(created, result.Backup, absDest); recordErr != nil && err == nil {
+			err = recordErr
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("wrote %d file(s) to %s, %d already current", len(result.Written), dest, len(result.Current)), nil
+}
+
+// resolve finds the commit a reference names; empty, "HEAD" and "latest" mean the newest
+func (bm *BatchManager) resolve(ref Ref) (*log.Commit, error) {
+	logManager := log.NewLogManager(bm.DgitDir)
+	text := strings.TrimSpace(string(ref))
+	switch text {
+	case "", "HEAD", "latest":
+		version := logManager.GetCurrentVersion()
+		if version == 0 {
+			return nil, fmt.Errorf("no commits yet")
+		}
+		return logManager.GetCommit(version)
+	}
+	if version, err := strconv.Atoi(strings.TrimPrefix(text, "v")); err == nil {
+		commit, err := logManager.GetCommit(version)
+		if err != nil {
+			return nil, fmt.Errorf("version v%d not found", version)
+		}
+		return commit, nil
+	}
+	if commit, err := tag.NewTagManager(bm.DgitDir).Resolve(text); commit != nil || err != nil {
+		return commit, err
+	}
+	commit, err := logManager.GetCommitByHash(text)
+	var ambiguous *log.AmbiguousHashError
+	if errors.As(err, &ambiguous) {
+		return nil, err
+	}
+	if err != nil || commit == nil {
+		return nil, fmt.Errorf("version '%s' not found", text)
+	}
+	return commit, nil
+}
+
+// acquireLock takes the repository lock that commit, add and the queue worker also take,
+// so none of them can change the repository while the batch runs
+func (bm *BatchManager) acquireLock() (*repolock.Lock, error) {
+	if queue.NewQueueManager(bm.DgitDir).WorkerRunning() {
+		return nil, ErrQueueBusy
+	}
+	lock, err := repolock.TryAcquire(bm.DgitDir, lockOperation)
+	if errors.Is(err, repolock.ErrBusy) {
+		return nil, fmt.Errorf("%w: held by %s", ErrLocked, repolock.Holder(bm.DgitDir))
+	}
+	return lock, err
+}
+
+// reporter returns the progress reporter handed to each step's managers
+func (bm *BatchManager) reporter() progress.Reporter {
+	if bm.Progress != nil {
+		return bm.Progress
+	}
+	return func(progress.Event) {}
+}
+
+// firstLine returns the first line of a message
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return line
+}
+
+// versionList renders versions as "v3, v7"
+func versionList(versions []int) string {
+	parts := make([]string, len(versions))
+	for i, version := range versions {
+		parts[i] = fmt.Sprintf("v%d", version)
+	}
+	return strings.Join(parts, ", ")
+}
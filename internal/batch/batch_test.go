@@ -0,0 +1,80 @@
+package batch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const deliveryYAML = `# Nightly delivery
+description: "Client delivery: round 3"
+operations:
+  - op: add
+    patterns: ["*.psd", 'logo #2.ai']
+    group: true
+  - op: commit
+    message: |
+      Round 3 for the client's review
+      Layers flattened
+    profile: balanced
+  - op: tag
+    name: delivery-3
+    version: 12
+  - op: archive
+    versions:
+      - 1
+      - v2
+`
+
+const deliveryJSON = `{
+  "description": "Client delivery: round 3",
+  "operations": [
+    {"op": "add", "patterns": ["*.psd", "logo #2.ai"], "group": true},
+    {"op": "commit", "message": "Round 3 for the client's review\nLayers flattened\n", "profile": "balanced"},
+    {"op": "tag", "name": "delivery-3", "version": 12},
+    {"op": "archive", "versions": [1, "v2"]}
+  ]
+}`
+
+func TestYAMLAndJSONScriptsMatch(t *testing.T) {
+	fromYAML, err := Parse([]byte(deliveryYAML), "delivery.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := Parse([]byte(deliveryJSON), "delivery.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Fatalf("YAML script %+v differs from JSON script %+v", fromYAML, fromJSON)
+	}
+	if got := fromYAML.Operations[3].Versions; !reflect.DeepEqual(got, []Ref{"1", "v2"}) {
+		t.Fatalf("versions = %q", got)
+	}
+}
+
+func TestYAMLOperationList(t *testing.T) {
+	script, err := Parse([]byte("- op: add\n  patterns: [hero.psd]\n- op: commit\n  message: first\n"), "-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(script.Operations) != 2 || script.Operations[1].Message != "first" {
+		t.Fatalf("operations = %+v", script.Operations)
+	}
+}
+
+func TestInvalidYAMLScripts(t *testing.T) {
+	for name, tc := range map[string]struct{ script, want string }{
+		"syntax":        {"operations:\n  - op: add\n   patterns: [a]\n", "invalid YAML"},
+		"two documents": {"operations: []\n---\noperations: []\n", "one YAML document"},
+		"unknown field": {"operations:\n  - op: add\n    pattern: [a]\n", "unknown field"},
+		"no operations": {"description: empty\n", "no operations"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.script), "script.yaml")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Parse error = %v, want one mentioning %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dgit/internal/generation"
+	initializer "dgit/internal/init"
+	"dgit/internal/trash"
+)
+
+// The journal makes a batch all-or-nothing. Before the first step it records which files
+// exist in the repository and copies the small state files steps rewrite in place
+// (HEAD, staging, tags, indexes); append-only logs are recorded by size. Steps that delete
+// an object first preserve it, and materialize steps record what they wrote outside the
+// repository. Rolling back deletes every file the batch created and puts the rest back.
+// The journal lives on disk, so a batch cut short by a crash is rolled back by the next
+// dgit command that changes the repository, or by 'dgit batch --recover'; read-only
+// commands only report it.
+//
+//	.dgit/batch/journal/journal.json   what to undo
+//	.dgit/batch/journal/saved/         copies of state files
+//	.dgit/batch/journal/preserved/     objects a step deleted
+
+// Journal records how to undo a batch
+type Journal struct {
+	StartedAt time.Time `json:"started_at"`
+	Script    string    `json:"script"`
+	PID       int       `json:"pid"`
+
+	Roots    []string         `json:"roots"`    // Directories scanned for files the batch creates
+	Existing map[string]bool  `json:"existing"` // Files and directories present before the batch
+	Saved    map[string]bool  `json:"saved"`    // State files copied to saved/ (by path)
+	Appended map[string]int64 `json:"appended"` // Append-only logs and their size before the batch
+	Missing  []string         `json:"missing"`  // State files that did not exist yet
+
+	Preserved   map[string]string `json:"preserved,omitempty"`    // Deleted object -> copy in preserved/
+	Written     []string          `json:"written,omitempty"`      // Directories and files materialized outside the repository
+	TrashBackup []TrashBackup     `json:"trash_backup,omitempty"` // Project files replaced by materialize
+
+	dir string
+}
+
+// TrashBackup is a trash entry holding project files a materialize step replaced
+type TrashBackup struct {
+	ID      string `json:"id"`
+	WorkDir string `json:"work_dir"`
+}
+
+// stateFiles are rewritten in place by batch steps, relative to .dgit
+var stateFiles = []string{
+	"HEAD", "HEAD.ring", "tags.json", "hidden.json",
+	"staging/staged.json", "staging/removed.json", "staging/renamed.json",
+}
+
+// appendLogs only grow, so truncating them undoes a batch's entries
+var appendLogs = []string{"activity.jsonl"}
+
+// journalDir is where an unfinished batch keeps its journal
+func journalDir(dgitDir string) string {
+	return filepath.Join(dgitDir, "batch", "journal")
+}
+
+// beginJournal records the repository before a batch changes it
+func beginJournal(dgitDir, script string) (*Journal, error) {
+	dir := journalDir(dgitDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "saved"), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create batch journal: %w", err)
+	}
+
+	j := &Journal{
+		StartedAt: time.Now().UTC(),
+		Script:    script,
+		PID:       os.Getpid(),
+		Roots:     []string{dgitDir},
+		Existing:  make(map[string]bool),
+		Saved:     make(map[string]bool),
+		Appended:  make(map[string]int64),
+		Preserved: make(map[string]string),
+		dir:       dir,
+	}
+	if storageDir := initializer.GetStorageDir(dgitDir); !within(storageDir, dgitDir) {
+		j.Roots = append(j.Roots, storageDir)
+	}
+
+	for _, root := range j.Roots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() && path == filepath.Join(dgitDir, "batch") {
+				return filepath.SkipDir
+			}
+			j.Existing[path] = true
+			// Indexes (snapshots/index.json, commits/index.json...) are rewritten by commits
+			if !info.IsDir() && filepath.Base(path) == "index.json" {
+				j.Saved[path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to record repository files: %w", err)
+		}
+	}
+	for _, name := range stateFiles {
+		path := filepath.Join(dgitDir, filepath.FromSlash(name))
+		if j.Existing[path] {
+			j.Saved[path] = true
+		} else {
+			j.Missing = append(j.Missing, path)
+		}
+	}
+	for _, name := range appendLogs {
+		path := filepath.Join(dgitDir, name)
+		if info, err := os.Stat(path); err == nil {
+			j.Appended[path] = info.Size()
+		}
+	}
+
+	for path := range j.Saved {
+		if err := copyFile(path, j.savedPath(path)); err != nil {
+			return nil, fmt.Errorf("failed to save %s: %w", filepath.Base(path), err)
+		}
+	}
+	return j, j.save()
+}
+
+// loadJournal reads the journal of an unfinished batch; nil when there is none
+func loadJournal(dgitDir string) (*Journal, error) {
+	dir := journalDir(dgitDir)
+	data, err := os.ReadFile(filepath.Join(dir, "journal.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("corrupt batch journal: %w", err)
+	}
+	j.dir = dir
+	return &j, nil
+}
+
+// preserve keeps a copy of an object a step is about to delete
+func (j *Journal) preserve(path string) error {
+	if _, ok := j.Preserved[path]; ok || !j.Existing[path] {
+		return nil // Already kept, or created by this batch and deleted on rollback anyway
+	}
+	target := filepath.Join(j.dir, "preserved", fmt.Sprintf("%d-%s", len(j.Preserved), filepath.Base(path)))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.Link(path, target); err != nil {
+		if err := copyFile(path, target); err != nil {
+			return fmt.Errorf("failed to preserve %s: %w", filepath.Base(path), err)
+		}
+	}
+	j.Preserved[path] = target
+	return j.save()
+}
+
+// recordWritten notes directories and files a step wrote outside the repository that did
+// not exist before, parents first
+func (j *Journal) recordWritten(paths []string, backup *trash.Entry, workDir string) error {
+	j.Written = append(j.Written, paths...)
+	if backup != nil {
+		j.TrashBackup = append(j.TrashBackup, TrashBackup{ID: backup.ID, WorkDir: workDir})
+	}
+	return j.save()
+}
+
+// rollback undoes everything the batch did and removes the journal
+func (j *Journal) rollback(dgitDir string) error {
+	var problems []string
+	note := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	// Files delivered outside the repository, then project files materialize replaced
+	for i := len(j.Written) - 1; i >= 0; i-- {
+		if err := os.Remove(j.Written[i]); err != nil && !os.IsNotExist(err) {
+			note(err)
+		}
+	}
+	trashManager := trash.NewTrashManager(dgitDir)
+	for i := len(j.TrashBackup) - 1; i >= 0; i-- {
+		_, err := trashManager.Recover(j.TrashBackup[i].ID, j.TrashBackup[i].WorkDir)
+		note(err)
+	}
+
+	// Objects steps deleted come back before new files are swept
+	for original, kept := range j.Preserved {
+		if _, err := os.Stat(original); err == nil {
+			continue
+		}
+		if err := os.Rename(kept, original); err != nil {
+			note(copyFile(kept, original))
+		}
+	}
+
+	var newDirs []string
+	for _, root := range j.Roots {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || j.Existing[path] {
+				return nil
+			}
+			if info.IsDir() {
+				if path == filepath.Join(dgitDir, "batch") {
+					return filepath.SkipDir
+				}
+				newDirs = append(newDirs, path)
+				return nil
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				note(err)
+			}
+			return nil
+		})
+	}
+	// Walk lists parents before children, so remove directories in reverse
+	for i := len(newDirs) - 1; i >= 0; i-- {
+		os.Remove(newDirs[i])
+	}
+
+	for path := range j.Saved {
+		note(copyFile(j.savedPath(path), path))
+	}
+	for _, path := range j.Missing {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			note(err)
+		}
+	}
+	for path, size := range j.Appended {
+		if err := os.Truncate(path, size); err != nil && !os.IsNotExist(err) {
+			note(err)
+		}
+	}
+
+	// Caches built from objects the batch created are no longer valid
+	_, err := generation.Bump(dgitDir, "batch rollback")
+	note(err)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("rollback incomplete (journal kept in %s): %s", j.dir, strings.Join(problems, "; "))
+	}
+	return j.discard()
+}
+
+// discard removes the journal once the batch has finished or been undone
+func (j *Journal) discard() error {
+	return os.RemoveAll(j.dir)
+}
+
+// savedPath is where a state file's copy is kept
+func (j *Journal) savedPath(path string) string {
+	return filepath.Join(j.dir, "saved", fmt.Sprintf("%x", path))
+}
+
+// save writes the journal atomically
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(j.dir, "journal.json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write batch journal: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// within reports whether path is dir or inside it
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyFile copies src over dst through a temporary file
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	temp := dst + ".tmp"
+	out, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(temp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return os.Rename(temp, dst)
+}
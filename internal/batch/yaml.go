@@ -0,0 +1,30 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scripts may be written in YAML. The document is decoded generically and handed to the
+// JSON decoder, so both formats share one set of field names and the same checks.
+
+// parseYAML decodes a single YAML document into maps, slices and scalars
+func parseYAML(data []byte) (interface{}, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var extra interface{}
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("only one YAML document is supported")
+	}
+	return document, nil
+}
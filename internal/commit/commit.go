@@ -4,19 +4,38 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
-	"crypto/sha256"
 	"dgit/internal/scanner/photoshop"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"dgit/internal/access"
+	"dgit/internal/activity"
+	"dgit/internal/environment"
+	"dgit/internal/filedelta"
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/metapack"
+	"dgit/internal/objfmt"
+	"dgit/internal/policy"
+	"dgit/internal/preview"
+	"dgit/internal/progress"
+	"dgit/internal/repolock"
 	"dgit/internal/scanner"
 	"dgit/internal/staging"
+	"dgit/internal/status"
+	"dgit/internal/sysmem"
+	"dgit/internal/telemetry"
+	"dgit/internal/xattr"
 
 	// Compression Libraries
 	"github.com/gabstv/go-bsdiff/pkg/bsdiff"
@@ -49,20 +68,53 @@ type CompressionResult struct {
 	CompressionTime  float64 `json:"compression_time_ms"`
 	CacheLevel       string  `json:"cache_level"`
 	SpeedImprovement float64 `json:"speed_improvement"`
+
+	// DeltaSkipped explains why a delta was not attempted or was abandoned (bsdiff resource and time guard)
+	DeltaSkipped string `json:"delta_skipped,omitempty"`
+
+	// Profile is the compression profile the commit was made with, if any
+	Profile string `json:"profile,omitempty"`
+
+	// Files breaks a bsdiff delta down per file, in commit order
+	Files []FileDelta `json:"files,omitempty"`
+}
+
+// FileDelta records how one file was stored in a per-file delta and how long it took
+type FileDelta struct {
+	Path           string  `json:"path"`
+	Base           string  `json:"base,omitempty"` // Base version path the patch applies to; empty when stored whole
+	OriginalSize   int64   `json:"original_size"`
+	CompressedSize int64   `json:"compressed_size"`
+	DiffTime       float64 `json:"diff_time_ms"`
 }
 
 // Commit represents a single commit in DGit
 type Commit struct {
-	Hash            string                 `json:"hash"`
-	Message         string                 `json:"message"`
-	Timestamp       time.Time              `json:"timestamp"`
-	Author          string                 `json:"author"`
-	FilesCount      int                    `json:"files_count"`
-	Version         int                    `json:"version"`
-	Metadata        map[string]interface{} `json:"metadata"`
-	ParentHash      string                 `json:"parent_hash,omitempty"`
-	SnapshotZip     string                 `json:"snapshot_zip,omitempty"`
-	CompressionInfo *CompressionResult     `json:"compression_info,omitempty"`
+	Hash            string                   `json:"hash"`
+	Message         string                   `json:"message"`
+	Timestamp       time.Time                `json:"timestamp"`
+	Zone            string                   `json:"zone,omitempty"`
+	Author          string                   `json:"author"`
+	FilesCount      int                      `json:"files_count"`
+	Version         int                      `json:"version"`
+	Metadata        map[string]interface{}   `json:"metadata"`
+	ParentHash      string                   `json:"parent_hash,omitempty"`
+	SnapshotZip     string                   `json:"snapshot_zip,omitempty"`
+	CompressionInfo *CompressionResult       `json:"compression_info,omitempty"`
+	Removed         []string                 `json:"removed,omitempty"`
+	Renamed         map[string]string        `json:"renamed,omitempty"`
+	HashChain       int                      `json:"hash_chain,omitempty"`
+	Environment     *environment.Environment `json:"environment,omitempty"`
+}
+
+// UnchangedError is returned when every staged file is identical to the previous version,
+// so committing would store a duplicate snapshot
+type UnchangedError struct {
+	Version int
+}
+
+func (e *UnchangedError) Error() string {
+	return fmt.Sprintf("nothing changed: staged files are identical to v%d", e.Version)
 }
 
 // CommitManager handles commit creation with simplified storage system
@@ -74,6 +126,7 @@ type CommitManager struct {
 
 	SnapshotsDir string
 	DeltasDir    string
+	ArchiveDir   string
 	CommitsDir   string
 	TempDir      string
 
@@ -82,23 +135,67 @@ type CommitManager struct {
 	CompressionThreshold float64
 
 	// Compression configuration
-	lz4CompressionLevel int
-	enableBackgroundOpt bool
+	profile              string
+	profileErr           error // Invalid profile in config; reported when committing
+	storageErr           error // Relocated storage missing (volume not mounted); reported when committing
+	lz4CompressionLevel  int
+	zstdCompressionLevel int
+	enableBackgroundOpt  bool
+
+	// bsdiff resource guard
+	maxDeltaInput       int64
+	deltaMemoryFraction float64
+	maxDeltaDuration    time.Duration
+	deltaWorkers        int // Files diffed at once; 0 means one per CPU
+
+	// Commit metadata packing
+	packMetadata  bool
+	packThreshold int
+	packBatchSize int
+
+	// Pipeline instrumentation (no-op unless telemetry is enabled)
+	tracer     *telemetry.Tracer
+	commitSpan *telemetry.Span
+
+	// Progress receives typed events instead of stdout output when set (library mode)
+	Progress progress.Reporter
+
+	// Removed lists tracked files deleted by this commit ('dgit rm')
+	Removed []string
+
+	// Renamed maps new paths to the paths they were moved from ('dgit mv')
+	Renamed map[string]string
+
+	// IgnorePolicies commits even when blocking asset policies fail (they are still reported)
+	IgnorePolicies bool
+
+	// Provisional commits may still be rolled back ('dgit batch'): records stay loose and
+	// no background optimization is scheduled, so undoing the commit only deletes files
+	Provisional bool
+
+	// Locked means the caller already holds the repository lock (repolock); otherwise
+	// CreateCommit takes it from choosing the version number to writing the record
+	Locked bool
 }
 
 // NewCommitManager creates a new commit manager with simplified structure
 func NewCommitManager(dgitDir string) *CommitManager {
-	objectsDir := filepath.Join(dgitDir, "objects") // 레거시 호환
+	storageDir := initializer.GetStorageDir(dgitDir)
+	objectsDir := filepath.Join(storageDir, "objects") // 레거시 호환
 
-	snapshotsDir := filepath.Join(dgitDir, "snapshots")
-	deltasDir := filepath.Join(dgitDir, "deltas")
+	snapshotsDir := filepath.Join(storageDir, "snapshots")
+	deltasDir := filepath.Join(storageDir, "deltas")
 	commitsDir := filepath.Join(dgitDir, "commits")
 	tempDir := filepath.Join(dgitDir, "temp")
 
-	// Ensure all directories exist
-	os.MkdirAll(objectsDir, 0755)
-	os.MkdirAll(snapshotsDir, 0755)
-	os.MkdirAll(deltasDir, 0755)
+	// Ensure all directories exist, but never recreate a relocated storage root whose
+	// volume is not mounted
+	storageErr := initializer.CheckStorage(dgitDir)
+	if storageErr == nil {
+		os.MkdirAll(objectsDir, 0755)
+		os.MkdirAll(snapshotsDir, 0755)
+		os.MkdirAll(deltasDir, 0755)
+	}
 	os.MkdirAll(commitsDir, 0755)
 	os.MkdirAll(tempDir, 0755)
 
@@ -110,13 +207,22 @@ func NewCommitManager(dgitDir string) *CommitManager {
 
 		SnapshotsDir: snapshotsDir,
 		DeltasDir:    deltasDir,
+		ArchiveDir:   filepath.Join(storageDir, "archive"),
 		CommitsDir:   commitsDir,
 		TempDir:      tempDir,
+		storageErr:   storageErr,
 
 		MaxDeltaChainLength:  5,
 		CompressionThreshold: 0.95,
 		lz4CompressionLevel:  1,
+		zstdCompressionLevel: 3,
 		enableBackgroundOpt:  false,
+		maxDeltaInput:        512 * 1024 * 1024,
+		deltaMemoryFraction:  0.5,
+		maxDeltaDuration:     120 * time.Second,
+		packThreshold:        500,
+		packBatchSize:        metapack.DefaultBatchSize,
+		tracer:               telemetry.NewTracer(dgitDir),
 	}
 
 	cm.loadConfig()
@@ -131,51 +237,157 @@ func (cm *CommitManager) CreateCommit(message string, stagedFiles []*staging.Sta
 	if len(stagedFiles) == 0 {
 		return nil, fmt.Errorf("no files staged for commit")
 	}
+	if cm.profileErr != nil {
+		return nil, cm.profileErr
+	}
+	if cm.storageErr != nil {
+		return nil, cm.storageErr
+	}
+
+	// A commit from the queue worker and one from the command line must not both take
+	// the next version number
+	if !cm.Locked {
+		lock, err := repolock.Acquire(cm.DgitDir, "commit", func(holder string) {
+			cm.printf("Waiting for another dgit command to finish (%s)...\n", holder)
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Release()
+	}
+
+	if err := initializer.EnsureFormatVersion(cm.DgitDir); err != nil {
+		return nil, fmt.Errorf("failed to record repository format: %w", err)
+	}
+
+	// A HEAD lost in a crash would leave the new commit without its parent
+	recovery, err := log.NewLogManager(cm.DgitDir).RecoverHead()
+	if err != nil {
+		return nil, fmt.Errorf("HEAD is unreadable: %w", err)
+	}
+	if recovery != nil {
+		cm.printf("Warning: HEAD was damaged; recovered it at v%d\n", recovery.Version)
+	}
 
 	// Generate version and commit metadata
 	currentVersion := cm.GetCurrentVersion()
 	newVersion := currentVersion + 1
 
-	hash := cm.generateCommitHash(message, stagedFiles, newVersion)
+	// Full content hashes detect identical re-commits and are kept in commit metadata
+	contentHashes, err := cm.hashStagedFiles(stagedFiles)
+	if err != nil {
+		return nil, err
+	}
+	if currentVersion > 0 && len(cm.Removed) == 0 && cm.unchangedSince(currentVersion, stagedFiles, contentHashes) {
+		return nil, &UnchangedError{Version: currentVersion}
+	}
+
+	// Trace the whole pipeline; phases attach child spans to commitSpan
+	cm.commitSpan = cm.tracer.StartSpan("dgit.commit", nil)
+	cm.commitSpan.SetAttribute("dgit.version", newVersion)
+	cm.commitSpan.SetAttribute("dgit.files_count", len(stagedFiles))
+	defer func() {
+		cm.commitSpan.End()
+		cm.commitSpan = nil
+		if err := cm.tracer.Flush(); err != nil {
+			cm.printf("Warning: telemetry export failed: %v\n", err)
+		}
+	}()
+
 	author := cm.getAuthor()
+	now := time.Now()
+	cm.warnClockSkew(currentVersion, now)
 
-	// Create commit structure
+	// Create commit structure; its hash is derived from the finished record below
 	commit := &Commit{
-		Hash:       hash,
 		Message:    message,
-		Timestamp:  time.Now(),
+		Timestamp:  now.UTC(),
+		Zone:       log.ZoneOffset(now),
 		Author:     author,
 		FilesCount: len(stagedFiles),
 		Version:    newVersion,
 		Metadata:   make(map[string]interface{}),
 		ParentHash: cm.getCurrentCommitHash(),
+		Removed:    cm.Removed,
+		Renamed:    cm.Renamed,
+		HashChain:  log.HashChainVersion,
+	}
+
+	var totalBytes int64
+	for _, file := range stagedFiles {
+		totalBytes += file.Size
 	}
+	cm.Progress.Emit(progress.Event{Operation: "commit", Phase: progress.PhaseScan, TotalBytes: totalBytes, Total: len(stagedFiles)})
 
 	// Extract design file metadata for commit tracking
+	scanSpan := cm.tracer.StartSpan("scan", cm.commitSpan)
 	meta, err := cm.scanFilesMetadata(stagedFiles)
+	scanSpan.RecordError(err)
+	scanSpan.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan metadata: %w", err)
 	}
+	if err := cm.checkPolicies(meta); err != nil {
+		return nil, err
+	}
 	commit.Metadata = meta
+	for path, hash := range contentHashes {
+		if fileMeta, ok := meta[path].(map[string]interface{}); ok {
+			fileMeta["hash"] = hash
+		}
+	}
+	for _, file := range stagedFiles {
+		if fileMeta, ok := meta[file.Path].(map[string]interface{}); ok && file.Template != nil {
+			fileMeta["template"] = file.Template
+		}
+	}
+	cm.captureAttributes(stagedFiles, meta)
+	if environment.Enabled(cm.DgitDir) {
+		commit.Environment = environment.Capture(cm.DgitDir, meta)
+	}
+
+	cm.Progress.Emit(progress.Event{Operation: "commit", Phase: progress.PhaseCompress, TotalBytes: totalBytes, Total: len(stagedFiles)})
 
 	// Create snapshot with compression
 	compressionResult, err := cm.createSnapshot(stagedFiles, newVersion, currentVersion, startTime)
 	if err != nil {
+		cm.commitSpan.RecordError(err)
 		return nil, fmt.Errorf("snapshot creation failed: %w", err)
 	}
+	compressionResult.Profile = cm.profile
+	cm.commitSpan.SetAttribute("dgit.strategy", compressionResult.Strategy)
+	cm.tracer.RecordMetric("dgit.commit.original_bytes", "By", float64(compressionResult.OriginalSize), nil)
+	cm.tracer.RecordMetric("dgit.commit.stored_bytes", "By", float64(compressionResult.CompressedSize), nil)
+	cm.tracer.RecordMetric("dgit.commit.compression_ratio", "1", compressionResult.CompressionRatio,
+		map[string]interface{}{"strategy": compressionResult.Strategy})
 
 	commit.CompressionInfo = compressionResult
 	if compressionResult.Strategy == "zip" {
 		commit.SnapshotZip = compressionResult.OutputFile
 	}
 
+	cm.Progress.Emit(progress.Event{Operation: "commit", Phase: progress.PhaseStore,
+		Bytes: totalBytes, TotalBytes: totalBytes, Current: len(stagedFiles), Total: len(stagedFiles)})
+
+	hash, err := cm.chainHash(commit)
+	if err != nil {
+		return nil, err
+	}
+	commit.Hash = hash
+
 	// Save commit metadata and update repository state
+	ioSpan := cm.tracer.StartSpan("io", cm.commitSpan)
 	if err := cm.saveCommitMetadata(commit); err != nil {
+		ioSpan.RecordError(err)
+		ioSpan.End()
 		return nil, fmt.Errorf("save metadata failed: %w", err)
 	}
-	if err := cm.updateHead(hash); err != nil {
+	if err := cm.updateHead(hash, newVersion); err != nil {
+		ioSpan.RecordError(err)
+		ioSpan.End()
 		return nil, fmt.Errorf("update HEAD failed: %w", err)
 	}
+	ioSpan.End()
 
 	// Calculate final performance metrics
 	totalTime := time.Since(startTime)
@@ -185,13 +397,54 @@ func (cm *CommitManager) CreateCommit(message string, stagedFiles []*staging.Sta
 	cm.displayCompressionStats(compressionResult, totalTime)
 
 	// Schedule background optimization for better compression ratios (non-blocking)
-	if cm.enableBackgroundOpt && compressionResult.Strategy == "lz4" {
+	if cm.enableBackgroundOpt && !cm.Provisional && compressionResult.Strategy == "lz4" {
 		go cm.scheduleBackgroundOptimization(newVersion, compressionResult)
 	}
 
+	cm.packMetadataIfNeeded()
+	cm.recordActivity(commit, stagedFiles, totalBytes)
+	cm.generatePreviews(stagedFiles, contentHashes)
+
+	cm.Progress.Emit(progress.Event{Operation: "commit", Phase: progress.PhaseDone,
+		Bytes: totalBytes, TotalBytes: totalBytes, Current: len(stagedFiles), Total: len(stagedFiles)})
+
 	return commit, nil
 }
 
+// printf writes human-readable progress in CLI mode; library callers get events instead
+func (cm *CommitManager) printf(format string, a ...interface{}) {
+	if !cm.Progress.Enabled() {
+		fmt.Printf(format, a...)
+	}
+}
+
+// println is the Println counterpart of printf
+func (cm *CommitManager) println(a ...interface{}) {
+	if !cm.Progress.Enabled() {
+		fmt.Println(a...)
+	}
+}
+
+// packMetadataIfNeeded packs loose commit JSONs once they exceed the configured threshold
+// Packing failures leave loose records untouched, so they are reported but never fail the commit
+func (cm *CommitManager) packMetadataIfNeeded() {
+	if !cm.packMetadata || cm.Provisional {
+		return
+	}
+
+	store := metapack.NewStore(cm.CommitsDir)
+	if store.LooseCount() < cm.packThreshold {
+		return
+	}
+
+	result, err := store.Pack(cm.packBatchSize)
+	if err != nil {
+		cm.printf("Warning: metadata packing failed: %v\n", err)
+		return
+	}
+	cm.printf("Packed %d commit records into %d packs\n", result.RecordsPacked, result.PacksWritten)
+}
+
 // createSnapshot chooses optimal compression strategy based on file characteristics
 func (cm *CommitManager) createSnapshot(files []*staging.StagedFile, version, prevVersion int, startTime time.Time) (*CompressionResult, error) {
 	// Strategy 1: LZ4 compression for appropriate files
@@ -201,16 +454,38 @@ func (cm *CommitManager) createSnapshot(files []*staging.StagedFile, version, pr
 
 	// Strategy 2: Smart Delta for compatible files
 	if version > 1 && !cm.shouldCreateNewSnapshot(prevVersion) {
+		// bsdiff can exhaust memory on huge inputs; store a snapshot instead of swapping
+		if reason := cm.checkDeltaResources(files); reason != "" {
+			cm.printf("Skipping bsdiff delta: %s - storing full snapshot\n", reason)
+			cm.commitSpan.SetAttribute("dgit.delta_skipped", reason)
+			result, err := cm.compressWithLZ4(files, version, startTime)
+			if result != nil {
+				result.DeltaSkipped = reason
+			}
+			return result, err
+		}
+
 		deltaResult, err := cm.createDelta(files, version, prevVersion, startTime)
+		var timeout *deltaTimeoutError
+		if errors.As(err, &timeout) {
+			reason := timeout.Error()
+			cm.printf("Abandoning bsdiff delta: %s - storing full snapshot\n", reason)
+			cm.commitSpan.SetAttribute("dgit.delta_skipped", reason)
+			result, err := cm.compressWithLZ4(files, version, startTime)
+			if result != nil {
+				result.DeltaSkipped = reason
+			}
+			return result, err
+		}
 		if err != nil {
-			fmt.Printf("Delta creation failed: %v\n", err)
-			fmt.Printf("Falling back to LZ4 compression...\n")
+			cm.printf("Delta creation failed: %v\n", err)
+			cm.printf("Falling back to LZ4 compression...\n")
 		} else if deltaResult.CompressionRatio <= cm.CompressionThreshold {
 			return deltaResult, nil
 		} else {
-			fmt.Printf("Delta compression ratio %.1f%% exceeds threshold %.1f%%\n",
+			cm.printf("Delta compression ratio %.1f%% exceeds threshold %.1f%%\n",
 				deltaResult.CompressionRatio*100, cm.CompressionThreshold*100)
-			fmt.Printf("Falling back to LZ4 compression...\n")
+			cm.printf("Falling back to LZ4 compression...\n")
 			os.Remove(filepath.Join(cm.DeltasDir, deltaResult.OutputFile))
 		}
 	}
@@ -228,14 +503,14 @@ func (cm *CommitManager) shouldUseLZ4(files []*staging.StagedFile, version int)
 	for _, file := range files {
 		// Very large files: use LZ4 snapshot (bsdiff is too slow)
 		if file.Size > 100*1024*1024 { // 100MB
-			fmt.Printf("Very large file detected (%s, %.1f MB) - creating new snapshot\n",
+			cm.printf("Very large file detected (%s, %.1f MB) - creating new snapshot\n",
 				filepath.Base(file.Path), float64(file.Size)/(1024*1024))
 			return true
 		}
 
 		// Medium files: use delta compression
 		if file.Size > SmallFileThreshold { // 50MB
-			fmt.Printf("Large file detected (%s, %.1f MB) - using delta compression\n",
+			cm.printf("Large file detected (%s, %.1f MB) - using delta compression\n",
 				filepath.Base(file.Path), float64(file.Size)/(1024*1024))
 			return false
 		}
@@ -249,6 +524,104 @@ func (cm *CommitManager) shouldUseLZ4(files []*staging.StagedFile, version int)
 	return true
 }
 
+// bsdiffMemoryFactor approximates bsdiff peak memory: two int suffix arrays (16 bytes
+// per base byte) plus the base itself, and the new data held roughly three times
+const bsdiffMemoryFactor = 17
+
+// fileDeltaMemory estimates the memory one file's bsdiff needs; the file's base is
+// assumed to be about its current size
+func fileDeltaMemory(size int64) uint64 {
+	return uint64(bsdiffMemoryFactor*size + 3*size)
+}
+
+// deltaWorkerCount returns how many files are diffed at once: the configured worker
+// count (one per CPU by default), no more than there are files, and lowered until the
+// largest files diffed together fit the memory bsdiff may use
+func (cm *CommitManager) deltaWorkerCount(files []*staging.StagedFile) int {
+	workers := cm.deltaWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	sizes := make([]int64, len(files))
+	for i, f := range files {
+		sizes[i] = f.Size
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+
+	if available := sysmem.Available(); available > 0 {
+		budget := float64(available) * cm.deltaMemoryFraction
+		for ; workers > 1; workers-- {
+			var needed uint64
+			for _, size := range sizes[:workers] {
+				needed += fileDeltaMemory(size)
+			}
+			if float64(needed) <= budget {
+				break
+			}
+		}
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// bsdiffBytesPerSecond is a pessimistic bsdiff throughput over base plus new data;
+// design files usually diff faster, and the deadline in runBsdiff catches the rest
+const bsdiffBytesPerSecond = 1024 * 1024
+
+// deltaTimeoutError is returned when bsdiff runs past the delta time limit
+type deltaTimeoutError struct {
+	limit time.Duration
+}
+
+func (e *deltaTimeoutError) Error() string {
+	return fmt.Sprintf("bsdiff ran past the %s delta time limit", e.limit)
+}
+
+// checkDeltaResources returns why diffing files would be too large or too slow for this
+// machine, or "" when it is safe to run. Each file is diffed on its own, so the largest
+// file bounds memory and input size, and the workers share the total time
+func (cm *CommitManager) checkDeltaResources(files []*staging.StagedFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	largest := files[0]
+	var total int64
+	for _, f := range files {
+		total += 2 * f.Size
+		if f.Size > largest.Size {
+			largest = f
+		}
+	}
+
+	if cm.maxDeltaInput > 0 && 2*largest.Size > cm.maxDeltaInput {
+		return fmt.Sprintf("%s and its base total %.1f MB, above the %.1f MB delta limit",
+			largest.Path, float64(2*largest.Size)/(1024*1024), float64(cm.maxDeltaInput)/(1024*1024))
+	}
+
+	work := total / int64(cm.deltaWorkerCount(files))
+	if 2*largest.Size > work {
+		work = 2 * largest.Size
+	}
+	estimate := time.Duration(float64(work) / bsdiffBytesPerSecond * float64(time.Second))
+	if cm.maxDeltaDuration > 0 && estimate > cm.maxDeltaDuration {
+		return fmt.Sprintf("would take about %s, above the %s delta time limit",
+			estimate.Round(time.Second), cm.maxDeltaDuration)
+	}
+
+	needed := fileDeltaMemory(largest.Size)
+	if available := sysmem.Available(); available > 0 && float64(needed) > float64(available)*cm.deltaMemoryFraction {
+		return fmt.Sprintf("needs about %.0f MB of memory but only %.0f MB is available",
+			float64(needed)/(1024*1024), float64(available)/(1024*1024))
+	}
+	return ""
+}
+
 // createDelta creates smart delta compression for design files
 func (cm *CommitManager) createDelta(files []*staging.StagedFile, version, baseVersion int, startTime time.Time) (*CompressionResult, error) {
 	// Use bsdiff for all delta compression
@@ -265,6 +638,10 @@ func (cm *CommitManager) selectDeltaAlgorithm(files []*staging.StagedFile) strin
 func (cm *CommitManager) compressWithLZ4(files []*staging.StagedFile, version int, startTime time.Time) (*CompressionResult, error) {
 	compressionStartTime := time.Now()
 
+	span := cm.tracer.StartSpan("compress", cm.commitSpan)
+	span.SetAttribute("dgit.algorithm", "lz4")
+	defer span.End()
+
 	// Store in versions directory for immediate access
 	versionPath := filepath.Join(cm.SnapshotsDir, fmt.Sprintf("v%d.lz4", version))
 
@@ -275,27 +652,37 @@ func (cm *CommitManager) compressWithLZ4(files []*staging.StagedFile, version in
 	}
 	defer outFile.Close()
 
+	if err := objfmt.WriteHeader(outFile, objfmt.TypeSnapshot); err != nil {
+		return nil, fmt.Errorf("write object header: %w", err)
+	}
+
 	// LZ4 compression with level 1 for speed
 	lz4Writer := lz4.NewWriter(outFile)
 	defer lz4Writer.Close()
 
-	lz4Writer.Apply(lz4.CompressionLevelOption(lz4.Level1))
+	lz4Writer.Apply(lz4.CompressionLevelOption(cm.lz4Level()))
+
+	var totalBytes int64
+	for _, file := range files {
+		totalBytes += file.Size
+	}
 
 	// Stream all files through LZ4 with structured headers
 	var originalSize int64
-	for _, file := range files {
+	for i, file := range files {
+
 		// 익명 함수로 defer 처리
 		func() {
 			srcFile, err := os.Open(file.AbsolutePath)
 			if err != nil {
-				fmt.Printf("Warning: failed to open %s: %v\n", file.Path, err)
+				cm.printf("Warning: failed to open %s: %v\n", file.Path, err)
 				return
 			}
 			defer srcFile.Close() // 이제 익명함수 내에서 defer 호출
 
 			fileContent, err := io.ReadAll(srcFile)
 			if err != nil {
-				fmt.Printf("Warning: failed to read %s: %v\n", file.Path, err)
+				cm.printf("Warning: failed to read %s: %v\n", file.Path, err)
 				return
 			}
 
@@ -306,17 +693,19 @@ func (cm *CommitManager) compressWithLZ4(files []*staging.StagedFile, version in
 			header := fmt.Sprintf("FILE:%s:%d\n", file.Path, actualSize)
 			_, err = lz4Writer.Write([]byte(header))
 			if err != nil {
-				fmt.Printf("Warning: failed to write header for %s: %v\n", file.Path, err)
+				cm.printf("Warning: failed to write header for %s: %v\n", file.Path, err)
 				return
 			}
 
 			// Write file content through LZ4
 			_, err = lz4Writer.Write(fileContent)
 			if err != nil {
-				fmt.Printf("Warning: failed to compress %s: %v\n", file.Path, err)
+				cm.printf("Warning: failed to compress %s: %v\n", file.Path, err)
 				return
 			}
 		}()
+		cm.Progress.Emit(progress.Event{Operation: "commit", Phase: progress.PhaseCompress, File: file.Path,
+			Bytes: originalSize, TotalBytes: totalBytes, Current: i + 1, Total: len(files)})
 	}
 
 	// Ensure LZ4 writer is properly closed before checking file size
@@ -364,109 +753,103 @@ func (cm *CommitManager) compressWithLZ4(files []*staging.StagedFile, version in
 		CompressionRatio: ratio,
 		CompressionTime:  compressionTime,
 		CacheLevel:       "snapshots",
-		CreatedAt:        time.Now(),
+		CreatedAt:        time.Now().UTC(),
 	}, nil
 }
 
 // Background optimization system for improved compression ratios
 
-// createBsdiffDelta creates binary diff delta compression
+// createBsdiffDelta diffs each file against the same file in the base version, several
+// files at a time, and stores the patches as one per-file delta (see filedelta)
 func (cm *CommitManager) createBsdiffDelta(
 	files []*staging.StagedFile,
 	version, baseVersion int,
 ) (*CompressionResult, error) {
 	compressionStart := time.Now()
 
-	fmt.Printf("Creating bsdiff delta: v%d from v%d\n", version, baseVersion)
-
-	// Step 1: Create temporary ZIP from current files (uncompressed originals)
-	tempCurrentZip := filepath.Join(cm.TempDir, fmt.Sprintf("temp_current_v%d.zip", version))
-	defer os.Remove(tempCurrentZip)
-
-	fmt.Printf("  Creating temporary current version ZIP...\n")
-	if err := cm.createTempZipFile(files, tempCurrentZip); err != nil {
-		return nil, fmt.Errorf("failed to create current temp ZIP: %w", err)
-	}
+	span := cm.tracer.StartSpan("delta", cm.commitSpan)
+	span.SetAttribute("dgit.algorithm", "bsdiff")
+	span.SetAttribute("dgit.base_version", baseVersion)
+	defer span.End()
 
-	currentZipSize, _ := getFileSize(tempCurrentZip)
-	fmt.Printf("  Current version ZIP: %.2f MB\n", float64(currentZipSize)/(1024*1024))
+	cm.printf("Creating bsdiff delta: v%d from v%d\n", version, baseVersion)
 
-	// Step 2: Find and convert base version to ZIP
 	basePath := cm.findVersionInStorage(baseVersion)
 	if basePath == "" {
 		return nil, fmt.Errorf("base version v%d not found", baseVersion)
 	}
+	access.NewAccessTracker(cm.DgitDir).RecordRead(baseVersion)
 
-	tempBaseZip := filepath.Join(cm.TempDir, fmt.Sprintf("temp_base_v%d.zip", baseVersion))
+	tempBaseZip, err := cm.tempPath(fmt.Sprintf("base_v%d_*.zip", baseVersion))
+	if err != nil {
+		return nil, err
+	}
 	defer os.Remove(tempBaseZip)
 
-	fmt.Printf("  Converting base version from %s...\n", filepath.Base(basePath))
+	cm.printf("  Converting base version from %s...\n", filepath.Base(basePath))
 	if err := cm.convertToZip(basePath, tempBaseZip); err != nil {
 		return nil, fmt.Errorf("failed to convert base to ZIP: %w", err)
 	}
-
-	baseZipSize, _ := getFileSize(tempBaseZip)
-	fmt.Printf("  Base version ZIP: %.2f MB\n", float64(baseZipSize)/(1024*1024))
-
-	// Create smart delta with layer change information
-	deltaPath := filepath.Join(cm.DeltasDir, fmt.Sprintf("v%d_from_v%d.psd_smart", version, baseVersion))
-
-	fmt.Printf("  Computing binary delta...\n")
-	baseFile, err := os.Open(tempBaseZip)
+	baseZip, err := zip.OpenReader(tempBaseZip)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open base ZIP: %w", err)
 	}
-	defer baseFile.Close()
+	defer baseZip.Close()
+	baseEntries := make(map[string]*zip.File, len(baseZip.File))
+	for _, f := range baseZip.File {
+		baseEntries[f.Name] = f
+	}
 
-	currentFile, err := os.Open(tempCurrentZip)
+	workers := cm.deltaWorkerCount(files)
+	span.SetAttribute("dgit.delta_workers", workers)
+	cm.printf("  Computing binary deltas for %d files, %d at a time...\n", len(files), workers)
+	deltas, err := cm.diffFiles(files, baseEntries, workers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open current ZIP: %w", err)
+		span.RecordError(err)
+		return nil, err
 	}
-	defer currentFile.Close()
 
+	deltaPath := filepath.Join(cm.DeltasDir, fmt.Sprintf("v%d_from_v%d.psd_smart", version, baseVersion))
 	deltaFile, err := os.Create(deltaPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create delta file: %w", err)
 	}
 	defer deltaFile.Close()
 
-	// Create the delta using Reader
-	oldData, err := io.ReadAll(baseFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read base file: %w", err)
+	if err := objfmt.WriteHeader(deltaFile, objfmt.TypeDelta); err != nil {
+		return nil, fmt.Errorf("failed to write object header: %w", err)
 	}
-
-	newData, err := io.ReadAll(currentFile)
+	writer, err := filedelta.NewWriter(deltaFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read current file: %w", err)
+		return nil, fmt.Errorf("failed to write delta: %w", err)
 	}
 
-	patch, err := bsdiff.Bytes(oldData, newData)
-	if err != nil {
-		return nil, fmt.Errorf("bsdiff delta creation failed: %w", err)
-	}
-
-	if _, err := deltaFile.Write(patch); err != nil {
-		return nil, fmt.Errorf("failed to write patch: %w", err)
+	var originalSize int64
+	fileStats := make([]FileDelta, len(deltas))
+	for i, delta := range deltas {
+		if delta.stats.Base != "" {
+			err = writer.WritePatch(delta.stats.Path, delta.stats.Base, delta.data)
+		} else {
+			err = writer.WriteFile(delta.stats.Path, delta.data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write delta for %s: %w", delta.stats.Path, err)
+		}
+		originalSize += delta.stats.OriginalSize
+		fileStats[i] = delta.stats
 	}
 
 	deltaFile.Close() // Ensure file is closed before stat
 
-	// Step 4: Calculate results
 	deltaSize, err := getFileSize(deltaPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat delta file: %w", err)
 	}
 
-	var originalSize int64
-	for _, f := range files {
-		originalSize += f.Size
-	}
-
 	compressionTime := float64(time.Since(compressionStart).Nanoseconds()) / 1000000.0
 	compressionRatio := float64(deltaSize) / float64(originalSize)
 
-	fmt.Printf("  ✓ Delta created: %.2f MB (%.1f%% of original)\n",
+	cm.printf("  ✓ Delta created: %.2f MB (%.1f%% of original)\n",
 		float64(deltaSize)/(1024*1024),
 		compressionRatio*100)
 
@@ -479,10 +862,166 @@ func (cm *CommitManager) createBsdiffDelta(
 		CompressionTime:  compressionTime,
 		CacheLevel:       "snapshots",
 		BaseVersion:      baseVersion,
-		CreatedAt:        time.Now(),
+		CreatedAt:        time.Now().UTC(),
+		Files:            fileStats,
 	}, nil
 }
 
+// fileDeltaResult is one file's share of a per-file delta: a patch against stats.Base,
+// or the whole file when stats.Base is empty
+type fileDeltaResult struct {
+	data  []byte
+	stats FileDelta
+}
+
+// diffFiles diffs files against the base version with a pool of workers. Results keep
+// the order of files; the first error stops workers from starting further files
+func (cm *CommitManager) diffFiles(files []*staging.StagedFile, baseEntries map[string]*zip.File, workers int) ([]fileDeltaResult, error) {
+	var deadline time.Time
+	if cm.maxDeltaDuration > 0 {
+		deadline = time.Now().Add(cm.maxDeltaDuration)
+	}
+
+	var totalBytes int64
+	for _, f := range files {
+		totalBytes += f.Size
+	}
+
+	results := make([]fileDeltaResult, len(files))
+	jobs := make(chan int)
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		firstErr  error
+		completed int
+		processed int64
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				mu.Lock()
+				stopped := firstErr != nil
+				mu.Unlock()
+				if stopped {
+					continue
+				}
+
+				result, err := cm.diffFile(files[i], baseEntries, deadline)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				results[i] = result
+				completed++
+				processed += files[i].Size
+				if result.stats.Base != "" {
+					cm.printf("    %s: %.2f MB patch in %.0fms\n", files[i].Path,
+						float64(result.stats.CompressedSize)/(1024*1024), result.stats.DiffTime)
+				} else {
+					cm.printf("    %s: stored whole in %.0fms\n", files[i].Path, result.stats.DiffTime)
+				}
+				cm.Progress.Emit(progress.Event{Operation: "commit", Phase: progress.PhaseCompress, File: files[i].Path,
+					Bytes: processed, TotalBytes: totalBytes, Current: completed, Total: len(files)})
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// diffFile diffs one file against its base entry, found under its own path or, for a
+// file moved with 'dgit mv', the path it was moved from. Files without a base entry, or
+// whose patch would not be smaller, are stored whole
+func (cm *CommitManager) diffFile(file *staging.StagedFile, baseEntries map[string]*zip.File, deadline time.Time) (fileDeltaResult, error) {
+	start := time.Now()
+	newData, err := os.ReadFile(file.AbsolutePath)
+	if err != nil {
+		return fileDeltaResult{}, fmt.Errorf("failed to read %s: %w", file.Path, err)
+	}
+	result := fileDeltaResult{data: newData, stats: FileDelta{Path: file.Path, OriginalSize: int64(len(newData))}}
+
+	baseName := file.Path
+	if _, ok := baseEntries[baseName]; !ok && cm.Renamed[file.Path] != "" {
+		baseName = cm.Renamed[file.Path]
+	}
+	if baseEntry, ok := baseEntries[baseName]; ok {
+		oldData, err := readZipEntry(baseEntry)
+		if err != nil {
+			return fileDeltaResult{}, fmt.Errorf("failed to read base of %s: %w", file.Path, err)
+		}
+		patch, err := cm.runBsdiff(oldData, newData, deadline)
+		if err != nil {
+			var timeout *deltaTimeoutError
+			if errors.As(err, &timeout) {
+				return fileDeltaResult{}, err
+			}
+			return fileDeltaResult{}, fmt.Errorf("bsdiff delta creation failed for %s: %w", file.Path, err)
+		}
+		if len(patch) < len(newData) {
+			result.data = patch
+			result.stats.Base = baseName
+		}
+	}
+
+	result.stats.CompressedSize = int64(len(result.data))
+	result.stats.DiffTime = float64(time.Since(start).Nanoseconds()) / 1000000.0
+	return result, nil
+}
+
+// readZipEntry reads one archive entry into memory
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// runBsdiff diffs oldData against newData, giving up once deadline passes (a zero
+// deadline never expires). bsdiff cannot be interrupted, so an abandoned diff finishes
+// in the background and its patch is dropped
+func (cm *CommitManager) runBsdiff(oldData, newData []byte, deadline time.Time) ([]byte, error) {
+	if deadline.IsZero() {
+		return bsdiff.Bytes(oldData, newData)
+	}
+
+	type outcome struct {
+		patch []byte
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		patch, err := bsdiff.Bytes(oldData, newData)
+		done <- outcome{patch, err}
+	}()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case result := <-done:
+		return result.patch, result.err
+	case <-timer.C:
+		return nil, &deltaTimeoutError{limit: cm.maxDeltaDuration}
+	}
+}
+
 // Background optimization system for improved compression ratios
 
 // scheduleBackgroundOptimization queues background optimization tasks
@@ -518,8 +1057,15 @@ func (cm *CommitManager) optimizeToCache(version int, result *CompressionResult)
 	defer cacheFile.Close()
 
 	// LZ4 decompression → Zstd compression pipeline
-	lz4Reader := lz4.NewReader(versionFile)
-	zstdWriter, err := zstd.NewWriter(cacheFile, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	payload, err := objfmt.NewReader(versionFile, versionPath)
+	if err != nil {
+		return
+	}
+	if err := objfmt.WriteHeader(cacheFile, objfmt.TypeSnapshot); err != nil {
+		return
+	}
+	lz4Reader := lz4.NewReader(payload)
+	zstdWriter, err := zstd.NewWriter(cacheFile, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(cm.zstdCompressionLevel)))
 	if err != nil {
 		return
 	}
@@ -547,19 +1093,19 @@ func (cm *CommitManager) createPSDSmartDelta(files []*staging.StagedFile, versio
 		return nil, fmt.Errorf("no PSD file found")
 	}
 
-	fmt.Printf("Analyzing PSD layers for smart delta (v%d vs v%d)...\n", version, baseVersion)
+	cm.printf("Analyzing PSD layers for smart delta (v%d vs v%d)...\n", version, baseVersion)
 
 	// Extract detailed layer information from current PSD
 	currentLayers, err := cm.extractPSDLayerInfo(psdFile.AbsolutePath)
 	if err != nil {
-		fmt.Printf("Warning: Failed to extract current layer info: %v\n", err)
+		cm.printf("Warning: Failed to extract current layer info: %v\n", err)
 		return cm.fallbackToBinaryDelta(files, version, baseVersion)
 	}
 
 	// Extract layer information from previous version
 	previousLayers, err := cm.extractPreviousVersionLayers(baseVersion, psdFile.Path)
 	if err != nil {
-		fmt.Printf("Warning: Failed to extract previous layer info: %v\n", err)
+		cm.printf("Warning: Failed to extract previous layer info: %v\n", err)
 		return cm.fallbackToBinaryDelta(files, version, baseVersion)
 	}
 
@@ -587,7 +1133,7 @@ func (cm *CommitManager) createPSDSmartDelta(files []*staging.StagedFile, versio
 		CompressionTime:  compressionTime,
 		CacheLevel:       "deltas",
 		BaseVersion:      baseVersion,
-		CreatedAt:        time.Now(),
+		CreatedAt:        time.Now().UTC(),
 	}, nil
 }
 
@@ -595,6 +1141,9 @@ func (cm *CommitManager) createPSDSmartDelta(files []*staging.StagedFile, versio
 type LayerChange struct {
 	LayerID         int                    `json:"layer_id"`
 	LayerName       string                 `json:"layer_name"`
+	OldName         string                 `json:"old_name,omitempty"`  // Previous name of a renamed layer
+	OldGroup        string                 `json:"old_group,omitempty"` // Enclosing group before a move, "" at the top level
+	NewGroup        string                 `json:"new_group,omitempty"` // Enclosing group after a move or reorder
 	ChangeType      string                 `json:"change_type"`
 	OldHash         string                 `json:"old_hash,omitempty"`
 	NewHash         string                 `json:"new_hash,omitempty"`
@@ -603,12 +1152,15 @@ type LayerChange struct {
 
 // ChangeAnalysis containsdetailed analysis of layer changes between versions
 type ChangeAnalysis struct {
-	TotalLayers    int           `json:"total_layers"`
-	ChangedLayers  []LayerChange `json:"changed_layers"`
-	AddedLayers    []LayerChange `json:"added_layers"`
-	DeletedLayers  []LayerChange `json:"deleted_layers"`
-	UnchangedCount int           `json:"unchanged_count"`
-	ChangesSummary string        `json:"changes_summary"`
+	TotalLayers     int           `json:"total_layers"`
+	ChangedLayers   []LayerChange `json:"changed_layers"`
+	AddedLayers     []LayerChange `json:"added_layers"`
+	DeletedLayers   []LayerChange `json:"deleted_layers"`
+	RenamedLayers   []LayerChange `json:"renamed_layers"`
+	MovedLayers     []LayerChange `json:"moved_layers"`     // Moved into, out of or between groups
+	ReorderedGroups []LayerChange `json:"reordered_groups"` // Groups that changed places within their parent
+	UnchangedCount  int           `json:"unchanged_count"`
+	ChangesSummary  string        `json:"changes_summary"`
 }
 
 // extractPSDLayerInfo extracts detailed layer information from PSD file
@@ -628,17 +1180,17 @@ func (cm *CommitManager) extractPreviousVersionLayers(baseVersion int, filePath
 		return nil, fmt.Errorf("previous version v%d not found in storage", baseVersion)
 	}
 
-	fmt.Printf("Previous version found at: %s\n", basePath)
+	cm.printf("Previous version found at: %s\n", basePath)
 
 	// Create temporary file to reconstruct the previous PSD
-	tempDir := filepath.Join(cm.TempDir, "temp")
-	os.MkdirAll(tempDir, 0755)
-
-	tempPSDPath := filepath.Join(tempDir, fmt.Sprintf("temp_v%d.psd", baseVersion))
+	tempPSDPath, err := cm.tempPath(fmt.Sprintf("v%d_*.psd", baseVersion))
+	if err != nil {
+		return nil, err
+	}
 	defer os.Remove(tempPSDPath)
 
 	// Extract/decompress the cached file to get the original PSD
-	err := cm.extractCachedFileToPSD(basePath, tempPSDPath, filePath)
+	err = cm.extractCachedFileToPSD(basePath, tempPSDPath, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract cached file: %w", err)
 	}
@@ -649,7 +1201,7 @@ func (cm *CommitManager) extractPreviousVersionLayers(baseVersion int, filePath
 		return nil, fmt.Errorf("failed to parse previous PSD layers: %w", err)
 	}
 
-	fmt.Printf("Extracted %d layers from previous version v%d\n", len(previousLayers), baseVersion)
+	cm.printf("Extracted %d layers from previous version v%d\n", len(previousLayers), baseVersion)
 	return previousLayers, nil
 }
 
@@ -663,29 +1215,29 @@ func (cm *CommitManager) displayCompressionStats(result *CompressionResult, tota
 	// Display compression results based on strategy
 	switch result.Strategy {
 	case "lz4":
-		fmt.Printf("LZ4 compression: %.1f%% compressed in %.1fms\n", compressionPercent, result.CompressionTime)
-		fmt.Printf("Compression completed efficiently\n")
-		fmt.Printf("Cache: %s | File: %s\n", result.CacheLevel, result.OutputFile)
+		cm.printf("LZ4 compression: %.1f%% compressed in %.1fms\n", compressionPercent, result.CompressionTime)
+		cm.printf("Compression completed efficiently\n")
+		cm.printf("Cache: %s | File: %s\n", result.CacheLevel, result.OutputFile)
 	case "psd_smart":
-		fmt.Printf("PSD Smart Delta: %.1f%% space saved in %.1fms\n", compressionPercent, result.CompressionTime)
-		fmt.Printf("Base: v%d | Changes detected and optimized\n", result.BaseVersion)
+		cm.printf("PSD Smart Delta: %.1f%% space saved in %.1fms\n", compressionPercent, result.CompressionTime)
+		cm.printf("Base: v%d | Changes detected and optimized\n", result.BaseVersion)
 	case "bsdiff":
-		fmt.Printf("Binary Delta: %.1f%% saved in %.1fms\n", compressionPercent, result.CompressionTime)
-		fmt.Printf("Base: v%d | Delta file: %s\n", result.BaseVersion, result.OutputFile)
+		cm.printf("Binary Delta: %.1f%% saved in %.1fms\n", compressionPercent, result.CompressionTime)
+		cm.printf("Base: v%d | Delta file: %s\n", result.BaseVersion, result.OutputFile)
 	default:
-		fmt.Printf("%s compression: %.1f%% in %.1fms\n", strings.ToUpper(result.Strategy), compressionPercent, result.CompressionTime)
+		cm.printf("%s compression: %.1f%% in %.1fms\n", strings.ToUpper(result.Strategy), compressionPercent, result.CompressionTime)
 	}
 
 	// Overall performance summary
 	if totalTimeMs < 500 {
-		fmt.Printf("Fast commit completed in %.0fms\n", totalTimeMs)
+		cm.printf("Fast commit completed in %.0fms\n", totalTimeMs)
 	} else {
-		fmt.Printf("Commit completed in %.0fms\n", totalTimeMs)
+		cm.printf("Commit completed in %.0fms\n", totalTimeMs)
 	}
 
 	// Background optimization notice
-	if cm.enableBackgroundOpt && result.Strategy == "lz4" {
-		fmt.Printf("Optimization scheduled\n")
+	if cm.enableBackgroundOpt && !cm.Provisional && result.Strategy == "lz4" {
+		cm.printf("Optimization scheduled\n")
 	}
 }
 
@@ -693,7 +1245,7 @@ func (cm *CommitManager) displayCompressionStats(result *CompressionResult, tota
 
 // loadConfig loads compression configuration from repository
 func (cm *CommitManager) loadConfig() {
-	if data, err := os.ReadFile(cm.ConfigFile); err == nil {
+	if data, err := initializer.ReadConfigJSON(cm.DgitDir); err == nil {
 		var config map[string]interface{}
 		if json.Unmarshal(data, &config) == nil {
 			if compression, ok := config["compression"].(map[string]interface{}); ok {
@@ -702,6 +1254,45 @@ func (cm *CommitManager) loadConfig() {
 						cm.lz4CompressionLevel = int(level)
 					}
 				}
+				if zstdConfig, ok := compression["zstd_stage"].(map[string]interface{}); ok {
+					if level, ok := zstdConfig["compression_level"].(float64); ok && level > 0 {
+						cm.zstdCompressionLevel = int(level)
+					}
+					if enabled, ok := zstdConfig["enabled"].(bool); ok {
+						cm.enableBackgroundOpt = enabled
+					}
+				}
+				if deltaConfig, ok := compression["delta"].(map[string]interface{}); ok {
+					if maxInput, ok := deltaConfig["max_input_size"].(float64); ok && maxInput > 0 {
+						cm.maxDeltaInput = int64(maxInput)
+					}
+					if fraction, ok := deltaConfig["max_memory_fraction"].(float64); ok && fraction > 0 {
+						cm.deltaMemoryFraction = fraction
+					}
+					if seconds, ok := deltaConfig["max_seconds"].(float64); ok && seconds > 0 {
+						cm.maxDeltaDuration = time.Duration(seconds * float64(time.Second))
+					}
+					if workers, ok := deltaConfig["workers"].(float64); ok && workers > 0 {
+						cm.deltaWorkers = int(workers)
+					}
+				}
+				// A profile replaces the individual knobs above
+				if profile, ok := compression["profile"].(string); ok && profile != "" {
+					if err := cm.ApplyProfile(profile); err != nil {
+						cm.profileErr = fmt.Errorf("compression.profile in config: %w", err)
+					}
+				}
+			}
+			if metadata, ok := config["metadata"].(map[string]interface{}); ok {
+				if enabled, ok := metadata["pack_enabled"].(bool); ok {
+					cm.packMetadata = enabled
+				}
+				if threshold, ok := metadata["pack_threshold"].(float64); ok {
+					cm.packThreshold = int(threshold)
+				}
+				if batchSize, ok := metadata["pack_batch_size"].(float64); ok {
+					cm.packBatchSize = int(batchSize)
+				}
 			}
 		}
 	}
@@ -733,6 +1324,12 @@ func (cm *CommitManager) findVersionInStorage(version int) string {
 		return legacyPath
 	}
 
+	// Check archive tier
+	archivePath := filepath.Join(cm.ArchiveDir, fmt.Sprintf("v%d.zstd", version))
+	if cm.fileExists(archivePath) {
+		return archivePath
+	}
+
 	return ""
 }
 
@@ -743,11 +1340,17 @@ func (cm *CommitManager) openStoredFile(path string) (io.ReadCloser, error) {
 		return nil, err
 	}
 
+	payload, err := objfmt.NewReader(file, path)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
 	// Return appropriate decompression reader based on file extension
 	if strings.HasSuffix(path, ".lz4") {
-		return &lz4ReadCloser{lz4.NewReader(file), file}, nil
+		return &lz4ReadCloser{lz4.NewReader(payload), file}, nil
 	} else if strings.HasSuffix(path, ".zstd") {
-		zstdReader, err := zstd.NewReader(file)
+		zstdReader, err := zstd.NewReader(payload)
 		if err != nil {
 			file.Close()
 			return nil, err
@@ -793,20 +1396,20 @@ func (cm *CommitManager) createTempLZ4File(files []*staging.StagedFile, outputPa
 
 	lz4Writer := lz4.NewWriter(outFile)
 	defer lz4Writer.Close()
-	lz4Writer.Apply(lz4.CompressionLevelOption(lz4.Level1))
+	lz4Writer.Apply(lz4.CompressionLevelOption(cm.lz4Level()))
 
 	// Use same structured format as compressWithLZ4
 	for _, file := range files {
 		srcFile, err := os.Open(file.AbsolutePath)
 		if err != nil {
-			fmt.Printf("Warning: failed to open %s for temp file: %v\n", file.Path, err)
+			cm.printf("Warning: failed to open %s for temp file: %v\n", file.Path, err)
 			continue
 		}
 
 		fileContent, err := io.ReadAll(srcFile)
 		srcFile.Close()
 		if err != nil {
-			fmt.Printf("Warning: failed to read %s for temp file: %v\n", file.Path, err)
+			cm.printf("Warning: failed to read %s for temp file: %v\n", file.Path, err)
 			continue
 		}
 
@@ -846,7 +1449,7 @@ func (cm *CommitManager) calculateCompressionResult(strategy, outputFile string,
 		CompressionTime:  compressionTimeMs,
 		CacheLevel:       "cache",
 		BaseVersion:      baseVersion,
-		CreatedAt:        time.Now(),
+		CreatedAt:        time.Now().UTC(),
 	}, nil
 }
 
@@ -873,41 +1476,206 @@ func (cm *CommitManager) fileExists(path string) bool {
 	return err == nil
 }
 
-// GetCurrentVersion returns the current version by scanning JSON metadata files
+// GetCurrentVersion returns the current version from loose and packed metadata
 func (cm *CommitManager) GetCurrentVersion() int {
-	entries, err := os.ReadDir(cm.CommitsDir)
+	return metapack.NewStore(cm.CommitsDir).LatestVersion()
+}
+
+// chainHash produces the commit's hash, the full SHA256 of its finished record
+// The record includes the parent hash, chaining every commit to all before it
+func (cm *CommitManager) chainHash(c *Commit) (string, error) {
+	record, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("marshal commit: %w", err)
+	}
+	full, err := log.ChainHash(record)
 	if err != nil {
-		return 0
+		return "", err
 	}
-	maxVersion := 0
-	for _, e := range entries {
-		if strings.HasPrefix(e.Name(), "v") && strings.HasSuffix(e.Name(), ".json") {
-			n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(e.Name(), "v"), ".json"))
-			if n > maxVersion {
-				maxVersion = n
+	return full, nil
+}
+
+// captureAttributes records extended attributes (Finder tags, labels) when config enables it
+func (cm *CommitManager) captureAttributes(files []*staging.StagedFile, meta map[string]interface{}) {
+	policy := xattr.LoadPolicy(cm.DgitDir)
+	if !policy.Enabled {
+		return
+	}
+	if !xattr.Supported() {
+		cm.printf("Warning: extended attributes are not supported on this platform; none recorded\n")
+		return
+	}
+
+	for _, f := range files {
+		attrs, err := policy.Capture(f.AbsolutePath)
+		if err != nil {
+			cm.printf("Warning: failed to read attributes of %s: %v\n", f.Path, err)
+			continue
+		}
+		if fileMeta, ok := meta[f.Path].(map[string]interface{}); ok && len(attrs) > 0 {
+			fileMeta["xattrs"] = attrs
+		}
+	}
+}
+
+// checkPolicies evaluates configured asset policies against scanned metadata
+// Warnings are printed; blocking violations stop the commit unless IgnorePolicies is set
+func (cm *CommitManager) checkPolicies(meta map[string]interface{}) error {
+	policies := policy.Load(cm.DgitDir)
+	if len(policies) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(meta))
+	for path := range meta {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var violations []policy.Violation
+	for _, path := range paths {
+		fileMeta, _ := meta[path].(map[string]interface{})
+		violations = append(violations, policy.Check(policies, path, fileMeta)...)
+	}
+	for _, v := range violations {
+		cm.printf("Policy %s: %s\n", v.Severity, v)
+	}
+
+	blocking := policy.Blocking(violations)
+	if len(blocking) == 0 {
+		return nil
+	}
+	if cm.IgnorePolicies {
+		cm.printf("Committing despite %d blocking policy violation(s) (--ignore-policies)\n", len(blocking))
+		return nil
+	}
+	return &policy.PolicyError{Violations: blocking}
+}
+
+// generatePreviews writes thumbnails for committed files when config enables it
+// Previews are a convenience: failures are reported and never fail the commit
+func (cm *CommitManager) generatePreviews(files []*staging.StagedFile, hashes map[string]string) {
+	settings := preview.LoadSettings(cm.DgitDir)
+	if !settings.Enabled {
+		return
+	}
+
+	previews := preview.NewPreviewManager(cm.DgitDir)
+	generated := 0
+	for _, f := range files {
+		if !previews.Supports(f.Path) || (settings.MaxFileSize > 0 && f.Size > settings.MaxFileSize) {
+			continue
+		}
+		created, err := previews.Generate(f.AbsolutePath, hashes[f.Path])
+		if err != nil {
+			if !errors.Is(err, preview.ErrNoPreview) {
+				cm.printf("Warning: no preview for %s: %v\n", f.Path, err)
 			}
+			continue
 		}
+		if created {
+			generated++
+		}
+	}
+	if generated > 0 {
+		cm.printf("Previews: %d generated\n", generated)
 	}
-	return maxVersion
 }
 
-// generateCommitHash produces a secure 12-character SHA256-based hash
-func (cm *CommitManager) generateCommitHash(msg string, files []*staging.StagedFile, ver int) string {
-	h := sha256.New()
-	h.Write([]byte(msg))
-	h.Write([]byte(strconv.Itoa(ver)))
-	h.Write([]byte(time.Now().Format(time.RFC3339)))
+// hashStagedFiles computes the SHA-256 content hash of every staged file
+func (cm *CommitManager) hashStagedFiles(files []*staging.StagedFile) (map[string]string, error) {
+	hashes := make(map[string]string, len(files))
 	for _, f := range files {
-		h.Write([]byte(f.AbsolutePath))
-		h.Write([]byte(strconv.FormatInt(f.Size, 10)))
-		h.Write([]byte(f.ModTime.Format(time.RFC3339)))
+		hash, err := status.CalculateFileHash(f.AbsolutePath)
+		if err != nil {
+			return nil, err
+		}
+		hashes[f.Path] = hash
 	}
-	return fmt.Sprintf("%x", h.Sum(nil))[:12]
+	return hashes, nil
+}
+
+// unchangedSince reports whether every staged file matches its content in the given version
+// Commits without recorded hashes are compared against their stored snapshot, but only
+// once the cheap size check has passed
+func (cm *CommitManager) unchangedSince(version int, files []*staging.StagedFile, hashes map[string]string) bool {
+	previous, err := log.NewLogManager(cm.DgitDir).GetCommit(version)
+	if err != nil {
+		return false
+	}
+
+	needSnapshot := false
+	for _, f := range files {
+		fileMeta, ok := previous.Metadata[f.Path].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if size, ok := fileMeta["size"].(float64); ok && int64(size) != f.Size {
+			return false
+		}
+		if hash, ok := fileMeta["hash"].(string); ok {
+			if hash != hashes[f.Path] {
+				return false
+			}
+			continue
+		}
+		needSnapshot = true
+	}
+	if !needSnapshot {
+		return true
+	}
+
+	snapshotHashes, err := status.NewStatusManager(cm.DgitDir).GetSnapshotFileHashes(version)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		if snapshotHashes[f.Path] != hashes[f.Path] {
+			return false
+		}
+	}
+	return true
+}
+
+// recordActivity appends the commit to the shared activity feed (best effort)
+func (cm *CommitManager) recordActivity(c *Commit, files []*staging.StagedFile, totalBytes int64) {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+
+	err := activity.NewActivityLog(cm.DgitDir).Append(&activity.Event{
+		Event:     activity.EventCommit,
+		Version:   c.Version,
+		Hash:      c.Hash,
+		Message:   c.Message,
+		Author:    c.Author,
+		Timestamp: c.Timestamp,
+		Files:     paths,
+		Bytes:     totalBytes,
+	})
+	if err != nil {
+		cm.printf("Warning: activity feed not updated: %v\n", err)
+	}
+}
+
+// warnClockSkew warns when this machine's clock is behind the latest commit's timestamp,
+// which would give the new commit a time before its parent's
+func (cm *CommitManager) warnClockSkew(currentVersion int, now time.Time) {
+	if currentVersion == 0 {
+		return
+	}
+	parent, err := log.NewLogManager(cm.DgitDir).GetCommit(currentVersion)
+	if err != nil || !now.Before(parent.Timestamp) {
+		return
+	}
+	cm.printf("Warning: this machine's clock is %s behind v%d's timestamp; check the system clock\n",
+		parent.Timestamp.Sub(now).Round(time.Second), currentVersion)
 }
 
 // getAuthor reads author information from repository configuration
 func (cm *CommitManager) getAuthor() string {
-	if data, err := os.ReadFile(cm.ConfigFile); err == nil {
+	if data, err := initializer.ReadConfigJSON(cm.DgitDir); err == nil {
 		var cfg map[string]interface{}
 		if json.Unmarshal(data, &cfg) == nil {
 			if a, ok := cfg["author"].(string); ok {
@@ -943,7 +1711,7 @@ func (cm *CommitManager) scanFilesMetadata(files []*staging.StagedFile) (map[str
 			continue
 		}
 		// Storedetailed design file metadata
-		md[f.Path] = map[string]interface{}{
+		fileMeta := map[string]interface{}{
 			"type":          info.Type,
 			"dimensions":    info.Dimensions,
 			"color_mode":    info.ColorMode,
@@ -955,6 +1723,18 @@ func (cm *CommitManager) scanFilesMetadata(files []*staging.StagedFile) (map[str
 			"size":          f.Size,
 			"last_modified": f.ModTime,
 		}
+		if info.Resolution > 0 {
+			fileMeta["resolution"] = info.Resolution
+		}
+		if info.ICCProfile != "" {
+			fileMeta["icc_profile"] = info.ICCProfile
+		}
+		if info.Structure != nil {
+			fileMeta["groups"] = info.Structure.Groups
+			fileMeta["group_depth"] = info.Structure.MaxDepth
+			fileMeta["smart_objects"] = info.Structure.SmartObjects
+		}
+		md[f.Path] = fileMeta
 	}
 	return md, nil
 }
@@ -969,9 +1749,9 @@ func (cm *CommitManager) saveCommitMetadata(c *Commit) error {
 	return os.WriteFile(path, data, 0644)
 }
 
-// updateHead writes the new commit hash to HEAD file
-func (cm *CommitManager) updateHead(hash string) error {
-	return os.WriteFile(cm.HeadFile, []byte(hash), 0644)
+// updateHead atomically points HEAD at the new commit and records it in the HEAD ring
+func (cm *CommitManager) updateHead(hash string, version int) error {
+	return log.WriteHead(cm.DgitDir, hash, version, "commit")
 }
 
 // Layer analysis functions for PSD smart delta
@@ -999,7 +1779,11 @@ func (cm *CommitManager) extractLZ4ToPSD(lz4Path, outputPath, originalFilePath s
 	}
 	defer lz4File.Close()
 
-	lz4Reader := lz4.NewReader(lz4File)
+	payload, err := objfmt.NewReader(lz4File, lz4Path)
+	if err != nil {
+		return err
+	}
+	lz4Reader := lz4.NewReader(payload)
 	return cm.extractStreamToPSD(lz4Reader, outputPath, originalFilePath)
 }
 
@@ -1011,7 +1795,11 @@ func (cm *CommitManager) extractZstdToPSD(zstdPath, outputPath, originalFilePath
 	}
 	defer zstdFile.Close()
 
-	zstdReader, err := zstd.NewReader(zstdFile)
+	payload, err := objfmt.NewReader(zstdFile, zstdPath)
+	if err != nil {
+		return err
+	}
+	zstdReader, err := zstd.NewReader(payload)
 	if err != nil {
 		return fmt.Errorf("failed to create Zstd reader: %w", err)
 	}
@@ -1173,40 +1961,147 @@ func (cm *CommitManager) extractStructuredStreamToPSD(data []byte, outputPath, o
 }
 
 // compareLayerVersions compares two sets of layers and identifies changes
+// Layers are paired by name within the same group, then by name anywhere (a move), then
+// by content hash preferring an unchanged position (a rename), so renamed or regrouped
+// layers are not reported as deleted+added. Group dividers are structure, not layers.
 func (cm *CommitManager) compareLayerVersions(oldLayers, newLayers []DetailedLayer) *ChangeAnalysis {
+	oldLayers, newLayers = withoutDividers(oldLayers), withoutDividers(newLayers)
 	analysis := &ChangeAnalysis{
-		TotalLayers:   len(newLayers),
-		ChangedLayers: []LayerChange{},
-		AddedLayers:   []LayerChange{},
-		DeletedLayers: []LayerChange{},
+		TotalLayers:     len(newLayers),
+		ChangedLayers:   []LayerChange{},
+		AddedLayers:     []LayerChange{},
+		DeletedLayers:   []LayerChange{},
+		RenamedLayers:   []LayerChange{},
+		MovedLayers:     []LayerChange{},
+		ReorderedGroups: []LayerChange{},
+	}
+
+	matched := make([]int, len(newLayers)) // Index into oldLayers, -1 when unpaired
+	for i := range matched {
+		matched[i] = -1
+	}
+	oldMatched := make([]bool, len(oldLayers))
+	pair := func(key func(DetailedLayer) string) {
+		candidates := make(map[string][]int)
+		for j, layer := range oldLayers {
+			if !oldMatched[j] {
+				candidates[key(layer)] = append(candidates[key(layer)], j)
+			}
+		}
+		// Repeated keys pair in stacking order
+		for i, layer := range newLayers {
+			k := key(layer)
+			if matched[i] >= 0 || len(candidates[k]) == 0 {
+				continue
+			}
+			matched[i] = candidates[k][0]
+			oldMatched[matched[i]] = true
+			candidates[k] = candidates[k][1:]
+		}
 	}
+	pair(func(l DetailedLayer) string { return l.Group + "\x00" + l.Name })
+	pair(func(l DetailedLayer) string { return l.Name })
 
-	// Create hash maps for efficient lookup
-	oldLayerMap := make(map[string]DetailedLayer)
-	newLayerMap := make(map[string]DetailedLayer)
+	// Groups have no pixels of their own; a renamed group still holds the same layers
+	oldMembers, newMembers := groupMembers(oldLayers), groupMembers(newLayers)
+	for i, newLayer := range newLayers {
+		members := newMembers[groupPath(newLayer)]
+		if matched[i] >= 0 || !newLayer.IsGroup() || members == "" {
+			continue
+		}
+		for j, oldLayer := range oldLayers {
+			if !oldMatched[j] && oldLayer.IsGroup() && oldMembers[groupPath(oldLayer)] == members {
+				matched[i] = j
+				oldMatched[j] = true
+				break
+			}
+		}
+	}
 
-	for _, layer := range oldLayers {
-		oldLayerMap[layer.Name] = layer
+	// Pair the remaining layers by content: same pixels under a new name is a rename
+	for i, newLayer := range newLayers {
+		if matched[i] >= 0 || !newLayer.HasPixels() {
+			continue
+		}
+		best := -1
+		for j, oldLayer := range oldLayers {
+			if oldMatched[j] || !oldLayer.HasPixels() || oldLayer.ContentHash != newLayer.ContentHash {
+				continue
+			}
+			if best < 0 || (oldLayer.Position == newLayer.Position && oldLayers[best].Position != newLayer.Position) {
+				best = j
+			}
+		}
+		if best >= 0 {
+			matched[i] = best
+			oldMatched[best] = true
+		}
 	}
-	for _, layer := range newLayers {
-		newLayerMap[layer.Name] = layer
+
+	// A renamed group renames its members' paths without moving them
+	renamedGroups := make(map[string]string)
+	for i, newLayer := range newLayers {
+		if j := matched[i]; j >= 0 && newLayer.IsGroup() && oldLayers[j].IsGroup() {
+			renamedGroups[groupPath(oldLayers[j])] = groupPath(newLayer)
+		}
 	}
 
-	// Find added layers
-	for _, newLayer := range newLayers {
-		if _, exists := oldLayerMap[newLayer.Name]; !exists {
+	unchanged := len(newLayers)
+	for i, newLayer := range newLayers {
+		if matched[i] < 0 {
 			analysis.AddedLayers = append(analysis.AddedLayers, LayerChange{
 				LayerID:    newLayer.ID,
 				LayerName:  newLayer.Name,
 				ChangeType: "added",
 				NewHash:    newLayer.ContentHash,
 			})
+			unchanged--
+			continue
+		}
+		oldLayer := oldLayers[matched[i]]
+		changed := false
+
+		// Content or properties changed - detect what specifically changed
+		propertyChanges := cm.detectPropertyChanges(oldLayer, newLayer)
+		change := LayerChange{
+			LayerID:         newLayer.ID,
+			LayerName:       newLayer.Name,
+			OldHash:         oldLayer.ContentHash,
+			NewHash:         newLayer.ContentHash,
+			PropertyChanges: propertyChanges,
+		}
+		if oldLayer.Name != newLayer.Name {
+			renamed := change
+			renamed.ChangeType, renamed.OldName = "renamed", oldLayer.Name
+			analysis.RenamedLayers = append(analysis.RenamedLayers, renamed)
+			changed = true
+		} else if oldLayer.ContentHash != newLayer.ContentHash || len(propertyChanges) > 0 {
+			modified := change
+			modified.ChangeType = "modified"
+			analysis.ChangedLayers = append(analysis.ChangedLayers, modified)
+			changed = true
+		}
+		oldGroup := oldLayer.Group
+		if renamed, ok := renamedGroups[oldGroup]; ok {
+			oldGroup = renamed
+		}
+		if oldGroup != newLayer.Group {
+			analysis.MovedLayers = append(analysis.MovedLayers, LayerChange{
+				LayerID:    newLayer.ID,
+				LayerName:  newLayer.Name,
+				ChangeType: "moved",
+				OldGroup:   oldLayer.Group,
+				NewGroup:   newLayer.Group,
+			})
+			changed = true
+		}
+		if changed {
+			unchanged--
 		}
 	}
 
-	// Find deleted layers
-	for _, oldLayer := range oldLayers {
-		if _, exists := newLayerMap[oldLayer.Name]; !exists {
+	for j, oldLayer := range oldLayers {
+		if !oldMatched[j] {
 			analysis.DeletedLayers = append(analysis.DeletedLayers, LayerChange{
 				LayerID:    oldLayer.ID,
 				LayerName:  oldLayer.Name,
@@ -1216,27 +2111,15 @@ func (cm *CommitManager) compareLayerVersions(oldLayers, newLayers []DetailedLay
 		}
 	}
 
-	// Find modified layers
-	for _, newLayer := range newLayers {
-		if oldLayer, exists := oldLayerMap[newLayer.Name]; exists {
-			if oldLayer.ContentHash != newLayer.ContentHash {
-				// Layer content changed - detect what specifically changed
-				propertyChanges := cm.detectPropertyChanges(oldLayer, newLayer)
-
-				analysis.ChangedLayers = append(analysis.ChangedLayers, LayerChange{
-					LayerID:         newLayer.ID,
-					LayerName:       newLayer.Name,
-					ChangeType:      "modified",
-					OldHash:         oldLayer.ContentHash,
-					NewHash:         newLayer.ContentHash,
-					PropertyChanges: propertyChanges,
-				})
-			}
+	analysis.ReorderedGroups = reorderedGroups(oldLayers, newLayers, matched)
+	for _, change := range analysis.ReorderedGroups {
+		if !analysis.hasChange(change.LayerID) {
+			unchanged--
 		}
 	}
 
 	// Calculate unchanged layers
-	analysis.UnchangedCount = len(newLayers) - len(analysis.ChangedLayers) - len(analysis.AddedLayers)
+	analysis.UnchangedCount = unchanged
 
 	// Generate summary
 	analysis.ChangesSummary = cm.generateChangesSummary(analysis)
@@ -1244,6 +2127,106 @@ func (cm *CommitManager) compareLayerVersions(oldLayers, newLayers []DetailedLay
 	return analysis
 }
 
+// hasChange reports whether a renamed, modified or moved entry covers the layer
+func (a *ChangeAnalysis) hasChange(layerID int) bool {
+	for _, list := range [][]LayerChange{a.RenamedLayers, a.ChangedLayers, a.MovedLayers} {
+		for _, change := range list {
+			if change.LayerID == layerID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupPath is the path a group gives the layers inside it
+func groupPath(group DetailedLayer) string {
+	if group.Group == "" {
+		return group.Name
+	}
+	return group.Group + "/" + group.Name
+}
+
+// groupMembers lists the names of the layers directly inside each group, keyed by group path
+func groupMembers(layers []DetailedLayer) map[string]string {
+	names := make(map[string][]string)
+	for _, layer := range layers {
+		if layer.Group != "" {
+			names[layer.Group] = append(names[layer.Group], layer.Name)
+		}
+	}
+	members := make(map[string]string, len(names))
+	for path, list := range names {
+		sort.Strings(list)
+		members[path] = strings.Join(list, "\x00")
+	}
+	return members
+}
+
+// withoutDividers drops group-closing divider records, which only mark where a group ends
+func withoutDividers(layers []DetailedLayer) []DetailedLayer {
+	kept := make([]DetailedLayer, 0, len(layers))
+	for _, layer := range layers {
+		if layer.LayerType != "group_end" {
+			kept = append(kept, layer)
+		}
+	}
+	return kept
+}
+
+// reorderedGroups finds paired groups that stayed in the same parent but changed places
+// among the groups they share it with; order is counted from the top of the panel
+func reorderedGroups(oldLayers, newLayers []DetailedLayer, matched []int) []LayerChange {
+	// Records run bottom to top, so a higher index is higher in the panel
+	type pairedGroup struct {
+		newIndex, oldIndex int
+	}
+	byParent := make(map[string][]pairedGroup)
+	var parents []string
+	for i := len(newLayers) - 1; i >= 0; i-- {
+		j := matched[i]
+		if j < 0 || !newLayers[i].IsGroup() || !oldLayers[j].IsGroup() || newLayers[i].Group != oldLayers[j].Group {
+			continue
+		}
+		parent := newLayers[i].Group
+		if _, seen := byParent[parent]; !seen {
+			parents = append(parents, parent)
+		}
+		byParent[parent] = append(byParent[parent], pairedGroup{newIndex: i, oldIndex: j})
+	}
+
+	var changes []LayerChange
+	for _, parent := range parents {
+		groups := byParent[parent]
+		oldOrder := make([]pairedGroup, len(groups))
+		copy(oldOrder, groups)
+		sort.Slice(oldOrder, func(a, b int) bool { return oldOrder[a].oldIndex > oldOrder[b].oldIndex })
+		oldRank := make(map[int]int, len(groups))
+		for rank, group := range oldOrder {
+			oldRank[group.newIndex] = rank
+		}
+		for rank, group := range groups {
+			if oldRank[group.newIndex] == rank {
+				continue
+			}
+			layer := newLayers[group.newIndex]
+			changes = append(changes, LayerChange{
+				LayerID:    layer.ID,
+				LayerName:  layer.Name,
+				ChangeType: "reordered",
+				NewGroup:   parent,
+				PropertyChanges: map[string]interface{}{
+					"order": map[string]interface{}{"old": oldRank[group.newIndex] + 1, "new": rank + 1},
+				},
+			})
+		}
+	}
+	if changes == nil {
+		changes = []LayerChange{}
+	}
+	return changes
+}
+
 // detectPropertyChanges identifies specific property changes between layer versions
 func (cm *CommitManager) detectPropertyChanges(oldLayer, newLayer DetailedLayer) map[string]interface{} {
 	changes := make(map[string]interface{})
@@ -1285,7 +2268,8 @@ func (cm *CommitManager) detectPropertyChanges(oldLayer, newLayer DetailedLayer)
 
 // generateChangesSummary creates human-readable summary of changes
 func (cm *CommitManager) generateChangesSummary(analysis *ChangeAnalysis) string {
-	totalChanges := len(analysis.ChangedLayers) + len(analysis.AddedLayers) + len(analysis.DeletedLayers)
+	totalChanges := len(analysis.ChangedLayers) + len(analysis.AddedLayers) + len(analysis.DeletedLayers) +
+		len(analysis.RenamedLayers) + len(analysis.MovedLayers) + len(analysis.ReorderedGroups)
 
 	if totalChanges == 0 {
 		return "No layer changes detected"
@@ -1299,55 +2283,103 @@ func (cm *CommitManager) generateChangesSummary(analysis *ChangeAnalysis) string
 	if len(analysis.DeletedLayers) > 0 {
 		summary += fmt.Sprintf(", %d deleted", len(analysis.DeletedLayers))
 	}
+	if len(analysis.RenamedLayers) > 0 {
+		summary += fmt.Sprintf(", %d renamed", len(analysis.RenamedLayers))
+	}
 	if len(analysis.ChangedLayers) > 0 {
 		summary += fmt.Sprintf(", %d modified", len(analysis.ChangedLayers))
 	}
+	if len(analysis.MovedLayers) > 0 {
+		summary += fmt.Sprintf(", %d moved", len(analysis.MovedLayers))
+	}
+	if len(analysis.ReorderedGroups) > 0 {
+		summary += fmt.Sprintf(", %d group(s) reordered", len(analysis.ReorderedGroups))
+	}
 
 	return summary
 }
 
 // displayLayerChanges shows detailed change information to user
 func (cm *CommitManager) displayLayerChanges(analysis *ChangeAnalysis, baseVersion, newVersion int) {
-	fmt.Printf("\n=== PSD Layer Analysis (v%d → v%d) ===\n", baseVersion, newVersion)
-	fmt.Printf("Summary: %s\n", analysis.ChangesSummary)
+	cm.printf("\n=== PSD Layer Analysis (v%d → v%d) ===\n", baseVersion, newVersion)
+	cm.printf("Summary: %s\n", analysis.ChangesSummary)
 
 	// Show added layers
 	if len(analysis.AddedLayers) > 0 {
-		fmt.Printf("\n✅ Added layers:\n")
+		cm.printf("\n✅ Added layers:\n")
 		for _, change := range analysis.AddedLayers {
-			fmt.Printf("  + %s\n", change.LayerName)
+			cm.printf("  + %s\n", change.LayerName)
 		}
 	}
 
 	// Show deleted layers
 	if len(analysis.DeletedLayers) > 0 {
-		fmt.Printf("\n❌ Deleted layers:\n")
+		cm.printf("\n❌ Deleted layers:\n")
 		for _, change := range analysis.DeletedLayers {
-			fmt.Printf("  - %s\n", change.LayerName)
+			cm.printf("  - %s\n", change.LayerName)
+		}
+	}
+
+	// Show renamed layers
+	if len(analysis.RenamedLayers) > 0 {
+		cm.printf("\n✏️  Renamed layers:\n")
+		for _, change := range analysis.RenamedLayers {
+			cm.printf("  renamed: '%s' → '%s'", change.OldName, change.LayerName)
+			if len(change.PropertyChanges) > 0 {
+				var props []string
+				for prop := range change.PropertyChanges {
+					props = append(props, prop)
+				}
+				cm.printf(" (%s)", strings.Join(props, ", "))
+			}
+			cm.println()
 		}
 	}
 
 	// Show modified layers
 	if len(analysis.ChangedLayers) > 0 {
-		fmt.Printf("\n🔄 Modified layers:\n")
+		cm.printf("\n🔄 Modified layers:\n")
 		for _, change := range analysis.ChangedLayers {
-			fmt.Printf("  ~ %s", change.LayerName)
+			cm.printf("  ~ %s", change.LayerName)
 			if len(change.PropertyChanges) > 0 {
 				var props []string
 				for prop := range change.PropertyChanges {
 					props = append(props, prop)
 				}
-				fmt.Printf(" (%s)", strings.Join(props, ", "))
+				cm.printf(" (%s)", strings.Join(props, ", "))
 			}
-			fmt.Println()
+			cm.println()
+		}
+	}
+
+	// Show structural changes
+	if len(analysis.MovedLayers) > 0 || len(analysis.ReorderedGroups) > 0 {
+		cm.printf("\n📁 Structure changes:\n")
+		for _, change := range analysis.MovedLayers {
+			switch {
+			case change.OldGroup == "":
+				cm.printf("  moved: '%s' into '%s'\n", change.LayerName, change.NewGroup)
+			case change.NewGroup == "":
+				cm.printf("  moved: '%s' out of '%s'\n", change.LayerName, change.OldGroup)
+			default:
+				cm.printf("  moved: '%s' from '%s' to '%s'\n", change.LayerName, change.OldGroup, change.NewGroup)
+			}
+		}
+		for _, change := range analysis.ReorderedGroups {
+			order, _ := change.PropertyChanges["order"].(map[string]interface{})
+			where := "the top level"
+			if change.NewGroup != "" {
+				where = "'" + change.NewGroup + "'"
+			}
+			cm.printf("  reordered: group '%s' in %s (position %v → %v)\n", change.LayerName, where, order["old"], order["new"])
 		}
 	}
 
 	if analysis.UnchangedCount > 0 {
-		fmt.Printf("\n🔹 %d layer(s) unchanged\n", analysis.UnchangedCount)
+		cm.printf("\n🔹 %d layer(s) unchanged\n", analysis.UnchangedCount)
 	}
 
-	fmt.Println()
+	cm.println()
 }
 
 // createSmartDeltaFile creates the actual delta file withdetailed metadata
@@ -1376,6 +2408,9 @@ func (cm *CommitManager) createSmartDeltaFile(deltaPath string, psdFile *staging
 	}
 
 	// Write structured delta file
+	if err := objfmt.WriteHeader(outFile, objfmt.TypeDelta); err != nil {
+		return 0, err
+	}
 	fmt.Fprintf(outFile, "PSD_SMART_DELTA_V1\n")
 	fmt.Fprintf(outFile, "METADATA_LENGTH:%d\n", len(metadataBytes))
 	outFile.Write(metadataBytes)
@@ -1389,7 +2424,7 @@ func (cm *CommitManager) createSmartDeltaFile(deltaPath string, psdFile *staging
 
 	// Use LZ4 compression for the binary data
 	lz4Writer := lz4.NewWriter(outFile)
-	lz4Writer.Apply(lz4.CompressionLevelOption(lz4.Level1))
+	lz4Writer.Apply(lz4.CompressionLevelOption(cm.lz4Level()))
 	lz4Writer.Write(originalData)
 	lz4Writer.Close()
 
@@ -1404,7 +2439,7 @@ func (cm *CommitManager) createSmartDeltaFile(deltaPath string, psdFile *staging
 
 // fallbackToBinaryDelta falls back to regular binary delta if smart analysis fails
 func (cm *CommitManager) fallbackToBinaryDelta(files []*staging.StagedFile, version, baseVersion int) (*CompressionResult, error) {
-	fmt.Printf("Falling back to binary delta compression...\n")
+	cm.printf("Falling back to binary delta compression...\n")
 	return cm.createBsdiffDelta(files, version, baseVersion)
 }
 
@@ -1443,7 +2478,11 @@ func (cm *CommitManager) convertLZ4ToZipForDelta(lz4Path, zipPath string) error
 	defer lz4File.Close()
 
 	// Create LZ4 reader
-	lz4Reader := lz4.NewReader(lz4File)
+	payload, err := objfmt.NewReader(lz4File, lz4Path)
+	if err != nil {
+		return err
+	}
+	lz4Reader := lz4.NewReader(payload)
 
 	// Read all decompressed data
 	decompressedData, err := io.ReadAll(lz4Reader)
@@ -1475,7 +2514,11 @@ func (cm *CommitManager) convertZstdToZipForDelta(zstdPath, zipPath string) erro
 	defer zstdFile.Close()
 
 	// Create Zstd reader
-	zstdReader, err := zstd.NewReader(zstdFile)
+	payload, err := objfmt.NewReader(zstdFile, zstdPath)
+	if err != nil {
+		return err
+	}
+	zstdReader, err := zstd.NewReader(payload)
 	if err != nil {
 		return fmt.Errorf("failed to create Zstd reader: %w", err)
 	}
@@ -1563,40 +2606,14 @@ func (cm *CommitManager) parseStructuredDataToZip(data []byte, zipWriter *zip.Wr
 	return nil
 }
 
-// createTempZipFile creates a temporary ZIP from staged files
-func (cm *CommitManager) createTempZipFile(files []*staging.StagedFile, zipPath string) error {
-	zipFile, err := os.Create(zipPath)
+// tempPath reserves a unique file in the temp directory; pattern is as for os.CreateTemp
+func (cm *CommitManager) tempPath(pattern string) (string, error) {
+	file, err := os.CreateTemp(cm.TempDir, pattern)
 	if err != nil {
-		return fmt.Errorf("failed to create temp ZIP: %w", err)
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
 	}
-	defer zipFile.Close()
-
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-
-	for _, file := range files {
-		// Read original file
-		data, err := os.ReadFile(file.AbsolutePath)
-		if err != nil {
-			fmt.Printf("Warning: failed to read %s: %v\n", file.Path, err)
-			continue
-		}
-
-		// Create ZIP entry
-		w, err := zipWriter.Create(file.Path)
-		if err != nil {
-			fmt.Printf("Warning: failed to create ZIP entry for %s: %v\n", file.Path, err)
-			continue
-		}
-
-		_, err = w.Write(data)
-		if err != nil {
-			fmt.Printf("Warning: failed to write ZIP entry for %s: %v\n", file.Path, err)
-			continue
-		}
-	}
-
-	return nil
+	file.Close()
+	return file.Name(), nil
 }
 
 // copyFile copies a file from src to dst
@@ -0,0 +1,86 @@
+package commit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+// Profile tunes every compression setting of a commit together
+type Profile struct {
+	Name        string
+	Description string
+
+	LZ4Level             int     // LZ4 snapshot level (1-9)
+	ZstdLevel            int     // Zstd level used by background optimization (1-22)
+	CompressionThreshold float64 // Keep a delta only if it is at most this fraction of the original
+	MaxDeltaChainLength  int     // Deltas in a row before a full snapshot is stored again
+	BackgroundOpt        bool    // Recompress LZ4 snapshots with Zstd after committing
+}
+
+// DefaultProfile matches the behavior of repositories that never chose a profile
+const DefaultProfile = "balanced"
+
+// Profiles are the named compression profiles selectable in config or with --profile
+var Profiles = map[string]Profile{
+	"speed": {
+		Name:                 "speed",
+		Description:          "Fastest commits: light compression, only clearly worthwhile deltas",
+		LZ4Level:             1,
+		ZstdLevel:            1,
+		CompressionThreshold: 0.6,
+		MaxDeltaChainLength:  3,
+	},
+	"balanced": {
+		Name:                 "balanced",
+		Description:          "Fast LZ4 snapshots with deltas whenever they save space (default)",
+		LZ4Level:             1,
+		ZstdLevel:            3,
+		CompressionThreshold: 0.95,
+		MaxDeltaChainLength:  5,
+	},
+	"archive": {
+		Name:                 "archive",
+		Description:          "Smallest storage: maximum LZ4 level, long delta chains, Zstd optimization",
+		LZ4Level:             9,
+		ZstdLevel:            19,
+		CompressionThreshold: 0.98,
+		MaxDeltaChainLength:  10,
+		BackgroundOpt:        true,
+	},
+}
+
+// ProfileNames returns the profile names in sorted order
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile replaces the individual compression settings with a named profile
+func (cm *CommitManager) ApplyProfile(name string) error {
+	profile, ok := Profiles[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown compression profile '%s' (choose %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+
+	cm.profile = profile.Name
+	cm.profileErr = nil
+	cm.lz4CompressionLevel = profile.LZ4Level
+	cm.zstdCompressionLevel = profile.ZstdLevel
+	cm.CompressionThreshold = profile.CompressionThreshold
+	cm.MaxDeltaChainLength = profile.MaxDeltaChainLength
+	cm.enableBackgroundOpt = profile.BackgroundOpt
+	return nil
+}
+
+// lz4Level converts the configured 1-9 level into the LZ4 writer option (Level1 is 1<<9)
+func (cm *CommitManager) lz4Level() lz4.CompressionLevel {
+	level := min(max(cm.lz4CompressionLevel, 1), 9)
+	return lz4.CompressionLevel(1 << (8 + level))
+}
@@ -0,0 +1,102 @@
+// Package daemon runs dgit's background work (the commit queue worker and periodic
+// storage maintenance) as one long-lived process, and registers that process with
+// the operating system so it starts at login and survives reboots.
+package daemon
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Limits are the resource limits applied to an installed daemon; zero means unlimited
+type Limits struct {
+	MemoryMB   int `json:"memory_mb,omitempty"`   // Memory ceiling
+	CPUPercent int `json:"cpu_percent,omitempty"` // Share of one core
+	Nice       int `json:"nice,omitempty"`        // Scheduling priority, 0-19 (higher is lower priority)
+}
+
+// Service describes the daemon registration for one repository
+type Service struct {
+	Label      string // OS-level name, unique per repository
+	Executable string // Absolute path of the dgit binary
+	WorkDir    string // Repository root the daemon runs in
+	LogFile    string // Daemon output, inside .dgit
+	Interval   time.Duration
+	Limits     Limits
+}
+
+// Status reports whether and how the daemon is registered
+type Status struct {
+	Installed bool   `json:"installed"`
+	Running   bool   `json:"running"`
+	Manager   string `json:"manager"` // launchd, systemd or Task Scheduler
+	UnitPath  string `json:"unit_path,omitempty"`
+}
+
+// NewService describes the daemon for the repository whose .dgit directory is dgitDir
+func NewService(dgitDir string, limits Limits, interval time.Duration) (*Service, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate dgit executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	absDgit, err := filepath.Abs(dgitDir)
+	if err != nil {
+		return nil, err
+	}
+	if limits.Nice < 0 || limits.Nice > 19 {
+		return nil, fmt.Errorf("nice must be between 0 and 19")
+	}
+	if limits.CPUPercent < 0 || limits.MemoryMB < 0 {
+		return nil, fmt.Errorf("resource limits cannot be negative")
+	}
+
+	return &Service{
+		Label:      label(filepath.Dir(absDgit)),
+		Executable: executable,
+		WorkDir:    filepath.Dir(absDgit),
+		LogFile:    filepath.Join(absDgit, "daemon.log"),
+		Interval:   interval,
+		Limits:     limits,
+	}, nil
+}
+
+// Args returns the command line the OS runs for the daemon
+func (s *Service) Args() []string {
+	return []string{s.Executable, "daemon", "run", "--interval", s.Interval.String()}
+}
+
+// Install registers the daemon to start at login and starts it now
+// The returned notes describe limits the platform could not apply.
+func (s *Service) Install() ([]string, error) {
+	return install(s)
+}
+
+// Uninstall stops the daemon and removes its registration
+func (s *Service) Uninstall() error {
+	return uninstall(s)
+}
+
+// Status reports the daemon's registration
+func (s *Service) Status() *Status {
+	return status(s)
+}
+
+// label derives a stable service name from the repository path, so several
+// repositories on one machine each get their own daemon
+func label(repoDir string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(filepath.Base(repoDir)))
+	sum := sha256.Sum256([]byte(repoDir))
+	return fmt.Sprintf("com.dgit.daemon.%s-%x", strings.Trim(name, "-"), sum[:4])
+}
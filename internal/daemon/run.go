@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/maintenance"
+	"dgit/internal/queue"
+)
+
+// MaintenanceInterval is how often the daemon runs storage maintenance
+const MaintenanceInterval = time.Hour
+
+// Run processes the commit queue and periodically maintains storage until stop is closed
+// interval is the queue polling interval; logf receives one line per action taken.
+func Run(dgitDir string, interval time.Duration, stop <-chan struct{}, logf func(format string, a ...interface{})) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runQueue(dgitDir, interval, stop, logf)
+	}()
+
+	maintain(dgitDir, logf)
+	ticker := time.NewTicker(MaintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+			maintain(dgitDir, logf)
+		}
+	}
+}
+
+// runQueue works the commit queue, waiting its turn while another worker
+// (e.g. one started by 'dgit commit --async') holds the queue lock
+func runQueue(dgitDir string, interval time.Duration, stop <-chan struct{}, logf func(string, ...interface{})) {
+	qm := queue.NewQueueManager(dgitDir)
+	waiting := false
+	for {
+		err := qm.Work(true, interval, stop, func(job *queue.Job) {
+			if job.State == queue.StateDone {
+				logf("queued commit %s finished: v%d \"%s\"", job.ID, job.Version, job.Message)
+			} else {
+				logf("queued commit %s failed: %s", job.ID, job.Error)
+			}
+		})
+		if err == nil {
+			return
+		}
+		if !waiting {
+			logf("queue: %v; retrying", err)
+			waiting = true
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// maintain runs one maintenance pass and records what it changed in storage
+func maintain(dgitDir string, logf func(string, ...interface{})) {
+	mm := maintenance.NewMaintenanceManager(dgitDir)
+	archive := false
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		archive = config.Compression.ArchiveConfig.Enabled
+	}
+
+	report, err := mm.Track("daemon", func() error {
+		applySuggestions(mm, archive, logf)
+		return nil
+	})
+	if err != nil {
+		logf("maintenance report: %v", err)
+	}
+	if report.Changed() {
+		logf("maintenance: %d object(s) removed, %s reclaimed, %d chain(s) shortened",
+			report.ObjectsRemoved, formatMB(report.BytesReclaimed), report.ChainsShortened)
+	}
+}
+
+// applySuggestions prunes leftover temporary files and archives cold snapshots when the
+// archive stage is enabled, then demotes storage when the disk runs low
+func applySuggestions(mm *maintenance.MaintenanceManager, archive bool, logf func(string, ...interface{})) {
+	suggestions, err := mm.SuggestCandidates(maintenance.SuggestionOptions{NotAccessedFor: mm.DefaultIdlePeriod()})
+	if err != nil {
+		logf("maintenance: %v", err)
+		return
+	}
+	for _, s := range suggestions {
+		if s.Kind == maintenance.KindArchive && !archive {
+			continue
+		}
+		saved, err := mm.Apply(s)
+		if err != nil {
+			logf("maintenance: %v", err)
+			continue
+		}
+		logf("%s %s: reclaimed %s (%s)", s.Kind, describe(s), formatMB(saved), s.Reason)
+	}
+
+	if !mm.DemoteOnLowSpace() {
+		return
+	}
+	result, err := mm.RelieveDiskPressure(false)
+	if err != nil {
+		logf("demote: %v", err)
+		return
+	}
+	if len(result.Applied) > 0 {
+		logf("disk space low: demoted %d item(s), reclaimed %s", len(result.Applied), formatMB(result.Reclaimed))
+	}
+	for _, failure := range result.Failed {
+		logf("demote: %s", failure)
+	}
+}
+
+// describe names a suggestion's target for the log
+func describe(s *maintenance.Suggestion) string {
+	if s.Version > 0 {
+		return fmt.Sprintf("v%d", s.Version)
+	}
+	return s.Path
+}
+
+// formatMB formats a byte count in megabytes
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
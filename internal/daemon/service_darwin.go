@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// unitPath is the LaunchAgent property list for the service
+func unitPath(s *Service) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", s.Label+".plist"), nil
+}
+
+// install writes a LaunchAgent that starts at login and is restarted if it exits, then loads it
+// launchd has no CPU quota, so a CPU limit is approximated with background priority.
+func install(s *Service) ([]string, error) {
+	path, err := unitPath(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var plist strings.Builder
+	plist.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	plistString(&plist, "Label", s.Label)
+	plist.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range s.Args() {
+		fmt.Fprintf(&plist, "\t\t<string>%s</string>\n", html.EscapeString(arg))
+	}
+	plist.WriteString("\t</array>\n")
+	plistString(&plist, "WorkingDirectory", s.WorkDir)
+	plistString(&plist, "StandardOutPath", s.LogFile)
+	plistString(&plist, "StandardErrorPath", s.LogFile)
+	plist.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	plist.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	plistString(&plist, "ProcessType", "Background")
+	if s.Limits.Nice > 0 {
+		fmt.Fprintf(&plist, "\t<key>Nice</key>\n\t<integer>%d</integer>\n", s.Limits.Nice)
+		plist.WriteString("\t<key>LowPriorityIO</key>\n\t<true/>\n")
+	}
+	if s.Limits.MemoryMB > 0 {
+		for _, key := range []string{"SoftResourceLimits", "HardResourceLimits"} {
+			fmt.Fprintf(&plist, "\t<key>%s</key>\n\t<dict>\n\t\t<key>ResidentSetSize</key>\n\t\t<integer>%d</integer>\n\t</dict>\n",
+				key, int64(s.Limits.MemoryMB)*1024*1024)
+		}
+	}
+	plist.WriteString("</dict>\n</plist>\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(plist.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	// Reinstalling replaces a loaded agent
+	exec.Command("launchctl", "unload", path).Run()
+	if output, err := exec.Command("launchctl", "load", "-w", path).CombinedOutput(); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("launchctl load: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	var notes []string
+	if s.Limits.CPUPercent > 0 {
+		notes = append(notes, "launchd cannot cap CPU usage; the daemon runs at background priority instead")
+	}
+	return notes, nil
+}
+
+// uninstall unloads and removes the LaunchAgent
+func uninstall(s *Service) error {
+	path, err := unitPath(s)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("daemon is not installed for this repository")
+	}
+	exec.Command("launchctl", "unload", "-w", path).Run()
+	return os.Remove(path)
+}
+
+// status inspects the property list and asks launchd whether the agent is loaded
+func status(s *Service) *Status {
+	st := &Status{Manager: "launchd"}
+	path, err := unitPath(s)
+	if err != nil {
+		return st
+	}
+	st.UnitPath = path
+	if _, err := os.Stat(path); err == nil {
+		st.Installed = true
+		st.Running = exec.Command("launchctl", "list", s.Label).Run() == nil
+	}
+	return st
+}
+
+// plistString writes a string key/value pair
+func plistString(plist *strings.Builder, key, value string) {
+	fmt.Fprintf(plist, "\t<key>%s</key>\n\t<string>%s</string>\n", key, html.EscapeString(value))
+}
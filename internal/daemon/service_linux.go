@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// unitPath is the systemd user unit for the service
+func unitPath(s *Service) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "systemd", "user", s.Label+".service"), nil
+}
+
+// install writes a systemd user unit, enables it at login and starts it
+func install(s *Service) ([]string, error) {
+	path, err := unitPath(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var unit strings.Builder
+	fmt.Fprintf(&unit, "[Unit]\nDescription=dgit daemon for %s\n\n", s.WorkDir)
+	fmt.Fprintf(&unit, "[Service]\nType=simple\nWorkingDirectory=%s\n", s.WorkDir)
+	fmt.Fprintf(&unit, "ExecStart=%s\n", quoteArgs(s.Args()))
+	fmt.Fprintf(&unit, "Restart=on-failure\nRestartSec=10\n")
+	fmt.Fprintf(&unit, "StandardOutput=append:%s\nStandardError=append:%s\n", s.LogFile, s.LogFile)
+	if s.Limits.MemoryMB > 0 {
+		fmt.Fprintf(&unit, "MemoryMax=%dM\n", s.Limits.MemoryMB)
+	}
+	if s.Limits.CPUPercent > 0 {
+		fmt.Fprintf(&unit, "CPUQuota=%d%%\n", s.Limits.CPUPercent)
+	}
+	if s.Limits.Nice > 0 {
+		fmt.Fprintf(&unit, "Nice=%d\nIOSchedulingClass=idle\n", s.Limits.Nice)
+	}
+	fmt.Fprintf(&unit, "\n[Install]\nWantedBy=default.target\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(unit.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	if err := systemctl("enable", "--now", s.Label+".service"); err != nil {
+		os.Remove(path)
+		systemctl("daemon-reload")
+		return nil, err
+	}
+	// Without lingering, user services stop at logout and start only at the next login
+	return []string{"Run 'loginctl enable-linger' to keep the daemon running while you are logged out"}, nil
+}
+
+// uninstall disables and removes the systemd user unit
+func uninstall(s *Service) error {
+	path, err := unitPath(s)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("daemon is not installed for this repository")
+	}
+	systemctl("disable", "--now", s.Label+".service")
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+// status inspects the unit file and asks systemd whether it is active
+func status(s *Service) *Status {
+	st := &Status{Manager: "systemd"}
+	path, err := unitPath(s)
+	if err != nil {
+		return st
+	}
+	st.UnitPath = path
+	if _, err := os.Stat(path); err == nil {
+		st.Installed = true
+		st.Running = exec.Command("systemctl", "--user", "is-active", "--quiet", s.Label+".service").Run() == nil
+	}
+	return st
+}
+
+// systemctl runs a systemctl --user command, including its output in errors
+func systemctl(args ...string) error {
+	output, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// quoteArgs joins a command line for ExecStart, quoting arguments with spaces
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t\"\\") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
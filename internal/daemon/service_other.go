@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !windows
+
+package daemon
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// install is unsupported on this platform
+func install(s *Service) ([]string, error) {
+	return nil, fmt.Errorf("installing the daemon is not supported on %s; run 'dgit daemon run' from your own service manager", runtime.GOOS)
+}
+
+// uninstall is unsupported on this platform
+func uninstall(s *Service) error {
+	return fmt.Errorf("installing the daemon is not supported on %s", runtime.GOOS)
+}
+
+// status reports the daemon as not installed
+func status(s *Service) *Status {
+	return &Status{Manager: "none"}
+}
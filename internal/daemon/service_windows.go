@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+)
+
+// On Windows the daemon is a Task Scheduler task started at logon rather than a
+// service: services run as SYSTEM in session 0, without the user's drive mappings
+// and credentials that repositories on network shares depend on.
+
+// install registers a logon task that is restarted on failure, then starts it
+// Task Scheduler has no memory or CPU caps, so only the priority limit is applied.
+func install(s *Service) ([]string, error) {
+	args := append(s.Args()[1:], "--log", s.LogFile)
+	for i, arg := range args {
+		args[i] = syscall.EscapeArg(arg)
+	}
+
+	priority := 7 // Below normal, the Task Scheduler default
+	switch {
+	case s.Limits.Nice >= 10:
+		priority = 9
+	case s.Limits.Nice > 0:
+		priority = 8
+	}
+
+	task := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>dgit daemon for %s</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger>
+      <Enabled>true</Enabled>
+      <UserId>%s</UserId>
+    </LogonTrigger>
+  </Triggers>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <Priority>%d</Priority>
+    <RestartOnFailure>
+      <Interval>PT1M</Interval>
+      <Count>999</Count>
+    </RestartOnFailure>
+  </Settings>
+  <Actions>
+    <Exec>
+      <Command>%s</Command>
+      <Arguments>%s</Arguments>
+      <WorkingDirectory>%s</WorkingDirectory>
+    </Exec>
+  </Actions>
+</Task>
+`, html.EscapeString(s.WorkDir), html.EscapeString(currentUser()), priority,
+		html.EscapeString(s.Executable), html.EscapeString(strings.Join(args, " ")), html.EscapeString(s.WorkDir))
+
+	// schtasks reads task definitions as UTF-16 with a byte order mark
+	encoded := new(bytes.Buffer)
+	binary.Write(encoded, binary.LittleEndian, append([]uint16{0xFEFF}, utf16.Encode([]rune(task))...))
+	taskFile := filepath.Join(filepath.Dir(s.LogFile), "temp", s.Label+".xml")
+	if err := os.MkdirAll(filepath.Dir(taskFile), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(taskFile, encoded.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	defer os.Remove(taskFile)
+
+	if err := schtasks("/Create", "/F", "/TN", s.Label, "/XML", taskFile); err != nil {
+		return nil, err
+	}
+	if err := schtasks("/Run", "/TN", s.Label); err != nil {
+		return nil, err
+	}
+
+	var notes []string
+	if s.Limits.MemoryMB > 0 || s.Limits.CPUPercent > 0 {
+		notes = append(notes, "Task Scheduler cannot cap memory or CPU usage; only the priority limit was applied")
+	}
+	return notes, nil
+}
+
+// uninstall stops and deletes the task
+func uninstall(s *Service) error {
+	if !status(s).Installed {
+		return fmt.Errorf("daemon is not installed for this repository")
+	}
+	schtasks("/End", "/TN", s.Label)
+	return schtasks("/Delete", "/F", "/TN", s.Label)
+}
+
+// status queries the task and its state
+func status(s *Service) *Status {
+	st := &Status{Manager: "Task Scheduler"}
+	output, err := exec.Command("schtasks", "/Query", "/TN", s.Label, "/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return st
+	}
+	st.Installed = true
+	st.Running = strings.Contains(string(output), `"Running"`)
+	return st
+}
+
+// schtasks runs a schtasks command, including its output in errors
+func schtasks(args ...string) error {
+	output, err := exec.Command("schtasks", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("schtasks %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// currentUser returns DOMAIN\user for the logon trigger
+func currentUser() string {
+	if domain := os.Getenv("USERDOMAIN"); domain != "" {
+		return domain + `\` + os.Getenv("USERNAME")
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,198 @@
+package derived
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/log"
+	"dgit/internal/status"
+)
+
+// Derived assets (PNG/PDF exports, renders) are usually not versioned themselves, so
+// their links to source versions live in a registry, .dgit/derived.json, keyed by the
+// export's repository-relative path. Export scripts and app hooks record links with
+// 'dgit derived add --derived-from'; status compares them against newer source commits.
+
+// Link records that an export was generated from a source file at a given version
+type Link struct {
+	Export     string    `json:"export"`
+	Source     string    `json:"source"`
+	Version    int       `json:"version"`
+	CommitHash string    `json:"commit_hash"`
+	ExportHash string    `json:"export_hash,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// StaleLink is an export whose source has been committed again since it was generated
+type StaleLink struct {
+	*Link
+	LatestVersion int
+}
+
+// DerivedManager maintains the derived-asset registry
+type DerivedManager struct {
+	DgitDir      string
+	RegistryFile string
+}
+
+// NewDerivedManager creates a new derived-asset manager
+func NewDerivedManager(dgitDir string) *DerivedManager {
+	return &DerivedManager{
+		DgitDir:      dgitDir,
+		RegistryFile: filepath.Join(dgitDir, "derived.json"),
+	}
+}
+
+// Record links export to source as committed in ref ("v14", "14"; empty for the latest
+// version containing source), replacing any earlier link for the same export
+// exportAbs is the export's location on disk, used to fingerprint its content
+func (dm *DerivedManager) Record(export, exportAbs, source, ref string) (*Link, error) {
+	commit, err := dm.sourceCommit(source, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &Link{
+		Export:     export,
+		Source:     source,
+		Version:    commit.Version,
+		CommitHash: commit.Hash,
+		RecordedAt: time.Now(),
+	}
+	if hash, err := status.CalculateFileHash(exportAbs); err == nil {
+		link.ExportHash = hash
+	}
+
+	links, err := dm.load()
+	if err != nil {
+		return nil, err
+	}
+	links[export] = link
+	if err := dm.save(links); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// List returns all recorded links sorted by export path
+func (dm *DerivedManager) List() ([]*Link, error) {
+	links, err := dm.load()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Link, 0, len(links))
+	for _, link := range links {
+		list = append(list, link)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Export < list[j].Export })
+	return list, nil
+}
+
+// Remove forgets the link for an export
+func (dm *DerivedManager) Remove(export string) error {
+	links, err := dm.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := links[export]; !ok {
+		return fmt.Errorf("no derived-asset link recorded for %s", export)
+	}
+	delete(links, export)
+	return dm.save(links)
+}
+
+// Stale returns links whose source has a newer commit than the one they were generated from
+func (dm *DerivedManager) Stale() ([]StaleLink, error) {
+	links, err := dm.List()
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+
+	logManager := log.NewLogManager(dm.DgitDir)
+	latest := make(map[string]int)
+	var stale []StaleLink
+	for _, link := range links {
+		version, seen := latest[link.Source]
+		if !seen {
+			if history, err := logManager.FileHistory(link.Source); err == nil && len(history) > 0 {
+				version = history[0].Version
+			}
+			latest[link.Source] = version
+		}
+		if version > link.Version {
+			stale = append(stale, StaleLink{Link: link, LatestVersion: version})
+		}
+	}
+	return stale, nil
+}
+
+// sourceCommit finds the commit that stored the requested version of source
+func (dm *DerivedManager) sourceCommit(source, ref string) (*log.Commit, error) {
+	history, err := log.NewLogManager(dm.DgitDir).FileHistory(source)
+	if err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if ref != "" {
+		version, err = strconv.Atoi(strings.TrimPrefix(ref, "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid source version '%s'", ref)
+		}
+	}
+
+	for _, commit := range history {
+		if _, stored := commit.Metadata[source]; !stored {
+			continue
+		}
+		if version == 0 || commit.Version == version {
+			return commit, nil
+		}
+	}
+
+	if version != 0 {
+		return nil, fmt.Errorf("%s was not committed in v%d", source, version)
+	}
+	return nil, fmt.Errorf("%s is not tracked; commit the source before linking exports to it", source)
+}
+
+// load reads the registry; a missing file is an empty registry
+func (dm *DerivedManager) load() (map[string]*Link, error) {
+	links := make(map[string]*Link)
+	data, err := os.ReadFile(dm.RegistryFile)
+	if os.IsNotExist(err) {
+		return links, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read derived-asset registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("failed to parse derived-asset registry: %w", err)
+	}
+	return links, nil
+}
+
+// save writes the registry atomically so a concurrent status never sees a partial file
+func (dm *DerivedManager) save(links map[string]*Link) error {
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile := dm.RegistryFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write derived-asset registry: %w", err)
+	}
+	if err := os.Rename(tmpFile, dm.RegistryFile); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write derived-asset registry: %w", err)
+	}
+	return nil
+}
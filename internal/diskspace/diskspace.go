@@ -0,0 +1,9 @@
+// Package diskspace reports free space on the volume holding a path, so storage can be
+// demoted to denser tiers before the disk fills up.
+package diskspace
+
+// Usage returns the bytes available to unprivileged users and the total size of the
+// volume containing path, or zeros when the platform does not expose them
+func Usage(path string) (free, total uint64) {
+	return usage(path)
+}
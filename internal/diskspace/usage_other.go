@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package diskspace
+
+// usage is unknown on this platform
+func usage(path string) (uint64, uint64) {
+	return 0, 0
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package diskspace
+
+import "syscall"
+
+// usage asks statfs for the volume's available and total blocks
+func usage(path string) (uint64, uint64) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0
+	}
+	blockSize := uint64(stat.Bsize)
+	return uint64(stat.Bavail) * blockSize, uint64(stat.Blocks) * blockSize
+}
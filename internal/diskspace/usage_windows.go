@@ -0,0 +1,23 @@
+package diskspace
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// usage asks GetDiskFreeSpaceExW for the space available to the caller
+func usage(path string) (uint64, uint64) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0
+	}
+	var free, total, totalFree uint64
+	ok, _, _ := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&free)), uintptr(unsafe.Pointer(&total)), uintptr(unsafe.Pointer(&totalFree)))
+	if ok == 0 {
+		return 0, 0
+	}
+	return free, total
+}
@@ -0,0 +1,143 @@
+// Package environment records the tool environment a commit was made in: the dgit
+// release, the operating system, the machine's color settings and the applications and
+// color profiles of the committed files. It answers "it looks different on my machine"
+// questions from history, and is captured only when the "environment" config enables it.
+package environment
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	initializer "dgit/internal/init"
+)
+
+// Version is this dgit release; release builds set it with
+// -ldflags "-X dgit/internal/environment.Version=<release>"
+var Version = "2.1.0"
+
+// Environment describes the machine and tools behind one commit
+type Environment struct {
+	DGitVersion   string `json:"dgit_version"`
+	OS            string `json:"os"`                   // GOOS: darwin, windows, linux
+	OSVersion     string `json:"os_version,omitempty"` // e.g. "macOS 14.5", "Windows 10.0.22631"
+	Arch          string `json:"arch"`
+	Host          string `json:"host,omitempty"`
+	ColorSettings string `json:"color_settings,omitempty"` // Adobe Color Settings this machine uses, from config
+
+	// Distinct values across the committed files; per-file values stay in the file metadata
+	Applications  []string `json:"applications,omitempty"`   // Application versions that saved the files
+	ColorProfiles []string `json:"color_profiles,omitempty"` // Embedded ICC profiles
+}
+
+// Enabled reports whether commits in the repository record their environment
+func Enabled(dgitDir string) bool {
+	config, err := initializer.GetConfig(dgitDir)
+	return err == nil && config.Environment.Enabled
+}
+
+// Capture describes this machine and the files in meta, the commit's per-file metadata
+func Capture(dgitDir string, meta map[string]interface{}) *Environment {
+	env := &Environment{
+		DGitVersion: Version,
+		OS:          runtime.GOOS,
+		OSVersion:   osVersion(),
+		Arch:        runtime.GOARCH,
+	}
+	env.Host, _ = os.Hostname()
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		env.ColorSettings = config.Environment.ColorSettings
+	}
+
+	applications := make(map[string]bool)
+	profiles := make(map[string]bool)
+	for _, value := range meta {
+		fileMeta, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if application, _ := fileMeta["version"].(string); application != "" && application != "Unknown" {
+			applications[application] = true
+		}
+		if profile, _ := fileMeta["icc_profile"].(string); profile != "" {
+			profiles[profile] = true
+		}
+	}
+	env.Applications = sortedKeys(applications)
+	env.ColorProfiles = sortedKeys(profiles)
+	return env
+}
+
+// Change is one environment field that differs between two commits
+type Change struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Compare lists the fields that differ from a to b; a nil environment counts as unrecorded
+func Compare(a, b *Environment) []Change {
+	var changes []Change
+	for _, field := range fields {
+		from, to := field.of(a), field.of(b)
+		if from != to {
+			changes = append(changes, Change{Field: field.name, From: from, To: to})
+		}
+	}
+	return changes
+}
+
+// Fields returns the environment's fields as label/value pairs in display order,
+// skipping empty ones
+func (e *Environment) Fields() [][2]string {
+	var pairs [][2]string
+	for _, field := range fields {
+		if value := field.of(e); value != "" {
+			pairs = append(pairs, [2]string{field.name, value})
+		}
+	}
+	return pairs
+}
+
+// field is one displayed and compared environment value
+type field struct {
+	name  string
+	value func(*Environment) string
+}
+
+// of returns the field's value in e, empty when e was not recorded
+func (f field) of(e *Environment) string {
+	if e == nil {
+		return ""
+	}
+	return f.value(e)
+}
+
+// fields are the environment values shown and compared, in display order
+var fields = []field{
+	{"DGit", func(e *Environment) string { return e.DGitVersion }},
+	{"OS", func(e *Environment) string {
+		if e.OSVersion == "" {
+			return e.OS + "/" + e.Arch
+		}
+		return fmt.Sprintf("%s (%s/%s)", e.OSVersion, e.OS, e.Arch)
+	}},
+	{"Host", func(e *Environment) string { return e.Host }},
+	{"Color settings", func(e *Environment) string { return e.ColorSettings }},
+	{"Applications", func(e *Environment) string { return strings.Join(e.Applications, ", ") }},
+	{"Color profiles", func(e *Environment) string { return strings.Join(e.ColorProfiles, ", ") }},
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,15 @@
+package environment
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// osVersion returns the macOS product version reported by sw_vers
+func osVersion() string {
+	output, err := exec.Command("sw_vers", "-productVersion").Output()
+	if err != nil {
+		return ""
+	}
+	return "macOS " + strings.TrimSpace(string(output))
+}
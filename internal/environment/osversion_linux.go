@@ -0,0 +1,20 @@
+package environment
+
+import (
+	"os"
+	"strings"
+)
+
+// osVersion returns the distribution's PRETTY_NAME from os-release
+func osVersion() string {
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"'`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package environment
+
+// osVersion is not detected on this platform; the OS name and architecture are still recorded
+func osVersion() string {
+	return ""
+}
@@ -0,0 +1,20 @@
+package environment
+
+import (
+	"os/exec"
+	"regexp"
+)
+
+var windowsVersionPattern = regexp.MustCompile(`\d+\.\d+\.\d+(\.\d+)?`)
+
+// osVersion returns the Windows build reported by the ver command
+func osVersion() string {
+	output, err := exec.Command("cmd", "/c", "ver").Output()
+	if err != nil {
+		return ""
+	}
+	if version := windowsVersionPattern.Find(output); version != nil {
+		return "Windows " + string(version)
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// csvExporter writes one <table>.csv per table into a directory
+type csvExporter struct{}
+
+func init() {
+	Register(csvExporter{})
+}
+
+func (csvExporter) Name() string { return "csv" }
+
+func (csvExporter) Description() string {
+	return "One CSV file per table (commits.csv, files.csv) in the target directory"
+}
+
+func (csvExporter) Export(tables []*Table, target string) ([]string, error) {
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+
+	var written []string
+	for _, table := range tables {
+		path := filepath.Join(target, table.Name+".csv")
+		if err := writeCSV(table, path); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// writeCSV writes a header row followed by every table row
+func writeCSV(table *Table, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Name
+	}
+	writer.Write(header)
+
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, value := range row {
+			switch v := value.(type) {
+			case int64:
+				record[i] = strconv.FormatInt(v, 10)
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			case string:
+				record[i] = v
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
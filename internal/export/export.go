@@ -0,0 +1,169 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"dgit/internal/log"
+)
+
+// Column types shared by every exporter
+const (
+	TypeInteger = "INTEGER"
+	TypeReal    = "REAL"
+	TypeText    = "TEXT"
+)
+
+// Column describes one exported field
+type Column struct {
+	Name string
+	Type string
+}
+
+// Table is a format-neutral set of rows; values are int64, float64 or string matching Column.Type
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+// Exporter writes tables in one output format
+type Exporter interface {
+	Name() string
+	Description() string
+	// Export writes all tables to target and returns the files it created
+	Export(tables []*Table, target string) ([]string, error)
+}
+
+var exporters = make(map[string]Exporter)
+
+// Register makes an exporter available by name
+func Register(exporter Exporter) {
+	exporters[exporter.Name()] = exporter
+}
+
+// Get returns a registered exporter
+func Get(name string) (Exporter, error) {
+	exporter, ok := exporters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format '%s' (available: %v)", name, Names())
+	}
+	return exporter, nil
+}
+
+// Names lists registered exporter names, sorted
+func Names() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// All returns registered exporters sorted by name
+func All() []Exporter {
+	var all []Exporter
+	for _, name := range Names() {
+		all = append(all, exporters[name])
+	}
+	return all
+}
+
+// Collect builds the commits and files tables from repository history, oldest first,
+// leaving out the versions in exclude
+func Collect(dgitDir string, exclude map[int]bool) ([]*Table, error) {
+	history, err := log.NewLogManager(dgitDir).GetCommitHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit history: %w", err)
+	}
+	var commits []*log.Commit
+	for _, commit := range history {
+		if !exclude[commit.Version] {
+			commits = append(commits, commit)
+		}
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Version < commits[j].Version })
+
+	commitTable := &Table{
+		Name: "commits",
+		Columns: []Column{
+			{"version", TypeInteger},
+			{"hash", TypeText},
+			{"parent_hash", TypeText},
+			{"message", TypeText},
+			{"author", TypeText},
+			{"timestamp", TypeText},
+			{"files_count", TypeInteger},
+			{"strategy", TypeText},
+			{"original_size", TypeInteger},
+			{"stored_size", TypeInteger},
+			{"compression_ratio", TypeReal},
+		},
+	}
+	fileTable := &Table{
+		Name: "files",
+		Columns: []Column{
+			{"version", TypeInteger},
+			{"commit_hash", TypeText},
+			{"path", TypeText},
+			{"type", TypeText},
+			{"size", TypeInteger},
+			{"dimensions", TypeText},
+			{"color_mode", TypeText},
+			{"layers", TypeInteger},
+			{"artboards", TypeInteger},
+			{"objects", TypeInteger},
+			{"last_modified", TypeText},
+		},
+	}
+
+	for _, commit := range commits {
+		var strategy string
+		var originalSize, storedSize int64
+		var ratio float64
+		if info := commit.CompressionInfo; info != nil {
+			strategy = info.Strategy
+			originalSize = info.OriginalSize
+			storedSize = info.CompressedSize
+			ratio = info.CompressionRatio
+		}
+		commitTable.Rows = append(commitTable.Rows, []interface{}{
+			int64(commit.Version), commit.Hash, commit.ParentHash, commit.Message, commit.Author,
+			commit.Timestamp.UTC().Format(time.RFC3339), int64(commit.FilesCount),
+			strategy, originalSize, storedSize, ratio,
+		})
+
+		paths := make([]string, 0, len(commit.Metadata))
+		for path := range commit.Metadata {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			meta, _ := commit.Metadata[path].(map[string]interface{})
+			fileTable.Rows = append(fileTable.Rows, []interface{}{
+				int64(commit.Version), commit.Hash, path,
+				stringField(meta, "type"), intField(meta, "size"),
+				stringField(meta, "dimensions"), stringField(meta, "color_mode"),
+				intField(meta, "layers"), intField(meta, "artboards"), intField(meta, "objects"),
+				stringField(meta, "last_modified"),
+			})
+		}
+	}
+
+	return []*Table{commitTable, fileTable}, nil
+}
+
+// stringField reads a string from decoded commit metadata
+func stringField(meta map[string]interface{}, key string) string {
+	value, _ := meta[key].(string)
+	return value
+}
+
+// intField reads a JSON number from decoded commit metadata
+func intField(meta map[string]interface{}, key string) int64 {
+	value, _ := meta[key].(float64)
+	return int64(value)
+}
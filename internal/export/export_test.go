@@ -0,0 +1,180 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// testTables returns a wide commits-like table and a table of rows from a few bytes to
+// many times a database page
+func testTables() []*Table {
+	wide := &Table{
+		Name: "commits",
+		Columns: []Column{
+			{"version", TypeInteger},
+			{"message", TypeText},
+			{"stored_size", TypeInteger},
+			{"compression_ratio", TypeReal},
+		},
+	}
+	for i := 0; i < 40000; i++ {
+		wide.Rows = append(wide.Rows, []interface{}{
+			int64(i + 1),
+			fmt.Sprintf("commit %d: 레이어 정리 / レイヤー整理 %s", i+1, strings.Repeat("x", i%90)),
+			int64(i) * int64(i) * 7919 * int64(1-2*(i%2)),
+			float64(i) / 3,
+		})
+	}
+
+	large := &Table{
+		Name:    "notes",
+		Columns: []Column{{"id", TypeInteger}, {"body", TypeText}},
+	}
+	for i, size := range []int{10, 4061, 4062, 4096, 9000, 100000, 20} {
+		large.Rows = append(large.Rows, []interface{}{int64(i + 1), noteBody(i, size)})
+	}
+	return []*Table{wide, large}
+}
+
+// noteBody is size bytes of text that differs along its length, so a misplaced
+// overflow page changes the content
+func noteBody(seed, size int) string {
+	var body strings.Builder
+	for i := 0; body.Len() < size; i++ {
+		fmt.Fprintf(&body, "%d.%d;", seed, i)
+	}
+	return body.String()[:size]
+}
+
+// sqlite runs a query against path with the sqlite3 command-line shell
+func sqlite(t *testing.T, path, query string) string {
+	t.Helper()
+	output, err := exec.Command("sqlite3", "-batch", path, query).CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3 %q: %v\n%s", query, err, output)
+	}
+	return strings.TrimSuffix(string(output), "\n")
+}
+
+func TestSQLiteExportOpensInSQLite(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	tables := testTables()
+	path := filepath.Join(t.TempDir(), "history.sqlite")
+	if _, err := (sqliteExporter{}).Export(tables, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sqlite(t, path, "PRAGMA integrity_check"); got != "ok" {
+		t.Fatalf("integrity_check: %s", got)
+	}
+	if got := sqlite(t, path, "SELECT name FROM sqlite_master ORDER BY name"); got != "commits\nnotes" {
+		t.Fatalf("tables: %q", got)
+	}
+
+	wide := tables[0]
+	var sumSize int64
+	var sumLength int
+	for _, row := range wide.Rows {
+		sumSize += row[2].(int64)
+		sumLength += len(row[1].(string))
+	}
+	want := fmt.Sprintf("%d|%d|%d", len(wide.Rows), sumSize, sumLength)
+	if got := sqlite(t, path, "SELECT count(*), sum(stored_size), sum(length(CAST(message AS BLOB))) FROM commits"); got != want {
+		t.Fatalf("commits aggregate: got %s, want %s", got, want)
+	}
+	for _, version := range []int{1, 2, 129, 20000, 40000} {
+		row := wide.Rows[version-1]
+		want := fmt.Sprintf("%d|%s|%d|1", row[0], row[1], row[2])
+		got := sqlite(t, path, fmt.Sprintf("SELECT version, message, stored_size, compression_ratio = %v FROM commits WHERE rowid = %d",
+			row[3], version))
+		if got != want {
+			t.Fatalf("commits row %d: got %s, want %s", version, got, want)
+		}
+	}
+
+	for _, row := range tables[1].Rows {
+		got := sqlite(t, path, fmt.Sprintf("SELECT body FROM notes WHERE id = %d", row[0]))
+		if got != row[1].(string) {
+			t.Fatalf("notes row %d: %d bytes read back, want %d matching bytes", row[0], len(got), len(row[1].(string)))
+		}
+	}
+}
+
+func TestParquetExportReadsBack(t *testing.T) {
+	tables := testTables()
+	dir := t.TempDir()
+	written, err := (parquetExporter{}).Export(tables, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != len(tables) {
+		t.Fatalf("wrote %d files, want %d", len(written), len(tables))
+	}
+
+	for i, table := range tables {
+		data, err := os.ReadFile(written[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader := parquet.NewReader(bytes.NewReader(data))
+		if got := reader.NumRows(); got != int64(len(table.Rows)) {
+			t.Fatalf("%s: %d rows, want %d", table.Name, got, len(table.Rows))
+		}
+
+		columns := make(map[string]int)
+		for index, field := range reader.Schema().Fields() {
+			if !field.Required() {
+				t.Errorf("%s.%s is not REQUIRED", table.Name, field.Name())
+			}
+			columns[field.Name()] = index
+		}
+
+		rows := make([]parquet.Row, 0, len(table.Rows))
+		buffer := make([]parquet.Row, 512)
+		for {
+			n, err := reader.ReadRows(buffer)
+			for _, row := range buffer[:n] {
+				rows = append(rows, row.Clone())
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		reader.Close()
+		if len(rows) != len(table.Rows) {
+			t.Fatalf("%s: read %d rows, want %d", table.Name, len(rows), len(table.Rows))
+		}
+
+		for r, want := range table.Rows {
+			for c, column := range table.Columns {
+				value := rows[r][columns[column.Name]]
+				var got interface{}
+				switch column.Type {
+				case TypeInteger:
+					got = value.Int64()
+				case TypeReal:
+					got = value.Double()
+				default:
+					got = string(value.ByteArray())
+				}
+				if got != want[c] {
+					t.Fatalf("%s row %d %s: got %v, want %v", table.Name, r+1, column.Name, got, want[c])
+				}
+			}
+		}
+	}
+}
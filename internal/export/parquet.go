@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetExporter writes one <table>.parquet per table into a directory.
+// Columns are REQUIRED, which every Parquet reader (Arrow, DuckDB, Spark) accepts;
+// they appear in name order, as Parquet groups keep their fields sorted.
+type parquetExporter struct{}
+
+func init() {
+	Register(parquetExporter{})
+}
+
+func (parquetExporter) Name() string { return "parquet" }
+
+func (parquetExporter) Description() string {
+	return "One Parquet file per table (commits.parquet, files.parquet) in the target directory"
+}
+
+func (parquetExporter) Export(tables []*Table, target string) ([]string, error) {
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+
+	var written []string
+	for _, table := range tables {
+		path := filepath.Join(target, table.Name+".parquet")
+		var data bytes.Buffer
+		if err := encodeParquet(table, &data); err != nil {
+			return written, fmt.Errorf("failed to encode %s: %w", table.Name, err)
+		}
+		if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// encodeParquet writes a complete Parquet file for one table
+func encodeParquet(table *Table, out *bytes.Buffer) error {
+	group := parquet.Group{}
+	for _, column := range table.Columns {
+		group[column.Name] = parquetNode(column.Type)
+	}
+	schema := parquet.NewSchema(table.Name, group)
+
+	// Rows hold values in the schema's column order, not the table's
+	columnIndex := make(map[string]int, len(table.Columns))
+	for i, field := range schema.Fields() {
+		columnIndex[field.Name()] = i
+	}
+
+	rows := make([]parquet.Row, 0, len(table.Rows))
+	for _, values := range table.Rows {
+		row := make(parquet.Row, len(table.Columns))
+		for col, column := range table.Columns {
+			value, err := parquetValue(column, values[col])
+			if err != nil {
+				return err
+			}
+			index := columnIndex[column.Name]
+			row[index] = value.Level(0, 0, index)
+		}
+		rows = append(rows, row)
+	}
+
+	writer := parquet.NewWriter(out, schema)
+	if _, err := writer.WriteRows(rows); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// parquetNode maps export column types to Parquet columns
+func parquetNode(columnType string) parquet.Node {
+	switch columnType {
+	case TypeInteger:
+		return parquet.Leaf(parquet.Int64Type)
+	case TypeReal:
+		return parquet.Leaf(parquet.DoubleType)
+	default:
+		return parquet.String()
+	}
+}
+
+// parquetValue converts one exported value to its Parquet column type
+func parquetValue(column Column, value interface{}) (parquet.Value, error) {
+	switch column.Type {
+	case TypeInteger:
+		v, ok := value.(int64)
+		if !ok {
+			return parquet.Value{}, fmt.Errorf("column %s: expected integer, got %T", column.Name, value)
+		}
+		return parquet.Int64Value(v), nil
+	case TypeReal:
+		v, ok := value.(float64)
+		if !ok {
+			return parquet.Value{}, fmt.Errorf("column %s: expected real, got %T", column.Name, value)
+		}
+		return parquet.DoubleValue(v), nil
+	default:
+		return parquet.ByteArrayValue([]byte(fmt.Sprint(value))), nil
+	}
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver, registered as "sqlite"
+)
+
+// sqliteExporter writes every table into a single SQLite 3 database file.
+// The database is built next to the target and renamed into place once complete.
+type sqliteExporter struct{}
+
+func init() {
+	Register(sqliteExporter{})
+}
+
+func (sqliteExporter) Name() string { return "sqlite" }
+
+func (sqliteExporter) Description() string {
+	return "Single SQLite database with commits and files tables, queryable with SQL"
+}
+
+func (sqliteExporter) Export(tables []*Table, target string) ([]string, error) {
+	tempPath := target + ".tmp"
+	os.Remove(tempPath)
+
+	if err := writeSQLite(tables, tempPath); err != nil {
+		os.Remove(tempPath)
+		return nil, err
+	}
+	if err := os.Rename(tempPath, target); err != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return []string{target}, nil
+}
+
+// writeSQLite creates a database at path and fills it with tables in one transaction
+func writeSQLite(tables []*Table, path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range tables {
+		if _, err := tx.Exec(createTableSQL(table)); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table.Name, err)
+		}
+		insert, err := tx.Prepare(insertSQL(table))
+		if err != nil {
+			return fmt.Errorf("failed to prepare insert into %s: %w", table.Name, err)
+		}
+		for _, row := range table.Rows {
+			if _, err := insert.Exec(row...); err != nil {
+				insert.Close()
+				return fmt.Errorf("failed to insert into %s: %w", table.Name, err)
+			}
+		}
+		insert.Close()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", path, err)
+	}
+	return db.Close()
+}
+
+// createTableSQL renders the CREATE TABLE statement for a table
+func createTableSQL(table *Table) string {
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = fmt.Sprintf("%s %s", column.Name, column.Type)
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", table.Name, strings.Join(columns, ", "))
+}
+
+// insertSQL renders a parameterized INSERT covering every column of a table
+func insertSQL(table *Table) string {
+	names := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		names[i] = column.Name
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table.Name, strings.Join(names, ", "), placeholders)
+}
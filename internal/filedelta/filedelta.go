@@ -0,0 +1,158 @@
+package filedelta
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gabstv/go-bsdiff/pkg/bspatch"
+)
+
+// Per-file delta payload layout (stored after the object header of a delta object):
+//
+//	DGIT_FILE_DELTAS_V1\n
+//	{"path":"poster.psd","base":"poster.psd","size":812}\n   entry header (JSON, one line)
+//	[812 bytes]                                              bsdiff patch against the base entry
+//	{"path":"new.ai","size":20480}\n                         no base: the bytes are the file itself
+//	[20480 bytes]
+//
+// Entries are in commit order, and the version they rebuild holds exactly these files.
+// Base entries are looked up by path in the archive of the base version.
+
+// Magic opens every per-file delta payload
+const Magic = "DGIT_FILE_DELTAS_V1\n"
+
+// Entry describes one file in a per-file delta
+type Entry struct {
+	Path string `json:"path"`
+	Base string `json:"base,omitempty"` // Base version path the patch applies to; empty when the data is the whole file
+	Size int64  `json:"size"`
+}
+
+// IsPayload reports whether a delta payload uses the per-file layout
+func IsPayload(payload []byte) bool {
+	return bytes.HasPrefix(payload, []byte(Magic))
+}
+
+// Writer appends entries to a per-file delta payload
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter writes the payload magic and returns a writer for its entries
+func NewWriter(w io.Writer) (*Writer, error) {
+	if _, err := io.WriteString(w, Magic); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// WritePatch adds a bsdiff patch that turns the base entry into path
+func (dw *Writer) WritePatch(path, base string, patch []byte) error {
+	return dw.write(Entry{Path: path, Base: base, Size: int64(len(patch))}, patch)
+}
+
+// WriteFile adds a file stored whole, for files the base version does not have
+func (dw *Writer) WriteFile(path string, data []byte) error {
+	return dw.write(Entry{Path: path, Size: int64(len(data))}, data)
+}
+
+func (dw *Writer) write(entry Entry, data []byte) error {
+	header, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := dw.w.Write(append(header, '\n')); err != nil {
+		return err
+	}
+	_, err = dw.w.Write(data)
+	return err
+}
+
+// Apply rebuilds a version archive at outZip from the base version archive and a
+// per-file delta payload
+func Apply(baseZip string, payload []byte, outZip string) error {
+	if !IsPayload(payload) {
+		return fmt.Errorf("not a per-file delta")
+	}
+
+	base, err := zip.OpenReader(baseZip)
+	if err != nil {
+		return fmt.Errorf("failed to open base archive: %w", err)
+	}
+	defer base.Close()
+	baseEntries := make(map[string]*zip.File, len(base.File))
+	for _, f := range base.File {
+		baseEntries[f.Name] = f
+	}
+
+	out, err := os.Create(outZip)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+	zipWriter := zip.NewWriter(out)
+
+	reader := bufio.NewReader(bytes.NewReader(payload[len(Magic):]))
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("truncated per-file delta")
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("invalid per-file delta entry: %w", err)
+		}
+		data := make([]byte, entry.Size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return fmt.Errorf("truncated per-file delta entry for %s", entry.Path)
+		}
+
+		if entry.Base != "" {
+			if data, err = patchEntry(baseEntries[entry.Base], entry, data); err != nil {
+				return err
+			}
+		}
+
+		w, err := zipWriter.Create(entry.Path)
+		if err != nil {
+			return fmt.Errorf("failed to create archive entry for %s: %w", entry.Path, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write archive entry for %s: %w", entry.Path, err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return out.Close()
+}
+
+// patchEntry applies one file's patch to its base archive entry
+func patchEntry(baseFile *zip.File, entry Entry, patch []byte) ([]byte, error) {
+	if baseFile == nil {
+		return nil, fmt.Errorf("base version has no %s to patch %s from", entry.Base, entry.Path)
+	}
+	rc, err := baseFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open base entry %s: %w", entry.Base, err)
+	}
+	defer rc.Close()
+	old, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base entry %s: %w", entry.Base, err)
+	}
+	data, err := bspatch.Bytes(old, patch)
+	if err != nil {
+		return nil, fmt.Errorf("bspatch failed for %s: %w", entry.Path, err)
+	}
+	return data, nil
+}
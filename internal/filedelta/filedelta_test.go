@@ -0,0 +1,129 @@
+package filedelta
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabstv/go-bsdiff/pkg/bsdiff"
+)
+
+// writeArchive writes files into a ZIP archive in the given order
+func writeArchive(t *testing.T, path string, names []string, files map[string][]byte) {
+	t.Helper()
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(files[name])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readArchive returns the entry names and contents of a ZIP archive
+func readArchive(t *testing.T, path string) ([]string, map[string][]byte) {
+	t.Helper()
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	files := make(map[string][]byte)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+		files[f.Name] = data
+	}
+	return names, files
+}
+
+func TestApplyRebuildsEveryFile(t *testing.T) {
+	dir := t.TempDir()
+	base := map[string][]byte{
+		"poster.psd":  bytes.Repeat([]byte("layer "), 4000),
+		"old-logo.ai": bytes.Repeat([]byte("path "), 3000),
+		"dropped.psd": []byte("removed in the new version"),
+	}
+	baseZip := filepath.Join(dir, "base.zip")
+	writeArchive(t, baseZip, []string{"poster.psd", "old-logo.ai", "dropped.psd"}, base)
+
+	want := map[string][]byte{
+		"poster.psd": append(bytes.Repeat([]byte("layer "), 3000), bytes.Repeat([]byte("edit "), 1000)...),
+		"logo.ai":    append(bytes.Repeat([]byte("path "), 3000), "moved"...),
+		"banner.psd": []byte("new in this version"),
+	}
+
+	var payload bytes.Buffer
+	writer, err := NewWriter(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, patch := range []struct{ path, base string }{{"poster.psd", "poster.psd"}, {"logo.ai", "old-logo.ai"}} {
+		data, err := bsdiff.Bytes(base[patch.base], want[patch.path])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WritePatch(patch.path, patch.base, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.WriteFile("banner.psd", want["banner.psd"]); err != nil {
+		t.Fatal(err)
+	}
+	if !IsPayload(payload.Bytes()) {
+		t.Fatal("IsPayload rejected a per-file delta")
+	}
+
+	outZip := filepath.Join(dir, "out.zip")
+	if err := Apply(baseZip, payload.Bytes(), outZip); err != nil {
+		t.Fatal(err)
+	}
+	names, got := readArchive(t, outZip)
+	if strings.Join(names, ",") != "poster.psd,logo.ai,banner.psd" {
+		t.Fatalf("rebuilt archive holds %v, want poster.psd, logo.ai, banner.psd in order", names)
+	}
+	for name, data := range want {
+		if !bytes.Equal(got[name], data) {
+			t.Errorf("%s does not match the new version", name)
+		}
+	}
+}
+
+func TestApplyRejectsMissingBase(t *testing.T) {
+	dir := t.TempDir()
+	baseZip := filepath.Join(dir, "base.zip")
+	writeArchive(t, baseZip, nil, nil)
+
+	var payload bytes.Buffer
+	writer, _ := NewWriter(&payload)
+	writer.WritePatch("poster.psd", "poster.psd", []byte("BSDIFF40"))
+
+	if err := Apply(baseZip, payload.Bytes(), filepath.Join(dir, "out.zip")); err == nil || !strings.Contains(err.Error(), "poster.psd") {
+		t.Fatalf("Apply without a base entry: got %v, want an error naming poster.psd", err)
+	}
+	if err := Apply(baseZip, []byte("BSDIFF40"), filepath.Join(dir, "out.zip")); err == nil {
+		t.Fatal("Apply accepted a payload that is not a per-file delta")
+	}
+}
@@ -0,0 +1,91 @@
+package generation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Every derived index or cache records the repository generation it was built at.
+// Operations that rewrite history or storage (gc, prune, squash, relocate) call Bump,
+// and any cache whose recorded generation differs from the current one is rebuilt.
+//
+//	.dgit/generation             current generation (repository state, never in cache/)
+//	<cache dir>/.generation      generation a cache directory was built at
+
+// stampFile is the per-directory generation marker
+const stampFile = ".generation"
+
+// State is the repository's current generation
+type State struct {
+	Generation uint64    `json:"generation"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// Load reads the generation state; a missing file is generation 0
+func Load(dgitDir string) *State {
+	state := &State{}
+	data, err := os.ReadFile(filepath.Join(dgitDir, "generation"))
+	if err != nil {
+		return state
+	}
+	json.Unmarshal(data, state)
+	return state
+}
+
+// Current returns the repository generation
+func Current(dgitDir string) uint64 {
+	return Load(dgitDir).Generation
+}
+
+// Bump advances the generation so every cache is rebuilt on next use
+func Bump(dgitDir, reason string) (uint64, error) {
+	state := Load(dgitDir)
+	state.Generation++
+	state.UpdatedAt = time.Now()
+	state.Reason = reason
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	path := filepath.Join(dgitDir, "generation")
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write generation: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return 0, fmt.Errorf("failed to write generation: %w", err)
+	}
+	return state.Generation, nil
+}
+
+// IsStale reports whether data built at generation built must be rebuilt
+func IsStale(dgitDir string, built uint64) bool {
+	return built != Current(dgitDir)
+}
+
+// DirIsStale reports whether a cache directory was built at an older generation
+// A directory without a marker predates generations and counts as generation 0
+func DirIsStale(dgitDir, dir string) bool {
+	var built uint64
+	if data, err := os.ReadFile(filepath.Join(dir, stampFile)); err == nil {
+		built, _ = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	}
+	return IsStale(dgitDir, built)
+}
+
+// MarkDir records that a cache directory is current
+func MarkDir(dgitDir, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, stampFile), []byte(strconv.FormatUint(Current(dgitDir), 10)+"\n"), 0644)
+}
@@ -0,0 +1,276 @@
+package health
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dgit/internal/maintenance"
+	"dgit/internal/objfmt"
+	"dgit/internal/queue"
+	"dgit/internal/restore"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Verification records when a version's objects were last read back
+type Verification struct {
+	At    time.Time `json:"at"`
+	Error string    `json:"error,omitempty"`
+}
+
+// Fix addresses a finding and describes what it did
+func (hm *HealthManager) Fix(finding *Finding) (string, error) {
+	switch finding.Kind {
+	case KindDeltaChain:
+		return hm.shortenChains(finding.Versions)
+	case KindUnverified, KindDamaged:
+		ok, failed, err := hm.Verify(finding.Versions)
+		if err != nil {
+			return "", err
+		}
+		if failed > 0 {
+			return fmt.Sprintf("verified %d version(s); %d failed verification", ok, failed), nil
+		}
+		return fmt.Sprintf("verified %d version(s)", ok), nil
+	case KindCache:
+		return hm.shrinkCache()
+	case KindTempFiles:
+		return hm.pruneTempFiles()
+	case KindQueue:
+		return hm.drainQueue()
+	default:
+		return "", fmt.Errorf("%s needs manual attention", finding.Kind)
+	}
+}
+
+// shortenChains stores full snapshots for versions whose chains are still too long,
+// oldest first, since each snapshot also shortens the chains of the versions after it
+func (hm *HealthManager) shortenChains(versions []int) (string, error) {
+	sorted := append([]int(nil), versions...)
+	sort.Ints(sorted)
+	planner := restore.NewRestoreManager(hm.DgitDir)
+
+	var rebased []int
+	var bytes int64
+	for _, version := range sorted {
+		files, err := planner.RequiredFiles(version)
+		if err != nil || len(files)-1 <= hm.maxChain {
+			continue
+		}
+		size, err := planner.Rebase(version)
+		if err != nil {
+			return "", fmt.Errorf("storing snapshot of v%d: %w", version, err)
+		}
+		if size > 0 {
+			rebased = append(rebased, version)
+			bytes += size
+		}
+	}
+	return fmt.Sprintf("stored full snapshots of %s (%s)", versionList(rebased), formatMB(bytes)), nil
+}
+
+// shrinkCache drops cached copies of stored versions until the cache fits its limit
+func (hm *HealthManager) shrinkCache() (string, error) {
+	mm := maintenance.NewMaintenanceManager(hm.DgitDir)
+	candidates, err := mm.PressureCandidates()
+	if err != nil {
+		return "", err
+	}
+	var drops []*maintenance.Suggestion
+	for _, candidate := range candidates {
+		if candidate.Kind == maintenance.KindDropCache {
+			drops = append(drops, candidate)
+		}
+	}
+	sort.Slice(drops, func(i, j int) bool { return drops[i].LastAccess.Before(drops[j].LastAccess) })
+
+	size := directorySize(hm.CacheDir)
+	var reclaimed int64
+	dropped := 0
+	for _, drop := range drops {
+		if size <= hm.cacheLimit {
+			break
+		}
+		saved, err := mm.Apply(drop)
+		if err != nil {
+			return "", err
+		}
+		size -= saved
+		reclaimed += saved
+		dropped++
+	}
+	summary := fmt.Sprintf("dropped %d cached version(s), reclaimed %s", dropped, formatMB(reclaimed))
+	if size > hm.cacheLimit {
+		summary += "; the rest of the cache holds staged files and required objects"
+	}
+	return summary, nil
+}
+
+// pruneTempFiles deletes leftovers of interrupted operations
+func (hm *HealthManager) pruneTempFiles() (string, error) {
+	mm := maintenance.NewMaintenanceManager(hm.DgitDir)
+	var reclaimed int64
+	removed := 0
+	for _, stale := range mm.StaleTempFiles() {
+		saved, err := mm.Apply(stale)
+		if err != nil {
+			return "", err
+		}
+		reclaimed += saved
+		removed++
+	}
+	return fmt.Sprintf("deleted %d temporary file(s), reclaimed %s", removed, formatMB(reclaimed)), nil
+}
+
+// drainQueue re-queues failed background commits and processes the queue
+func (hm *HealthManager) drainQueue() (string, error) {
+	qm := queue.NewQueueManager(hm.DgitDir)
+	jobs, err := qm.List()
+	if err != nil {
+		return "", err
+	}
+	retried := 0
+	for _, job := range jobs {
+		if job.State == queue.StateFailed {
+			if _, err := qm.Retry(job.ID); err != nil {
+				return "", err
+			}
+			retried++
+		}
+	}
+	if qm.WorkerRunning() {
+		return fmt.Sprintf("re-queued %d failed commit(s) for the running worker", retried), nil
+	}
+
+	done, failed := 0, 0
+	err = qm.Work(false, 0, nil, func(job *queue.Job) {
+		if job.State == queue.StateDone {
+			done++
+		} else {
+			failed++
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	summary := fmt.Sprintf("stored %d background commit(s)", done)
+	if failed > 0 {
+		summary += fmt.Sprintf("; %d failed again, see 'dgit queue'", failed)
+	}
+	return summary, nil
+}
+
+// Verify reads back every object the given versions need and records the outcome;
+// it returns how many versions passed and failed
+func (hm *HealthManager) Verify(versions []int) (int, int, error) {
+	planner := restore.NewRestoreManager(hm.DgitDir)
+	verified := hm.loadVerified()
+	ok, failed := 0, 0
+	for _, version := range versions {
+		record := &Verification{At: time.Now().UTC()}
+		files, err := planner.RequiredFiles(version)
+		if err == nil {
+			for _, file := range files {
+				if err = verifyObject(file); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			record.Error = err.Error()
+			failed++
+		} else {
+			ok++
+		}
+		verified[version] = record
+	}
+	return ok, failed, hm.saveVerified(verified)
+}
+
+// verifyObject reads an object to the end, checking ZIP CRCs and compressed stream framing
+func verifyObject(path string) error {
+	name := filepath.Base(path)
+	if strings.HasSuffix(path, ".zip") {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		defer archive.Close()
+		for _, entry := range archive.File {
+			reader, err := entry.Open()
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", name, entry.Name, err)
+			}
+			_, err = io.Copy(io.Discard, reader)
+			reader.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", name, entry.Name, err)
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer file.Close()
+	reader, err := objfmt.NewReader(file, path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	switch filepath.Ext(path) {
+	case ".lz4":
+		reader = lz4.NewReader(reader)
+	case ".zstd":
+		decoder, err := zstd.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		defer decoder.Close()
+		reader = decoder
+	}
+	// A header with no compressed frame after it decodes to EOF rather than to zero bytes
+	n, err := io.Copy(io.Discard, reader)
+	if n == 0 && (err == nil || errors.Is(err, io.EOF)) {
+		return fmt.Errorf("%s is empty", name)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// loadVerified reads the verification records by version
+func (hm *HealthManager) loadVerified() map[int]*Verification {
+	verified := make(map[int]*Verification)
+	if data, err := os.ReadFile(hm.VerifiedFile); err == nil {
+		json.Unmarshal(data, &verified)
+	}
+	return verified
+}
+
+// saveVerified writes the verification records atomically
+func (hm *HealthManager) saveVerified(verified map[int]*Verification) error {
+	if err := os.MkdirAll(filepath.Dir(hm.VerifiedFile), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(verified, "", "  ")
+	if err != nil {
+		return err
+	}
+	tempPath := hm.VerifiedFile + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, hm.VerifiedFile)
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dgit/internal/commit"
+	initializer "dgit/internal/init"
+	"dgit/internal/staging"
+)
+
+// TestProfileSnapshotsDecode commits a first version under every compression profile and
+// reads its LZ4 snapshot back the way 'dgit doctor' does. A level the LZ4 writer does
+// not accept leaves only the object header behind, which verifyObject reports as empty
+func TestProfileSnapshotsDecode(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, name := range commit.ProfileNames() {
+		t.Run(name, func(t *testing.T) {
+			repo := t.TempDir()
+			if err := initializer.NewRepositoryInitializer().InitializeRepository(repo); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(repo); err != nil {
+				t.Fatal(err)
+			}
+			content := bytes.Repeat([]byte("layer data "), 4096)
+			if err := os.WriteFile("hero.psd", content, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			dgitDir := filepath.Join(repo, initializer.DGitDir)
+			stagingArea := staging.NewStagingArea(dgitDir)
+			if err := stagingArea.LoadStaging(); err != nil {
+				t.Fatal(err)
+			}
+			if err := stagingArea.AddFile("hero.psd"); err != nil {
+				t.Fatal(err)
+			}
+
+			manager := commit.NewCommitManager(dgitDir)
+			if err := manager.ApplyProfile(name); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := manager.CreateCommit("first", stagingArea.GetStagedFiles()); err != nil {
+				t.Fatal(err)
+			}
+
+			snapshot := filepath.Join(initializer.GetStorageDir(dgitDir), "snapshots", "v1.lz4")
+			if err := verifyObject(snapshot); err != nil {
+				t.Fatalf("snapshot written with the %s profile does not decode: %v", name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+// Package health rolls storage and background-work signals into one repository health
+// score: long delta chains, a cache over its limit, leftover temporary files, objects
+// never checked since they were written, and background commits that stalled or failed.
+// Each finding says what 'dgit doctor --fix' does about it.
+package health
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dgit/internal/commit"
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/maintenance"
+	"dgit/internal/queue"
+	"dgit/internal/restore"
+)
+
+// Finding kinds
+const (
+	KindDeltaChain = "delta-chain" // Versions that replay more deltas than the compression profile allows
+	KindCache      = "cache"       // Cache directory above compression.cache.main_cache_size
+	KindTempFiles  = "temp-files"  // Leftovers of interrupted commits and restores
+	KindUnverified = "unverified"  // Versions whose objects were never read back
+	KindDamaged    = "damaged"     // Versions whose objects are missing or failed verification
+	KindQueue      = "queue"       // Background commits stalled without a worker, or failed
+)
+
+// Severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Statuses by score
+const (
+	StatusHealthy = "healthy" // 90 and above
+	StatusFair    = "fair"    // 70 and above
+	StatusPoor    = "poor"
+)
+
+// Finding is one health problem
+type Finding struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"` // What 'dgit doctor --fix' does; empty when it needs a person
+	Penalty  int    `json:"penalty"`       // Points taken off the score
+	Versions []int  `json:"versions,omitempty"`
+	Bytes    int64  `json:"bytes,omitempty"`
+}
+
+// Report is the result of a health check
+type Report struct {
+	Score     int        `json:"score"` // 0-100
+	Status    string     `json:"status"`
+	Findings  []*Finding `json:"findings"`
+	CheckedAt time.Time  `json:"checked_at"`
+}
+
+// HealthManager checks and repairs repository health
+type HealthManager struct {
+	DgitDir      string
+	CacheDir     string
+	VerifiedFile string
+
+	maxChain   int   // Delta steps a version may need before it counts as a long chain
+	cacheLimit int64 // Bytes
+}
+
+// NewHealthManager creates a health manager using the repository's compression settings
+func NewHealthManager(dgitDir string) *HealthManager {
+	hm := &HealthManager{
+		DgitDir:      dgitDir,
+		CacheDir:     filepath.Join(dgitDir, "cache"),
+		VerifiedFile: filepath.Join(dgitDir, "metrics", "verified.json"),
+		maxChain:     commit.Profiles[commit.DefaultProfile].MaxDeltaChainLength,
+	}
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		if profile, ok := commit.Profiles[strings.ToLower(config.Compression.Profile)]; ok {
+			hm.maxChain = profile.MaxDeltaChainLength
+		}
+		hm.cacheLimit = config.Compression.CacheConfig.MainCacheSize * 1024 * 1024
+	}
+	return hm
+}
+
+// Check gathers every signal into a report
+func (hm *HealthManager) Check() (*Report, error) {
+	commits, err := log.NewLogManager(hm.DgitDir).GetCommitHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commit history: %w", err)
+	}
+	verified := hm.loadVerified()
+
+	// The objects each version's restore reads; the delta steps are all but the first
+	planner := restore.NewRestoreManager(hm.DgitDir)
+	required := make(map[int][]string, len(commits))
+	for _, c := range commits {
+		files, err := planner.RequiredFiles(c.Version)
+		if err == nil {
+			required[c.Version] = files
+		}
+	}
+
+	report := &Report{CheckedAt: time.Now()}
+	add := func(finding *Finding) {
+		if finding != nil {
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+	add(hm.checkStorage(commits, required, verified))
+	add(hm.checkChains(commits, required))
+	add(hm.checkUnverified(commits, required, verified))
+	add(hm.checkCache())
+	add(hm.checkTempFiles())
+	add(hm.checkQueue())
+
+	report.Score = 100
+	for _, finding := range report.Findings {
+		report.Score -= finding.Penalty
+	}
+	report.Score = max(report.Score, 0)
+	switch {
+	case report.Score >= 90:
+		report.Status = StatusHealthy
+	case report.Score >= 70:
+		report.Status = StatusFair
+	default:
+		report.Status = StatusPoor
+	}
+	return report, nil
+}
+
+// checkStorage reports versions whose objects are missing or failed their last verification
+func (hm *HealthManager) checkStorage(commits []*log.Commit, required map[int][]string, verified map[int]*Verification) *Finding {
+	var damaged []int
+	for _, c := range commits {
+		if required[c.Version] == nil {
+			damaged = append(damaged, c.Version)
+		} else if v := verified[c.Version]; v != nil && v.Error != "" {
+			damaged = append(damaged, c.Version)
+		}
+	}
+	if len(damaged) == 0 {
+		return nil
+	}
+	return &Finding{
+		Kind:     KindDamaged,
+		Severity: SeverityCritical,
+		Message:  fmt.Sprintf("%d version(s) cannot be restored: objects missing or corrupt (%s)", len(damaged), versionList(damaged)),
+		Fix:      "check their objects again (restore them from a mirror or backup first)",
+		Penalty:  40,
+		Versions: damaged,
+	}
+}
+
+// checkChains reports versions that replay more deltas than the profile allows
+func (hm *HealthManager) checkChains(commits []*log.Commit, required map[int][]string) *Finding {
+	var long []int
+	longest := 0
+	for _, c := range commits {
+		files := required[c.Version]
+		if len(files)-1 <= hm.maxChain {
+			continue
+		}
+		long = append(long, c.Version)
+		longest = max(longest, len(files)-1)
+	}
+	if len(long) == 0 {
+		return nil
+	}
+	return &Finding{
+		Kind:     KindDeltaChain,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%d version(s) replay up to %d deltas to restore (limit %d)", len(long), longest, hm.maxChain),
+		Fix:      "store full snapshots where chains run long",
+		Penalty:  min(3*len(long), 20),
+		Versions: long,
+	}
+}
+
+// checkUnverified reports versions whose objects have never been read back
+func (hm *HealthManager) checkUnverified(commits []*log.Commit, required map[int][]string, verified map[int]*Verification) *Finding {
+	var unverified []int
+	for _, c := range commits {
+		if verified[c.Version] == nil && required[c.Version] != nil {
+			unverified = append(unverified, c.Version)
+		}
+	}
+	if len(unverified) == 0 {
+		return nil
+	}
+	// At most 10 points: unverified data is a risk, not a known fault
+	penalty := (10*len(unverified) + len(commits) - 1) / len(commits)
+	return &Finding{
+		Kind:     KindUnverified,
+		Severity: SeverityInfo,
+		Message:  fmt.Sprintf("%d of %d version(s) have objects that were never verified", len(unverified), len(commits)),
+		Fix:      "read back and check every object those versions need",
+		Penalty:  penalty,
+		Versions: unverified,
+	}
+}
+
+// checkCache reports a cache directory above its configured size
+func (hm *HealthManager) checkCache() *Finding {
+	if hm.cacheLimit <= 0 {
+		return nil
+	}
+	size := directorySize(hm.CacheDir)
+	if size <= hm.cacheLimit {
+		return nil
+	}
+	return &Finding{
+		Kind:     KindCache,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("cache is %s over its %s limit", formatMB(size-hm.cacheLimit), formatMB(hm.cacheLimit)),
+		Fix:      "drop cached copies of versions stored elsewhere, least recently used first",
+		Penalty:  10,
+		Bytes:    size - hm.cacheLimit,
+	}
+}
+
+// checkTempFiles reports leftovers of interrupted operations
+func (hm *HealthManager) checkTempFiles() *Finding {
+	stale := maintenance.NewMaintenanceManager(hm.DgitDir).StaleTempFiles()
+	if len(stale) == 0 {
+		return nil
+	}
+	var bytes int64
+	for _, s := range stale {
+		bytes += s.Size
+	}
+	return &Finding{
+		Kind:     KindTempFiles,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%d leftover temporary file(s) from interrupted operations (%s)", len(stale), formatMB(bytes)),
+		Fix:      "delete them",
+		Penalty:  5,
+		Bytes:    bytes,
+	}
+}
+
+// checkQueue reports background commits that failed, or wait with no worker to run them
+func (hm *HealthManager) checkQueue() *Finding {
+	qm := queue.NewQueueManager(hm.DgitDir)
+	jobs, err := qm.List()
+	if err != nil || len(jobs) == 0 {
+		return nil
+	}
+	pending, failed := 0, 0
+	for _, job := range jobs {
+		switch job.State {
+		case queue.StateQueued, queue.StateRunning:
+			pending++
+		case queue.StateFailed:
+			failed++
+		}
+	}
+	if qm.WorkerRunning() {
+		pending = 0 // Being worked on; not a problem
+	}
+	if pending == 0 && failed == 0 {
+		return nil
+	}
+
+	var parts []string
+	penalty := 0
+	if pending > 0 {
+		parts = append(parts, fmt.Sprintf("%d waiting with no worker running", pending))
+		penalty += 15
+	}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", failed))
+		penalty += 10
+	}
+	return &Finding{
+		Kind:     KindQueue,
+		Severity: SeverityWarning,
+		Message:  "background commits: " + strings.Join(parts, ", "),
+		Fix:      "retry failed commits and process the queue",
+		Penalty:  penalty,
+	}
+}
+
+// directorySize sums file sizes under dir
+func directorySize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// formatMB renders a byte count in MB or GB
+func formatMB(bytes int64) string {
+	if bytes >= 1<<30 {
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
+
+// versionList renders versions as "v3, v7", eliding long lists
+func versionList(versions []int) string {
+	sorted := append([]int(nil), versions...)
+	sort.Ints(sorted)
+	var parts []string
+	for i, version := range sorted {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("and %d more", len(sorted)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("v%d", version))
+	}
+	return strings.Join(parts, ", ")
+}
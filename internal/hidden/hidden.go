@@ -0,0 +1,150 @@
+// Package hidden keeps versions out of default listings without touching their data.
+// A hidden version stays in the history and its objects stay in storage, so it can
+// still be shown and restored by number; log, feed, export, reports, review lists and
+// preview rebuilds simply skip it unless asked to include hidden versions. The hidden
+// set lives in .dgit/hidden.json, outside the commit records, so hiding never changes
+// commit hashes.
+package hidden
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Entry records why and when a version was hidden
+type Entry struct {
+	Version  int       `json:"version"`
+	HiddenAt time.Time `json:"hidden_at"`
+	HiddenBy string    `json:"hidden_by,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// HiddenManager reads and updates the hidden version set
+type HiddenManager struct {
+	DgitDir    string
+	HiddenFile string
+}
+
+// NewHiddenManager creates a new hidden version manager
+func NewHiddenManager(dgitDir string) *HiddenManager {
+	return &HiddenManager{
+		DgitDir:    dgitDir,
+		HiddenFile: filepath.Join(dgitDir, "hidden.json"),
+	}
+}
+
+// List returns the hidden versions, lowest first
+func (hm *HiddenManager) List() ([]*Entry, error) {
+	data, err := os.ReadFile(hm.HiddenFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hidden versions: %w", err)
+	}
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse hidden.json: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Version < entries[j].Version })
+	return entries, nil
+}
+
+// Versions returns the hidden version numbers as a set; an unreadable file hides nothing
+func (hm *HiddenManager) Versions() map[int]bool {
+	entries, _ := hm.List()
+	versions := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		versions[entry.Version] = true
+	}
+	return versions
+}
+
+// Get returns the entry for a hidden version, or nil when it is visible
+func (hm *HiddenManager) Get(version int) *Entry {
+	entries, _ := hm.List()
+	for _, entry := range entries {
+		if entry.Version == version {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Hide marks versions hidden and returns the ones that were not hidden already
+func (hm *HiddenManager) Hide(versions []int, reason, by string) ([]int, error) {
+	entries, err := hm.List()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		existing[entry.Version] = true
+	}
+
+	var added []int
+	now := time.Now()
+	for _, version := range versions {
+		if existing[version] {
+			continue
+		}
+		existing[version] = true
+		entries = append(entries, &Entry{Version: version, HiddenAt: now, HiddenBy: by, Reason: reason})
+		added = append(added, version)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, hm.save(entries)
+}
+
+// Unhide makes versions visible again and returns the ones that were hidden
+func (hm *HiddenManager) Unhide(versions []int) ([]int, error) {
+	entries, err := hm.List()
+	if err != nil {
+		return nil, err
+	}
+	remove := make(map[int]bool, len(versions))
+	for _, version := range versions {
+		remove[version] = true
+	}
+
+	var removed []int
+	kept := entries[:0]
+	for _, entry := range entries {
+		if remove[entry.Version] {
+			removed = append(removed, entry.Version)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, hm.save(kept)
+}
+
+// save writes the entries through a temporary file so readers never see a partial list
+func (hm *HiddenManager) save(entries []*Entry) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Version < entries[j].Version })
+	if entries == nil {
+		entries = []*Entry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := hm.HiddenFile + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write hidden versions: %w", err)
+	}
+	if err := os.Rename(temp, hm.HiddenFile); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to write hidden versions: %w", err)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"dgit/internal/objfmt"
 )
 
 // DGitDir defines the standard DGit repository directory name
@@ -27,15 +29,52 @@ type RepositoryConfig struct {
 	Version     string    `json:"version"`
 	Description string    `json:"description"`
 
+	// Object format negotiation; readers refuse repositories newer than they understand
+	FormatVersion    int    `json:"format_version"`
+	MinReaderVersion string `json:"min_reader_version,omitempty"`
+
 	// Compression System Configuration
 	Compression CompressionConfig `json:"compression"`
 
 	// Performance Monitoring Settings
 	Performance PerformanceConfig `json:"performance"`
+
+	// Tracing and Metrics Export
+	Telemetry TelemetryConfig `json:"telemetry"`
+
+	// Object Storage Location
+	Storage StorageConfig `json:"storage"`
+
+	// Commit Metadata Packing
+	Metadata MetadataConfig `json:"metadata"`
+
+	// Unicode Path Handling
+	Paths PathsConfig `json:"paths"`
+
+	// Extended Attributes (Finder tags, labels)
+	Attributes AttributesConfig `json:"attributes"`
+
+	// Thumbnails of committed files
+	Previews PreviewsConfig `json:"previews"`
+
+	// Parallel Restore
+	Restore RestoreConfig `json:"restore"`
+
+	// Tool environment recorded with each commit
+	Environment EnvironmentConfig `json:"environment"`
+
+	// How hashes are shown
+	Display DisplayConfig `json:"display"`
+
+	// Production constraints checked at commit time
+	Policies []AssetPolicy `json:"policies,omitempty"`
 }
 
 // CompressionConfig represents simplified compression settings
 type CompressionConfig struct {
+	// Named profile (speed, balanced, archive) that replaces the stage settings below
+	Profile string `json:"profile,omitempty"`
+
 	// LZ4 Fast Compression
 	LZ4Config LZ4StageConfig `json:"lz4_stage"`
 
@@ -47,6 +86,17 @@ type CompressionConfig struct {
 
 	// Cache Management Settings
 	CacheConfig SmartCacheConfig `json:"cache"`
+
+	// Binary Delta Resource Limits
+	DeltaConfig DeltaStageConfig `json:"delta"`
+}
+
+// DeltaStageConfig bounds bsdiff, whose memory use is roughly 17x the size of the file it diffs
+type DeltaStageConfig struct {
+	MaxInputSize   int64   `json:"max_input_size"`      // Largest base+current size of one file to diff (bytes); above it a snapshot is stored
+	MemoryFraction float64 `json:"max_memory_fraction"` // Share of available RAM bsdiff may use
+	MaxSeconds     float64 `json:"max_seconds"`         // Longest bsdiff may run before a snapshot is stored instead
+	Workers        int     `json:"workers"`             // Files diffed at once; 0 uses one per CPU
 }
 
 // LZ4StageConfig configures fast compression
@@ -72,6 +122,9 @@ type ArchiveStageConfig struct {
 	CompressionLevel int   `json:"compression_level"`  // Zstd level 22 (maximum compression)
 	ArchiveAfterDays int   `json:"archive_after_days"` // Days before moving to archive
 	MaxArchiveSize   int64 `json:"max_archive_size"`   // Max size per archive file (bytes)
+
+	LowSpaceThreshold int64 `json:"low_space_threshold_mb"` // Free space (MB) below which hot snapshots are demoted
+	DemoteOnLowSpace  bool  `json:"demote_on_low_space"`    // Demote automatically after restores when space is low
 }
 
 // SmartCacheConfig configures cache management
@@ -90,6 +143,94 @@ type PerformanceConfig struct {
 	StatsRetentionDays int  `json:"stats_retention_days"` // Days to keep performance statistics
 }
 
+// TelemetryConfig configures OpenTelemetry (OTLP/HTTP) export of commit pipeline spans and metrics
+type TelemetryConfig struct {
+	Enabled     bool              `json:"enabled"`           // Record spans for commit phases
+	Endpoint    string            `json:"endpoint"`          // OTLP/HTTP collector, e.g. "http://localhost:4318"
+	ServiceName string            `json:"service_name"`      // Reported service.name resource attribute
+	Headers     map[string]string `json:"headers,omitempty"` // Extra HTTP headers (auth tokens, tenant IDs)
+	TimeoutMs   int               `json:"timeout_ms"`        // Export timeout so slow collectors never block commits
+}
+
+// StorageConfig locates object storage, which may live on another volume after 'dgit relocate'
+type StorageConfig struct {
+	Path string `json:"path,omitempty"` // Absolute object storage root; empty means inside .dgit
+}
+
+// MetadataConfig configures packing of commit metadata JSONs into Zstd batches
+type MetadataConfig struct {
+	PackEnabled   bool `json:"pack_enabled"`    // Pack loose commit records automatically after commits
+	PackThreshold int  `json:"pack_threshold"`  // Loose records allowed before packing
+	PackBatchSize int  `json:"pack_batch_size"` // Max records per pack file
+}
+
+// PathsConfig controls Unicode normalization of manifest paths (macOS NFD vs Windows NFC)
+type PathsConfig struct {
+	Normalization string `json:"normalization"` // "nfc", "nfd" or "none"
+	StatusMatch   string `json:"status_match"`  // "normalized" matches across normal forms, "exact" compares bytes
+}
+
+// AttributesConfig gates versioning of extended attributes such as Finder tags
+type AttributesConfig struct {
+	Enabled bool     `json:"enabled"`           // Capture attributes on commit and restore them on restore
+	Exclude []string `json:"exclude,omitempty"` // Names or "prefix." patterns to skip; defaults drop quarantine/security data
+}
+
+// PreviewsConfig controls thumbnail generation at commit time
+type PreviewsConfig struct {
+	Enabled     bool  `json:"enabled"`       // Generate thumbnails for committed files
+	Size        int   `json:"size"`          // Longest edge in pixels
+	MaxFileSize int64 `json:"max_file_size"` // Larger files are skipped at commit ('dgit previews rebuild' still covers them)
+
+	// Renderers tried in order: "embedded", "raster", or the name of an external renderer
+	Renderers []string                 `json:"renderers,omitempty"`
+	External  []ExternalRendererConfig `json:"external,omitempty"`
+}
+
+// RestoreConfig tunes how many files a restore writes at once for the disk it writes to
+type RestoreConfig struct {
+	Workers   int    `json:"workers"`    // Files written concurrently; 0 picks a count for the medium
+	IOProfile string `json:"io_profile"` // "auto" (detect), "ssd", "hdd" or "network"
+}
+
+// EnvironmentConfig gates recording of the tool environment (OS, dgit release, application
+// versions, color profiles) with each commit
+type EnvironmentConfig struct {
+	Enabled       bool   `json:"enabled"`
+	ColorSettings string `json:"color_settings,omitempty"` // Adobe Color Settings in use, e.g. "North America Prepress 2"; set with --local
+}
+
+// DisplayConfig controls how commit hashes are abbreviated in output
+type DisplayConfig struct {
+	HashLength int `json:"hash_length"` // Hex characters shown (4-64); commits always store the full hash
+}
+
+// ExternalRendererConfig runs a command to render previews (ImageMagick, a headless app script)
+// Command arguments may use {input}, {output} (a PNG path to write) and {size}
+type ExternalRendererConfig struct {
+	Name           string   `json:"name"`
+	Command        []string `json:"command"`
+	Extensions     []string `json:"extensions"` // e.g. [".psd", ".ai"]
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+// AssetPolicy is a production constraint (print DPI, color mode, size limits) checked
+// against scanned metadata when files are committed; zero-valued limits are not checked
+type AssetPolicy struct {
+	Name        string   `json:"name"`
+	Paths       []string `json:"paths,omitempty"` // Glob patterns or "dir/" prefixes; empty matches every file
+	Types       []string `json:"types,omitempty"` // File types such as "psd", "ai"; empty matches all
+	MinDPI      int      `json:"min_dpi,omitempty"`
+	MaxWidth    int      `json:"max_width,omitempty"`  // Pixels
+	MaxHeight   int      `json:"max_height,omitempty"` // Pixels
+	ColorMode   string   `json:"color_mode,omitempty"` // Required mode, e.g. "CMYK"
+	MaxFileSize int64    `json:"max_file_size,omitempty"`
+	Severity    string   `json:"severity,omitempty"` // "warn" (default) or "block"
+}
+
+// ObjectStorageDirs lists the directories holding object data; they always move together
+var ObjectStorageDirs = []string{"snapshots", "deltas", "archive", "objects"}
+
 // InitializeRepository initializes a new DGit repository
 func (ri *RepositoryInitializer) InitializeRepository(path string) error {
 	dgitPath := filepath.Join(path, DGitDir)
@@ -175,6 +316,9 @@ func (ri *RepositoryInitializer) createConfig(dgitPath string) error {
 		Version:     "2.0.0",
 		Description: "DGit repository with simplified structure",
 
+		FormatVersion:    objfmt.FormatVersion,
+		MinReaderVersion: objfmt.MinReaderVersion,
+
 		// Simplified Compression Configuration
 		Compression: CompressionConfig{
 			// LZ4 Fast Compression (single compression method)
@@ -200,6 +344,9 @@ func (ri *RepositoryInitializer) createConfig(dgitPath string) error {
 				CompressionLevel: 22,
 				ArchiveAfterDays: 90,                     // 3 months later
 				MaxArchiveSize:   5 * 1024 * 1024 * 1024, // 5GB
+
+				LowSpaceThreshold: 10 * 1024, // Demote below 10GB free
+				DemoteOnLowSpace:  true,
 			},
 
 			// Simplified Cache Configuration
@@ -209,6 +356,13 @@ func (ri *RepositoryInitializer) createConfig(dgitPath string) error {
 				AccessThreshold: 1,        // Immediate cache
 				EvictionPolicy:  "LRU",
 			},
+
+			// bsdiff Guard (downgrades to a full snapshot when exceeded)
+			DeltaConfig: DeltaStageConfig{
+				MaxInputSize:   512 * 1024 * 1024, // 512MB
+				MemoryFraction: 0.5,
+				MaxSeconds:     120,
+			},
 		},
 
 		// Performance Monitoring Configuration
@@ -218,6 +372,55 @@ func (ri *RepositoryInitializer) createConfig(dgitPath string) error {
 			LogCacheHits:       false, // Simplified
 			StatsRetentionDays: 30,    // 1 month
 		},
+
+		// Telemetry Export (Disabled by default)
+		Telemetry: TelemetryConfig{
+			Enabled:     false,
+			Endpoint:    "",
+			ServiceName: "dgit",
+			TimeoutMs:   2000,
+		},
+
+		// Metadata Packing (Disabled by default)
+		Metadata: MetadataConfig{
+			PackEnabled:   false,
+			PackThreshold: 500,
+			PackBatchSize: 1000,
+		},
+
+		// Path Normalization (NFC manifests, cross-form status matching)
+		Paths: PathsConfig{
+			Normalization: "nfc",
+			StatusMatch:   "normalized",
+		},
+
+		// Extended Attributes (opt-in; Finder tags and labels travel with files when enabled)
+		Attributes: AttributesConfig{
+			Enabled: false,
+		},
+
+		// Previews (256px thumbnails, files up to 2GB decoded at commit)
+		Previews: PreviewsConfig{
+			Enabled:     true,
+			Size:        256,
+			MaxFileSize: 2 * 1024 * 1024 * 1024,
+		},
+
+		// Parallel Restore (worker count chosen from the detected disk type)
+		Restore: RestoreConfig{
+			Workers:   0,
+			IOProfile: "auto",
+		},
+
+		// Environment Capture (opt-in; OS, dgit and application versions recorded per commit)
+		Environment: EnvironmentConfig{
+			Enabled: false,
+		},
+
+		// Hash Display
+		Display: DisplayConfig{
+			HashLength: 12,
+		},
 	}
 
 	configPath := filepath.Join(dgitPath, "config")
@@ -285,7 +488,7 @@ func IsDGitRepository(path string) bool {
 	}
 
 	// Check for essential directories
-	snapshotsPath := filepath.Join(dgitPath, "snapshots")
+	snapshotsPath := filepath.Join(GetStorageDir(dgitPath), "snapshots")
 	if info, err := os.Stat(snapshotsPath); err != nil || !info.IsDir() {
 		return false
 	}
@@ -293,13 +496,11 @@ func IsDGitRepository(path string) bool {
 	return true
 }
 
-// GetConfig loads repository configuration
+// GetConfig loads repository configuration, with config.local overrides applied
 func GetConfig(dgitPath string) (*RepositoryConfig, error) {
-	configPath := filepath.Join(dgitPath, "config")
-
-	data, err := os.ReadFile(configPath)
+	data, err := ReadConfigJSON(dgitPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
+		return nil, err
 	}
 
 	var config RepositoryConfig
@@ -310,18 +511,64 @@ func GetConfig(dgitPath string) (*RepositoryConfig, error) {
 	return &config, nil
 }
 
-// UpdateConfig saves repository configuration
+// UpdateConfig saves repository configuration loaded with GetConfig
+// Changed values are written to the shared config, or to config.local for keys it
+// overrides and machine-local keys (see MachineLocalKeys)
 func UpdateConfig(dgitPath string, config *RepositoryConfig) error {
-	configPath := filepath.Join(dgitPath, "config")
+	shared, local, err := splitConfig(dgitPath, config)
+	if err != nil {
+		return err
+	}
+	if err := UpdateSharedConfig(dgitPath, shared); err != nil {
+		return err
+	}
+	return writeLocalConfig(dgitPath, local)
+}
 
-	configData, err := json.MarshalIndent(config, "", "  ")
+// CheckFormat returns an error if the repository was written by a newer DGit
+func CheckFormat(dgitPath string) error {
+	config, err := GetConfig(dgitPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return nil // Unreadable config is reported by the command itself
 	}
+	return objfmt.CheckRelease(config.FormatVersion, config.MinReaderVersion, dgitPath)
+}
 
-	if err := os.WriteFile(configPath, configData, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+// EnsureFormatVersion records that the repository now contains current-format objects
+// Called before writing objects so newer-format repositories are detectable by config alone
+func EnsureFormatVersion(dgitPath string) error {
+	config, err := GetConfig(dgitPath)
+	if err != nil {
+		return err
+	}
+	if config.FormatVersion >= objfmt.FormatVersion {
+		return nil
+	}
+	config.FormatVersion = objfmt.FormatVersion
+	config.MinReaderVersion = objfmt.MinReaderVersion
+	return UpdateConfig(dgitPath, config)
+}
+
+// GetStorageDir returns the root directory containing object storage
+// Falls back to the .dgit directory itself when storage has not been relocated
+func GetStorageDir(dgitPath string) string {
+	config, err := GetConfig(dgitPath)
+	if err != nil || config.Storage.Path == "" {
+		return dgitPath
 	}
+	return config.Storage.Path
+}
 
+// CheckStorage reports relocated object storage that is missing, typically because its
+// volume is not mounted. Nothing may create directories there in that state: they would
+// land on the disk under the mount point instead
+func CheckStorage(dgitPath string) error {
+	storageDir := GetStorageDir(dgitPath)
+	if storageDir == dgitPath {
+		return nil
+	}
+	if _, err := os.Stat(storageDir); err != nil {
+		return fmt.Errorf("object storage not available at %s", storageDir)
+	}
 	return nil
 }
@@ -0,0 +1,381 @@
+package init
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Configuration is split in two files. .dgit/config holds the shared settings that
+// travel with the repository (backups, mirrors, presets); .dgit/config.local holds
+// per-machine overrides such as cache sizes, the storage path and memory limits.
+// config.local has the same layout as config but only the keys it overrides, and
+// its values win when the configuration is read.
+
+// LocalConfigFile is the per-machine override file inside .dgit
+const LocalConfigFile = "config.local"
+
+// MachineLocalKeys are config paths that describe this machine rather than the project;
+// changes to them are written to config.local instead of the shared config
+var MachineLocalKeys = []string{
+	"storage",
+	"compression.cache",
+	"compression.delta",
+	"compression.archive_stage.low_space_threshold_mb",
+	"restore",
+	"environment.color_settings",
+}
+
+// configMap is a config file decoded without its schema
+type configMap = map[string]interface{}
+
+// readConfigMap decodes a config file; a missing file is an empty map
+func readConfigMap(path string) (configMap, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return configMap{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	values := configMap{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return values, nil
+}
+
+// ReadConfigJSON returns the effective configuration as JSON: the shared config with
+// config.local overrides applied
+func ReadConfigJSON(dgitPath string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dgitPath, "config"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	local, err := readConfigMap(filepath.Join(dgitPath, LocalConfigFile))
+	if err != nil || len(local) == 0 {
+		return data, err
+	}
+
+	shared := configMap{}
+	if err := json.Unmarshal(data, &shared); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	mergeConfig(shared, local)
+	return json.Marshal(shared)
+}
+
+// GetSharedConfig loads the shared configuration without local overrides
+func GetSharedConfig(dgitPath string) (*RepositoryConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dgitPath, "config"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config RepositoryConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &config, nil
+}
+
+// GetLocalConfig returns the raw overrides in config.local (empty when there are none)
+func GetLocalConfig(dgitPath string) (map[string]interface{}, error) {
+	return readConfigMap(filepath.Join(dgitPath, LocalConfigFile))
+}
+
+// UpdateSharedConfig writes the shared configuration as given, leaving config.local alone
+func UpdateSharedConfig(dgitPath string, config *RepositoryConfig) error {
+	configData, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(dgitPath, "config"), configData)
+}
+
+// SetConfigValue sets one dotted key ("compression.cache.main_cache_size") in the shared
+// config, or in config.local when local is true
+// The result must still parse as a repository config, so type mistakes are rejected.
+func SetConfigValue(dgitPath, key string, value interface{}, local bool) error {
+	path := strings.Split(key, ".")
+	target := filepath.Join(dgitPath, "config")
+	if local {
+		target = filepath.Join(dgitPath, LocalConfigFile)
+	}
+
+	values, err := readConfigMap(target)
+	if err != nil {
+		return err
+	}
+	setConfig(values, path, value)
+
+	effective, err := readConfigMap(filepath.Join(dgitPath, "config"))
+	if err != nil {
+		return err
+	}
+	overrides, err := GetLocalConfig(dgitPath)
+	if err != nil {
+		return err
+	}
+	if local {
+		overrides = values
+	} else {
+		effective = cloneConfig(values)
+	}
+	mergeConfig(effective, overrides)
+
+	// Check the file on its own too: a shared value may be masked by a local override
+	for _, candidate := range []configMap{values, effective} {
+		data, err := json.Marshal(candidate)
+		if err != nil {
+			return err
+		}
+		var config RepositoryConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+
+	if local {
+		return writeLocalConfig(dgitPath, values)
+	}
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(target, data)
+}
+
+// splitConfig divides an updated effective config between the two files
+// Only values that differ from the current effective config are written. A changed
+// value goes to config.local when that file already overrides it or it is a
+// machine-local key, and to the shared config otherwise.
+func splitConfig(dgitPath string, config *RepositoryConfig) (*RepositoryConfig, configMap, error) {
+	shared, err := GetSharedConfig(dgitPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	local, err := GetLocalConfig(dgitPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := GetConfig(dgitPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedMap, err := toConfigMap(shared)
+	if err != nil {
+		return nil, nil, err
+	}
+	effective, err := toConfigMap(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	updated, err := toConfigMap(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paths := leafPaths(effective, nil)
+	for _, path := range leafPaths(updated, nil) {
+		if _, ok := lookupConfig(effective, path); !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	for _, path := range paths {
+		before, hadBefore := lookupConfig(effective, path)
+		after, hasAfter := lookupConfig(updated, path)
+		if hadBefore == hasAfter && sameValue(before, after) {
+			continue
+		}
+
+		_, overridden := lookupConfig(local, path)
+		if !overridden && !isMachineLocal(path) {
+			if hasAfter {
+				setConfig(sharedMap, path, after)
+			} else {
+				deleteConfig(sharedMap, path)
+			}
+			continue
+		}
+
+		switch {
+		case hasAfter:
+			setConfig(local, path, after)
+		case hadBefore:
+			// Omitted means the zero value; record it so the shared value stays masked
+			setConfig(local, path, zeroLike(before))
+		}
+	}
+
+	data, err := json.Marshal(sharedMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result RepositoryConfig
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, nil, err
+	}
+	return &result, local, nil
+}
+
+// writeLocalConfig writes config.local, removing it when there are no overrides
+func writeLocalConfig(dgitPath string, local configMap) error {
+	path := filepath.Join(dgitPath, LocalConfigFile)
+	if len(local) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(local, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", LocalConfigFile, err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes to a temporary file and renames it so readers never see a partial write
+func writeFileAtomic(path string, data []byte) error {
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// isMachineLocal reports whether a config path belongs in config.local
+func isMachineLocal(path []string) bool {
+	joined := strings.Join(path, ".")
+	for _, key := range MachineLocalKeys {
+		if joined == key || strings.HasPrefix(joined, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// toConfigMap converts a typed config into its JSON object form
+func toConfigMap(config *RepositoryConfig) (configMap, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	values := configMap{}
+	return values, json.Unmarshal(data, &values)
+}
+
+// mergeConfig applies overrides onto base, recursing into nested objects
+func mergeConfig(base, overrides configMap) {
+	for key, value := range overrides {
+		if nested, ok := value.(configMap); ok {
+			if baseNested, ok := base[key].(configMap); ok {
+				mergeConfig(baseNested, nested)
+				continue
+			}
+			value = cloneConfig(nested)
+		}
+		base[key] = value
+	}
+}
+
+// cloneConfig deep-copies nested objects so merges never alias the source
+func cloneConfig(values configMap) configMap {
+	clone := make(configMap, len(values))
+	for key, value := range values {
+		if nested, ok := value.(configMap); ok {
+			value = cloneConfig(nested)
+		}
+		clone[key] = value
+	}
+	return clone
+}
+
+// leafPaths lists the paths of every non-object value
+func leafPaths(values configMap, prefix []string) [][]string {
+	var paths [][]string
+	for key, value := range values {
+		path := append(append([]string(nil), prefix...), key)
+		if nested, ok := value.(configMap); ok && len(nested) > 0 {
+			paths = append(paths, leafPaths(nested, path)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// lookupConfig returns the value at path
+func lookupConfig(values configMap, path []string) (interface{}, bool) {
+	for i, key := range path {
+		value, ok := values[key]
+		if !ok {
+			return nil, false
+		}
+		if i == len(path)-1 {
+			return value, true
+		}
+		if values, ok = value.(configMap); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// setConfig stores value at path, creating intermediate objects
+func setConfig(values configMap, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := values[key].(configMap)
+		if !ok {
+			nested = configMap{}
+			values[key] = nested
+		}
+		values = nested
+	}
+	values[path[len(path)-1]] = value
+}
+
+// deleteConfig removes the value at path
+func deleteConfig(values configMap, path []string) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := values[key].(configMap)
+		if !ok {
+			return
+		}
+		values = nested
+	}
+	delete(values, path[len(path)-1])
+}
+
+// sameValue compares decoded JSON values
+func sameValue(a, b interface{}) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+// zeroLike returns the JSON zero value of value's type
+func zeroLike(value interface{}) interface{} {
+	switch value.(type) {
+	case string:
+		return ""
+	case float64:
+		return 0
+	case bool:
+		return false
+	case []interface{}:
+		return []interface{}{}
+	case configMap:
+		return configMap{}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package iosched runs independent file writes concurrently with limits suited to the
+// disk they land on: SSDs take many parallel writers, while spinning disks and network
+// shares slow down when several large files stream at once, so large writes are
+// serialized there and only small files overlap.
+package iosched
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Medium is the kind of storage a path lives on
+type Medium string
+
+// Storage media
+const (
+	MediumSSD     Medium = "ssd"
+	MediumHDD     Medium = "hdd"
+	MediumNetwork Medium = "network"
+	MediumUnknown Medium = "unknown"
+)
+
+// LargeFile is the size above which a write counts against the medium's large-write slots
+const LargeFile = 8 * 1024 * 1024
+
+// Detect reports the medium holding path, or MediumUnknown when it cannot be told
+func Detect(path string) Medium {
+	return detect(path)
+}
+
+// ParseMedium maps a configured profile to a medium; "auto" and "" detect it from path
+func ParseMedium(profile, path string) Medium {
+	switch Medium(profile) {
+	case MediumSSD, MediumHDD, MediumNetwork:
+		return Medium(profile)
+	}
+	return Detect(path)
+}
+
+// Job is one independent file write
+type Job struct {
+	Name string
+	Size int64
+	Run  func() error
+}
+
+// Scheduler runs jobs on a pool of workers
+type Scheduler struct {
+	Medium     Medium
+	Workers    int // Concurrent jobs
+	LargeSlots int // Concurrent jobs larger than LargeFile
+}
+
+// NewScheduler creates a scheduler for medium; workers overrides the medium's default when positive
+func NewScheduler(medium Medium, workers int) *Scheduler {
+	s := &Scheduler{Medium: medium}
+	switch medium {
+	case MediumSSD:
+		s.Workers = min(max(runtime.NumCPU(), 2), 8)
+		s.LargeSlots = s.Workers
+	case MediumHDD:
+		// One streaming write at a time keeps the heads from seeking between files
+		s.Workers, s.LargeSlots = 2, 1
+	case MediumNetwork:
+		// Latency-bound: overlapping requests helps until the link saturates
+		s.Workers, s.LargeSlots = 4, 2
+	default:
+		s.Workers, s.LargeSlots = 4, 2
+	}
+	if workers > 0 {
+		s.Workers = workers
+		s.LargeSlots = min(s.LargeSlots, workers)
+	}
+	return s
+}
+
+// Run executes every job and calls done after each one finishes; calls to done are
+// serialized, so it may update shared results without locking
+// Large jobs start first on SSDs so a big file does not finish last on its own; other
+// media keep the given order, which follows the layout of the source archive.
+func (s *Scheduler) Run(jobs []Job, done func(Job, error)) {
+	if s.Medium == MediumSSD {
+		sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].Size > jobs[j].Size })
+	}
+
+	workers := max(s.Workers, 1)
+	large := make(chan struct{}, max(s.LargeSlots, 1))
+	queue := make(chan Job)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(workers, len(jobs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if job.Size > LargeFile {
+					large <- struct{}{}
+				}
+				err := job.Run()
+				if job.Size > LargeFile {
+					<-large
+				}
+				mu.Lock()
+				done(job, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,28 @@
+package iosched
+
+import "syscall"
+
+// networkFilesystems are the statfs type names of network filesystems
+var networkFilesystems = map[string]bool{
+	"nfs": true, "smbfs": true, "afpfs": true, "webdav": true, "cifs": true, "macfuse": true,
+}
+
+// detect recognizes network shares; local disks on current Macs are almost always SSDs,
+// but spinning externals cannot be told apart without IOKit, so they stay unknown
+func detect(path string) Medium {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return MediumUnknown
+	}
+	name := make([]byte, 0, len(fs.Fstypename))
+	for _, c := range fs.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	if networkFilesystems[string(name)] {
+		return MediumNetwork
+	}
+	return MediumUnknown
+}
@@ -0,0 +1,48 @@
+package iosched
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// networkFilesystems are statfs magic numbers of network and FUSE filesystems
+var networkFilesystems = map[uint32]bool{
+	0x6969:     true, // NFS
+	0x517B:     true, // SMB
+	0xFF534D42: true, // CIFS
+	0xFE534D42: true, // SMB2
+	0x65735546: true, // FUSE (sshfs, rclone, NAS clients)
+	0x00C36400: true, // Ceph
+	0x5346414F: true, // AFS
+	0x01021997: true, // 9P
+}
+
+// detect checks the filesystem type, then whether the block device is rotational
+func detect(path string) Medium {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err == nil && networkFilesystems[uint32(fs.Type)] {
+		return MediumNetwork
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return MediumUnknown
+	}
+	dev := uint64(st.Dev)
+	major := (dev>>8)&0xfff | (dev>>32)&^uint64(0xfff)
+	minor := dev&0xff | (dev>>12)&^uint64(0xff)
+
+	// Partitions have no queue of their own; their parent disk does
+	device := fmt.Sprintf("/sys/dev/block/%d:%d", major, minor)
+	for _, queue := range []string{device + "/queue/rotational", device + "/../queue/rotational"} {
+		if data, err := os.ReadFile(queue); err == nil {
+			if strings.TrimSpace(string(data)) == "1" {
+				return MediumHDD
+			}
+			return MediumSSD
+		}
+	}
+	return MediumUnknown
+}
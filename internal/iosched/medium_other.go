@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package iosched
+
+// detect is unsupported on this platform
+func detect(path string) Medium {
+	return MediumUnknown
+}
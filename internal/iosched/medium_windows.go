@@ -0,0 +1,33 @@
+package iosched
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// driveRemote is GetDriveType's result for network drives
+const driveRemote = 4
+
+var getDriveType = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDriveTypeW")
+
+// detect recognizes UNC paths and mapped network drives
+func detect(path string) Medium {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return MediumUnknown
+	}
+	volume := filepath.VolumeName(abs)
+	if strings.HasPrefix(volume, `\\`) {
+		return MediumNetwork
+	}
+	root, err := syscall.UTF16PtrFromString(volume + `\`)
+	if err != nil {
+		return MediumUnknown
+	}
+	if kind, _, _ := getDriveType.Call(uintptr(unsafe.Pointer(root))); kind == driveRemote {
+		return MediumNetwork
+	}
+	return MediumUnknown
+}
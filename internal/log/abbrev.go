@@ -0,0 +1,49 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+
+	initializer "dgit/internal/init"
+)
+
+// Commits store the full SHA256 of their record; older commits hold a 12-character prefix.
+// Hashes are abbreviated only for display, and lookups accept any unique prefix.
+
+// DefaultHashLength is how many hex characters of a commit hash are shown by default
+const DefaultHashLength = 12
+
+// MinPrefixLength is the shortest hash prefix a lookup accepts, so version-like
+// arguments such as "12" are never taken for a hash
+const MinPrefixLength = 4
+
+// AmbiguousHashError reports a hash prefix that matches more than one commit
+type AmbiguousHashError struct {
+	Prefix   string
+	Versions []int
+}
+
+func (e *AmbiguousHashError) Error() string {
+	versions := make([]string, len(e.Versions))
+	for i, version := range e.Versions {
+		versions[i] = fmt.Sprintf("v%d", version)
+	}
+	return fmt.Sprintf("hash prefix '%s' is ambiguous: matches %s", e.Prefix, strings.Join(versions, ", "))
+}
+
+// HashLength returns the configured display length of commit hashes (display.hash_length)
+func HashLength(dgitDir string) int {
+	config, err := initializer.GetConfig(dgitDir)
+	if err != nil || config.Display.HashLength == 0 {
+		return DefaultHashLength
+	}
+	return min(max(config.Display.HashLength, MinPrefixLength), 64)
+}
+
+// AbbreviateHash shortens hash to length characters for display
+func AbbreviateHash(hash string, length int) string {
+	if length > 0 && len(hash) > length {
+		return hash[:length]
+	}
+	return hash
+}
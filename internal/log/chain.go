@@ -0,0 +1,158 @@
+package log
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HashChainVersion marks commits whose hash is derived from their own content
+// Such a commit's hash covers its parent's hash, so rewriting any earlier commit
+// changes every hash after it and no longer matches HEAD.
+const HashChainVersion = 1
+
+// ChainHash returns the SHA256 of a commit record's canonical JSON, every field except
+// "hash" with object keys sorted, so the same content always yields the same hash
+func ChainHash(record []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(record))
+	decoder.UseNumber() // Keep numbers exactly as written
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return "", fmt.Errorf("invalid commit record: %w", err)
+	}
+	delete(fields, "hash")
+
+	canonical, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(canonical)), nil
+}
+
+// ChainIssue is one integrity problem found in the history
+type ChainIssue struct {
+	Version int    `json:"version"`
+	Problem string `json:"problem"`
+}
+
+// ChainReport summarizes a history verification
+type ChainReport struct {
+	Commits  int          `json:"commits"`
+	Verified int          `json:"verified"` // Commits whose content hash was recomputed and matched
+	Legacy   int          `json:"legacy"`   // Commits made before hash chaining; only linkage is checked
+	Head     string       `json:"head"`
+	Issues   []ChainIssue `json:"issues,omitempty"`
+
+	// ClockSkew lists commits timestamped before their parent; a warning, not an issue
+	ClockSkew []ClockSkew `json:"clock_skew,omitempty"`
+}
+
+// OK reports whether no integrity problems were found
+func (r *ChainReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// chainRecord holds the fields verification needs from a commit record
+type chainRecord struct {
+	Hash       string `json:"hash"`
+	Version    int    `json:"version"`
+	ParentHash string `json:"parent_hash"`
+	HashChain  int    `json:"hash_chain"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// VerifyHistory checks every commit record: each hash must match its content, each
+// commit must name the previous one as its parent, and HEAD must name the latest
+func (lm *LogManager) VerifyHistory() (*ChainReport, error) {
+	records, err := lm.metadata.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit records: %w", err)
+	}
+
+	versions := make([]int, 0, len(records))
+	for version := range records {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	report := &ChainReport{Commits: len(versions)}
+	issue := func(version int, format string, a ...interface{}) {
+		report.Issues = append(report.Issues, ChainIssue{Version: version, Problem: fmt.Sprintf(format, a...)})
+	}
+
+	previousHash := ""
+	var previous *chainRecord
+	chained := false
+	expected := 1
+	for _, version := range versions {
+		if version != expected {
+			issue(version, "commit records v%d-v%d are missing", expected, version-1)
+		}
+		expected = version + 1
+
+		var record chainRecord
+		if err := json.Unmarshal(records[version], &record); err != nil {
+			issue(version, "unreadable commit record: %v", err)
+			previousHash = ""
+			previous = nil
+			continue
+		}
+		if record.Version != version {
+			issue(version, "record claims to be v%d", record.Version)
+		}
+		if record.ParentHash != previousHash {
+			issue(version, "parent is %s, but the previous commit is %s", quoteHash(record.ParentHash), quoteHash(previousHash))
+		}
+
+		switch {
+		case record.HashChain >= HashChainVersion:
+			chained = true
+			full, err := ChainHash(records[version])
+			if err != nil {
+				issue(version, "%v", err)
+			} else if len(record.Hash) < 12 || !strings.HasPrefix(full, record.Hash) {
+				issue(version, "content does not match hash %s (record modified after commit)", quoteHash(record.Hash))
+			} else {
+				report.Verified++
+			}
+		case chained:
+			issue(version, "hash chaining is missing after chained commits (record modified after commit)")
+		default:
+			report.Legacy++
+		}
+		if previous != nil && record.Timestamp.Before(previous.Timestamp) {
+			report.ClockSkew = append(report.ClockSkew, ClockSkew{
+				Version:         version,
+				Timestamp:       record.Timestamp,
+				Parent:          previous.Version,
+				ParentTimestamp: previous.Timestamp,
+			})
+		}
+		previousHash = record.Hash
+		previous = &record
+	}
+
+	if data, err := os.ReadFile(filepath.Join(lm.DgitDir, "HEAD")); err == nil {
+		report.Head = strings.TrimSpace(string(data))
+	}
+	if len(versions) > 0 && report.Head != previousHash {
+		issue(versions[len(versions)-1], "HEAD is %s, but the latest commit is %s", quoteHash(report.Head), quoteHash(previousHash))
+	}
+
+	return report, nil
+}
+
+// quoteHash formats a hash for issue messages, naming empty ones
+func quoteHash(hash string) string {
+	if hash == "" {
+		return "(none)"
+	}
+	return hash
+}
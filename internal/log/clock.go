@@ -0,0 +1,93 @@
+package log
+
+import (
+	"sort"
+	"time"
+)
+
+// Commit times are stored in UTC, so a record's bytes, and with them its hash, do not
+// depend on the timezone of the machine that made it; Zone keeps the author's UTC offset
+// so their local time can still be shown. Records written before this kept the author's
+// local time with its offset and are read unchanged. History is ordered by version,
+// never by time: clocks on a team's machines disagree, so a commit can carry a timestamp
+// earlier than its parent's. FindClockSkew reports those, and EffectiveTimes gives the
+// times date filters compare against.
+
+// Now returns the current time the way commit records store it
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+// ZoneOffset formats t's UTC offset as "+09:00"
+func ZoneOffset(t time.Time) string {
+	return t.Format("-07:00")
+}
+
+// AuthorTime returns the commit time in the author's timezone
+func (c *Commit) AuthorTime() time.Time {
+	if c.Zone == "" {
+		return c.Timestamp // Older records carry the author's offset in the timestamp itself
+	}
+	zoned, err := time.Parse("-07:00", c.Zone)
+	if err != nil {
+		return c.Timestamp
+	}
+	_, offset := zoned.Zone()
+	return c.Timestamp.In(time.FixedZone(c.Zone, offset))
+}
+
+// ClockSkew is a commit timestamped before its parent, made on a machine whose clock
+// was behind
+type ClockSkew struct {
+	Version         int       `json:"version"`
+	Timestamp       time.Time `json:"timestamp"`
+	Parent          int       `json:"parent"`
+	ParentTimestamp time.Time `json:"parent_timestamp"`
+}
+
+// Behind is how far the commit's timestamp precedes its parent's
+func (s ClockSkew) Behind() time.Duration {
+	return s.ParentTimestamp.Sub(s.Timestamp)
+}
+
+// FindClockSkew returns the commits timestamped before their parent, oldest first
+func FindClockSkew(commits []*Commit) []ClockSkew {
+	var skews []ClockSkew
+	ordered := byVersion(commits)
+	for i := 1; i < len(ordered); i++ {
+		parent, commit := ordered[i-1], ordered[i]
+		if commit.Timestamp.Before(parent.Timestamp) {
+			skews = append(skews, ClockSkew{
+				Version:         commit.Version,
+				Timestamp:       commit.Timestamp,
+				Parent:          parent.Version,
+				ParentTimestamp: parent.Timestamp,
+			})
+		}
+	}
+	return skews
+}
+
+// EffectiveTimes maps each version to the later of its own timestamp and its parent's
+// effective time, so filtering history by date always keeps a commit whose parent was
+// kept, however far behind its author's clock was
+func EffectiveTimes(commits []*Commit) map[int]time.Time {
+	times := make(map[int]time.Time, len(commits))
+	var latest time.Time
+	for _, commit := range byVersion(commits) {
+		if commit.Timestamp.After(latest) {
+			latest = commit.Timestamp
+		}
+		times[commit.Version] = latest
+	}
+	return times
+}
+
+// byVersion returns commits sorted oldest first, leaving the argument unchanged
+func byVersion(commits []*Commit) []*Commit {
+	ordered := append([]*Commit(nil), commits...)
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].Version < ordered[j].Version
+	})
+	return ordered
+}
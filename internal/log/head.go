@@ -0,0 +1,251 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HEAD is replaced atomically (temporary file, fsync, rename) so a crash leaves either the
+// old or the new value, never an empty file. Every value it takes is also kept in a small
+// ring file, and a HEAD that is still missing or unreadable is pointed back at the newest
+// commit whose record verifies. Writers take HEAD.lock, so a repair never races a commit
+// that is replacing HEAD.
+//
+//	.dgit/HEAD          current commit hash
+//	.dgit/HEAD.ring     last HeadRingSize values of HEAD, newest last
+//	.dgit/HEAD.lock     held while HEAD and the ring are written
+
+// HeadRingSize is how many past HEAD values the ring file keeps
+const HeadRingSize = 32
+
+const (
+	headLockWait  = 5 * time.Second  // How long a writer waits for HEAD.lock
+	headLockStale = 30 * time.Second // A lock this old was left by a crashed writer
+)
+
+// HeadEntry is one value HEAD took
+type HeadEntry struct {
+	Hash    string    `json:"hash"`
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Reason  string    `json:"reason"`
+}
+
+// HeadRecovery describes a HEAD that was repaired
+type HeadRecovery struct {
+	Previous string `json:"previous"` // What HEAD held, "" when it was empty or missing
+	Hash     string `json:"hash"`
+	Version  int    `json:"version"`
+	Source   string `json:"source"` // "commits" (newest valid record) or "ring" (last recorded HEAD)
+}
+
+// headFile is the path of HEAD
+func headFile(dgitDir string) string {
+	return filepath.Join(dgitDir, "HEAD")
+}
+
+// headRingFile is the path of the HEAD ring
+func headRingFile(dgitDir string) string {
+	return filepath.Join(dgitDir, "HEAD.ring")
+}
+
+// lockHead takes HEAD.lock, waiting for another writer to finish; the returned function
+// releases it
+func lockHead(dgitDir string) (func(), error) {
+	path := filepath.Join(dgitDir, "HEAD.lock")
+	deadline := time.Now().Add(headLockWait)
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock HEAD: %w", err)
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > headLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("HEAD is locked by another dgit process (%s)", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// WriteHead points HEAD at a commit and records the value in the ring
+func WriteHead(dgitDir, hash string, version int, reason string) error {
+	unlock, err := lockHead(dgitDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeHead(dgitDir, hash, version, reason)
+}
+
+// writeHead is WriteHead for a caller holding HEAD.lock
+func writeHead(dgitDir, hash string, version int, reason string) error {
+	if err := syncWrite(headFile(dgitDir), []byte(hash)); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+
+	entries, _ := HeadHistory(dgitDir)
+	entries = append(entries, HeadEntry{Hash: hash, Version: version, Time: time.Now().UTC(), Reason: reason})
+	if len(entries) > HeadRingSize {
+		entries = entries[len(entries)-HeadRingSize:]
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := syncWrite(headRingFile(dgitDir), data); err != nil {
+		return fmt.Errorf("failed to record HEAD history: %w", err)
+	}
+	return nil
+}
+
+// HeadHistory returns the recorded HEAD values, oldest first
+func HeadHistory(dgitDir string) ([]HeadEntry, error) {
+	data, err := os.ReadFile(headRingFile(dgitDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []HeadEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("corrupt HEAD history: %w", err)
+	}
+	return entries, nil
+}
+
+// CheckHead reports a HEAD that is missing, empty or does not name a commit while
+// commits exist, with the commit RecoverHead would point it at; it changes nothing and
+// returns nil when HEAD is fine
+func (lm *LogManager) CheckHead() (*HeadRecovery, error) {
+	data, err := os.ReadFile(headFile(lm.DgitDir))
+	previous := strings.TrimSpace(string(data))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if validHashSyntax(previous) {
+		return nil, nil
+	}
+	if lm.GetCurrentVersion() == 0 {
+		return nil, nil
+	}
+
+	recovery, err := lm.headCandidate()
+	if err != nil {
+		return nil, err
+	}
+	recovery.Previous = previous
+	return recovery, nil
+}
+
+// RecoverHead repairs what CheckHead reports under HEAD.lock, so a commit replacing
+// HEAD at the same time is never overwritten; it returns nil when HEAD was fine
+func (lm *LogManager) RecoverHead() (*HeadRecovery, error) {
+	if recovery, err := lm.CheckHead(); recovery == nil || err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockHead(lm.DgitDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	// Another writer may have fixed HEAD while this one waited
+	recovery, err := lm.CheckHead()
+	if recovery == nil || err != nil {
+		return nil, err
+	}
+	if err := writeHead(lm.DgitDir, recovery.Hash, recovery.Version, "recovered"); err != nil {
+		return nil, err
+	}
+	return recovery, nil
+}
+
+// headCandidate finds the newest commit whose record verifies, falling back to the
+// newest HEAD in the ring that still names a commit
+func (lm *LogManager) headCandidate() (*HeadRecovery, error) {
+	records, err := lm.metadata.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit records: %w", err)
+	}
+	versions := make([]int, 0, len(records))
+	for version := range records {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	for _, version := range versions {
+		var record chainRecord
+		if json.Unmarshal(records[version], &record) != nil || record.Version != version || record.Hash == "" {
+			continue
+		}
+		if record.HashChain >= HashChainVersion {
+			full, err := ChainHash(records[version])
+			if err != nil || !strings.HasPrefix(full, record.Hash) {
+				continue
+			}
+		}
+		return &HeadRecovery{Hash: record.Hash, Version: version, Source: "commits"}, nil
+	}
+
+	entries, _ := HeadHistory(lm.DgitDir)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if commit, err := lm.GetCommitByHash(entries[i].Hash); err == nil {
+			return &HeadRecovery{Hash: commit.Hash, Version: commit.Version, Source: "ring"}, nil
+		}
+	}
+	return nil, fmt.Errorf("no valid commit to point HEAD at")
+}
+
+// validHashSyntax reports whether s looks like a commit hash
+func validHashSyntax(s string) bool {
+	if len(s) < 12 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// syncWrite replaces path with data so readers and crashes see the old or the new content
+func syncWrite(path string, data []byte) error {
+	tempPath := path + ".tmp"
+	file, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
@@ -6,9 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
+
+	"dgit/internal/environment"
+	initializer "dgit/internal/init"
+	"dgit/internal/metapack"
 )
 
 // CompressionResult contains comprehensive compression operation results
@@ -26,6 +29,8 @@ type CompressionResult struct {
 	CompressionTime  float64 `json:"compression_time_ms"` // Milliseconds - KEY METRIC for performance analysis
 	CacheLevel       string  `json:"cache_level"`         // "versions", "cache" - cache tier utilization
 	SpeedImprovement float64 `json:"speed_improvement"`   // Multiplier vs traditional methods
+
+	DeltaSkipped string `json:"delta_skipped,omitempty"` // Why bsdiff was skipped or abandoned (resource and time guard)
 }
 
 // Commit represents a single commit with enhanced compression information
@@ -34,6 +39,7 @@ type Commit struct {
 	Hash       string                 `json:"hash"`
 	Message    string                 `json:"message"`
 	Timestamp  time.Time              `json:"timestamp"`
+	Zone       string                 `json:"zone,omitempty"` // Author's UTC offset ("+09:00"); Timestamp is UTC
 	Author     string                 `json:"author"`
 	FilesCount int                    `json:"files_count"`
 	Version    int                    `json:"version"`
@@ -43,56 +49,71 @@ type Commit struct {
 	// Enhanced compression information for performance analysis
 	SnapshotZip     string             `json:"snapshot_zip,omitempty"`     // Legacy field for backward compatibility
 	CompressionInfo *CompressionResult `json:"compression_info,omitempty"` // Compression metrics and data
+
+	// Files deliberately deleted in this version ('dgit rm')
+	Removed []string `json:"removed,omitempty"`
+
+	// Files moved in this version, new path -> old path ('dgit mv')
+	Renamed map[string]string `json:"renamed,omitempty"`
+
+	// HashChain is set when Hash is derived from the record's content (see ChainHash)
+	HashChain int `json:"hash_chain,omitempty"`
+
+	// Tool environment the commit was made in, when the repository records it
+	Environment *environment.Environment `json:"environment,omitempty"`
 }
 
 // LogManager handles commit history operations with simplified storage system
 // Updated to work with simplified 2-tier storage system for optimal performance
 type LogManager struct {
 	DgitDir    string
+	StorageDir string // Object storage root (.dgit/ unless relocated)
 	ObjectsDir string
 	// Simplified Storage System Integration
 	VersionsDir string // 메인 버전 저장소 (.dgit/versions/)
 	CommitsDir  string // 커밋 메타데이터 (.dgit/commits/)
 	CacheDir    string // 단일 캐시 디렉토리 (.dgit/cache/)
+
+	metadata *metapack.Store // Loose and packed commit records
 }
 
 // NewLogManager creates a new log manager with simplified storage system
 // Initializes with simplified 2-tier storage system integration for optimal performance
 func NewLogManager(dgitDir string) *LogManager {
+	storageDir := initializer.GetStorageDir(dgitDir)
 	return &LogManager{
 		DgitDir:     dgitDir,
-		ObjectsDir:  filepath.Join(dgitDir, "objects"),
+		StorageDir:  storageDir,
+		ObjectsDir:  filepath.Join(storageDir, "objects"),
 		VersionsDir: filepath.Join(dgitDir, "versions"),
 		CommitsDir:  filepath.Join(dgitDir, "commits"),
 		CacheDir:    filepath.Join(dgitDir, "cache"),
+		metadata:    metapack.NewStore(filepath.Join(dgitDir, "commits")),
 	}
 }
 
 // GetCommitHistory returns complete commit history sorted by timestamp (newest first)
 // Efficiently loads all commits with compression information
 func (lm *LogManager) GetCommitHistory() ([]*Commit, error) {
-	entries, err := os.ReadDir(lm.CommitsDir)
+	records, err := lm.metadata.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read commits directory: %w", err)
+		return nil, err
 	}
 
 	var commits []*Commit
-	// Process all commit metadata files
-	for _, entry := range entries {
-		if strings.HasPrefix(entry.Name(), "v") && strings.HasSuffix(entry.Name(), ".json") {
-			commitPath := filepath.Join(lm.CommitsDir, entry.Name())
-			commit, err := lm.loadCommit(commitPath)
-			if err != nil {
-				// Skip failed commits but continue processing others
-				continue
-			}
-			commits = append(commits, commit)
+	// Process all commit records (loose JSON and packed)
+	for _, data := range records {
+		commit, err := lm.parseCommit(data)
+		if err != nil {
+			// Skip failed commits but continue processing others
+			continue
 		}
+		commits = append(commits, commit)
 	}
 
-	// Sort commits by timestamp (newest first) for intuitive display
+	// Newest first; versions, not timestamps, since machines' clocks disagree
 	sort.Slice(commits, func(i, j int) bool {
-		return commits[i].Timestamp.After(commits[j].Timestamp)
+		return commits[i].Version > commits[j].Version
 	})
 
 	return commits, nil
@@ -101,55 +122,111 @@ func (lm *LogManager) GetCommitHistory() ([]*Commit, error) {
 // GetCommit returns a specific commit by version number
 // Efficiently loads individual commit with all metadata
 func (lm *LogManager) GetCommit(version int) (*Commit, error) {
-	commitPath := filepath.Join(lm.CommitsDir, fmt.Sprintf("v%d.json", version))
-	return lm.loadCommit(commitPath)
+	data, err := lm.metadata.ReadRecord(version)
+	if err != nil {
+		return nil, err
+	}
+	return lm.parseCommit(data)
 }
 
-// GetCommitByHash retrieves a commit by its full or short hash
-// Supports partial hash matching for user convenience
+// GetCommitByHash retrieves a commit by its full hash or a unique prefix of it
+// Prefixes shorter than MinPrefixLength never match; a prefix shared by several
+// commits returns an *AmbiguousHashError
 func (lm *LogManager) GetCommitByHash(hash string) (*Commit, error) {
-	entries, err := os.ReadDir(lm.CommitsDir)
+	records, err := lm.metadata.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read commits directory: %w", err)
+		return nil, err
 	}
 
-	// Search through all commit files for hash match
-	for _, entry := range entries {
-		if strings.HasPrefix(entry.Name(), "v") && strings.HasSuffix(entry.Name(), ".json") {
-			commitPath := filepath.Join(lm.CommitsDir, entry.Name())
-			commit, err := lm.loadCommit(commitPath)
-			if err != nil {
-				continue
-			}
-			// Support both full and partial hash matching
-			if strings.HasPrefix(commit.Hash, hash) {
-				return commit, nil
-			}
+	// Search through all commit records for hash match
+	hash = strings.ToLower(hash)
+	var matches []*Commit
+	for _, data := range records {
+		commit, err := lm.parseCommit(data)
+		if err != nil || commit.Hash == "" {
+			continue
 		}
+		if commit.Hash == hash {
+			return commit, nil
+		}
+		if len(hash) >= MinPrefixLength && strings.HasPrefix(commit.Hash, hash) {
+			matches = append(matches, commit)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("commit with hash '%s' not found", hash)
+	case 1:
+		return matches[0], nil
+	}
+	ambiguous := &AmbiguousHashError{Prefix: hash}
+	for _, commit := range matches {
+		ambiguous.Versions = append(ambiguous.Versions, commit.Version)
 	}
-	return nil, fmt.Errorf("commit with hash '%s' not found", hash)
+	sort.Ints(ambiguous.Versions)
+	return nil, ambiguous
 }
 
-// GetCurrentVersion returns the current version number by scanning metadata files
+// GetCurrentVersion returns the current version number from loose and packed metadata
 // Efficiently determines the latest version for next commit numbering
 func (lm *LogManager) GetCurrentVersion() int {
-	entries, err := os.ReadDir(lm.CommitsDir)
+	return lm.metadata.LatestVersion()
+}
+
+// GetTrackedFiles returns the paths recorded in the latest commit, sorted
+func (lm *LogManager) GetTrackedFiles() ([]string, error) {
+	latest := lm.GetCurrentVersion()
+	if latest == 0 {
+		return nil, nil
+	}
+	commit, err := lm.GetCommit(latest)
 	if err != nil {
-		return 0
+		return nil, err
 	}
 
-	maxVersion := 0
-	// Find the highest version number in commit metadata files
-	for _, entry := range entries {
-		if strings.HasPrefix(entry.Name(), "v") && strings.HasSuffix(entry.Name(), ".json") {
-			versionStr := strings.TrimPrefix(strings.TrimSuffix(entry.Name(), ".json"), "v")
-			if version, err := strconv.Atoi(versionStr); err == nil && version > maxVersion {
-				maxVersion = version
-			}
+	paths := make([]string, 0, len(commit.Metadata))
+	for path := range commit.Metadata {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// FileHistory returns the commits that touched path, newest first
+// Renames recorded by 'dgit mv' are followed, so older commits match the file's earlier path
+func (lm *LogManager) FileHistory(path string) ([]*Commit, error) {
+	commits, err := lm.GetCommitHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var history []*Commit
+	for _, commit := range commits {
+		if commit.Touches(path) {
+			history = append(history, commit)
+		}
+		if oldPath, ok := commit.Renamed[path]; ok {
+			path = oldPath
 		}
 	}
+	return history, nil
+}
 
-	return maxVersion
+// Touches reports whether a commit stored, removed or moved path
+func (c *Commit) Touches(path string) bool {
+	if _, ok := c.Metadata[path]; ok {
+		return true
+	}
+	if _, ok := c.Renamed[path]; ok {
+		return true
+	}
+	for _, removed := range c.Removed {
+		if removed == path {
+			return true
+		}
+	}
+	return false
 }
 
 // GenerateCommitSummary generates human-readable summary with metrics
@@ -526,14 +603,9 @@ type CacheUtilization struct {
 	TotalCacheSize int64 `json:"total_cache_size"` // Total cached data size
 }
 
-// loadCommit loads a commit from a JSON metadata file
+// parseCommit decodes a commit metadata record
 // Core function for reading commit information with error handling
-func (lm *LogManager) loadCommit(path string) (*Commit, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
+func (lm *LogManager) parseCommit(data []byte) (*Commit, error) {
 	var commit Commit
 	if err := json.Unmarshal(data, &commit); err != nil {
 		return nil, err
@@ -541,3 +613,110 @@ func (lm *LogManager) loadCommit(path string) (*Commit, error) {
 
 	return &commit, nil
 }
+
+// PathStorage attributes stored object bytes to a single file path across history
+type PathStorage struct {
+	Path           string `json:"path"`
+	StoredBytes    int64  `json:"stored_bytes"`    // Share of snapshot/delta objects attributed to this path
+	OriginalBytes  int64  `json:"original_bytes"`  // Sum of uncompressed sizes across all versions
+	Versions       []int  `json:"versions"`        // Versions containing this path
+	LargestVersion int    `json:"largest_version"` // Version contributing the most stored bytes
+	LargestBytes   int64  `json:"largest_bytes"`
+}
+
+// StorageAttribution summarizes which paths account for repository size
+type StorageAttribution struct {
+	Paths        []*PathStorage `json:"paths"` // Sorted by StoredBytes, largest first
+	TotalStored  int64          `json:"total_stored"`
+	TotalCommits int            `json:"total_commits"`
+}
+
+// GetStorageAttribution splits each commit's stored object size across its files
+// Objects hold several files, so each file gets a share proportional to its original size
+func (lm *LogManager) GetStorageAttribution() (*StorageAttribution, error) {
+	commits, err := lm.GetCommitHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	attribution := &StorageAttribution{TotalCommits: len(commits)}
+	byPath := make(map[string]*PathStorage)
+
+	for _, commit := range commits {
+		stored := lm.getStoredObjectSize(commit)
+		attribution.TotalStored += stored
+
+		// Collect original sizes for proportional attribution
+		sizes := make(map[string]int64)
+		var totalOriginal int64
+		for path, metadata := range commit.Metadata {
+			var size int64
+			if metaMap, ok := metadata.(map[string]interface{}); ok {
+				if s, ok := metaMap["size"].(float64); ok {
+					size = int64(s)
+				}
+			}
+			sizes[path] = size
+			totalOriginal += size
+		}
+
+		for path, size := range sizes {
+			var share int64
+			if totalOriginal > 0 {
+				share = int64(float64(stored) * float64(size) / float64(totalOriginal))
+			} else if len(sizes) > 0 {
+				share = stored / int64(len(sizes))
+			}
+
+			entry, exists := byPath[path]
+			if !exists {
+				entry = &PathStorage{Path: path}
+				byPath[path] = entry
+			}
+			entry.StoredBytes += share
+			entry.OriginalBytes += size
+			entry.Versions = append(entry.Versions, commit.Version)
+			if share > entry.LargestBytes {
+				entry.LargestBytes = share
+				entry.LargestVersion = commit.Version
+			}
+		}
+	}
+
+	for _, entry := range byPath {
+		sort.Ints(entry.Versions)
+		attribution.Paths = append(attribution.Paths, entry)
+	}
+	sort.Slice(attribution.Paths, func(i, j int) bool {
+		if attribution.Paths[i].StoredBytes == attribution.Paths[j].StoredBytes {
+			return attribution.Paths[i].Path < attribution.Paths[j].Path
+		}
+		return attribution.Paths[i].StoredBytes > attribution.Paths[j].StoredBytes
+	})
+
+	return attribution, nil
+}
+
+// getStoredObjectSize returns the on-disk size of a commit's object, falling back to recorded size
+func (lm *LogManager) getStoredObjectSize(commit *Commit) int64 {
+	if commit.CompressionInfo != nil && commit.CompressionInfo.OutputFile != "" {
+		for _, dir := range []string{
+			filepath.Join(lm.StorageDir, "snapshots"),
+			filepath.Join(lm.StorageDir, "deltas"),
+			lm.CacheDir,
+			lm.ObjectsDir,
+		} {
+			if info, err := os.Stat(filepath.Join(dir, commit.CompressionInfo.OutputFile)); err == nil {
+				return info.Size()
+			}
+		}
+		return commit.CompressionInfo.CompressedSize
+	}
+
+	if commit.SnapshotZip != "" {
+		if info, err := os.Stat(filepath.Join(lm.ObjectsDir, commit.SnapshotZip)); err == nil {
+			return info.Size()
+		}
+	}
+	return 0
+}
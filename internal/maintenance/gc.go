@@ -0,0 +1,217 @@
+package maintenance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/generation"
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/restore"
+)
+
+// Garbage collection is mark and sweep. Every commit is a root: hidden, tagged and pinned
+// versions are all still restorable. The mark phase records each version's own stored
+// objects under every name a tier may hold them by, plus everything a restore of it
+// reads (delta bases, rebased snapshots); a version whose objects cannot be resolved
+// stops the pass before anything is deleted. The sweep lists every object file in the
+// storage tiers and the restore cache that no version marked.
+
+// KindUnreachable is an object no commit references, removed by garbage collection
+const KindUnreachable = "unreachable"
+
+// DefaultGCGrace is how long an unreachable object is kept after it was last written,
+// since a commit or restore in progress writes objects before anything references them
+const DefaultGCGrace = time.Hour
+
+// GCOptions controls a garbage collection pass
+type GCOptions struct {
+	DryRun bool          // List unreachable objects without removing them
+	Grace  time.Duration // Keep unreachable objects written more recently than this
+}
+
+// GCResult reports a garbage collection pass
+type GCResult struct {
+	Commits     int           `json:"commits"`
+	Scanned     int           `json:"scanned"`   // Object files found in storage
+	Reachable   int           `json:"reachable"` // Object files some version needs
+	Unreachable []*Suggestion `json:"unreachable"`
+	Recent      []*Suggestion `json:"recent,omitempty"` // Unreachable but inside the grace period; kept
+	Removed     int           `json:"removed"`
+	Reclaimed   int64         `json:"reclaimed"`
+	Failed      []string      `json:"failed,omitempty"`
+	DryRun      bool          `json:"dry_run"`
+}
+
+// UnreadableHistoryError stops garbage collection when a version's objects cannot be
+// marked, since anything it needs would look unreachable
+type UnreadableHistoryError struct {
+	Version int
+	Err     error
+}
+
+func (e *UnreadableHistoryError) Error() string {
+	return fmt.Sprintf("cannot mark objects of v%d: %v; nothing was removed", e.Version, e.Err)
+}
+
+func (e *UnreadableHistoryError) Unwrap() error {
+	return e.Err
+}
+
+// versionedObject matches object names that start with their version (v3.lz4, v4_from_v3.bsdiff)
+var versionedObject = regexp.MustCompile(`^v(\d+)[._]`)
+
+// GC marks every object reachable from a commit and removes the rest
+func (mm *MaintenanceManager) GC(opts GCOptions) (*GCResult, error) {
+	result := &GCResult{DryRun: opts.DryRun, Unreachable: []*Suggestion{}}
+
+	marked, commits, err := mm.markReachable()
+	if err != nil {
+		return nil, err
+	}
+	result.Commits = commits
+
+	cutoff := time.Now().Add(-opts.Grace)
+	for _, object := range mm.storedObjects() {
+		result.Scanned++
+		if marked[object.path] {
+			result.Reachable++
+			continue
+		}
+		candidate := &Suggestion{
+			Kind:       KindUnreachable,
+			Path:       object.path,
+			Size:       object.info.Size(),
+			LastAccess: object.info.ModTime(),
+			Reason:     unreachableReason(filepath.Base(object.path), commits),
+		}
+		if match := versionedObject.FindStringSubmatch(filepath.Base(object.path)); match != nil {
+			candidate.Version, _ = strconv.Atoi(match[1])
+		}
+		if object.info.ModTime().After(cutoff) {
+			result.Recent = append(result.Recent, candidate)
+			continue
+		}
+		result.Unreachable = append(result.Unreachable, candidate)
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	for _, candidate := range result.Unreachable {
+		if err := os.Remove(candidate.Path); err != nil && !os.IsNotExist(err) {
+			result.Failed = append(result.Failed, fmt.Sprintf("failed to remove %s: %v", candidate.Path, err))
+			continue
+		}
+		result.Removed++
+		result.Reclaimed += candidate.Size
+	}
+	if result.Removed > 0 {
+		if _, err := generation.Bump(mm.DgitDir, "gc"); err != nil {
+			return result, fmt.Errorf("removed unreachable objects but failed to invalidate caches: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// markReachable returns every object path some version needs, and the number of versions
+func (mm *MaintenanceManager) markReachable() (map[string]bool, int, error) {
+	logManager := log.NewLogManager(mm.DgitDir)
+	planner := restore.NewRestoreManager(mm.DgitDir)
+	storageDir := initializer.GetStorageDir(mm.DgitDir)
+	tiers := []string{mm.SnapshotsDir, filepath.Join(storageDir, "deltas"), mm.ArchiveDir, mm.ObjectsDir, mm.CacheDir}
+
+	marked := make(map[string]bool)
+	mark := func(dir, name string) {
+		marked[filepath.Join(dir, name)] = true
+	}
+
+	// Versions run 1..latest; a record that cannot be read would hide what it references
+	latest := logManager.GetCurrentVersion()
+	for version := 1; version <= latest; version++ {
+		commit, err := logManager.GetCommit(version)
+		if err != nil {
+			return nil, 0, &UnreadableHistoryError{Version: version, Err: err}
+		}
+		required, err := planner.RequiredFiles(version)
+		if err != nil {
+			return nil, 0, &UnreadableHistoryError{Version: version, Err: err}
+		}
+		for _, path := range required {
+			marked[filepath.Clean(path)] = true
+		}
+
+		// The version's own data, under every name a tier may hold it by
+		mark(mm.SnapshotsDir, fmt.Sprintf("v%d.lz4", version))
+		mark(mm.ArchiveDir, fmt.Sprintf("v%d.zstd", version))
+		mark(mm.ObjectsDir, fmt.Sprintf("v%d.zip", version))
+		mark(mm.CacheDir, fmt.Sprintf("v%d.lz4", version))
+		mark(mm.CacheDir, fmt.Sprintf("v%d_optimized.zstd", version))
+		if commit.SnapshotZip != "" {
+			mark(mm.ObjectsDir, commit.SnapshotZip)
+		}
+		if info := commit.CompressionInfo; info != nil && info.OutputFile != "" {
+			for _, dir := range tiers {
+				mark(dir, info.OutputFile)
+			}
+		}
+	}
+	return marked, latest, nil
+}
+
+// storedObject is one file in a storage tier
+type storedObject struct {
+	path string
+	info os.FileInfo
+}
+
+// storedObjects lists object files in the storage tiers and version objects in the
+// restore cache; indexes, markers and other bookkeeping files are left out
+func (mm *MaintenanceManager) storedObjects() []storedObject {
+	storageDir := initializer.GetStorageDir(mm.DgitDir)
+	var objects []storedObject
+	collect := func(dir string, versionedOnly bool) {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			name := info.Name()
+			if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".json") {
+				return nil
+			}
+			if versionedOnly && !versionedObject.MatchString(name) {
+				return nil
+			}
+			objects = append(objects, storedObject{path: filepath.Clean(path), info: info})
+			return nil
+		})
+	}
+	for _, dir := range initializer.ObjectStorageDirs {
+		collect(filepath.Join(storageDir, dir), false)
+	}
+	collect(mm.CacheDir, true)
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].path < objects[j].path })
+	return objects
+}
+
+// unreachableReason explains why no version references an object
+func unreachableReason(name string, latest int) string {
+	if strings.HasPrefix(name, "temp_") || strings.Contains(name, ".tmp") {
+		return "leftover temporary file"
+	}
+	match := versionedObject.FindStringSubmatch(name)
+	if match == nil {
+		return "not referenced by any commit"
+	}
+	if version, _ := strconv.Atoi(match[1]); version > latest {
+		return fmt.Sprintf("written for v%d, which was never committed", version)
+	}
+	return fmt.Sprintf("not used by v%s", match[1])
+}
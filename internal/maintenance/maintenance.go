@@ -0,0 +1,333 @@
+package maintenance
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dgit/internal/access"
+	"dgit/internal/generation"
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/objfmt"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Suggestion kinds
+const (
+	KindArchive   = "archive"    // Recompress a cold LZ4 snapshot into the Zstd archive tier
+	KindPrune     = "prune"      // Delete leftover temporary data from interrupted operations
+	KindDropCache = "drop-cache" // Delete a cached LZ4 copy of a version stored elsewhere
+)
+
+// Suggestion describes a single archive or prune candidate
+type Suggestion struct {
+	Kind       string    `json:"kind"`
+	Version    int       `json:"version,omitempty"`
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	LastAccess time.Time `json:"last_access"`
+	Reason     string    `json:"reason"`
+}
+
+// SuggestionOptions controls which versions are considered cold
+type SuggestionOptions struct {
+	MinSize        int64         // Only suggest archiving snapshots at least this large
+	NotAccessedFor time.Duration // Only suggest versions idle for at least this long
+}
+
+// MaintenanceManager analyzes storage access patterns and applies archive/prune actions
+type MaintenanceManager struct {
+	DgitDir      string
+	ObjectsDir   string
+	SnapshotsDir string
+	ArchiveDir   string
+	CacheDir     string
+	TempDir      string
+
+	archiveLevel      int
+	archiveAfterDays  int
+	lowSpaceThreshold int64
+	demoteOnLowSpace  bool
+}
+
+// NewMaintenanceManager creates a new maintenance manager
+func NewMaintenanceManager(dgitDir string) *MaintenanceManager {
+	storageDir := initializer.GetStorageDir(dgitDir)
+	mm := &MaintenanceManager{
+		DgitDir:           dgitDir,
+		ObjectsDir:        filepath.Join(storageDir, "objects"),
+		SnapshotsDir:      filepath.Join(storageDir, "snapshots"),
+		ArchiveDir:        filepath.Join(storageDir, "archive"),
+		CacheDir:          filepath.Join(dgitDir, "cache"),
+		TempDir:           filepath.Join(dgitDir, "temp"),
+		archiveLevel:      22,
+		archiveAfterDays:  90,
+		lowSpaceThreshold: DefaultLowSpaceThreshold,
+	}
+
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		if config.Compression.ArchiveConfig.CompressionLevel > 0 {
+			mm.archiveLevel = config.Compression.ArchiveConfig.CompressionLevel
+		}
+		if config.Compression.ArchiveConfig.ArchiveAfterDays > 0 {
+			mm.archiveAfterDays = config.Compression.ArchiveConfig.ArchiveAfterDays
+		}
+		if config.Compression.ArchiveConfig.LowSpaceThreshold > 0 {
+			mm.lowSpaceThreshold = config.Compression.ArchiveConfig.LowSpaceThreshold * 1024 * 1024
+		}
+		mm.demoteOnLowSpace = config.Compression.ArchiveConfig.DemoteOnLowSpace
+	}
+
+	return mm
+}
+
+// DefaultIdlePeriod returns the configured archive_after_days as a duration
+func (mm *MaintenanceManager) DefaultIdlePeriod() time.Duration {
+	return time.Duration(mm.archiveAfterDays) * 24 * time.Hour
+}
+
+// SuggestCandidates lists cold versions worth archiving and stale temporary data worth pruning
+// A version's last access is its most recent read/restore, or its commit time if never accessed
+func (mm *MaintenanceManager) SuggestCandidates(opts SuggestionOptions) ([]*Suggestion, error) {
+	logManager := log.NewLogManager(mm.DgitDir)
+	commits, err := logManager.GetCommitHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commit history: %w", err)
+	}
+
+	tracker := access.NewAccessTracker(mm.DgitDir)
+	if err := tracker.Load(); err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-opts.NotAccessedFor)
+	currentVersion := logManager.GetCurrentVersion()
+	var suggestions []*Suggestion
+
+	for _, commit := range commits {
+		// Keep the checked-out version hot; it is the base for the next delta
+		if commit.Version == currentVersion {
+			continue
+		}
+		if commit.CompressionInfo == nil || commit.CompressionInfo.Strategy != "lz4" {
+			continue
+		}
+
+		snapshotPath := filepath.Join(mm.SnapshotsDir, fmt.Sprintf("v%d.lz4", commit.Version))
+		info, err := os.Stat(snapshotPath)
+		if err != nil || info.Size() < opts.MinSize {
+			continue
+		}
+
+		lastAccess := commit.Timestamp
+		if va := tracker.Get(commit.Version); va != nil && va.LastAccess().After(lastAccess) {
+			lastAccess = va.LastAccess()
+		}
+		if lastAccess.After(cutoff) {
+			continue
+		}
+
+		suggestions = append(suggestions, &Suggestion{
+			Kind:       KindArchive,
+			Version:    commit.Version,
+			Path:       snapshotPath,
+			Size:       info.Size(),
+			LastAccess: lastAccess,
+			Reason:     fmt.Sprintf("not accessed for %d days", int(time.Since(lastAccess).Hours()/24)),
+		})
+	}
+
+	suggestions = append(suggestions, mm.StaleTempFiles()...)
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Kind != suggestions[j].Kind {
+			return suggestions[i].Kind < suggestions[j].Kind
+		}
+		return suggestions[i].Size > suggestions[j].Size
+	})
+
+	return suggestions, nil
+}
+
+// StaleTempFiles finds working files left behind by interrupted commits and restores
+func (mm *MaintenanceManager) StaleTempFiles() []*Suggestion {
+	var suggestions []*Suggestion
+	cutoff := time.Now().Add(-24 * time.Hour)
+
+	addIfStale := func(path string, info os.FileInfo) {
+		if info.IsDir() || info.ModTime().After(cutoff) {
+			return
+		}
+		suggestions = append(suggestions, &Suggestion{
+			Kind:       KindPrune,
+			Path:       path,
+			Size:       info.Size(),
+			LastAccess: info.ModTime(),
+			Reason:     "leftover temporary file",
+		})
+	}
+
+	filepath.Walk(mm.TempDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil {
+			addIfStale(path, info)
+		}
+		return nil
+	})
+
+	// Restore/status write temp ZIPs next to legacy objects
+	if entries, err := os.ReadDir(mm.ObjectsDir); err == nil {
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), "temp_") {
+				continue
+			}
+			if info, err := entry.Info(); err == nil {
+				addIfStale(filepath.Join(mm.ObjectsDir, entry.Name()), info)
+			}
+		}
+	}
+
+	return suggestions
+}
+
+// Apply executes a suggestion and returns the number of bytes reclaimed
+func (mm *MaintenanceManager) Apply(suggestion *Suggestion) (int64, error) {
+	switch suggestion.Kind {
+	case KindArchive:
+		return mm.ArchiveVersion(suggestion.Version)
+	case KindPrune, KindDropCache:
+		if err := os.Remove(suggestion.Path); err != nil {
+			return 0, fmt.Errorf("failed to remove %s: %w", suggestion.Path, err)
+		}
+		if _, err := generation.Bump(mm.DgitDir, suggestion.Kind); err != nil {
+			return suggestion.Size, fmt.Errorf("pruned %s but failed to invalidate caches: %w", suggestion.Path, err)
+		}
+		return suggestion.Size, nil
+	default:
+		return 0, fmt.Errorf("unknown suggestion kind: %s", suggestion.Kind)
+	}
+}
+
+// ArchiveVersion recompresses an LZ4 snapshot into .dgit/archive/vN.zstd
+// The archive is verified against the original stream before the LZ4 snapshot is removed
+func (mm *MaintenanceManager) ArchiveVersion(version int) (int64, error) {
+	snapshotPath := filepath.Join(mm.SnapshotsDir, fmt.Sprintf("v%d.lz4", version))
+	archivePath := filepath.Join(mm.ArchiveDir, fmt.Sprintf("v%d.zstd", version))
+	tempPath := archivePath + ".tmp"
+
+	snapshotInfo, err := os.Stat(snapshotPath)
+	if err != nil {
+		return 0, fmt.Errorf("snapshot for v%d not found: %w", version, err)
+	}
+
+	if err := initializer.EnsureFormatVersion(mm.DgitDir); err != nil {
+		return 0, fmt.Errorf("failed to record repository format: %w", err)
+	}
+
+	if err := os.MkdirAll(mm.ArchiveDir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	originalHash, err := mm.writeArchive(snapshotPath, tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return 0, err
+	}
+
+	archivedHash, err := hashZstdStream(tempPath)
+	if err != nil || archivedHash != originalHash {
+		os.Remove(tempPath)
+		return 0, fmt.Errorf("archive verification failed for v%d", version)
+	}
+
+	if err := os.Rename(tempPath, archivePath); err != nil {
+		os.Remove(tempPath)
+		return 0, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	if err := os.Remove(snapshotPath); err != nil {
+		return 0, fmt.Errorf("archived v%d but failed to remove snapshot: %w", version, err)
+	}
+
+	archiveInfo, err := os.Stat(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	return snapshotInfo.Size() - archiveInfo.Size(), nil
+}
+
+// writeArchive streams LZ4 → Zstd and returns the SHA256 of the uncompressed stream
+func (mm *MaintenanceManager) writeArchive(lz4Path, zstdPath string) (string, error) {
+	source, err := os.Open(lz4Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer source.Close()
+
+	target, err := os.Create(zstdPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer target.Close()
+
+	payload, err := objfmt.NewReader(source, lz4Path)
+	if err != nil {
+		return "", err
+	}
+	if err := objfmt.WriteHeader(target, objfmt.TypeSnapshot); err != nil {
+		return "", fmt.Errorf("failed to write archive header: %w", err)
+	}
+
+	encoder, err := zstd.NewWriter(target,
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(mm.archiveLevel)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create zstd encoder: %w", err)
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(encoder, hasher), lz4.NewReader(payload)); err != nil {
+		encoder.Close()
+		return "", fmt.Errorf("failed to recompress snapshot: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := target.Sync(); err != nil {
+		return "", fmt.Errorf("failed to sync archive: %w", err)
+	}
+
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
+
+// hashZstdStream returns the SHA256 of a Zstd file's uncompressed content
+func hashZstdStream(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	payload, err := objfmt.NewReader(file, path)
+	if err != nil {
+		return "", err
+	}
+
+	decoder, err := zstd.NewReader(payload)
+	if err != nil {
+		return "", err
+	}
+	defer decoder.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, decoder); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
@@ -0,0 +1,159 @@
+package maintenance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"dgit/internal/access"
+	"dgit/internal/diskspace"
+	"dgit/internal/log"
+)
+
+// DefaultLowSpaceThreshold is the free space below which storage is under pressure
+// when the config sets no low_space_threshold_mb
+const DefaultLowSpaceThreshold = 10 * 1024 * 1024 * 1024
+
+// DiskStatus describes free space on the volume holding repository storage
+type DiskStatus struct {
+	Path      string `json:"path"`
+	Free      int64  `json:"free"`
+	Total     int64  `json:"total"`
+	Threshold int64  `json:"threshold"`
+}
+
+// Known reports whether the platform reported the volume's free space
+func (ds *DiskStatus) Known() bool {
+	return ds.Total > 0
+}
+
+// Low reports whether free space is below the threshold
+func (ds *DiskStatus) Low() bool {
+	return ds.Known() && ds.Free < ds.Threshold
+}
+
+// PressureResult summarizes a pass that demoted storage to free disk space
+type PressureResult struct {
+	Before    *DiskStatus   `json:"before"`
+	After     *DiskStatus   `json:"after"`
+	Applied   []*Suggestion `json:"applied"`
+	Failed    []string      `json:"failed,omitempty"`
+	Reclaimed int64         `json:"reclaimed"`
+}
+
+// DemoteOnLowSpace reports whether restores should relieve disk pressure automatically
+func (mm *MaintenanceManager) DemoteOnLowSpace() bool {
+	return mm.demoteOnLowSpace
+}
+
+// DiskStatus measures free space on the volume holding the snapshots
+func (mm *MaintenanceManager) DiskStatus() *DiskStatus {
+	path := mm.SnapshotsDir
+	if _, err := os.Stat(path); err != nil {
+		path = mm.DgitDir
+	}
+	free, total := diskspace.Usage(path)
+	return &DiskStatus{Path: path, Free: int64(free), Total: int64(total), Threshold: mm.lowSpaceThreshold}
+}
+
+// PressureCandidates lists what can be given up to free space, in the order to do it:
+// cached copies of versions stored elsewhere, then every LZ4 snapshot except the
+// checked-out version, least recently restored first, regardless of size or age
+func (mm *MaintenanceManager) PressureCandidates() ([]*Suggestion, error) {
+	logManager := log.NewLogManager(mm.DgitDir)
+	commits, err := logManager.GetCommitHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commit history: %w", err)
+	}
+
+	tracker := access.NewAccessTracker(mm.DgitDir)
+	if err := tracker.Load(); err != nil {
+		return nil, err
+	}
+
+	currentVersion := logManager.GetCurrentVersion()
+	var drops, demotions []*Suggestion
+
+	for _, commit := range commits {
+		snapshotPath := filepath.Join(mm.SnapshotsDir, fmt.Sprintf("v%d.lz4", commit.Version))
+		archivePath := filepath.Join(mm.ArchiveDir, fmt.Sprintf("v%d.zstd", commit.Version))
+		cachePath := filepath.Join(mm.CacheDir, fmt.Sprintf("v%d.lz4", commit.Version))
+
+		lastRestore, reason := commit.Timestamp, "never restored"
+		if va := tracker.Get(commit.Version); va != nil && !va.LastRestore.IsZero() {
+			lastRestore, reason = va.LastRestore, fmt.Sprintf("last restored %s", va.LastRestore.Format("2006-01-02"))
+		}
+
+		snapshotInfo, snapshotErr := os.Stat(snapshotPath)
+		// A cached copy of a delta version was rebuilt from its chain, which is still stored
+		rebuilt := commit.CompressionInfo != nil && commit.CompressionInfo.Strategy != "lz4"
+		if cacheInfo, err := os.Stat(cachePath); err == nil && (snapshotErr == nil || fileExists(archivePath) || rebuilt) {
+			drops = append(drops, &Suggestion{
+				Kind:       KindDropCache,
+				Version:    commit.Version,
+				Path:       cachePath,
+				Size:       cacheInfo.Size(),
+				LastAccess: lastRestore,
+				Reason:     "cached copy of a stored version",
+			})
+		}
+
+		// Keep the checked-out version hot; it is the base for the next delta
+		if commit.Version == currentVersion || snapshotErr != nil {
+			continue
+		}
+		if commit.CompressionInfo == nil || commit.CompressionInfo.Strategy != "lz4" {
+			continue
+		}
+		demotions = append(demotions, &Suggestion{
+			Kind:       KindArchive,
+			Version:    commit.Version,
+			Path:       snapshotPath,
+			Size:       snapshotInfo.Size(),
+			LastAccess: lastRestore,
+			Reason:     reason,
+		})
+	}
+
+	sort.Slice(demotions, func(i, j int) bool {
+		return demotions[i].LastAccess.Before(demotions[j].LastAccess)
+	})
+	return append(drops, demotions...), nil
+}
+
+// RelieveDiskPressure applies pressure candidates until free space is back above the
+// threshold; with force every candidate is applied even if the disk is not low
+func (mm *MaintenanceManager) RelieveDiskPressure(force bool) (*PressureResult, error) {
+	result := &PressureResult{Before: mm.DiskStatus()}
+	result.After = result.Before
+	if !force && !result.Before.Low() {
+		return result, nil
+	}
+
+	candidates, err := mm.PressureCandidates()
+	if err != nil {
+		return result, err
+	}
+
+	for _, candidate := range candidates {
+		if !force && !result.After.Low() {
+			break
+		}
+		saved, err := mm.Apply(candidate)
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("v%d %s: %v", candidate.Version, candidate.Kind, err))
+			continue
+		}
+		result.Applied = append(result.Applied, candidate)
+		result.Reclaimed += saved
+		result.After = mm.DiskStatus()
+	}
+	return result, nil
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
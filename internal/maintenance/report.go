@@ -0,0 +1,240 @@
+package maintenance
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/restore"
+)
+
+// Every maintenance operation that changes storage is measured before and after, and
+// the comparison is appended to .dgit/metrics/maintenance.jsonl for 'dgit maintenance log'.
+
+// Checkout cost model used for estimated speedups; rough single-disk figures that are
+// only ever compared with each other
+const (
+	lz4ReadRate  = 400 << 20 // Bytes/s decompressing LZ4 snapshots
+	zstdReadRate = 150 << 20 // Bytes/s decompressing Zstd archives
+	zipReadRate  = 200 << 20 // Bytes/s extracting ZIP objects
+	patchRate    = 80 << 20  // Bytes/s of base data rewritten per bsdiff step
+	stepOverhead = 20 * time.Millisecond
+)
+
+// StorageState measures repository storage at one point in time
+type StorageState struct {
+	Objects       int   `json:"objects"`
+	ObjectBytes   int64 `json:"object_bytes"`
+	MetadataFiles int   `json:"metadata_files"`
+	MetadataBytes int64 `json:"metadata_bytes"`
+
+	LongestChain      int           `json:"longest_chain"`         // Most delta steps any version needs
+	ChainSteps        int           `json:"chain_steps"`           // Delta steps summed over every version
+	EstimatedCheckout time.Duration `json:"estimated_checkout_ns"` // Average estimated full checkout
+
+	files  map[string]bool // Object paths
+	chains map[int]int     // Delta steps per version
+}
+
+// CompactionReport compares storage before and after a maintenance operation
+type CompactionReport struct {
+	Operation       string        `json:"operation"`
+	Time            time.Time     `json:"time"`
+	Duration        time.Duration `json:"duration_ns"`
+	Before          *StorageState `json:"before"`
+	After           *StorageState `json:"after"`
+	ObjectsRemoved  int           `json:"objects_removed"`
+	ObjectsAdded    int           `json:"objects_added"`
+	BytesReclaimed  int64         `json:"bytes_reclaimed"` // Negative when storage grew
+	ChainsShortened int           `json:"chains_shortened"`
+	CheckoutSpeedup float64       `json:"checkout_speedup"` // Estimated before/after checkout time; below 1 is slower
+	Error           string        `json:"error,omitempty"`
+}
+
+// Changed reports whether the operation changed storage at all
+func (r *CompactionReport) Changed() bool {
+	return r.ObjectsRemoved > 0 || r.ObjectsAdded > 0 || r.BytesReclaimed != 0 || r.ChainsShortened > 0
+}
+
+// reportLog is where compaction reports are appended
+func reportLog(dgitDir string) string {
+	return filepath.Join(dgitDir, "metrics", "maintenance.jsonl")
+}
+
+// Track measures storage around fn and records the comparison when storage changed;
+// the report is returned even when fn fails part way
+func (mm *MaintenanceManager) Track(operation string, fn func() error) (*CompactionReport, error) {
+	before := mm.CaptureStorage()
+	start := time.Now()
+	err := fn()
+	report := Compare(operation, before, mm.CaptureStorage())
+	report.Time = start
+	report.Duration = time.Since(start)
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if report.Changed() {
+		if recordErr := mm.RecordReport(report); recordErr != nil && err == nil {
+			err = recordErr
+		}
+	}
+	return report, err
+}
+
+// CaptureStorage measures object and metadata storage and the delta chains of every version
+func (mm *MaintenanceManager) CaptureStorage() *StorageState {
+	state := &StorageState{files: make(map[string]bool), chains: make(map[int]int)}
+
+	storageDir := initializer.GetStorageDir(mm.DgitDir)
+	dirs := []string{mm.CacheDir}
+	for _, dir := range initializer.ObjectStorageDirs {
+		dirs = append(dirs, filepath.Join(storageDir, dir))
+	}
+	for _, dir := range dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || strings.HasSuffix(path, ".json") || strings.HasPrefix(info.Name(), ".") {
+				return nil
+			}
+			state.files[path] = true
+			state.Objects++
+			state.ObjectBytes += info.Size()
+			return nil
+		})
+	}
+	filepath.Walk(filepath.Join(mm.DgitDir, "commits"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			state.MetadataFiles++
+			state.MetadataBytes += info.Size()
+		}
+		return nil
+	})
+
+	commits, err := log.NewLogManager(mm.DgitDir).GetCommitHistory()
+	if err != nil || len(commits) == 0 {
+		return state
+	}
+	planner := restore.NewRestoreManager(mm.DgitDir)
+	var total time.Duration
+	for _, commit := range commits {
+		files, err := planner.RequiredFiles(commit.Version)
+		if err != nil || len(files) == 0 {
+			continue
+		}
+		steps := len(files) - 1
+		state.chains[commit.Version] = steps
+		state.ChainSteps += steps
+		state.LongestChain = max(state.LongestChain, steps)
+		total += checkoutCost(files)
+	}
+	state.EstimatedCheckout = total / time.Duration(len(commits))
+	return state
+}
+
+// Compare builds the report for storage going from before to after
+func Compare(operation string, before, after *StorageState) *CompactionReport {
+	report := &CompactionReport{
+		Operation:      operation,
+		Before:         before,
+		After:          after,
+		BytesReclaimed: before.ObjectBytes + before.MetadataBytes - after.ObjectBytes - after.MetadataBytes,
+	}
+	for path := range before.files {
+		if !after.files[path] {
+			report.ObjectsRemoved++
+		}
+	}
+	for path := range after.files {
+		if !before.files[path] {
+			report.ObjectsAdded++
+		}
+	}
+	for version, steps := range before.chains {
+		if afterSteps, ok := after.chains[version]; ok && afterSteps < steps {
+			report.ChainsShortened++
+		}
+	}
+	if after.EstimatedCheckout > 0 {
+		report.CheckoutSpeedup = float64(before.EstimatedCheckout) / float64(after.EstimatedCheckout)
+	}
+	return report
+}
+
+// RecordReport appends a report to the maintenance log
+func (mm *MaintenanceManager) RecordReport(report *CompactionReport) error {
+	path := reportLog(mm.DgitDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Reports returns recorded compaction reports, oldest first; unreadable lines are skipped
+func (mm *MaintenanceManager) Reports() ([]*CompactionReport, error) {
+	file, err := os.Open(reportLog(mm.DgitDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reports []*CompactionReport
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var report CompactionReport
+		if json.Unmarshal(scanner.Bytes(), &report) == nil {
+			reports = append(reports, &report)
+		}
+	}
+	return reports, scanner.Err()
+}
+
+// checkoutCost estimates a full checkout that reads files: the base object is
+// decompressed once and every delta step after it rewrites a base-sized file
+func checkoutCost(files []string) time.Duration {
+	base := fileSize(files[0])
+	rate := int64(lz4ReadRate)
+	switch filepath.Ext(files[0]) {
+	case ".zstd":
+		rate = zstdReadRate
+	case ".zip":
+		rate = zipReadRate
+	}
+	cost := transferTime(base, rate)
+	for range files[1:] {
+		cost += stepOverhead + transferTime(base, patchRate)
+	}
+	return cost
+}
+
+// transferTime is how long processing size bytes takes at rate bytes per second
+func transferTime(size, rate int64) time.Duration {
+	return time.Duration(float64(size) / float64(rate) * float64(time.Second))
+}
+
+// fileSize returns a file's size, or 0 when it cannot be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
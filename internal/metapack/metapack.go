@@ -0,0 +1,431 @@
+package metapack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dgit/internal/generation"
+	"dgit/internal/objfmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Packed commit metadata layout:
+//
+//	.dgit/commits/v12.json               loose record (always wins over packed)
+//	.dgit/commits/packs/index.json       version → pack/offset/length
+//	.dgit/commits/packs/pack-000001.zst  Zstd stream of compact JSON records
+//
+// Offsets and lengths refer to the decompressed stream.
+
+// DefaultBatchSize is the maximum number of records per pack file
+const DefaultBatchSize = 1000
+
+// PackEntry locates one commit record inside a pack
+type PackEntry struct {
+	Pack   string `json:"pack"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+}
+
+// PackIndex maps versions to their packed records
+type PackIndex struct {
+	FormatVersion int                  `json:"format_version"`
+	Generation    uint64               `json:"generation"` // Repository generation the index was built at
+	Entries       map[string]PackEntry `json:"entries"`
+	Packs         []string             `json:"packs"`
+}
+
+// PackResult summarizes a packing run
+type PackResult struct {
+	RecordsPacked int
+	PacksWritten  int
+	LooseBytes    int64
+	PackedBytes   int64
+}
+
+// Store reads and packs commit records in a commits directory
+type Store struct {
+	DgitDir    string
+	CommitsDir string
+	PacksDir   string
+	IndexFile  string
+}
+
+// NewStore creates a metadata store for the given commits directory
+func NewStore(commitsDir string) *Store {
+	packsDir := filepath.Join(commitsDir, "packs")
+	return &Store{
+		DgitDir:    filepath.Dir(commitsDir),
+		CommitsDir: commitsDir,
+		PacksDir:   packsDir,
+		IndexFile:  filepath.Join(packsDir, "index.json"),
+	}
+}
+
+// ReadRecord returns the raw JSON record for a version, loose first then packed
+func (s *Store) ReadRecord(version int) ([]byte, error) {
+	loosePath := filepath.Join(s.CommitsDir, fmt.Sprintf("v%d.json", version))
+	if data, err := os.ReadFile(loosePath); err == nil {
+		return data, nil
+	}
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	entry, exists := index.Entries[strconv.Itoa(version)]
+	if !exists {
+		return nil, fmt.Errorf("commit v%d not found", version)
+	}
+
+	stream, err := s.readPack(entry.Pack)
+	if err != nil {
+		return nil, err
+	}
+	return sliceRecord(stream, entry)
+}
+
+// HasRecord reports whether a version exists loose or packed
+func (s *Store) HasRecord(version int) bool {
+	if _, err := os.Stat(filepath.Join(s.CommitsDir, fmt.Sprintf("v%d.json", version))); err == nil {
+		return true
+	}
+	index, err := s.loadIndex()
+	if err != nil {
+		return false
+	}
+	_, exists := index.Entries[strconv.Itoa(version)]
+	return exists
+}
+
+// ReadAll returns every record keyed by version, decompressing each pack once
+func (s *Store) ReadAll() (map[int][]byte, error) {
+	records := make(map[int][]byte)
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	streams := make(map[string][]byte)
+	for key, entry := range index.Entries {
+		version, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		stream, cached := streams[entry.Pack]
+		if !cached {
+			if stream, err = s.readPack(entry.Pack); err != nil {
+				return nil, err
+			}
+			streams[entry.Pack] = stream
+		}
+		if record, err := sliceRecord(stream, entry); err == nil {
+			records[version] = record
+		}
+	}
+
+	loose, err := s.looseVersions()
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range loose {
+		data, err := os.ReadFile(filepath.Join(s.CommitsDir, fmt.Sprintf("v%d.json", version)))
+		if err != nil {
+			continue
+		}
+		records[version] = data
+	}
+
+	return records, nil
+}
+
+// LatestVersion returns the highest version stored loose or packed
+func (s *Store) LatestVersion() int {
+	maxVersion := 0
+
+	if loose, err := s.looseVersions(); err == nil {
+		for _, version := range loose {
+			if version > maxVersion {
+				maxVersion = version
+			}
+		}
+	}
+
+	if index, err := s.loadIndex(); err == nil {
+		for key := range index.Entries {
+			if version, err := strconv.Atoi(key); err == nil && version > maxVersion {
+				maxVersion = version
+			}
+		}
+	}
+
+	return maxVersion
+}
+
+// LooseCount returns the number of unpacked JSON records
+func (s *Store) LooseCount() int {
+	loose, _ := s.looseVersions()
+	return len(loose)
+}
+
+// Pack moves loose JSON records into Zstd packs of at most batchSize records
+// Packs and the index are written before loose files are removed, so an interrupted
+// run leaves loose records in place (they take priority when read)
+func (s *Store) Pack(batchSize int) (*PackResult, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	loose, err := s.looseVersions()
+	if err != nil {
+		return nil, err
+	}
+	result := &PackResult{}
+	if len(loose) == 0 {
+		return result, nil
+	}
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.PacksDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create packs directory: %w", err)
+	}
+
+	for start := 0; start < len(loose); start += batchSize {
+		end := start + batchSize
+		if end > len(loose) {
+			end = len(loose)
+		}
+		batch := loose[start:end]
+
+		packName := fmt.Sprintf("pack-%06d.zst", len(index.Packs)+1)
+		var stream bytes.Buffer
+		entries := make(map[string]PackEntry)
+
+		for _, version := range batch {
+			data, err := os.ReadFile(filepath.Join(s.CommitsDir, fmt.Sprintf("v%d.json", version)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read v%d.json: %w", version, err)
+			}
+			result.LooseBytes += int64(len(data))
+
+			// Pretty-printed records are compacted before compression
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, data); err != nil {
+				return nil, fmt.Errorf("invalid commit record v%d: %w", version, err)
+			}
+
+			entries[strconv.Itoa(version)] = PackEntry{
+				Pack:   packName,
+				Offset: int64(stream.Len()),
+				Length: int64(compact.Len()),
+			}
+			stream.Write(compact.Bytes())
+			stream.WriteByte('\n')
+		}
+
+		packedSize, err := s.writePack(packName, stream.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		for key, entry := range entries {
+			index.Entries[key] = entry
+		}
+		index.Packs = append(index.Packs, packName)
+		result.PacksWritten++
+		result.PackedBytes += packedSize
+		result.RecordsPacked += len(batch)
+	}
+
+	if err := s.saveIndex(index); err != nil {
+		return nil, err
+	}
+
+	for _, version := range loose {
+		os.Remove(filepath.Join(s.CommitsDir, fmt.Sprintf("v%d.json", version)))
+	}
+
+	return result, nil
+}
+
+// looseVersions lists versions with a vN.json file, ascending
+func (s *Store) looseVersions() ([]int, error) {
+	entries, err := os.ReadDir(s.CommitsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commits directory: %w", err)
+	}
+
+	var versions []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "v") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "v"), ".json")); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// loadIndex reads the pack index; a missing index means nothing is packed
+// An index built at an older repository generation is rebuilt from the pack files
+func (s *Store) loadIndex() (*PackIndex, error) {
+	index, err := s.readIndex()
+	if err != nil || !generation.IsStale(s.DgitDir, index.Generation) {
+		return index, err
+	}
+	if _, statErr := os.Stat(s.IndexFile); os.IsNotExist(statErr) {
+		return index, nil
+	}
+
+	rebuilt, err := s.rebuildIndex()
+	if err != nil {
+		return nil, fmt.Errorf("pack index is stale and could not be rebuilt: %w", err)
+	}
+	if err := s.saveIndex(rebuilt); err != nil {
+		return nil, err
+	}
+	return rebuilt, nil
+}
+
+// rebuildIndex scans every pack file and recomputes record offsets
+// Packs are scanned in name order so later packs win for duplicate versions
+func (s *Store) rebuildIndex() (*PackIndex, error) {
+	index := &PackIndex{FormatVersion: 1, Entries: make(map[string]PackEntry)}
+
+	packs, err := filepath.Glob(filepath.Join(s.PacksDir, "pack-*.zst"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(packs)
+
+	for _, packPath := range packs {
+		packName := filepath.Base(packPath)
+		stream, err := s.readPack(packName)
+		if err != nil {
+			return nil, err
+		}
+
+		var offset int64
+		for _, line := range bytes.Split(stream, []byte("\n")) {
+			length := int64(len(line))
+			if length > 0 {
+				var record struct {
+					Version int `json:"version"`
+				}
+				if err := json.Unmarshal(line, &record); err != nil {
+					return nil, fmt.Errorf("corrupt record in %s at offset %d: %w", packName, offset, err)
+				}
+				index.Entries[strconv.Itoa(record.Version)] = PackEntry{Pack: packName, Offset: offset, Length: length}
+			}
+			offset += length + 1
+		}
+		index.Packs = append(index.Packs, packName)
+	}
+	return index, nil
+}
+
+// readIndex reads the pack index file as stored
+func (s *Store) readIndex() (*PackIndex, error) {
+	index := &PackIndex{FormatVersion: 1, Entries: make(map[string]PackEntry)}
+
+	data, err := os.ReadFile(s.IndexFile)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse pack index: %w", err)
+	}
+	if index.Entries == nil {
+		index.Entries = make(map[string]PackEntry)
+	}
+	return index, nil
+}
+
+// saveIndex writes the pack index atomically, stamped with the current generation
+func (s *Store) saveIndex(index *PackIndex) error {
+	index.Generation = generation.Current(s.DgitDir)
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pack index: %w", err)
+	}
+	return writeFileAtomic(s.IndexFile, data)
+}
+
+// readPack decompresses an entire pack file
+func (s *Store) readPack(packName string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.PacksDir, packName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", packName, err)
+	}
+	if data, err = objfmt.Strip(data, packName); err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	stream, err := decoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", packName, err)
+	}
+	return stream, nil
+}
+
+// writePack compresses a record stream into a pack file and returns its size
+func (s *Store) writePack(packName string, stream []byte) (int64, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return 0, err
+	}
+	var packed bytes.Buffer
+	objfmt.WriteHeader(&packed, objfmt.TypeMetadataPack)
+	compressed := encoder.EncodeAll(stream, packed.Bytes())
+	encoder.Close()
+
+	if err := writeFileAtomic(filepath.Join(s.PacksDir, packName), compressed); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", packName, err)
+	}
+	return int64(len(compressed)), nil
+}
+
+// sliceRecord extracts one record from a decompressed pack stream
+func sliceRecord(stream []byte, entry PackEntry) ([]byte, error) {
+	end := entry.Offset + entry.Length
+	if entry.Offset < 0 || end > int64(len(stream)) {
+		return nil, fmt.Errorf("corrupt pack index entry for %s", entry.Pack)
+	}
+	return stream[entry.Offset:end], nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,496 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/relocate"
+)
+
+// A mirror is a complete, read-only copy of a repository's .dgit directory on another
+// volume (NAS, mounted bucket). Object storage is copied into the mirror's .dgit even when
+// the source keeps it elsewhere, so 'dgit restore' works from the mirror as-is.
+//
+// Syncs are incremental (files whose size or modification time differ are copied and
+// verified by SHA256) and ordered so a mirror never references data it does not hold:
+// objects first, then commit metadata, then HEAD. Files deleted from the source
+// (e.g. by garbage collection) are pruned from the mirror afterwards. Working state that
+// only makes sense on the original machine (staging, cache, temp, trash, queue, worktrees)
+// is not mirrored.
+
+// MarkerFile marks a .dgit directory as a mirror; commands that write refuse to run there
+const MarkerFile = "MIRROR"
+
+// localOnly lists .dgit entries that are never mirrored
+var localOnly = map[string]bool{
+	"staging":                   true,
+	"cache":                     true,
+	"temp":                      true,
+	"trash":                     true,
+	"queue":                     true,
+	"worktrees":                 true,
+	"batch":                     true,
+	"previews":                  true,
+	"mirrors.json":              true,
+	initializer.LocalConfigFile: true,
+	MarkerFile:                  true,
+}
+
+// lastEntries are copied after everything else so refs never point at missing data
+var lastEntries = []string{"HEAD"}
+
+// Mirror is one registered mirror location
+type Mirror struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	LastSync    time.Time `json:"last_sync,omitempty"`
+	LastVersion int       `json:"last_version,omitempty"`
+	LastVerify  time.Time `json:"last_verify,omitempty"`
+}
+
+// Marker is written into a mirror's .dgit after every completed sync
+type Marker struct {
+	Source   string    `json:"source"`
+	SyncedAt time.Time `json:"synced_at"`
+	Version  int       `json:"version"`
+}
+
+// SyncResult summarizes one sync
+type SyncResult struct {
+	Copied    int
+	Bytes     int64
+	Pruned    int
+	Unchanged int
+	Version   int
+	Duration  time.Duration
+}
+
+// VerifyResult lists differences between a repository and its mirror
+type VerifyResult struct {
+	Checked    int
+	Missing    []string
+	Mismatched []string
+	Extra      []string
+}
+
+// OK reports whether the mirror matched the source exactly
+func (v *VerifyResult) OK() bool {
+	return len(v.Missing) == 0 && len(v.Mismatched) == 0 && len(v.Extra) == 0
+}
+
+// MirrorManager registers, syncs and verifies mirrors of one repository
+type MirrorManager struct {
+	DgitDir      string
+	RegistryFile string
+
+	// Full copies every file instead of trusting matching size and modification time
+	Full bool
+
+	// Progress is called after each copied file (optional)
+	Progress func(relPath string, size int64)
+}
+
+// NewMirrorManager creates a new mirror manager
+func NewMirrorManager(dgitDir string) *MirrorManager {
+	return &MirrorManager{
+		DgitDir:      dgitDir,
+		RegistryFile: filepath.Join(dgitDir, "mirrors.json"),
+	}
+}
+
+// IsMirror reports whether dgitDir belongs to a mirror, returning its marker
+func IsMirror(dgitDir string) (*Marker, bool) {
+	data, err := os.ReadFile(filepath.Join(dgitDir, MarkerFile))
+	if err != nil {
+		return nil, false
+	}
+	var marker Marker
+	json.Unmarshal(data, &marker)
+	return &marker, true
+}
+
+// Add registers a new mirror location; the directory must be new, empty or an existing
+// mirror of this repository
+func (mm *MirrorManager) Add(path, name string) (*Mirror, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := mm.checkTarget(absPath); err != nil {
+		return nil, err
+	}
+
+	mirrors, err := mm.List()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = filepath.Base(absPath)
+	}
+	for _, m := range mirrors {
+		if m.Path == absPath {
+			return nil, fmt.Errorf("%s is already registered as mirror '%s'", absPath, m.Name)
+		}
+		if m.Name == name {
+			return nil, fmt.Errorf("a mirror named '%s' already exists", name)
+		}
+	}
+
+	mirror := &Mirror{Name: name, Path: absPath}
+	return mirror, mm.save(append(mirrors, mirror))
+}
+
+// List returns registered mirrors sorted by name
+func (mm *MirrorManager) List() ([]*Mirror, error) {
+	data, err := os.ReadFile(mm.RegistryFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var mirrors []*Mirror
+	if err := json.Unmarshal(data, &mirrors); err != nil {
+		return nil, fmt.Errorf("corrupt mirror registry: %w", err)
+	}
+	sort.Slice(mirrors, func(i, j int) bool { return mirrors[i].Name < mirrors[j].Name })
+	return mirrors, nil
+}
+
+// Find returns the mirror registered under a name or path
+func (mm *MirrorManager) Find(nameOrPath string) (*Mirror, error) {
+	mirrors, err := mm.List()
+	if err != nil {
+		return nil, err
+	}
+	absPath, _ := filepath.Abs(nameOrPath)
+	for _, m := range mirrors {
+		if m.Name == nameOrPath || m.Path == absPath {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("no mirror named '%s'", nameOrPath)
+}
+
+// Remove unregisters a mirror; its files are left in place
+func (mm *MirrorManager) Remove(mirror *Mirror) error {
+	mirrors, err := mm.List()
+	if err != nil {
+		return err
+	}
+	kept := mirrors[:0]
+	for _, m := range mirrors {
+		if m.Name != mirror.Name {
+			kept = append(kept, m)
+		}
+	}
+	return mm.save(kept)
+}
+
+// Sync brings a mirror up to date with the repository
+func (mm *MirrorManager) Sync(mirror *Mirror) (*SyncResult, error) {
+	startTime := time.Now()
+	if err := mm.checkTarget(mirror.Path); err != nil {
+		return nil, err
+	}
+	target := filepath.Join(mirror.Path, initializer.DGitDir)
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return nil, fmt.Errorf("mirror location unavailable: %w", err)
+	}
+
+	files, err := mm.sourceFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark the directory before copying so an interrupted first sync can be resumed
+	if _, ok := IsMirror(target); !ok {
+		if err := mm.writeMarker(target, Marker{Source: filepath.Dir(mm.DgitDir)}); err != nil {
+			return nil, err
+		}
+	}
+	// The mirror holds objects inside its own .dgit
+	if err := mm.writeMirrorConfig(target); err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{Version: log.NewLogManager(mm.DgitDir).GetCurrentVersion()}
+	for _, rel := range orderForSync(files) {
+		if rel == "config" {
+			continue
+		}
+		source := files[rel]
+		info, err := os.Stat(source)
+		if err != nil {
+			// Removed while syncing (e.g. a temporary pack); the next sync settles it
+			continue
+		}
+
+		destination := filepath.Join(target, filepath.FromSlash(rel))
+		if existing, err := os.Stat(destination); err == nil && !mm.Full &&
+			existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+			result.Unchanged++
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+			return result, err
+		}
+		if err := relocate.CopyVerified(source, destination, info); err != nil {
+			return result, fmt.Errorf("%s: %w", rel, err)
+		}
+		result.Copied++
+		result.Bytes += info.Size()
+		if mm.Progress != nil {
+			mm.Progress(rel, info.Size())
+		}
+	}
+
+	extra, err := extraFiles(target, files)
+	if err != nil {
+		return result, err
+	}
+	for _, rel := range extra {
+		if os.Remove(filepath.Join(target, filepath.FromSlash(rel))) == nil {
+			result.Pruned++
+		}
+	}
+
+	marker := Marker{Source: filepath.Dir(mm.DgitDir), SyncedAt: time.Now(), Version: result.Version}
+	if err := mm.writeMarker(target, marker); err != nil {
+		return result, err
+	}
+
+	result.Duration = time.Since(startTime)
+	mirror.LastSync = marker.SyncedAt
+	mirror.LastVersion = result.Version
+	return result, mm.update(mirror)
+}
+
+// Verify hashes every mirrored file on both sides and reports differences
+func (mm *MirrorManager) Verify(mirror *Mirror) (*VerifyResult, error) {
+	target := filepath.Join(mirror.Path, initializer.DGitDir)
+	if _, ok := IsMirror(target); !ok {
+		return nil, fmt.Errorf("%s has not been synced yet; run 'dgit mirror sync %s'", mirror.Path, mirror.Name)
+	}
+
+	files, err := mm.sourceFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyResult{}
+	for _, rel := range orderForSync(files) {
+		if rel == "config" {
+			continue // Rewritten to point at the mirror's own storage
+		}
+		result.Checked++
+		mirrored := filepath.Join(target, filepath.FromSlash(rel))
+		if _, err := os.Stat(mirrored); err != nil {
+			result.Missing = append(result.Missing, rel)
+			continue
+		}
+		sourceHash, err := relocate.HashFile(files[rel])
+		if err != nil {
+			continue // Deleted from the source since listing
+		}
+		if mirrorHash, err := relocate.HashFile(mirrored); err != nil || mirrorHash != sourceHash {
+			result.Mismatched = append(result.Mismatched, rel)
+		}
+	}
+
+	result.Extra, err = extraFiles(target, files)
+	if err != nil {
+		return nil, err
+	}
+
+	mirror.LastVerify = time.Now()
+	return result, mm.update(mirror)
+}
+
+// sourceFiles maps mirror-relative paths to source files
+func (mm *MirrorManager) sourceFiles() (map[string]string, error) {
+	files := make(map[string]string)
+	storageDir := initializer.GetStorageDir(mm.DgitDir)
+
+	objectDirs := make(map[string]bool)
+	for _, dir := range initializer.ObjectStorageDirs {
+		objectDirs[dir] = true
+		if err := walkFiles(filepath.Join(storageDir, dir), dir, files); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := os.ReadDir(mm.DgitDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if localOnly[name] || objectDirs[name] || strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		if err := walkFiles(filepath.Join(mm.DgitDir, name), name, files); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// writeMarker records the mirror's source and last completed sync
+func (mm *MirrorManager) writeMarker(target string, marker Marker) error {
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(target, MarkerFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to mark mirror: %w", err)
+	}
+	return nil
+}
+
+// writeMirrorConfig copies the shared repository config with storage pointed inside the mirror
+// Machine-local overrides (config.local) stay behind
+func (mm *MirrorManager) writeMirrorConfig(target string) error {
+	config, err := initializer.GetSharedConfig(mm.DgitDir)
+	if err != nil {
+		return err
+	}
+	config.Storage.Path = ""
+	return initializer.UpdateSharedConfig(target, config)
+}
+
+// checkTarget refuses locations inside the repository or holding unrelated data
+func (mm *MirrorManager) checkTarget(path string) error {
+	root := filepath.Dir(mm.DgitDir)
+	if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return fmt.Errorf("mirror %s must be outside the repository", path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if _, ok := IsMirror(filepath.Join(path, initializer.DGitDir)); !ok {
+		return fmt.Errorf("%s is not empty and is not a dgit mirror; refusing to write there", path)
+	}
+	return nil
+}
+
+func (mm *MirrorManager) update(mirror *Mirror) error {
+	mirrors, err := mm.List()
+	if err != nil {
+		return err
+	}
+	for i, m := range mirrors {
+		if m.Name == mirror.Name {
+			mirrors[i] = mirror
+		}
+	}
+	return mm.save(mirrors)
+}
+
+func (mm *MirrorManager) save(mirrors []*Mirror) error {
+	data, err := json.MarshalIndent(mirrors, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := mm.RegistryFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, mm.RegistryFile)
+}
+
+// walkFiles adds every regular file under root to files, keyed by prefix-relative slash path
+func walkFiles(root, prefix string, files map[string]string) error {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		key := prefix
+		if rel != "." {
+			key = prefix + "/" + filepath.ToSlash(rel)
+		}
+		files[key] = path
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// orderForSync sorts paths so object data comes first and refs last
+func orderForSync(files map[string]string) []string {
+	rank := func(rel string) int {
+		top := strings.SplitN(rel, "/", 2)[0]
+		for _, dir := range initializer.ObjectStorageDirs {
+			if top == dir {
+				return 0
+			}
+		}
+		for _, last := range lastEntries {
+			if rel == last {
+				return 2
+			}
+		}
+		return 1
+	}
+
+	paths := make([]string, 0, len(files))
+	for rel := range files {
+		paths = append(paths, rel)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if ri, rj := rank(paths[i]), rank(paths[j]); ri != rj {
+			return ri < rj
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// extraFiles lists mirrored files that no longer exist in the source
+func extraFiles(target string, files map[string]string) ([]string, error) {
+	mirrored := make(map[string]string)
+	if err := walkFiles(target, "", mirrored); err != nil {
+		return nil, err
+	}
+
+	var extra []string
+	for key := range mirrored {
+		rel := strings.TrimPrefix(key, "/")
+		// Restores run inside a mirror keep their own local state (cache, temp)
+		if localOnly[strings.SplitN(rel, "/", 2)[0]] || rel == "config" {
+			continue
+		}
+		if _, ok := files[rel]; !ok {
+			extra = append(extra, rel)
+		}
+	}
+	sort.Strings(extra)
+	return extra, nil
+}
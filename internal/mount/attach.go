@@ -0,0 +1,13 @@
+package mount
+
+import "errors"
+
+// ErrNoClient is returned when dgit cannot mount the share on this platform itself;
+// the share can still be connected from the file manager
+var ErrNoClient = errors.New("no WebDAV client dgit can drive on this platform")
+
+// Attach mounts the share served at url on dir with the operating system's WebDAV
+// client, returning a function that unmounts it
+func Attach(url, dir string) (func() error, error) {
+	return attach(url, dir)
+}
@@ -0,0 +1,18 @@
+package mount
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// attach mounts with mount_webdav, which Finder uses for "Connect to Server"
+func attach(url, dir string) (func() error, error) {
+	output, err := exec.Command("mount_webdav", "-S", "-v", "DGit history", "-o", "rdonly", url, dir).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("mount_webdav failed: %s", strings.TrimSpace(string(output)))
+	}
+	return func() error {
+		return exec.Command("umount", dir).Run()
+	}, nil
+}
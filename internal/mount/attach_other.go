@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package mount
+
+// attach is not available here; Windows maps the share as a network drive from Explorer
+func attach(url, dir string) (func() error, error) {
+	return nil, ErrNoClient
+}
@@ -0,0 +1,385 @@
+// Package mount serves repository history as a read-only folder tree, one folder per
+// version, so old versions can be opened or dragged into design apps from the file
+// manager. The tree is a WebDAV share, which Finder, Explorer and Linux file managers
+// mount without extra drivers; files are materialized from storage on first read.
+package mount
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dgit/internal/hidden"
+	"dgit/internal/log"
+	"dgit/internal/progress"
+	"dgit/internal/replica"
+	"dgit/internal/restore"
+)
+
+// Server answers WebDAV and browser requests for the history tree
+//
+//	/v12/                 one folder per version
+//	/v12/hero.psd         files as committed in that version
+//	/v12/exports/a.psd    folders follow the committed paths
+type Server struct {
+	DgitDir string
+
+	// CacheDir is where versions are materialized on first read
+	CacheDir string
+
+	// IncludeHidden lists versions hidden with 'dgit hide'
+	IncludeHidden bool
+
+	// OnMaterialize is told when a version has been materialized, for logging
+	OnMaterialize func(version int, took time.Duration, err error)
+
+	mu       sync.Mutex
+	versions map[int]string // Materialized versions and their directories
+	restore  sync.Mutex     // One restore at a time
+}
+
+// NewServer creates a server for the repository at dgitDir
+func NewServer(dgitDir, cacheDir string) *Server {
+	return &Server{DgitDir: dgitDir, CacheDir: cacheDir, versions: make(map[int]string)}
+}
+
+// node is a folder or file in the history tree
+type node struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+	version int
+	path    string // Committed path of a file, or folder prefix inside a version
+}
+
+// ServeHTTP implements the read-only subset of WebDAV clients need to browse and copy
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1")
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PROPFIND")
+		w.WriteHeader(http.StatusOK)
+	case "PROPFIND":
+		s.propfind(w, r)
+	case http.MethodGet, http.MethodHead:
+		s.get(w, r)
+	case http.MethodPut, http.MethodDelete, "MKCOL", "MOVE", "COPY", "PROPPATCH", "LOCK", "UNLOCK":
+		http.Error(w, "history is read-only", http.StatusForbidden)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// lookup resolves a URL path to a node and, for folders, its children
+func (s *Server) lookup(urlPath string) (*node, []*node, error) {
+	clean := strings.Trim(path.Clean("/"+urlPath), "/")
+	logManager := log.NewLogManager(s.DgitDir)
+
+	if clean == "" {
+		root := &node{name: "", dir: true}
+		children, err := s.versionFolders(logManager)
+		if len(children) > 0 {
+			root.modTime = children[len(children)-1].modTime
+		}
+		return root, children, err
+	}
+
+	first, rest, _ := strings.Cut(clean, "/")
+	version, err := strconv.Atoi(strings.TrimPrefix(first, "v"))
+	if err != nil || !strings.HasPrefix(first, "v") || s.hidden(version) {
+		return nil, nil, os.ErrNotExist
+	}
+	commit, err := logManager.GetCommit(version)
+	if err != nil {
+		return nil, nil, os.ErrNotExist
+	}
+
+	// Files of the version under the requested folder, and folders on the way to them
+	prefix := ""
+	if rest != "" {
+		prefix = rest + "/"
+	}
+	folders := make(map[string]bool)
+	var children []*node
+	for file := range commit.Metadata {
+		if file == rest {
+			return s.fileNode(commit, file), nil, nil
+		}
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+		inside := strings.TrimPrefix(file, prefix)
+		if name, _, nested := strings.Cut(inside, "/"); nested {
+			if !folders[name] {
+				folders[name] = true
+				children = append(children, &node{name: name, dir: true, modTime: commit.Timestamp, version: version, path: prefix + name})
+			}
+			continue
+		}
+		children = append(children, s.fileNode(commit, file))
+	}
+	if rest != "" && len(children) == 0 {
+		return nil, nil, os.ErrNotExist
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+
+	name := first
+	if rest != "" {
+		name = path.Base(rest)
+	}
+	return &node{name: name, dir: true, modTime: commit.Timestamp, version: version, path: rest}, children, nil
+}
+
+// versionFolders lists one folder per version, oldest first
+func (s *Server) versionFolders(logManager *log.LogManager) ([]*node, error) {
+	commits, err := logManager.GetCommitHistory()
+	if err != nil {
+		return nil, err
+	}
+	hiddenVersions := hidden.NewHiddenManager(s.DgitDir).Versions()
+	var folders []*node
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		if !s.IncludeHidden && hiddenVersions[commit.Version] {
+			continue
+		}
+		folders = append(folders, &node{name: fmt.Sprintf("v%d", commit.Version), dir: true,
+			modTime: commit.Timestamp, version: commit.Version})
+	}
+	return folders, nil
+}
+
+// fileNode describes a committed file, using its real size once materialized
+func (s *Server) fileNode(commit *log.Commit, file string) *node {
+	n := &node{name: path.Base(file), modTime: commit.Timestamp, version: commit.Version, path: file}
+	if meta, ok := commit.Metadata[file].(map[string]interface{}); ok {
+		if size, ok := meta["size"].(float64); ok {
+			n.size = int64(size)
+		}
+	}
+	if local := s.localPath(n); local != "" {
+		if info, err := os.Stat(local); err == nil {
+			n.size = info.Size()
+		}
+	}
+	return n
+}
+
+// hidden reports whether a version is left out of the tree
+func (s *Server) hidden(version int) bool {
+	return !s.IncludeHidden && hidden.NewHiddenManager(s.DgitDir).Versions()[version]
+}
+
+// localPath returns where a materialized file is, or "" when its version is not yet
+func (s *Server) localPath(n *node) string {
+	s.mu.Lock()
+	dir, ok := s.versions[n.version]
+	s.mu.Unlock()
+	if !ok {
+		return ""
+	}
+	return filepath.Join(dir, filepath.FromSlash(n.path))
+}
+
+// materialize restores every file of version into the cache once
+// Design versions hold a handful of files that are usually opened together, and one
+// restore reads the snapshot or replays the delta chain only once for all of them.
+func (s *Server) materialize(version int) (string, error) {
+	s.restore.Lock()
+	defer s.restore.Unlock()
+
+	s.mu.Lock()
+	dir, ok := s.versions[version]
+	s.mu.Unlock()
+	if ok {
+		return dir, nil
+	}
+
+	start := time.Now()
+	dir = filepath.Join(s.CacheDir, fmt.Sprintf("v%d", version))
+	err := s.restoreInto(version, dir)
+	if s.OnMaterialize != nil {
+		s.OnMaterialize(version, time.Since(start), err)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.versions[version] = dir
+	s.mu.Unlock()
+	return dir, nil
+}
+
+// restoreInto restores version into an empty directory, fetching objects in a replica
+func (s *Server) restoreInto(version int, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if _, ok := replica.IsReplica(s.DgitDir); ok {
+		manager, err := replica.NewReplicaManager(s.DgitDir)
+		if err != nil {
+			return err
+		}
+		if _, err := manager.Fetch([]int{version}); err != nil {
+			return fmt.Errorf("fetching objects from the shared store: %w", err)
+		}
+	}
+
+	rm := restore.NewRestoreManager(s.DgitDir)
+	rm.WorkDir = dir
+	rm.Progress = func(progress.Event) {}
+	_, err := rm.Restore(fmt.Sprintf("v%d", version), nil)
+	return err
+}
+
+// get serves a file, or an HTML listing of a folder for browsers
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	n, children, err := s.lookup(r.URL.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if n.dir {
+		s.listing(w, r, n, children)
+		return
+	}
+
+	if _, err := s.materialize(n.version); err != nil {
+		http.Error(w, fmt.Sprintf("restoring v%d: %v", n.version, err), http.StatusInternalServerError)
+		return
+	}
+	file, err := os.Open(s.localPath(n))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s was not restored from v%d", n.path, n.version), http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Cache-Control", "max-age=31536000, immutable") // Versions never change
+	http.ServeContent(w, r, n.name, n.modTime, file)
+}
+
+// listing writes a plain HTML folder listing
+func (s *Server) listing(w http.ResponseWriter, r *http.Request, n *node, children []*node) {
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	title := "DGit history"
+	if n.name != "" {
+		title = html.EscapeString(strings.Trim(r.URL.Path, "/"))
+	}
+	fmt.Fprintf(w, "<!doctype html>\n<title>%s</title>\n<h1>%s</h1>\n<ul>\n", title, title)
+	if n.name != "" {
+		fmt.Fprintln(w, `<li><a href="../">../</a></li>`)
+	}
+	for _, child := range children {
+		name := child.name
+		if child.dir {
+			name += "/"
+		}
+		fmt.Fprintf(w, "<li><a href=\"%s\">%s</a></li>\n", (&url.URL{Path: name}).EscapedPath(), html.EscapeString(name))
+	}
+	fmt.Fprintln(w, "</ul>")
+}
+
+// WebDAV multistatus response
+type multistatus struct {
+	XMLName   xml.Name   `xml:"D:multistatus"`
+	Namespace string     `xml:"xmlns:D,attr"`
+	Responses []response `xml:"D:response"`
+}
+
+type response struct {
+	Href     string   `xml:"D:href"`
+	Propstat propstat `xml:"D:propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"D:prop"`
+	Status string `xml:"D:status"`
+}
+
+type prop struct {
+	DisplayName   string        `xml:"D:displayname"`
+	ResourceType  *resourceType `xml:"D:resourcetype"`
+	ContentLength *int64        `xml:"D:getcontentlength,omitempty"`
+	ContentType   string        `xml:"D:getcontenttype,omitempty"`
+	LastModified  string        `xml:"D:getlastmodified"`
+	CreationDate  string        `xml:"D:creationdate"`
+}
+
+type resourceType struct {
+	Collection *struct{} `xml:"D:collection,omitempty"`
+}
+
+// propfind lists a node, and its children unless the client asked for depth 0
+func (s *Server) propfind(w http.ResponseWriter, r *http.Request) {
+	n, children, err := s.lookup(r.URL.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	base := "/" + strings.Trim(path.Clean("/"+r.URL.Path), "/")
+	if base != "/" {
+		base += "/"
+	}
+	if !n.dir {
+		base = strings.TrimSuffix(base, "/")
+	}
+	result := multistatus{Namespace: "DAV:", Responses: []response{davResponse(base, n)}}
+	if n.dir && r.Header.Get("Depth") != "0" {
+		for _, child := range children {
+			href := base + child.name
+			if child.dir {
+				href += "/"
+			}
+			result.Responses = append(result.Responses, davResponse(href, child))
+		}
+	}
+
+	data, err := xml.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", `application/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+// davResponse describes one node in a multistatus response
+func davResponse(href string, n *node) response {
+	p := prop{
+		DisplayName:  n.name,
+		ResourceType: &resourceType{},
+		LastModified: n.modTime.UTC().Format(http.TimeFormat),
+		CreationDate: n.modTime.UTC().Format(time.RFC3339),
+	}
+	if n.dir {
+		p.ResourceType.Collection = &struct{}{}
+	} else {
+		size := n.size
+		p.ContentLength = &size
+		p.ContentType = "application/octet-stream"
+	}
+	return response{
+		Href:     (&url.URL{Path: href}).EscapedPath(),
+		Propstat: propstat{Prop: p, Status: "HTTP/1.1 200 OK"},
+	}
+}
@@ -0,0 +1,159 @@
+package objfmt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Object header layout (16 bytes, prepended to every stored object):
+//
+//	0-3   magic "DGIT"
+//	4     object type
+//	5     object format version
+//	6-8   minimum DGit release able to read this format (major, minor, patch)
+//	9-15  reserved (zero)
+//
+// Objects written before headers existed have no magic and are read as format 0.
+
+const (
+	Magic      = "DGIT"
+	HeaderSize = 16
+
+	// FormatVersion is the object format written by this build and the highest it can read
+	FormatVersion = 1
+
+	// MinReaderVersion is the oldest DGit release that understands FormatVersion
+	MinReaderVersion = "2.1.0"
+)
+
+// ObjectType identifies what a stored object contains
+type ObjectType byte
+
+const (
+	TypeSnapshot     ObjectType = 'S' // Structured "FILE:path:size" stream (LZ4/Zstd)
+	TypeDelta        ObjectType = 'D' // bsdiff patch or PSD smart delta
+	TypeMetadataPack ObjectType = 'M' // Packed commit metadata records
+)
+
+// Header describes a stored object's format
+type Header struct {
+	Type          ObjectType
+	FormatVersion int
+	MinReader     string
+}
+
+// UnsupportedFormatError is returned when an object was written by a newer DGit
+type UnsupportedFormatError struct {
+	Path          string
+	FormatVersion int
+	MinReader     string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf("%s: repository requires DGit >= %s (object format v%d, this build reads up to v%d)",
+		e.Path, e.MinReader, e.FormatVersion, FormatVersion)
+}
+
+// WriteHeader writes the current-format header for an object type
+func WriteHeader(w io.Writer, objectType ObjectType) error {
+	header := make([]byte, HeaderSize)
+	copy(header, Magic)
+	header[4] = byte(objectType)
+	header[5] = FormatVersion
+	copy(header[6:9], encodeRelease(MinReaderVersion))
+	_, err := w.Write(header)
+	return err
+}
+
+// NewReader returns a reader positioned after the object header
+// Headerless legacy objects are passed through unchanged
+func NewReader(r io.Reader, path string) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	peek, err := buffered.Peek(HeaderSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if !bytes.HasPrefix(peek, []byte(Magic)) {
+		return buffered, nil
+	}
+
+	if len(peek) < HeaderSize {
+		return nil, fmt.Errorf("%s: truncated object header", path)
+	}
+	if _, err := parseHeader(peek, path); err != nil {
+		return nil, err
+	}
+	buffered.Discard(HeaderSize)
+	return buffered, nil
+}
+
+// Strip validates and removes the header from an in-memory object
+func Strip(data []byte, path string) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(Magic)) {
+		return data, nil
+	}
+	if len(data) < HeaderSize {
+		return nil, fmt.Errorf("%s: truncated object header", path)
+	}
+	if _, err := parseHeader(data[:HeaderSize], path); err != nil {
+		return nil, err
+	}
+	return data[HeaderSize:], nil
+}
+
+// ReadHeader reads an object's header without consuming its payload
+// Legacy objects return a format 0 header
+func ReadHeader(path string) (*Header, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, HeaderSize)
+	n, _ := io.ReadFull(file, buf)
+	if n < len(Magic) || !bytes.HasPrefix(buf, []byte(Magic)) {
+		return &Header{}, nil
+	}
+	if n < HeaderSize {
+		return nil, fmt.Errorf("%s: truncated object header", path)
+	}
+	return parseHeader(buf, path)
+}
+
+// CheckRelease returns an error if a repository format is newer than this build supports
+func CheckRelease(formatVersion int, minReader, location string) error {
+	if formatVersion > FormatVersion {
+		return &UnsupportedFormatError{Path: location, FormatVersion: formatVersion, MinReader: minReader}
+	}
+	return nil
+}
+
+// parseHeader decodes a header and rejects formats newer than this build
+func parseHeader(buf []byte, path string) (*Header, error) {
+	header := &Header{
+		Type:          ObjectType(buf[4]),
+		FormatVersion: int(buf[5]),
+		MinReader:     fmt.Sprintf("%d.%d.%d", buf[6], buf[7], buf[8]),
+	}
+	if err := CheckRelease(header.FormatVersion, header.MinReader, path); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// encodeRelease packs "major.minor.patch" into three bytes
+func encodeRelease(release string) []byte {
+	out := make([]byte, 3)
+	for i, part := range strings.SplitN(release, ".", 3) {
+		if n, err := strconv.Atoi(part); err == nil && n >= 0 && n < 256 {
+			out[i] = byte(n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package pathnorm
+
+import (
+	"unicode/utf8"
+
+	initializer "dgit/internal/init"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization forms accepted in config ("paths.normalization")
+const (
+	FormNFC  = "nfc"  // Composed (Windows, Linux, most editors)
+	FormNFD  = "nfd"  // Decomposed (macOS HFS+/APFS file listings)
+	FormNone = "none" // Store paths exactly as the filesystem reports them
+)
+
+// Status match modes accepted in config ("paths.status_match")
+const (
+	MatchNormalized = "normalized" // Treat NFC/NFD spellings of a path as the same file
+	MatchExact      = "exact"      // Byte-for-byte comparison
+)
+
+// Policy controls how manifest paths are stored and compared
+type Policy struct {
+	Form      string
+	MatchMode string
+}
+
+// LoadPolicy reads the repository's path policy; unset values default to NFC + normalized matching
+func LoadPolicy(dgitDir string) Policy {
+	policy := Policy{Form: FormNFC, MatchMode: MatchNormalized}
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		if config.Paths.Normalization != "" {
+			policy.Form = config.Paths.Normalization
+		}
+		if config.Paths.StatusMatch != "" {
+			policy.MatchMode = config.Paths.StatusMatch
+		}
+	}
+	return policy
+}
+
+// Apply normalizes a path for storage in manifests according to the policy
+func (p Policy) Apply(path string) string {
+	switch p.Form {
+	case FormNFD:
+		return NFD(path)
+	case FormNone:
+		return path
+	default:
+		return NFC(path)
+	}
+}
+
+// MatchKey returns the key used to compare paths in status; equal keys mean the same file
+func (p Policy) MatchKey(path string) string {
+	if p.MatchMode == MatchExact {
+		return path
+	}
+	return NFC(path)
+}
+
+// Equal reports whether two paths are the same under Unicode canonical equivalence
+func Equal(a, b string) bool {
+	return a == b || NFC(a) == NFC(b)
+}
+
+// IsASCII reports whether normalization can be skipped entirely
+func IsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// NFD returns the canonically decomposed form of s
+func NFD(s string) string {
+	if IsASCII(s) {
+		return s
+	}
+	return norm.NFD.String(s)
+}
+
+// NFC returns the canonically composed form of s
+func NFC(s string) string {
+	if IsASCII(s) {
+		return s
+	}
+	return norm.NFC.String(s)
+}
@@ -0,0 +1,70 @@
+package pathnorm
+
+import "testing"
+
+// Each case is one file name as Windows (NFC) and macOS (NFD) report it
+var spellings = []struct {
+	name string
+	nfc  string
+	nfd  string
+}{
+	{"korean", "시안/최종_수정.psd", "\u1109\u1175\u110B\u1161\u11AB/\u110E\u116C\u110C\u1169\u11BC_\u1109\u116E\u110C\u1165\u11BC.psd"},
+	{"korean without final", "로고.ai", "\u1105\u1169\u1100\u1169.ai"},
+	{"japanese dakuten", "デザイン/ガイド.psd", "テ\u3099サ\u3099イン/カ\u3099イト\u3099.psd"},
+	{"japanese handakuten", "ポスター.ai", "ホ\u309Aスター.ai"},
+	{"latin", "café/Übersicht.sketch", "cafe\u0301/U\u0308bersicht.sketch"},
+	{"cyrillic", "Йошкар-Ола/ёлка.psd", "И\u0306ошкар-Ола/е\u0308лка.psd"},
+	{"greek", "ῷδή.psd", "ω\u0342\u0345δη\u0301.psd"},
+	{"vietnamese stacked", "Thiết kế/Bộ nhận diện.psd", "Thie\u0302\u0301t ke\u0302\u0301/Bo\u0323\u0302 nha\u0302\u0323n die\u0323\u0302n.psd"},
+}
+
+func TestNFCAndNFDSpellingsMatch(t *testing.T) {
+	policy := Policy{Form: FormNFC, MatchMode: MatchNormalized}
+	for _, tc := range spellings {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NFC(tc.nfd); got != tc.nfc {
+				t.Errorf("NFC(%+q) = %+q, want %+q", tc.nfd, got, tc.nfc)
+			}
+			if got := NFD(tc.nfc); got != NFD(tc.nfd) {
+				t.Errorf("NFD(%+q) = %+q, want %+q", tc.nfc, got, NFD(tc.nfd))
+			}
+			if !Equal(tc.nfc, tc.nfd) {
+				t.Errorf("Equal(%+q, %+q) is false", tc.nfc, tc.nfd)
+			}
+			if policy.MatchKey(tc.nfc) != policy.MatchKey(tc.nfd) {
+				t.Errorf("status keys differ for %+q and %+q", tc.nfc, tc.nfd)
+			}
+			if policy.Apply(tc.nfd) != tc.nfc {
+				t.Errorf("manifest path for %+q is %+q, want %+q", tc.nfd, policy.Apply(tc.nfd), tc.nfc)
+			}
+		})
+	}
+}
+
+// Combining marks may arrive in any order a tool wrote them; canonical reordering
+// makes every order of marks with different classes the same name
+func TestMarkOrderDoesNotMatter(t *testing.T) {
+	below := "Thie\u0323\u0302u.psd" // dot below (class 220) before circumflex (230)
+	above := "Thie\u0302\u0323u.psd" // circumflex before dot below
+	if NFC(below) != "Thiệu.psd" || NFC(above) != "Thiệu.psd" {
+		t.Fatalf("NFC(%+q) = %+q, NFC(%+q) = %+q, want both %+q", below, NFC(below), above, NFC(above), "Thiệu.psd")
+	}
+	if NFD(below) != NFD(above) {
+		t.Fatalf("NFD spellings differ: %+q and %+q", NFD(below), NFD(above))
+	}
+	if !Equal(below, above) {
+		t.Fatalf("Equal(%+q, %+q) is false", below, above)
+	}
+}
+
+func TestExactMatchKeepsSpellingsApart(t *testing.T) {
+	policy := Policy{Form: FormNone, MatchMode: MatchExact}
+	for _, tc := range spellings {
+		if policy.MatchKey(tc.nfc) == policy.MatchKey(tc.nfd) {
+			t.Errorf("exact match treats %+q and %+q as the same file", tc.nfc, tc.nfd)
+		}
+		if policy.Apply(tc.nfd) != tc.nfd {
+			t.Errorf("form none changed %+q", tc.nfd)
+		}
+	}
+}
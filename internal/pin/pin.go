@@ -0,0 +1,278 @@
+package pin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/log"
+	"dgit/internal/progress"
+	"dgit/internal/restore"
+	"dgit/internal/status"
+	"dgit/internal/trash"
+)
+
+// A pinfile (lockfile) maps asset paths to exact committed versions so build pipelines can
+// fetch the same bytes every time. Each entry records the version, the commit hash (so a
+// pinfile is never applied to the wrong repository) and the file's content hash, which is
+// checked after every file is materialized.
+
+// FormatVersion is the pinfile format written by Pin
+const FormatVersion = 1
+
+// Entry pins one asset path
+type Entry struct {
+	Path    string `json:"path"`
+	Version int    `json:"version"`
+	Commit  string `json:"commit"`
+	Hash    string `json:"hash"`
+}
+
+// Lockfile is the content of a pinfile
+type Lockfile struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []Entry   `json:"files"`
+}
+
+// Result describes what Materialize did with each entry
+type Result struct {
+	Written []Entry      // Restored and verified
+	Current []Entry      // Already present with the pinned content
+	Backup  *trash.Entry // Project files replaced by pinned versions, when dest is the project
+}
+
+// MismatchError reports a restored file whose content differs from its pinned hash
+type MismatchError struct {
+	Path    string
+	Version int
+	Got     string
+	Want    string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("%s from v%d does not match its pinned hash (got %s, want %s)", e.Path, e.Version, e.Got, e.Want)
+}
+
+// PinManager creates pinfiles and materializes the assets they name
+type PinManager struct {
+	DgitDir string
+	TempDir string
+}
+
+// NewPinManager creates a new pin manager
+func NewPinManager(dgitDir string) *PinManager {
+	return &PinManager{
+		DgitDir: dgitDir,
+		TempDir: filepath.Join(dgitDir, "temp"),
+	}
+}
+
+// Pin pins paths as committed in ref ("v3", "3"; empty for the latest version)
+// With no paths, every file in that version is pinned
+func (pm *PinManager) Pin(paths []string, ref string) (*Lockfile, error) {
+	commit, err := pm.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		for path := range commit.Metadata {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	lock := &Lockfile{Format: FormatVersion, CreatedAt: time.Now()}
+	for _, path := range paths {
+		fileMeta, ok := commit.Metadata[path].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is not tracked in v%d", path, commit.Version)
+		}
+		hash, _ := fileMeta["hash"].(string)
+		if hash == "" {
+			return nil, fmt.Errorf("v%d has no content hash for %s; commit it again before pinning", commit.Version, path)
+		}
+		lock.Files = append(lock.Files, Entry{Path: path, Version: commit.Version, Commit: commit.Hash, Hash: hash})
+	}
+	return lock, nil
+}
+
+// Load reads a pinfile
+func Load(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("%s is not a valid pinfile: %w", path, err)
+	}
+	if lock.Format > FormatVersion {
+		return nil, fmt.Errorf("%s uses pinfile format %d; upgrade dgit to read it", path, lock.Format)
+	}
+	return &lock, nil
+}
+
+// Save writes a pinfile
+func (l *Lockfile) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Materialize writes every pinned file under dest, restoring versions from the object store
+// Files already holding the pinned content are left untouched; when dest is the project
+// itself, files about to be replaced are saved to the DGit trash first
+func (pm *PinManager) Materialize(lock *Lockfile, dest string) (*Result, error) {
+	logManager := log.NewLogManager(pm.DgitDir)
+	result := &Result{}
+	var replaced []string
+
+	// Restore one version at a time with all of its pinned files
+	byVersion := make(map[int][]Entry)
+	var versions []int
+	for _, entry := range lock.Files {
+		target := filepath.Join(dest, filepath.FromSlash(entry.Path))
+		if hash, err := status.CalculateFileHash(target); err == nil {
+			if hash == entry.Hash {
+				result.Current = append(result.Current, entry)
+				continue
+			}
+			replaced = append(replaced, entry.Path)
+		}
+
+		commit, err := logManager.GetCommit(entry.Version)
+		if err != nil || commit.Hash != entry.Commit {
+			return result, fmt.Errorf("%s: v%d (%s) is not in this repository", entry.Path, entry.Version, entry.Commit)
+		}
+		if _, ok := byVersion[entry.Version]; !ok {
+			versions = append(versions, entry.Version)
+		}
+		byVersion[entry.Version] = append(byVersion[entry.Version], entry)
+	}
+	sort.Ints(versions)
+
+	if absDest, err := filepath.Abs(dest); err == nil && absDest == filepath.Dir(pm.DgitDir) && len(replaced) > 0 {
+		backup, err := trash.NewTrashManager(pm.DgitDir).Save(absDest, replaced, "materialize from pinfile")
+		if err != nil {
+			return result, fmt.Errorf("failed to back up files before replacing them: %w", err)
+		}
+		result.Backup = backup
+	}
+
+	for _, version := range versions {
+		written, err := pm.materializeVersion(version, byVersion[version], dest)
+		result.Written = append(result.Written, written...)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// materializeVersion restores the entries of one version into a temp dir, verifies them
+// and moves them into place
+func (pm *PinManager) materializeVersion(version int, entries []Entry, dest string) ([]Entry, error) {
+	if err := os.MkdirAll(pm.TempDir, 0755); err != nil {
+		return nil, err
+	}
+	checkoutDir, err := os.MkdirTemp(pm.TempDir, "materialize-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(checkoutDir)
+
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.Path
+	}
+
+	restoreManager := restore.NewRestoreManager(pm.DgitDir)
+	restoreManager.WorkDir = checkoutDir
+	restoreManager.Progress = func(progress.Event) {}
+	if _, err := restoreManager.Restore(fmt.Sprintf("v%d", version), paths); err != nil {
+		return nil, fmt.Errorf("failed to restore v%d: %w", version, err)
+	}
+
+	var written []Entry
+	for _, entry := range entries {
+		restored := filepath.Join(checkoutDir, filepath.FromSlash(entry.Path))
+		hash, err := status.CalculateFileHash(restored)
+		if err != nil {
+			return written, fmt.Errorf("%s was not restored from v%d", entry.Path, version)
+		}
+		if hash != entry.Hash {
+			return written, &MismatchError{Path: entry.Path, Version: version, Got: hash, Want: entry.Hash}
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(entry.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return written, err
+		}
+		if err := moveFile(restored, target); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		written = append(written, entry)
+	}
+	return written, nil
+}
+
+// resolve returns the commit named by ref, or the latest one
+func (pm *PinManager) resolve(ref string) (*log.Commit, error) {
+	logManager := log.NewLogManager(pm.DgitDir)
+	version := logManager.GetCurrentVersion()
+	if ref != "" {
+		parsed, err := strconv.Atoi(strings.TrimPrefix(ref, "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version '%s'", ref)
+		}
+		version = parsed
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("no commits yet")
+	}
+
+	commit, err := logManager.GetCommit(version)
+	if err != nil {
+		return nil, fmt.Errorf("version v%d not found", version)
+	}
+	return commit, nil
+}
+
+// moveFile renames src over dst, copying when they are on different volumes
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".dgit-tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,154 @@
+// Package plugin discovers external dgit subcommands. Like git, running 'dgit deliver'
+// when deliver is not a built-in command executes the first dgit-deliver found on PATH,
+// passing the remaining arguments through and describing the repository in environment
+// variables, so studios can ship their own commands without patching dgit.
+package plugin
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prefix is the executable name prefix of plugins
+const Prefix = "dgit-"
+
+// APIVersion is the version of the environment contract below; plugins can refuse to
+// run when it is newer or older than they understand
+const APIVersion = 1
+
+// Environment variables passed to plugins
+const (
+	EnvAPI      = "DGIT_PLUGIN_API"  // APIVersion
+	EnvName     = "DGIT_PLUGIN_NAME" // Plugin name without the prefix
+	EnvExec     = "DGIT_EXEC"        // Absolute path of the dgit executable, for calling back
+	EnvDir      = "DGIT_DIR"         // Repository's .dgit directory; unset outside a repository
+	EnvWorkTree = "DGIT_WORK_TREE"   // Directory containing .dgit
+	EnvStorage  = "DGIT_STORAGE_DIR" // Object storage root, which differs after 'dgit relocate'
+	EnvHead     = "DGIT_HEAD"        // Checked-out version number, 0 before the first commit
+	EnvHeadHash = "DGIT_HEAD_HASH"   // Checked-out commit hash
+	EnvCI       = "DGIT_CI"          // "1" when dgit was started in CI mode
+)
+
+// Plugin is an external command found on PATH
+type Plugin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Context describes the repository a plugin runs in
+type Context struct {
+	Exec     string
+	DgitDir  string
+	WorkTree string
+	Storage  string
+	Head     int
+	HeadHash string
+	CI       bool
+}
+
+// Environ returns the environment for plugin name: the current environment plus the context
+func (c *Context) Environ(name string) []string {
+	env := os.Environ()
+	set := func(key, value string) {
+		env = append(env, key+"="+value)
+	}
+	set(EnvAPI, strconv.Itoa(APIVersion))
+	set(EnvName, name)
+	set(EnvExec, c.Exec)
+	if c.DgitDir != "" {
+		set(EnvDir, c.DgitDir)
+		set(EnvWorkTree, c.WorkTree)
+		set(EnvStorage, c.Storage)
+		set(EnvHead, strconv.Itoa(c.Head))
+		set(EnvHeadHash, c.HeadHash)
+	}
+	if c.CI {
+		set(EnvCI, "1")
+	}
+	return env
+}
+
+// Find looks up the plugin for a subcommand name on PATH
+func Find(name string) (*Plugin, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, false
+	}
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return nil, false
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return &Plugin{Name: name, Path: path}, true
+}
+
+// Discover lists the plugins on PATH, sorted by name; when several directories provide
+// the same plugin the first one wins, as it would when run
+func Discover() []*Plugin {
+	seen := make(map[string]bool)
+	var plugins []*Plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			path, _ := filepath.Abs(filepath.Join(dir, entry.Name()))
+			plugins = append(plugins, &Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the subcommand an executable directory entry provides
+func pluginName(entry os.DirEntry) (string, bool) {
+	fileName := entry.Name()
+	if entry.IsDir() || !strings.HasPrefix(fileName, Prefix) {
+		return "", false
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(fileName, Prefix)
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if !executableExtension(ext) {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	} else if info.Mode()&0111 == 0 {
+		return "", false
+	}
+	return name, name != ""
+}
+
+// executableExtension reports whether Windows runs files with ext directly (PATHEXT)
+func executableExtension(ext string) bool {
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = ".com;.exe;.bat;.cmd"
+	}
+	for _, candidate := range strings.Split(strings.ToLower(pathExt), ";") {
+		if candidate != "" && candidate == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	initializer "dgit/internal/init"
+)
+
+// Asset policies catch files that would fail in production (RGB artwork headed to print,
+// low-resolution images, oversized canvases) when they are committed. Each policy is
+// evaluated against the metadata the scanner recorded for the file; limits the scanner
+// could not determine (no DPI in an AI file, "Unknown" dimensions) are not reported.
+
+// Policy severities
+const (
+	SeverityWarn  = "warn"
+	SeverityBlock = "block"
+)
+
+// Violation is one failed check
+type Violation struct {
+	Policy   string
+	Path     string
+	Severity string
+	Message  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s [%s]", v.Path, v.Message, v.Policy)
+}
+
+// PolicyError is returned when blocking policies fail
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	if len(e.Violations) == 1 {
+		return fmt.Sprintf("asset policy violation: %s", e.Violations[0])
+	}
+	return fmt.Sprintf("%d blocking asset policy violations", len(e.Violations))
+}
+
+// Load returns the policies configured for a repository
+func Load(dgitDir string) []initializer.AssetPolicy {
+	config, err := initializer.GetConfig(dgitDir)
+	if err != nil {
+		return nil
+	}
+	return config.Policies
+}
+
+// Check evaluates every applicable policy against one file's metadata
+func Check(policies []initializer.AssetPolicy, filePath string, meta map[string]interface{}) []Violation {
+	var violations []Violation
+	for _, p := range policies {
+		if !applies(p, filePath, meta) {
+			continue
+		}
+
+		severity := p.Severity
+		if severity != SeverityBlock {
+			severity = SeverityWarn
+		}
+		name := p.Name
+		if name == "" {
+			name = "policy"
+		}
+		report := func(format string, args ...interface{}) {
+			violations = append(violations, Violation{Policy: name, Path: filePath, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if p.ColorMode != "" {
+			if mode, _ := meta["color_mode"].(string); known(mode) && !strings.EqualFold(mode, p.ColorMode) {
+				report("color mode is %s, %s required", mode, p.ColorMode)
+			}
+		}
+		if p.MinDPI > 0 {
+			if dpi := number(meta["resolution"]); dpi > 0 && dpi < int64(p.MinDPI) {
+				report("resolution is %d DPI, at least %d required", dpi, p.MinDPI)
+			}
+		}
+		if p.MaxWidth > 0 || p.MaxHeight > 0 {
+			if width, height, ok := dimensions(meta["dimensions"]); ok {
+				if p.MaxWidth > 0 && width > p.MaxWidth {
+					report("width is %dpx, at most %dpx allowed", width, p.MaxWidth)
+				}
+				if p.MaxHeight > 0 && height > p.MaxHeight {
+					report("height is %dpx, at most %dpx allowed", height, p.MaxHeight)
+				}
+			}
+		}
+		if p.MaxFileSize > 0 {
+			if size := number(meta["size"]); size > p.MaxFileSize {
+				report("file is %.1f MB, at most %.1f MB allowed", float64(size)/(1024*1024), float64(p.MaxFileSize)/(1024*1024))
+			}
+		}
+	}
+	return violations
+}
+
+// Blocking returns the violations that must stop a commit
+func Blocking(violations []Violation) []Violation {
+	var blocking []Violation
+	for _, v := range violations {
+		if v.Severity == SeverityBlock {
+			blocking = append(blocking, v)
+		}
+	}
+	return blocking
+}
+
+// applies reports whether a policy covers a file by path and type
+func applies(p initializer.AssetPolicy, filePath string, meta map[string]interface{}) bool {
+	if len(p.Types) > 0 {
+		fileType, _ := meta["type"].(string)
+		if fileType == "" {
+			fileType = strings.TrimPrefix(filepath.Ext(filePath), ".")
+		}
+		if !containsFold(p.Types, fileType) {
+			return false
+		}
+	}
+	if len(p.Paths) == 0 {
+		return true
+	}
+
+	slashed := filepath.ToSlash(filePath)
+	for _, pattern := range p.Paths {
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(slashed, pattern) {
+			return true
+		}
+		if matched, _ := path.Match(pattern, slashed); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, path.Base(slashed)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// dimensions parses scanner dimensions such as "1920x1080 px"
+func dimensions(value interface{}) (int, int, bool) {
+	text, _ := value.(string)
+	var width, height int
+	if _, err := fmt.Sscanf(text, "%dx%d", &width, &height); err != nil {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// number reads an integer from metadata, which holds int/int64 before a JSON round trip
+// and float64 after
+func number(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+func known(value string) bool {
+	return value != "" && value != "Unknown"
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimPrefix(v, "."), value) {
+			return true
+		}
+	}
+	return false
+}
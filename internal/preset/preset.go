@@ -0,0 +1,186 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	initializer "dgit/internal/init"
+)
+
+// A preset carries the repository settings a studio standardizes across projects
+// (compression, asset policies, path handling, attributes, previews, metadata
+// packing). Machine- and project-specific settings such as the author, storage
+// location, format version and telemetry credentials are never exported.
+
+// FormatVersion is the preset format written by Export
+const FormatVersion = 1
+
+// Sections lists the config sections a preset may carry, by their config key
+var Sections = []string{"compression", "policies", "paths", "attributes", "previews", "metadata", "performance", "environment", "display"}
+
+// Preset is the content of a preset file
+type Preset struct {
+	Format     int                        `json:"format"`
+	Name       string                     `json:"name,omitempty"`
+	ExportedAt time.Time                  `json:"exported_at"`
+	Settings   map[string]json.RawMessage `json:"settings"`
+}
+
+// Change describes how importing a preset affects one config section
+type Change struct {
+	Section string `json:"section"`
+	Changed bool   `json:"changed"`
+}
+
+// PresetManager exports and imports repository configuration presets
+type PresetManager struct {
+	DgitDir    string
+	ConfigFile string
+}
+
+// NewPresetManager creates a new preset manager
+func NewPresetManager(dgitDir string) *PresetManager {
+	return &PresetManager{
+		DgitDir:    dgitDir,
+		ConfigFile: filepath.Join(dgitDir, "config"),
+	}
+}
+
+// Export builds a preset from the repository's shared settings
+func (pm *PresetManager) Export(name string) (*Preset, error) {
+	current, err := pm.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	preset := &Preset{
+		Format:     FormatVersion,
+		Name:       name,
+		ExportedAt: time.Now(),
+		Settings:   make(map[string]json.RawMessage),
+	}
+	for _, section := range Sections {
+		if value, ok := current[section]; ok {
+			preset.Settings[section] = value
+		}
+	}
+	return preset, nil
+}
+
+// Save writes the preset as indented JSON
+func (p *Preset) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Load reads a preset file, rejecting sections a preset may not carry
+func Load(path string) (*Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var preset Preset
+	if err := json.Unmarshal(data, &preset); err != nil {
+		return nil, fmt.Errorf("invalid preset: %w", err)
+	}
+	if preset.Format > FormatVersion {
+		return nil, fmt.Errorf("preset format %d is newer than this dgit supports (%d)", preset.Format, FormatVersion)
+	}
+	for section := range preset.Settings {
+		if !isSection(section) {
+			return nil, fmt.Errorf("preset section '%s' cannot be imported (allowed: %s)", section, strings.Join(Sections, ", "))
+		}
+	}
+	return &preset, nil
+}
+
+// Import replaces config sections with the preset's; only, when given, limits it to those sections
+// With dryRun the config is left unchanged and only the changes are reported
+func (pm *PresetManager) Import(preset *Preset, only []string, dryRun bool) ([]Change, error) {
+	for _, section := range only {
+		if !isSection(section) {
+			return nil, fmt.Errorf("unknown section '%s' (choose %s)", section, strings.Join(Sections, ", "))
+		}
+	}
+
+	current, err := pm.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, section := range Sections {
+		value, ok := preset.Settings[section]
+		if !ok || (len(only) > 0 && !contains(only, section)) {
+			continue
+		}
+		changes = append(changes, Change{Section: section, Changed: !sameJSON(current[section], value)})
+		current[section] = value
+	}
+	if dryRun {
+		return changes, nil
+	}
+
+	// Round-trip through the typed config so a malformed preset never reaches disk
+	merged, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var config initializer.RepositoryConfig
+	if err := json.Unmarshal(merged, &config); err != nil {
+		return nil, fmt.Errorf("preset does not fit the repository config: %w", err)
+	}
+	if err := initializer.UpdateSharedConfig(pm.DgitDir, &config); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// readConfig returns the shared repository config as raw sections; local overrides are
+// per machine and never part of a preset
+func (pm *PresetManager) readConfig() (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(pm.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return sections, nil
+}
+
+// sameJSON compares two JSON values regardless of formatting and key order
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb)
+}
+
+// isSection reports whether name is a section presets may carry
+func isSection(name string) bool {
+	return contains(Sections, name)
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Design apps save a ready-made preview inside most documents: Photoshop writes a JPEG
+// thumbnail image resource, Illustrator embeds a JPEG in the XMP packet. Using those
+// avoids decoding the composite (or rendering at all), so previews cost a few reads
+// even for huge documents. They are small (Photoshop's is at most 160px), which is
+// plenty for history browsing.
+
+// psdThumbnailResource is the image resource ID of the JPEG thumbnail (Photoshop 5.0+)
+const psdThumbnailResource = 0x040C
+
+// aiXMPScanLimit bounds how far into an AI file the XMP packet is searched for
+const aiXMPScanLimit = 16 << 20
+
+// embeddedPreview returns the preview image saved inside a document, if any
+func embeddedPreview(path string) (image.Image, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".psd", ".psb":
+		return psdEmbeddedThumbnail(path)
+	case ".ai":
+		return aiEmbeddedThumbnail(path)
+	default:
+		return nil, ErrNoPreview
+	}
+}
+
+// psdEmbeddedThumbnail reads the JPEG thumbnail from the image resources section
+func psdEmbeddedThumbnail(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+
+	if _, err := readPSDHeader(r); err != nil {
+		return nil, err
+	}
+	if err := skipSection(r, false); err != nil { // Color mode data
+		return nil, err
+	}
+	length, err := sectionLength(r, false)
+	if err != nil {
+		return nil, err
+	}
+	resources := io.LimitReader(r, int64(length))
+
+	for {
+		var block struct {
+			Signature [4]byte
+			ID        uint16
+		}
+		if err := binary.Read(resources, binary.BigEndian, &block); err != nil {
+			return nil, ErrNoPreview
+		}
+		if string(block.Signature[:]) != "8BIM" {
+			return nil, fmt.Errorf("corrupt image resource block")
+		}
+
+		// Pascal string name, padded so length byte + name is even
+		var nameLength [1]byte
+		if _, err := io.ReadFull(resources, nameLength[:]); err != nil {
+			return nil, ErrNoPreview
+		}
+		if _, err := io.CopyN(io.Discard, resources, int64(nameLength[0])+int64(1-nameLength[0]%2)); err != nil {
+			return nil, ErrNoPreview
+		}
+
+		var size uint32
+		if err := binary.Read(resources, binary.BigEndian, &size); err != nil {
+			return nil, ErrNoPreview
+		}
+		padded := int64(size) + int64(size%2)
+
+		if block.ID != psdThumbnailResource {
+			if _, err := io.CopyN(io.Discard, resources, padded); err != nil {
+				return nil, ErrNoPreview
+			}
+			continue
+		}
+
+		// 28-byte thumbnail header (format 1 = JFIF), then the JPEG stream
+		data := make([]byte, size)
+		if _, err := io.ReadFull(resources, data); err != nil {
+			return nil, err
+		}
+		if len(data) < 28 || binary.BigEndian.Uint32(data[:4]) != 1 {
+			return nil, ErrNoPreview
+		}
+		return jpeg.Decode(bytes.NewReader(data[28:]))
+	}
+}
+
+// aiEmbeddedThumbnail decodes the base64 JPEG from the XMP xmpGImg:image property
+func aiEmbeddedThumbnail(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, aiXMPScanLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, ok := xmpImage(data)
+	if !ok {
+		return nil, ErrNoPreview
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XMP thumbnail: %w", err)
+	}
+	return jpeg.Decode(bytes.NewReader(raw))
+}
+
+// xmpImage extracts the base64 payload of xmpGImg:image, in element or attribute form
+func xmpImage(data []byte) (string, bool) {
+	var payload []byte
+	if start := bytes.Index(data, []byte("<xmpGImg:image>")); start >= 0 {
+		rest := data[start+len("<xmpGImg:image>"):]
+		end := bytes.Index(rest, []byte("</xmpGImg:image>"))
+		if end < 0 {
+			return "", false
+		}
+		payload = rest[:end]
+	} else if start := bytes.Index(data, []byte(`xmpGImg:image="`)); start >= 0 {
+		rest := data[start+len(`xmpGImg:image="`):]
+		end := bytes.IndexByte(rest, '"')
+		if end < 0 {
+			return "", false
+		}
+		payload = rest[:end]
+	} else {
+		return "", false
+	}
+
+	// Line breaks are written as XML character references
+	text := strings.NewReplacer("&#xA;", "", "&#xa;", "", "&#10;", "", "&#xD;", "", "&#13;", "").Replace(string(payload))
+	text = strings.Join(strings.Fields(text), "")
+	return text, text != ""
+}
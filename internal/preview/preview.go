@@ -0,0 +1,152 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	initializer "dgit/internal/init"
+)
+
+// Previews are PNG thumbnails of committed file content, stored once per content hash in
+// .dgit/previews/<hash>.png so identical content across versions shares one thumbnail.
+
+// ErrNoPreview is returned for files no preview can be produced for
+var ErrNoPreview = errors.New("no preview available for this file")
+
+// DefaultSize is the longest thumbnail edge when config does not set one
+const DefaultSize = 256
+
+// DefaultRenderers is the renderer order when config does not set one
+var DefaultRenderers = []string{"embedded", "raster"}
+
+// Settings is the preview section of repository config
+type Settings struct {
+	Enabled     bool
+	Size        int
+	MaxFileSize int64
+	Renderers   []string
+	External    []initializer.ExternalRendererConfig
+}
+
+// LoadSettings reads preview settings; repositories created before previews existed
+// have them disabled until 'dgit previews rebuild' or a config change turns them on
+func LoadSettings(dgitDir string) Settings {
+	settings := Settings{Size: DefaultSize, Renderers: DefaultRenderers}
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		settings.Enabled = config.Previews.Enabled
+		settings.MaxFileSize = config.Previews.MaxFileSize
+		settings.External = config.Previews.External
+		if config.Previews.Size > 0 {
+			settings.Size = config.Previews.Size
+		}
+		if len(config.Previews.Renderers) > 0 {
+			settings.Renderers = config.Previews.Renderers
+		}
+	}
+	return settings
+}
+
+// PreviewManager generates and locates thumbnails
+type PreviewManager struct {
+	DgitDir     string
+	PreviewsDir string
+	Size        int
+	Renderers   []Renderer // Tried in order until one produces an image
+}
+
+// NewPreviewManager creates a preview manager using the repository's thumbnail size and renderers
+func NewPreviewManager(dgitDir string) *PreviewManager {
+	settings := LoadSettings(dgitDir)
+	return &PreviewManager{
+		DgitDir:     dgitDir,
+		PreviewsDir: filepath.Join(dgitDir, "previews"),
+		Size:        settings.Size,
+		Renderers:   buildRenderers(settings),
+	}
+}
+
+// Path returns where the thumbnail for a content hash is stored
+func (pm *PreviewManager) Path(hash string) string {
+	return filepath.Join(pm.PreviewsDir, hash+".png")
+}
+
+// Has reports whether a thumbnail exists for a content hash
+func (pm *PreviewManager) Has(hash string) bool {
+	_, err := os.Stat(pm.Path(hash))
+	return err == nil
+}
+
+// Generate writes the thumbnail for the file at path, whose content hash is hash
+// It reports false without doing any work when the thumbnail already exists
+func (pm *PreviewManager) Generate(path, hash string) (bool, error) {
+	if hash == "" {
+		return false, fmt.Errorf("no content hash for %s", path)
+	}
+	if pm.Has(hash) {
+		return false, nil
+	}
+
+	img, err := pm.render(path)
+	if err != nil {
+		return false, err
+	}
+	if err := pm.write(hash, img); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Supports reports whether any configured renderer handles a file type
+func (pm *PreviewManager) Supports(path string) bool {
+	for _, renderer := range pm.Renderers {
+		if renderer.Supports(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// render asks each renderer in turn for an image no larger than pm.Size
+func (pm *PreviewManager) render(path string) (image.Image, error) {
+	var lastErr error = ErrNoPreview
+	for _, renderer := range pm.Renderers {
+		if !renderer.Supports(path) {
+			continue
+		}
+		img, err := renderer.Render(path, pm.Size)
+		if err == nil {
+			return fit(img, pm.Size), nil
+		}
+		if !errors.Is(err, ErrNoPreview) {
+			lastErr = fmt.Errorf("%s renderer: %w", renderer.Name(), err)
+		}
+	}
+	return nil, lastErr
+}
+
+// write stores a thumbnail atomically
+func (pm *PreviewManager) write(hash string, img image.Image) error {
+	if err := os.MkdirAll(pm.PreviewsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create previews directory: %w", err)
+	}
+
+	tmpFile := pm.Path(hash) + ".tmp"
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to encode preview: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return os.Rename(tmpFile, pm.Path(hash))
+}
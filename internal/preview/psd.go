@@ -0,0 +1,287 @@
+package preview
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+)
+
+// PSD/PSB files end with the merged composite the application saved ("Image Data"
+// section): every channel stored planar, raw or PackBits-compressed row by row. Decoding
+// it needs no layer compositing, and streaming rows through a boxScaler keeps memory at
+// thumbnail size even for multi-gigabyte documents.
+
+// PSD color modes
+const (
+	psdModeGrayscale = 1
+	psdModeIndexed   = 2
+	psdModeRGB       = 3
+	psdModeCMYK      = 4
+)
+
+// psdHeader is the fixed 26-byte file header
+type psdHeader struct {
+	Signature [4]byte
+	Version   uint16 // 1 = PSD, 2 = PSB (large document)
+	Reserved  [6]byte
+	Channels  uint16
+	Height    uint32
+	Width     uint32
+	Depth     uint16
+	ColorMode uint16
+}
+
+// decodePSDComposite reads the saved composite image scaled to fit size
+func decodePSDComposite(path string, size int) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := bufio.NewReaderSize(file, 1<<20)
+
+	header, err := readPSDHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if header.Width == 0 || header.Height == 0 {
+		return nil, ErrNoPreview
+	}
+	if header.Depth != 8 && header.Depth != 16 {
+		return nil, fmt.Errorf("%d-bit documents are not supported for previews: %w", header.Depth, ErrNoPreview)
+	}
+	large := header.Version == 2
+
+	// Color mode data holds the palette for indexed documents
+	colorData, err := readSection(r, false)
+	if err != nil {
+		return nil, err
+	}
+	// Image resources; then layer and mask information (8-byte length in PSB)
+	if err := skipSection(r, false); err != nil {
+		return nil, err
+	}
+	if err := skipSection(r, large); err != nil {
+		return nil, err
+	}
+
+	var channels int
+	var toRGB func([]uint8) color.RGBA
+	switch header.ColorMode {
+	case psdModeRGB:
+		channels, toRGB = 3, rgb
+	case psdModeGrayscale:
+		channels, toRGB = 1, func(v []uint8) color.RGBA { return color.RGBA{v[0], v[0], v[0], 255} }
+	case psdModeCMYK:
+		// Stored inverted (255 = no ink)
+		channels, toRGB = 4, func(v []uint8) color.RGBA {
+			k := uint16(v[3])
+			return color.RGBA{uint8(uint16(v[0]) * k / 255), uint8(uint16(v[1]) * k / 255), uint8(uint16(v[2]) * k / 255), 255}
+		}
+	case psdModeIndexed:
+		if len(colorData) < 768 {
+			return nil, fmt.Errorf("indexed PSD without a palette")
+		}
+		channels, toRGB = 1, rgb
+	default:
+		return nil, fmt.Errorf("color mode %d is not supported for previews: %w", header.ColorMode, ErrNoPreview)
+	}
+	if int(header.Channels) < channels {
+		return nil, fmt.Errorf("PSD has %d channels, expected %d", header.Channels, channels)
+	}
+
+	width, height := int(header.Width), int(header.Height)
+	bytesPerSample := int(header.Depth / 8)
+	scaler := newBoxScaler(width, height, size, channels)
+	if header.ColorMode == psdModeIndexed {
+		scaler = newBoxScaler(width, height, size, 3)
+	}
+
+	var compression uint16
+	if err := binary.Read(r, binary.BigEndian, &compression); err != nil {
+		return nil, fmt.Errorf("failed to read composite image: %w", err)
+	}
+
+	rows := newRowReader(r, compression, width*bytesPerSample, height, int(header.Channels), large)
+	if err := rows.init(); err != nil {
+		return nil, err
+	}
+
+	sample := make([]byte, width)
+	for channel := 0; channel < channels; channel++ {
+		for y := 0; y < height; y++ {
+			row, err := rows.next()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read composite row: %w", err)
+			}
+			// 16-bit samples are big-endian; the high byte is enough for a thumbnail
+			for x := 0; x < width; x++ {
+				sample[x] = row[x*bytesPerSample]
+			}
+			if header.ColorMode == psdModeIndexed {
+				for x, index := range sample {
+					scaler.addPixel(x, y, colorData[index], colorData[256+int(index)], colorData[512+int(index)])
+				}
+				continue
+			}
+			scaler.addChannelRow(y, channel, sample)
+		}
+	}
+	return scaler.image(toRGB), nil
+}
+
+// readPSDHeader reads and validates the file header
+func readPSDHeader(r io.Reader) (*psdHeader, error) {
+	var header psdHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read PSD header: %w", err)
+	}
+	if string(header.Signature[:]) != "8BPS" || (header.Version != 1 && header.Version != 2) {
+		return nil, fmt.Errorf("not a PSD/PSB file")
+	}
+	return &header, nil
+}
+
+// rowReader yields composite rows, decompressing PackBits when needed
+type rowReader struct {
+	r           *bufio.Reader
+	compression uint16
+	rowBytes    int
+	rowCount    int // rows of all channels
+	large       bool
+	counts      []uint32
+	index       int
+	row         []byte
+	packed      []byte
+}
+
+func newRowReader(r *bufio.Reader, compression uint16, rowBytes, height, channels int, large bool) *rowReader {
+	return &rowReader{
+		r:           r,
+		compression: compression,
+		rowBytes:    rowBytes,
+		rowCount:    height * channels,
+		large:       large,
+		row:         make([]byte, rowBytes),
+	}
+}
+
+// init reads the per-row byte counts that precede PackBits data
+func (rr *rowReader) init() error {
+	switch rr.compression {
+	case 0:
+		return nil
+	case 1:
+		rr.counts = make([]uint32, rr.rowCount)
+		for i := range rr.counts {
+			if rr.large {
+				if err := binary.Read(rr.r, binary.BigEndian, &rr.counts[i]); err != nil {
+					return fmt.Errorf("failed to read row lengths: %w", err)
+				}
+				continue
+			}
+			var count uint16
+			if err := binary.Read(rr.r, binary.BigEndian, &count); err != nil {
+				return fmt.Errorf("failed to read row lengths: %w", err)
+			}
+			rr.counts[i] = uint32(count)
+		}
+		return nil
+	default:
+		return fmt.Errorf("composite compression %d is not supported: %w", rr.compression, ErrNoPreview)
+	}
+}
+
+// next returns the next row; the slice is reused between calls
+func (rr *rowReader) next() ([]byte, error) {
+	if rr.compression == 0 {
+		_, err := io.ReadFull(rr.r, rr.row)
+		return rr.row, err
+	}
+
+	if rr.index >= len(rr.counts) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	count := int(rr.counts[rr.index])
+	rr.index++
+	if cap(rr.packed) < count {
+		rr.packed = make([]byte, count)
+	}
+	packed := rr.packed[:count]
+	if _, err := io.ReadFull(rr.r, packed); err != nil {
+		return nil, err
+	}
+	return rr.row, unpackBits(packed, rr.row)
+}
+
+// unpackBits decodes one PackBits-compressed row into dst
+func unpackBits(src, dst []byte) error {
+	out := 0
+	for i := 0; i < len(src) && out < len(dst); {
+		n := int(int8(src[i]))
+		i++
+		switch {
+		case n >= 0:
+			end := i + n + 1
+			if end > len(src) {
+				return fmt.Errorf("truncated PackBits literal")
+			}
+			out += copy(dst[out:], src[i:end])
+			i = end
+		case n != -128:
+			if i >= len(src) {
+				return fmt.Errorf("truncated PackBits run")
+			}
+			for j := 0; j < 1-n && out < len(dst); j++ {
+				dst[out] = src[i]
+				out++
+			}
+			i++
+		}
+	}
+	for ; out < len(dst); out++ {
+		dst[out] = 0
+	}
+	return nil
+}
+
+// readSection reads a length-prefixed section
+func readSection(r *bufio.Reader, large bool) ([]byte, error) {
+	length, err := sectionLength(r, large)
+	if err != nil {
+		return nil, err
+	}
+	if length > 16<<20 {
+		return nil, fmt.Errorf("PSD section too large (%d bytes)", length)
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+// skipSection skips a length-prefixed section
+func skipSection(r *bufio.Reader, large bool) error {
+	length, err := sectionLength(r, large)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil {
+		return fmt.Errorf("truncated PSD section: %w", err)
+	}
+	return nil
+}
+
+func sectionLength(r io.Reader, large bool) (uint64, error) {
+	if large {
+		var length uint64
+		err := binary.Read(r, binary.BigEndian, &length)
+		return length, err
+	}
+	var length uint32
+	err := binary.Read(r, binary.BigEndian, &length)
+	return uint64(length), err
+}
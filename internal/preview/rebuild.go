@@ -0,0 +1,175 @@
+package preview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"dgit/internal/log"
+	"dgit/internal/progress"
+	"dgit/internal/restore"
+	"dgit/internal/status"
+)
+
+// A rebuild walks history oldest first, restoring each version's files into a scratch
+// directory and generating missing thumbnails. Progress is saved after every version in
+// .dgit/previews/rebuild.json, so an interrupted rebuild resumes where it stopped.
+
+// RebuildState is the persisted progress of a rebuild
+type RebuildState struct {
+	From      int       `json:"from"`
+	Completed int       `json:"completed"` // Last version fully processed
+	Generated int       `json:"generated"`
+	Existing  int       `json:"existing"`
+	Skipped   int       `json:"skipped"` // Files with no preview support
+	Failed    int       `json:"failed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RebuildOptions selects the versions to process
+type RebuildOptions struct {
+	From    int  // First version (1 when zero)
+	Restart bool // Ignore saved progress
+
+	Skip map[int]bool // Versions left out (hidden versions)
+}
+
+// Rebuild backfills thumbnails for every version from opts.From onwards
+func (pm *PreviewManager) Rebuild(opts RebuildOptions, report progress.Reporter) (*RebuildState, error) {
+	if opts.From < 1 {
+		opts.From = 1
+	}
+
+	state := &RebuildState{From: opts.From, Completed: opts.From - 1}
+	if saved, err := pm.loadState(); err == nil && !opts.Restart && saved.From == opts.From {
+		state = saved
+	}
+
+	commits, err := log.NewLogManager(pm.DgitDir).GetCommitHistory()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Version < commits[j].Version })
+
+	var pending []*log.Commit
+	for _, commit := range commits {
+		if commit.Version > state.Completed && !opts.Skip[commit.Version] {
+			pending = append(pending, commit)
+		}
+	}
+
+	done := len(commits) - len(pending)
+	for _, commit := range pending {
+		if err := pm.rebuildVersion(commit, state); err != nil {
+			return state, fmt.Errorf("v%d: %w", commit.Version, err)
+		}
+		state.Completed = commit.Version
+		if err := pm.saveState(state); err != nil {
+			return state, err
+		}
+		done++
+		report.Emit(progress.Event{Operation: "previews", Phase: progress.PhasePreview,
+			File: fmt.Sprintf("v%d", commit.Version), Current: done, Total: len(commits)})
+	}
+
+	report.Emit(progress.Event{Operation: "previews", Phase: progress.PhaseDone, Current: done, Total: len(commits)})
+	return state, nil
+}
+
+// ClearState forgets saved rebuild progress
+func (pm *PreviewManager) ClearState() error {
+	if err := os.Remove(pm.statePath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// rebuildVersion generates thumbnails missing for one version's files
+func (pm *PreviewManager) rebuildVersion(commit *log.Commit, state *RebuildState) error {
+	var missing []string
+	for path, raw := range commit.Metadata {
+		meta, _ := raw.(map[string]interface{})
+		if hash, _ := meta["hash"].(string); hash != "" && pm.Has(hash) {
+			state.Existing++
+			continue
+		}
+		if !pm.Supports(path) {
+			state.Skipped++
+			continue
+		}
+		missing = append(missing, path)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(pm.DgitDir, "temp"), 0755); err != nil {
+		return err
+	}
+	scratch, err := os.MkdirTemp(filepath.Join(pm.DgitDir, "temp"), "previews-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+
+	restoreManager := restore.NewRestoreManager(pm.DgitDir)
+	restoreManager.WorkDir = scratch
+	restoreManager.Progress = func(progress.Event) {}
+	if _, err := restoreManager.Restore(fmt.Sprintf("v%d", commit.Version), missing); err != nil {
+		return err
+	}
+
+	for _, path := range missing {
+		restored := filepath.Join(scratch, path)
+		// Commits made before content hashes were recorded are hashed now
+		hash, err := status.CalculateFileHash(restored)
+		if err != nil {
+			state.Failed++
+			continue
+		}
+		generated, err := pm.Generate(restored, hash)
+		switch {
+		case errors.Is(err, ErrNoPreview):
+			state.Skipped++
+		case err != nil:
+			state.Failed++
+		case generated:
+			state.Generated++
+		default:
+			state.Existing++
+		}
+	}
+	return nil
+}
+
+func (pm *PreviewManager) statePath() string {
+	return filepath.Join(pm.PreviewsDir, "rebuild.json")
+}
+
+func (pm *PreviewManager) loadState() (*RebuildState, error) {
+	data, err := os.ReadFile(pm.statePath())
+	if err != nil {
+		return nil, err
+	}
+	var state RebuildState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (pm *PreviewManager) saveState(state *RebuildState) error {
+	state.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(pm.PreviewsDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(pm.statePath(), data, 0644)
+}
@@ -0,0 +1,163 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // Registered for the raster renderer
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	initializer "dgit/internal/init"
+)
+
+// Full-fidelity rendering of layered PSD/AI documents is out of reach natively, so previews
+// come from a chain of renderers: the document's own embedded preview, a basic raster
+// decoder, and optionally external commands (ImageMagick, a headless Photoshop or
+// Illustrator script) configured under "previews.external" for high-fidelity output.
+
+// Renderer produces a preview image for a design file
+type Renderer interface {
+	// Name identifies the renderer in config and messages
+	Name() string
+	// Supports reports whether the renderer handles a file type
+	Supports(path string) bool
+	// Render returns an image; it may be larger than size, which callers scale down
+	// ErrNoPreview means this renderer has nothing for the file and the next one is tried
+	Render(path string, size int) (image.Image, error)
+}
+
+// defaultExternalTimeout bounds external renderer commands without a configured timeout
+const defaultExternalTimeout = 60 * time.Second
+
+// buildRenderers turns the configured renderer names into renderers; unknown names are skipped
+func buildRenderers(settings Settings) []Renderer {
+	external := make(map[string]initializer.ExternalRendererConfig, len(settings.External))
+	for _, config := range settings.External {
+		external[config.Name] = config
+	}
+
+	var renderers []Renderer
+	for _, name := range settings.Renderers {
+		switch name {
+		case "embedded":
+			renderers = append(renderers, EmbeddedRenderer{})
+		case "raster":
+			renderers = append(renderers, RasterRenderer{})
+		default:
+			if config, ok := external[name]; ok && len(config.Command) > 0 {
+				renderers = append(renderers, &ExternalRenderer{Config: config})
+			}
+		}
+	}
+	return renderers
+}
+
+// EmbeddedRenderer returns the preview the design app saved inside the document
+type EmbeddedRenderer struct{}
+
+func (EmbeddedRenderer) Name() string { return "embedded" }
+
+func (EmbeddedRenderer) Supports(path string) bool {
+	return hasExtension(path, ".psd", ".psb", ".ai")
+}
+
+func (EmbeddedRenderer) Render(path string, _ int) (image.Image, error) {
+	return embeddedPreview(path)
+}
+
+// RasterRenderer decodes pixels directly: the saved PSD/PSB composite and plain image files
+type RasterRenderer struct{}
+
+func (RasterRenderer) Name() string { return "raster" }
+
+func (RasterRenderer) Supports(path string) bool {
+	return hasExtension(path, ".psd", ".psb", ".png", ".jpg", ".jpeg", ".gif")
+}
+
+func (RasterRenderer) Render(path string, size int) (image.Image, error) {
+	if hasExtension(path, ".psd", ".psb") {
+		return decodePSDComposite(path, size)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// ExternalRenderer runs a configured command that writes a PNG
+type ExternalRenderer struct {
+	Config initializer.ExternalRendererConfig
+}
+
+func (r *ExternalRenderer) Name() string { return r.Config.Name }
+
+func (r *ExternalRenderer) Supports(path string) bool {
+	return hasExtension(path, r.Config.Extensions...)
+}
+
+func (r *ExternalRenderer) Render(path string, size int) (image.Image, error) {
+	outputDir, err := os.MkdirTemp("", "dgit-render-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(outputDir)
+	output := filepath.Join(outputDir, "preview.png")
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	replacer := strings.NewReplacer("{input}", absPath, "{output}", output, "{size}", strconv.Itoa(size))
+	args := make([]string, len(r.Config.Command))
+	for i, arg := range r.Config.Command {
+		args[i] = replacer.Replace(arg)
+	}
+
+	timeout := defaultExternalTimeout
+	if r.Config.TimeoutSeconds > 0 {
+		timeout = time.Duration(r.Config.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out after %v", timeout)
+		}
+		return nil, fmt.Errorf("%s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		return nil, fmt.Errorf("%s wrote no image to {output}", args[0])
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// hasExtension reports whether path ends in one of the extensions (case-insensitive)
+func hasExtension(path string, extensions ...string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, candidate := range extensions {
+		if ext == strings.ToLower(candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+)
+
+// boxScaler averages source pixels into a thumbnail-sized grid, so sources of any size
+// can be reduced while holding only the thumbnail in memory. Channels are averaged
+// independently and converted to RGB at the end, which lets planar formats (PSD stores
+// all of channel 0, then all of channel 1, ...) be fed one channel row at a time.
+type boxScaler struct {
+	srcWidth, srcHeight int
+	width, height       int
+	channels            int
+	sums                []uint64 // Per thumbnail pixel: one sum per channel, then a sample count
+}
+
+// newBoxScaler prepares a grid fitting a srcWidth x srcHeight image into size x size
+func newBoxScaler(srcWidth, srcHeight, size, channels int) *boxScaler {
+	width, height := srcWidth, srcHeight
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, srcHeight*size/srcWidth)
+		} else {
+			width, height = max(1, srcWidth*size/srcHeight), size
+		}
+	}
+	return &boxScaler{
+		srcWidth:  srcWidth,
+		srcHeight: srcHeight,
+		width:     width,
+		height:    height,
+		channels:  channels,
+		sums:      make([]uint64, width*height*(channels+1)),
+	}
+}
+
+// cell returns the offset of the thumbnail pixel a source pixel falls into
+func (b *boxScaler) cell(x, y int) int {
+	return ((y*b.height/b.srcHeight)*b.width + x*b.width/b.srcWidth) * (b.channels + 1)
+}
+
+// addChannelRow adds one source row of a single channel
+func (b *boxScaler) addChannelRow(y, channel int, row []byte) {
+	for x, value := range row {
+		offset := b.cell(x, y)
+		b.sums[offset+channel] += uint64(value)
+		if channel == 0 {
+			b.sums[offset+b.channels]++
+		}
+	}
+}
+
+// addPixel adds one source pixel with a value per channel
+func (b *boxScaler) addPixel(x, y int, values ...uint8) {
+	offset := b.cell(x, y)
+	for channel, value := range values {
+		b.sums[offset+channel] += uint64(value)
+	}
+	b.sums[offset+b.channels]++
+}
+
+// image returns the averaged thumbnail, converting channel averages with toRGB
+func (b *boxScaler) image(toRGB func(values []uint8) color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, b.width, b.height))
+	values := make([]uint8, b.channels)
+	stride := b.channels + 1
+	for i := 0; i < b.width*b.height; i++ {
+		cell := b.sums[i*stride : (i+1)*stride]
+		count := cell[b.channels]
+		if count == 0 {
+			continue
+		}
+		for channel := range values {
+			values[channel] = uint8(cell[channel] / count)
+		}
+		img.SetRGBA(i%b.width, i/b.width, toRGB(values))
+	}
+	return img
+}
+
+// rgb converts three averaged channels
+func rgb(values []uint8) color.RGBA {
+	return color.RGBA{R: values[0], G: values[1], B: values[2], A: 255}
+}
+
+// fit scales an in-memory image down to size on its longest edge
+func fit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= size && bounds.Dy() <= size {
+		return img
+	}
+
+	scaler := newBoxScaler(bounds.Dx(), bounds.Dy(), size, 3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			scaler.addPixel(x-bounds.Min.X, y-bounds.Min.Y, uint8(r>>8), uint8(g>>8), uint8(b>>8))
+		}
+	}
+	return scaler.image(rgb)
+}
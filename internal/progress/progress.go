@@ -0,0 +1,55 @@
+package progress
+
+// Phases reported by long-running operations, in the order they occur
+const (
+	PhaseStage    = "stage"    // add: hashing and pre-compressing a file
+	PhaseScan     = "scan"     // commit: extracting design metadata
+	PhaseCompress = "compress" // commit: writing snapshot or delta data
+	PhaseStore    = "store"    // commit: writing metadata and HEAD
+	PhaseAnalyze  = "analyze"  // restore: choosing a restoration strategy
+	PhaseBackup   = "backup"   // restore: saving local changes to the trash
+	PhaseRestore  = "restore"  // restore: writing a file to the working tree
+	PhasePreview  = "preview"  // previews rebuild: thumbnails generated for one version
+	PhaseDone     = "done"     // final event of every operation
+)
+
+// Event is a typed progress update emitted by add, commit and restore
+type Event struct {
+	Operation  string  `json:"operation"` // "add", "commit", "restore", "previews"
+	Phase      string  `json:"phase"`
+	File       string  `json:"file,omitempty"`
+	Bytes      int64   `json:"bytes"`       // Bytes processed so far in this operation
+	TotalBytes int64   `json:"total_bytes"` // 0 when the total is not known up front
+	Current    int     `json:"current"`     // Items processed so far
+	Total      int     `json:"total"`       // 0 when the item count is not known up front
+	Percent    float64 `json:"percent"`
+}
+
+// Reporter receives progress events. A nil Reporter means CLI mode: managers print
+// human-readable progress to stdout instead
+type Reporter func(Event)
+
+// Enabled reports whether events are being collected (library mode)
+func (r Reporter) Enabled() bool {
+	return r != nil
+}
+
+// Emit fills in Percent and delivers the event; safe to call on a nil Reporter
+func (r Reporter) Emit(e Event) {
+	if r == nil {
+		return
+	}
+
+	switch {
+	case e.Phase == PhaseDone:
+		e.Percent = 100
+	case e.TotalBytes > 0:
+		e.Percent = float64(e.Bytes) / float64(e.TotalBytes) * 100
+	case e.Total > 0:
+		e.Percent = float64(e.Current) / float64(e.Total) * 100
+	}
+	if e.Percent > 100 {
+		e.Percent = 100
+	}
+	r(e)
+}
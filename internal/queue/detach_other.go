@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package queue
+
+import "os/exec"
+
+// detach leaves the worker as started where sessions cannot be changed portably
+func detach(*exec.Cmd) {}
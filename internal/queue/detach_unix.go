@@ -0,0 +1,14 @@
+//go:build linux || darwin
+
+package queue
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the worker in a new session, away from the terminal's process group,
+// so closing the terminal or pressing Ctrl-C does not stop it
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
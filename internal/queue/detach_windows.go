@@ -0,0 +1,18 @@
+package queue
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachedProcess and createNewProcessGroup are Win32 process creation flags
+const (
+	detachedProcess       = 0x00000008
+	createNewProcessGroup = 0x00000200
+)
+
+// detach starts the worker without a console and outside the caller's process group,
+// so closing the console window or pressing Ctrl-C does not stop it
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | createNewProcessGroup}
+}
@@ -0,0 +1,407 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dgit/internal/commit"
+	"dgit/internal/progress"
+	"dgit/internal/staging"
+)
+
+// Job states
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// staleLockAge is how long a worker lock may go without a heartbeat before another worker takes over
+const staleLockAge = 2 * time.Minute
+
+// Job is a commit whose compression and storage runs in the background
+type Job struct {
+	ID             string                `json:"id"`
+	Message        string                `json:"message"`
+	Files          []*staging.StagedFile `json:"files"`
+	Removed        []string              `json:"removed,omitempty"`
+	Renamed        map[string]string     `json:"renamed,omitempty"`
+	IgnorePolicies bool                  `json:"ignore_policies,omitempty"`
+	Profile        string                `json:"profile,omitempty"`
+	State          string                `json:"state"`
+	QueuedAt       time.Time             `json:"queued_at"`
+	StartedAt      time.Time             `json:"started_at,omitempty"`
+	FinishedAt     time.Time             `json:"finished_at,omitempty"`
+	Version        int                   `json:"version,omitempty"`
+	Hash           string                `json:"hash,omitempty"`
+	Error          string                `json:"error,omitempty"`
+}
+
+// TotalSize returns the combined size of the job's files
+func (j *Job) TotalSize() int64 {
+	var total int64
+	for _, file := range j.Files {
+		total += file.Size
+	}
+	return total
+}
+
+// QueueManager stores pending commits in .dgit/queue/<id>/ and runs them in order
+type QueueManager struct {
+	DgitDir  string
+	QueueDir string
+	LockFile string
+
+	// Progress receives the commit events of each job and silences their stdout output
+	Progress progress.Reporter
+}
+
+// NewQueueManager creates a new commit queue manager
+func NewQueueManager(dgitDir string) *QueueManager {
+	queueDir := filepath.Join(dgitDir, "queue")
+	return &QueueManager{
+		DgitDir:  dgitDir,
+		QueueDir: queueDir,
+		LockFile: filepath.Join(queueDir, "worker.lock"),
+	}
+}
+
+// JobOptions carries the parts of a commit besides its message and files
+type JobOptions struct {
+	Removed        []string
+	Renamed        map[string]string
+	IgnorePolicies bool
+	Profile        string // Compression profile overriding the config
+}
+
+// Enqueue records the staged set as a pending commit and returns immediately
+// Each file is hard-linked into the job directory so saves made by design apps after
+// enqueueing (which replace the file) do not change what gets committed
+func (qm *QueueManager) Enqueue(message string, files []*staging.StagedFile, opts JobOptions) (*Job, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files staged for commit")
+	}
+
+	job := &Job{
+		ID:             strings.ReplaceAll(time.Now().Format("20060102-150405.000000"), ".", "-"),
+		Message:        message,
+		Removed:        opts.Removed,
+		Renamed:        opts.Renamed,
+		IgnorePolicies: opts.IgnorePolicies,
+		Profile:        opts.Profile,
+		State:          StateQueued,
+		QueuedAt:       time.Now(),
+	}
+	jobDir := filepath.Join(qm.QueueDir, job.ID)
+	pinDir := filepath.Join(jobDir, "files")
+	if err := os.MkdirAll(pinDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create queue entry: %w", err)
+	}
+
+	for i, file := range files {
+		pinned := *file
+		pinPath := filepath.Join(pinDir, fmt.Sprintf("%d-%s", i, filepath.Base(file.AbsolutePath)))
+		if err := os.Link(file.AbsolutePath, pinPath); err == nil {
+			pinned.AbsolutePath = pinPath
+		}
+		// The worker verifies against the file as it is now, not as it was at 'dgit add'
+		if info, err := os.Stat(pinned.AbsolutePath); err == nil {
+			pinned.Size = info.Size()
+			pinned.ModTime = info.ModTime()
+		}
+		job.Files = append(job.Files, &pinned)
+	}
+
+	if err := qm.save(job); err != nil {
+		os.RemoveAll(jobDir)
+		return nil, err
+	}
+	return job, nil
+}
+
+// List returns all jobs, oldest first
+func (qm *QueueManager) List() ([]*Job, error) {
+	entries, err := os.ReadDir(qm.QueueDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		job, err := qm.load(entry.Name())
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].QueuedAt.Before(jobs[j].QueuedAt)
+	})
+	return jobs, nil
+}
+
+// Pending returns jobs that have not finished yet
+func (qm *QueueManager) Pending() []*Job {
+	jobs, _ := qm.List()
+	var pending []*Job
+	for _, job := range jobs {
+		if job.State == StateQueued || job.State == StateRunning {
+			pending = append(pending, job)
+		}
+	}
+	return pending
+}
+
+// Retry puts a failed job back in the queue
+func (qm *QueueManager) Retry(id string) (*Job, error) {
+	job, err := qm.load(id)
+	if err != nil {
+		return nil, fmt.Errorf("queue entry '%s' not found", id)
+	}
+	if job.State != StateFailed {
+		return nil, fmt.Errorf("queue entry '%s' is %s, only failed entries can be retried", id, job.State)
+	}
+
+	job.State = StateQueued
+	job.Error = ""
+	job.StartedAt = time.Time{}
+	job.FinishedAt = time.Time{}
+	return job, qm.save(job)
+}
+
+// Clean removes finished jobs and their pinned files
+func (qm *QueueManager) Clean() (int, error) {
+	jobs, err := qm.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, job := range jobs {
+		if job.State != StateDone {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(qm.QueueDir, job.ID)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Work processes queued jobs in order until the queue is empty
+// With watch set it keeps polling for new jobs until stop is closed
+func (qm *QueueManager) Work(watch bool, interval time.Duration, stop <-chan struct{}, report func(*Job)) error {
+	if err := qm.acquireLock(); err != nil {
+		return err
+	}
+
+	// Keep the lock fresh while a long commit is compressing
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(staleLockAge / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				qm.heartbeat()
+			}
+		}
+	}()
+
+	for {
+		qm.heartbeat()
+
+		job := qm.next()
+		if job != nil {
+			qm.run(job)
+			if report != nil {
+				report(job)
+			}
+			continue
+		}
+
+		if !watch {
+			// A commit enqueued while we were releasing the lock saw a live worker and
+			// did not start one, so look once more before exiting
+			os.Remove(qm.LockFile)
+			if qm.next() == nil || qm.acquireLock() != nil {
+				return nil
+			}
+			continue
+		}
+		select {
+		case <-stop:
+			os.Remove(qm.LockFile)
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// StartWorker runs '<executable> queue work' in workDir as a detached background
+// process, writing its output to worker.log. It is not a child this process waits on:
+// it gets its own session (or console) and is released at once, so it is never left
+// defunct however long the starting process lives
+func (qm *QueueManager) StartWorker(executable, workDir string) error {
+	if err := os.MkdirAll(qm.QueueDir, 0755); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(filepath.Join(qm.QueueDir, "worker.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	worker := exec.Command(executable, "queue", "work")
+	worker.Dir = workDir
+	worker.Stdout = logFile
+	worker.Stderr = logFile
+	detach(worker)
+	if err := worker.Start(); err != nil {
+		return err
+	}
+	// Reap the worker when it exits if this process is still around; Release alone
+	// leaves an exited child as a zombie until its parent ends
+	go worker.Wait()
+	return nil
+}
+
+// WorkerRunning reports whether a live worker holds the queue lock
+func (qm *QueueManager) WorkerRunning() bool {
+	info, err := os.Stat(qm.LockFile)
+	return err == nil && time.Since(info.ModTime()) < staleLockAge
+}
+
+// run commits one job and records the outcome
+func (qm *QueueManager) run(job *Job) {
+	job.State = StateRunning
+	job.StartedAt = time.Now()
+	qm.save(job)
+
+	newCommit, err := qm.commit(job)
+	job.FinishedAt = time.Now()
+	if err != nil {
+		job.State = StateFailed
+		job.Error = err.Error()
+	} else {
+		job.State = StateDone
+		job.Version = newCommit.Version
+		job.Hash = newCommit.Hash
+		os.RemoveAll(filepath.Join(qm.QueueDir, job.ID, "files"))
+	}
+	qm.save(job)
+}
+
+// commit verifies the pinned files are unchanged and creates the commit
+func (qm *QueueManager) commit(job *Job) (*commit.Commit, error) {
+	for _, file := range job.Files {
+		info, err := os.Stat(file.AbsolutePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s is no longer available: %w", file.Path, err)
+		}
+		if info.Size() != file.Size || !info.ModTime().Equal(file.ModTime) {
+			return nil, fmt.Errorf("%s was modified in place after it was queued; re-add and commit again", file.Path)
+		}
+	}
+
+	commitManager := commit.NewCommitManager(qm.DgitDir)
+	commitManager.Progress = qm.Progress
+	commitManager.Removed = job.Removed
+	commitManager.Renamed = job.Renamed
+	commitManager.IgnorePolicies = job.IgnorePolicies
+	if job.Profile != "" {
+		if err := commitManager.ApplyProfile(job.Profile); err != nil {
+			return nil, err
+		}
+	}
+	return commitManager.CreateCommit(job.Message, job.Files)
+}
+
+// next returns the oldest queued job, resuming one left running by a crashed worker
+func (qm *QueueManager) next() *Job {
+	jobs, _ := qm.List()
+	for _, job := range jobs {
+		if job.State == StateQueued || job.State == StateRunning {
+			return job
+		}
+	}
+	return nil
+}
+
+// acquireLock creates the worker lock, replacing it if its owner stopped heart-beating
+func (qm *QueueManager) acquireLock() error {
+	if err := os.MkdirAll(qm.QueueDir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(qm.LockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		if qm.WorkerRunning() {
+			return fmt.Errorf("another queue worker is already running")
+		}
+		os.Remove(qm.LockFile)
+		file, err = os.OpenFile(qm.LockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock commit queue: %w", err)
+	}
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	return file.Close()
+}
+
+// heartbeat refreshes the lock modification time
+func (qm *QueueManager) heartbeat() {
+	now := time.Now()
+	os.Chtimes(qm.LockFile, now, now)
+}
+
+// save writes a job manifest atomically
+func (qm *QueueManager) save(job *Job) error {
+	jobDir := filepath.Join(qm.QueueDir, job.ID)
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode queue entry: %w", err)
+	}
+
+	jobFile := filepath.Join(jobDir, "job.json")
+	tmpFile := jobFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write queue entry: %w", err)
+	}
+	return os.Rename(tmpFile, jobFile)
+}
+
+// load reads a job manifest
+func (qm *QueueManager) load(id string) (*Job, error) {
+	data, err := os.ReadFile(filepath.Join(qm.QueueDir, id, "job.json"))
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,212 @@
+package relocate
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	initializer "dgit/internal/init"
+)
+
+// RelocateResult summarizes a completed storage move
+type RelocateResult struct {
+	SourceDir     string
+	TargetDir     string
+	FilesCopied   int
+	BytesCopied   int64
+	SourceRemoved bool
+	Duration      time.Duration
+}
+
+// RelocateManager moves object storage between volumes with hash verification
+type RelocateManager struct {
+	DgitDir string
+
+	// Progress is called after each verified file (optional)
+	Progress func(relPath string, size int64)
+}
+
+// NewRelocateManager creates a new relocate manager
+func NewRelocateManager(dgitDir string) *RelocateManager {
+	return &RelocateManager{DgitDir: dgitDir}
+}
+
+// Relocate copies every object storage file to targetDir, verifies each copy by SHA256,
+// atomically points the repository config at the new location, then removes the source.
+// The config is only switched after all files verify, so an interrupted move leaves the
+// repository using the original storage.
+func (rm *RelocateManager) Relocate(targetDir string, keepSource bool) (*RelocateResult, error) {
+	startTime := time.Now()
+
+	sourceDir := initializer.GetStorageDir(rm.DgitDir)
+	targetDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target path: %w", err)
+	}
+
+	if err := rm.validateTarget(sourceDir, targetDir); err != nil {
+		return nil, err
+	}
+
+	result := &RelocateResult{
+		SourceDir: sourceDir,
+		TargetDir: targetDir,
+	}
+
+	// Phase 1: copy and verify
+	for _, dir := range initializer.ObjectStorageDirs {
+		sourceRoot := filepath.Join(sourceDir, dir)
+		targetRoot := filepath.Join(targetDir, dir)
+
+		if err := os.MkdirAll(targetRoot, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", targetRoot, err)
+		}
+
+		err := filepath.Walk(sourceRoot, func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(sourceRoot, path)
+			if err != nil {
+				return err
+			}
+			targetPath := filepath.Join(targetRoot, relPath)
+
+			if info.IsDir() {
+				return os.MkdirAll(targetPath, 0755)
+			}
+
+			if err := CopyVerified(path, targetPath, info); err != nil {
+				return fmt.Errorf("%s: %w", filepath.Join(dir, relPath), err)
+			}
+
+			result.FilesCopied++
+			result.BytesCopied += info.Size()
+			if rm.Progress != nil {
+				rm.Progress(filepath.Join(dir, relPath), info.Size())
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("copy failed, repository still uses %s: %w", sourceDir, err)
+		}
+	}
+
+	// Phase 2: switch indirection atomically
+	config, err := initializer.GetConfig(rm.DgitDir)
+	if err != nil {
+		return nil, err
+	}
+	if targetDir == filepath.Clean(rm.DgitDir) {
+		config.Storage.Path = "" // Moved back inside .dgit
+	} else {
+		config.Storage.Path = targetDir
+	}
+	if err := initializer.UpdateConfig(rm.DgitDir, config); err != nil {
+		return nil, fmt.Errorf("copied storage but failed to update config: %w", err)
+	}
+
+	// Phase 3: remove source (best effort; the repository no longer references it)
+	if !keepSource {
+		result.SourceRemoved = true
+		for _, dir := range initializer.ObjectStorageDirs {
+			if err := os.RemoveAll(filepath.Join(sourceDir, dir)); err != nil {
+				result.SourceRemoved = false
+			}
+		}
+		if sourceDir != filepath.Clean(rm.DgitDir) {
+			os.Remove(sourceDir) // Only succeeds if nothing else lives there
+		}
+	}
+
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// validateTarget rejects moves that would overwrite data or nest storage inside itself
+func (rm *RelocateManager) validateTarget(sourceDir, targetDir string) error {
+	sourceDir = filepath.Clean(sourceDir)
+	if targetDir == sourceDir {
+		return fmt.Errorf("object storage is already at %s", targetDir)
+	}
+
+	for _, dir := range initializer.ObjectStorageDirs {
+		sourceRoot := filepath.Join(sourceDir, dir)
+		if targetDir == sourceRoot || strings.HasPrefix(targetDir, sourceRoot+string(filepath.Separator)) {
+			return fmt.Errorf("target %s is inside current object storage", targetDir)
+		}
+
+		entries, err := os.ReadDir(filepath.Join(targetDir, dir))
+		if err == nil && len(entries) > 0 {
+			return fmt.Errorf("target already contains %s/ with data; refusing to overwrite", dir)
+		}
+	}
+
+	if info, err := os.Stat(targetDir); err == nil && !info.IsDir() {
+		return fmt.Errorf("target %s is not a directory", targetDir)
+	}
+
+	return nil
+}
+
+// CopyVerified copies a file and confirms the written bytes hash identically to the source
+func CopyVerified(sourcePath, targetPath string, info os.FileInfo) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(targetPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	sourceHash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(target, sourceHash), source); err != nil {
+		target.Close()
+		return err
+	}
+	if err := target.Sync(); err != nil {
+		target.Close()
+		return err
+	}
+	if err := target.Close(); err != nil {
+		return err
+	}
+
+	// Re-read from the target volume rather than trusting the write path
+	targetHash, err := HashFile(targetPath)
+	if err != nil {
+		return err
+	}
+	if targetHash != fmt.Sprintf("%x", sourceHash.Sum(nil)) {
+		os.Remove(targetPath)
+		return fmt.Errorf("checksum mismatch after copy")
+	}
+
+	return os.Chtimes(targetPath, info.ModTime(), info.ModTime())
+}
+
+// HashFile returns the SHA256 of a file's content
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
@@ -0,0 +1,391 @@
+package replica
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/relocate"
+	"dgit/internal/restore"
+)
+
+// A read replica is a lightweight, read-only checkout of a repository whose objects
+// live on a shared network store. Commit history is copied from the shared repository
+// on every refresh (it is small); objects are fetched on demand into the replica's own
+// .dgit, which acts as a local read-through cache capped at a size limit. Render farm
+// nodes can then restore and materialize pinned versions concurrently, each reading
+// only the objects its jobs need, and repeated jobs are served from local disk.
+//
+// A cached object is reused only while its size and modification time still match the
+// shared copy, so objects rewritten on the shared store are fetched again.
+
+// MarkerFile marks a .dgit directory as a read replica; commands that write refuse to run there
+const MarkerFile = "REPLICA"
+
+// DefaultCacheLimit is the default local object cache size in MB
+const DefaultCacheLimit = 20 * 1024
+
+// metadataEntries are copied from the shared repository on every refresh
+var metadataEntries = []string{"commits", "HEAD", "generation", "hidden.json", "tags.json"}
+
+// Marker describes a replica and where its data comes from
+type Marker struct {
+	Source      string    `json:"source"` // Shared repository's .dgit directory
+	Store       string    `json:"store"`  // Shared object storage root
+	CacheLimit  int64     `json:"cache_limit_mb"`
+	CreatedAt   time.Time `json:"created_at"`
+	RefreshedAt time.Time `json:"refreshed_at,omitempty"`
+	Version     int       `json:"version,omitempty"` // Latest version at the last refresh
+}
+
+// FetchResult summarizes a fetch of the objects some versions need
+type FetchResult struct {
+	Fetched int
+	Cached  int
+	Bytes   int64
+	Evicted int
+}
+
+// CacheUsage describes the local object cache
+type CacheUsage struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// ReplicaManager refreshes a replica's history and fills its object cache
+type ReplicaManager struct {
+	DgitDir string
+	Marker  *Marker
+}
+
+// IsReplica reports whether dgitDir belongs to a read replica, returning its marker
+func IsReplica(dgitDir string) (*Marker, bool) {
+	data, err := os.ReadFile(filepath.Join(dgitDir, MarkerFile))
+	if err != nil {
+		return nil, false
+	}
+	var marker Marker
+	json.Unmarshal(data, &marker)
+	return &marker, true
+}
+
+// NewReplicaManager opens the replica whose .dgit directory is dgitDir
+func NewReplicaManager(dgitDir string) (*ReplicaManager, error) {
+	marker, ok := IsReplica(dgitDir)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a read replica", filepath.Dir(dgitDir))
+	}
+	return &ReplicaManager{DgitDir: dgitDir, Marker: marker}, nil
+}
+
+// Create sets up a replica of the repository at source in dir
+// store overrides the shared object storage location; by default it is the source's.
+func Create(source, dir, store string, cacheLimit int64) (*ReplicaManager, error) {
+	sourceDgit, err := filepath.Abs(source)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Base(sourceDgit) != initializer.DGitDir {
+		sourceDgit = filepath.Join(sourceDgit, initializer.DGitDir)
+	}
+	if !initializer.IsDGitRepository(filepath.Dir(sourceDgit)) {
+		return nil, fmt.Errorf("no dgit repository at %s", filepath.Dir(sourceDgit))
+	}
+	if _, ok := IsReplica(sourceDgit); ok {
+		return nil, fmt.Errorf("%s is itself a replica; point at the shared repository", filepath.Dir(sourceDgit))
+	}
+
+	if store == "" {
+		store = initializer.GetStorageDir(sourceDgit)
+	}
+	if store, err = filepath.Abs(store); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(store); err != nil {
+		return nil, fmt.Errorf("shared object store not reachable: %w", err)
+	}
+
+	dgitDir, err := filepath.Abs(filepath.Join(dir, initializer.DGitDir))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dgitDir); err == nil {
+		return nil, fmt.Errorf("%s already contains a dgit repository", dir)
+	}
+	for _, subdir := range append([]string{"commits", "temp", "staging", "cache"}, initializer.ObjectStorageDirs...) {
+		if err := os.MkdirAll(filepath.Join(dgitDir, subdir), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	if cacheLimit <= 0 {
+		cacheLimit = DefaultCacheLimit
+	}
+	rm := &ReplicaManager{
+		DgitDir: dgitDir,
+		Marker:  &Marker{Source: sourceDgit, Store: store, CacheLimit: cacheLimit, CreatedAt: time.Now()},
+	}
+	if err := rm.saveMarker(); err != nil {
+		return nil, err
+	}
+	return rm, rm.Refresh()
+}
+
+// Refresh copies the shared repository's history and settings into the replica
+func (rm *ReplicaManager) Refresh() error {
+	if _, err := os.Stat(rm.Marker.Source); err != nil {
+		return fmt.Errorf("shared repository not reachable: %w", err)
+	}
+
+	// History before HEAD, so HEAD never names a commit the replica lacks
+	for _, entry := range metadataEntries {
+		if err := rm.copyTree(filepath.Join(rm.Marker.Source, entry), filepath.Join(rm.DgitDir, entry)); err != nil {
+			return fmt.Errorf("refreshing %s: %w", entry, err)
+		}
+	}
+
+	// Shared settings apply as-is, except that objects are read from the local cache
+	config, err := initializer.GetSharedConfig(rm.Marker.Source)
+	if err != nil {
+		return err
+	}
+	config.Storage = initializer.StorageConfig{}
+	if err := initializer.UpdateSharedConfig(rm.DgitDir, config); err != nil {
+		return err
+	}
+
+	rm.Marker.RefreshedAt = time.Now()
+	rm.Marker.Version = log.NewLogManager(rm.DgitDir).GetCurrentVersion()
+	return rm.saveMarker()
+}
+
+// Fetch copies the objects needed to restore versions from the shared store into the
+// local cache, then evicts least recently used objects above the cache limit
+func (rm *ReplicaManager) Fetch(versions []int) (*FetchResult, error) {
+	planner := restore.NewRestoreManager(rm.Marker.Source)
+	planner.ObjectsDir = filepath.Join(rm.Marker.Store, "objects")
+	planner.SnapshotsDir = filepath.Join(rm.Marker.Store, "snapshots")
+	planner.DeltasDir = filepath.Join(rm.Marker.Store, "deltas")
+	planner.ArchiveDir = filepath.Join(rm.Marker.Store, "archive")
+
+	result := &FetchResult{}
+	needed := make(map[string]bool)
+	for _, version := range versions {
+		files, err := planner.RequiredFiles(version)
+		if err != nil {
+			return result, fmt.Errorf("v%d: %w", version, err)
+		}
+		for _, source := range files {
+			local, err := rm.localPath(source)
+			if err != nil {
+				return result, err
+			}
+			if needed[local] {
+				continue
+			}
+			needed[local] = true
+
+			fetched, size, err := rm.fetchFile(source, local)
+			if err != nil {
+				return result, fmt.Errorf("v%d: %w", version, err)
+			}
+			if fetched {
+				result.Fetched++
+				result.Bytes += size
+			} else {
+				result.Cached++
+			}
+		}
+	}
+
+	evicted, err := rm.Evict(needed)
+	result.Evicted = evicted
+	return result, err
+}
+
+// Evict removes least recently used cached objects until the cache fits its limit;
+// objects in keep are never removed
+func (rm *ReplicaManager) Evict(keep map[string]bool) (int, error) {
+	objects, usage := rm.cachedObjects()
+	limit := rm.Marker.CacheLimit * 1024 * 1024
+
+	evicted := 0
+	for _, object := range objects {
+		if usage.Bytes <= limit {
+			break
+		}
+		if keep[object.path] {
+			continue
+		}
+		if err := os.Remove(object.path); err != nil {
+			return evicted, err
+		}
+		os.Remove(object.path + usedSuffix)
+		usage.Bytes -= object.size
+		evicted++
+	}
+	return evicted, nil
+}
+
+// Usage reports the size of the local object cache
+func (rm *ReplicaManager) Usage() CacheUsage {
+	_, usage := rm.cachedObjects()
+	return usage
+}
+
+// cachedObject is one file in the local object cache
+type cachedObject struct {
+	path     string
+	size     int64
+	lastUsed time.Time
+}
+
+// cachedObjects lists cached objects, least recently used first
+func (rm *ReplicaManager) cachedObjects() ([]cachedObject, CacheUsage) {
+	var objects []cachedObject
+	var usage CacheUsage
+	for _, dir := range append([]string{"cache"}, initializer.ObjectStorageDirs...) {
+		filepath.Walk(filepath.Join(rm.DgitDir, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !isObject(path) {
+				return nil
+			}
+			objects = append(objects, cachedObject{path: path, size: info.Size(), lastUsed: lastUsed(path, info)})
+			usage.Files++
+			usage.Bytes += info.Size()
+			return nil
+		})
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].lastUsed.Before(objects[j].lastUsed) })
+	return objects, usage
+}
+
+// fetchFile copies source to local unless an identical copy is cached
+// Copies go through a temporary name so concurrent fetches never see a partial object.
+func (rm *ReplicaManager) fetchFile(source, local string) (bool, int64, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return false, 0, fmt.Errorf("shared object unavailable: %w", err)
+	}
+	if existing, err := os.Stat(local); err == nil && existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+		touch(local)
+		return false, 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return false, 0, err
+	}
+	temp := fmt.Sprintf("%s.fetch-%d", local, os.Getpid())
+	if err := relocate.CopyVerified(source, temp, info); err != nil {
+		os.Remove(temp)
+		return false, 0, fmt.Errorf("fetching %s: %w", filepath.Base(source), err)
+	}
+	if err := os.Rename(temp, local); err != nil {
+		os.Remove(temp)
+		return false, 0, err
+	}
+	touch(local)
+	return true, info.Size(), nil
+}
+
+// localPath maps a file in the shared store or repository to its place in the replica
+func (rm *ReplicaManager) localPath(source string) (string, error) {
+	for _, root := range []string{rm.Marker.Store, rm.Marker.Source} {
+		if rel, err := filepath.Rel(root, source); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join(rm.DgitDir, rel), nil
+		}
+	}
+	return "", fmt.Errorf("%s is outside the shared repository", source)
+}
+
+// copyTree mirrors a metadata file or directory, copying only what changed and removing
+// files the source no longer has (e.g. loose commits that were packed)
+func (rm *ReplicaManager) copyTree(source, target string) error {
+	info, err := os.Stat(source)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyIfChanged(source, target, info)
+	}
+
+	seen := make(map[string]bool)
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(source, path)
+		seen[rel] = true
+		return copyIfChanged(path, filepath.Join(target, rel), info)
+	})
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if rel, _ := filepath.Rel(target, path); !seen[rel] {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// saveMarker writes the replica marker
+func (rm *ReplicaManager) saveMarker() error {
+	data, err := json.MarshalIndent(rm.Marker, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rm.DgitDir, MarkerFile), data, 0644)
+}
+
+// copyIfChanged copies a file unless the target has the same size and modification time
+func copyIfChanged(source, target string, info os.FileInfo) error {
+	if existing, err := os.Stat(target); err == nil && existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	temp := fmt.Sprintf("%s.fetch-%d", target, os.Getpid())
+	if err := relocate.CopyVerified(source, temp, info); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return os.Rename(temp, target)
+}
+
+// usedSuffix names the sidecar recording when a cached object was last used; object
+// modification times must keep matching the shared copy
+const usedSuffix = ".used"
+
+// touch records that a cached object was just used
+func touch(path string) {
+	os.WriteFile(path+usedSuffix, nil, 0644)
+}
+
+// lastUsed returns when a cached object was last used
+func lastUsed(path string, info os.FileInfo) time.Time {
+	if used, err := os.Stat(path + usedSuffix); err == nil {
+		return used.ModTime()
+	}
+	return info.ModTime()
+}
+
+// isObject reports whether a file in the cache is an object rather than bookkeeping
+// (last-use sidecars, access logs, generation stamps)
+func isObject(path string) bool {
+	name := filepath.Base(path)
+	return !strings.HasSuffix(name, usedSuffix) && !strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package repolock
+
+import "os"
+
+// tryLock always succeeds where no file locking is available
+func tryLock(*os.File) (bool, error) {
+	return true, nil
+}
+
+// unlock has nothing to release
+func unlock(*os.File) {}
@@ -0,0 +1,43 @@
+package repolock
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = syscall.Errno(33)
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// lockOffset places the locked byte past the holder line, so waiting processes can
+// still read who holds the lock
+var lockOffset = syscall.Overlapped{OffsetHigh: 0x7fffffff}
+
+// tryLock takes an exclusive LockFileEx lock without waiting
+func tryLock(file *os.File) (bool, error) {
+	overlapped := lockOffset
+	ok, _, err := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0,
+		uintptr(unsafe.Pointer(&overlapped)))
+	if ok != 0 {
+		return true, nil
+	}
+	if err == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
+
+// unlock releases the LockFileEx lock
+func unlock(file *os.File) {
+	overlapped := lockOffset
+	procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+}
@@ -0,0 +1,152 @@
+// Package repolock is the repository's exclusive write lock. Commands that change
+// history or staging (commit, add, the queue worker, batch) hold it for the whole
+// change, so two of them never allocate the same version or interleave their writes.
+//
+//	.dgit/lock    locked by the operating system while held; names the holder
+//
+// LockFile takes the same kind of lock on a side file guarding a single index, for
+// writers that may run with or without the repository lock held.
+//
+// The lock is taken with flock (LockFileEx on Windows) rather than by creating the
+// file, so the operating system releases it when its holder exits or crashes and
+// there is never a stale lock to take over. The file itself is never removed.
+package repolock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrBusy is returned by TryAcquire when another process or command holds the lock
+var ErrBusy = errors.New("another dgit command is changing the repository")
+
+// pollInterval is how often Acquire retries a held lock
+const pollInterval = 100 * time.Millisecond
+
+// Lock is a held repository lock
+type Lock struct {
+	file *os.File
+}
+
+// Path returns the lock file of a repository
+func Path(dgitDir string) string {
+	return filepath.Join(dgitDir, "lock")
+}
+
+// TryAcquire takes the lock for operation, returning ErrBusy when it is held
+func TryAcquire(dgitDir, operation string) (*Lock, error) {
+	lock, err := tryAcquire(dgitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Name the holder for anyone left waiting
+	lock.file.Truncate(0)
+	lock.file.WriteAt([]byte(fmt.Sprintf("%d %s\n", os.Getpid(), operation)), 0)
+	return lock, nil
+}
+
+// tryAcquire takes the lock without naming a holder, leaving the file's contents alone
+func tryAcquire(dgitDir string) (*Lock, error) {
+	file, err := os.OpenFile(Path(dgitDir), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository lock: %w", err)
+	}
+	locked, err := tryLock(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock repository: %w", err)
+	}
+	if !locked {
+		file.Close()
+		return nil, ErrBusy
+	}
+	return &Lock{file: file}, nil
+}
+
+// Acquire takes the lock for operation, waiting for the current holder to release it;
+// onWait is called once, with the holder, when the lock is busy
+func Acquire(dgitDir, operation string, onWait func(holder string)) (*Lock, error) {
+	for waited := false; ; waited = true {
+		lock, err := TryAcquire(dgitDir, operation)
+		if !errors.Is(err, ErrBusy) {
+			return lock, err
+		}
+		if !waited && onWait != nil {
+			onWait(Holder(dgitDir))
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// LockFile takes an exclusive lock on path, waiting while another process holds it.
+// It guards one shared file (such as cache/access.json.lock for the access index)
+// and is independent of the repository lock
+func LockFile(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	for {
+		locked, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			return &Lock{file: file}, nil
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Release gives the lock up
+func (l *Lock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	unlock(l.file)
+	l.file.Close()
+	l.file = nil
+}
+
+// Held reports whether some process holds the lock right now; probing never rewrites
+// the holder, so read-only commands can ask
+func Held(dgitDir string) bool {
+	lock, err := tryAcquire(dgitDir)
+	if err != nil {
+		return errors.Is(err, ErrBusy)
+	}
+	lock.Release()
+	return false
+}
+
+// HeldFor returns the operation the current holder named, or "" when the lock is free
+func HeldFor(dgitDir string) string {
+	if !Held(dgitDir) {
+		return ""
+	}
+	data, err := os.ReadFile(Path(dgitDir))
+	if err != nil {
+		return ""
+	}
+	_, operation, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	return operation
+}
+
+// Holder describes the process that last took the lock, such as "pid 4121 (commit)"
+func Holder(dgitDir string) string {
+	data, err := os.ReadFile(Path(dgitDir))
+	if err != nil {
+		return "unknown process"
+	}
+	pid, operation, ok := strings.Cut(strings.TrimSpace(string(data)), " ")
+	if !ok || pid == "" {
+		return "unknown process"
+	}
+	return fmt.Sprintf("pid %s (%s)", pid, operation)
+}
@@ -0,0 +1,79 @@
+package repolock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLockIsExclusive(t *testing.T) {
+	dgitDir := t.TempDir()
+	first, err := TryAcquire(dgitDir, "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TryAcquire(dgitDir, "add"); !errors.Is(err, ErrBusy) {
+		t.Fatalf("second TryAcquire: got %v, want ErrBusy", err)
+	}
+	if !Held(dgitDir) {
+		t.Fatal("Held is false while the lock is taken")
+	}
+	if want := fmt.Sprintf("pid %d (commit)", os.Getpid()); Holder(dgitDir) != want {
+		t.Fatalf("Holder = %q, want %q", Holder(dgitDir), want)
+	}
+
+	first.Release()
+	if Held(dgitDir) {
+		t.Fatal("Held is true after Release")
+	}
+	second, err := TryAcquire(dgitDir, "add")
+	if err != nil {
+		t.Fatalf("TryAcquire after Release: %v", err)
+	}
+	second.Release()
+}
+
+func TestAcquireWaitsForHolder(t *testing.T) {
+	dgitDir := t.TempDir()
+	holder, err := TryAcquire(dgitDir, "batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	released := make(chan time.Time, 1)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		released <- time.Now()
+		holder.Release()
+	}()
+
+	var waitedOn string
+	lock, err := Acquire(dgitDir, "commit", func(h string) { waitedOn = h })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+	if acquired := time.Now(); acquired.Before(<-released) {
+		t.Fatal("Acquire returned before the holder released the lock")
+	}
+	if want := fmt.Sprintf("pid %d (batch)", os.Getpid()); waitedOn != want {
+		t.Fatalf("onWait got %q, want %q", waitedOn, want)
+	}
+}
+
+func TestHeldLeavesHolderAlone(t *testing.T) {
+	dgitDir := t.TempDir()
+	lock, err := TryAcquire(dgitDir, "commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Release()
+
+	if Held(dgitDir) {
+		t.Fatal("Held is true after Release")
+	}
+	if want := fmt.Sprintf("pid %d (commit)", os.Getpid()); Holder(dgitDir) != want {
+		t.Fatalf("Holder after probing = %q, want %q", Holder(dgitDir), want)
+	}
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"dgit/internal/log"
+)
+
+// Complexity reports are built from the metadata the scanner records at commit time, so
+// no old versions are restored. Versions committed before group and smart-object counts
+// were recorded only carry a layer count; their Structure field is false.
+
+// ComplexityPoint is one version of a file in a complexity report
+type ComplexityPoint struct {
+	Version      int       `json:"version"`
+	Hash         string    `json:"hash"`
+	Timestamp    time.Time `json:"timestamp"`
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	Layers       int       `json:"layers"`
+	Groups       int       `json:"groups"`
+	GroupDepth   int       `json:"group_depth"`
+	SmartObjects int       `json:"smart_objects"`
+	Structure    bool      `json:"structure"` // Groups, depth and smart objects were recorded
+}
+
+// Metric names accepted by Value
+const (
+	MetricLayers       = "layers"
+	MetricGroups       = "groups"
+	MetricDepth        = "depth"
+	MetricSmartObjects = "smart"
+	MetricSize         = "size"
+)
+
+// Metrics lists the plottable metrics in display order
+var Metrics = []string{MetricLayers, MetricGroups, MetricDepth, MetricSmartObjects, MetricSize}
+
+// Value returns a metric of the point and whether it was recorded
+func (p ComplexityPoint) Value(metric string) (int64, bool) {
+	switch metric {
+	case MetricLayers:
+		return int64(p.Layers), true
+	case MetricGroups:
+		return int64(p.Groups), p.Structure
+	case MetricDepth:
+		return int64(p.GroupDepth), p.Structure
+	case MetricSmartObjects:
+		return int64(p.SmartObjects), p.Structure
+	case MetricSize:
+		return p.Size, p.Size > 0
+	}
+	return 0, false
+}
+
+// ValidMetric reports whether name is one of Metrics
+func ValidMetric(name string) bool {
+	for _, metric := range Metrics {
+		if metric == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Complexity returns the recorded complexity of every committed version of path, oldest
+// first, following renames made with 'dgit mv'
+func Complexity(dgitDir, path string) ([]ComplexityPoint, error) {
+	commits, err := log.NewLogManager(dgitDir).GetCommitHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	requested := path
+	var points []ComplexityPoint
+	for _, commit := range commits {
+		if fileMeta, ok := commit.Metadata[path].(map[string]interface{}); ok {
+			point := ComplexityPoint{
+				Version:   commit.Version,
+				Hash:      commit.Hash,
+				Timestamp: commit.Timestamp,
+				Path:      path,
+				Size:      number(fileMeta["size"]),
+				Layers:    int(number(fileMeta["layers"])),
+			}
+			if _, ok := fileMeta["groups"]; ok {
+				point.Structure = true
+				point.Groups = int(number(fileMeta["groups"]))
+				point.GroupDepth = int(number(fileMeta["group_depth"]))
+				point.SmartObjects = int(number(fileMeta["smart_objects"]))
+			}
+			points = append(points, point)
+		}
+		if oldPath, ok := commit.Renamed[path]; ok {
+			path = oldPath
+		}
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("'%s' has no committed versions", requested)
+	}
+
+	// History is newest first; reports read oldest to newest
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	return points, nil
+}
+
+// number reads an integer from commit metadata, which is float64 after a JSON round trip
+func number(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,157 @@
+package restore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dgit/internal/log"
+	"dgit/internal/pathnorm"
+	"dgit/internal/status"
+	"dgit/internal/trash"
+)
+
+// OverwriteError is returned when a restore would overwrite local changes without --force
+type OverwriteError struct {
+	Files []string
+}
+
+func (e *OverwriteError) Error() string {
+	return fmt.Sprintf("restore would overwrite %d locally modified file(s): %s",
+		len(e.Files), strings.Join(e.Files, ", "))
+}
+
+// findModifiedFiles lists working tree files the restore would overwrite that differ from HEAD
+// Files absent from HEAD count as modified, since their content exists nowhere else
+func (rm *RestoreManager) findModifiedFiles(commit *log.Commit, filesToRestore []string) ([]string, error) {
+	workDir, err := rm.workDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	normalizedTargets := make([]string, len(filesToRestore))
+	for i, target := range filesToRestore {
+		normalizedTargets[i] = filepath.Clean(strings.ReplaceAll(target, "\\", "/"))
+	}
+
+	head := rm.headFiles()
+	var modified []string
+	var unhashed []string // Tracked in a HEAD committed before content hashes were recorded
+	for path := range commit.Metadata {
+		if len(filesToRestore) > 0 && !rm.shouldRestoreFile(path, normalizedTargets) {
+			continue
+		}
+
+		localPath := filepath.Join(workDir, path)
+		info, err := os.Stat(localPath)
+		if err != nil {
+			continue // Nothing to overwrite
+		}
+
+		headFile, tracked := head[pathnorm.NFC(path)]
+		if !tracked {
+			modified = append(modified, path)
+			continue
+		}
+		if size, ok := headFile["size"].(float64); ok && int64(size) != info.Size() {
+			modified = append(modified, path)
+			continue
+		}
+		headHash, ok := headFile["hash"].(string)
+		if !ok {
+			unhashed = append(unhashed, path)
+			continue
+		}
+		currentHash, err := status.CalculateFileHash(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		if currentHash != headHash {
+			modified = append(modified, path)
+		}
+	}
+
+	// Only older commits need their snapshot decompressed to compare content
+	if len(unhashed) > 0 {
+		changed, err := rm.changedSinceSnapshot(workDir, unhashed)
+		if err != nil {
+			return nil, err
+		}
+		modified = append(modified, changed...)
+	}
+
+	sort.Strings(modified)
+	return modified, nil
+}
+
+// headFiles returns the per-file metadata HEAD recorded (size, and the content hash of
+// commits made since hashes were kept), keyed by normalized path
+func (rm *RestoreManager) headFiles() map[string]map[string]interface{} {
+	files := make(map[string]map[string]interface{})
+	headVersion := rm.headVersion()
+	if headVersion == 0 {
+		return files
+	}
+	head, err := log.NewLogManager(rm.DgitDir).GetCommit(headVersion)
+	if err != nil {
+		return files
+	}
+	for path, meta := range head.Metadata {
+		fileMeta, ok := meta.(map[string]interface{})
+		if !ok {
+			fileMeta = map[string]interface{}{}
+		}
+		files[pathnorm.NFC(path)] = fileMeta
+	}
+	return files
+}
+
+// changedSinceSnapshot compares files against the HEAD snapshot, for commits without hashes
+func (rm *RestoreManager) changedSinceSnapshot(workDir string, paths []string) ([]string, error) {
+	hashes, err := status.NewStatusManager(rm.DgitDir).GetSnapshotFileHashes(rm.headVersion())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load HEAD snapshot: %w", err)
+	}
+	headHashes := make(map[string]string, len(hashes))
+	for path, hash := range hashes {
+		headHashes[pathnorm.NFC(path)] = hash
+	}
+
+	var changed []string
+	for _, path := range paths {
+		currentHash, err := status.CalculateFileHash(filepath.Join(workDir, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		if headHash, ok := headHashes[pathnorm.NFC(path)]; !ok || headHash != currentHash {
+			changed = append(changed, path)
+		}
+	}
+	return changed, nil
+}
+
+// backupModifiedFiles saves files into the trash before a forced restore overwrites them
+func (rm *RestoreManager) backupModifiedFiles(files []string, version int) (*trash.Entry, error) {
+	workDir, err := rm.workDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	reason := fmt.Sprintf("forced restore of v%d", version)
+	return trash.NewTrashManager(rm.DgitDir).Save(workDir, files, reason)
+}
+
+// headVersion returns the version HEAD points at, falling back to the latest version
+func (rm *RestoreManager) headVersion() int {
+	logManager := log.NewLogManager(rm.DgitDir)
+	if data, err := os.ReadFile(filepath.Join(rm.DgitDir, "HEAD")); err == nil {
+		if hash := strings.TrimSpace(string(data)); hash != "" {
+			if commit, err := logManager.GetCommitByHash(hash); err == nil {
+				return commit.Version
+			}
+		}
+	}
+	return logManager.GetCurrentVersion()
+}
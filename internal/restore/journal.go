@@ -0,0 +1,314 @@
+package restore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"dgit/internal/log"
+)
+
+// A resumable restore writes every file into .dgit/checkout/stage first and only then
+// moves the staged files into the work tree. Its progress is recorded in
+// .dgit/checkout/journal.json, including a checkpoint after every delta replay step,
+// so a restore interrupted by a crash, reboot or full disk continues where it stopped
+// instead of replaying the whole delta chain again.
+
+// Journal phases
+const (
+	PhaseStage = "stage" // Reconstructing files into the staging area
+	PhasePlace = "place" // Moving staged files into the work tree
+)
+
+// ErrNoCheckout is returned when there is no interrupted restore to continue or abort
+var ErrNoCheckout = errors.New("no interrupted checkout to continue")
+
+// Journal records the progress of a resumable restore
+type Journal struct {
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	Files     []string  `json:"files,omitempty"` // Requested paths; empty means every file
+	WorkDir   string    `json:"work_dir"`
+	StartedAt time.Time `json:"started_at"`
+	Phase     string    `json:"phase"`
+
+	ReplayStep    int    `json:"replay_step,omitempty"`    // Delta chain steps completed
+	ReplayVersion int    `json:"replay_version,omitempty"` // Version the replay checkpoint holds
+	ReplayFile    string `json:"replay_file,omitempty"`    // Checkpoint after ReplayStep
+
+	Method   string            `json:"method,omitempty"`
+	CacheHit string            `json:"cache_hit,omitempty"`
+	Bytes    int64             `json:"bytes,omitempty"`
+	Staged   []string          `json:"staged,omitempty"`
+	Placed   int               `json:"placed,omitempty"` // Staged files already moved, in order
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// InterruptedError is returned when a resumable restore starts while another is unfinished
+type InterruptedError struct {
+	Journal *Journal
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("an interrupted checkout of v%d is pending (%s phase)", e.Journal.Version, e.Journal.Phase)
+}
+
+// checkoutDir holds the journal, staging area and replay checkpoints
+func checkoutDir(dgitDir string) string {
+	return filepath.Join(dgitDir, "checkout")
+}
+
+// LoadJournal returns the journal of an interrupted restore, or nil when there is none
+func LoadJournal(dgitDir string) (*Journal, error) {
+	data, err := os.ReadFile(filepath.Join(checkoutDir(dgitDir), "journal.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("corrupt checkout journal: %w", err)
+	}
+	return &journal, nil
+}
+
+// AbortCheckout discards an interrupted restore; files already moved into the work tree stay
+func AbortCheckout(dgitDir string) (*Journal, error) {
+	journal, err := LoadJournal(dgitDir)
+	if err != nil {
+		return nil, err
+	}
+	if journal == nil {
+		return nil, ErrNoCheckout
+	}
+	return journal, os.RemoveAll(checkoutDir(dgitDir))
+}
+
+// Continue finishes an interrupted resumable restore
+func (rm *RestoreManager) Continue() (*RestoreResult, error) {
+	startTime := time.Now()
+	journal, err := LoadJournal(rm.DgitDir)
+	if err != nil {
+		return nil, err
+	}
+	if journal == nil {
+		return nil, ErrNoCheckout
+	}
+
+	commit, err := log.NewLogManager(rm.DgitDir).GetCommit(journal.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commit data: %w", err)
+	}
+	if commit.Hash != journal.Hash {
+		return nil, fmt.Errorf("v%d changed since the checkout was interrupted (was %s, now %s); abort it and restore again",
+			journal.Version, journal.Hash, commit.Hash)
+	}
+
+	rm.WorkDir = journal.WorkDir
+	rm.journal = journal
+	rm.expected = 0
+	if len(journal.Files) == 0 {
+		rm.expected = len(commit.Metadata)
+	}
+	rm.printf("Resuming checkout of v%d (%s phase, started %s)\n", journal.Version, journal.Phase, journal.StartedAt.Format("2006-01-02 15:04"))
+
+	result, err := rm.runJournal(commit)
+	if err != nil {
+		return nil, err
+	}
+	return rm.finishRestore(result, commit, fmt.Sprintf("v%d", commit.Version), startTime), nil
+}
+
+// journaledRestore starts a resumable restore of commit
+func (rm *RestoreManager) journaledRestore(commit *log.Commit, filesToRestore []string) (*RestoreResult, error) {
+	workDir, err := rm.workDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	if workDir, err = filepath.Abs(workDir); err != nil {
+		return nil, err
+	}
+
+	rm.journal = &Journal{
+		Version:   commit.Version,
+		Hash:      commit.Hash,
+		Files:     filesToRestore,
+		WorkDir:   workDir,
+		StartedAt: time.Now(),
+		Phase:     PhaseStage,
+	}
+	if err := os.MkdirAll(checkoutDir(rm.DgitDir), 0755); err != nil {
+		return nil, err
+	}
+	if err := rm.saveJournal(); err != nil {
+		return nil, err
+	}
+	return rm.runJournal(commit)
+}
+
+// runJournal carries the active journal through its remaining phases
+func (rm *RestoreManager) runJournal(commit *log.Commit) (*RestoreResult, error) {
+	journal := rm.journal
+	defer func() { rm.journal = nil }()
+
+	if journal.Phase == PhaseStage {
+		result, err := rm.stage(commit)
+		if err != nil {
+			return nil, err
+		}
+		journal.Phase = PhasePlace
+		journal.Method = result.RestoreMethod
+		journal.CacheHit = result.CacheHitLevel
+		journal.Bytes = result.DataTransferred
+		journal.Staged = result.RestoredFiles
+		journal.Errors = make(map[string]string, len(result.ErrorFiles))
+		for file, err := range result.ErrorFiles {
+			journal.Errors[file] = err.Error()
+		}
+		if err := rm.saveJournal(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rm.place(); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(checkoutDir(rm.DgitDir)); err != nil {
+		rm.printf("Warning: failed to clean up %s: %v\n", checkoutDir(rm.DgitDir), err)
+	}
+
+	result := &RestoreResult{
+		SourceVersion:    commit.Version,
+		SourceCommitHash: commit.Hash,
+		RestoredFiles:    journal.Staged,
+		SkippedFiles:     []string{},
+		ErrorFiles:       make(map[string]error, len(journal.Errors)),
+		RestoreMethod:    journal.Method,
+		CacheHitLevel:    journal.CacheHit,
+		DataTransferred:  journal.Bytes,
+	}
+	for file, message := range journal.Errors {
+		result.ErrorFiles[file] = errors.New(message)
+	}
+	result.TotalFilesCount = len(result.RestoredFiles) + len(result.ErrorFiles)
+	return result, nil
+}
+
+// stage reconstructs the requested files into the staging area
+func (rm *RestoreManager) stage(commit *log.Commit) (*RestoreResult, error) {
+	rm.stageDir = filepath.Join(checkoutDir(rm.DgitDir), "stage")
+	defer func() { rm.stageDir = "" }()
+	if err := os.MkdirAll(rm.stageDir, 0755); err != nil {
+		return nil, err
+	}
+	return rm.performFastRestore(commit, rm.journal.Files, commit.Version)
+}
+
+// place moves staged files into the work tree, recording each move in the journal
+func (rm *RestoreManager) place() error {
+	journal := rm.journal
+	stageDir := filepath.Join(checkoutDir(rm.DgitDir), "stage")
+	for journal.Placed < len(journal.Staged) {
+		file := journal.Staged[journal.Placed]
+		target := filepath.Join(journal.WorkDir, file)
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		source := filepath.Join(stageDir, file)
+		// A crash between the move and the journal update leaves the file already placed
+		if rm.fileExists(source) || !rm.fileExists(target) {
+			if err := moveFile(source, target); err != nil {
+				return fmt.Errorf("failed to place %s: %w", file, err)
+			}
+		}
+		journal.Placed++
+		if err := rm.saveJournal(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkpointReplay records that the delta replay has produced file after step
+func (rm *RestoreManager) checkpointReplay(step int, version int, file string) {
+	if rm.journal == nil {
+		return
+	}
+	rm.journal.ReplayStep = step
+	rm.journal.ReplayVersion = version
+	rm.journal.ReplayFile = file
+	rm.saveJournal()
+}
+
+// replayCheckpoint returns the step and file to resume a delta replay from, if the
+// journal holds a checkpoint for this restoration path
+func (rm *RestoreManager) replayCheckpoint(path []RestorationStep) (int, string) {
+	if rm.journal == nil || rm.journal.ReplayFile == "" || rm.journal.ReplayStep >= len(path) {
+		return 0, ""
+	}
+	if path[rm.journal.ReplayStep].Version != rm.journal.ReplayVersion || !rm.fileExists(rm.journal.ReplayFile) {
+		return 0, ""
+	}
+	return rm.journal.ReplayStep, rm.journal.ReplayFile
+}
+
+// scratchDir is where delta replay writes intermediate files; resumable restores keep
+// them with the journal so they survive until the restore completes
+func (rm *RestoreManager) scratchDir() string {
+	if rm.journal != nil {
+		return checkoutDir(rm.DgitDir)
+	}
+	return rm.ObjectsDir
+}
+
+// discardScratch removes an intermediate file unless it is the journal's checkpoint
+func (rm *RestoreManager) discardScratch(path string) {
+	if rm.journal == nil || rm.journal.ReplayFile != path {
+		os.Remove(path)
+	}
+}
+
+// saveJournal writes the active journal atomically
+func (rm *RestoreManager) saveJournal() error {
+	data, err := json.MarshalIndent(rm.journal, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(checkoutDir(rm.DgitDir), "journal.json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkout journal: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// moveFile renames src to dst, copying when they are on different volumes
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
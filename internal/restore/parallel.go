@@ -0,0 +1,55 @@
+package restore
+
+import (
+	initializer "dgit/internal/init"
+	"dgit/internal/iosched"
+	"dgit/internal/progress"
+)
+
+// scheduler returns an IO scheduler for the disk holding workDir, honoring the restore
+// config and the manager's Workers override
+func (rm *RestoreManager) scheduler(workDir string) *iosched.Scheduler {
+	workers, profile := 0, ""
+	if config, err := initializer.GetConfig(rm.DgitDir); err == nil {
+		workers, profile = config.Restore.Workers, config.Restore.IOProfile
+	}
+	if rm.Workers > 0 {
+		workers = rm.Workers
+	}
+	return iosched.NewScheduler(iosched.ParseMedium(profile, workDir), workers)
+}
+
+// writeFiles runs independent file writes concurrently and records each in result as it
+// finishes, reporting per-file progress
+func (rm *RestoreManager) writeFiles(workDir string, jobs []iosched.Job, result *RestoreResult) {
+	if len(jobs) == 0 {
+		return
+	}
+	scheduler := rm.scheduler(workDir)
+	if len(jobs) > 1 {
+		rm.printf("Writing %d files with %d workers (%s)\n", len(jobs), min(scheduler.Workers, len(jobs)), scheduler.Medium)
+	}
+
+	var total, written int64
+	for _, job := range jobs {
+		total += job.Size
+	}
+	scheduler.Run(jobs, func(job iosched.Job, err error) {
+		if err != nil {
+			result.ErrorFiles[job.Name] = err
+			return
+		}
+		written += job.Size
+		result.RestoredFiles = append(result.RestoredFiles, job.Name)
+		rm.Progress.Emit(progress.Event{Operation: "restore", Phase: progress.PhaseRestore, File: job.Name,
+			Bytes: written, TotalBytes: total, Current: len(result.RestoredFiles), Total: rm.expected})
+		rm.printf("  [%d/%d] %s\n", len(result.RestoredFiles)+len(result.ErrorFiles), len(jobs), job.Name)
+	})
+}
+
+// metadataSize returns a file's recorded size from commit metadata, or 0 when unknown
+func metadataSize(metadata map[string]interface{}, file string) int64 {
+	fileMeta, _ := metadata[file].(map[string]interface{})
+	size, _ := fileMeta["size"].(float64)
+	return int64(size)
+}
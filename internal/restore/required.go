@@ -0,0 +1,99 @@
+package restore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dgit/internal/log"
+)
+
+// RequiredFiles lists the stored objects a full restore of version reads, following the
+// same lookup order as the restore itself; replicas use it to fetch only what a job needs
+func (rm *RestoreManager) RequiredFiles(version int) ([]string, error) {
+	commit, err := log.NewLogManager(rm.DgitDir).GetCommit(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commit data: %w", err)
+	}
+
+	info := commit.CompressionInfo
+	if info != nil {
+		switch info.Strategy {
+		case "lz4":
+			if path, _ := rm.findFileInStorage(commit.Version, "lz4"); path != "" {
+				return []string{path}, nil
+			}
+			if path, _ := rm.findFileInStorage(commit.Version, "zstd"); path != "" {
+				return []string{path}, nil
+			}
+		case "psd_smart", "design_smart_delta":
+			path, _ := rm.findFileInStorage(commit.Version, strings.TrimPrefix(filepath.Ext(info.OutputFile), "."))
+			for _, candidate := range []string{path, filepath.Join(rm.DeltasDir, info.OutputFile), filepath.Join(rm.CacheDir, info.OutputFile)} {
+				if candidate != "" && rm.fileExists(candidate) {
+					return []string{candidate}, nil
+				}
+			}
+			return nil, fmt.Errorf("smart delta file not found: %s", info.OutputFile)
+		case "bsdiff", "xdelta3":
+			path, err := rm.findOptimizedRestorationPath(version)
+			if err != nil {
+				return nil, err
+			}
+			files := make([]string, len(path))
+			for i, step := range path {
+				files[i] = step.File
+			}
+			return files, nil
+		case "zip":
+			return rm.requireObject(info.OutputFile)
+		}
+	}
+
+	if commit.SnapshotZip != "" {
+		return rm.requireObject(commit.SnapshotZip)
+	}
+	return nil, fmt.Errorf("no restoration method available for version %d", version)
+}
+
+// requireObject returns a ZIP object's path, failing when it is missing
+func (rm *RestoreManager) requireObject(name string) ([]string, error) {
+	path := filepath.Join(rm.ObjectsDir, name)
+	if !rm.fileExists(path) {
+		return nil, fmt.Errorf("ZIP file not found: %s", name)
+	}
+	return []string{path}, nil
+}
+
+// Rebase stores version as a full ZIP snapshot rebuilt from its delta chain, so restoring
+// it or any later version starts there instead of replaying the whole chain; it returns
+// the snapshot's size, or 0 when version already restores without replaying deltas
+func (rm *RestoreManager) Rebase(version int) (int64, error) {
+	path, err := rm.findOptimizedRestorationPath(version)
+	if err != nil {
+		return 0, err
+	}
+	if len(path) <= 1 {
+		return 0, nil
+	}
+
+	rebuilt, err := rm.executeOptimizedRestorationPath(path)
+	if err != nil {
+		return 0, err
+	}
+	target := filepath.Join(rm.ObjectsDir, fmt.Sprintf("v%d.zip", version))
+	if err := moveFile(rebuilt, target+".tmp"); err != nil {
+		os.Remove(rebuilt)
+		return 0, fmt.Errorf("failed to store snapshot of v%d: %w", version, err)
+	}
+	if err := os.Rename(target+".tmp", target); err != nil {
+		os.Remove(target + ".tmp")
+		return 0, fmt.Errorf("failed to store snapshot of v%d: %w", version, err)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
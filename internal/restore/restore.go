@@ -3,16 +3,28 @@ package restore
 import (
 	"archive/zip"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"dgit/internal/access"
+	"dgit/internal/filedelta"
+	"dgit/internal/generation"
+	initializer "dgit/internal/init"
+	"dgit/internal/iosched"
 	"dgit/internal/log"
+	"dgit/internal/metapack"
+	"dgit/internal/objfmt"
+	"dgit/internal/pathnorm"
+	"dgit/internal/progress"
+	"dgit/internal/xattr"
 
 	"github.com/gabstv/go-bsdiff/pkg/bspatch"
 	"github.com/klauspost/compress/zstd"
@@ -34,18 +46,40 @@ type RestoreManager struct {
 	DeltasDir    string // Delta files (.dgit/deltas/)
 	CommitsDir   string // Commit metadata (.dgit/commits/)
 	CacheDir     string // Single cache directory (.dgit/cache/)
+	ArchiveDir   string // Cold Zstd archive tier (.dgit/archive/)
+
+	// Force overwrites locally modified files after backing them up to the trash
+	Force bool
+
+	// WorkDir is the tree files are restored into; empty means the current directory
+	WorkDir string
+
+	// Resumable stages files with a journal so an interrupted restore can be continued
+	Resumable bool
+
+	// Workers caps how many files are written at once; 0 uses the restore config
+	Workers int
+
+	// Progress receives typed events instead of stdout output when set (library mode)
+	Progress progress.Reporter
+	expected int // Files expected in the current restore, for progress percentages
+
+	journal  *Journal // Progress of the running resumable restore
+	stageDir string   // Staging area files are written to instead of WorkDir
 }
 
 // NewRestoreManager creates a new restore manager with unified structure
 func NewRestoreManager(dgitDir string) *RestoreManager {
-	objectsDir := filepath.Join(dgitDir, "objects")
+	storageDir := initializer.GetStorageDir(dgitDir)
+	objectsDir := filepath.Join(storageDir, "objects")
 	return &RestoreManager{
 		DgitDir:      dgitDir,
 		ObjectsDir:   objectsDir,
-		SnapshotsDir: filepath.Join(dgitDir, "snapshots"),
-		DeltasDir:    filepath.Join(dgitDir, "deltas"),
+		SnapshotsDir: filepath.Join(storageDir, "snapshots"),
+		DeltasDir:    filepath.Join(storageDir, "deltas"),
 		CommitsDir:   filepath.Join(dgitDir, "commits"),
 		CacheDir:     filepath.Join(dgitDir, "cache"),
+		ArchiveDir:   filepath.Join(storageDir, "archive"),
 	}
 }
 
@@ -80,37 +114,187 @@ func (e *RestoreError) Error() string {
 
 // RestoreFilesFromCommit restores files using optimized strategies
 func (rm *RestoreManager) RestoreFilesFromCommit(commitHashOrVersion string, filesToRestore []string, targetCommit interface{}) error {
+	_, err := rm.Restore(commitHashOrVersion, filesToRestore)
+	return err
+}
+
+// Restore restores files from a commit and returns the typed result
+func (rm *RestoreManager) Restore(commitHashOrVersion string, filesToRestore []string) (*RestoreResult, error) {
 	startTime := time.Now()
 
 	// Parse commit reference (supports both hash and version formats)
 	version, err := rm.parseCommitReference(commitHashOrVersion)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Printf("Analyzing restoration strategy for v%d...\n", version)
+	if rm.Resumable {
+		journal, err := LoadJournal(rm.DgitDir)
+		if err != nil {
+			return nil, err
+		}
+		if journal != nil {
+			return nil, &InterruptedError{Journal: journal}
+		}
+	}
+
+	rm.printf("Analyzing restoration strategy for v%d...\n", version)
+	rm.invalidateStaleCache()
+	rm.Progress.Emit(progress.Event{Operation: "restore", Phase: progress.PhaseAnalyze})
 
 	// Load commit data using log manager
 	logManager := log.NewLogManager(rm.DgitDir)
 	commit, err := logManager.GetCommit(version)
 	if err != nil {
-		return fmt.Errorf("failed to load commit data: %w", err)
+		return nil, fmt.Errorf("failed to load commit data: %w", err)
+	}
+	rm.expected = 0
+	if len(filesToRestore) == 0 {
+		rm.expected = len(commit.Metadata)
+	}
+
+	// Protect local changes before anything is overwritten
+	modifiedFiles, err := rm.findModifiedFiles(commit, filesToRestore)
+	if err != nil {
+		return nil, err
+	}
+	if len(modifiedFiles) > 0 {
+		if !rm.Force {
+			return nil, &OverwriteError{Files: modifiedFiles}
+		}
+		rm.Progress.Emit(progress.Event{Operation: "restore", Phase: progress.PhaseBackup, Total: len(modifiedFiles)})
+		entry, err := rm.backupModifiedFiles(modifiedFiles, version)
+		if err != nil {
+			return nil, fmt.Errorf("backup before forced restore failed: %w", err)
+		}
+		rm.printf("Backed up %d modified files to trash %s\n", len(entry.Files), entry.ID)
+		rm.printf("  Recover with: dgit trash restore %s\n\n", entry.ID)
 	}
 
 	// Choose optimal restoration method based on cache availability
-	result, err := rm.performFastRestore(commit, filesToRestore, version)
+	var result *RestoreResult
+	if rm.Resumable {
+		result, err = rm.journaledRestore(commit, filesToRestore)
+	} else {
+		result, err = rm.performFastRestore(commit, filesToRestore, version)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	return rm.finishRestore(result, commit, commitHashOrVersion, startTime), nil
+}
+
+// finishRestore applies attributes, records the access and reports the result
+func (rm *RestoreManager) finishRestore(result *RestoreResult, commit *log.Commit, commitRef string, startTime time.Time) *RestoreResult {
+	version := commit.Version
+	rm.restoreAttributes(commit, result)
+
+	// Record access so maintenance can tell hot versions from cold ones
+	access.NewAccessTracker(rm.DgitDir).RecordRestore(version)
+
 	// Calculate performance metrics
 	result.RestorationTime = time.Since(startTime)
 	result.SpeedImprovement = rm.calculateSpeedImprovement(result.RestoreMethod, result.RestorationTime)
 
 	// Display restoration results
-	rm.displayRestoreResults(result, commitHashOrVersion, version)
+	rm.displayRestoreResults(result, commitRef, version)
+	rm.Progress.Emit(progress.Event{Operation: "restore", Phase: progress.PhaseDone,
+		Bytes: result.DataTransferred, Current: len(result.RestoredFiles), Total: len(result.RestoredFiles)})
 
-	return nil
+	return result
+}
+
+// restoreAttributes reapplies extended attributes recorded with the commit (config-gated)
+func (rm *RestoreManager) restoreAttributes(commit *log.Commit, result *RestoreResult) {
+	policy := xattr.LoadPolicy(rm.DgitDir)
+	if !policy.Enabled {
+		return
+	}
+	workDir, err := rm.workDir()
+	if err != nil {
+		return
+	}
+
+	applied, skipped := 0, 0
+	for _, file := range result.RestoredFiles {
+		fileMeta, _ := commit.Metadata[file].(map[string]interface{})
+		encoded, _ := fileMeta["xattrs"].(map[string]interface{})
+		if len(encoded) == 0 {
+			continue
+		}
+
+		attrs := make(map[string][]byte, len(encoded))
+		for name, value := range encoded {
+			if text, ok := value.(string); ok {
+				if data, err := base64.StdEncoding.DecodeString(text); err == nil {
+					attrs[name] = data
+				}
+			}
+		}
+		a, s := policy.Apply(filepath.Join(workDir, file), attrs)
+		applied += a
+		skipped += s
+	}
+
+	if applied > 0 || skipped > 0 {
+		rm.printf("Extended attributes: %d restored", applied)
+		if skipped > 0 {
+			rm.printf(", %d not supported here", skipped)
+		}
+		rm.println()
+	}
+}
+
+// invalidateStaleCache drops cached version objects built before the last history rewrite,
+// since version numbers may now refer to different content
+func (rm *RestoreManager) invalidateStaleCache() {
+	if !generation.DirIsStale(rm.DgitDir, rm.CacheDir) {
+		return
+	}
+
+	entries, _ := os.ReadDir(rm.CacheDir)
+	for _, entry := range entries {
+		if !entry.IsDir() && cachedVersionPattern.MatchString(entry.Name()) {
+			os.Remove(filepath.Join(rm.CacheDir, entry.Name()))
+		}
+	}
+	generation.MarkDir(rm.DgitDir, rm.CacheDir)
+}
+
+// cachedVersionPattern matches version objects in the cache tier (v3.lz4, v3_optimized.zstd, v3_from_v2.psd_smart)
+var cachedVersionPattern = regexp.MustCompile(`^v\d+(_[A-Za-z0-9_]+)?\.(lz4|zstd|psd_smart)$`)
+
+// markRestored records a restored file and reports it
+func (rm *RestoreManager) markRestored(result *RestoreResult, file string) {
+	result.RestoredFiles = append(result.RestoredFiles, file)
+	rm.Progress.Emit(progress.Event{Operation: "restore", Phase: progress.PhaseRestore, File: file,
+		Current: len(result.RestoredFiles), Total: rm.expected})
+}
+
+// printf writes human-readable progress in CLI mode; library callers get events instead
+func (rm *RestoreManager) printf(format string, a ...interface{}) {
+	if !rm.Progress.Enabled() {
+		fmt.Printf(format, a...)
+	}
+}
+
+// println is the Println counterpart of printf
+func (rm *RestoreManager) println(a ...interface{}) {
+	if !rm.Progress.Enabled() {
+		fmt.Println(a...)
+	}
+}
+
+// workDir returns the directory files are restored into
+func (rm *RestoreManager) workDir() (string, error) {
+	if rm.stageDir != "" {
+		return rm.stageDir, nil
+	}
+	if rm.WorkDir != "" {
+		return rm.WorkDir, nil
+	}
+	return os.Getwd()
 }
 
 // performFastRestore intelligently chooses the fastest available restoration method
@@ -135,22 +319,22 @@ func (rm *RestoreManager) performFastRestore(commit *log.Commit, filesToRestore
 	if commit.CompressionInfo != nil {
 		switch commit.CompressionInfo.Strategy {
 		case "psd_smart":
-			fmt.Println("Using smart PSD delta restoration...")
+			rm.println("Using smart PSD delta restoration...")
 			result.RestoreMethod = "smart_delta"
 			result.CacheHitLevel = "smart"
 			return rm.restoreFromSmartDelta(commit, filesToRestore, result)
 		case "design_smart_delta":
-			fmt.Println("Using smart design delta restoration...")
+			rm.println("Using smart design delta restoration...")
 			result.RestoreMethod = "smart_delta"
 			result.CacheHitLevel = "smart"
 			return rm.restoreFromSmartDelta(commit, filesToRestore, result)
 		case "bsdiff", "xdelta3":
-			fmt.Println("Using optimized delta chain restoration...")
+			rm.println("Using optimized delta chain restoration...")
 			result.RestoreMethod = "delta_chain"
 			result.CacheHitLevel = "miss"
 			return rm.restoreFromOptimizedDeltaChain(version, filesToRestore, result)
 		case "zip":
-			fmt.Println("Using direct ZIP restoration...")
+			rm.println("Using direct ZIP restoration...")
 			result.RestoreMethod = "zip"
 			result.CacheHitLevel = "miss"
 			return rm.restoreFromZip(commit.CompressionInfo.OutputFile, filesToRestore, result)
@@ -159,7 +343,7 @@ func (rm *RestoreManager) performFastRestore(commit *log.Commit, filesToRestore
 
 	// Fallback: Legacy ZIP restoration for backward compatibility
 	if commit.SnapshotZip != "" {
-		fmt.Println("Using legacy ZIP restoration...")
+		rm.println("Using legacy ZIP restoration...")
 		result.RestoreMethod = "zip"
 		result.CacheHitLevel = "miss"
 		return rm.restoreFromZip(commit.SnapshotZip, filesToRestore, result)
@@ -179,6 +363,7 @@ func (rm *RestoreManager) findFileInStorage(version int, ext string) (string, st
 		{rm.CacheDir, "cache"},
 		{rm.DeltasDir, "deltas"},
 		{rm.ObjectsDir, "objects"}, // Legacy compatibility
+		{rm.ArchiveDir, "archive"}, // Cold storage tier
 	}
 
 	for _, loc := range searchLocations {
@@ -199,10 +384,25 @@ func (rm *RestoreManager) tryVersionRestore(commit *log.Commit, filesToRestore [
 	// Use unified search to find LZ4 file
 	lz4Path, level := rm.findFileInStorage(commit.Version, "lz4")
 	if lz4Path == "" {
+		// Snapshot may have been moved to the archive tier
+		if archivePath, archiveLevel := rm.findFileInStorage(commit.Version, "zstd"); archivePath != "" {
+			rm.printf("Using %s directory - cold storage\n", archiveLevel)
+			result.RestoreMethod = archiveLevel
+			result.CacheHitLevel = archiveLevel
+			if err := rm.extractFromZstd(archivePath, filesToRestore, result); err != nil {
+				return nil, &RestoreError{
+					Operation: "Zstd archive extraction",
+					Version:   commit.Version,
+					FilePath:  archivePath,
+					Err:       err,
+				}
+			}
+			return result, nil
+		}
 		return nil, nil // Not found, try other methods
 	}
 
-	fmt.Printf("Using %s directory - fast access!\n", level)
+	rm.printf("Using %s directory - fast access!\n", level)
 	result.RestoreMethod = level
 	result.CacheHitLevel = level
 
@@ -227,14 +427,19 @@ func (rm *RestoreManager) decompressFile(path string) ([]byte, error) {
 	}
 	defer file.Close()
 
+	payload, err := objfmt.NewReader(file, path)
+	if err != nil {
+		return nil, err
+	}
+
 	var reader io.Reader
 	ext := strings.ToLower(filepath.Ext(path))
 
 	switch ext {
 	case ".lz4":
-		reader = lz4.NewReader(file)
+		reader = lz4.NewReader(payload)
 	case ".zstd":
-		zstdReader, err := zstd.NewReader(file)
+		zstdReader, err := zstd.NewReader(payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
 		}
@@ -273,13 +478,15 @@ func (rm *RestoreManager) extractFromLZ4(lz4Path string, filesToRestore []string
 	result.DataTransferred = int64(len(decompressedData))
 
 	// Get current working directory for file restoration
-	currentWorkDir, err := os.Getwd()
+	currentWorkDir, err := rm.workDir()
 	if err != nil {
 		return fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
 	// Process all files from structured LZ4 stream
+	entries := parseStructuredData(decompressedData)
 	processedFiles := 0
+	var jobs []iosched.Job
 	for fileName := range commit.Metadata {
 		// Check if this file should be restored based on user request
 		if len(filesToRestore) > 0 {
@@ -296,21 +503,18 @@ func (rm *RestoreManager) extractFromLZ4(lz4Path string, filesToRestore []string
 			}
 		}
 
-		// Create target file path in working directory
-		targetPath := filepath.Join(currentWorkDir, fileName)
-
 		// Create file from decompressed data using structured format
-		if err := rm.createFileFromStructuredData(targetPath, decompressedData, fileName); err != nil {
-			result.ErrorFiles[fileName] = err
-		} else {
-			result.RestoredFiles = append(result.RestoredFiles, fileName)
-			fmt.Printf("Restored %s\n", fileName)
-		}
+		targetPath := filepath.Join(currentWorkDir, fileName)
+		name := fileName
+		jobs = append(jobs, iosched.Job{Name: name, Size: metadataSize(commit.Metadata, name), Run: func() error {
+			return rm.createFileFromStructuredData(targetPath, entries, name)
+		}})
 
 		processedFiles++
 	}
+	rm.writeFiles(currentWorkDir, jobs, result)
 
-	fmt.Printf("Processed %d files from storage\n", processedFiles)
+	rm.printf("Processed %d files from storage\n", processedFiles)
 	result.TotalFilesCount = len(result.RestoredFiles) + len(result.SkippedFiles) + len(result.ErrorFiles)
 	return nil
 }
@@ -332,7 +536,7 @@ func (rm *RestoreManager) extractFromZstd(zstdPath string, filesToRestore []stri
 // extractFilesFromData extracts files from decompressed data
 func (rm *RestoreManager) extractFilesFromData(data []byte, filesToRestore []string, result *RestoreResult) error {
 	// Get current working directory for file restoration
-	currentWorkDir, err := os.Getwd()
+	currentWorkDir, err := rm.workDir()
 	if err != nil {
 		return fmt.Errorf("failed to get current working directory: %w", err)
 	}
@@ -348,6 +552,7 @@ func (rm *RestoreManager) extractFilesFromData(data []byte, filesToRestore []str
 	}
 
 	// Process each file in the stream
+	var jobs []iosched.Job
 	for pos < len(content) {
 		// Find file header line
 		headerEnd := strings.Index(content[pos:], "\n")
@@ -397,14 +602,13 @@ func (rm *RestoreManager) extractFilesFromData(data []byte, filesToRestore []str
 
 		// Create target file in working directory
 		targetPath := filepath.Join(currentWorkDir, filePath)
-		if err := rm.createFileFromData(targetPath, fileData); err != nil {
-			result.ErrorFiles[filePath] = err
-		} else {
-			result.RestoredFiles = append(result.RestoredFiles, filePath)
-		}
+		jobs = append(jobs, iosched.Job{Name: filePath, Size: fileSize, Run: func() error {
+			return rm.createFileFromData(targetPath, fileData)
+		}})
 
 		pos = fileDataEnd
 	}
+	rm.writeFiles(currentWorkDir, jobs, result)
 
 	result.TotalFilesCount = len(result.RestoredFiles) + len(result.SkippedFiles) + len(result.ErrorFiles)
 	return nil
@@ -440,13 +644,16 @@ func (rm *RestoreManager) restoreFromSmartDelta(commit *log.Commit, filesToResto
 		}
 	}
 
-	fmt.Printf("Restoring from smart delta: %s\n", deltaPath)
+	rm.printf("Restoring from smart delta: %s\n", deltaPath)
 
 	// Read delta file
 	deltaData, err := os.ReadFile(deltaPath)
 	if err != nil {
 		return result, fmt.Errorf("failed to read delta file: %w", err)
 	}
+	if deltaData, err = objfmt.Strip(deltaData, deltaPath); err != nil {
+		return result, err
+	}
 
 	// Parse delta file format
 	content := string(deltaData)
@@ -500,9 +707,8 @@ func (rm *RestoreManager) restoreFromSmartDelta(commit *log.Commit, filesToResto
 
 	// Check if base version exists
 	if int(baseVersion) > 0 {
-		baseVersionPath := filepath.Join(rm.CommitsDir, fmt.Sprintf("v%d.json", int(baseVersion)))
-		if !rm.fileExists(baseVersionPath) {
-			fmt.Printf("Warning: base version v%d metadata not found\n", int(baseVersion))
+		if !metapack.NewStore(rm.CommitsDir).HasRecord(int(baseVersion)) {
+			rm.printf("Warning: base version v%d metadata not found\n", int(baseVersion))
 		}
 	}
 
@@ -525,7 +731,7 @@ func (rm *RestoreManager) restoreFromSmartDelta(commit *log.Commit, filesToResto
 	}
 
 	// Get current working directory
-	currentWorkDir, err := os.Getwd()
+	currentWorkDir, err := rm.workDir()
 	if err != nil {
 		return result, fmt.Errorf("failed to get working directory: %w", err)
 	}
@@ -559,28 +765,28 @@ func (rm *RestoreManager) restoreFromSmartDelta(commit *log.Commit, filesToResto
 		return result, fmt.Errorf("failed to write restored file: %w", err)
 	}
 
-	result.RestoredFiles = append(result.RestoredFiles, filePath)
+	rm.markRestored(result, filePath)
 	result.TotalFilesCount = 1
 	result.DataTransferred = int64(len(decompressedData))
 
 	// Log layer change information if available
 	if layerAnalysis, ok := deltaMetadata["layer_analysis"].(map[string]interface{}); ok {
 		if summary, ok := layerAnalysis["changes_summary"].(string); ok {
-			fmt.Printf("Layer changes applied: %s\n", summary)
+			rm.printf("Layer changes applied: %s\n", summary)
 		}
 
 		if addedLayers, ok := layerAnalysis["added_layers"].([]interface{}); ok && len(addedLayers) > 0 {
-			fmt.Printf("  Added %d layers\n", len(addedLayers))
+			rm.printf("  Added %d layers\n", len(addedLayers))
 		}
 		if deletedLayers, ok := layerAnalysis["deleted_layers"].([]interface{}); ok && len(deletedLayers) > 0 {
-			fmt.Printf("  Deleted %d layers\n", len(deletedLayers))
+			rm.printf("  Deleted %d layers\n", len(deletedLayers))
 		}
 		if changedLayers, ok := layerAnalysis["changed_layers"].([]interface{}); ok && len(changedLayers) > 0 {
-			fmt.Printf("  Modified %d layers\n", len(changedLayers))
+			rm.printf("  Modified %d layers\n", len(changedLayers))
 		}
 	}
 
-	fmt.Printf("Successfully restored %s (%d bytes)\n", filePath, len(decompressedData))
+	rm.printf("Successfully restored %s (%d bytes)\n", filePath, len(decompressedData))
 
 	return result, nil
 }
@@ -593,7 +799,7 @@ func (rm *RestoreManager) restoreFromOptimizedDeltaChain(targetVersion int, file
 		return result, err
 	}
 
-	fmt.Printf("   Found restoration path: %d steps\n", len(restorationPath))
+	rm.printf("   Found restoration path: %d steps\n", len(restorationPath))
 
 	// Execute optimized restoration sequence
 	tempFile, err := rm.executeOptimizedRestorationPath(restorationPath)
@@ -650,6 +856,18 @@ func (rm *RestoreManager) findOptimizedRestorationPath(targetVersion int) ([]Res
 			break
 		}
 
+		// Check archive tier
+		archivePath := filepath.Join(rm.ArchiveDir, fmt.Sprintf("v%d.zstd", currentVersion))
+		if rm.fileExists(archivePath) {
+			step := RestorationStep{
+				Type:    "zstd",
+				File:    archivePath,
+				Version: currentVersion,
+			}
+			path = append([]RestorationStep{step}, path...)
+			break
+		}
+
 		// Check for direct ZIP snapshot (legacy compatibility)
 		zipPath := filepath.Join(rm.ObjectsDir, fmt.Sprintf("v%d.zip", currentVersion))
 		if rm.fileExists(zipPath) {
@@ -723,8 +941,14 @@ func (rm *RestoreManager) executeOptimizedRestorationPath(path []RestorationStep
 	// Start with the base file from simplified storage hierarchy
 	baseStep := path[0]
 
+	// Resume from the last completed step of an interrupted resumable restore
+	if step, checkpoint := rm.replayCheckpoint(path); checkpoint != "" {
+		rm.printf("   Resuming delta replay after step %d of %d\n", step+1, len(path))
+		return rm.replaySteps(path, step+1, checkpoint)
+	}
+
 	// Create working file based on base type
-	tempFile := filepath.Join(rm.ObjectsDir, fmt.Sprintf("temp_restore_%d.zip", time.Now().UnixNano()))
+	tempFile := filepath.Join(rm.scratchDir(), fmt.Sprintf("temp_restore_%d.zip", time.Now().UnixNano()))
 
 	switch baseStep.Type {
 	case "lz4":
@@ -757,15 +981,21 @@ func (rm *RestoreManager) executeOptimizedRestorationPath(path []RestorationStep
 	default:
 		return "", fmt.Errorf("unsupported base file type: %s", baseStep.Type)
 	}
+	rm.checkpointReplay(0, baseStep.Version, tempFile)
 
-	for i := 1; i < len(path); i++ {
+	return rm.replaySteps(path, 1, tempFile)
+}
+
+// replaySteps applies path[start:] to tempFile, the result of the steps before start
+func (rm *RestoreManager) replaySteps(path []RestorationStep, start int, tempFile string) (string, error) {
+	for i := start; i < len(path); i++ {
 		step := path[i]
-		nextTempFile := filepath.Join(rm.ObjectsDir, fmt.Sprintf("temp_restore_%d_%d.zip", time.Now().UnixNano(), i))
+		nextTempFile := filepath.Join(rm.scratchDir(), fmt.Sprintf("temp_restore_%d_%d.zip", time.Now().UnixNano(), i))
 
 		switch step.Type {
 		case "bsdiff":
 			if err := rm.applyBsdiffPatch(tempFile, step.File, nextTempFile); err != nil {
-				os.Remove(tempFile)
+				rm.discardScratch(tempFile)
 				return "", &RestoreError{
 					Operation: "bsdiff patch application",
 					Version:   step.Version,
@@ -775,7 +1005,7 @@ func (rm *RestoreManager) executeOptimizedRestorationPath(path []RestorationStep
 			}
 		case "smart_delta":
 			if err := rm.applySmartDelta(tempFile, step.File, nextTempFile); err != nil {
-				os.Remove(tempFile)
+				rm.discardScratch(tempFile)
 				return "", &RestoreError{
 					Operation: "smart delta application",
 					Version:   step.Version,
@@ -784,14 +1014,15 @@ func (rm *RestoreManager) executeOptimizedRestorationPath(path []RestorationStep
 				}
 			}
 		case "xdelta3":
-			os.Remove(tempFile)
+			rm.discardScratch(tempFile)
 			return "", fmt.Errorf("xdelta3 restoration not yet implemented")
 		default:
-			os.Remove(tempFile)
+			rm.discardScratch(tempFile)
 			return "", fmt.Errorf("unknown restoration step type: %s", step.Type)
 		}
 
-		// Clean up previous temp file and use new one
+		// Record the new result before dropping the previous one
+		rm.checkpointReplay(i, step.Version, nextTempFile)
 		os.Remove(tempFile)
 		tempFile = nextTempFile
 	}
@@ -913,6 +1144,18 @@ func (rm *RestoreManager) applySmartDelta(baseFile, deltaFile, newFile string) e
 	if err != nil {
 		return fmt.Errorf("failed to read delta file: %w", err)
 	}
+	if deltaData, err = objfmt.Strip(deltaData, deltaFile); err != nil {
+		return err
+	}
+
+	// bsdiff deltas share the .psd_smart name: per-file deltas, and older ones patching
+	// the whole version archive
+	if filedelta.IsPayload(deltaData) {
+		return filedelta.Apply(baseFile, deltaData, newFile)
+	}
+	if bytes.HasPrefix(deltaData, []byte(bsdiffMagic)) {
+		return rm.applyBsdiffPatch(baseFile, deltaFile, newFile)
+	}
 
 	// Parse delta file to check format
 	content := string(deltaData)
@@ -967,7 +1210,7 @@ func (rm *RestoreManager) applySmartDelta(baseFile, deltaFile, newFile string) e
 		if json.Unmarshal(metadataBytes, &metadata) == nil {
 			if layerAnalysis, ok := metadata["layer_analysis"].(map[string]interface{}); ok {
 				if summary, ok := layerAnalysis["changes_summary"].(string); ok {
-					fmt.Printf("Applied smart delta: %s\n", summary)
+					rm.printf("Applied smart delta: %s\n", summary)
 				}
 			}
 		}
@@ -992,49 +1235,52 @@ func (rm *RestoreManager) calculateSpeedImprovement(method string, duration time
 // displayRestoreResults shows restoration results
 func (rm *RestoreManager) displayRestoreResults(result *RestoreResult, commitRef string, version int) {
 	if len(result.RestoredFiles) > 0 {
-		fmt.Printf("\nRestoration completed in %.3f seconds\n",
+		rm.printf("\nRestoration completed in %.3f seconds\n",
 			result.RestorationTime.Seconds())
 
 		// Show method-specific information
 		switch result.RestoreMethod {
 		case "snapshots":
-			fmt.Printf("Snapshots directory restoration - %.1fx faster than traditional!\n", result.SpeedImprovement)
-			fmt.Printf("Data transferred: %.2f KB from snapshots storage\n", float64(result.DataTransferred)/1024)
+			rm.printf("Snapshots directory restoration - %.1fx faster than traditional!\n", result.SpeedImprovement)
+			rm.printf("Data transferred: %.2f KB from snapshots storage\n", float64(result.DataTransferred)/1024)
 		case "cache":
-			fmt.Printf("Cache directory restoration - %.1fx faster than traditional!\n", result.SpeedImprovement)
-			fmt.Printf("Data transferred: %.2f KB from cache storage\n", float64(result.DataTransferred)/1024)
+			rm.printf("Cache directory restoration - %.1fx faster than traditional!\n", result.SpeedImprovement)
+			rm.printf("Data transferred: %.2f KB from cache storage\n", float64(result.DataTransferred)/1024)
+		case "archive":
+			rm.printf("Archive restoration completed\n")
+			rm.printf("Data transferred: %.2f KB from archive storage\n", float64(result.DataTransferred)/1024)
 		case "smart_delta":
-			fmt.Printf("Smart delta restoration - intelligent reconstruction!\n")
+			rm.printf("Smart delta restoration - intelligent reconstruction!\n")
 		case "delta_chain":
-			fmt.Printf("Optimized delta chain restoration completed\n")
+			rm.printf("Optimized delta chain restoration completed\n")
 		case "zip":
-			fmt.Printf("ZIP extraction completed\n")
+			rm.printf("ZIP extraction completed\n")
 		}
 
-		fmt.Printf("Successfully restored %d files\n", len(result.RestoredFiles))
+		rm.printf("Successfully restored %d files\n", len(result.RestoredFiles))
 
 		// List restored files with visual file type indicators
 		for _, file := range result.RestoredFiles {
 			fileType := rm.getFileTypeIndicator(file)
-			fmt.Printf("  %s %s\n", fileType, file)
+			rm.printf("  %s %s\n", fileType, file)
 		}
 	}
 
 	// Show any restoration errors encountered
 	if len(result.ErrorFiles) > 0 {
-		fmt.Printf("\n%d files failed to restore:\n", len(result.ErrorFiles))
+		rm.printf("\n%d files failed to restore:\n", len(result.ErrorFiles))
 		for file, err := range result.ErrorFiles {
-			fmt.Printf("   %s: %v\n", file, err)
+			rm.printf("   %s: %v\n", file, err)
 		}
 	}
 
 	// Handle case where no files matched criteria
 	if len(result.RestoredFiles) == 0 && len(result.ErrorFiles) == 0 {
-		fmt.Println("No files found matching the specified criteria.")
+		rm.println("No files found matching the specified criteria.")
 	}
 
-	fmt.Printf("\nRestoration from commit %s (v%d) completed!\n", commitRef, version)
-	fmt.Printf("Cache performance: %s cache hit\n", result.CacheHitLevel)
+	rm.printf("\nRestoration from commit %s (v%d) completed!\n", commitRef, version)
+	rm.printf("Cache performance: %s cache hit\n", result.CacheHitLevel)
 }
 
 // RestorationStep represents a single step in restoration process
@@ -1156,7 +1402,7 @@ func (rm *RestoreManager) extractFilesFromZip(zipPath string, filesToRestore []s
 	defer r.Close()
 
 	// Get current working directory
-	currentWorkDir, err := os.Getwd()
+	currentWorkDir, err := rm.workDir()
 	if err != nil {
 		return result, fmt.Errorf("failed to get current working directory: %w", err)
 	}
@@ -1168,6 +1414,7 @@ func (rm *RestoreManager) extractFilesFromZip(zipPath string, filesToRestore []s
 	}
 
 	// Process each file in the ZIP archive
+	var jobs []iosched.Job
 	for _, f := range r.File {
 		// Normalize file path in ZIP
 		filePathInZip := strings.ReplaceAll(f.Name, "\\", "/")
@@ -1185,14 +1432,13 @@ func (rm *RestoreManager) extractFilesFromZip(zipPath string, filesToRestore []s
 			continue
 		}
 
-		// Restore the individual file
-		if err := rm.restoreFile(f, filePathInZip, currentWorkDir); err != nil {
-			result.ErrorFiles[filePathInZip] = err
-			continue
-		}
-
-		result.RestoredFiles = append(result.RestoredFiles, filePathInZip)
+		// Restore the individual file; entries of one archive can be read concurrently
+		f, name := f, filePathInZip
+		jobs = append(jobs, iosched.Job{Name: name, Size: int64(f.UncompressedSize64), Run: func() error {
+			return rm.restoreFile(f, name, currentWorkDir)
+		}})
 	}
+	rm.writeFiles(currentWorkDir, jobs, result)
 
 	result.TotalFilesCount = len(r.File)
 	return result, nil
@@ -1200,7 +1446,11 @@ func (rm *RestoreManager) extractFilesFromZip(zipPath string, filesToRestore []s
 
 // shouldRestoreFile determines if a file should be restored
 func (rm *RestoreManager) shouldRestoreFile(filePathInZip string, normalizedTargets []string) bool {
+	// Compare in NFC so targets typed on macOS (NFD) match manifests written elsewhere
+	filePathInZip = pathnorm.NFC(filePathInZip)
 	for _, target := range normalizedTargets {
+		target = pathnorm.NFC(target)
+
 		// Exact file path match
 		if filePathInZip == target {
 			return true
@@ -1257,6 +1507,9 @@ func (rm *RestoreManager) restoreFile(f *zip.File, filePathInZip, currentWorkDir
 	return nil
 }
 
+// bsdiffMagic opens every bsdiff patch
+const bsdiffMagic = "BSDIFF40"
+
 // applyBsdiffPatch applies a bsdiff patch
 func (rm *RestoreManager) applyBsdiffPatch(oldFile, patchFile, newFile string) error {
 	// Open old file
@@ -1280,29 +1533,39 @@ func (rm *RestoreManager) applyBsdiffPatch(oldFile, patchFile, newFile string) e
 	}
 	defer new.Close()
 
+	patchPayload, err := objfmt.NewReader(patch, patchFile)
+	if err != nil {
+		return err
+	}
+
 	// Apply binary patch
-	if err := bspatch.Reader(old, new, patch); err != nil {
+	if err := bspatch.Reader(old, new, patchPayload); err != nil {
 		return fmt.Errorf("bspatch failed: %w", err)
 	}
 
 	return nil
 }
 
-// createFileFromStructuredData creates a file from structured LZ4/Zstd data
-func (rm *RestoreManager) createFileFromStructuredData(filePath string, data []byte, targetFileName string) error {
-	// Parse structured data to find target file
-	content := string(data)
-	pos := 0
+// structuredEntry is one file in a structured LZ4/Zstd stream
+type structuredEntry struct {
+	name string
+	data []byte
+}
 
-	for pos < len(content) {
+// parseStructuredData splits a structured LZ4/Zstd stream ("FILE:path:size\n[data]") into
+// its files once, so each file can then be written independently
+func parseStructuredData(data []byte) []structuredEntry {
+	var entries []structuredEntry
+	pos := 0
+	for pos < len(data) {
 		// Find file header line
-		headerEnd := strings.Index(content[pos:], "\n")
+		headerEnd := bytes.IndexByte(data[pos:], '\n')
 		if headerEnd == -1 {
 			break
 		}
 		headerEnd += pos
 
-		headerLine := content[pos:headerEnd]
+		headerLine := string(data[pos:headerEnd])
 		if !strings.HasPrefix(headerLine, "FILE:") {
 			pos = headerEnd + 1
 			continue
@@ -1314,37 +1577,44 @@ func (rm *RestoreManager) createFileFromStructuredData(filePath string, data []b
 			pos = headerEnd + 1
 			continue
 		}
-
-		fileName := parts[1]
 		fileSize, err := strconv.ParseInt(parts[2], 10, 64)
 		if err != nil || fileSize <= 0 {
 			pos = headerEnd + 1
 			continue
 		}
 
-		// Check if this is our target file
-		if fileName == targetFileName || filepath.Base(fileName) == filepath.Base(targetFileName) {
-			// Extract file data
-			fileDataStart := headerEnd + 1
-			fileDataEnd := fileDataStart + int(fileSize)
-
-			if fileDataEnd > len(data) {
-				return fmt.Errorf("file data exceeds available data")
-			}
+		fileDataStart := headerEnd + 1
+		fileDataEnd := fileDataStart + int(fileSize)
+		if fileDataEnd > len(data) {
+			entries = append(entries, structuredEntry{name: parts[1]})
+			break
+		}
+		entries = append(entries, structuredEntry{name: parts[1], data: data[fileDataStart:fileDataEnd]})
 
-			fileData := data[fileDataStart:fileDataEnd]
+		// Skip to next file
+		pos = fileDataEnd
+	}
+	return entries
+}
 
-			// Create target directory if needed
-			if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-				return fmt.Errorf("failed to create directory: %w", err)
-			}
+// createFileFromStructuredData creates a file from a parsed structured LZ4/Zstd stream
+func (rm *RestoreManager) createFileFromStructuredData(filePath string, entries []structuredEntry, targetFileName string) error {
+	for _, entry := range entries {
+		// Check if this is our target file
+		if entry.name != targetFileName && filepath.Base(entry.name) != filepath.Base(targetFileName) {
+			continue
+		}
+		if entry.data == nil {
+			return fmt.Errorf("file data exceeds available data")
+		}
 
-			// Write file
-			return os.WriteFile(filePath, fileData, 0644)
+		// Create target directory if needed
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
 		}
 
-		// Skip to next file
-		pos = headerEnd + 1 + int(fileSize)
+		// Write file
+		return os.WriteFile(filePath, entry.data, 0644)
 	}
 
 	return fmt.Errorf("file not found in structured data: %s", targetFileName)
@@ -0,0 +1,92 @@
+package review
+
+import (
+	"encoding/base64"
+	"html/template"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// The HTML export is a single self-contained page: attachments are inlined as data URIs
+// so the file can be mailed or dropped into a chat without the repository.
+
+var htmlTemplate = template.Must(template.New("review").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Review {{.Bundle.ID}}: {{.Bundle.Message}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em auto; max-width: 960px; color: #222; }
+h1 { font-size: 1.4em; } h2 { font-size: 1.1em; margin-top: 2em; border-bottom: 1px solid #ddd; }
+.meta { color: #666; } .state { font-weight: bold; text-transform: uppercase; }
+table { border-collapse: collapse; width: 100%; } td, th { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eee; }
+figure { margin: 1em 0; } figure img { max-width: 100%; border: 1px solid #ccc; }
+figcaption { color: #555; font-size: 0.9em; }
+.comment { margin: 0.8em 0; padding: 0.6em 0.8em; background: #f6f6f6; border-radius: 4px; }
+.comment .who { color: #666; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>{{if .Bundle.Title}}{{.Bundle.Title}}{{else}}Review of v{{.Bundle.Version}}{{end}}</h1>
+<p class="meta">v{{.Bundle.Version}} ({{.Bundle.CommitHash}}) — {{.Bundle.Message}}<br>
+Opened by {{.Bundle.CreatedBy}} on {{.Bundle.CreatedAt.Format "2006-01-02 15:04"}} — <span class="state">{{.Bundle.State}}</span></p>
+
+<h2>Files</h2>
+<table>
+<tr><th>File</th><th>Properties</th><th>Changes</th></tr>
+{{range .Bundle.Files}}<tr>
+<td>{{.Path}}</td>
+<td>{{.Dimensions}} {{.ColorMode}}{{if .Layers}} · {{.Layers}} layers{{end}}</td>
+<td>{{if .PreviousVersion}}since v{{.PreviousVersion}}: {{range $i, $c := .Changes}}{{if $i}}, {{end}}{{$c}}{{else}}no visible property changes{{end}}{{else}}new file{{end}}</td>
+</tr>{{end}}
+</table>
+
+{{if .Images}}<h2>Screenshots</h2>
+{{range .Images}}<figure>
+<img src="{{.Data}}" alt="{{.Attachment.Name}}">
+<figcaption>{{if .Attachment.File}}{{.Attachment.File}} — {{end}}{{.Attachment.Caption}} <span class="who">({{.Attachment.AddedBy}})</span></figcaption>
+</figure>
+{{end}}{{end}}
+
+<h2>Comments</h2>
+{{range .Bundle.Comments}}<div class="comment">
+<div class="who">{{.Author}} · {{.CreatedAt.Format "2006-01-02 15:04"}}{{if .File}} · {{.File}}{{end}}{{if .Attachment}} · see {{.Attachment}}{{end}}</div>
+{{.Text}}
+</div>
+{{else}}<p class="meta">No comments.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// inlineImage is an attachment ready for embedding
+type inlineImage struct {
+	Attachment *Attachment
+	Data       template.URL
+}
+
+// ExportHTML writes the bundle as a standalone HTML page
+func (rm *ReviewManager) ExportHTML(bundle *Bundle, w io.Writer) error {
+	var images []inlineImage
+	for _, attachment := range bundle.Attachments {
+		data, err := os.ReadFile(rm.AttachmentPath(bundle, attachment))
+		if err != nil {
+			continue
+		}
+		mimeType := mime.TypeByExtension(filepath.Ext(attachment.Name))
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		images = append(images, inlineImage{
+			Attachment: attachment,
+			Data:       template.URL("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)),
+		})
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Bundle *Bundle
+		Images []inlineImage
+	}{bundle, images})
+}
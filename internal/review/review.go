@@ -0,0 +1,296 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/log"
+)
+
+// A review bundle collects what reviewers looked at and said about one version: the files
+// in the commit with their visible changes since the previous version of each file,
+// attached screenshots, and comments. Bundles live in .dgit/reviews/<id>/ (bundle.json plus
+// an attachments/ directory) next to the commit they describe.
+
+// Review states
+const (
+	StateOpen             = "open"
+	StateApproved         = "approved"
+	StateChangesRequested = "changes-requested"
+)
+
+// FileChange summarizes one committed file for reviewers
+type FileChange struct {
+	Path            string   `json:"path"`
+	PreviousVersion int      `json:"previous_version,omitempty"` // 0 when the file is new
+	Dimensions      string   `json:"dimensions,omitempty"`
+	ColorMode       string   `json:"color_mode,omitempty"`
+	Layers          int      `json:"layers,omitempty"`
+	Changes         []string `json:"changes,omitempty"` // e.g. "Layers: 12→15"
+}
+
+// Attachment is an image stored in the bundle (annotated screenshot, thumbnail)
+type Attachment struct {
+	Name     string    `json:"name"` // File name inside attachments/
+	File     string    `json:"file,omitempty"`
+	Caption  string    `json:"caption,omitempty"`
+	AddedBy  string    `json:"added_by"`
+	AddedAt  time.Time `json:"added_at"`
+	Original string    `json:"original"` // Path it was attached from
+}
+
+// Comment is one reviewer remark, optionally about a specific file
+type Comment struct {
+	Author     string    `json:"author"`
+	Text       string    `json:"text"`
+	File       string    `json:"file,omitempty"`
+	Attachment string    `json:"attachment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Bundle is a review of one version
+type Bundle struct {
+	ID          string        `json:"id"`
+	Version     int           `json:"version"`
+	CommitHash  string        `json:"commit_hash"`
+	Message     string        `json:"message"`
+	Title       string        `json:"title,omitempty"`
+	State       string        `json:"state"`
+	CreatedBy   string        `json:"created_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Files       []FileChange  `json:"files"`
+	Attachments []*Attachment `json:"attachments,omitempty"`
+	Comments    []*Comment    `json:"comments,omitempty"`
+}
+
+// ReviewManager creates and updates review bundles
+type ReviewManager struct {
+	DgitDir    string
+	ReviewsDir string
+}
+
+// NewReviewManager creates a new review manager
+func NewReviewManager(dgitDir string) *ReviewManager {
+	return &ReviewManager{
+		DgitDir:    dgitDir,
+		ReviewsDir: filepath.Join(dgitDir, "reviews"),
+	}
+}
+
+// Create starts a review of the version named by ref ("12" or "v12")
+func (rm *ReviewManager) Create(ref, title, author string) (*Bundle, error) {
+	version, err := strconv.Atoi(strings.TrimPrefix(ref, "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version '%s'", ref)
+	}
+
+	logManager := log.NewLogManager(rm.DgitDir)
+	commit, err := logManager.GetCommit(version)
+	if err != nil {
+		return nil, fmt.Errorf("version v%d not found", version)
+	}
+	history, err := logManager.GetCommitHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{
+		ID:         rm.uniqueID(fmt.Sprintf("v%d", version)),
+		Version:    version,
+		CommitHash: commit.Hash,
+		Message:    commit.Message,
+		Title:      title,
+		State:      StateOpen,
+		CreatedBy:  author,
+		CreatedAt:  time.Now(),
+	}
+	for path, raw := range commit.Metadata {
+		bundle.Files = append(bundle.Files, describeFile(path, raw, commit, history))
+	}
+	sort.Slice(bundle.Files, func(i, j int) bool { return bundle.Files[i].Path < bundle.Files[j].Path })
+
+	if err := os.MkdirAll(rm.attachmentsDir(bundle.ID), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create review bundle: %w", err)
+	}
+	if err := rm.Save(bundle); err != nil {
+		os.RemoveAll(rm.bundleDir(bundle.ID))
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// Load reads a bundle by ID
+func (rm *ReviewManager) Load(id string) (*Bundle, error) {
+	data, err := os.ReadFile(filepath.Join(rm.bundleDir(id), "bundle.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no review '%s'", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse review '%s': %w", id, err)
+	}
+	return &bundle, nil
+}
+
+// List returns all bundles, newest version first
+func (rm *ReviewManager) List() ([]*Bundle, error) {
+	entries, err := os.ReadDir(rm.ReviewsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles []*Bundle
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if bundle, err := rm.Load(entry.Name()); err == nil {
+			bundles = append(bundles, bundle)
+		}
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		if bundles[i].Version != bundles[j].Version {
+			return bundles[i].Version > bundles[j].Version
+		}
+		return bundles[i].CreatedAt.After(bundles[j].CreatedAt)
+	})
+	return bundles, nil
+}
+
+// Save writes bundle.json
+func (rm *ReviewManager) Save(bundle *Bundle) error {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(rm.bundleDir(bundle.ID), "bundle.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to save review: %w", err)
+	}
+	return nil
+}
+
+// Attach copies an image into the bundle and records it
+func (rm *ReviewManager) Attach(bundle *Bundle, source, file, caption, author string) (*Attachment, error) {
+	name := fmt.Sprintf("%02d-%s", len(bundle.Attachments)+1, filepath.Base(source))
+	if err := copyFile(source, filepath.Join(rm.attachmentsDir(bundle.ID), name)); err != nil {
+		return nil, fmt.Errorf("failed to attach %s: %w", source, err)
+	}
+
+	attachment := &Attachment{
+		Name:     name,
+		File:     file,
+		Caption:  caption,
+		AddedBy:  author,
+		AddedAt:  time.Now(),
+		Original: source,
+	}
+	bundle.Attachments = append(bundle.Attachments, attachment)
+	return attachment, nil
+}
+
+// AttachmentPath returns where an attachment is stored on disk
+func (rm *ReviewManager) AttachmentPath(bundle *Bundle, attachment *Attachment) string {
+	return filepath.Join(rm.attachmentsDir(bundle.ID), attachment.Name)
+}
+
+// describeFile summarizes a committed file against the previous version that stored it
+func describeFile(path string, raw interface{}, commit *log.Commit, history []*log.Commit) FileChange {
+	change := FileChange{Path: path}
+	meta, _ := raw.(map[string]interface{})
+	change.Dimensions, _ = meta["dimensions"].(string)
+	change.ColorMode, _ = meta["color_mode"].(string)
+	layers, _ := meta["layers"].(float64)
+	change.Layers = int(layers)
+
+	// History is newest first; find the closest older commit holding this path
+	lookup := path
+	if oldPath, ok := commit.Renamed[path]; ok {
+		lookup = oldPath
+		change.Changes = append(change.Changes, fmt.Sprintf("Renamed from %s", oldPath))
+	}
+	for _, older := range history {
+		if older.Version >= commit.Version {
+			continue
+		}
+		previous, ok := older.Metadata[lookup].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		change.PreviousVersion = older.Version
+		change.Changes = append(change.Changes, compareMetadata(previous, meta)...)
+		break
+	}
+	return change
+}
+
+// compareMetadata lists visible property changes between two metadata maps
+func compareMetadata(old, current map[string]interface{}) []string {
+	var changes []string
+	for _, field := range []struct{ key, label string }{
+		{"layers", "Layers"},
+		{"artboards", "Artboards"},
+		{"dimensions", "Dimensions"},
+		{"color_mode", "ColorMode"},
+	} {
+		before, after := fmt.Sprint(old[field.key]), fmt.Sprint(current[field.key])
+		if before != after && after != "<nil>" && after != "Unknown" {
+			changes = append(changes, fmt.Sprintf("%s: %s→%s", field.label, before, after))
+		}
+	}
+	if old["hash"] != nil && old["hash"] == current["hash"] {
+		changes = append(changes, "Content unchanged")
+	}
+	return changes
+}
+
+func (rm *ReviewManager) bundleDir(id string) string {
+	return filepath.Join(rm.ReviewsDir, id)
+}
+
+func (rm *ReviewManager) attachmentsDir(id string) string {
+	return filepath.Join(rm.bundleDir(id), "attachments")
+}
+
+// uniqueID names a bundle after its version, numbering repeat reviews (v12, v12-2, ...)
+func (rm *ReviewManager) uniqueID(base string) string {
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(rm.bundleDir(id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// copyFile copies src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
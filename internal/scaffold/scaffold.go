@@ -0,0 +1,128 @@
+package scaffold
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"dgit/internal/log"
+	"dgit/internal/progress"
+	"dgit/internal/restore"
+	"dgit/internal/staging"
+)
+
+// New deliverables are copied from the committed content of a tracked template, never
+// from its working copy, so the recorded template version is exactly what was copied.
+
+// ScaffoldManager creates new files from tracked templates
+type ScaffoldManager struct {
+	DgitDir string
+	TempDir string
+}
+
+// NewScaffoldManager creates a new scaffold manager
+func NewScaffoldManager(dgitDir string) *ScaffoldManager {
+	return &ScaffoldManager{
+		DgitDir: dgitDir,
+		TempDir: filepath.Join(dgitDir, "temp"),
+	}
+}
+
+// Create copies templatePath as committed in ref ("v3", "3"; empty for the latest version
+// containing it) to destination and returns the provenance to record with the new file
+func (sm *ScaffoldManager) Create(templatePath, ref, destination string) (*staging.TemplateOrigin, error) {
+	if _, err := os.Stat(destination); err == nil {
+		return nil, fmt.Errorf("%s already exists", destination)
+	}
+
+	commit, err := sm.resolve(templatePath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(sm.TempDir, 0755); err != nil {
+		return nil, err
+	}
+	checkoutDir, err := os.MkdirTemp(sm.TempDir, "template-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(checkoutDir)
+
+	restoreManager := restore.NewRestoreManager(sm.DgitDir)
+	restoreManager.WorkDir = checkoutDir
+	restoreManager.Progress = func(progress.Event) {}
+	if _, err := restoreManager.Restore(fmt.Sprintf("v%d", commit.Version), []string{templatePath}); err != nil {
+		return nil, fmt.Errorf("failed to read template %s from v%d: %w", templatePath, commit.Version, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return nil, err
+	}
+	if err := copyFile(filepath.Join(checkoutDir, templatePath), destination); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", destination, err)
+	}
+
+	origin := &staging.TemplateOrigin{
+		Path:       templatePath,
+		Version:    commit.Version,
+		CommitHash: commit.Hash,
+	}
+	if fileMeta, ok := commit.Metadata[templatePath].(map[string]interface{}); ok {
+		origin.Hash, _ = fileMeta["hash"].(string)
+	}
+	return origin, nil
+}
+
+// resolve finds the commit holding the requested version of a template
+func (sm *ScaffoldManager) resolve(templatePath, ref string) (*log.Commit, error) {
+	history, err := log.NewLogManager(sm.DgitDir).FileHistory(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if ref != "" {
+		version, err = strconv.Atoi(strings.TrimPrefix(ref, "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid template version '%s'", ref)
+		}
+	}
+
+	for _, commit := range history {
+		if _, stored := commit.Metadata[templatePath]; !stored {
+			continue
+		}
+		if version == 0 || commit.Version == version {
+			return commit, nil
+		}
+	}
+
+	if version != 0 {
+		return nil, fmt.Errorf("template %s was not committed in v%d", templatePath, version)
+	}
+	return nil, fmt.Errorf("template %s is not tracked; commit it before using it as a template", templatePath)
+}
+
+// copyFile copies src to a new file at dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -105,7 +105,7 @@ func (ds *DetailedScanner) analyzePSD(filePath string, result *DetailedFileInfo)
 	}
 
 	result.Dimensions = fmt.Sprintf("%dx%d px", psdInfo.Width, psdInfo.Height)
-	result.ColorMode = mapPSDColorMode(psdInfo.Channels, psdInfo.Bits)
+	result.ColorMode = photoshop.ColorModeName(psdInfo.ColorMode, psdInfo.Channels)
 	result.Version = "Adobe Photoshop"
 	result.Layers = psdInfo.LayerCount
 	result.LayerNames = psdInfo.LayerNames
@@ -140,17 +140,3 @@ func (ds *DetailedScanner) analyzeXD(filePath string, result *DetailedFileInfo)
 	result.LayerNames = []string{"XD Artboard"}
 	return result, nil
 }
-
-// mapPSDColorMode maps PSD channel information to readable color mode names
-func mapPSDColorMode(channels, bits int) string {
-	switch channels {
-	case 1:
-		return "Grayscale"
-	case 3:
-		return "RGB"
-	case 4:
-		return "CMYK"
-	default:
-		return fmt.Sprintf("RGB (%d channels)", channels)
-	}
-}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 	"unicode/utf16"
 )
@@ -17,8 +18,21 @@ type PSDInfo struct {
 	Height     int      // Document height in pixels
 	Channels   int      // Number of color channels
 	Bits       int      // Bit depth per channel
+	ColorMode  int      // Header color mode (Grayscale=1, RGB=3, CMYK=4, ...)
+	Resolution int      // Horizontal resolution in DPI (0 when not recorded)
 	LayerCount int      // Total number of layers in document
 	LayerNames []string // Names of all layers in the document
+	Structure  LayerStructure
+
+	CreatorTool string // Application that last saved the document, from XMP (empty when absent)
+	ICCProfile  string // Embedded color profile name (empty when none is embedded)
+}
+
+// LayerStructure summarizes how complex a document's layer tree is
+type LayerStructure struct {
+	Groups       int `json:"groups"`        // Layer groups (folders)
+	MaxDepth     int `json:"max_depth"`     // Deepest group nesting, 0 when there are no groups
+	SmartObjects int `json:"smart_objects"` // Embedded or linked smart object layers
 }
 
 // psdFileHeader represents the core PSD file header structure
@@ -55,14 +69,17 @@ type DetailedPSDInfo struct {
 // DetailedLayer contains comprehensive information about individual layers
 // Provides detailed analysis of layer properties, position, and content
 type DetailedLayer struct {
-	ID          int      `json:"id"`           // Unique layer identifier
-	Name        string   `json:"name"`         // Layer name as set by user
-	Position    [4]int32 `json:"position"`     // Layer bounds: top, left, bottom, right
-	BlendMode   string   `json:"blend_mode"`   // Layer blending mode
-	Opacity     uint8    `json:"opacity"`      // Layer opacity (0-255)
-	Visible     bool     `json:"visible"`      // Layer visibility state
-	ContentHash string   `json:"content_hash"` // Hash of layer content for change detection
-	LayerType   string   `json:"layer_type"`   // Layer type: "normal", "text", "adjustment", etc.
+	ID          int      `json:"id"`              // Unique layer identifier
+	Name        string   `json:"name"`            // Layer name as set by user
+	Position    [4]int32 `json:"position"`        // Layer bounds: top, left, bottom, right
+	BlendMode   string   `json:"blend_mode"`      // Layer blending mode
+	Opacity     uint8    `json:"opacity"`         // Layer opacity (0-255)
+	Visible     bool     `json:"visible"`         // Layer visibility state
+	ContentHash string   `json:"content_hash"`    // Hash of layer content for change detection
+	LayerType   string   `json:"layer_type"`      // Layer type: "normal", "text", "group", "group_end", etc.
+	Group       string   `json:"group,omitempty"` // Enclosing groups, outermost first, joined by "/"
+
+	dataLength int64 // Bytes of channel image data, from the channel info
 }
 
 // CanvasInfo contains document-level canvas information
@@ -110,13 +127,18 @@ func GetPSDInfo(filePath string) (*PSDInfo, error) {
 		return nil, fmt.Errorf("failed to skip color mode data: %w", err)
 	}
 
-	// Step 3: Skip image resources section (contains metadata we don't need)
+	// Step 3: Read resolution, creator and color profile from the image resources section
 	var imageResourcesLength uint32
 	err = binary.Read(file, binary.BigEndian, &imageResourcesLength)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read image resources length: %w", err)
 	}
-	_, err = file.Seek(int64(imageResourcesLength), io.SeekCurrent)
+	resourcesStart, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate image resources: %w", err)
+	}
+	resources := parseImageResources(file, int64(imageResourcesLength))
+	_, err = file.Seek(resourcesStart+int64(imageResourcesLength), io.SeekStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to skip image resources: %w", err)
 	}
@@ -135,8 +157,13 @@ func GetPSDInfo(filePath string) (*PSDInfo, error) {
 			Height:     int(header.Height),
 			Channels:   int(header.Channels),
 			Bits:       int(header.Depth),
+			ColorMode:  int(header.ColorMode),
+			Resolution: resources.Resolution,
 			LayerCount: 0,
 			LayerNames: []string{},
+
+			CreatorTool: resources.CreatorTool,
+			ICCProfile:  resources.ICCProfile,
 		}, nil
 	}
 
@@ -161,6 +188,7 @@ func GetPSDInfo(filePath string) (*PSDInfo, error) {
 	}
 
 	// Extract actual layer names from layer records
+	recordsStart, _ := file.Seek(0, io.SeekCurrent)
 	layerNames, parseErr := parseLayerNames(file, layerCount)
 	if parseErr != nil {
 		// If layer name parsing fails, generate default names
@@ -171,17 +199,298 @@ func GetPSDInfo(filePath string) (*PSDInfo, error) {
 		}
 	}
 
+	// Walk the records again for groups and smart objects; a damaged tree leaves zeros
+	var structure LayerStructure
+	if _, err := file.Seek(recordsStart, io.SeekStart); err == nil {
+		structure, _ = parseLayerStructure(file, layerCount, header.Version == 2)
+	}
+
 	// Return comprehensive PSD information
 	return &PSDInfo{
 		Width:      int(header.Width),
 		Height:     int(header.Height),
 		Channels:   int(header.Channels),
 		Bits:       int(header.Depth),
+		ColorMode:  int(header.ColorMode),
+		Resolution: resources.Resolution,
 		LayerCount: layerCount,
 		LayerNames: layerNames,
+		Structure:  structure,
+
+		CreatorTool: resources.CreatorTool,
+		ICCProfile:  resources.ICCProfile,
 	}, nil
 }
 
+// parseLayerStructure reads the section-divider and smart-object blocks of each layer record
+// Records run bottom to top, so a group's end marker precedes its folder record
+func parseLayerStructure(file *os.File, layerCount int, psb bool) (LayerStructure, error) {
+	var structure LayerStructure
+	channelInfoSize := int64(6)
+	if psb {
+		channelInfoSize = 10
+	}
+
+	depth := 0
+	for i := 0; i < layerCount; i++ {
+		var layerRec layerRecord
+		if err := binary.Read(file, binary.BigEndian, &layerRec); err != nil {
+			return structure, fmt.Errorf("failed to read layer record %d: %w", i, err)
+		}
+		// Skip channel info, blend mode signature/key and flags
+		if _, err := file.Seek(int64(layerRec.Channels)*channelInfoSize+12, io.SeekCurrent); err != nil {
+			return structure, err
+		}
+		var extraDataLength uint32
+		if err := binary.Read(file, binary.BigEndian, &extraDataLength); err != nil {
+			return structure, err
+		}
+		extraStart, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return structure, err
+		}
+		extraEnd := extraStart + int64(extraDataLength)
+
+		keys, err := layerInfoKeys(file, extraEnd)
+		if err != nil {
+			return structure, fmt.Errorf("failed to read layer %d: %w", i, err)
+		}
+		switch keys["lsct"] {
+		case 3: // Bounding section divider: the bottom of a group
+			depth++
+			structure.MaxDepth = max(structure.MaxDepth, depth)
+		case 1, 2: // Open or closed folder: the group itself
+			structure.Groups++
+			if depth > 0 {
+				depth--
+			}
+		}
+		if _, ok := keys["SoLd"]; ok {
+			structure.SmartObjects++
+		} else if _, ok := keys["PlLd"]; ok {
+			structure.SmartObjects++
+		}
+
+		if _, err := file.Seek(extraEnd, io.SeekStart); err != nil {
+			return structure, err
+		}
+	}
+	return structure, nil
+}
+
+// layerInfoKeys lists the additional layer information keys in a layer's extra data
+// For section dividers ("lsct") the value is the divider type; other keys map to 0
+func layerInfoKeys(file *os.File, extraEnd int64) (map[string]uint32, error) {
+	// Skip layer mask data and blending ranges
+	for i := 0; i < 2; i++ {
+		var length uint32
+		if err := binary.Read(file, binary.BigEndian, &length); err != nil {
+			return nil, err
+		}
+		if _, err := file.Seek(int64(length), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+	// Skip the Pascal name, padded to a multiple of 4 bytes
+	var nameLength byte
+	if err := binary.Read(file, binary.BigEndian, &nameLength); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(int64(nameLength)+int64((4-(1+int(nameLength))%4)%4), io.SeekCurrent); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]uint32)
+	for {
+		pos, err := file.Seek(0, io.SeekCurrent)
+		if err != nil || pos+12 > extraEnd {
+			return keys, nil
+		}
+		var block struct {
+			Signature [4]byte
+			Key       [4]byte
+			Length    uint32
+		}
+		if err := binary.Read(file, binary.BigEndian, &block); err != nil {
+			return keys, nil
+		}
+		if sig := string(block.Signature[:]); sig != "8BIM" && sig != "8B64" {
+			return keys, nil
+		}
+
+		key := string(block.Key[:])
+		keys[key] = 0
+		if key == "lsct" && block.Length >= 4 {
+			var dividerType uint32
+			if err := binary.Read(file, binary.BigEndian, &dividerType); err != nil {
+				return keys, nil
+			}
+			keys[key] = dividerType
+		}
+
+		next := pos + 12 + int64(block.Length) + int64(block.Length%2)
+		if _, err := file.Seek(next, io.SeekStart); err != nil {
+			return keys, nil
+		}
+	}
+}
+
+// Image resource IDs read by the scanner
+const (
+	resolutionInfoResource = 1005 // ResolutionInfo
+	iccProfileResource     = 1039 // Embedded ICC profile
+	xmpMetadataResource    = 1060 // XMP packet
+)
+
+// imageResources holds the image resource values the scanner reports
+type imageResources struct {
+	Resolution  int    // Horizontal resolution in DPI
+	CreatorTool string // XMP xmp:CreatorTool, e.g. "Adobe Photoshop 25.4 (Macintosh)"
+	ICCProfile  string // Description of the embedded ICC profile
+}
+
+// xmpCreatorToolPattern matches CreatorTool written as an element or an attribute
+var xmpCreatorToolPattern = regexp.MustCompile(`xmp:CreatorTool(?:>([^<]+)</xmp:CreatorTool>|="([^"]+)")`)
+
+// parseImageResources walks image resource blocks for ResolutionInfo, the XMP packet and
+// the ICC profile; values the document does not record are left empty
+func parseImageResources(file *os.File, length int64) imageResources {
+	var resources imageResources
+	section := io.LimitReader(file, length)
+	for {
+		var block struct {
+			Signature [4]byte
+			ID        uint16
+			NameLen   uint8
+		}
+		if err := binary.Read(section, binary.BigEndian, &block); err != nil || string(block.Signature[:]) != "8BIM" {
+			return resources
+		}
+		// Pascal name padded so that length byte + name is even
+		if _, err := io.CopyN(io.Discard, section, int64(block.NameLen)+int64(1-block.NameLen%2)); err != nil {
+			return resources
+		}
+		var size uint32
+		if err := binary.Read(section, binary.BigEndian, &size); err != nil {
+			return resources
+		}
+		// Data is padded to an even length; remaining is what is left to skip
+		remaining := int64(size) + int64(size%2)
+
+		switch {
+		case block.ID == resolutionInfoResource && size >= 8:
+			var info struct {
+				HRes      uint32 // Fixed-point 16.16
+				HResUnit  uint16 // 1 = pixels per inch, 2 = pixels per cm
+				WidthUnit uint16
+			}
+			if err := binary.Read(section, binary.BigEndian, &info); err != nil {
+				return resources
+			}
+			dpi := float64(info.HRes) / 65536
+			if info.HResUnit == 2 {
+				dpi *= 2.54
+			}
+			resources.Resolution = int(dpi + 0.5)
+			remaining -= 8
+		case block.ID == xmpMetadataResource || block.ID == iccProfileResource:
+			data := make([]byte, size)
+			if _, err := io.ReadFull(section, data); err != nil {
+				return resources
+			}
+			if block.ID == xmpMetadataResource {
+				if match := xmpCreatorToolPattern.FindSubmatch(data); match != nil {
+					resources.CreatorTool = strings.TrimSpace(string(match[1]) + string(match[2]))
+				}
+			} else {
+				resources.ICCProfile = iccDescription(data)
+			}
+			remaining -= int64(size)
+		}
+
+		if _, err := io.CopyN(io.Discard, section, remaining); err != nil {
+			return resources
+		}
+	}
+}
+
+// iccDescription returns the profile description ("desc" tag) of an ICC profile,
+// reading both the ICC v2 textDescriptionType and the v4 multiLocalizedUnicodeType
+func iccDescription(profile []byte) string {
+	if len(profile) < 132 {
+		return ""
+	}
+	count := binary.BigEndian.Uint32(profile[128:132])
+	for i := uint32(0); i < count && 132+12*(i+1) <= uint32(len(profile)); i++ {
+		entry := profile[132+12*i:]
+		if string(entry[:4]) != "desc" {
+			continue
+		}
+		offset, size := binary.BigEndian.Uint32(entry[4:8]), binary.BigEndian.Uint32(entry[8:12])
+		if uint64(offset)+uint64(size) > uint64(len(profile)) || size < 12 {
+			return ""
+		}
+		tag := profile[offset : offset+size]
+		switch string(tag[:4]) {
+		case "desc":
+			n := binary.BigEndian.Uint32(tag[8:12])
+			if uint64(n) > uint64(len(tag)-12) {
+				return ""
+			}
+			return strings.TrimRight(string(tag[12:12+n]), "\x00")
+		case "mluc":
+			if len(tag) < 28 {
+				return ""
+			}
+			n, start := binary.BigEndian.Uint32(tag[20:24]), binary.BigEndian.Uint32(tag[24:28])
+			if uint64(start)+uint64(n) > uint64(len(tag)) {
+				return ""
+			}
+			text := tag[start : start+n]
+			runes := make([]uint16, len(text)/2)
+			for j := range runes {
+				runes[j] = binary.BigEndian.Uint16(text[2*j:])
+			}
+			return strings.TrimRight(string(utf16.Decode(runes)), "\x00")
+		}
+		return ""
+	}
+	return ""
+}
+
+// ColorModeName returns a readable name for a PSD header color mode
+// Unknown modes fall back to a guess from the channel count
+func ColorModeName(mode, channels int) string {
+	switch mode {
+	case 0:
+		return "Bitmap"
+	case 1:
+		return "Grayscale"
+	case 2:
+		return "Indexed"
+	case 3:
+		return "RGB"
+	case 4:
+		return "CMYK"
+	case 7:
+		return "Multichannel"
+	case 8:
+		return "Duotone"
+	case 9:
+		return "Lab"
+	}
+	switch channels {
+	case 1:
+		return "Grayscale"
+	case 3:
+		return "RGB"
+	case 4:
+		return "CMYK"
+	default:
+		return fmt.Sprintf("RGB (%d channels)", channels)
+	}
+}
+
 // parseLayerNames extracts actual layer names from layer record structures
 // Handles complex PSD layer data format and Unicode name extraction
 func parseLayerNames(file *os.File, layerCount int) ([]string, error) {
@@ -443,10 +752,11 @@ func GetDetailedPSDInfo(filePath string) (*DetailedPSDInfo, error) {
 		PSDInfo: basicInfo,
 		Layers:  make([]DetailedLayer, 0, basicInfo.LayerCount),
 		CanvasInfo: CanvasInfo{
-			Width:     basicInfo.Width,
-			Height:    basicInfo.Height,
-			ColorMode: basicInfo.Channels,
-			BitDepth:  basicInfo.Bits,
+			Width:      basicInfo.Width,
+			Height:     basicInfo.Height,
+			ColorMode:  basicInfo.ColorMode,
+			BitDepth:   basicInfo.Bits,
+			Resolution: basicInfo.Resolution,
 		},
 	}
 
@@ -491,6 +801,7 @@ func parseDetailedLayers(file *os.File, layerCount int, filePath string) ([]Deta
 			// If individual layer parsing fails, create basic layer info
 			fmt.Printf("Warning: Failed to parse layer %d: %v\n", i, err)
 			layer = &DetailedLayer{
+				dataLength:  -1, // Records after this one can't be located
 				ID:          i,
 				Name:        fmt.Sprintf("Layer %d", i+1),
 				Position:    [4]int32{0, 0, 100, 100},
@@ -504,9 +815,64 @@ func parseDetailedLayers(file *os.File, layerCount int, filePath string) ([]Deta
 		layers = append(layers, *layer)
 	}
 
+	// Channel image data follows the records in the same order; hashing it gives each
+	// layer a hash that survives renames and moves
+	hashChannelData(file, layers)
+	assignGroups(layers)
+
 	return layers, nil
 }
 
+// assignGroups sets each layer's enclosing group path. Records run bottom to top, so
+// walking them backwards meets a group's folder record before its contents and its
+// closing divider after them.
+func assignGroups(layers []DetailedLayer) {
+	var stack []string
+	for i := len(layers) - 1; i >= 0; i-- {
+		switch layers[i].LayerType {
+		case "group":
+			layers[i].Group = strings.Join(stack, "/")
+			stack = append(stack, layers[i].Name)
+		case "group_end":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			layers[i].Group = strings.Join(stack, "/")
+		default:
+			layers[i].Group = strings.Join(stack, "/")
+		}
+	}
+}
+
+// HasPixels reports whether the layer has image data of its own, so its content hash
+// identifies it; groups, dividers and empty layers all share the hash of no content
+func (l DetailedLayer) HasPixels() bool {
+	return l.dataLength > 0 && l.Position[2] > l.Position[0] && l.Position[3] > l.Position[1] && !l.IsGroup()
+}
+
+// IsGroup reports whether the layer is a group folder
+func (l DetailedLayer) IsGroup() bool {
+	return l.LayerType == "group"
+}
+
+// hashChannelData replaces the layers' content hashes with hashes of their channel image
+// data, read from the current position; when a record could not be parsed the position
+// is unknown and every layer keeps its placeholder
+func hashChannelData(file *os.File, layers []DetailedLayer) {
+	for _, layer := range layers {
+		if layer.dataLength < 0 {
+			return
+		}
+	}
+	for i := range layers {
+		hasher := sha256.New()
+		if _, err := io.CopyN(hasher, file, layers[i].dataLength); err != nil {
+			return
+		}
+		layers[i].ContentHash = fmt.Sprintf("%x", hasher.Sum(nil))[:16]
+	}
+}
+
 // skipToLayerInfo navigates file pointer to the layer information section
 // Skips header, color mode data, and image resources to reach layer data
 func skipToLayerInfo(file *os.File) error {
@@ -567,11 +933,17 @@ func parseIndividualLayer(file *os.File, layerIndex int, filePath string) (*Deta
 		return nil, fmt.Errorf("failed to read layer record: %w", err)
 	}
 
-	// Skip channel information (6 bytes per channel)
-	channelInfoSize := int64(layerRec.Channels) * 6
-	_, err = file.Seek(channelInfoSize, io.SeekCurrent)
-	if err != nil {
-		return nil, fmt.Errorf("failed to skip channel info: %w", err)
+	// Channel information: ID and data length (6 bytes per channel)
+	var dataLength int64
+	for c := 0; c < int(layerRec.Channels); c++ {
+		var channel struct {
+			ID     int16
+			Length uint32
+		}
+		if err := binary.Read(file, binary.BigEndian, &channel); err != nil {
+			return nil, fmt.Errorf("failed to read channel info: %w", err)
+		}
+		dataLength += int64(channel.Length)
 	}
 
 	// Read blend mode signature
@@ -610,24 +982,35 @@ func parseIndividualLayer(file *os.File, layerIndex int, filePath string) (*Deta
 		return nil, fmt.Errorf("failed to read extra data length: %w", err)
 	}
 
-	// Extract layer name from extra data
+	// Extract layer name and section divider type from extra data
 	layerName := fmt.Sprintf("Layer %d", layerIndex+1)
+	var divider uint32
 	if extraDataLength > 0 {
 		startPos, _ := file.Seek(0, io.SeekCurrent)
+		extraEnd := startPos + int64(extraDataLength)
 		extractedName, nameErr := extractLayerNameFromExtraData(file, extraDataLength)
 		if nameErr == nil && extractedName != "" {
 			layerName = extractedName
-		} else {
-			// If name extraction fails, skip the extra data
-			file.Seek(startPos+int64(extraDataLength), io.SeekStart)
 		}
+		if _, err := file.Seek(startPos, io.SeekStart); err == nil {
+			if keys, err := layerInfoKeys(file, extraEnd); err == nil {
+				divider = keys["lsct"]
+			}
+		}
+		file.Seek(extraEnd, io.SeekStart)
 	}
 
-	// Generate content hash based on layer properties
+	// Placeholder until the channel image data after the records is hashed
 	contentHash := generateLayerContentHash(filePath, layerIndex, layerName)
 
 	// Determine layer type based on characteristics
 	layerType := determineLayerType(layerName, blendMode)
+	switch divider {
+	case 1, 2: // Open or closed folder
+		layerType = "group"
+	case 3: // Bounding section divider closing a group
+		layerType = "group_end"
+	}
 
 	return &DetailedLayer{
 		ID:          layerIndex,
@@ -638,6 +1021,7 @@ func parseIndividualLayer(file *os.File, layerIndex int, filePath string) (*Deta
 		Visible:     visible,
 		ContentHash: contentHash,
 		LayerType:   layerType,
+		dataLength:  dataLength,
 	}, nil
 }
 
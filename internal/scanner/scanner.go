@@ -14,17 +14,22 @@ import (
 
 // DesignFile contains metadata for detected design files
 type DesignFile struct {
-	Path       string   `json:"path"`        // Relative file path
-	FileName   string   `json:"file_name"`   // Base filename
-	Type       string   `json:"type"`        // File type: ai, psd, sketch, etc.
-	Dimensions string   `json:"dimensions"`  // Canvas size: "1920x1080"
-	ColorMode  string   `json:"color_mode"`  // Color space: RGB, CMYK, Grayscale
-	Version    string   `json:"version"`     // Application version: "CC 2025 (29.x)"
-	Layers     int      `json:"layers"`      // Number of layers in document
-	Artboards  int      `json:"artboards"`   // Number of artboards/pages
-	Objects    int      `json:"objects"`     // Estimated object count
-	LayerNames []string `json:"layer_names"` // Names of all layers
-	FileSize   int64    `json:"file_size"`   // File size in bytes
+	Path       string   `json:"path"`                  // Relative file path
+	FileName   string   `json:"file_name"`             // Base filename
+	Type       string   `json:"type"`                  // File type: ai, psd, sketch, etc.
+	Dimensions string   `json:"dimensions"`            // Canvas size: "1920x1080"
+	ColorMode  string   `json:"color_mode"`            // Color space: RGB, CMYK, Grayscale
+	Version    string   `json:"version"`               // Application version: "CC 2025 (29.x)"
+	Layers     int      `json:"layers"`                // Number of layers in document
+	Artboards  int      `json:"artboards"`             // Number of artboards/pages
+	Objects    int      `json:"objects"`               // Estimated object count
+	LayerNames []string `json:"layer_names"`           // Names of all layers
+	FileSize   int64    `json:"file_size"`             // File size in bytes
+	Resolution int      `json:"resolution,omitempty"`  // Document DPI, when the format records it
+	ICCProfile string   `json:"icc_profile,omitempty"` // Embedded color profile name
+
+	// Structure is the layer tree summary (groups, nesting, smart objects) for PSDs
+	Structure *photoshop.LayerStructure `json:"structure,omitempty"`
 
 	// Cache Integration
 	Hash       string        `json:"hash"`               // File hash for cache key generation
@@ -276,16 +281,22 @@ func (fs *FileScanner) analyzePSDFile(filePath string, designFile *DesignFile) (
 	}
 
 	designFile.Dimensions = fmt.Sprintf("%dx%d px", psdInfo.Width, psdInfo.Height)
-	designFile.ColorMode = fs.mapPSDColorMode(psdInfo.Channels, psdInfo.Bits)
-	designFile.Version = "CC 2025" // PSD version extraction is complex, use default
+	designFile.ColorMode = photoshop.ColorModeName(psdInfo.ColorMode, psdInfo.Channels)
+	designFile.Resolution = psdInfo.Resolution
+	designFile.Version = "CC 2025" // Documents without XMP don't say which release saved them
+	if psdInfo.CreatorTool != "" {
+		designFile.Version = psdInfo.CreatorTool
+	}
+	designFile.ICCProfile = psdInfo.ICCProfile
 	designFile.Layers = psdInfo.LayerCount
 	designFile.LayerNames = psdInfo.LayerNames
 	designFile.Objects = len(psdInfo.LayerNames) * 2 // Estimated object count
+	designFile.Structure = &psdInfo.Structure
 
 	designFile.Metadata = &FileMetadata{
 		Dimensions:  designFile.Dimensions,
 		ColorMode:   designFile.ColorMode,
-		Resolution:  72, // Default resolution assumption
+		Resolution:  designFile.Resolution,
 		LayerCount:  psdInfo.LayerCount,
 		FileVersion: designFile.Version,
 		ExtractedAt: time.Now(),
@@ -410,20 +421,6 @@ func (fs *FileScanner) updateScanStats(designFile *DesignFile, cacheStats *ScanC
 	}
 }
 
-// mapPSDColorMode maps PSD channel information to readable color mode names
-func (fs *FileScanner) mapPSDColorMode(channels, bits int) string {
-	switch channels {
-	case 1:
-		return "Grayscale"
-	case 3:
-		return "RGB"
-	case 4:
-		return "CMYK"
-	default:
-		return fmt.Sprintf("RGB (%d channels)", channels)
-	}
-}
-
 // IsDesignFile checks if a file is a supported design file format
 func IsDesignFile(filePath string) bool {
 	ext := strings.ToLower(filepath.Ext(filePath))
@@ -446,6 +443,40 @@ func IsDesignFile(filePath string) bool {
 	return supportedExts[ext]
 }
 
+// autosaveDirs are folders design apps write crash-recovery copies into
+var autosaveDirs = map[string]bool{
+	"psautorecover": true, // Photoshop
+	"datarecovery":  true, // Illustrator
+	"autorecover":   true,
+}
+
+// autosaveExts are the extensions design apps give temporary and recovery data;
+// "recovered" in any other file's name is the user's own naming
+var autosaveExts = map[string]bool{
+	".tmp": true, // Photoshop and Illustrator scratch files
+	".psb": true, // Photoshop recovery data
+}
+
+// IsAutosaveFile checks if a file is an application autosave or crash-recovery copy:
+// ~*.tmp, *recovered* with an autosave extension, or anything under PSAutoRecover/DataRecovery
+func IsAutosaveFile(filePath string) bool {
+	name := strings.ToLower(filepath.Base(filePath))
+	ext := filepath.Ext(name)
+	if strings.HasPrefix(name, "~") && ext == ".tmp" {
+		return true
+	}
+	if strings.Contains(name, "recovered") && autosaveExts[ext] {
+		return true
+	}
+
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(filePath)), "/") {
+		if autosaveDirs[strings.ToLower(dir)] {
+			return true
+		}
+	}
+	return false
+}
+
 // GetScanPerformanceReport generates performance analysis from scan results
 func (fs *FileScanner) GetScanPerformanceReport(result *ScanResult) *ScanPerformanceReport {
 	if result == nil || result.CacheStats == nil || result.MetadataStats == nil {
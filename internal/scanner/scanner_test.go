@@ -0,0 +1,31 @@
+package scanner
+
+import "testing"
+
+func TestIsAutosaveFile(t *testing.T) {
+	cases := []struct {
+		path string
+		want bool
+	}{
+		{"~ps5F2A.tmp", true},
+		{"client/~AI-0001.TMP", true},
+		{"poster (Recovered).tmp", true},
+		{"poster_Recovered_0001.psb", true},
+		{"PSAutoRecover/poster_0000000001.psb", true},
+		{"art/DataRecovery/logo.ai", true},
+		{"AutoRecover/banner.psd", true},
+
+		{"~draft.psd", false},
+		{"recovered-logo.ai", false},
+		{"poster (Recovered).psd", false},
+		{"notes.tmp", false},
+		{"logo.ai", false},
+		{"recovery/poster.psd", false},
+		{"~/poster.psd", false},
+	}
+	for _, tc := range cases {
+		if got := IsAutosaveFile(tc.path); got != tc.want {
+			t.Errorf("IsAutosaveFile(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
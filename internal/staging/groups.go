@@ -0,0 +1,242 @@
+package staging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PackageGroup is a multi-file unit (InDesign package, Sketch workspace, font family)
+// whose members should always be versioned together
+type PackageGroup struct {
+	Kind    string   // "InDesign package", "Sketch workspace", "font family"
+	Name    string   // Package directory or family name
+	Root    string   // Absolute directory containing the group
+	Members []string // Absolute paths of every member file
+}
+
+// IncompleteGroup is a package group with some but not all members staged
+type IncompleteGroup struct {
+	Group    *PackageGroup
+	Unstaged []string // Paths relative to the work tree
+}
+
+// packageLayout describes a directory-based package: a primary document next to asset folders
+type packageLayout struct {
+	kind    string
+	primary string
+	folders []string
+}
+
+var packageLayouts = []packageLayout{
+	{kind: "InDesign package", primary: ".indd", folders: []string{"Links", "Document fonts", "Fonts"}},
+	{kind: "Sketch workspace", primary: ".sketch", folders: []string{"Libraries", "Symbols", "Assets"}},
+}
+
+var fontExtensions = map[string]bool{
+	".otf": true, ".ttf": true, ".ttc": true, ".woff": true, ".woff2": true,
+}
+
+// FindGroup returns the package group containing path, or nil if the file stands alone
+func (s *StagingArea) FindGroup(path string) *PackageGroup {
+	absPath, err := s.absPath(path)
+	if err != nil {
+		return nil
+	}
+	repoRoot := filepath.Dir(s.DgitDir)
+
+	start := filepath.Dir(absPath)
+	if info, err := os.Stat(absPath); err == nil && info.IsDir() {
+		start = absPath
+	}
+
+	// Directory packages: walk up until the repository root
+	for dir := start; strings.HasPrefix(dir, repoRoot); dir = filepath.Dir(dir) {
+		if group := s.packageAt(dir); group != nil {
+			return group
+		}
+		if dir == repoRoot {
+			break
+		}
+	}
+
+	if fontExtensions[strings.ToLower(filepath.Ext(absPath))] {
+		return s.fontFamilyOf(absPath)
+	}
+	return nil
+}
+
+// HasMember reports whether absPath is one of the group's member files
+func (g *PackageGroup) HasMember(absPath string) bool {
+	i := sort.SearchStrings(g.Members, absPath)
+	return i < len(g.Members) && g.Members[i] == absPath
+}
+
+// packageAt returns the package rooted at dir, detecting it once per add
+func (s *StagingArea) packageAt(dir string) *PackageGroup {
+	if group, ok := s.packageDirs[dir]; ok {
+		return group
+	}
+	if s.packageDirs == nil {
+		s.packageDirs = make(map[string]*PackageGroup)
+	}
+	group := detectPackage(dir)
+	s.packageDirs[dir] = group
+	return group
+}
+
+// fontFamilyOf returns the font family absPath belongs to, detecting it once per add
+func (s *StagingArea) fontFamilyOf(absPath string) *PackageGroup {
+	key := filepath.Dir(absPath) + "\x00" + strings.ToLower(fontFamilyName(filepath.Base(absPath)))
+	if group, ok := s.fontFamilies[key]; ok {
+		return group
+	}
+	if s.fontFamilies == nil {
+		s.fontFamilies = make(map[string]*PackageGroup)
+	}
+	group := detectFontFamily(absPath)
+	s.fontFamilies[key] = group
+	return group
+}
+
+// detectPackage checks whether dir is the root of a known package layout
+func detectPackage(dir string) *PackageGroup {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	for _, layout := range packageLayouts {
+		hasPrimary, hasFolder := false, false
+		for _, entry := range entries {
+			if entry.IsDir() {
+				for _, folder := range layout.folders {
+					if entry.Name() == folder {
+						hasFolder = true
+					}
+				}
+			} else if strings.EqualFold(filepath.Ext(entry.Name()), layout.primary) {
+				hasPrimary = true
+			}
+		}
+		if hasPrimary && hasFolder {
+			return &PackageGroup{
+				Kind:    layout.kind,
+				Name:    filepath.Base(dir),
+				Root:    dir,
+				Members: collectMembers(dir),
+			}
+		}
+	}
+	return nil
+}
+
+// detectFontFamily groups font files in one directory sharing a family prefix
+// (Inter-Regular.otf, Inter-Bold.otf → "Inter")
+func detectFontFamily(absPath string) *PackageGroup {
+	dir := filepath.Dir(absPath)
+	family := fontFamilyName(filepath.Base(absPath))
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var members []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !fontExtensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		if strings.EqualFold(fontFamilyName(name), family) {
+			members = append(members, filepath.Join(dir, name))
+		}
+	}
+	if len(members) < 2 {
+		return nil
+	}
+
+	sort.Strings(members)
+	return &PackageGroup{Kind: "font family", Name: family, Root: dir, Members: members}
+}
+
+// fontFamilyName strips the style suffix from a font file name
+func fontFamilyName(fileName string) string {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	if i := strings.IndexAny(name, "-_ "); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// collectMembers lists every non-hidden file below a package root
+func collectMembers(root string) []string {
+	var members []string
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != root {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			members = append(members, path)
+		}
+		return nil
+	})
+	sort.Strings(members)
+	return members
+}
+
+// AddGroup stages every member of a package group
+func (s *StagingArea) AddGroup(group *PackageGroup, result *AddResult) {
+	for _, member := range group.Members {
+		if _, staged := s.files[member]; staged {
+			continue
+		}
+		relPath := s.relPath(member)
+		if err := s.AddFile(relPath); err != nil {
+			result.FailedFiles[relPath] = err
+		} else {
+			result.AddedFiles = append(result.AddedFiles, relPath)
+			s.cacheStats.NewFiles++
+		}
+	}
+}
+
+// IncompleteGroups reports package groups that are only partially staged
+func (s *StagingArea) IncompleteGroups() []IncompleteGroup {
+	seen := make(map[string]bool)
+	var incomplete []IncompleteGroup
+
+	for absPath := range s.files {
+		group := s.FindGroup(absPath)
+		if group == nil {
+			continue
+		}
+		key := group.Kind + "\x00" + group.Root + "\x00" + group.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var unstaged []string
+		for _, member := range group.Members {
+			if _, staged := s.files[member]; !staged {
+				unstaged = append(unstaged, s.relPath(member))
+			}
+		}
+		if len(unstaged) > 0 {
+			incomplete = append(incomplete, IncompleteGroup{Group: group, Unstaged: unstaged})
+		}
+	}
+
+	sort.Slice(incomplete, func(i, j int) bool {
+		return incomplete[i].Group.Root < incomplete[j].Group.Root
+	})
+	return incomplete
+}
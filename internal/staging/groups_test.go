@@ -0,0 +1,84 @@
+package staging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/progress"
+)
+
+// newPackageRepository initializes a repository holding an InDesign package with a
+// Finder .DS_Store in its root and in its Links folder
+func newPackageRepository(t *testing.T) *StagingArea {
+	t.Helper()
+	workDir := t.TempDir()
+	if err := initializer.NewRepositoryInitializer().InitializeRepository(workDir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		"Brochure/Brochure.indd",
+		"Brochure/Links/hero.psd",
+		"Brochure/Links/map.png",
+		"Brochure/Document fonts/Inter-Regular.otf",
+		"Brochure/.DS_Store",
+		"Brochure/Links/.DS_Store",
+	} {
+		path := filepath.Join(workDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stagingArea := NewStagingArea(filepath.Join(workDir, ".dgit"))
+	stagingArea.WorkDir = workDir
+	stagingArea.Progress = func(progress.Event) {}
+	return stagingArea
+}
+
+func TestAddSkipsHiddenFilesInPackages(t *testing.T) {
+	stagingArea := newPackageRepository(t)
+
+	result, err := stagingArea.AddPattern(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := result.AddedFiles
+	sort.Strings(added)
+	want := []string{
+		"Brochure/Brochure.indd",
+		"Brochure/Document fonts/Inter-Regular.otf",
+		"Brochure/Links/hero.psd",
+		"Brochure/Links/map.png",
+	}
+	if len(added) != len(want) {
+		t.Fatalf("added %v, want %v", added, want)
+	}
+	for i := range want {
+		if filepath.ToSlash(added[i]) != want[i] {
+			t.Fatalf("added %v, want %v", added, want)
+		}
+	}
+
+	if err := stagingArea.AddFile(filepath.Join("Brochure", ".DS_Store")); err == nil {
+		t.Fatal("AddFile staged a .DS_Store inside a package")
+	}
+}
+
+func TestFindGroupDetectsEachPackageOnce(t *testing.T) {
+	stagingArea := newPackageRepository(t)
+
+	first := stagingArea.FindGroup(filepath.Join("Brochure", "Links", "hero.psd"))
+	second := stagingArea.FindGroup(filepath.Join("Brochure", "Brochure.indd"))
+	if first == nil || first != second {
+		t.Fatalf("FindGroup returned %p and %p, want one shared package group", first, second)
+	}
+	if len(first.Members) != 4 {
+		t.Fatalf("package has %d members, want 4: %v", len(first.Members), first.Members)
+	}
+}
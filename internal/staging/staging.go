@@ -8,9 +8,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"dgit/internal/pathnorm"
+	"dgit/internal/progress"
 	"dgit/internal/scanner" // 파일 확장자 검증 통합
 
 	"github.com/pierrec/lz4/v4"
@@ -37,6 +40,17 @@ type StagedFile struct {
 	CacheLevel    string        `json:"cache_level"`        // "versions", "cache"
 	PreCompressed bool          `json:"pre_compressed"`     // LZ4 pre-compression status
 	Metadata      *FileMetadata `json:"metadata,omitempty"` // Pre-extracted metadata
+
+	// Template the file was created from with 'dgit new --from-template'
+	Template *TemplateOrigin `json:"template,omitempty"`
+}
+
+// TemplateOrigin records which committed template version a new file was derived from
+type TemplateOrigin struct {
+	Path       string `json:"path"`
+	Version    int    `json:"version"`
+	CommitHash string `json:"commit_hash"`
+	Hash       string `json:"hash,omitempty"` // Content hash of the template file
 }
 
 // FileMetadata contains pre-extracted design file metadata
@@ -51,10 +65,11 @@ type FileMetadata struct {
 
 // AddResult contains the result of adding files with cache performance metrics
 type AddResult struct {
-	AddedFiles     []string
-	FailedFiles    map[string]error
-	CacheStats     *CacheStats
-	ProcessingTime time.Duration
+	AddedFiles      []string
+	FailedFiles     map[string]error
+	SkippedAutosave []string // Autosave/recovery files left out of a pattern
+	CacheStats      *CacheStats
+	ProcessingTime  time.Duration
 }
 
 // CacheStats tracks cache performance
@@ -70,7 +85,28 @@ type CacheStats struct {
 type StagingArea struct {
 	DgitDir     string
 	StagingFile string
+	RemovedFile string // Deletions staged by 'dgit rm' (repository-relative paths)
+	RenamedFile string // Renames staged by 'dgit mv' (new path -> old path)
 	files       map[string]*StagedFile
+	removed     map[string]bool
+	renamed     map[string]string
+
+	// WholeGroups stages every member of a package group when any member matches
+	WholeGroups bool
+
+	// IncludeAutosave allows staging app autosave and crash-recovery files
+	IncludeAutosave bool
+
+	// Progress silences stdout output when set (library mode); callers report per-file events
+	Progress progress.Reporter
+
+	// WorkDir is the tree relative paths are resolved against; empty means the current directory
+	WorkDir string
+
+	// Package detection for the current add, so each package is walked once: directory →
+	// its package (nil when it is not a package root), and directory+family → font family
+	packageDirs  map[string]*PackageGroup
+	fontFamilies map[string]*PackageGroup
 
 	// Simplified storage directories
 	versionsDir string // 메인 버전 저장소 (.dgit/versions/)
@@ -96,7 +132,11 @@ func NewStagingArea(dgitDir string) *StagingArea {
 	return &StagingArea{
 		DgitDir:     dgitDir,
 		StagingFile: filepath.Join(stagingDir, "staged.json"),
+		RemovedFile: filepath.Join(stagingDir, "removed.json"),
+		RenamedFile: filepath.Join(stagingDir, "renamed.json"),
 		files:       make(map[string]*StagedFile),
+		removed:     make(map[string]bool),
+		renamed:     make(map[string]string),
 		versionsDir: versionsDir,
 		commitsDir:  commitsDir,
 		cacheDir:    cacheDir,
@@ -106,6 +146,13 @@ func NewStagingArea(dgitDir string) *StagingArea {
 
 // LoadStaging loads the current staging area from disk with cache validation
 func (s *StagingArea) LoadStaging() error {
+	if err := s.loadRemoved(); err != nil {
+		return err
+	}
+	if err := s.loadRenamed(); err != nil {
+		return err
+	}
+
 	if _, err := os.Stat(s.StagingFile); os.IsNotExist(err) {
 		return nil // No staging file exists yet
 	}
@@ -150,15 +197,168 @@ func (s *StagingArea) SaveStaging() error {
 		return fmt.Errorf("failed to write staging file: %w", err)
 	}
 
+	if err := s.saveRemoved(); err != nil {
+		return err
+	}
+	return s.saveRenamed()
+}
+
+// loadRemoved reads staged deletions; kept in a separate file so staged.json keeps its format
+func (s *StagingArea) loadRemoved() error {
+	data, err := os.ReadFile(s.RemovedFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read staged deletions: %w", err)
+	}
+
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return fmt.Errorf("failed to parse staged deletions: %w", err)
+	}
+	for _, path := range paths {
+		s.removed[path] = true
+	}
 	return nil
 }
 
+// saveRemoved writes staged deletions, removing the file when there are none
+func (s *StagingArea) saveRemoved() error {
+	if len(s.removed) == 0 {
+		if err := os.Remove(s.RemovedFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear staged deletions: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.GetRemovedFiles(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal staged deletions: %w", err)
+	}
+	if err := os.WriteFile(s.RemovedFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write staged deletions: %w", err)
+	}
+	return nil
+}
+
+// loadRenamed reads staged renames
+func (s *StagingArea) loadRenamed() error {
+	data, err := os.ReadFile(s.RenamedFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read staged renames: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.renamed); err != nil {
+		return fmt.Errorf("failed to parse staged renames: %w", err)
+	}
+	return nil
+}
+
+// saveRenamed writes staged renames, removing the file when there are none
+func (s *StagingArea) saveRenamed() error {
+	if len(s.renamed) == 0 {
+		if err := os.Remove(s.RenamedFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear staged renames: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.renamed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal staged renames: %w", err)
+	}
+	if err := os.WriteFile(s.RenamedFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write staged renames: %w", err)
+	}
+	return nil
+}
+
+// StageRename records that the tracked file oldPath now lives at newPath
+// Chained moves keep the original tracked path; moving a file back drops the record
+func (s *StagingArea) StageRename(oldPath, newPath string) {
+	if origin, ok := s.renamed[oldPath]; ok {
+		delete(s.renamed, oldPath)
+		oldPath = origin
+	}
+	if oldPath != newPath {
+		s.renamed[newPath] = oldPath
+	}
+}
+
+// GetRenamedFiles returns staged renames keyed by new path
+func (s *StagingArea) GetRenamedFiles() map[string]string {
+	renamed := make(map[string]string, len(s.renamed))
+	for newPath, oldPath := range s.renamed {
+		renamed[newPath] = oldPath
+	}
+	return renamed
+}
+
+// RenamedFrom returns the tracked path a staged file was moved from
+func (s *StagingArea) RenamedFrom(path string) (string, bool) {
+	oldPath, ok := s.renamed[path]
+	return oldPath, ok
+}
+
+// StageRemoval records that a tracked file is deleted in the next commit
+// path is repository-relative as stored in commit metadata
+func (s *StagingArea) StageRemoval(path string) {
+	s.removed[path] = true
+}
+
+// UnstageRemoval drops a staged deletion, reporting whether one existed
+func (s *StagingArea) UnstageRemoval(path string) bool {
+	if !s.removed[path] {
+		return false
+	}
+	delete(s.removed, path)
+	return true
+}
+
+// GetRemovedFiles returns staged deletions, sorted
+func (s *StagingArea) GetRemovedFiles() []string {
+	paths := make([]string, 0, len(s.removed))
+	for path := range s.removed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// IsRemoved reports whether a deletion of path is staged
+func (s *StagingArea) IsRemoved(path string) bool {
+	return s.removed[path]
+}
+
+// StageRemaining stages tracked files that are neither staged nor removed, so a commit
+// that only deletes files still records a complete version; a tracked file that is
+// missing without a staged deletion is an error rather than silently dropped
+func (s *StagingArea) StageRemaining(tracked []string) ([]string, error) {
+	var added []string
+	for _, path := range tracked {
+		if s.removed[path] || s.HasFile(path) {
+			continue
+		}
+		if _, err := os.Stat(s.resolve(path)); err != nil {
+			return added, fmt.Errorf("tracked file %s cannot be carried into the commit (stage its deletion with 'dgit rm'): %w", path, err)
+		}
+		if err := s.AddFile(path); err != nil {
+			return added, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+		added = append(added, path)
+	}
+	return added, nil
+}
+
 // AddFile adds a file to the staging area with cache pre-processing
 func (s *StagingArea) AddFile(path string) error {
 	startTime := time.Now()
 
 	// Convert to absolute path
-	absPath, err := filepath.Abs(path)
+	absPath, err := s.absPath(path)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
@@ -169,18 +369,21 @@ func (s *StagingArea) AddFile(path string) error {
 		return fmt.Errorf("file not found: %w", err)
 	}
 
-	// Check if it's a design file using unified function
-	if !scanner.IsDesignFile(absPath) {
-		return fmt.Errorf("not a design file: %s", path)
+	if s.isExcludedAutosave(absPath) {
+		return fmt.Errorf("autosave/recovery file not staged by default: %s", path)
 	}
 
-	// Get relative path from current directory
-	currentDir, _ := os.Getwd()
-	relPath, err := filepath.Rel(currentDir, absPath)
-	if err != nil {
-		relPath = absPath
+	// Design files, plus any member of a multi-file package (links, fonts)
+	if !s.isStageable(absPath) {
+		return fmt.Errorf("not a design file: %s", path)
 	}
 
+	// Get relative path from the work tree
+	relPath := s.relPath(absPath)
+
+	// Manifest paths use one Unicode normal form so macOS (NFD) and Windows (NFC) agree
+	relPath = pathnorm.LoadPolicy(s.DgitDir).Apply(relPath)
+
 	hash, err := s.generateFileHash(absPath)
 	if err != nil {
 		return fmt.Errorf("failed to generate file hash: %w", err)
@@ -193,7 +396,7 @@ func (s *StagingArea) AddFile(path string) error {
 	stagedFile := &StagedFile{
 		Path:          relPath,
 		AbsolutePath:  absPath,
-		FileType:      strings.ToLower(filepath.Ext(absPath)[1:]),
+		FileType:      strings.TrimPrefix(strings.ToLower(filepath.Ext(absPath)), "."),
 		Size:          fileInfo.Size(),
 		ModTime:       fileInfo.ModTime(),
 		AddedAt:       time.Now(),
@@ -202,20 +405,72 @@ func (s *StagingArea) AddFile(path string) error {
 		PreCompressed: false,
 	}
 
+	// Re-adding an edited file keeps where it came from
+	if previous, ok := s.files[absPath]; ok {
+		stagedFile.Template = previous.Template
+	}
+
 	// Pre-process for commits
 	if err := s.preprocessFile(stagedFile); err != nil {
-		fmt.Printf("Warning: failed to preprocess %s: %v\n", path, err)
+		s.printf("Warning: failed to preprocess %s: %v\n", path, err)
 	}
 
 	s.files[absPath] = stagedFile
 
 	processingTime := time.Since(startTime)
-	fmt.Printf("Added %s to %s (processed in %v)\n",
+	s.printf("Added %s to %s (processed in %v)\n",
 		filepath.Base(path), cacheLevel, processingTime)
 
 	return nil
 }
 
+// isStageable reports whether a file may be staged: design files outside packages, and
+// package group members. Hidden files inside a package (.DS_Store) are not members
+func (s *StagingArea) isStageable(path string) bool {
+	if group := s.FindGroup(path); group != nil {
+		absPath, err := s.absPath(path)
+		return err == nil && group.HasMember(absPath)
+	}
+	return scanner.IsDesignFile(path)
+}
+
+// isExcludedAutosave reports whether path is an autosave/recovery file left out by default
+func (s *StagingArea) isExcludedAutosave(path string) bool {
+	return !s.IncludeAutosave && scanner.IsAutosaveFile(path)
+}
+
+// resolve anchors a relative path at WorkDir; without one it stays relative to the current directory
+func (s *StagingArea) resolve(path string) string {
+	if s.WorkDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.WorkDir, path)
+}
+
+// absPath returns the absolute path of a file named relative to the work tree
+func (s *StagingArea) absPath(path string) (string, error) {
+	return filepath.Abs(s.resolve(path))
+}
+
+// relPath converts an absolute path to one relative to the work tree
+func (s *StagingArea) relPath(absPath string) string {
+	workDir := s.WorkDir
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	if relPath, err := filepath.Rel(workDir, absPath); err == nil {
+		return relPath
+	}
+	return absPath
+}
+
+// printf writes human-readable progress in CLI mode; library callers get events instead
+func (s *StagingArea) printf(format string, a ...interface{}) {
+	if !s.Progress.Enabled() {
+		fmt.Printf(format, a...)
+	}
+}
+
 // preprocessFile performs preprocessing for commits
 func (s *StagingArea) preprocessFile(file *StagedFile) error {
 	// LZ4 Pre-compression for versions directory files
@@ -230,7 +485,7 @@ func (s *StagingArea) preprocessFile(file *StagedFile) error {
 	// Extract metadata for commit info
 	metadata, err := s.extractDesignFileMetadata(file.AbsolutePath, file.FileType)
 	if err != nil {
-		fmt.Printf("Warning: failed to extract metadata from %s: %v\n", file.Path, err)
+		s.printf("Warning: failed to extract metadata from %s: %v\n", file.Path, err)
 	} else {
 		file.Metadata = metadata
 		s.cacheStats.MetadataExtracted++
@@ -504,10 +759,11 @@ func (s *StagingArea) generateFileHash(path string) (string, error) {
 // AddPattern adds files matching a pattern to staging area
 func (s *StagingArea) AddPattern(pattern string) (*AddResult, error) {
 	startTime := time.Now()
+	s.packageDirs, s.fontFamilies = nil, nil
 
 	if pattern == "." {
 		// Add all design files in current directory
-		result, err := s.addAllDesignFiles(".")
+		result, err := s.addAllDesignFiles(s.resolve("."))
 		if result != nil {
 			result.ProcessingTime = time.Since(startTime)
 			result.CacheStats = s.cacheStats
@@ -516,7 +772,7 @@ func (s *StagingArea) AddPattern(pattern string) (*AddResult, error) {
 	}
 
 	// Handle glob patterns
-	matches, err := filepath.Glob(pattern)
+	matches, err := filepath.Glob(s.resolve(pattern))
 	if err != nil {
 		return nil, fmt.Errorf("invalid pattern: %w", err)
 	}
@@ -532,7 +788,20 @@ func (s *StagingArea) AddPattern(pattern string) (*AddResult, error) {
 	}
 
 	for _, match := range matches {
-		if scanner.IsDesignFile(match) {
+		if s.WorkDir != "" && !filepath.IsAbs(pattern) {
+			match = s.relPath(match)
+		}
+		if s.isExcludedAutosave(match) {
+			result.SkippedAutosave = append(result.SkippedAutosave, match)
+			continue
+		}
+		if s.WholeGroups {
+			if group := s.FindGroup(match); group != nil {
+				s.AddGroup(group, result)
+				continue
+			}
+		}
+		if s.isStageable(match) {
 			if err := s.AddFile(match); err != nil {
 				result.FailedFiles[match] = err
 			} else {
@@ -543,6 +812,9 @@ func (s *StagingArea) AddPattern(pattern string) (*AddResult, error) {
 	}
 
 	if len(result.AddedFiles) == 0 {
+		if len(result.SkippedAutosave) > 0 {
+			return nil, fmt.Errorf("only autosave/recovery files match pattern: %s (use --include-autosave to stage them)", pattern)
+		}
 		return nil, fmt.Errorf("no design files found matching pattern: %s", pattern)
 	}
 
@@ -562,6 +834,9 @@ func (s *StagingArea) addAllDesignFiles(dir string) (*AddResult, error) {
 		if err != nil {
 			return err
 		}
+		if s.WorkDir != "" {
+			path = s.relPath(path)
+		}
 
 		// Skip .dgit directory
 		if strings.Contains(path, ".dgit") {
@@ -571,7 +846,12 @@ func (s *StagingArea) addAllDesignFiles(dir string) (*AddResult, error) {
 			return nil
 		}
 
-		if !info.IsDir() && scanner.IsDesignFile(path) {
+		if !info.IsDir() && s.isExcludedAutosave(path) {
+			result.SkippedAutosave = append(result.SkippedAutosave, path)
+			return nil
+		}
+
+		if !info.IsDir() && s.isStageable(path) {
 			if err := s.AddFile(path); err != nil {
 				result.FailedFiles[path] = err
 			} else {
@@ -600,7 +880,7 @@ func (s *StagingArea) GetCacheStats() *CacheStats {
 
 // RemoveFile removes a file from staging area and cache
 func (s *StagingArea) RemoveFile(path string) error {
-	absPath, err := filepath.Abs(path)
+	absPath, err := s.absPath(path)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
@@ -620,6 +900,20 @@ func (s *StagingArea) RemoveFile(path string) error {
 	return nil
 }
 
+// SetTemplate records the template a staged file was created from
+func (s *StagingArea) SetTemplate(path string, origin *TemplateOrigin) error {
+	absPath, err := s.absPath(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	file, exists := s.files[absPath]
+	if !exists {
+		return fmt.Errorf("file not in staging area: %s", path)
+	}
+	file.Template = origin
+	return nil
+}
+
 // GetStagedFiles returns all files in the staging area
 func (s *StagingArea) GetStagedFiles() []*StagedFile {
 	files := make([]*StagedFile, 0, len(s.files))
@@ -631,7 +925,7 @@ func (s *StagingArea) GetStagedFiles() []*StagedFile {
 
 // IsEmpty returns true if the staging area is empty
 func (s *StagingArea) IsEmpty() bool {
-	return len(s.files) == 0
+	return len(s.files) == 0 && len(s.removed) == 0
 }
 
 // ClearStaging clears all files from staging area and cache
@@ -645,6 +939,8 @@ func (s *StagingArea) ClearStaging() error {
 	}
 
 	s.files = make(map[string]*StagedFile)
+	s.removed = make(map[string]bool)
+	s.renamed = make(map[string]string)
 	s.cacheStats = &CacheStats{}
 	return s.SaveStaging()
 }
@@ -656,7 +952,7 @@ func (s *StagingArea) GetFileCount() int {
 
 // HasFile checks if a file is in the staging area
 func (s *StagingArea) HasFile(path string) bool {
-	absPath, err := filepath.Abs(path)
+	absPath, err := s.absPath(path)
 	if err != nil {
 		return false
 	}
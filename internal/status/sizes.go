@@ -0,0 +1,103 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+
+	"dgit/internal/log"
+)
+
+// Storage estimates are based on how well recent commits of this repository compressed,
+// split into full snapshots and deltas against an earlier version, because design files
+// compress very differently from each other. Without history, conservative defaults apply.
+
+// estimateSamples is how many recent commits feed the compression ratios
+const estimateSamples = 20
+
+// Default ratios of stored to original bytes when the repository has no history to learn from
+const (
+	defaultSnapshotRatio = 0.9
+	defaultDeltaRatio    = 0.5
+)
+
+// SizeChange compares a working file with its last committed version
+type SizeChange struct {
+	Path          string
+	Size          int64
+	CommittedSize int64 // 0 when the file has never been committed
+	Version       int   // Version the committed size comes from
+}
+
+// Delta is the growth of the file since it was last committed
+func (c SizeChange) Delta() int64 {
+	return c.Size - c.CommittedSize
+}
+
+// StorageEstimate predicts how much storage committing some files will add
+type StorageEstimate struct {
+	OriginalBytes int64
+	StoredBytes   int64
+	Ratio         float64
+	Delta         bool // Expected to be stored as a delta against the previous version
+	Samples       int  // Commits the ratio was learned from; 0 means defaults
+}
+
+// SizeChanges returns the working and last committed size of each path under workDir
+func (sm *StatusManager) SizeChanges(paths []string, workDir string) []SizeChange {
+	logManager := log.NewLogManager(sm.DgitDir)
+	commits, _ := logManager.GetCommitHistory()
+
+	changes := make([]SizeChange, 0, len(paths))
+	for _, path := range paths {
+		change := SizeChange{Path: path}
+		if info, err := os.Stat(filepath.Join(workDir, path)); err == nil {
+			change.Size = info.Size()
+		}
+		for _, commit := range commits {
+			if fileMeta, ok := commit.Metadata[path].(map[string]interface{}); ok {
+				if size, ok := fileMeta["size"].(float64); ok {
+					change.CommittedSize = int64(size)
+					change.Version = commit.Version
+				}
+				break
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// EstimateCommitStorage predicts the storage a commit of originalBytes would add
+func (sm *StatusManager) EstimateCommitStorage(originalBytes int64) StorageEstimate {
+	logManager := log.NewLogManager(sm.DgitDir)
+	commits, _ := logManager.GetCommitHistory()
+
+	estimate := StorageEstimate{OriginalBytes: originalBytes, Delta: len(commits) > 0}
+
+	var original, stored int64
+	for _, commit := range commits {
+		info := commit.CompressionInfo
+		if info == nil || info.OriginalSize <= 0 || info.CompressedSize <= 0 {
+			continue
+		}
+		if (info.BaseVersion > 0) != estimate.Delta {
+			continue
+		}
+		original += info.OriginalSize
+		stored += info.CompressedSize
+		if estimate.Samples++; estimate.Samples == estimateSamples {
+			break
+		}
+	}
+
+	switch {
+	case original > 0:
+		estimate.Ratio = float64(stored) / float64(original)
+	case estimate.Delta:
+		estimate.Ratio = defaultDeltaRatio
+	default:
+		estimate.Ratio = defaultSnapshotRatio
+	}
+	estimate.StoredBytes = int64(float64(originalBytes) * estimate.Ratio)
+	return estimate
+}
@@ -11,8 +11,13 @@ import (
 	"strings"
 	"time"
 
+	"dgit/internal/filedelta"
+	initializer "dgit/internal/init"
 	"dgit/internal/log"
+	"dgit/internal/objfmt"
+	"dgit/internal/pathnorm"
 	"github.com/gabstv/go-bsdiff/pkg/bspatch"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 )
 
@@ -22,16 +27,22 @@ type StatusManager struct {
 	ObjectsDir   string
 	SnapshotsDir string
 	DeltasDir    string
+	ArchiveDir   string
+
+	pathPolicy pathnorm.Policy // Unicode normal-form matching for NFC/NFD paths
 }
 
 // NewStatusManager creates a new status manager
 func NewStatusManager(dgitDir string) *StatusManager {
-	objectsDir := filepath.Join(dgitDir, "objects")
+	storageDir := initializer.GetStorageDir(dgitDir)
+	objectsDir := filepath.Join(storageDir, "objects")
 	return &StatusManager{
 		DgitDir:      dgitDir,
 		ObjectsDir:   objectsDir,
-		SnapshotsDir: filepath.Join(dgitDir, "snapshots"),
-		DeltasDir:    filepath.Join(dgitDir, "deltas"),
+		SnapshotsDir: filepath.Join(storageDir, "snapshots"),
+		DeltasDir:    filepath.Join(storageDir, "deltas"),
+		ArchiveDir:   filepath.Join(storageDir, "archive"),
+		pathPolicy:   pathnorm.LoadPolicy(dgitDir),
 	}
 }
 
@@ -143,7 +154,19 @@ func (sm *StatusManager) findRestorationPath(targetVersion int) ([]RestorationSt
 			break
 		}
 
-		// Priority 2: Check for direct ZIP snapshot (legacy)
+		// Priority 2: Check archive tier for Zstd snapshot
+		archivePath := filepath.Join(sm.ArchiveDir, fmt.Sprintf("v%d.zstd", currentVersion))
+		if sm.fileExists(archivePath) {
+			step := RestorationStep{
+				Type:    "zstd",
+				File:    archivePath,
+				Version: currentVersion,
+			}
+			path = append([]RestorationStep{step}, path...)
+			break
+		}
+
+		// Priority 3: Check for direct ZIP snapshot (legacy)
 		zipPath := filepath.Join(sm.ObjectsDir, fmt.Sprintf("v%d.zip", currentVersion))
 		if sm.fileExists(zipPath) {
 			step := RestorationStep{
@@ -155,7 +178,7 @@ func (sm *StatusManager) findRestorationPath(targetVersion int) ([]RestorationSt
 			break
 		}
 
-		// Priority 3: Look for bsdiff delta files in deltas/
+		// Priority 4: Look for bsdiff delta files in deltas/
 		deltaPath := filepath.Join(sm.DeltasDir, fmt.Sprintf("v%d_from_v%d.bsdiff", currentVersion, currentVersion-1))
 		if sm.fileExists(deltaPath) {
 			step := RestorationStep{
@@ -168,7 +191,7 @@ func (sm *StatusManager) findRestorationPath(targetVersion int) ([]RestorationSt
 			continue
 		}
 
-		// Priority 4: Look for psd_smart delta files in deltas/
+		// Priority 5: Look for psd_smart delta files in deltas/
 		psdSmartPath := filepath.Join(sm.DeltasDir, fmt.Sprintf("v%d_from_v%d.psd_smart", currentVersion, currentVersion-1))
 		if sm.fileExists(psdSmartPath) {
 			step := RestorationStep{
@@ -181,7 +204,7 @@ func (sm *StatusManager) findRestorationPath(targetVersion int) ([]RestorationSt
 			continue
 		}
 
-		// Priority 5: Check legacy deltas (objects/deltas/)
+		// Priority 6: Check legacy deltas (objects/deltas/)
 		legacyDeltaPath := filepath.Join(sm.ObjectsDir, "deltas", fmt.Sprintf("v%d_from_v%d.bsdiff", currentVersion, currentVersion-1))
 		if sm.fileExists(legacyDeltaPath) {
 			step := RestorationStep{
@@ -218,6 +241,11 @@ func (sm *StatusManager) executeRestorationPath(path []RestorationStep, outputFi
 		if err := sm.convertLZ4ToZip(baseStep.File, tempFile); err != nil {
 			return err
 		}
+	case "zstd":
+		// Convert archived Zstd snapshot to ZIP
+		if err := sm.convertZstdToZip(baseStep.File, tempFile); err != nil {
+			return err
+		}
 	case "zip":
 		// Copy ZIP directly
 		if err := sm.copyFile(baseStep.File, tempFile); err != nil {
@@ -238,8 +266,8 @@ func (sm *StatusManager) executeRestorationPath(path []RestorationStep, outputFi
 				return fmt.Errorf("failed to apply bsdiff patch for v%d: %w", step.Version, err)
 			}
 		case "psd_smart":
-			// PSD smart delta uses same bsdiff format
-			if err := sm.applyBsdiffPatch(tempFile, step.File, nextTempFile); err != nil {
+			// bsdiff deltas are stored under this name, either per file or over the whole archive
+			if err := sm.applyDelta(tempFile, step.File, nextTempFile); err != nil {
 				return fmt.Errorf("failed to apply psd_smart patch for v%d: %w", step.Version, err)
 			}
 		case "xdelta3":
@@ -264,6 +292,22 @@ func (sm *StatusManager) executeRestorationPath(path []RestorationStep, outputFi
 	return nil
 }
 
+// applyDelta applies a delta stored as .psd_smart: a per-file delta, or a bsdiff patch
+// of the whole archive
+func (sm *StatusManager) applyDelta(oldFile, deltaFile, newFile string) error {
+	data, err := os.ReadFile(deltaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read delta file: %w", err)
+	}
+	if data, err = objfmt.Strip(data, deltaFile); err != nil {
+		return err
+	}
+	if filedelta.IsPayload(data) {
+		return filedelta.Apply(oldFile, data, newFile)
+	}
+	return sm.applyBsdiffPatch(oldFile, deltaFile, newFile)
+}
+
 // applyBsdiffPatch applies a bsdiff patch
 func (sm *StatusManager) applyBsdiffPatch(oldFile, patchFile, newFile string) error {
 	// Open old file
@@ -287,8 +331,13 @@ func (sm *StatusManager) applyBsdiffPatch(oldFile, patchFile, newFile string) er
 	}
 	defer new.Close()
 
+	patchPayload, err := objfmt.NewReader(patch, patchFile)
+	if err != nil {
+		return err
+	}
+
 	// Apply patch using bspatch
-	if err := bspatch.Reader(old, new, patch); err != nil {
+	if err := bspatch.Reader(old, new, patchPayload); err != nil {
 		return fmt.Errorf("bspatch failed: %w", err)
 	}
 
@@ -362,14 +411,19 @@ func (sm *StatusManager) extractHashesFromLZ4(lz4FileName string, version int) (
 	var lz4Path string
 
 	// 우선순위 1: snapshots
-	lz4Path = filepath.Join(sm.DgitDir, "snapshots", lz4FileName)
+	lz4Path = filepath.Join(sm.SnapshotsDir, lz4FileName)
 	if !sm.fileExists(lz4Path) {
 		// 우선순위 2: versions (하위 호환)
 		lz4Path = filepath.Join(sm.DgitDir, "versions", lz4FileName)
 		if !sm.fileExists(lz4Path) {
 			// 우선순위 3: deltas
-			lz4Path = filepath.Join(sm.DgitDir, "deltas", lz4FileName)
+			lz4Path = filepath.Join(sm.DeltasDir, lz4FileName)
 			if !sm.fileExists(lz4Path) {
+				// Snapshot may have been moved to the archive tier
+				archivePath := filepath.Join(sm.ArchiveDir, fmt.Sprintf("v%d.zstd", version))
+				if sm.fileExists(archivePath) {
+					return sm.extractHashesFromZstd(archivePath)
+				}
 				return make(map[string]string), fmt.Errorf("LZ4 file not found: %s", lz4FileName)
 			}
 		}
@@ -382,8 +436,13 @@ func (sm *StatusManager) extractHashesFromLZ4(lz4FileName string, version int) (
 	}
 	defer file.Close()
 
+	payload, err := objfmt.NewReader(file, lz4Path)
+	if err != nil {
+		return nil, err
+	}
+
 	// LZ4 압축 해제
-	lz4Reader := lz4.NewReader(file)
+	lz4Reader := lz4.NewReader(payload)
 	decompressedData, err := io.ReadAll(lz4Reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decompress LZ4: %w", err)
@@ -501,9 +560,19 @@ func (sm *StatusManager) CompareWithCommit(commitVersion int, currentDirFiles ma
 		DeletedFiles:   []FileStatus{},
 	}
 
+	// Index both sides by match key so NFC and NFD spellings of a name pair up
+	committedByKey := make(map[string]string, len(lastCommitFileHashes))
+	for path, hash := range lastCommitFileHashes {
+		committedByKey[sm.pathPolicy.MatchKey(path)] = hash
+	}
+	currentByKey := make(map[string]bool, len(currentDirFiles))
+	for path := range currentDirFiles {
+		currentByKey[sm.pathPolicy.MatchKey(path)] = true
+	}
+
 	// Find modified and untracked files
 	for path, currentHash := range currentDirFiles {
-		if lastCommitHash, ok := lastCommitFileHashes[path]; ok {
+		if lastCommitHash, ok := committedByKey[sm.pathPolicy.MatchKey(path)]; ok {
 			// File existed in last commit, check if modified
 			if lastCommitHash != currentHash {
 				result.ModifiedFiles = append(result.ModifiedFiles, FileStatus{
@@ -522,7 +591,7 @@ func (sm *StatusManager) CompareWithCommit(commitVersion int, currentDirFiles ma
 
 	// Find deleted files
 	for lastCommitPath := range lastCommitFileHashes {
-		if _, ok := currentDirFiles[lastCommitPath]; !ok {
+		if !currentByKey[sm.pathPolicy.MatchKey(lastCommitPath)] {
 			// File existed in last commit but not in current directory
 			result.DeletedFiles = append(result.DeletedFiles, FileStatus{
 				Path:   lastCommitPath,
@@ -543,13 +612,67 @@ func (sm *StatusManager) convertLZ4ToZip(lz4Path, zipPath string) error {
 	}
 	defer lz4File.Close()
 
+	payload, err := objfmt.NewReader(lz4File, lz4Path)
+	if err != nil {
+		return err
+	}
+
 	// Decompress LZ4
-	lz4Reader := lz4.NewReader(lz4File)
+	lz4Reader := lz4.NewReader(payload)
 	decompressedData, err := io.ReadAll(lz4Reader)
 	if err != nil {
 		return fmt.Errorf("failed to decompress LZ4: %w", err)
 	}
 
+	return sm.writeStructuredDataToZip(decompressedData, zipPath)
+}
+
+// convertZstdToZip converts an archived Zstd snapshot to ZIP format for delta restoration
+func (sm *StatusManager) convertZstdToZip(zstdPath, zipPath string) error {
+	decompressedData, err := sm.decompressZstd(zstdPath)
+	if err != nil {
+		return err
+	}
+	return sm.writeStructuredDataToZip(decompressedData, zipPath)
+}
+
+// extractHashesFromZstd extracts file hashes from an archived Zstd snapshot
+func (sm *StatusManager) extractHashesFromZstd(zstdPath string) (map[string]string, error) {
+	decompressedData, err := sm.decompressZstd(zstdPath)
+	if err != nil {
+		return nil, err
+	}
+	return sm.extractHashesFromStructuredData(decompressedData)
+}
+
+// decompressZstd reads and decompresses a Zstd file
+func (sm *StatusManager) decompressZstd(zstdPath string) ([]byte, error) {
+	zstdFile, err := os.Open(zstdPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Zstd: %w", err)
+	}
+	defer zstdFile.Close()
+
+	payload, err := objfmt.NewReader(zstdFile, zstdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	zstdReader, err := zstd.NewReader(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Zstd reader: %w", err)
+	}
+	defer zstdReader.Close()
+
+	decompressedData, err := io.ReadAll(zstdReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress Zstd: %w", err)
+	}
+	return decompressedData, nil
+}
+
+// writeStructuredDataToZip writes a "FILE:path:size" stream as ZIP entries
+func (sm *StatusManager) writeStructuredDataToZip(decompressedData []byte, zipPath string) error {
 	// Create ZIP file
 	zipFile, err := os.Create(zipPath)
 	if err != nil {
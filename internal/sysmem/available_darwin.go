@@ -0,0 +1,40 @@
+package sysmem
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pageSizePattern  = regexp.MustCompile(`page size of (\d+) bytes`)
+	pageCountPattern = regexp.MustCompile(`^Pages (free|inactive|speculative|purgeable):\s+(\d+)\.`)
+)
+
+// available sums free, inactive, speculative and purgeable pages reported by vm_stat
+func available() uint64 {
+	output, err := exec.Command("vm_stat").Output()
+	if err != nil {
+		return 0
+	}
+
+	lines := strings.Split(string(output), "\n")
+	if len(lines) == 0 {
+		return 0
+	}
+	match := pageSizePattern.FindStringSubmatch(lines[0])
+	if match == nil {
+		return 0
+	}
+	pageSize, _ := strconv.ParseUint(match[1], 10, 64)
+
+	var pages uint64
+	for _, line := range lines[1:] {
+		if match := pageCountPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			count, _ := strconv.ParseUint(match[2], 10, 64)
+			pages += count
+		}
+	}
+	return pages * pageSize
+}
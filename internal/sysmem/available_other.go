@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package sysmem
+
+// available is unknown on this platform
+func available() uint64 {
+	return 0
+}
@@ -0,0 +1,9 @@
+// Package sysmem reports how much physical memory the machine can spare, so
+// memory-hungry operations (bsdiff) can be skipped before they push the system into swap.
+package sysmem
+
+// Available returns the bytes of memory available to new allocations without swapping,
+// or 0 when the platform does not expose it
+func Available() uint64 {
+	return available()
+}
@@ -0,0 +1,195 @@
+// Package tag gives versions names ("delivery-2026-10", "client-approved") that can be
+// used wherever a version or commit hash is accepted. Tags live in .dgit/tags.json,
+// outside the commit records, so tagging never changes commit hashes. Each tag also
+// records the commit hash it was created on, so a tag is never silently read against
+// a different history.
+package tag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"dgit/internal/log"
+)
+
+// Tag names one version
+type Tag struct {
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+// ExistsError is returned when a tag name is already used for another version
+type ExistsError struct {
+	Tag *Tag
+}
+
+func (e *ExistsError) Error() string {
+	return fmt.Sprintf("tag '%s' already names v%d", e.Tag.Name, e.Tag.Version)
+}
+
+// validName allows names that cannot be mistaken for versions, hashes or flags
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// versionLike matches "17" and "v17", which always mean versions
+var versionLike = regexp.MustCompile(`^v?[0-9]+$`)
+
+// TagManager reads and updates tags
+type TagManager struct {
+	DgitDir  string
+	TagsFile string
+}
+
+// NewTagManager creates a new tag manager
+func NewTagManager(dgitDir string) *TagManager {
+	return &TagManager{
+		DgitDir:  dgitDir,
+		TagsFile: filepath.Join(dgitDir, "tags.json"),
+	}
+}
+
+// ValidateName rejects names that are empty, look like versions or contain spaces
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid tag name '%s': use letters, digits, '.', '_', '/' and '-'", name)
+	}
+	if versionLike.MatchString(name) {
+		return fmt.Errorf("invalid tag name '%s': it reads as a version number", name)
+	}
+	return nil
+}
+
+// List returns every tag, oldest version first
+func (tm *TagManager) List() ([]*Tag, error) {
+	data, err := os.ReadFile(tm.TagsFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	var tags []*Tag
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to parse tags.json: %w", err)
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+// Get returns the tag with the given name, or nil
+func (tm *TagManager) Get(name string) *Tag {
+	tags, _ := tm.List()
+	for _, t := range tags {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// ForVersion returns the names of the tags on a version
+func (tm *TagManager) ForVersion(version int) []string {
+	tags, _ := tm.List()
+	var names []string
+	for _, t := range tags {
+		if t.Version == version {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// Resolve returns the commit a tag names, or nil when there is no such tag
+// A tag whose commit hash no longer matches its version is reported, not followed
+func (tm *TagManager) Resolve(name string) (*log.Commit, error) {
+	t := tm.Get(name)
+	if t == nil {
+		return nil, nil
+	}
+	commit, err := log.NewLogManager(tm.DgitDir).GetCommit(t.Version)
+	if err != nil {
+		return nil, fmt.Errorf("tag '%s' names v%d, which is not in this repository", name, t.Version)
+	}
+	if commit.Hash != t.Hash {
+		return nil, fmt.Errorf("tag '%s' was created on a different v%d (commit %s)", name, t.Version, t.Hash)
+	}
+	return commit, nil
+}
+
+// Set names a version; an existing tag on another version is moved only with force
+// It returns the previous tag when one was replaced
+func (tm *TagManager) Set(name string, version int, hash, message, by string, force bool) (*Tag, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	tags, err := tm.List()
+	if err != nil {
+		return nil, err
+	}
+
+	created := &Tag{Name: name, Version: version, Hash: hash, Message: message, CreatedAt: time.Now().UTC(), CreatedBy: by}
+	for i, t := range tags {
+		if t.Name != name {
+			continue
+		}
+		if t.Version != version && !force {
+			return nil, &ExistsError{Tag: t}
+		}
+		tags[i] = created
+		return t, tm.save(tags)
+	}
+	return nil, tm.save(append(tags, created))
+}
+
+// Delete removes a tag
+func (tm *TagManager) Delete(name string) (*Tag, error) {
+	tags, err := tm.List()
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range tags {
+		if t.Name == name {
+			return t, tm.save(append(tags[:i], tags[i+1:]...))
+		}
+	}
+	return nil, fmt.Errorf("tag '%s' not found", name)
+}
+
+// save writes the tags through a temporary file so readers never see a partial list
+func (tm *TagManager) save(tags []*Tag) error {
+	sortTags(tags)
+	if tags == nil {
+		tags = []*Tag{}
+	}
+	data, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := tm.TagsFile + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tags: %w", err)
+	}
+	if err := os.Rename(temp, tm.TagsFile); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to write tags: %w", err)
+	}
+	return nil
+}
+
+// sortTags orders tags by version, then name
+func sortTags(tags []*Tag) {
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Version != tags[j].Version {
+			return tags[i].Version < tags[j].Version
+		}
+		return tags[i].Name < tags[j].Name
+	})
+}
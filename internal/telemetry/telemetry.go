@@ -0,0 +1,386 @@
+package telemetry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	initializer "dgit/internal/init"
+)
+
+// Standard OpenTelemetry environment variables honoured in addition to repository config
+const (
+	EnvEndpoint    = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvServiceName = "OTEL_SERVICE_NAME"
+	EnvDisabled    = "OTEL_SDK_DISABLED"
+)
+
+// Span represents a single timed phase of a DGit operation (scan, compress, delta, io)
+type Span struct {
+	TraceID    string                 `json:"trace_id"`
+	SpanID     string                 `json:"span_id"`
+	ParentID   string                 `json:"parent_span_id,omitempty"`
+	Name       string                 `json:"name"`
+	StartTime  time.Time              `json:"start"`
+	EndTime    time.Time              `json:"end"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+
+	tracer *Tracer
+}
+
+// Metric represents a single gauge data point recorded during an operation
+type Metric struct {
+	Name       string                 `json:"name"`
+	Unit       string                 `json:"unit"`
+	Value      float64                `json:"value"`
+	Time       time.Time              `json:"time"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Tracer collects spans and metrics for one operation and exports them over OTLP/HTTP
+// A disabled tracer is safe to use; all calls become no-ops
+type Tracer struct {
+	enabled     bool
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	timeout     time.Duration
+	localFile   string // Fallback JSONL sink when no collector endpoint is configured
+
+	mu      sync.Mutex
+	spans   []*Span
+	metrics []Metric
+}
+
+// NewTracer creates a tracer from repository telemetry config and OTEL_* environment variables
+func NewTracer(dgitDir string) *Tracer {
+	t := &Tracer{
+		serviceName: "dgit",
+		headers:     make(map[string]string),
+		timeout:     2 * time.Second,
+		localFile:   filepath.Join(dgitDir, "metrics", "traces.jsonl"),
+	}
+
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		t.enabled = config.Telemetry.Enabled
+		t.endpoint = config.Telemetry.Endpoint
+		if config.Telemetry.ServiceName != "" {
+			t.serviceName = config.Telemetry.ServiceName
+		}
+		for k, v := range config.Telemetry.Headers {
+			t.headers[k] = v
+		}
+		if config.Telemetry.TimeoutMs > 0 {
+			t.timeout = time.Duration(config.Telemetry.TimeoutMs) * time.Millisecond
+		}
+	}
+
+	// Environment overrides config so daemons/servers can be pointed at a collector without editing repos
+	if endpoint := os.Getenv(EnvEndpoint); endpoint != "" {
+		t.enabled = true
+		t.endpoint = endpoint
+	}
+	if name := os.Getenv(EnvServiceName); name != "" {
+		t.serviceName = name
+	}
+	if disabled, _ := strconv.ParseBool(os.Getenv(EnvDisabled)); disabled {
+		t.enabled = false
+	}
+
+	return t
+}
+
+// Enabled reports whether spans are being recorded
+func (t *Tracer) Enabled() bool {
+	return t != nil && t.enabled
+}
+
+// StartSpan begins a new span; pass nil parent for a root span, which starts a new trace
+func (t *Tracer) StartSpan(name string, parent *Span) *Span {
+	if !t.Enabled() {
+		return nil
+	}
+
+	span := &Span{
+		TraceID:    randomHex(16),
+		SpanID:     randomHex(8),
+		Name:       name,
+		StartTime:  time.Now(),
+		Attributes: make(map[string]interface{}),
+		tracer:     t,
+	}
+	if parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	}
+	return span
+}
+
+// SetAttribute attaches a key/value pair to the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.Attributes[key] = value
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.Error = err.Error()
+}
+
+// End finishes the span and records its duration as a phase metric
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.EndTime = time.Now()
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s)
+	s.tracer.mu.Unlock()
+
+	s.tracer.RecordMetric("dgit.phase.duration", "ms",
+		float64(s.EndTime.Sub(s.StartTime).Nanoseconds())/1000000.0,
+		map[string]interface{}{"phase": s.Name})
+}
+
+// RecordMetric records a gauge data point (bytes processed, ratios, durations)
+func (t *Tracer) RecordMetric(name, unit string, value float64, attributes map[string]interface{}) {
+	if !t.Enabled() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = append(t.metrics, Metric{
+		Name:       name,
+		Unit:       unit,
+		Value:      value,
+		Time:       time.Now(),
+		Attributes: attributes,
+	})
+}
+
+// Flush exports all recorded spans and metrics, then resets the tracer
+// Export failures are returned but never affect the traced operation
+func (t *Tracer) Flush() error {
+	if !t.Enabled() {
+		return nil
+	}
+
+	t.mu.Lock()
+	spans, metrics := t.spans, t.metrics
+	t.spans, t.metrics = nil, nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 && len(metrics) == 0 {
+		return nil
+	}
+
+	if t.endpoint == "" {
+		return t.writeLocal(spans, metrics)
+	}
+
+	if err := t.post("/v1/traces", t.encodeTraces(spans)); err != nil {
+		return fmt.Errorf("trace export failed: %w", err)
+	}
+	if err := t.post("/v1/metrics", t.encodeMetrics(metrics)); err != nil {
+		return fmt.Errorf("metrics export failed: %w", err)
+	}
+	return nil
+}
+
+// post sends an OTLP/HTTP JSON payload to the collector
+func (t *Tracer) post(path string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(t.endpoint, "/") + path
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: t.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// writeLocal appends spans and metrics to .dgit/metrics/traces.jsonl
+func (t *Tracer) writeLocal(spans []*Span, metrics []Metric) error {
+	if err := os.MkdirAll(filepath.Dir(t.localFile), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(t.localFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, span := range spans {
+		if err := encoder.Encode(map[string]interface{}{"type": "span", "span": span}); err != nil {
+			return err
+		}
+	}
+	for _, metric := range metrics {
+		if err := encoder.Encode(map[string]interface{}{"type": "metric", "metric": metric}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OTLP JSON encoding
+
+// encodeTraces builds an ExportTraceServiceRequest in OTLP JSON form
+func (t *Tracer) encodeTraces(spans []*Span) map[string]interface{} {
+	otlpSpans := make([]map[string]interface{}, 0, len(spans))
+	for _, span := range spans {
+		otlpSpan := map[string]interface{}{
+			"traceId":           span.TraceID,
+			"spanId":            span.SpanID,
+			"name":              span.Name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			"attributes":        encodeAttributes(span.Attributes),
+		}
+		if span.ParentID != "" {
+			otlpSpan["parentSpanId"] = span.ParentID
+		}
+		if span.Error != "" {
+			otlpSpan["status"] = map[string]interface{}{"code": 2, "message": span.Error}
+		}
+		otlpSpans = append(otlpSpans, otlpSpan)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": t.encodeResource(),
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": "dgit"},
+						"spans": otlpSpans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// encodeMetrics builds an ExportMetricsServiceRequest in OTLP JSON form, one gauge per metric name
+func (t *Tracer) encodeMetrics(metrics []Metric) map[string]interface{} {
+	order := []string{}
+	byName := make(map[string][]Metric)
+	for _, m := range metrics {
+		if _, exists := byName[m.Name]; !exists {
+			order = append(order, m.Name)
+		}
+		byName[m.Name] = append(byName[m.Name], m)
+	}
+
+	otlpMetrics := make([]map[string]interface{}, 0, len(order))
+	for _, name := range order {
+		points := make([]map[string]interface{}, 0, len(byName[name]))
+		for _, m := range byName[name] {
+			points = append(points, map[string]interface{}{
+				"asDouble":     m.Value,
+				"timeUnixNano": strconv.FormatInt(m.Time.UnixNano(), 10),
+				"attributes":   encodeAttributes(m.Attributes),
+			})
+		}
+		otlpMetrics = append(otlpMetrics, map[string]interface{}{
+			"name":  name,
+			"unit":  byName[name][0].Unit,
+			"gauge": map[string]interface{}{"dataPoints": points},
+		})
+	}
+
+	return map[string]interface{}{
+		"resourceMetrics": []interface{}{
+			map[string]interface{}{
+				"resource": t.encodeResource(),
+				"scopeMetrics": []interface{}{
+					map[string]interface{}{
+						"scope":   map[string]interface{}{"name": "dgit"},
+						"metrics": otlpMetrics,
+					},
+				},
+			},
+		},
+	}
+}
+
+// encodeResource describes the reporting process
+func (t *Tracer) encodeResource() map[string]interface{} {
+	hostname, _ := os.Hostname()
+	return map[string]interface{}{
+		"attributes": encodeAttributes(map[string]interface{}{
+			"service.name": t.serviceName,
+			"host.name":    hostname,
+		}),
+	}
+}
+
+// encodeAttributes converts a plain map to OTLP KeyValue list
+func encodeAttributes(attributes map[string]interface{}) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(attributes))
+	for key, value := range attributes {
+		var anyValue map[string]interface{}
+		switch v := value.(type) {
+		case string:
+			anyValue = map[string]interface{}{"stringValue": v}
+		case bool:
+			anyValue = map[string]interface{}{"boolValue": v}
+		case int:
+			anyValue = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			anyValue = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			anyValue = map[string]interface{}{"doubleValue": v}
+		default:
+			anyValue = map[string]interface{}{"stringValue": fmt.Sprintf("%v", v)}
+		}
+		result = append(result, map[string]interface{}{"key": key, "value": anyValue})
+	}
+	return result
+}
+
+// randomHex returns n random bytes hex-encoded (trace IDs are 16 bytes, span IDs 8)
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return strings.Repeat("0", n*2)
+	}
+	return hex.EncodeToString(buf)
+}
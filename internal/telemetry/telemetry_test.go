@@ -0,0 +1,18 @@
+package telemetry
+
+import "testing"
+
+func TestRootSpansStartNewTraces(t *testing.T) {
+	tracer := &Tracer{enabled: true}
+
+	first := tracer.StartSpan("dgit.commit", nil)
+	child := tracer.StartSpan("dgit.commit.compress", first)
+	second := tracer.StartSpan("dgit.commit", nil)
+
+	if first.TraceID == second.TraceID {
+		t.Fatalf("two commits share trace %s", first.TraceID)
+	}
+	if child.TraceID != first.TraceID || child.ParentID != first.SpanID {
+		t.Fatalf("child span in trace %s under %s, want trace %s under %s", child.TraceID, child.ParentID, first.TraceID, first.SpanID)
+	}
+}
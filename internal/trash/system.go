@@ -0,0 +1,43 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrSystemTrashUnsupported is returned where DGit cannot reach the OS trash/recycle bin
+var ErrSystemTrashUnsupported = errors.New("moving files to the system trash is not supported on this platform")
+
+// MoveToSystemTrash moves a file into the operating system's trash so it can be
+// recovered from Finder or the desktop file manager
+func MoveToSystemTrash(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return moveToSystemTrash(absPath)
+}
+
+// uniqueTrashName picks a name not yet used in dir, like Finder's "name 2.psd"
+func uniqueTrashName(dir, name string) string {
+	if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	stamp := time.Now().Format("15-04-05")
+	for i := 0; ; i++ {
+		candidate := fmt.Sprintf("%s %s%s", stem, stamp, ext)
+		if i > 0 {
+			candidate = fmt.Sprintf("%s %s-%d%s", stem, stamp, i, ext)
+		}
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// moveToSystemTrash moves the file into ~/.Trash
+// Files on other volumes cannot be renamed there; the caller falls back to the DGit trash
+func moveToSystemTrash(path string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	trashDir := filepath.Join(home, ".Trash")
+	if err := os.MkdirAll(trashDir, 0700); err != nil {
+		return err
+	}
+
+	target := filepath.Join(trashDir, uniqueTrashName(trashDir, filepath.Base(path)))
+	if err := os.Rename(path, target); err != nil {
+		return fmt.Errorf("failed to move %s to the Trash: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package trash
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// moveToSystemTrash follows the freedesktop.org trash specification used by GNOME/KDE:
+// the file goes to $XDG_DATA_HOME/Trash/files with a .trashinfo record of where it came from
+func moveToSystemTrash(path string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	filesDir := filepath.Join(dataHome, "Trash", "files")
+	infoDir := filepath.Join(dataHome, "Trash", "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return err
+	}
+
+	name := uniqueTrashName(filesDir, filepath.Base(path))
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	infoPath := filepath.Join(infoDir, name+".trashinfo")
+	if err := os.WriteFile(infoPath, []byte(info), 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(path, filepath.Join(filesDir, name)); err != nil {
+		os.Remove(infoPath)
+		return fmt.Errorf("failed to move %s to the trash: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package trash
+
+// moveToSystemTrash is not available; the Windows Recycle Bin needs shell APIs DGit does not use
+func moveToSystemTrash(path string) error {
+	return ErrSystemTrashUnsupported
+}
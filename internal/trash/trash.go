@@ -0,0 +1,204 @@
+package trash
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EntryFile records one working tree file saved into the trash
+type EntryFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash"`
+}
+
+// Entry is a set of working tree files saved before a destructive operation
+type Entry struct {
+	ID        string      `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Reason    string      `json:"reason"`
+	Files     []EntryFile `json:"files"`
+}
+
+// TrashManager stores backups of working tree files in .dgit/trash/<id>/
+type TrashManager struct {
+	DgitDir  string
+	TrashDir string
+}
+
+// NewTrashManager creates a new trash manager
+func NewTrashManager(dgitDir string) *TrashManager {
+	return &TrashManager{
+		DgitDir:  dgitDir,
+		TrashDir: filepath.Join(dgitDir, "trash"),
+	}
+}
+
+// Save copies working tree files (relative to workDir) into a new trash entry
+func (tm *TrashManager) Save(workDir string, paths []string, reason string) (*Entry, error) {
+	entry := &Entry{
+		ID:        time.Now().Format("20060102-150405.000"),
+		CreatedAt: time.Now(),
+		Reason:    reason,
+	}
+	entry.ID = strings.ReplaceAll(entry.ID, ".", "-")
+	entryDir := filepath.Join(tm.TrashDir, entry.ID)
+
+	for _, path := range paths {
+		source := filepath.Join(workDir, path)
+		target := filepath.Join(entryDir, "files", path)
+
+		size, hash, err := copyWithHash(source, target)
+		if err != nil {
+			os.RemoveAll(entryDir)
+			return nil, fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		entry.Files = append(entry.Files, EntryFile{Path: path, Size: size, Hash: hash})
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		os.RemoveAll(entryDir)
+		return nil, fmt.Errorf("failed to marshal trash manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(entryDir, "manifest.json"), data, 0644); err != nil {
+		os.RemoveAll(entryDir)
+		return nil, fmt.Errorf("failed to write trash manifest: %w", err)
+	}
+
+	return entry, nil
+}
+
+// List returns all trash entries, newest first
+func (tm *TrashManager) List() ([]*Entry, error) {
+	dirs, err := os.ReadDir(tm.TrashDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	var entries []*Entry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry, err := tm.load(dir.Name())
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// Get returns the entry with the given ID or unique ID prefix
+func (tm *TrashManager) Get(id string) (*Entry, error) {
+	entries, err := tm.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Entry
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+		if strings.HasPrefix(entry.ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf("trash ID '%s' is ambiguous", id)
+			}
+			match = entry
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("trash entry '%s' not found", id)
+	}
+	return match, nil
+}
+
+// Recover copies an entry's files back into workDir, overwriting current versions
+func (tm *TrashManager) Recover(id, workDir string) ([]string, error) {
+	entry, err := tm.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var recovered []string
+	for _, file := range entry.Files {
+		source := filepath.Join(tm.TrashDir, entry.ID, "files", file.Path)
+		if _, _, err := copyWithHash(source, filepath.Join(workDir, file.Path)); err != nil {
+			return recovered, fmt.Errorf("failed to recover %s: %w", file.Path, err)
+		}
+		recovered = append(recovered, file.Path)
+	}
+	return recovered, nil
+}
+
+// Drop permanently deletes a trash entry
+func (tm *TrashManager) Drop(id string) error {
+	entry, err := tm.Get(id)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(tm.TrashDir, entry.ID))
+}
+
+// load reads an entry manifest
+func (tm *TrashManager) load(id string) (*Entry, error) {
+	data, err := os.ReadFile(filepath.Join(tm.TrashDir, id, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// copyWithHash copies a file, preserving its modification time, and returns size and SHA256
+func copyWithHash(source, target string) (int64, string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return 0, "", err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return 0, "", err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, "", err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return 0, "", err
+	}
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hasher), in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	os.Chtimes(target, info.ModTime(), info.ModTime())
+	return size, fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
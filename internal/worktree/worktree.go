@@ -0,0 +1,301 @@
+package worktree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dgit/internal/log"
+	"dgit/internal/progress"
+	"dgit/internal/restore"
+	"dgit/internal/status"
+	"dgit/internal/tag"
+)
+
+// A worktree is an extra directory holding a checked-out version, restored from the
+// repository's object store (nothing under .dgit is copied). Worktrees are registered in
+// .dgit/worktrees/<name>.json and marked by a .dgit-worktree file in their root.
+
+// MarkerFile identifies a directory as a worktree of some repository
+const MarkerFile = ".dgit-worktree"
+
+// File is a checked-out file and its content hash at checkout time
+type File struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// Worktree is one registered checkout
+type Worktree struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []File    `json:"files"`
+}
+
+// Missing reports whether the worktree directory has been deleted or moved
+func (w *Worktree) Missing() bool {
+	_, err := os.Stat(w.Path)
+	return err != nil
+}
+
+// WorktreeManager creates and tracks worktrees of one repository
+type WorktreeManager struct {
+	DgitDir      string
+	WorktreesDir string
+
+	// Progress receives the checkout's restore events and silences its stdout output
+	Progress progress.Reporter
+}
+
+// NewWorktreeManager creates a new worktree manager
+func NewWorktreeManager(dgitDir string) *WorktreeManager {
+	return &WorktreeManager{
+		DgitDir:      dgitDir,
+		WorktreesDir: filepath.Join(dgitDir, "worktrees"),
+	}
+}
+
+// Add checks out the version named by ref ("12", "v12" or a hash prefix) into path,
+// which must not exist or be an empty directory
+func (wm *WorktreeManager) Add(path, ref string) (*Worktree, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	created, err := checkTarget(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := wm.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	name := wm.uniqueName(filepath.Base(absPath))
+	if err := os.MkdirAll(absPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	cleanup := func() {
+		if created {
+			os.RemoveAll(absPath)
+		}
+	}
+
+	restoreManager := restore.NewRestoreManager(wm.DgitDir)
+	restoreManager.WorkDir = absPath
+	restoreManager.Progress = wm.Progress
+	result, err := restoreManager.Restore(fmt.Sprintf("v%d", commit.Version), nil)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("checkout of v%d failed: %w", commit.Version, err)
+	}
+
+	worktree := &Worktree{
+		Name:      name,
+		Path:      absPath,
+		Version:   commit.Version,
+		Hash:      commit.Hash,
+		CreatedAt: time.Now(),
+	}
+	for _, file := range result.RestoredFiles {
+		hash, err := status.CalculateFileHash(filepath.Join(absPath, file))
+		if err != nil {
+			continue
+		}
+		worktree.Files = append(worktree.Files, File{Path: file, Hash: hash})
+	}
+
+	if err := wm.save(worktree); err != nil {
+		cleanup()
+		return nil, err
+	}
+	return worktree, nil
+}
+
+// List returns all registered worktrees sorted by name
+func (wm *WorktreeManager) List() ([]*Worktree, error) {
+	entries, err := os.ReadDir(wm.WorktreesDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var worktrees []*Worktree
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(wm.WorktreesDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var worktree Worktree
+		if json.Unmarshal(data, &worktree) == nil {
+			worktrees = append(worktrees, &worktree)
+		}
+	}
+
+	sort.Slice(worktrees, func(i, j int) bool { return worktrees[i].Name < worktrees[j].Name })
+	return worktrees, nil
+}
+
+// Find returns the worktree registered under a name or path
+func (wm *WorktreeManager) Find(nameOrPath string) (*Worktree, error) {
+	worktrees, err := wm.List()
+	if err != nil {
+		return nil, err
+	}
+
+	absPath, _ := filepath.Abs(nameOrPath)
+	for _, worktree := range worktrees {
+		if worktree.Name == nameOrPath || worktree.Path == absPath {
+			return worktree, nil
+		}
+	}
+	return nil, fmt.Errorf("no worktree named '%s'", nameOrPath)
+}
+
+// Modified lists checked-out files that were edited or deleted since checkout
+func (wm *WorktreeManager) Modified(worktree *Worktree) []string {
+	var modified []string
+	for _, file := range worktree.Files {
+		hash, err := status.CalculateFileHash(filepath.Join(worktree.Path, file.Path))
+		if err != nil || hash != file.Hash {
+			modified = append(modified, file.Path)
+		}
+	}
+	return modified
+}
+
+// Remove deletes the worktree directory and its registration
+// Without force, edited files are never thrown away
+func (wm *WorktreeManager) Remove(worktree *Worktree, force bool) error {
+	if !worktree.Missing() {
+		if _, err := os.Stat(filepath.Join(worktree.Path, MarkerFile)); err != nil {
+			return fmt.Errorf("%s is not a dgit worktree (missing %s); refusing to delete it", worktree.Path, MarkerFile)
+		}
+		if modified := wm.Modified(worktree); len(modified) > 0 && !force {
+			return fmt.Errorf("worktree '%s' has %d modified file(s): %s", worktree.Name, len(modified), strings.Join(modified, ", "))
+		}
+		if err := os.RemoveAll(worktree.Path); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", worktree.Path, err)
+		}
+	}
+	return os.Remove(wm.registryPath(worktree.Name))
+}
+
+// Prune drops registrations whose directories no longer exist
+func (wm *WorktreeManager) Prune() ([]*Worktree, error) {
+	worktrees, err := wm.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []*Worktree
+	for _, worktree := range worktrees {
+		if worktree.Missing() {
+			if err := os.Remove(wm.registryPath(worktree.Name)); err != nil {
+				return pruned, err
+			}
+			pruned = append(pruned, worktree)
+		}
+	}
+	return pruned, nil
+}
+
+// resolve turns a version number, tag or hash prefix into a commit
+func (wm *WorktreeManager) resolve(ref string) (*log.Commit, error) {
+	logManager := log.NewLogManager(wm.DgitDir)
+	if version, err := strconv.Atoi(strings.TrimPrefix(ref, "v")); err == nil {
+		commit, err := logManager.GetCommit(version)
+		if err != nil {
+			return nil, fmt.Errorf("version v%d not found", version)
+		}
+		return commit, nil
+	}
+	if commit, err := tag.NewTagManager(wm.DgitDir).Resolve(ref); commit != nil || err != nil {
+		return commit, err
+	}
+
+	commit, err := logManager.GetCommitByHash(ref)
+	var ambiguous *log.AmbiguousHashError
+	if errors.As(err, &ambiguous) {
+		return nil, err
+	}
+	if err != nil || commit == nil {
+		return nil, fmt.Errorf("commit '%s' not found", ref)
+	}
+	return commit, nil
+}
+
+// save writes the registry entry and the marker file
+func (wm *WorktreeManager) save(worktree *Worktree) error {
+	if err := os.MkdirAll(wm.WorktreesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create worktree registry: %w", err)
+	}
+
+	data, err := json.MarshalIndent(worktree, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(wm.registryPath(worktree.Name), data, 0644); err != nil {
+		return fmt.Errorf("failed to register worktree: %w", err)
+	}
+
+	marker := fmt.Sprintf("repository: %s\nversion: v%d\nhash: %s\n", filepath.Dir(wm.DgitDir), worktree.Version, worktree.Hash)
+	if err := os.WriteFile(filepath.Join(worktree.Path, MarkerFile), []byte(marker), 0644); err != nil {
+		os.Remove(wm.registryPath(worktree.Name))
+		return fmt.Errorf("failed to write worktree marker: %w", err)
+	}
+	return nil
+}
+
+// uniqueName picks a registry name based on the directory name
+func (wm *WorktreeManager) uniqueName(base string) string {
+	name := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(wm.registryPath(name)); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (wm *WorktreeManager) registryPath(name string) string {
+	return filepath.Join(wm.WorktreesDir, name+".json")
+}
+
+// checkTarget accepts a path that does not exist yet or is an empty directory,
+// reporting whether the directory still has to be created
+func checkTarget(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return false, fmt.Errorf("%s already exists and is not a directory", path)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+	if len(entries) > 0 {
+		return false, fmt.Errorf("%s already exists and is not empty", path)
+	}
+	return false, nil
+}
@@ -0,0 +1,115 @@
+// Package xattr captures and restores extended attributes (Finder tags, labels, user
+// metadata) with committed files when the repository's "attributes" config enables it.
+//
+// Platform support:
+//
+//	macOS    all attributes via the xattr tool (Finder tags: com.apple.metadata:_kMDItemUserTags,
+//	         label colors: com.apple.FinderInfo)
+//	Linux    user.* attributes via getxattr/setxattr; other namespaces need privileges
+//	Windows  not supported (capture records nothing, restore skips)
+//
+// Attributes are restored best-effort: names the target platform or filesystem rejects
+// (for example com.apple.* on Linux) are skipped and counted, never fatal.
+package xattr
+
+import (
+	"sort"
+	"strings"
+
+	initializer "dgit/internal/init"
+)
+
+// DefaultExclude lists machine-specific attributes that must not travel between machines
+var DefaultExclude = []string{
+	"com.apple.quarantine",
+	"com.apple.provenance",
+	"com.apple.lastuseddate#PS",
+	"com.apple.macl",
+	"security.",
+	"system.",
+	"trusted.",
+}
+
+// Policy says whether attributes are versioned and which are left out
+type Policy struct {
+	Enabled bool
+	Exclude []string // Attribute names, or prefixes ending in "."
+}
+
+// LoadPolicy reads the attribute policy from repository config (disabled by default)
+func LoadPolicy(dgitDir string) Policy {
+	policy := Policy{Exclude: DefaultExclude}
+	if config, err := initializer.GetConfig(dgitDir); err == nil {
+		policy.Enabled = config.Attributes.Enabled
+		if config.Attributes.Exclude != nil {
+			policy.Exclude = config.Attributes.Exclude
+		}
+	}
+	return policy
+}
+
+// Supported reports whether this platform can read and write extended attributes
+func Supported() bool {
+	return supported
+}
+
+// Capture returns the file's attributes allowed by the policy; nil when there are none
+func (p Policy) Capture(path string) (map[string][]byte, error) {
+	if !p.Enabled || !supported {
+		return nil, nil
+	}
+
+	names, err := list(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var attrs map[string][]byte
+	for _, name := range names {
+		if p.excluded(name) {
+			continue
+		}
+		value, err := get(path, name)
+		if err != nil {
+			continue // Attribute vanished or is unreadable; not worth failing a commit
+		}
+		if attrs == nil {
+			attrs = make(map[string][]byte)
+		}
+		attrs[name] = value
+	}
+	return attrs, nil
+}
+
+// Apply writes attributes onto a restored file, returning how many were applied and skipped
+func (p Policy) Apply(path string, attrs map[string][]byte) (int, int) {
+	if !p.Enabled || !supported {
+		return 0, len(attrs)
+	}
+
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	applied, skipped := 0, 0
+	for _, name := range names {
+		if p.excluded(name) || set(path, name, attrs[name]) != nil {
+			skipped++
+			continue
+		}
+		applied++
+	}
+	return applied, skipped
+}
+
+// excluded reports whether an attribute name matches the exclude list
+func (p Policy) excluded(name string) bool {
+	for _, pattern := range p.Exclude {
+		if name == pattern || (strings.HasSuffix(pattern, ".") && strings.HasPrefix(name, pattern)) {
+			return true
+		}
+	}
+	return false
+}
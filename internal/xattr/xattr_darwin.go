@@ -0,0 +1,41 @@
+package xattr
+
+import (
+	"encoding/hex"
+	"os/exec"
+	"strings"
+)
+
+// The standard library has no xattr calls on macOS, so the system xattr tool is used
+
+const supported = true
+
+// list returns the attribute names of a file
+func list(path string) ([]string, error) {
+	output, err := exec.Command("xattr", path).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// get reads one attribute value; -px prints it as hex so binary plists survive
+func get(path, name string) ([]byte, error) {
+	output, err := exec.Command("xattr", "-px", name, path).Output()
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.Join(strings.Fields(string(output)), ""))
+}
+
+// set writes one attribute value, replacing any existing one
+func set(path, name string, value []byte) error {
+	return exec.Command("xattr", "-wx", name, hex.EncodeToString(value), path).Run()
+}
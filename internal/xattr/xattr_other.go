@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+
+package xattr
+
+import "errors"
+
+const supported = false
+
+var errUnsupported = errors.New("extended attributes are not supported on this platform")
+
+func list(path string) ([]string, error) {
+	return nil, errUnsupported
+}
+
+func get(path, name string) ([]byte, error) {
+	return nil, errUnsupported
+}
+
+func set(path, name string, value []byte) error {
+	return errUnsupported
+}
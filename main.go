@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"dgit/cmd"
@@ -21,7 +20,16 @@ Figma, and other design file formats with metadata-aware versioning.
 - Design file format support (AI, PSD, Sketch, Figma, XD)
 - Visual diff for design changes with layer/artboard tracking
 - Team collaboration optimized for creative workflows
-- Git-like interface with design-specific enhancements`,
+- Git-like interface with design-specific enhancements
+
+Automation: pass --ci or set DGIT_CI=1 to disable prompts, colors, emoji and
+progress output, print JSON, and exit with specific codes.
+
+` + cmd.ExitCodesHelp,
+	PersistentPreRun: func(c *cobra.Command, _ []string) {
+		ci, _ := c.Flags().GetBool("ci")
+		cmd.SetupCI(c, ci)
+	},
 }
 
 func init() {
@@ -33,10 +41,51 @@ func init() {
 	rootCmd.AddCommand(cmd.RestoreCmd)
 	rootCmd.AddCommand(cmd.ScanCmd)
 	rootCmd.AddCommand(cmd.ShowCmd) // 새로 추가
+	rootCmd.AddCommand(cmd.BlameSizeCmd)
+	rootCmd.AddCommand(cmd.StatsCmd)
+	rootCmd.AddCommand(cmd.RelocateCmd)
+	rootCmd.AddCommand(cmd.MaintenanceCmd)
+	rootCmd.AddCommand(cmd.TrashCmd)
+	rootCmd.AddCommand(cmd.QueueCmd)
+	rootCmd.AddCommand(cmd.ExportCmd)
+	rootCmd.AddCommand(cmd.FeedCmd)
+	rootCmd.AddCommand(cmd.WorktreeCmd)
+	rootCmd.AddCommand(cmd.RmCmd)
+	rootCmd.AddCommand(cmd.MvCmd)
+	rootCmd.AddCommand(cmd.NewCmd)
+	rootCmd.AddCommand(cmd.DerivedCmd)
+	rootCmd.AddCommand(cmd.ReviewCmd)
+	rootCmd.AddCommand(cmd.PreviewsCmd)
+	rootCmd.AddCommand(cmd.ReportCmd)
+	rootCmd.AddCommand(cmd.PinCmd)
+	rootCmd.AddCommand(cmd.MaterializeCmd)
+	rootCmd.AddCommand(cmd.MirrorCmd)
+	rootCmd.AddCommand(cmd.VerifyHistoryCmd)
+	rootCmd.AddCommand(cmd.ConfigCmd)
+	rootCmd.AddCommand(cmd.DaemonCmd)
+	rootCmd.AddCommand(cmd.ReplicaCmd)
+	rootCmd.AddCommand(cmd.PluginCmd)
+	rootCmd.AddCommand(cmd.HideCmd)
+	rootCmd.AddCommand(cmd.UnhideCmd)
+	rootCmd.AddCommand(cmd.HeadCmd)
+	rootCmd.AddCommand(cmd.DoctorCmd)
+	rootCmd.AddCommand(cmd.TagCmd)
+	rootCmd.AddCommand(cmd.BatchCmd)
+	rootCmd.AddCommand(cmd.PrefetchCmd)
+	rootCmd.AddCommand(cmd.MountCmd)
+
+	rootCmd.PersistentFlags().Bool("ci", false, "Non-interactive machine output for automation (also DGIT_CI=1)")
 }
 func main() {
+	// Unknown commands run dgit-<name> from PATH when one exists
+	if cmd.RunPlugin(rootCmd, os.Args[1:]) {
+		return
+	}
+
+	// In CI mode errors are reported once, as JSON, by FailUsage
+	rootCmd.SilenceErrors = cmd.CIRequested()
+	rootCmd.SilenceUsage = rootCmd.SilenceErrors
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Printf("❌ Error: %v\n", err)
-		os.Exit(1)
+		cmd.FailUsage(err)
 	}
 }
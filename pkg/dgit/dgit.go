@@ -0,0 +1,375 @@
+// Package dgit is the embeddable API for DGit repositories.
+//
+// Long-running operations return a *Job: typed Progress events arrive in order on
+// Events(), which is closed when the operation ends (after a final PhaseDone event
+// on success), and Wait returns the typed result. Nothing is printed to stdout in library mode.
+//
+// Relative paths are resolved against Repository.WorkDir, the work tree Open found,
+// whatever the process working directory is. Operations that change the repository
+// take the same repository lock as the CLI, so they wait for a running dgit command.
+package dgit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dgit/internal/commit"
+	initializer "dgit/internal/init"
+	"dgit/internal/log"
+	"dgit/internal/policy"
+	"dgit/internal/progress"
+	"dgit/internal/repolock"
+	"dgit/internal/restore"
+	"dgit/internal/staging"
+	"dgit/internal/tag"
+)
+
+// Progress is a typed progress event (operation, phase, bytes, percent)
+type Progress = progress.Event
+
+// Phases reported in Progress.Phase
+const (
+	PhaseStage    = progress.PhaseStage
+	PhaseScan     = progress.PhaseScan
+	PhaseCompress = progress.PhaseCompress
+	PhaseStore    = progress.PhaseStore
+	PhaseAnalyze  = progress.PhaseAnalyze
+	PhaseBackup   = progress.PhaseBackup
+	PhaseRestore  = progress.PhaseRestore
+	PhaseDone     = progress.PhaseDone
+)
+
+// OverwriteError is returned by Restore when local changes would be lost without Force
+type OverwriteError = restore.OverwriteError
+
+// UnchangedError is returned by Commit when every staged file matches the previous version
+type UnchangedError = commit.UnchangedError
+
+// PolicyError is returned by Commit when files fail blocking asset policies
+type PolicyError = policy.PolicyError
+
+// AddResult is the outcome of staging files
+type AddResult struct {
+	Added  []string
+	Failed map[string]error
+}
+
+// CommitResult is the outcome of a commit
+type CommitResult struct {
+	Hash          string
+	Version       int
+	Files         int
+	Strategy      string
+	OriginalBytes int64
+	StoredBytes   int64
+	Duration      time.Duration
+}
+
+// RestoreResult is the outcome of a restore
+type RestoreResult struct {
+	Version  int
+	Hash     string
+	Restored []string
+	Skipped  []string
+	Failed   map[string]error
+	Method   string
+	Bytes    int64
+	Duration time.Duration
+}
+
+// RestoreOptions controls a restore
+type RestoreOptions struct {
+	Files []string // Empty restores every file in the commit
+	Force bool     // Overwrite local changes after backing them up to the trash
+}
+
+// Job is a running operation with ordered progress events and a typed result
+type Job[T any] struct {
+	events chan Progress
+	done   chan struct{}
+	result T
+	err    error
+}
+
+// Events returns the progress channel; it is closed when the operation ends
+func (j *Job[T]) Events() <-chan Progress {
+	return j.events
+}
+
+// Done is closed when the operation has finished
+func (j *Job[T]) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the operation finishes and returns its result
+// Events not yet received are discarded, so either range over Events before
+// calling Wait from the same goroutine, or call Wait alone
+func (j *Job[T]) Wait() (T, error) {
+	<-j.done
+	for range j.events {
+	}
+	return j.result, j.err
+}
+
+// startJob runs op in the background, delivering its events in order without
+// ever blocking the operation on a slow consumer
+func startJob[T any](op func(report progress.Reporter) (T, error)) *Job[T] {
+	job := &Job[T]{
+		events: make(chan Progress),
+		done:   make(chan struct{}),
+	}
+
+	var mu sync.Mutex
+	var pending []Progress
+	wake := make(chan struct{}, 1)
+	finished := false
+
+	report := progress.Reporter(func(e progress.Event) {
+		mu.Lock()
+		pending = append(pending, e)
+		mu.Unlock()
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+
+	// Forwarder: drains the pending list into the unbuffered channel in order
+	go func() {
+		defer close(job.events)
+		for {
+			mu.Lock()
+			batch := pending
+			pending = nil
+			last := finished
+			mu.Unlock()
+
+			for _, e := range batch {
+				job.events <- e
+			}
+			if last && len(batch) == 0 {
+				return
+			}
+			if len(batch) == 0 {
+				<-wake
+			}
+		}
+	}()
+
+	go func() {
+		job.result, job.err = op(report)
+		close(job.done)
+
+		mu.Lock()
+		finished = true
+		mu.Unlock()
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}()
+
+	return job
+}
+
+// Repository is an open DGit repository
+type Repository struct {
+	DgitDir string
+	WorkDir string // Work tree that relative paths are resolved against
+
+	mu sync.Mutex // Operations on one repository run one at a time
+}
+
+// Open finds the repository containing path
+func Open(path string) (*Repository, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dgitDir := filepath.Join(dir, ".dgit")
+		if info, err := os.Stat(dgitDir); err == nil && info.IsDir() {
+			if err := initializer.CheckFormat(dgitDir); err != nil {
+				return nil, err
+			}
+			return &Repository{DgitDir: dgitDir, WorkDir: dir}, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("not a dgit repository: %s", path)
+		}
+		dir = parent
+	}
+}
+
+// Add stages files or glob patterns ("." stages every design file)
+func (r *Repository) Add(patterns ...string) *Job[*AddResult] {
+	return startJob(func(report progress.Reporter) (*AddResult, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		lock, err := repolock.Acquire(r.DgitDir, "add", nil)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Release()
+
+		stagingArea := r.stagingArea(report)
+		if err := stagingArea.LoadStaging(); err != nil {
+			return nil, err
+		}
+
+		result := &AddResult{Failed: make(map[string]error)}
+		for i, pattern := range patterns {
+			added, err := stagingArea.AddPattern(pattern)
+			if err != nil {
+				result.Failed[pattern] = err
+				continue
+			}
+			result.Added = append(result.Added, added.AddedFiles...)
+			for file, fileErr := range added.FailedFiles {
+				result.Failed[file] = fileErr
+			}
+			report.Emit(progress.Event{Operation: "add", Phase: progress.PhaseStage, File: pattern,
+				Current: i + 1, Total: len(patterns)})
+		}
+
+		if err := stagingArea.SaveStaging(); err != nil {
+			return result, err
+		}
+		report.Emit(progress.Event{Operation: "add", Phase: progress.PhaseDone, Current: len(result.Added), Total: len(result.Added)})
+		return result, nil
+	})
+}
+
+// Commit commits the staged files
+func (r *Repository) Commit(message string) *Job[*CommitResult] {
+	return startJob(func(report progress.Reporter) (*CommitResult, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		lock, err := repolock.Acquire(r.DgitDir, "commit", nil)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Release()
+
+		stagingArea := r.stagingArea(report)
+		if err := stagingArea.LoadStaging(); err != nil {
+			return nil, err
+		}
+		removed := stagingArea.GetRemovedFiles()
+		if len(removed) > 0 && len(stagingArea.GetStagedFiles()) == 0 {
+			tracked, err := log.NewLogManager(r.DgitDir).GetTrackedFiles()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := stagingArea.StageRemaining(tracked); err != nil {
+				return nil, err
+			}
+		}
+
+		stagedFiles := stagingArea.GetStagedFiles()
+		sort.Slice(stagedFiles, func(i, j int) bool { return stagedFiles[i].Path < stagedFiles[j].Path })
+
+		startTime := time.Now()
+		commitManager := commit.NewCommitManager(r.DgitDir)
+		commitManager.Progress = report
+		commitManager.Locked = true
+		commitManager.Removed = removed
+		commitManager.Renamed = stagingArea.GetRenamedFiles()
+		newCommit, err := commitManager.CreateCommit(message, stagedFiles)
+		if err != nil {
+			return nil, err
+		}
+		if err := stagingArea.ClearStaging(); err != nil {
+			return nil, fmt.Errorf("commit %s created but staging area was not cleared: %w", log.AbbreviateHash(newCommit.Hash, log.HashLength(r.DgitDir)), err)
+		}
+
+		result := &CommitResult{
+			Hash:     newCommit.Hash,
+			Version:  newCommit.Version,
+			Files:    newCommit.FilesCount,
+			Duration: time.Since(startTime),
+		}
+		if info := newCommit.CompressionInfo; info != nil {
+			result.Strategy = info.Strategy
+			result.OriginalBytes = info.OriginalSize
+			result.StoredBytes = info.CompressedSize
+		}
+		return result, nil
+	})
+}
+
+// Restore restores files from a version ("3", "v3"), tag or commit hash prefix
+func (r *Repository) Restore(ref string, options RestoreOptions) *Job[*RestoreResult] {
+	return startJob(func(report progress.Reporter) (*RestoreResult, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		version, err := r.resolveVersion(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		restoreManager := restore.NewRestoreManager(r.DgitDir)
+		restoreManager.WorkDir = r.WorkDir
+		restoreManager.Force = options.Force
+		restoreManager.Progress = report
+		restored, err := restoreManager.Restore(fmt.Sprintf("v%d", version), options.Files)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RestoreResult{
+			Version:  restored.SourceVersion,
+			Hash:     restored.SourceCommitHash,
+			Restored: restored.RestoredFiles,
+			Skipped:  restored.SkippedFiles,
+			Failed:   restored.ErrorFiles,
+			Method:   restored.RestoreMethod,
+			Bytes:    restored.DataTransferred,
+			Duration: restored.RestorationTime,
+		}, nil
+	})
+}
+
+// stagingArea opens the staging area with paths anchored at the work tree
+func (r *Repository) stagingArea(report progress.Reporter) *staging.StagingArea {
+	stagingArea := staging.NewStagingArea(r.DgitDir)
+	stagingArea.WorkDir = r.WorkDir
+	stagingArea.Progress = report
+	return stagingArea
+}
+
+// resolveVersion turns a version number, tag or hash prefix into a version
+func (r *Repository) resolveVersion(ref string) (int, error) {
+	if version, err := strconv.Atoi(strings.TrimPrefix(ref, "v")); err == nil {
+		return version, nil
+	}
+	if commit, err := tag.NewTagManager(r.DgitDir).Resolve(ref); commit != nil || err != nil {
+		if err != nil {
+			return 0, err
+		}
+		return commit.Version, nil
+	}
+
+	commit, err := log.NewLogManager(r.DgitDir).GetCommitByHash(ref)
+	var ambiguous *log.AmbiguousHashError
+	if errors.As(err, &ambiguous) {
+		return 0, err
+	}
+	if err != nil || commit == nil {
+		return 0, fmt.Errorf("commit '%s' not found", ref)
+	}
+	return commit.Version, nil
+}
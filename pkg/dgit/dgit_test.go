@@ -0,0 +1,198 @@
+package dgit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	initializer "dgit/internal/init"
+	"dgit/internal/staging"
+)
+
+// psdContent returns bytes that pass as a Photoshop document
+func psdContent(fill string) []byte {
+	return append([]byte("8BPS"), bytes.Repeat([]byte(fill), 5000)...)
+}
+
+// newRepository initializes a repository and moves the process into an unrelated
+// directory, so any path resolved against the working directory misses the repository
+func newRepository(t *testing.T, files map[string][]byte) *Repository {
+	t.Helper()
+	workDir := t.TempDir()
+	if err := initializer.NewRepositoryInitializer().InitializeRepository(workDir); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(workDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	repo, err := Open(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestOpenFindsRepositoryFromSubdirectory(t *testing.T) {
+	repo := newRepository(t, nil)
+	nested := filepath.Join(repo.WorkDir, "client", "round-3")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := Open(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.WorkDir != repo.WorkDir || opened.DgitDir != repo.DgitDir {
+		t.Fatalf("Open(%s) = %s, %s; want %s, %s", nested, opened.WorkDir, opened.DgitDir, repo.WorkDir, repo.DgitDir)
+	}
+
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Fatal("Open outside a repository succeeded")
+	}
+}
+
+func TestAddCommitRestoreUseWorkDir(t *testing.T) {
+	original := psdContent("layer")
+	repo := newRepository(t, map[string][]byte{"poster.psd": original})
+
+	added, err := repo.Add("poster.psd").Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added.Added) != 1 || added.Added[0] != "poster.psd" || len(added.Failed) != 0 {
+		t.Fatalf("Add = %+v, want poster.psd staged", added)
+	}
+
+	committed, err := repo.Commit("First round").Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed.Version != 1 || committed.Files != 1 {
+		t.Fatalf("Commit = version %d with %d files, want version 1 with 1 file", committed.Version, committed.Files)
+	}
+
+	target := filepath.Join(repo.WorkDir, "poster.psd")
+	if err := os.WriteFile(target, psdContent("edit"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var overwrite *OverwriteError
+	if _, err := repo.Restore("v1", RestoreOptions{}).Wait(); !errors.As(err, &overwrite) {
+		t.Fatalf("Restore over a local change: got %v, want OverwriteError", err)
+	}
+
+	restored, err := repo.Restore("v1", RestoreOptions{Force: true}).Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.Restored) != 1 {
+		t.Fatalf("Restore = %+v, want one restored file", restored)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, original) {
+		t.Fatal("poster.psd in the work tree does not match version 1")
+	}
+	if _, err := os.Stat("poster.psd"); !os.IsNotExist(err) {
+		t.Fatal("Restore wrote into the process working directory")
+	}
+}
+
+func TestCommitReportsMissingTrackedFile(t *testing.T) {
+	repo := newRepository(t, map[string][]byte{
+		"poster.psd": psdContent("poster"),
+		"banner.psd": psdContent("banner"),
+	})
+	if _, err := repo.Add(".").Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commit("First round").Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stage one deletion while the other tracked file disappears without one
+	stagingArea := staging.NewStagingArea(repo.DgitDir)
+	if err := stagingArea.LoadStaging(); err != nil {
+		t.Fatal(err)
+	}
+	stagingArea.StageRemoval("poster.psd")
+	if err := stagingArea.SaveStaging(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(repo.WorkDir, "banner.psd")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := repo.Commit("Drop the poster").Wait()
+	if err == nil || !strings.Contains(err.Error(), "banner.psd") {
+		t.Fatalf("Commit with a missing tracked file: got %v, want an error naming banner.psd", err)
+	}
+}
+
+func TestDeltaCommitRestoresEveryFile(t *testing.T) {
+	repo := newRepository(t, map[string][]byte{
+		"poster.psd": psdContent("poster"),
+		"banner.psd": psdContent("banner"),
+	})
+	if _, err := repo.Add(".").Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commit("First round").Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]byte{
+		"poster.psd": append(psdContent("poster"), "retouched"...),
+		"banner.psd": psdContent("banner"),
+		"flyer.psd":  psdContent("flyer"),
+	}
+	for name, data := range want {
+		if err := os.WriteFile(filepath.Join(repo.WorkDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.Add(".").Wait(); err != nil {
+		t.Fatal(err)
+	}
+	committed, err := repo.Commit("Second round").Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed.Strategy != "bsdiff" {
+		t.Fatalf("second commit stored as %q, want a bsdiff delta", committed.Strategy)
+	}
+
+	for name := range want {
+		os.Remove(filepath.Join(repo.WorkDir, name))
+	}
+	if _, err := repo.Restore("v2", RestoreOptions{Force: true}).Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range want {
+		got, err := os.ReadFile(filepath.Join(repo.WorkDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s in the work tree does not match version 2", name)
+		}
+	}
+}